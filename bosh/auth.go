@@ -0,0 +1,64 @@
+package bosh
+
+import (
+	"fmt"
+
+	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/ghodss/yaml"
+)
+
+// The secrets of the OIDC, LDAP and GitLab auth providers are read from the vars store, where they
+// are kept instead of the config
+const (
+	OIDCClientSecretVar   = "oidc_client_secret"
+	LDAPBindPasswordVar   = "ldap_bind_password"
+	GitLabClientSecretVar = "gitlab_client_secret"
+)
+
+// authFlags returns the ops files of the OIDC, LDAP and GitLab auth providers that are configured,
+// along with a vars file of their settings
+func authFlags(workingdir workingdir.IClient, c config.ConfigView) ([]string, error) {
+	vars := map[string]interface{}{}
+	var flags []string
+
+	if oidc := c.GetOIDCAuth(); oidc != nil {
+		vars["oidc_issuer"] = oidc.Issuer
+		vars["oidc_client_id"] = oidc.ClientID
+		vars["oidc_scopes"] = oidc.Scopes
+		vars["oidc_groups_claim"] = oidc.GroupsClaim
+		flags = append(flags, "--ops-file", workingdir.PathInWorkingDir(concourseOIDCAuthFilename))
+	}
+
+	if ldap := c.GetLDAPAuth(); ldap != nil {
+		vars["ldap_host"] = ldap.Host
+		vars["ldap_bind_dn"] = ldap.BindDN
+		vars["ldap_user_search_base_dn"] = ldap.UserSearchBaseDN
+		vars["ldap_user_search_filter"] = ldap.UserSearchFilter
+		vars["ldap_user_search_username"] = ldap.UserSearchUsername
+		vars["ldap_group_search_base_dn"] = ldap.GroupSearchBaseDN
+		vars["ldap_group_search_filter"] = ldap.GroupSearchFilter
+		flags = append(flags, "--ops-file", workingdir.PathInWorkingDir(concourseLDAPAuthFilename))
+	}
+
+	if gitlab := c.GetGitLabAuth(); gitlab != nil {
+		vars["gitlab_client_id"] = gitlab.ClientID
+		vars["gitlab_host"] = gitlab.Host
+		flags = append(flags, "--ops-file", workingdir.PathInWorkingDir(concourseGitLabAuthFilename))
+	}
+
+	if len(flags) == 0 {
+		return nil, nil
+	}
+
+	// Written as a vars file rather than --var flags so that the OIDC scopes are passed as a list
+	contents, err := yaml.Marshal(vars)
+	if err != nil {
+		return nil, err
+	}
+	path, err := workingdir.SaveFileToWorkingDir(concourseAuthVarsFilename, contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save %s to working directory: [%v]", concourseAuthVarsFilename, err)
+	}
+	return append(flags, "--vars-file", path), nil
+}
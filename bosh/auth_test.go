@@ -0,0 +1,75 @@
+package bosh
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir/workingdirfakes"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/util/yaml"
+	yamlenc "github.com/ghodss/yaml"
+)
+
+func TestAuthFlags(t *testing.T) {
+	workingdir := &workingdirfakes.FakeIClient{}
+	workingdir.PathInWorkingDirStub = func(filename string) string {
+		return "/working/" + filename
+	}
+	workingdir.SaveFileToWorkingDirStub = func(filename string, contents []byte) (string, error) {
+		return "/working/" + filename, nil
+	}
+
+	flags, err := authFlags(workingdir, config.Config{})
+	if err != nil || flags != nil {
+		t.Errorf("authFlags() with no providers = %v, %v", flags, err)
+	}
+
+	conf := config.Config{
+		OIDCAuth:   &config.OIDCAuth{Issuer: "https://sso.example.com", ClientID: "concourse", Scopes: []string{"profile", "groups"}, GroupsClaim: "groups"},
+		LDAPAuth:   &config.LDAPAuth{Host: "ldap.example.com:636", BindDN: "cn=concourse", UserSearchBaseDN: "ou=people", UserSearchUsername: "uid"},
+		GitLabAuth: &config.GitLabAuth{ClientID: "gitlab-id", Host: "https://gitlab.example.com"},
+	}
+	flags, err = authFlags(workingdir, conf)
+	if err != nil {
+		t.Fatalf("authFlags() error = %v", err)
+	}
+	want := []string{
+		"--ops-file", "/working/oidc-auth.yml",
+		"--ops-file", "/working/ldap-auth.yml",
+		"--ops-file", "/working/gitlab-auth.yml",
+		"--vars-file", "/working/auth-vars.yml",
+	}
+	if !reflect.DeepEqual(flags, want) {
+		t.Errorf("authFlags() = %v, want %v", flags, want)
+	}
+
+	_, contents := workingdir.SaveFileToWorkingDirArgsForCall(0)
+	var vars map[string]interface{}
+	if err = yamlenc.Unmarshal(contents, &vars); err != nil {
+		t.Fatal(err)
+	}
+	vars[OIDCClientSecretVar] = "oidc-secret"
+	vars[LDAPBindPasswordVar] = "bind-password"
+	vars[GitLabClientSecretVar] = "gitlab-secret"
+
+	manifest := "instance_groups:\n- name: web\n  jobs:\n  - name: web\n    properties: {}\n"
+	ops := strings.Join([]string{string(concourseOIDCAuth), string(concourseLDAPAuth), string(concourseGitLabAuth)}, "\n")
+	rendered, err := yaml.Interpolate(manifest, ops, vars)
+	if err != nil {
+		t.Fatalf("interpolating the auth ops files error = %v", err)
+	}
+	for _, want := range []string{
+		"issuer: https://sso.example.com",
+		"scopes:\n        - profile\n        - groups",
+		"client_secret: oidc-secret",
+		"bind_pw: bind-password",
+		"base_dn: ou=people",
+		"host: https://gitlab.example.com",
+		"client_secret: gitlab-secret",
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("rendered manifest\n%s\nwant it to contain %q", rendered, want)
+		}
+	}
+}
@@ -8,10 +8,10 @@ import (
 	"github.com/EngineerBetter/control-tower/bosh/internal/boshcli"
 	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir"
 	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/iaas"
 	"github.com/EngineerBetter/control-tower/terraform"
 	"github.com/lib/pq"
-	"golang.org/x/crypto/ssh"
 )
 
 //AWSClient is an AWS specific implementation of IClient
@@ -22,26 +22,22 @@ type AWSClient struct {
 	db          Opener
 	stdout      io.Writer
 	stderr      io.Writer
+	events      events.Recorder
 	provider    iaas.Provider
 	boshCLI     boshcli.ICLI
 	versionFile []byte
 }
 
 //NewAWSClient returns a AWS specific implementation of IClient
-func NewAWSClient(config config.ConfigView, outputs terraform.Outputs, workingdir workingdir.IClient, stdout, stderr io.Writer, provider iaas.Provider, boshCLI boshcli.ICLI, versionFile []byte) (IClient, error) {
+func NewAWSClient(config config.ConfigView, outputs terraform.Outputs, workingdir workingdir.IClient, stdout, stderr io.Writer, recorder events.Recorder, provider iaas.Provider, boshCLI boshcli.ICLI, versionFile []byte) (IClient, error) {
 	directorPublicIP, err := outputs.Get("DirectorPublicIP")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get DirectorPublicIP from terraform outputs: [%v]", err)
 	}
 	addr := net.JoinHostPort(directorPublicIP, "22")
-	key, err := ssh.ParsePrivateKey([]byte(config.GetPrivateKey()))
+	conf, err := sshGatewayConfig("vcap", config.GetPrivateKey())
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key for bosh: [%v]", err)
-	}
-	conf := &ssh.ClientConfig{
-		User:            "vcap",
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Auth:            []ssh.AuthMethod{ssh.PublicKeys(key)},
+		return nil, err
 	}
 	var boshDBAddress, boshDBPort string
 
@@ -74,6 +70,7 @@ func NewAWSClient(config config.ConfigView, outputs terraform.Outputs, workingdi
 		db:          db,
 		stdout:      stdout,
 		stderr:      stderr,
+		events:      recorder,
 		provider:    provider,
 		boshCLI:     boshCLI,
 		versionFile: versionFile,
@@ -2,16 +2,16 @@ package bosh
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
-	"os"
 	"strings"
 
 	"github.com/EngineerBetter/control-tower/db"
 	"github.com/apparentlymart/go-cidr/cidr"
 )
 
-func (client *AWSClient) deployConcourse(creds []byte, detach bool) ([]byte, error) {
+func (client *AWSClient) deployConcourse(creds []byte, detach bool, stdout io.Writer, extraFlags ...string) ([]byte, error) {
 
 	err := saveFilesToWorkingDir(client.workingdir, client.provider, creds)
 	if err != nil {
@@ -54,7 +54,7 @@ func (client *AWSClient) deployConcourse(creds []byte, detach bool) ([]byte, err
 		"postgres_ca_cert":           db.RDSRootCert,
 		"web_vm_type":                "concourse-web-" + client.config.GetConcourseWebSize(),
 		"worker_vm_type":             "concourse-" + client.config.GetConcourseWorkerSize(),
-		"worker_count":               client.config.GetConcourseWorkerCount(),
+		"worker_count":               concourseWorkerCount(client.config),
 		"atc_eip":                    atcPublicIP,
 		"external_tls.certificate":   client.config.GetConcourseCert(),
 		"external_tls.private_key":   client.config.GetConcourseKey(),
@@ -100,10 +100,33 @@ func (client *AWSClient) deployConcourse(creds []byte, detach bool) ([]byte, err
 		flagFiles = append(flagFiles, "--ops-file", client.workingdir.PathInWorkingDir(concourseMicrosoftAuthFilename))
 	}
 
+	authFlagFiles, err := authFlags(client.workingdir, client.config)
+	if err != nil {
+		return creds, err
+	}
+	flagFiles = append(flagFiles, authFlagFiles...)
+
 	if client.config.IsSpot() {
 		flagFiles = append(flagFiles, "--ops-file", client.workingdir.PathInWorkingDir(concourseEphemeralWorkersFilename))
 	}
 
+	if client.config.IsPrivate() {
+		flagFiles = append(flagFiles, "--ops-file", client.workingdir.PathInWorkingDir(concoursePrivateWebFilename))
+	}
+
+	if client.config.GetConcourseWebCount() > 1 {
+		vmap["web_count"] = client.config.GetConcourseWebCount()
+		vmap["web_network_name"] = "web"
+		flagFiles = append(flagFiles, "--ops-file", client.workingdir.PathInWorkingDir(concourseWebLBFilename))
+	}
+
+	poolFlags, err := workerPoolsFlags(client.workingdir, concourseWorkerPools(client.config))
+	if err != nil {
+		return creds, err
+	}
+	flagFiles = append(flagFiles, poolFlags...)
+	flagFiles = append(flagFiles, hibernateFlags(client.workingdir, client.config)...)
+
 	t, err1 := client.buildTagsYaml(vmap["project"], "concourse")
 	if err1 != nil {
 		return creds, err
@@ -111,6 +134,12 @@ func (client *AWSClient) deployConcourse(creds []byte, detach bool) ([]byte, err
 	vmap["tags"] = t
 	flagFiles = append(flagFiles, "--ops-file", client.workingdir.PathInWorkingDir(extraTagsFilename))
 
+	customFlagFiles, err := customFlags(client.workingdir, client.config)
+	if err != nil {
+		return creds, err
+	}
+	flagFiles = append(flagFiles, customFlagFiles...)
+
 	vs := vars(vmap)
 
 	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
@@ -124,8 +153,8 @@ func (client *AWSClient) deployConcourse(creds []byte, detach bool) ([]byte, err
 		client.config.GetDirectorPassword(),
 		client.config.GetDirectorCACert(),
 		detach,
-		os.Stdout,
-		append(flagFiles, vs...)...)
+		stdout,
+		append(append(flagFiles, vs...), extraFlags...)...)
 	if err != nil {
 		return creds, fmt.Errorf("failed to run bosh deploy with commands %+v: [%v]", flagFiles, err)
 	}
@@ -60,7 +60,7 @@ func newProxyOpener(jumpboxAddr string, config *ssh.ClientConfig, d driver.Drive
 	}
 	var startOnce sync.Once
 	f := func() {
-		p, err := ssh.Dial("tcp", jumpboxAddr, config)
+		p, err := dialSSH(jumpboxAddr, config)
 		if err != nil {
 			return //TODO: handle
 		}
@@ -130,3 +130,48 @@ func (client *AWSClient) createDefaultDatabases() error {
 	}
 	return nil
 }
+
+func (client *AWSClient) dbConnection() (dbConnection, error) {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return dbConnection{}, fmt.Errorf("failed to get DirectorPublicIP from terraform outputs: [%v]", err)
+	}
+	boshDBAddress, err := client.outputs.Get("BoshDBAddress")
+	if err != nil {
+		return dbConnection{}, fmt.Errorf("failed to get BoshDBAddress from terraform outputs: [%v]", err)
+	}
+	boshDBPort, err := client.outputs.Get("BoshDBPort")
+	if err != nil {
+		return dbConnection{}, fmt.Errorf("failed to get BoshDBPort from terraform outputs: [%v]", err)
+	}
+	gatewayConfig, err := sshGatewayConfig("vcap", client.config.GetPrivateKey())
+	if err != nil {
+		return dbConnection{}, err
+	}
+	return dbConnection{
+		gatewayAddr:   net.JoinHostPort(directorPublicIP, "22"),
+		gatewayConfig: gatewayConfig,
+		host:          boshDBAddress,
+		port:          boshDBPort,
+		username:      client.config.GetRDSUsername(),
+		password:      client.config.GetRDSPassword(),
+	}, nil
+}
+
+// DumpDatabase writes a pg_dump archive of the named database to w
+func (client *AWSClient) DumpDatabase(name string, w io.Writer) error {
+	conn, err := client.dbConnection()
+	if err != nil {
+		return err
+	}
+	return dumpDatabase(conn, name, w, client.stderr)
+}
+
+// RestoreDatabase replaces the contents of the named database with the pg_dump archive read from r
+func (client *AWSClient) RestoreDatabase(name string, r io.Reader) error {
+	conn, err := client.dbConnection()
+	if err != nil {
+		return err
+	}
+	return restoreDatabase(conn, name, r, client.stdout, client.stderr)
+}
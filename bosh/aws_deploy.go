@@ -1,35 +1,57 @@
 package bosh
 
 import (
+	"bytes"
+	"fmt"
 	"net"
+	"os"
+	"strings"
 
 	"github.com/EngineerBetter/control-tower/bosh/internal/boshcli"
+	"github.com/EngineerBetter/control-tower/config"
 	"github.com/EngineerBetter/control-tower/db"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/apparentlymart/go-cidr/cidr"
 )
 
 // Deploy implements deploy for AWS client
 func (client *AWSClient) Deploy(state, creds []byte, detach bool) (newState, newCreds []byte, err error) {
-	state, creds, err = client.CreateEnv(state, creds, "")
+	err = client.events.Phase(events.CreateEnv, func(r events.Resources) error {
+		r["director_ip"] = client.config.GetDirectorPublicIP()
+		state, creds, err = client.CreateEnv(state, creds, "")
+		return err
+	})
 	if err != nil {
 		return state, creds, err
 	}
 
-	if err = client.updateCloudConfig(client.boshCLI); err != nil {
+	err = client.events.Phase(events.CloudConfig, func(events.Resources) error {
+		return client.updateCloudConfig(client.boshCLI)
+	})
+	if err != nil {
 		return state, creds, err
 	}
-	if err = client.uploadConcourseStemcell(client.boshCLI); err != nil {
+	err = client.events.Phase(events.StemcellUpload, func(events.Resources) error {
+		return client.uploadConcourseStemcell(client.boshCLI)
+	})
+	if err != nil {
 		return state, creds, err
 	}
 	if err = client.createDefaultDatabases(); err != nil {
 		return state, creds, err
 	}
 
-	creds, err = client.deployConcourse(creds, detach)
-	if err != nil {
-		return state, creds, err
+	// VMs created while the deployment used access keys keep them until they are recreated
+	var extraFlags []string
+	if client.config.UsesInstanceIdentity() && client.config.HasLegacyAccessKeys() {
+		extraFlags = append(extraFlags, "--recreate")
 	}
 
+	err = client.events.Phase(events.BoshDeploy, func(r events.Resources) error {
+		r["deployment"] = concourseDeploymentName
+		creds, err = client.deployConcourse(creds, detach, os.Stdout, extraFlags...)
+		return err
+	})
 	return state, creds, err
 }
 
@@ -45,92 +67,118 @@ func (client *AWSClient) Locks() ([]byte, error) {
 
 }
 
+// DeployDryRun runs bosh deploy --dry-run for Concourse and returns the output describing its changes
+func (client *AWSClient) DeployDryRun(creds []byte) (string, error) {
+	output := new(bytes.Buffer)
+	if _, err := client.deployConcourse(creds, false, output, "--dry-run"); err != nil {
+		return output.String(), err
+	}
+	return output.String(), nil
+}
+
 // CreateEnv exposes bosh create-env functionality
 func (client *AWSClient) CreateEnv(state, creds []byte, customOps string) (newState, newCreds []byte, err error) {
-	tags, err := splitTags(client.config.GetTags())
+	environment, tags, err := client.directorEnvironment(customOps)
 	if err != nil {
 		return state, creds, err
 	}
+
+	createEnvFiles, err1 := client.boshCLI.CreateEnv(&boshcli.CreateEnvFiles{StateFileContents: state, VarsFileContents: creds}, environment, client.config.GetDirectorPassword(), client.config.GetDirectorCert(), client.config.GetDirectorKey(), client.config.GetDirectorCACert(), tags)
+	if err1 != nil {
+		return createEnvFiles.StateFileContents, createEnvFiles.VarsFileContents, err1
+	}
+	return createEnvFiles.StateFileContents, createEnvFiles.VarsFileContents, err
+}
+
+// DirectorManifest renders the manifest create-env would use without deploying it
+func (client *AWSClient) DirectorManifest() (string, error) {
+	environment, tags, err := client.directorEnvironment("")
+	if err != nil {
+		return "", err
+	}
+
+	return client.boshCLI.DirectorManifest(environment, client.config.GetDirectorPassword(), client.config.GetDirectorCert(), client.config.GetDirectorKey(), client.config.GetDirectorCACert(), tags)
+}
+
+func (client *AWSClient) directorEnvironment(customOps string) (boshcli.AWSEnvironment, map[string]string, error) {
+	tags, err := splitTags(client.config.GetTags())
+	if err != nil {
+		return boshcli.AWSEnvironment{}, nil, err
+	}
 	tags["control-tower-project"] = client.config.GetProject()
 	tags["control-tower-component"] = "concourse"
 
-	boshUserAccessKeyID, err1 := client.outputs.Get("BoshUserAccessKeyID")
-	if err1 != nil {
-		return state, creds, err1
+	customOps, customVars, err := directorCustomizations(client.config, customOps)
+	if err != nil {
+		return boshcli.AWSEnvironment{}, nil, err
 	}
-	boshSecretAccessKey, err1 := client.outputs.Get("BoshSecretAccessKey")
+
+	credentials, err1 := client.directorCredentials()
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	publicSubnetID, err1 := client.outputs.Get("PublicSubnetID")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	privateSubnetID, err1 := client.outputs.Get("PrivateSubnetID")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	directorPublicIP, err1 := client.outputs.Get("DirectorPublicIP")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	atcSecurityGroupID, err1 := client.outputs.Get("ATCSecurityGroupID")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	vmSecurityGroupID, err1 := client.outputs.Get("VMsSecurityGroupID")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	blobstoreBucket, err1 := client.outputs.Get("BlobstoreBucket")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	boshDBAddress, err1 := client.outputs.Get("BoshDBAddress")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	boshDbPort, err1 := client.outputs.Get("BoshDBPort")
 	if err1 != nil {
-		return state, creds, err1
-	}
-	blobstoreUserAccessKeyID, err1 := client.outputs.Get("BlobstoreUserAccessKeyID")
-	if err1 != nil {
-		return state, creds, err1
-	}
-	blobstoreSecretAccessKey, err1 := client.outputs.Get("BlobstoreSecretAccessKey")
-	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	directorKeyPair, err1 := client.outputs.Get("DirectorKeyPair")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	directorSecurityGroup, err1 := client.outputs.Get("DirectorSecurityGroupID")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 
 	publicCIDR := client.config.GetPublicCIDR()
 	_, pubCIDR, err1 := net.ParseCIDR(publicCIDR)
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	internalGateway, err1 := cidr.Host(pubCIDR, 1)
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 	directorInternalIP, err1 := cidr.Host(pubCIDR, 6)
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.AWSEnvironment{}, nil, err1
 	}
 
-	createEnvFiles, err1 := client.boshCLI.CreateEnv(&boshcli.CreateEnvFiles{StateFileContents: state, VarsFileContents: creds}, boshcli.AWSEnvironment{
+	return boshcli.AWSEnvironment{
 		InternalCIDR:    client.config.GetPublicCIDR(),
 		InternalGateway: internalGateway.String(),
 		InternalIP:      directorInternalIP.String(),
-		AccessKeyID:     boshUserAccessKeyID,
-		SecretAccessKey: boshSecretAccessKey,
+		AccessKeyID:     credentials.AccessKeyID,
+		SecretAccessKey: credentials.SecretAccessKey,
+		SessionToken:    credentials.SessionToken,
 		Region:          client.config.GetRegion(),
 		AZ:              client.config.GetAvailabilityZone(),
 		DefaultKeyName:  directorKeyPair,
@@ -138,30 +186,76 @@ func (client *AWSClient) CreateEnv(state, creds []byte, customOps string) (newSt
 			directorSecurityGroup,
 			vmSecurityGroupID,
 		},
-		PrivateKey:           client.config.GetPrivateKey(),
-		PublicSubnetID:       publicSubnetID,
-		PrivateSubnetID:      privateSubnetID,
-		ExternalIP:           directorPublicIP,
-		ATCSecurityGroup:     atcSecurityGroupID,
-		VMSecurityGroup:      vmSecurityGroupID,
-		BlobstoreBucket:      blobstoreBucket,
-		DBCACert:             db.RDSRootCert,
-		DBHost:               boshDBAddress,
-		DBName:               client.config.GetRDSDefaultDatabaseName(),
-		DBPassword:           client.config.GetRDSPassword(),
-		DBPort:               boshDbPort,
-		DBUsername:           client.config.GetRDSUsername(),
-		S3AWSAccessKeyID:     blobstoreUserAccessKeyID,
-		S3AWSSecretAccessKey: blobstoreSecretAccessKey,
-		Spot:                 client.config.IsSpot(),
-		WorkerType:           client.config.GetWorkerType(),
-		CustomOperations:     customOps,
-		VersionFile:          client.versionFile,
-	}, client.config.GetDirectorPassword(), client.config.GetDirectorCert(), client.config.GetDirectorKey(), client.config.GetDirectorCACert(), tags)
-	if err1 != nil {
-		return createEnvFiles.StateFileContents, createEnvFiles.VarsFileContents, err1
+		PrivateKey:              client.config.GetPrivateKey(),
+		PublicSubnetID:          publicSubnetID,
+		PrivateSubnetID:         privateSubnetID,
+		Private:                 client.config.IsPrivate(),
+		ExternalIP:              directorPublicIP,
+		ATCSecurityGroup:        atcSecurityGroupID,
+		VMSecurityGroup:         vmSecurityGroupID,
+		BlobstoreBucket:         blobstoreBucket,
+		DBCACert:                db.RDSRootCert,
+		DBHost:                  boshDBAddress,
+		DBName:                  client.config.GetRDSDefaultDatabaseName(),
+		DBPassword:              client.config.GetRDSPassword(),
+		DBPort:                  boshDbPort,
+		DBUsername:              client.config.GetRDSUsername(),
+		S3AWSAccessKeyID:        credentials.S3AWSAccessKeyID,
+		S3AWSSecretAccessKey:    credentials.S3AWSSecretAccessKey,
+		DirectorInstanceProfile: credentials.DirectorInstanceProfile,
+		VMsInstanceProfile:      credentials.VMsInstanceProfile,
+		Spot:                    client.config.IsSpot(),
+		WorkerType:              client.config.GetWorkerType(),
+		CustomOperations:        customOps,
+		CustomVars:              customVars,
+		VersionFile:             client.versionFile,
+	}, tags, nil
+}
+
+// directorCredentials returns what the director and create-env authenticate to AWS with. Deployments
+// using instance identity give the director instance profiles, and create-env the credentials that
+// control-tower itself is running with, rather than the keys of IAM users.
+func (client *AWSClient) directorCredentials() (boshcli.AWSEnvironment, error) {
+	if !client.config.UsesInstanceIdentity() {
+		outputs := map[string]string{}
+		for _, name := range []string{"BoshUserAccessKeyID", "BoshSecretAccessKey", "BlobstoreUserAccessKeyID", "BlobstoreSecretAccessKey"} {
+			value, err := client.outputs.Get(name)
+			if err != nil {
+				return boshcli.AWSEnvironment{}, err
+			}
+			outputs[name] = value
+		}
+		return boshcli.AWSEnvironment{
+			AccessKeyID:          outputs["BoshUserAccessKeyID"],
+			SecretAccessKey:      outputs["BoshSecretAccessKey"],
+			S3AWSAccessKeyID:     outputs["BlobstoreUserAccessKeyID"],
+			S3AWSSecretAccessKey: outputs["BlobstoreSecretAccessKey"],
+		}, nil
 	}
-	return createEnvFiles.StateFileContents, createEnvFiles.VarsFileContents, err
+
+	attrs := map[string]string{}
+	for _, name := range []string{"access_key_id", "secret_access_key", "session_token"} {
+		value, err := client.provider.Attr(name)
+		if err != nil {
+			return boshcli.AWSEnvironment{}, err
+		}
+		attrs[name] = value
+	}
+	directorInstanceProfile, err := client.outputs.Get("DirectorInstanceProfile")
+	if err != nil {
+		return boshcli.AWSEnvironment{}, err
+	}
+	vmsInstanceProfile, err := client.outputs.Get("VMsInstanceProfile")
+	if err != nil {
+		return boshcli.AWSEnvironment{}, err
+	}
+	return boshcli.AWSEnvironment{
+		AccessKeyID:             attrs["access_key_id"],
+		SecretAccessKey:         attrs["secret_access_key"],
+		SessionToken:            attrs["session_token"],
+		DirectorInstanceProfile: directorInstanceProfile,
+		VMsInstanceProfile:      vmsInstanceProfile,
+	}, nil
 }
 
 // Recreate exposes BOSH recreate
@@ -231,24 +325,86 @@ func (client *AWSClient) updateCloudConfig(bosh boshcli.ICLI) error {
 		return err
 	}
 
+	webAvailabilityZone, webSubnets, webTargetGroups, err := client.webLoadBalancing()
+	if err != nil {
+		return err
+	}
+
 	return bosh.UpdateCloudConfig(boshcli.AWSEnvironment{
-		AZ:                  client.config.GetAvailabilityZone(),
-		PublicSubnetID:      publicSubnetID,
-		PrivateSubnetID:     privateSubnetID,
-		ATCSecurityGroup:    aTCSecurityGroupID,
-		VMSecurityGroup:     vMsSecurityGroupID,
-		Spot:                client.config.IsSpot(),
-		ExternalIP:          directorPublicIP,
-		WorkerType:          client.config.GetWorkerType(),
-		PublicCIDR:          publicCIDR,
-		PublicCIDRGateway:   publicCIDRGateway,
-		PublicCIDRStatic:    publicCIDRStatic,
-		PublicCIDRReserved:  publicCIDRReserved,
-		PrivateCIDR:         privateCIDR,
-		PrivateCIDRGateway:  privateCIDRGateway,
-		PrivateCIDRReserved: privateCIDRReserved,
+		AZ:                          client.config.GetAvailabilityZone(),
+		PublicSubnetID:              publicSubnetID,
+		PrivateSubnetID:             privateSubnetID,
+		ATCSecurityGroup:            aTCSecurityGroupID,
+		VMSecurityGroup:             vMsSecurityGroupID,
+		Spot:                        client.config.IsSpot(),
+		WorkerPoolProvisioningTypes: config.WorkerPoolProvisioningTypes(client.config.GetWorkerPools()),
+		ExternalIP:                  directorPublicIP,
+		WorkerType:                  client.config.GetWorkerType(),
+		PublicCIDR:                  publicCIDR,
+		PublicCIDRGateway:           publicCIDRGateway,
+		PublicCIDRStatic:            publicCIDRStatic,
+		PublicCIDRReserved:          publicCIDRReserved,
+		PrivateCIDR:                 privateCIDR,
+		PrivateCIDRGateway:          privateCIDRGateway,
+		PrivateCIDRReserved:         privateCIDRReserved,
+		WebAvailabilityZone:         webAvailabilityZone,
+		WebSubnets:                  webSubnets,
+		WebTargetGroups:             webTargetGroups,
 	}, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert())
 }
+
+// webLoadBalancing returns the second availability zone, the subnets and the load balancer target
+// groups of several web VMs, or nothing for a single web VM
+func (client *AWSClient) webLoadBalancing() (string, []boshcli.WebSubnet, []string, error) {
+	if client.config.GetConcourseWebCount() < 2 {
+		return "", nil, nil, nil
+	}
+
+	webAvailabilityZone, err := client.outputs.Get("WebAvailabilityZone")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	webSubnetAID, err := client.outputs.Get("WebSubnetAID")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	webSubnetBID, err := client.outputs.Get("WebSubnetBID")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	webTargetGroups, err := client.outputs.Get("WebTargetGroups")
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	webCIDRs, err := config.WebCIDRs(client.config.GetNetworkCIDR())
+	if err != nil {
+		return "", nil, nil, err
+	}
+	var webSubnets []boshcli.WebSubnet
+	for i, subnetID := range []string{webSubnetAID, webSubnetBID} {
+		_, webCIDR, err := net.ParseCIDR(webCIDRs[i])
+		if err != nil {
+			return "", nil, nil, err
+		}
+		gateway, err := cidr.Host(webCIDR, 1)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		reserved, err := formatIPRange(webCIDRs[i], "-", []int{1, 3})
+		if err != nil {
+			return "", nil, nil, err
+		}
+		webSubnets = append(webSubnets, boshcli.WebSubnet{
+			AZ:       fmt.Sprintf("z%d", i+1),
+			CIDR:     webCIDRs[i],
+			Gateway:  gateway.String(),
+			Reserved: reserved,
+			SubnetID: subnetID,
+		})
+	}
+	return webAvailabilityZone, webSubnets, strings.Split(webTargetGroups, ","), nil
+}
 func (client *AWSClient) uploadConcourseStemcell(bosh boshcli.ICLI) error {
 	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
 	if err != nil {
@@ -258,3 +414,30 @@ func (client *AWSClient) uploadConcourseStemcell(bosh boshcli.ICLI) error {
 		ExternalIP: directorPublicIP,
 	}, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert())
 }
+
+// StopConcourseWeb stops the jobs on the web instances, including CredHub and UAA
+func (client *AWSClient) StopConcourseWeb() error {
+	return client.setConcourseWebState("stop")
+}
+
+// StartConcourseWeb starts the jobs on the web instances again
+func (client *AWSClient) StartConcourseWeb() error {
+	return client.setConcourseWebState("start")
+}
+
+func (client *AWSClient) setConcourseWebState(action string) error {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return err
+	}
+	return setConcourseWebState(client.boshCLI, action, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert(), client.stdout)
+}
+
+// RecreateUnresponsive recreates the Concourse VMs, including those whose agents no longer respond to the director
+func (client *AWSClient) RecreateUnresponsive() error {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return err
+	}
+	return client.boshCLI.RunAuthenticatedCommand("recreate", directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert(), false, client.stdout, "--fix")
+}
@@ -0,0 +1,185 @@
+package bosh
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/EngineerBetter/control-tower/db"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/apparentlymart/go-cidr/cidr"
+)
+
+func (client *AzureClient) deployConcourse(creds []byte, detach bool, stdout io.Writer, extraFlags ...string) ([]byte, error) {
+
+	err := saveFilesToWorkingDir(client.workingdir, client.provider, creds)
+	if err != nil {
+		return nil, fmt.Errorf("failed saving files to working directory in deployConcourse: [%v]", err)
+	}
+
+	uaaCertPath, err := client.workingdir.SaveFileToWorkingDir(uaaCertFilename, uaaCert)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	boshDBAddress, err := client.outputs.Get("BoshDBAddress")
+	if err != nil {
+		return []byte{}, err
+	}
+	atcPublicIP, err := client.outputs.Get("ATCPublicIP")
+	if err != nil {
+		return []byte{}, err
+	}
+
+	networkName, err := client.outputs.Get("Network")
+	if err != nil {
+		return []byte{}, err
+	}
+
+	dbName, err := client.outputs.Get("DBName")
+	if err != nil {
+		return []byte{}, err
+	}
+
+	publicCIDR := client.config.GetPublicCIDR()
+	_, pubCIDR, err1 := net.ParseCIDR(publicCIDR)
+	if err1 != nil {
+		return creds, err
+	}
+	atcPrivateIP, err := cidr.Host(pubCIDR, 7)
+	if err != nil {
+		return creds, err
+	}
+
+	vmap := map[string]interface{}{
+		"deployment_name":            concourseDeploymentName,
+		"domain":                     client.config.GetDomain(),
+		"project":                    client.config.GetProject(),
+		"web_network_name":           "public",
+		"worker_network_name":        "private",
+		"postgres_host":              boshDBAddress,
+		"postgres_role":              iaas.AzureDBUsername(client.config.GetRDSUsername(), dbName),
+		"postgres_port":              "5432",
+		"postgres_password":          client.config.GetRDSPassword(),
+		"postgres_ca_cert":           db.AzurePostgresRootCert,
+		"web_vm_type":                "concourse-web-" + client.config.GetConcourseWebSize(),
+		"worker_vm_type":             "concourse-" + client.config.GetConcourseWorkerSize(),
+		"worker_count":               concourseWorkerCount(client.config),
+		"atc_eip":                    atcPublicIP,
+		"external_tls.certificate":   client.config.GetConcourseCert(),
+		"external_tls.private_key":   client.config.GetConcourseKey(),
+		"atc_encryption_key":         client.config.GetEncryptionKey(),
+		"network_name":               networkName,
+		"web_static_ip":              atcPrivateIP.String(),
+		"enable_global_resources":    client.config.GetEnableGlobalResources(),
+		"enable_pipeline_instances":  client.config.GetEnablePipelineInstances(),
+		"influx_db_retention_period": client.config.GetInfluxDbRetention(),
+	}
+
+	flagFiles := []string{
+		client.workingdir.PathInWorkingDir(concourseManifestFilename),
+		"--vars-store",
+		client.workingdir.PathInWorkingDir(credsFilename),
+		"--ops-file",
+		client.workingdir.PathInWorkingDir(concourseVersionsFilename),
+		"--ops-file",
+		client.workingdir.PathInWorkingDir(concourseSHAsFilename),
+		"--ops-file",
+		uaaCertPath,
+		"--vars-file",
+		client.workingdir.PathInWorkingDir(concourseGrafanaFilename),
+	}
+
+	if client.config.GetConcoursePassword() != "" {
+		vmap["atc_password"] = client.config.GetConcoursePassword()
+	}
+
+	if client.config.IsBitbucketAuthSet() {
+		vmap["bitbucket_client_id"] = client.config.GetBitbucketClientID()
+		vmap["bitbucket_client_secret"] = client.config.GetBitbucketClientSecret()
+		flagFiles = append(flagFiles, "--ops-file", client.workingdir.PathInWorkingDir(concourseBitBucketAuthFilename))
+	}
+
+	if client.config.IsGithubAuthSet() {
+		vmap["github_client_id"] = client.config.GetGithubClientID()
+		vmap["github_client_secret"] = client.config.GetGithubClientSecret()
+		flagFiles = append(flagFiles, "--ops-file", client.workingdir.PathInWorkingDir(concourseGitHubAuthFilename))
+	}
+
+	if client.config.IsMicrosoftAuthSet() {
+		vmap["microsoft_client_id"] = client.config.GetMicrosoftClientID()
+		vmap["microsoft_client_secret"] = client.config.GetMicrosoftClientSecret()
+		vmap["microsoft_tenant"] = client.config.GetMicrosoftTenant()
+		flagFiles = append(flagFiles, "--ops-file", client.workingdir.PathInWorkingDir(concourseMicrosoftAuthFilename))
+	}
+
+	authFlagFiles, err := authFlags(client.workingdir, client.config)
+	if err != nil {
+		return creds, err
+	}
+	flagFiles = append(flagFiles, authFlagFiles...)
+
+	if client.config.IsSpot() {
+		flagFiles = append(flagFiles, "--ops-file", client.workingdir.PathInWorkingDir(concourseEphemeralWorkersFilename))
+	}
+
+	poolFlags, err := workerPoolsFlags(client.workingdir, concourseWorkerPools(client.config))
+	if err != nil {
+		return creds, err
+	}
+	flagFiles = append(flagFiles, poolFlags...)
+	flagFiles = append(flagFiles, hibernateFlags(client.workingdir, client.config)...)
+
+	t, err1 := client.buildTagsYaml(vmap["project"], "concourse")
+	if err1 != nil {
+		return nil, err
+	}
+	vmap["tags"] = t
+	flagFiles = append(flagFiles, "--ops-file", client.workingdir.PathInWorkingDir(extraTagsFilename))
+
+	customFlagFiles, err := customFlags(client.workingdir, client.config)
+	if err != nil {
+		return nil, err
+	}
+	flagFiles = append(flagFiles, customFlagFiles...)
+
+	vs := vars(vmap)
+
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve director IP: [%v]", err)
+	}
+
+	err = client.boshCLI.RunAuthenticatedCommand(
+		"deploy",
+		directorPublicIP,
+		client.config.GetDirectorPassword(),
+		client.config.GetDirectorCACert(),
+		detach,
+		stdout,
+		append(append(flagFiles, vs...), extraFlags...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run bosh deploy with commands %+v: [%v]", flagFiles, err)
+	}
+
+	return ioutil.ReadFile(client.workingdir.PathInWorkingDir(credsFilename))
+}
+
+func (client *AzureClient) buildTagsYaml(project interface{}, component string) (string, error) {
+	var b strings.Builder
+
+	for _, e := range client.config.GetTags() {
+		kv := strings.Join(strings.Split(e, "="), ": ")
+		_, err := fmt.Fprintf(&b, "%s,", kv)
+		if err != nil {
+			return "", err
+		}
+	}
+	cProjectTag := fmt.Sprintf("control-tower-project: %v,", project)
+	b.WriteString(cProjectTag)
+	cComponentTag := fmt.Sprintf("control-tower-component: %s", component)
+	b.WriteString(cComponentTag)
+	return fmt.Sprintf("{%s}", b.String()), nil
+}
@@ -0,0 +1,47 @@
+package bosh
+
+import (
+	"io"
+
+	"github.com/EngineerBetter/control-tower/iaas"
+)
+
+func (client *AzureClient) createDefaultDatabases() error {
+	dbName, err := client.outputs.Get("DBName")
+	if err != nil {
+		return err
+	}
+	return client.provider.CreateDatabases(dbName, client.config.GetRDSUsername(), client.config.GetRDSPassword())
+}
+
+// The server's firewall admits the source IP, so no tunnel is needed
+func (client *AzureClient) dbConnection() (dbConnection, error) {
+	dbName, err := client.outputs.Get("DBName")
+	if err != nil {
+		return dbConnection{}, err
+	}
+	return dbConnection{
+		host:     iaas.AzureDBHost(dbName),
+		port:     "5432",
+		username: iaas.AzureDBUsername(client.config.GetRDSUsername(), dbName),
+		password: client.config.GetRDSPassword(),
+	}, nil
+}
+
+// DumpDatabase writes a pg_dump archive of the named database to w
+func (client *AzureClient) DumpDatabase(name string, w io.Writer) error {
+	conn, err := client.dbConnection()
+	if err != nil {
+		return err
+	}
+	return dumpDatabase(conn, name, w, client.stderr)
+}
+
+// RestoreDatabase replaces the contents of the named database with the pg_dump archive read from r
+func (client *AzureClient) RestoreDatabase(name string, r io.Reader) error {
+	conn, err := client.dbConnection()
+	if err != nil {
+		return err
+	}
+	return restoreDatabase(conn, name, r, client.stdout, client.stderr)
+}
@@ -0,0 +1,292 @@
+package bosh
+
+import (
+	"bytes"
+	"net"
+	"os"
+
+	"github.com/EngineerBetter/control-tower/bosh/internal/boshcli"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/apparentlymart/go-cidr/cidr"
+)
+
+// Deploy deploys a new Bosh director or converges an existing deployment
+// Returns new contents of bosh state file
+func (client *AzureClient) Deploy(state, creds []byte, detach bool) (newState, newCreds []byte, err error) {
+	err = client.events.Phase(events.CreateEnv, func(r events.Resources) error {
+		r["director_ip"] = client.config.GetDirectorPublicIP()
+		state, creds, err = client.CreateEnv(state, creds, "")
+		return err
+	})
+	if err != nil {
+		return state, creds, err
+	}
+
+	err = client.events.Phase(events.CloudConfig, func(events.Resources) error {
+		return client.updateCloudConfig(client.boshCLI)
+	})
+	if err != nil {
+		return state, creds, err
+	}
+	err = client.events.Phase(events.StemcellUpload, func(events.Resources) error {
+		return client.uploadConcourseStemcell(client.boshCLI)
+	})
+	if err != nil {
+		return state, creds, err
+	}
+	if err = client.createDefaultDatabases(); err != nil {
+		return state, creds, err
+	}
+
+	err = client.events.Phase(events.BoshDeploy, func(r events.Resources) error {
+		r["deployment"] = concourseDeploymentName
+		creds, err = client.deployConcourse(creds, detach, os.Stdout)
+		return err
+	})
+	return state, creds, err
+}
+
+// DeployDryRun runs bosh deploy --dry-run for Concourse and returns the output describing its changes
+func (client *AzureClient) DeployDryRun(creds []byte) (string, error) {
+	output := new(bytes.Buffer)
+	if _, err := client.deployConcourse(creds, false, output, "--dry-run"); err != nil {
+		return output.String(), err
+	}
+	return output.String(), nil
+}
+
+// CreateEnv exposes bosh create-env functionality
+func (client *AzureClient) CreateEnv(state, creds []byte, customOps string) (newState, newCreds []byte, err error) {
+	environment, tags, err := client.directorEnvironment(customOps)
+	if err != nil {
+		return state, creds, err
+	}
+
+	createEnvFiles, err1 := client.boshCLI.CreateEnv(&boshcli.CreateEnvFiles{StateFileContents: state, VarsFileContents: creds}, environment, client.config.GetDirectorPassword(), client.config.GetDirectorCert(), client.config.GetDirectorKey(), client.config.GetDirectorCACert(), tags)
+	if err1 != nil {
+		return createEnvFiles.StateFileContents, createEnvFiles.VarsFileContents, err1
+	}
+	return createEnvFiles.StateFileContents, createEnvFiles.VarsFileContents, err
+}
+
+// DirectorManifest renders the manifest create-env would use without deploying it
+func (client *AzureClient) DirectorManifest() (string, error) {
+	environment, tags, err := client.directorEnvironment("")
+	if err != nil {
+		return "", err
+	}
+
+	return client.boshCLI.DirectorManifest(environment, client.config.GetDirectorPassword(), client.config.GetDirectorCert(), client.config.GetDirectorKey(), client.config.GetDirectorCACert(), tags)
+}
+
+func (client *AzureClient) directorEnvironment(customOps string) (boshcli.AzureEnvironment, map[string]string, error) {
+	tags, err := splitTags(client.config.GetTags())
+	if err != nil {
+		return boshcli.AzureEnvironment{}, nil, err
+	}
+	tags["control-tower-project"] = client.config.GetProject()
+	tags["control-tower-component"] = "concourse"
+
+	customOps, customVars, err := directorCustomizations(client.config, customOps)
+	if err != nil {
+		return boshcli.AzureEnvironment{}, nil, err
+	}
+
+	resourceGroup, err1 := client.outputs.Get("ResourceGroup")
+	if err1 != nil {
+		return boshcli.AzureEnvironment{}, nil, err1
+	}
+	network, err1 := client.outputs.Get("Network")
+	if err1 != nil {
+		return boshcli.AzureEnvironment{}, nil, err1
+	}
+	publicSubnetwork, err1 := client.outputs.Get("PublicSubnetworkName")
+	if err1 != nil {
+		return boshcli.AzureEnvironment{}, nil, err1
+	}
+	privateSubnetwork, err1 := client.outputs.Get("PrivateSubnetworkName")
+	if err1 != nil {
+		return boshcli.AzureEnvironment{}, nil, err1
+	}
+	directorPublicIP, err1 := client.outputs.Get("DirectorPublicIP")
+	if err1 != nil {
+		return boshcli.AzureEnvironment{}, nil, err1
+	}
+
+	azureCreds := map[string]string{}
+	for _, key := range []string{"subscription_id", "tenant_id", "client_id", "client_secret"} {
+		value, err1 := client.provider.Attr(key)
+		if err1 != nil {
+			return boshcli.AzureEnvironment{}, nil, err1
+		}
+		azureCreds[key] = value
+	}
+
+	publicCIDR := client.config.GetPublicCIDR()
+	_, pubCIDR, err1 := net.ParseCIDR(publicCIDR)
+	if err1 != nil {
+		return boshcli.AzureEnvironment{}, nil, err1
+	}
+	internalGateway, err1 := cidr.Host(pubCIDR, 1)
+	if err1 != nil {
+		return boshcli.AzureEnvironment{}, nil, err1
+	}
+	directorInternalIP, err1 := cidr.Host(pubCIDR, 6)
+	if err1 != nil {
+		return boshcli.AzureEnvironment{}, nil, err1
+	}
+
+	return boshcli.AzureEnvironment{
+		InternalCIDR:      client.config.GetPublicCIDR(),
+		InternalGW:        internalGateway.String(),
+		InternalIP:        directorInternalIP.String(),
+		DirectorName:      "bosh",
+		Zone:              client.provider.Zone("", ""),
+		ResourceGroup:     resourceGroup,
+		Network:           network,
+		PublicSubnetwork:  publicSubnetwork,
+		PrivateSubnetwork: privateSubnetwork,
+		SubscriptionID:    azureCreds["subscription_id"],
+		TenantID:          azureCreds["tenant_id"],
+		ClientID:          azureCreds["client_id"],
+		ClientSecret:      azureCreds["client_secret"],
+		ExternalIP:        directorPublicIP,
+		Spot:              client.config.IsSpot(),
+		PublicKey:         client.config.GetPublicKey(),
+		CustomOperations:  customOps,
+		CustomVars:        customVars,
+		VersionFile:       client.versionFile,
+	}, tags, nil
+}
+
+// Recreate exposes BOSH recreate
+func (client *AzureClient) Recreate() error {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return err
+	}
+	return client.boshCLI.Recreate(boshcli.AzureEnvironment{
+		ExternalIP: directorPublicIP,
+	}, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert())
+}
+
+// Locks implements locks for Azure client
+func (client *AzureClient) Locks() ([]byte, error) {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return nil, err
+	}
+	return client.boshCLI.Locks(boshcli.AzureEnvironment{
+		ExternalIP: directorPublicIP,
+	}, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert())
+}
+
+func (client *AzureClient) updateCloudConfig(bosh boshcli.ICLI) error {
+	resourceGroup, err := client.outputs.Get("ResourceGroup")
+	if err != nil {
+		return err
+	}
+	privateSubnetwork, err := client.outputs.Get("PrivateSubnetworkName")
+	if err != nil {
+		return err
+	}
+	publicSubnetwork, err := client.outputs.Get("PublicSubnetworkName")
+	if err != nil {
+		return err
+	}
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return err
+	}
+	network, err := client.outputs.Get("Network")
+	if err != nil {
+		return err
+	}
+
+	publicCIDR := client.config.GetPublicCIDR()
+	_, pubCIDR, err := net.ParseCIDR(publicCIDR)
+	if err != nil {
+		return err
+	}
+	pubGateway, err := cidr.Host(pubCIDR, 1)
+	if err != nil {
+		return err
+	}
+	publicCIDRStatic, err := formatIPRange(publicCIDR, ", ", []int{7})
+	if err != nil {
+		return err
+	}
+	publicCIDRReserved, err := formatIPRange(publicCIDR, "-", []int{1, 5})
+	if err != nil {
+		return err
+	}
+
+	privateCIDR := client.config.GetPrivateCIDR()
+	_, privCIDR, err := net.ParseCIDR(privateCIDR)
+	if err != nil {
+		return err
+	}
+	privGateway, err := cidr.Host(privCIDR, 1)
+	if err != nil {
+		return err
+	}
+	privateCIDRReserved, err := formatIPRange(privateCIDR, "-", []int{1, 5})
+	if err != nil {
+		return err
+	}
+
+	return bosh.UpdateCloudConfig(boshcli.AzureEnvironment{
+		PublicCIDR:                  publicCIDR,
+		PublicCIDRGateway:           pubGateway.String(),
+		PublicCIDRStatic:            publicCIDRStatic,
+		PublicCIDRReserved:          publicCIDRReserved,
+		PrivateCIDR:                 privateCIDR,
+		PrivateCIDRGateway:          privGateway.String(),
+		PrivateCIDRReserved:         privateCIDRReserved,
+		Spot:                        client.config.IsSpot(),
+		WorkerPoolProvisioningTypes: config.WorkerPoolProvisioningTypes(client.config.GetWorkerPools()),
+		ResourceGroup:               resourceGroup,
+		PublicSubnetwork:            publicSubnetwork,
+		PrivateSubnetwork:           privateSubnetwork,
+		Zone:                        client.provider.Zone("", ""),
+		Network:                     network,
+	}, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert())
+}
+
+func (client *AzureClient) uploadConcourseStemcell(bosh boshcli.ICLI) error {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return err
+	}
+	return bosh.UploadConcourseStemcell(boshcli.AzureEnvironment{
+		ExternalIP: directorPublicIP,
+	}, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert())
+}
+
+// StopConcourseWeb stops the jobs on the web instances, including CredHub and UAA
+func (client *AzureClient) StopConcourseWeb() error {
+	return client.setConcourseWebState("stop")
+}
+
+// StartConcourseWeb starts the jobs on the web instances again
+func (client *AzureClient) StartConcourseWeb() error {
+	return client.setConcourseWebState("start")
+}
+
+func (client *AzureClient) setConcourseWebState(action string) error {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return err
+	}
+	return setConcourseWebState(client.boshCLI, action, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert(), client.stdout)
+}
+
+// RecreateUnresponsive recreates the Concourse VMs, including those whose agents no longer respond to the director
+func (client *AzureClient) RecreateUnresponsive() error {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return err
+	}
+	return client.boshCLI.RunAuthenticatedCommand("recreate", directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert(), false, client.stdout, "--fix")
+}
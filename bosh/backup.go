@@ -0,0 +1,104 @@
+package bosh
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+
+	"github.com/EngineerBetter/control-tower/bosh/internal/boshcli"
+	"golang.org/x/crypto/ssh"
+)
+
+// ConcourseDatabases are the databases holding the state of Concourse, CredHub and UAA
+var ConcourseDatabases = []string{"concourse_atc", "credhub", "uaa"}
+
+// pgCommand builds the pg_dump and pg_restore commands, and is replaced in tests
+var pgCommand = exec.Command
+
+// dbConnection describes how to reach the Concourse databases. When gatewayAddr is set
+// the connection is tunnelled over SSH through that host.
+type dbConnection struct {
+	gatewayAddr   string
+	gatewayConfig *ssh.ClientConfig
+	host          string
+	port          string
+	username      string
+	password      string
+}
+
+func sshGatewayConfig(user, privateKey string) (*ssh.ClientConfig, error) {
+	key, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key for bosh: [%v]", err)
+	}
+	return &ssh.ClientConfig{
+		User:            user,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(key)},
+	}, nil
+}
+
+// dumpDatabase writes a pg_dump archive of the database called name to w
+func dumpDatabase(conn dbConnection, name string, w, stderr io.Writer) error {
+	return runPGCommand(conn, nil, w, stderr, "pg_dump",
+		"--format=custom", "--no-owner", "--no-privileges", "--dbname", name)
+}
+
+// restoreDatabase replaces the contents of the database called name with the pg_dump archive read from r
+func restoreDatabase(conn dbConnection, name string, r io.Reader, stdout, stderr io.Writer) error {
+	return runPGCommand(conn, r, stdout, stderr, "pg_restore",
+		"--clean", "--if-exists", "--no-owner", "--no-privileges", "--single-transaction", "--dbname", name)
+}
+
+func runPGCommand(conn dbConnection, stdin io.Reader, stdout, stderr io.Writer, binary string, args ...string) error {
+	host, port := conn.host, conn.port
+	if conn.gatewayAddr != "" {
+		listener, err := openTunnel(conn.gatewayAddr, conn.gatewayConfig, net.JoinHostPort(conn.host, conn.port))
+		if err != nil {
+			return fmt.Errorf("failed to open tunnel to the database through %s: [%v]", conn.gatewayAddr, err)
+		}
+		defer listener.Close()
+		host, port, _ = net.SplitHostPort(listener.Addr().String())
+	}
+
+	cmd := pgCommand(binary, append([]string{"--host", host, "--port", port, "--username", conn.username}, args...)...)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+conn.password, "PGSSLMODE=require")
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: [%v]", binary, err)
+	}
+	return nil
+}
+
+// openTunnel listens on a local port and forwards every connection to target through the SSH host at addr
+func openTunnel(addr string, config *ssh.ClientConfig, target string) (net.Listener, error) {
+	gateway, err := dialSSH(addr, config)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		gateway.Close()
+		return nil, err
+	}
+	go func() {
+		defer gateway.Close()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go proxyConn(conn, gateway, target)
+		}
+	}()
+	return listener, nil
+}
+
+// setConcourseWebState stops or starts the web instances, which also run CredHub and UAA
+func setConcourseWebState(boshCLI boshcli.ICLI, action, ip, password, ca string, stdout io.Writer) error {
+	return boshCLI.RunAuthenticatedCommand(action, ip, password, ca, false, stdout, "web")
+}
@@ -0,0 +1,48 @@
+package bosh
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestDumpAndRestoreDatabase(t *testing.T) {
+	defer func() { pgCommand = exec.Command }()
+	var ran []string
+	pgCommand = func(name string, args ...string) *exec.Cmd {
+		ran = append([]string{name}, args...)
+		return exec.Command("sh", "-c", `echo "$PGPASSWORD $PGSSLMODE"; cat`)
+	}
+
+	conn := dbConnection{host: "db.example.com", port: "5432", username: "admin", password: "s3cret"}
+
+	var dump bytes.Buffer
+	if err := dumpDatabase(conn, "credhub", &dump, &bytes.Buffer{}); err != nil {
+		t.Fatalf("dumpDatabase() error = %v", err)
+	}
+	if got := strings.Join(ran, " "); got != "pg_dump --host db.example.com --port 5432 --username admin --format=custom --no-owner --no-privileges --dbname credhub" {
+		t.Errorf("dumpDatabase() ran %q", got)
+	}
+	if got := dump.String(); got != "s3cret require\n" {
+		t.Errorf("dumpDatabase() wrote %q", got)
+	}
+
+	var out bytes.Buffer
+	if err := restoreDatabase(conn, "uaa", strings.NewReader("archive"), &out, &bytes.Buffer{}); err != nil {
+		t.Fatalf("restoreDatabase() error = %v", err)
+	}
+	if got := strings.Join(ran, " "); got != "pg_restore --host db.example.com --port 5432 --username admin --clean --if-exists --no-owner --no-privileges --single-transaction --dbname uaa" {
+		t.Errorf("restoreDatabase() ran %q", got)
+	}
+	if got := out.String(); got != "s3cret require\narchive" {
+		t.Errorf("restoreDatabase() gave pg_restore %q", got)
+	}
+
+	pgCommand = func(name string, args ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+	if err := dumpDatabase(conn, "credhub", &dump, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "pg_dump failed") {
+		t.Errorf("dumpDatabase() error = %v, expected pg_dump failed", err)
+	}
+}
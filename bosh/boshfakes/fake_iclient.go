@@ -2,6 +2,7 @@
 package boshfakes
 
 import (
+	"io"
 	"sync"
 
 	"github.com/EngineerBetter/control-tower/bosh"
@@ -52,6 +53,43 @@ type FakeIClient struct {
 		result2 []byte
 		result3 error
 	}
+	DeployDryRunStub        func([]byte) (string, error)
+	deployDryRunMutex       sync.RWMutex
+	deployDryRunArgsForCall []struct {
+		arg1 []byte
+	}
+	deployDryRunReturns struct {
+		result1 string
+		result2 error
+	}
+	deployDryRunReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	DirectorManifestStub        func() (string, error)
+	directorManifestMutex       sync.RWMutex
+	directorManifestArgsForCall []struct {
+	}
+	directorManifestReturns struct {
+		result1 string
+		result2 error
+	}
+	directorManifestReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	DumpDatabaseStub        func(string, io.Writer) error
+	dumpDatabaseMutex       sync.RWMutex
+	dumpDatabaseArgsForCall []struct {
+		arg1 string
+		arg2 io.Writer
+	}
+	dumpDatabaseReturns struct {
+		result1 error
+	}
+	dumpDatabaseReturnsOnCall map[int]struct {
+		result1 error
+	}
 	InstancesStub        func() ([]bosh.Instance, error)
 	instancesMutex       sync.RWMutex
 	instancesArgsForCall []struct {
@@ -86,6 +124,48 @@ type FakeIClient struct {
 	recreateReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RecreateUnresponsiveStub        func() error
+	recreateUnresponsiveMutex       sync.RWMutex
+	recreateUnresponsiveArgsForCall []struct {
+	}
+	recreateUnresponsiveReturns struct {
+		result1 error
+	}
+	recreateUnresponsiveReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RestoreDatabaseStub        func(string, io.Reader) error
+	restoreDatabaseMutex       sync.RWMutex
+	restoreDatabaseArgsForCall []struct {
+		arg1 string
+		arg2 io.Reader
+	}
+	restoreDatabaseReturns struct {
+		result1 error
+	}
+	restoreDatabaseReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StartConcourseWebStub        func() error
+	startConcourseWebMutex       sync.RWMutex
+	startConcourseWebArgsForCall []struct {
+	}
+	startConcourseWebReturns struct {
+		result1 error
+	}
+	startConcourseWebReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StopConcourseWebStub        func() error
+	stopConcourseWebMutex       sync.RWMutex
+	stopConcourseWebArgsForCall []struct {
+	}
+	stopConcourseWebReturns struct {
+		result1 error
+	}
+	stopConcourseWebReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -301,6 +381,193 @@ func (fake *FakeIClient) DeployReturnsOnCall(i int, result1 []byte, result2 []by
 	}{result1, result2, result3}
 }
 
+func (fake *FakeIClient) DeployDryRun(arg1 []byte) (string, error) {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.deployDryRunMutex.Lock()
+	ret, specificReturn := fake.deployDryRunReturnsOnCall[len(fake.deployDryRunArgsForCall)]
+	fake.deployDryRunArgsForCall = append(fake.deployDryRunArgsForCall, struct {
+		arg1 []byte
+	}{arg1Copy})
+	stub := fake.DeployDryRunStub
+	fakeReturns := fake.deployDryRunReturns
+	fake.recordInvocation("DeployDryRun", []interface{}{arg1Copy})
+	fake.deployDryRunMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeIClient) DeployDryRunCallCount() int {
+	fake.deployDryRunMutex.RLock()
+	defer fake.deployDryRunMutex.RUnlock()
+	return len(fake.deployDryRunArgsForCall)
+}
+
+func (fake *FakeIClient) DeployDryRunCalls(stub func([]byte) (string, error)) {
+	fake.deployDryRunMutex.Lock()
+	defer fake.deployDryRunMutex.Unlock()
+	fake.DeployDryRunStub = stub
+}
+
+func (fake *FakeIClient) DeployDryRunArgsForCall(i int) []byte {
+	fake.deployDryRunMutex.RLock()
+	defer fake.deployDryRunMutex.RUnlock()
+	argsForCall := fake.deployDryRunArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeIClient) DeployDryRunReturns(result1 string, result2 error) {
+	fake.deployDryRunMutex.Lock()
+	defer fake.deployDryRunMutex.Unlock()
+	fake.DeployDryRunStub = nil
+	fake.deployDryRunReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) DeployDryRunReturnsOnCall(i int, result1 string, result2 error) {
+	fake.deployDryRunMutex.Lock()
+	defer fake.deployDryRunMutex.Unlock()
+	fake.DeployDryRunStub = nil
+	if fake.deployDryRunReturnsOnCall == nil {
+		fake.deployDryRunReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.deployDryRunReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) DirectorManifest() (string, error) {
+	fake.directorManifestMutex.Lock()
+	ret, specificReturn := fake.directorManifestReturnsOnCall[len(fake.directorManifestArgsForCall)]
+	fake.directorManifestArgsForCall = append(fake.directorManifestArgsForCall, struct {
+	}{})
+	stub := fake.DirectorManifestStub
+	fakeReturns := fake.directorManifestReturns
+	fake.recordInvocation("DirectorManifest", []interface{}{})
+	fake.directorManifestMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeIClient) DirectorManifestCallCount() int {
+	fake.directorManifestMutex.RLock()
+	defer fake.directorManifestMutex.RUnlock()
+	return len(fake.directorManifestArgsForCall)
+}
+
+func (fake *FakeIClient) DirectorManifestCalls(stub func() (string, error)) {
+	fake.directorManifestMutex.Lock()
+	defer fake.directorManifestMutex.Unlock()
+	fake.DirectorManifestStub = stub
+}
+
+func (fake *FakeIClient) DirectorManifestReturns(result1 string, result2 error) {
+	fake.directorManifestMutex.Lock()
+	defer fake.directorManifestMutex.Unlock()
+	fake.DirectorManifestStub = nil
+	fake.directorManifestReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) DirectorManifestReturnsOnCall(i int, result1 string, result2 error) {
+	fake.directorManifestMutex.Lock()
+	defer fake.directorManifestMutex.Unlock()
+	fake.DirectorManifestStub = nil
+	if fake.directorManifestReturnsOnCall == nil {
+		fake.directorManifestReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.directorManifestReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) DumpDatabase(arg1 string, arg2 io.Writer) error {
+	fake.dumpDatabaseMutex.Lock()
+	ret, specificReturn := fake.dumpDatabaseReturnsOnCall[len(fake.dumpDatabaseArgsForCall)]
+	fake.dumpDatabaseArgsForCall = append(fake.dumpDatabaseArgsForCall, struct {
+		arg1 string
+		arg2 io.Writer
+	}{arg1, arg2})
+	stub := fake.DumpDatabaseStub
+	fakeReturns := fake.dumpDatabaseReturns
+	fake.recordInvocation("DumpDatabase", []interface{}{arg1, arg2})
+	fake.dumpDatabaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIClient) DumpDatabaseCallCount() int {
+	fake.dumpDatabaseMutex.RLock()
+	defer fake.dumpDatabaseMutex.RUnlock()
+	return len(fake.dumpDatabaseArgsForCall)
+}
+
+func (fake *FakeIClient) DumpDatabaseCalls(stub func(string, io.Writer) error) {
+	fake.dumpDatabaseMutex.Lock()
+	defer fake.dumpDatabaseMutex.Unlock()
+	fake.DumpDatabaseStub = stub
+}
+
+func (fake *FakeIClient) DumpDatabaseArgsForCall(i int) (string, io.Writer) {
+	fake.dumpDatabaseMutex.RLock()
+	defer fake.dumpDatabaseMutex.RUnlock()
+	argsForCall := fake.dumpDatabaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeIClient) DumpDatabaseReturns(result1 error) {
+	fake.dumpDatabaseMutex.Lock()
+	defer fake.dumpDatabaseMutex.Unlock()
+	fake.DumpDatabaseStub = nil
+	fake.dumpDatabaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) DumpDatabaseReturnsOnCall(i int, result1 error) {
+	fake.dumpDatabaseMutex.Lock()
+	defer fake.dumpDatabaseMutex.Unlock()
+	fake.DumpDatabaseStub = nil
+	if fake.dumpDatabaseReturnsOnCall == nil {
+		fake.dumpDatabaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.dumpDatabaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeIClient) Instances() ([]bosh.Instance, error) {
 	fake.instancesMutex.Lock()
 	ret, specificReturn := fake.instancesReturnsOnCall[len(fake.instancesArgsForCall)]
@@ -466,6 +733,227 @@ func (fake *FakeIClient) RecreateReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeIClient) RecreateUnresponsive() error {
+	fake.recreateUnresponsiveMutex.Lock()
+	ret, specificReturn := fake.recreateUnresponsiveReturnsOnCall[len(fake.recreateUnresponsiveArgsForCall)]
+	fake.recreateUnresponsiveArgsForCall = append(fake.recreateUnresponsiveArgsForCall, struct {
+	}{})
+	stub := fake.RecreateUnresponsiveStub
+	fakeReturns := fake.recreateUnresponsiveReturns
+	fake.recordInvocation("RecreateUnresponsive", []interface{}{})
+	fake.recreateUnresponsiveMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIClient) RecreateUnresponsiveCallCount() int {
+	fake.recreateUnresponsiveMutex.RLock()
+	defer fake.recreateUnresponsiveMutex.RUnlock()
+	return len(fake.recreateUnresponsiveArgsForCall)
+}
+
+func (fake *FakeIClient) RecreateUnresponsiveCalls(stub func() error) {
+	fake.recreateUnresponsiveMutex.Lock()
+	defer fake.recreateUnresponsiveMutex.Unlock()
+	fake.RecreateUnresponsiveStub = stub
+}
+
+func (fake *FakeIClient) RecreateUnresponsiveReturns(result1 error) {
+	fake.recreateUnresponsiveMutex.Lock()
+	defer fake.recreateUnresponsiveMutex.Unlock()
+	fake.RecreateUnresponsiveStub = nil
+	fake.recreateUnresponsiveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) RecreateUnresponsiveReturnsOnCall(i int, result1 error) {
+	fake.recreateUnresponsiveMutex.Lock()
+	defer fake.recreateUnresponsiveMutex.Unlock()
+	fake.RecreateUnresponsiveStub = nil
+	if fake.recreateUnresponsiveReturnsOnCall == nil {
+		fake.recreateUnresponsiveReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.recreateUnresponsiveReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) RestoreDatabase(arg1 string, arg2 io.Reader) error {
+	fake.restoreDatabaseMutex.Lock()
+	ret, specificReturn := fake.restoreDatabaseReturnsOnCall[len(fake.restoreDatabaseArgsForCall)]
+	fake.restoreDatabaseArgsForCall = append(fake.restoreDatabaseArgsForCall, struct {
+		arg1 string
+		arg2 io.Reader
+	}{arg1, arg2})
+	stub := fake.RestoreDatabaseStub
+	fakeReturns := fake.restoreDatabaseReturns
+	fake.recordInvocation("RestoreDatabase", []interface{}{arg1, arg2})
+	fake.restoreDatabaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIClient) RestoreDatabaseCallCount() int {
+	fake.restoreDatabaseMutex.RLock()
+	defer fake.restoreDatabaseMutex.RUnlock()
+	return len(fake.restoreDatabaseArgsForCall)
+}
+
+func (fake *FakeIClient) RestoreDatabaseCalls(stub func(string, io.Reader) error) {
+	fake.restoreDatabaseMutex.Lock()
+	defer fake.restoreDatabaseMutex.Unlock()
+	fake.RestoreDatabaseStub = stub
+}
+
+func (fake *FakeIClient) RestoreDatabaseArgsForCall(i int) (string, io.Reader) {
+	fake.restoreDatabaseMutex.RLock()
+	defer fake.restoreDatabaseMutex.RUnlock()
+	argsForCall := fake.restoreDatabaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeIClient) RestoreDatabaseReturns(result1 error) {
+	fake.restoreDatabaseMutex.Lock()
+	defer fake.restoreDatabaseMutex.Unlock()
+	fake.RestoreDatabaseStub = nil
+	fake.restoreDatabaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) RestoreDatabaseReturnsOnCall(i int, result1 error) {
+	fake.restoreDatabaseMutex.Lock()
+	defer fake.restoreDatabaseMutex.Unlock()
+	fake.RestoreDatabaseStub = nil
+	if fake.restoreDatabaseReturnsOnCall == nil {
+		fake.restoreDatabaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.restoreDatabaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) StartConcourseWeb() error {
+	fake.startConcourseWebMutex.Lock()
+	ret, specificReturn := fake.startConcourseWebReturnsOnCall[len(fake.startConcourseWebArgsForCall)]
+	fake.startConcourseWebArgsForCall = append(fake.startConcourseWebArgsForCall, struct {
+	}{})
+	stub := fake.StartConcourseWebStub
+	fakeReturns := fake.startConcourseWebReturns
+	fake.recordInvocation("StartConcourseWeb", []interface{}{})
+	fake.startConcourseWebMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIClient) StartConcourseWebCallCount() int {
+	fake.startConcourseWebMutex.RLock()
+	defer fake.startConcourseWebMutex.RUnlock()
+	return len(fake.startConcourseWebArgsForCall)
+}
+
+func (fake *FakeIClient) StartConcourseWebCalls(stub func() error) {
+	fake.startConcourseWebMutex.Lock()
+	defer fake.startConcourseWebMutex.Unlock()
+	fake.StartConcourseWebStub = stub
+}
+
+func (fake *FakeIClient) StartConcourseWebReturns(result1 error) {
+	fake.startConcourseWebMutex.Lock()
+	defer fake.startConcourseWebMutex.Unlock()
+	fake.StartConcourseWebStub = nil
+	fake.startConcourseWebReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) StartConcourseWebReturnsOnCall(i int, result1 error) {
+	fake.startConcourseWebMutex.Lock()
+	defer fake.startConcourseWebMutex.Unlock()
+	fake.StartConcourseWebStub = nil
+	if fake.startConcourseWebReturnsOnCall == nil {
+		fake.startConcourseWebReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.startConcourseWebReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) StopConcourseWeb() error {
+	fake.stopConcourseWebMutex.Lock()
+	ret, specificReturn := fake.stopConcourseWebReturnsOnCall[len(fake.stopConcourseWebArgsForCall)]
+	fake.stopConcourseWebArgsForCall = append(fake.stopConcourseWebArgsForCall, struct {
+	}{})
+	stub := fake.StopConcourseWebStub
+	fakeReturns := fake.stopConcourseWebReturns
+	fake.recordInvocation("StopConcourseWeb", []interface{}{})
+	fake.stopConcourseWebMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIClient) StopConcourseWebCallCount() int {
+	fake.stopConcourseWebMutex.RLock()
+	defer fake.stopConcourseWebMutex.RUnlock()
+	return len(fake.stopConcourseWebArgsForCall)
+}
+
+func (fake *FakeIClient) StopConcourseWebCalls(stub func() error) {
+	fake.stopConcourseWebMutex.Lock()
+	defer fake.stopConcourseWebMutex.Unlock()
+	fake.StopConcourseWebStub = stub
+}
+
+func (fake *FakeIClient) StopConcourseWebReturns(result1 error) {
+	fake.stopConcourseWebMutex.Lock()
+	defer fake.stopConcourseWebMutex.Unlock()
+	fake.StopConcourseWebStub = nil
+	fake.stopConcourseWebReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) StopConcourseWebReturnsOnCall(i int, result1 error) {
+	fake.stopConcourseWebMutex.Lock()
+	defer fake.stopConcourseWebMutex.Unlock()
+	fake.StopConcourseWebStub = nil
+	if fake.stopConcourseWebReturnsOnCall == nil {
+		fake.stopConcourseWebReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.stopConcourseWebReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeIClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -475,12 +963,26 @@ func (fake *FakeIClient) Invocations() map[string][][]interface{} {
 	defer fake.createEnvMutex.RUnlock()
 	fake.deployMutex.RLock()
 	defer fake.deployMutex.RUnlock()
+	fake.deployDryRunMutex.RLock()
+	defer fake.deployDryRunMutex.RUnlock()
+	fake.directorManifestMutex.RLock()
+	defer fake.directorManifestMutex.RUnlock()
+	fake.dumpDatabaseMutex.RLock()
+	defer fake.dumpDatabaseMutex.RUnlock()
 	fake.instancesMutex.RLock()
 	defer fake.instancesMutex.RUnlock()
 	fake.locksMutex.RLock()
 	defer fake.locksMutex.RUnlock()
 	fake.recreateMutex.RLock()
 	defer fake.recreateMutex.RUnlock()
+	fake.recreateUnresponsiveMutex.RLock()
+	defer fake.recreateUnresponsiveMutex.RUnlock()
+	fake.restoreDatabaseMutex.RLock()
+	defer fake.restoreDatabaseMutex.RUnlock()
+	fake.startConcourseWebMutex.RLock()
+	defer fake.startConcourseWebMutex.RUnlock()
+	fake.stopConcourseWebMutex.RLock()
+	defer fake.stopConcourseWebMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os/exec"
 
 	"github.com/EngineerBetter/control-tower/iaas"
 
@@ -14,6 +13,7 @@ import (
 	"github.com/EngineerBetter/control-tower/bosh/internal/boshcli"
 	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir"
 	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/util"
 )
 
@@ -32,8 +32,15 @@ type IClient interface {
 	Cleanup() error
 	Instances() ([]Instance, error)
 	CreateEnv([]byte, []byte, string) ([]byte, []byte, error)
+	DirectorManifest() (string, error)
+	DeployDryRun([]byte) (string, error)
 	Recreate() error
+	RecreateUnresponsive() error
 	Locks() ([]byte, error)
+	DumpDatabase(string, io.Writer) error
+	RestoreDatabase(string, io.Reader) error
+	StopConcourseWeb() error
+	StartConcourseWeb() error
 }
 
 // Instance represents a vm deployed by BOSH
@@ -44,10 +51,10 @@ type Instance struct {
 }
 
 // ClientFactory creates a new IClient
-type ClientFactory func(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.Writer, provider iaas.Provider, versionFile []byte) (IClient, error)
+type ClientFactory func(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.Writer, recorder events.Recorder, provider iaas.Provider, versionFile []byte) (IClient, error)
 
 //New returns an IAAS specific implementation of BOSH client
-func New(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.Writer, provider iaas.Provider, versionFile []byte) (IClient, error) {
+func New(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.Writer, recorder events.Recorder, provider iaas.Provider, versionFile []byte) (IClient, error) {
 	workingdir, err := workingdir.New()
 	if err != nil {
 		return nil, err
@@ -65,13 +72,15 @@ func New(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.
 		return nil, fmt.Errorf("failed to determine BOSH CLI path: [%v]", err)
 	}
 
-	boshCLI := boshcli.New(boshCLIPath, exec.Command)
+	boshCLI := boshcli.New(boshCLIPath, directorCommand)
 
 	switch provider.IAAS() {
 	case iaas.AWS:
-		return NewAWSClient(config, outputs, workingdir, stdout, stderr, provider, boshCLI, versionFile)
+		return NewAWSClient(config, outputs, workingdir, stdout, stderr, recorder, provider, boshCLI, versionFile)
 	case iaas.GCP:
-		return NewGCPClient(config, outputs, workingdir, stdout, stderr, provider, boshCLI, versionFile)
+		return NewGCPClient(config, outputs, workingdir, stdout, stderr, recorder, provider, boshCLI, versionFile)
+	case iaas.Azure:
+		return NewAzureClient(config, outputs, workingdir, stdout, stderr, recorder, provider, boshCLI, versionFile)
 	}
 	return nil, fmt.Errorf("IAAS not supported: %s", provider.IAAS())
 }
@@ -122,12 +131,14 @@ func instances(boshCLI boshcli.ICLI, ip, password, ca string) ([]Instance, error
 
 func saveFilesToWorkingDir(workingdir workingdir.IClient, provider iaas.Provider, creds []byte) error {
 	concourseVersionsContents, _ := provider.Choose(iaas.Choice{
-		AWS: awsConcourseVersions,
-		GCP: gcpConcourseVersions,
+		AWS:   awsConcourseVersions,
+		GCP:   gcpConcourseVersions,
+		Azure: azureConcourseVersions,
 	}).([]byte)
 	concourseSHAsContents, _ := provider.Choose(iaas.Choice{
-		AWS: awsConcourseSHAs,
-		GCP: gcpConcourseSHAs,
+		AWS:   awsConcourseSHAs,
+		GCP:   gcpConcourseSHAs,
+		Azure: azureConcourseSHAs,
 	}).([]byte)
 
 	filesToSave := map[string][]byte{
@@ -138,7 +149,13 @@ func saveFilesToWorkingDir(workingdir workingdir.IClient, provider iaas.Provider
 		concourseBitBucketAuthFilename:    concourseBitBucketAuth,
 		concourseGitHubAuthFilename:       concourseGitHubAuth,
 		concourseMicrosoftAuthFilename:    concourseMicrosoftAuth,
+		concourseOIDCAuthFilename:         concourseOIDCAuth,
+		concourseLDAPAuthFilename:         concourseLDAPAuth,
+		concourseGitLabAuthFilename:       concourseGitLabAuth,
 		concourseEphemeralWorkersFilename: concourseEphemeralWorkers,
+		concourseHibernateFilename:        concourseHibernate,
+		concoursePrivateWebFilename:       concoursePrivateWeb,
+		concourseWebLBFilename:            concourseWebLB,
 		credsFilename:                     creds,
 		extraTagsFilename:                 extraTags,
 	}
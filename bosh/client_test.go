@@ -3,11 +3,14 @@ package bosh_test
 import (
 	"errors"
 	"io"
+	"io/ioutil"
+	"os"
 
 	"github.com/EngineerBetter/control-tower/bosh"
 	"github.com/EngineerBetter/control-tower/bosh/internal/boshcli/boshclifakes"
 	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir/workingdirfakes"
 	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/iaas"
 	"github.com/EngineerBetter/control-tower/iaas/iaasfakes"
 	"github.com/EngineerBetter/control-tower/terraform/terraformfakes"
@@ -139,7 +142,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 				})
 
 				It("returns an AWSClient", func() {
-					client, err := bosh.New(configInput, terraformOutputs, stdout, stderr, provider, versionFile)
+					client, err := bosh.New(configInput, terraformOutputs, stdout, stderr, events.Discard, provider, versionFile)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(client).To(BeAssignableToTypeOf(&bosh.AWSClient{}))
 				})
@@ -151,7 +154,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 				})
 
 				It("returns an appropriate error", func() {
-					_, err := bosh.New(configInput, terraformOutputs, stdout, stderr, provider, versionFile)
+					_, err := bosh.New(configInput, terraformOutputs, stdout, stderr, events.Discard, provider, versionFile)
 					Expect(err.Error()).To(HavePrefix("failed to determine BOSH CLI path:"))
 				})
 			})
@@ -178,7 +181,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 				})
 
 				It("returns an AWSClient", func() {
-					client, err := bosh.New(configInput, terraformOutputs, stdout, stderr, provider, versionFile)
+					client, err := bosh.New(configInput, terraformOutputs, stdout, stderr, events.Discard, provider, versionFile)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(client).To(BeAssignableToTypeOf(&bosh.GCPClient{}))
 				})
@@ -190,7 +193,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 				})
 
 				It("returns an appropriate error", func() {
-					_, err := bosh.New(configInput, terraformOutputs, stdout, stderr, provider, versionFile)
+					_, err := bosh.New(configInput, terraformOutputs, stdout, stderr, events.Discard, provider, versionFile)
 					Expect(err.Error()).To(HavePrefix("failed to determine BOSH CLI path:"))
 				})
 			})
@@ -213,7 +216,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 			})
 
 			It("returns an appropriate error", func() {
-				_, err := bosh.New(configInput, terraformOutputs, stdout, stderr, provider, versionFile)
+				_, err := bosh.New(configInput, terraformOutputs, stdout, stderr, events.Discard, provider, versionFile)
 				Expect(err.Error()).To(HavePrefix("IAAS not supported: Unknown"))
 			})
 		})
@@ -232,7 +235,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 				stderr = gbytes.NewBuffer()
 
 				buildClient = func() bosh.IClient {
-					client, err := bosh.NewAWSClient(configInput, terraformOutputs, directorClient, stdout, stderr, provider, boshCLI, versionFile)
+					client, err := bosh.NewAWSClient(configInput, terraformOutputs, directorClient, stdout, stderr, events.Discard, provider, boshCLI, versionFile)
 					Expect(err).ToNot(HaveOccurred())
 					return client
 				}
@@ -260,4 +263,56 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 			})
 		})
 	})
+
+	Describe("DeployDryRun", func() {
+		Context("When on AWS", func() {
+			var credsFile string
+
+			JustBeforeEach(func() {
+				boshCLI = &boshclifakes.FakeICLI{}
+				directorClient = &workingdirfakes.FakeIClient{}
+				terraformOutputs = &terraformfakes.FakeOutputs{}
+				provider = setupFakeAwsProvider()
+				versionFile = []byte("{}")
+				configInput.PublicCIDR = "10.0.0.0/24"
+
+				f, err := ioutil.TempFile("", "creds")
+				Expect(err).ToNot(HaveOccurred())
+				credsFile = f.Name()
+				Expect(f.Close()).To(Succeed())
+				directorClient.PathInWorkingDirReturns(credsFile)
+
+				stdout = gbytes.NewBuffer()
+				stderr = gbytes.NewBuffer()
+
+				boshCLI.RunAuthenticatedCommandStub = func(action, ip, password, ca string, detach bool, stdout io.Writer, flags ...string) error {
+					stdout.Write([]byte("  worker_count: 1 -> 2\n"))
+					return nil
+				}
+
+				buildClient = func() bosh.IClient {
+					client, err := bosh.NewAWSClient(configInput, terraformOutputs, directorClient, stdout, stderr, events.Discard, provider, boshCLI, versionFile)
+					Expect(err).ToNot(HaveOccurred())
+					return client
+				}
+			})
+
+			AfterEach(func() {
+				os.Remove(credsFile)
+			})
+
+			It("runs bosh deploy with --dry-run and returns its output", func() {
+				client := buildClient()
+				diff, err := client.DeployDryRun([]byte("creds"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diff).To(ContainSubstring("worker_count: 1 -> 2"))
+
+				Expect(boshCLI.RunAuthenticatedCommandCallCount()).To(Equal(1))
+				action, _, _, _, detach, _, flags := boshCLI.RunAuthenticatedCommandArgsForCall(0)
+				Expect(action).To(Equal("deploy"))
+				Expect(detach).To(BeFalse())
+				Expect(flags).To(ContainElement("--dry-run"))
+			})
+		})
+	})
 })
@@ -0,0 +1,48 @@
+package bosh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir"
+	"github.com/EngineerBetter/control-tower/config"
+)
+
+// customFlags saves the user's ops files and vars files for the Concourse deployment to the working
+// directory, returning the bosh deploy flags that apply them. They are expected to come after
+// control-tower's own ops files, so that the user's ops files can change anything in the manifest.
+func customFlags(workingdir workingdir.IClient, c config.ConfigView) ([]string, error) {
+	var flags []string
+	for i, file := range c.GetConcourseOpsFiles() {
+		path, err := workingdir.SaveFileToWorkingDir(fmt.Sprintf("custom-ops-%d.yml", i), []byte(file.Contents))
+		if err != nil {
+			return nil, fmt.Errorf("failed to save ops file %s to working directory: [%v]", file.Name, err)
+		}
+		flags = append(flags, "--ops-file", path)
+	}
+	for i, file := range c.GetVarsFiles() {
+		path, err := workingdir.SaveFileToWorkingDir(fmt.Sprintf("custom-vars-%d.yml", i), []byte(file.Contents))
+		if err != nil {
+			return nil, fmt.Errorf("failed to save vars file %s to working directory: [%v]", file.Name, err)
+		}
+		flags = append(flags, "--vars-file", path)
+	}
+	return flags, nil
+}
+
+// directorCustomizations returns the user's ops files for the director manifest as one, following
+// customOps, and the variables of their vars files
+func directorCustomizations(c config.ConfigView, customOps string) (string, map[string]interface{}, error) {
+	ops, err := config.ConcatOpsFiles(c.GetDirectorOpsFiles())
+	if err != nil {
+		return "", nil, err
+	}
+	vars, err := config.MergeVarsFiles(c.GetVarsFiles())
+	if err != nil {
+		return "", nil, err
+	}
+	if customOps != "" && !strings.HasSuffix(customOps, "\n") {
+		customOps += "\n"
+	}
+	return customOps + ops, vars, nil
+}
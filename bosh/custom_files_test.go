@@ -0,0 +1,70 @@
+package bosh
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir/workingdirfakes"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/util/yaml"
+)
+
+func TestCustomFlags(t *testing.T) {
+	workingdir := &workingdirfakes.FakeIClient{}
+	workingdir.SaveFileToWorkingDirStub = func(filename string, contents []byte) (string, error) {
+		return "/working/" + filename, nil
+	}
+
+	flags, err := customFlags(workingdir, config.Config{})
+	if err != nil || flags != nil {
+		t.Errorf("customFlags() with no files = %v, %v", flags, err)
+	}
+
+	conf := config.Config{
+		ConcourseOpsFiles: []config.ManifestFile{{Name: "garden.yml", Contents: "garden"}, {Name: "syslog.yml", Contents: "syslog"}},
+		VarsFiles:         []config.ManifestFile{{Name: "vars.yml", Contents: "vars"}},
+	}
+	flags, err = customFlags(workingdir, conf)
+	if err != nil {
+		t.Fatalf("customFlags() error = %v", err)
+	}
+	want := []string{
+		"--ops-file", "/working/custom-ops-0.yml",
+		"--ops-file", "/working/custom-ops-1.yml",
+		"--vars-file", "/working/custom-vars-0.yml",
+	}
+	if !reflect.DeepEqual(flags, want) {
+		t.Errorf("customFlags() = %v, want %v", flags, want)
+	}
+	if _, contents := workingdir.SaveFileToWorkingDirArgsForCall(1); string(contents) != "syslog" {
+		t.Errorf("customFlags() saved %q as the second ops file, want %q", contents, "syslog")
+	}
+}
+
+func TestDirectorCustomizations(t *testing.T) {
+	conf := config.Config{
+		DirectorOpsFiles: []config.ManifestFile{
+			{Name: "first.yml", Contents: "---\n- type: replace\n  path: /first?\n  value: ((first))\n"},
+			{Name: "second.yml", Contents: "- type: replace\n  path: /second?\n  value: ((second))"},
+		},
+		VarsFiles: []config.ManifestFile{
+			{Name: "a.yml", Contents: "first: a\nsecond: a\n"},
+			{Name: "b.yml", Contents: "second: b\n"},
+		},
+	}
+	ops, vars, err := directorCustomizations(conf, "- type: replace\n  path: /maintenance?\n  value: true")
+	if err != nil {
+		t.Fatalf("directorCustomizations() error = %v", err)
+	}
+
+	got, err := yaml.Interpolate("name: bosh\n", ops, vars)
+	if err != nil {
+		t.Fatalf("interpolating the director ops files error = %v", err)
+	}
+	for _, want := range []string{"first: a", "second: b", "maintenance: true"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("directorCustomizations() rendered\n%s\nwhich does not contain %q", got, want)
+		}
+	}
+}
@@ -17,7 +17,14 @@ const (
 	concourseBitBucketAuthFilename    = "bitbucket-auth.yml"
 	concourseGitHubAuthFilename       = "github-auth.yml"
 	concourseMicrosoftAuthFilename    = "microsoft-auth.yml"
+	concourseOIDCAuthFilename         = "oidc-auth.yml"
+	concourseLDAPAuthFilename         = "ldap-auth.yml"
+	concourseGitLabAuthFilename       = "gitlab-auth.yml"
+	concourseAuthVarsFilename         = "auth-vars.yml"
 	concourseEphemeralWorkersFilename = "ephemeral_workers.yml"
+	concourseHibernateFilename        = "hibernate.yml"
+	concoursePrivateWebFilename       = "private-web.yml"
+	concourseWebLBFilename            = "web-lb.yml"
 	extraTagsFilename                 = "extra_tags.yml"
 	uaaCertFilename                   = "uaa-cert.yml"
 )
@@ -35,9 +42,27 @@ var (
 	//go:embed assets/ops/microsoft-auth.yml
 	concourseMicrosoftAuth []byte
 
+	//go:embed assets/ops/oidc-auth.yml
+	concourseOIDCAuth []byte
+
+	//go:embed assets/ops/ldap-auth.yml
+	concourseLDAPAuth []byte
+
+	//go:embed assets/ops/gitlab-auth.yml
+	concourseGitLabAuth []byte
+
 	//go:embed assets/ops/ephemeral_workers.yml
 	concourseEphemeralWorkers []byte
 
+	//go:embed assets/ops/hibernate.yml
+	concourseHibernate []byte
+
+	//go:embed assets/ops/private-web.yml
+	concoursePrivateWeb []byte
+
+	//go:embed assets/ops/web-lb.yml
+	concourseWebLB []byte
+
 	//go:embed assets/ops/extra_tags.yml
 	extraTags []byte
 
@@ -46,5 +71,7 @@ var (
 	awsConcourseSHAs          = opsassets.AwsConcourseSHAs
 	gcpConcourseVersions      = opsassets.GcpConcourseVersions
 	gcpConcourseSHAs          = opsassets.GcpConcourseSHAs
+	azureConcourseVersions    = opsassets.AzureConcourseVersions
+	azureConcourseSHAs        = opsassets.AzureConcourseSHAs
 	uaaCert                   = resource.UAACert
 )
@@ -0,0 +1,104 @@
+package bosh
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+// DirectorProxy is the proxy that connections to the director go through, for directors that cannot be
+// reached directly. It takes the same form as BOSH_ALL_PROXY: socks5://host:port for a SOCKS5 proxy, or
+// ssh+socks5://user@host:port?private-key=path for an SSH jump host.
+var DirectorProxy string
+
+// directorCommand builds BOSH CLI commands that reach the director through DirectorProxy
+func directorCommand(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	if DirectorProxy != "" {
+		cmd.Env = append(os.Environ(), "BOSH_ALL_PROXY="+DirectorProxy)
+	}
+	return cmd
+}
+
+// dialSSH connects to the SSH server at addr, going through DirectorProxy if it is set
+func dialSSH(addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	if DirectorProxy == "" {
+		return ssh.Dial("tcp", addr, config)
+	}
+
+	dialer, closer, err := proxyDialer(DirectorProxy)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialer.Dial("tcp", addr)
+	if err != nil {
+		closer.Close()
+		return nil, fmt.Errorf("failed to reach %s through the director proxy: [%v]", addr, err)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		closer.Close()
+		return nil, err
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	go func() {
+		client.Wait()
+		closer.Close()
+	}()
+	return client, nil
+}
+
+// proxyDialer connects to a proxy given in the BOSH_ALL_PROXY format, returning a dialer that goes
+// through it and a closer that disconnects from it
+func proxyDialer(proxyURL string) (proxy.Dialer, io.Closer, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse director proxy: [%v]", err)
+	}
+
+	switch u.Scheme {
+	case "socks5":
+		var auth *proxy.Auth
+		if u.User != nil {
+			password, _ := u.User.Password()
+			auth = &proxy.Auth{User: u.User.Username(), Password: password}
+		}
+		dialer, err := proxy.SOCKS5("tcp", u.Host, auth, proxy.Direct)
+		if err != nil {
+			return nil, nil, err
+		}
+		return dialer, ioutil.NopCloser(nil), nil
+	case "ssh+socks5":
+		keyPath := u.Query().Get("private-key")
+		if u.User == nil || keyPath == "" {
+			return nil, nil, fmt.Errorf("director proxy %s must name a user and a private-key, eg ssh+socks5://user@host:22?private-key=path", u.Host)
+		}
+		key, err := ioutil.ReadFile(keyPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read private key for director proxy: [%v]", err)
+		}
+		config, err := sshGatewayConfig(u.User.Username(), string(key))
+		if err != nil {
+			return nil, nil, err
+		}
+		addr := u.Host
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "22")
+		}
+		jumpHost, err := ssh.Dial("tcp", addr, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to director proxy %s: [%v]", addr, err)
+		}
+		return jumpHost, jumpHost, nil
+	default:
+		return nil, nil, fmt.Errorf("director proxy %q is not supported: must start with socks5:// or ssh+socks5://", u.Scheme)
+	}
+}
@@ -0,0 +1,44 @@
+package bosh
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDirectorCommand(t *testing.T) {
+	defer func() { DirectorProxy = "" }()
+
+	if cmd := directorCommand("bosh", "instances"); cmd.Env != nil {
+		t.Errorf("directorCommand() set the environment without a proxy")
+	}
+
+	DirectorProxy = "socks5://localhost:1080"
+	cmd := directorCommand("bosh", "instances")
+	if len(cmd.Env) != len(os.Environ())+1 || cmd.Env[len(cmd.Env)-1] != "BOSH_ALL_PROXY=socks5://localhost:1080" {
+		t.Errorf("directorCommand() env = %v, want BOSH_ALL_PROXY added", cmd.Env)
+	}
+}
+
+func TestProxyDialer(t *testing.T) {
+	if _, _, err := proxyDialer("socks5://localhost:1080"); err != nil {
+		t.Errorf("proxyDialer() error = %v", err)
+	}
+
+	tests := []struct {
+		proxyURL string
+		wantErr  string
+	}{
+		{proxyURL: "http://proxy:3128", wantErr: `director proxy "http" is not supported`},
+		{proxyURL: "ssh+socks5://jumpbox:22", wantErr: "must name a user and a private-key"},
+		{proxyURL: "ssh+socks5://vcap@jumpbox:22?private-key=/does/not/exist", wantErr: "failed to read private key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.proxyURL, func(t *testing.T) {
+			_, _, err := proxyDialer(tt.proxyURL)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("proxyDialer() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/EngineerBetter/control-tower/bosh/internal/boshcli"
 	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir"
 	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/iaas"
 	"github.com/EngineerBetter/control-tower/terraform"
 )
@@ -17,21 +18,23 @@ type GCPClient struct {
 	workingdir  workingdir.IClient
 	stdout      io.Writer
 	stderr      io.Writer
+	events      events.Recorder
 	provider    iaas.Provider
 	boshCLI     boshcli.ICLI
 	versionFile []byte
 }
 
 //NewGCPClient returns a GCP specific implementation of IClient
-func NewGCPClient(config config.ConfigView, outputs terraform.Outputs, workingdir workingdir.IClient, stdout, stderr io.Writer, provider iaas.Provider, boshCLI boshcli.ICLI, versionFile []byte) (IClient, error) {
+func NewGCPClient(config config.ConfigView, outputs terraform.Outputs, workingdir workingdir.IClient, stdout, stderr io.Writer, recorder events.Recorder, provider iaas.Provider, boshCLI boshcli.ICLI, versionFile []byte) (IClient, error) {
 	return &GCPClient{
-		config:     config,
-		outputs:    outputs,
-		workingdir: workingdir,
-		stdout:     stdout,
-		stderr:     stderr,
-		provider:   provider,
-		boshCLI:    boshCLI,
+		config:      config,
+		outputs:     outputs,
+		workingdir:  workingdir,
+		stdout:      stdout,
+		stderr:      stderr,
+		events:      recorder,
+		provider:    provider,
+		boshCLI:     boshCLI,
 		versionFile: versionFile,
 	}, nil
 }
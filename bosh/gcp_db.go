@@ -1,5 +1,54 @@
 package bosh
 
+import (
+	"fmt"
+	"io"
+	"net"
+)
+
 func (client *GCPClient) createDefaultDatabases() error {
 	return client.provider.CreateDatabases(client.config.GetRDSDefaultDatabaseName(), client.config.GetRDSUsername(), client.config.GetRDSPassword())
 }
+
+// Cloud SQL only accepts connections from the director, NAT and ATC addresses, so the
+// connection goes through the director's jumpbox user
+func (client *GCPClient) dbConnection() (dbConnection, error) {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return dbConnection{}, fmt.Errorf("failed to get DirectorPublicIP from terraform outputs: [%v]", err)
+	}
+	boshDBAddress, err := client.outputs.Get("BoshDBAddress")
+	if err != nil {
+		return dbConnection{}, fmt.Errorf("failed to get BoshDBAddress from terraform outputs: [%v]", err)
+	}
+	gatewayConfig, err := sshGatewayConfig("jumpbox", client.config.GetPrivateKey())
+	if err != nil {
+		return dbConnection{}, err
+	}
+	return dbConnection{
+		gatewayAddr:   net.JoinHostPort(directorPublicIP, "22"),
+		gatewayConfig: gatewayConfig,
+		host:          boshDBAddress,
+		port:          "5432",
+		username:      client.config.GetRDSUsername(),
+		password:      client.config.GetRDSPassword(),
+	}, nil
+}
+
+// DumpDatabase writes a pg_dump archive of the named database to w
+func (client *GCPClient) DumpDatabase(name string, w io.Writer) error {
+	conn, err := client.dbConnection()
+	if err != nil {
+		return err
+	}
+	return dumpDatabase(conn, name, w, client.stderr)
+}
+
+// RestoreDatabase replaces the contents of the named database with the pg_dump archive read from r
+func (client *GCPClient) RestoreDatabase(name string, r io.Reader) error {
+	conn, err := client.dbConnection()
+	if err != nil {
+		return err
+	}
+	return restoreDatabase(conn, name, r, client.stdout, client.stderr)
+}
@@ -1,112 +1,166 @@
 package bosh
 
 import (
+	"bytes"
 	"net"
+	"os"
 
 	"github.com/EngineerBetter/control-tower/bosh/internal/boshcli"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/apparentlymart/go-cidr/cidr"
 )
 
 // Deploy deploys a new Bosh director or converges an existing deployment
 // Returns new contents of bosh state file
 func (client *GCPClient) Deploy(state, creds []byte, detach bool) (newState, newCreds []byte, err error) {
+	err = client.events.Phase(events.CreateEnv, func(r events.Resources) error {
+		r["director_ip"] = client.config.GetDirectorPublicIP()
+		state, creds, err = client.CreateEnv(state, creds, "")
+		return err
+	})
 	if err != nil {
 		return state, creds, err
 	}
 
-	state, creds, err = client.CreateEnv(state, creds, "")
+	err = client.events.Phase(events.CloudConfig, func(events.Resources) error {
+		return client.updateCloudConfig(client.boshCLI)
+	})
 	if err != nil {
 		return state, creds, err
 	}
-
-	if err = client.updateCloudConfig(client.boshCLI); err != nil {
-		return state, creds, err
-	}
-	if err = client.uploadConcourseStemcell(client.boshCLI); err != nil {
+	err = client.events.Phase(events.StemcellUpload, func(events.Resources) error {
+		return client.uploadConcourseStemcell(client.boshCLI)
+	})
+	if err != nil {
 		return state, creds, err
 	}
 	if err = client.createDefaultDatabases(); err != nil {
 		return state, creds, err
 	}
 
-	creds, err = client.deployConcourse(creds, detach)
+	err = client.events.Phase(events.BoshDeploy, func(r events.Resources) error {
+		r["deployment"] = concourseDeploymentName
+		creds, err = client.deployConcourse(creds, detach, os.Stdout)
+		return err
+	})
+	return state, creds, err
+}
+
+// DeployDryRun runs bosh deploy --dry-run for Concourse and returns the output describing its changes
+func (client *GCPClient) DeployDryRun(creds []byte) (string, error) {
+	output := new(bytes.Buffer)
+	if _, err := client.deployConcourse(creds, false, output, "--dry-run"); err != nil {
+		return output.String(), err
+	}
+	return output.String(), nil
+}
+
+// CreateEnv exposes bosh create-env functionality
+func (client *GCPClient) CreateEnv(state, creds []byte, customOps string) (newState, newCreds []byte, err error) {
+	environment, tags, err := client.directorEnvironment(customOps)
 	if err != nil {
 		return state, creds, err
 	}
 
-	return state, creds, err
+	createEnvFiles, err1 := client.boshCLI.CreateEnv(&boshcli.CreateEnvFiles{StateFileContents: state, VarsFileContents: creds}, environment, client.config.GetDirectorPassword(), client.config.GetDirectorCert(), client.config.GetDirectorKey(), client.config.GetDirectorCACert(), tags)
+	if err1 != nil {
+		return createEnvFiles.StateFileContents, createEnvFiles.VarsFileContents, err1
+	}
+	return createEnvFiles.StateFileContents, createEnvFiles.VarsFileContents, err
 }
 
-// CreateEnv exposes bosh create-env functionality
-func (client *GCPClient) CreateEnv(state, creds []byte, customOps string) (newState, newCreds []byte, err error) {
+// DirectorManifest renders the manifest create-env would use without deploying it
+func (client *GCPClient) DirectorManifest() (string, error) {
+	environment, tags, err := client.directorEnvironment("")
+	if err != nil {
+		return "", err
+	}
+
+	return client.boshCLI.DirectorManifest(environment, client.config.GetDirectorPassword(), client.config.GetDirectorCert(), client.config.GetDirectorKey(), client.config.GetDirectorCACert(), tags)
+}
+
+func (client *GCPClient) directorEnvironment(customOps string) (boshcli.GCPEnvironment, map[string]string, error) {
 	tags, err := splitTags(client.config.GetTags())
 	if err != nil {
-		return state, creds, err
+		return boshcli.GCPEnvironment{}, nil, err
 	}
 	tags["control-tower-project"] = client.config.GetProject()
 	tags["control-tower-component"] = "concourse"
 
+	customOps, customVars, err := directorCustomizations(client.config, customOps)
+	if err != nil {
+		return boshcli.GCPEnvironment{}, nil, err
+	}
+
 	network, err1 := client.outputs.Get("Network")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.GCPEnvironment{}, nil, err1
 	}
 	publicSubnetwork, err1 := client.outputs.Get("PublicSubnetworkName")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.GCPEnvironment{}, nil, err1
 	}
 	privateSubnetwork, err1 := client.outputs.Get("PrivateSubnetworkName")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.GCPEnvironment{}, nil, err1
 	}
 	directorPublicIP, err1 := client.outputs.Get("DirectorPublicIP")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.GCPEnvironment{}, nil, err1
 	}
 	project, err1 := client.provider.Attr("project")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.GCPEnvironment{}, nil, err1
 	}
 	credentialsPath, err1 := client.provider.Attr("credentials_path")
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.GCPEnvironment{}, nil, err1
+	}
+	// With instance identity the director's VM runs as the bosh service account, and the operator's
+	// credentials are only used by create-env
+	var directorServiceAccount string
+	if client.config.UsesInstanceIdentity() {
+		directorServiceAccount, err1 = client.outputs.Get("DirectorServiceAccount")
+		if err1 != nil {
+			return boshcli.GCPEnvironment{}, nil, err1
+		}
 	}
 
 	publicCIDR := client.config.GetPublicCIDR()
 	_, pubCIDR, err1 := net.ParseCIDR(publicCIDR)
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.GCPEnvironment{}, nil, err1
 	}
 	internalGateway, err1 := cidr.Host(pubCIDR, 1)
 	if err1 != nil {
-		return state, creds, err1
+		return boshcli.GCPEnvironment{}, nil, err1
 	}
 	directorInternalIP, err1 := cidr.Host(pubCIDR, 6)
 	if err1 != nil {
-		return state, creds, err1
-	}
-
-	createEnvFiles, err1 := client.boshCLI.CreateEnv(&boshcli.CreateEnvFiles{StateFileContents: state, VarsFileContents: creds}, boshcli.GCPEnvironment{
-		InternalCIDR:       client.config.GetPublicCIDR(),
-		InternalGW:         internalGateway.String(),
-		InternalIP:         directorInternalIP.String(),
-		DirectorName:       "bosh",
-		Zone:               client.provider.Zone("", ""),
-		Network:            network,
-		PublicSubnetwork:   publicSubnetwork,
-		PrivateSubnetwork:  privateSubnetwork,
-		Tags:               "[internal]",
-		ProjectID:          project,
-		GcpCredentialsJSON: credentialsPath,
-		ExternalIP:         directorPublicIP,
-		Spot:               client.config.IsSpot(),
-		PublicKey:          client.config.GetPublicKey(),
-		CustomOperations:   customOps,
-		VersionFile:        client.versionFile,
-	}, client.config.GetDirectorPassword(), client.config.GetDirectorCert(), client.config.GetDirectorKey(), client.config.GetDirectorCACert(), tags)
-	if err1 != nil {
-		return createEnvFiles.StateFileContents, createEnvFiles.VarsFileContents, err1
+		return boshcli.GCPEnvironment{}, nil, err1
 	}
-	return createEnvFiles.StateFileContents, createEnvFiles.VarsFileContents, err
+
+	return boshcli.GCPEnvironment{
+		InternalCIDR:           client.config.GetPublicCIDR(),
+		InternalGW:             internalGateway.String(),
+		InternalIP:             directorInternalIP.String(),
+		DirectorName:           "bosh",
+		Zone:                   client.provider.Zone("", ""),
+		Network:                network,
+		PublicSubnetwork:       publicSubnetwork,
+		PrivateSubnetwork:      privateSubnetwork,
+		Tags:                   "[internal]",
+		ProjectID:              project,
+		GcpCredentialsJSON:     credentialsPath,
+		DirectorServiceAccount: directorServiceAccount,
+		ExternalIP:             directorPublicIP,
+		Spot:                   client.config.IsSpot(),
+		PublicKey:              client.config.GetPublicKey(),
+		CustomOperations:       customOps,
+		CustomVars:             customVars,
+		VersionFile:            client.versionFile,
+	}, tags, nil
 }
 
 // Recreate exposes BOSH recreate
@@ -192,19 +246,34 @@ func (client *GCPClient) updateCloudConfig(bosh boshcli.ICLI) error {
 		return err
 	}
 
+	var webZone, webTargetPool string
+	if client.config.GetConcourseWebCount() > 1 {
+		webZone, err = client.outputs.Get("WebZone")
+		if err != nil {
+			return err
+		}
+		webTargetPool, err = client.outputs.Get("WebTargetPool")
+		if err != nil {
+			return err
+		}
+	}
+
 	return bosh.UpdateCloudConfig(boshcli.GCPEnvironment{
-		PublicCIDR:          client.config.GetPublicCIDR(),
-		PublicCIDRGateway:   publicCIDRGateway,
-		PublicCIDRStatic:    publicCIDRStatic,
-		PublicCIDRReserved:  publicCIDRReserved,
-		PrivateCIDRGateway:  privateCIDRGateway,
-		PrivateCIDRReserved: privateCIDRReserved,
-		PrivateCIDR:         client.config.GetPrivateCIDR(),
-		Spot:                client.config.IsSpot(),
-		PublicSubnetwork:    publicSubnetwork,
-		PrivateSubnetwork:   privateSubnetwork,
-		Zone:                zone,
-		Network:             network,
+		PublicCIDR:                  client.config.GetPublicCIDR(),
+		PublicCIDRGateway:           publicCIDRGateway,
+		PublicCIDRStatic:            publicCIDRStatic,
+		PublicCIDRReserved:          publicCIDRReserved,
+		PrivateCIDRGateway:          privateCIDRGateway,
+		PrivateCIDRReserved:         privateCIDRReserved,
+		PrivateCIDR:                 client.config.GetPrivateCIDR(),
+		Spot:                        client.config.IsSpot(),
+		WorkerPoolProvisioningTypes: config.WorkerPoolProvisioningTypes(client.config.GetWorkerPools()),
+		PublicSubnetwork:            publicSubnetwork,
+		PrivateSubnetwork:           privateSubnetwork,
+		Zone:                        zone,
+		Network:                     network,
+		WebTargetPool:               webTargetPool,
+		WebZone:                     webZone,
 	}, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert())
 }
 func (client *GCPClient) uploadConcourseStemcell(bosh boshcli.ICLI) error {
@@ -216,3 +285,30 @@ func (client *GCPClient) uploadConcourseStemcell(bosh boshcli.ICLI) error {
 		ExternalIP: directorPublicIP,
 	}, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert())
 }
+
+// StopConcourseWeb stops the jobs on the web instances, including CredHub and UAA
+func (client *GCPClient) StopConcourseWeb() error {
+	return client.setConcourseWebState("stop")
+}
+
+// StartConcourseWeb starts the jobs on the web instances again
+func (client *GCPClient) StartConcourseWeb() error {
+	return client.setConcourseWebState("start")
+}
+
+func (client *GCPClient) setConcourseWebState(action string) error {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return err
+	}
+	return setConcourseWebState(client.boshCLI, action, directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert(), client.stdout)
+}
+
+// RecreateUnresponsive recreates the Concourse VMs, including those whose agents no longer respond to the director
+func (client *GCPClient) RecreateUnresponsive() error {
+	directorPublicIP, err := client.outputs.Get("DirectorPublicIP")
+	if err != nil {
+		return err
+	}
+	return client.boshCLI.RunAuthenticatedCommand("recreate", directorPublicIP, client.config.GetDirectorPassword(), client.config.GetDirectorCACert(), false, client.stdout, "--fix")
+}
@@ -13,6 +13,7 @@ type AWSEnvironment struct {
 	AZ                    string
 	BlobstoreBucket       string
 	CustomOperations      string
+	CustomVars            map[string]interface{}
 	DBCACert              string
 	DBHost                string
 	DBName                string
@@ -21,28 +22,52 @@ type AWSEnvironment struct {
 	DBUsername            string
 	DefaultKeyName        string
 	DefaultSecurityGroups []string
-	ExternalIP            string
-	InternalCIDR          string
-	InternalGateway       string
-	InternalIP            string
-	PrivateCIDR           string
-	PrivateCIDRGateway    string
-	PrivateCIDRReserved   string
-	PrivateKey            string
-	PrivateSubnetID       string
-	PublicCIDR            string
-	PublicCIDRGateway     string
-	PublicCIDRReserved    string
-	PublicCIDRStatic      string
-	PublicSubnetID        string
-	Region                string
-	S3AWSAccessKeyID      string
-	S3AWSSecretAccessKey  string
-	SecretAccessKey       string
-	Spot                  bool
-	VersionFile           []byte
-	VMSecurityGroup       string
-	WorkerType            string
+	// DirectorInstanceProfile gives the director its credentials, and VMsInstanceProfile gives the VMs it
+	// creates access to the blobstore, in place of access keys. AccessKeyID, SecretAccessKey and
+	// SessionToken are then only used by create-env.
+	DirectorInstanceProfile string
+	ExternalIP              string
+	InternalCIDR            string
+	InternalGateway         string
+	InternalIP              string
+	PrivateCIDR             string
+	PrivateCIDRGateway      string
+	PrivateCIDRReserved     string
+	PrivateKey              string
+	PrivateSubnetID         string
+	PublicCIDR              string
+	PublicCIDRGateway       string
+	PublicCIDRReserved      string
+	PublicCIDRStatic        string
+	PublicSubnetID          string
+	// Private leaves out the director's public IP, so that it is only reachable on InternalIP
+	Private              bool
+	Region               string
+	S3AWSAccessKeyID     string
+	S3AWSSecretAccessKey string
+	SecretAccessKey      string
+	SessionToken         string
+	Spot                 bool
+	// WorkerPoolProvisioningTypes are the provisioning types used by worker pools, each of which needs its own worker VM types
+	WorkerPoolProvisioningTypes []string
+	VersionFile                 []byte
+	VMsInstanceProfile          string
+	VMSecurityGroup             string
+	// WebAvailabilityZone, WebSubnets and WebTargetGroups place several web VMs in two availability
+	// zones behind a load balancer, and are empty for a single web VM
+	WebAvailabilityZone string
+	WebSubnets          []WebSubnet
+	WebTargetGroups     []string
+	WorkerType          string
+}
+
+// WebSubnet is a subnet of the network that several web VMs are spread across
+type WebSubnet struct {
+	AZ       string
+	CIDR     string
+	Gateway  string
+	Reserved string
+	SubnetID string
 }
 
 func (e AWSEnvironment) ExtractBOSHandBPM() (util.Resource, util.Resource, error) {
@@ -54,6 +79,12 @@ func (e AWSEnvironment) ExtractBOSHandBPM() (util.Resource, util.Resource, error
 	return boshRelease, bpmRelease, nil
 }
 
+// AWSDirectorOperations are the ops files applied to the director manifest on AWS
+var AWSDirectorOperations = resource.AWSCPIOps + resource.AWSExternalIPOps + resource.AWSBlobstoreOps + resource.AWSDirectorCustomOps
+
+// AWSPrivateDirectorOperations are the ops files applied to the director manifest of a private deployment on AWS
+var AWSPrivateDirectorOperations = resource.AWSCPIOps + resource.AWSBlobstoreOps + resource.AWSDirectorCustomOps
+
 // ConfigureDirectorManifestCPI interpolates all the Environment parameters and
 // required release versions into ready to use Director manifest
 func (e AWSEnvironment) ConfigureDirectorManifestCPI() (string, error) {
@@ -62,36 +93,48 @@ func (e AWSEnvironment) ConfigureDirectorManifestCPI() (string, error) {
 	cpiResource := util.GetResource("cpi", resources)
 	stemcellResource := util.GetResource("stemcell", resources)
 
-	var allOperations = resource.AWSCPIOps + resource.AWSExternalIPOps + resource.AWSBlobstoreOps + resource.AWSDirectorCustomOps
-
-	return yaml.Interpolate(resource.DirectorManifest, allOperations+e.CustomOperations, map[string]interface{}{
-		"cpi_url":                  cpiResource.URL,
-		"cpi_version":              cpiResource.Version,
-		"cpi_sha1":                 cpiResource.SHA1,
-		"stemcell_url":             stemcellResource.URL,
-		"stemcell_sha1":            stemcellResource.SHA1,
-		"internal_cidr":            e.InternalCIDR,
-		"internal_gw":              e.InternalGateway,
-		"internal_ip":              e.InternalIP,
-		"access_key_id":            e.AccessKeyID,
-		"secret_access_key":        e.SecretAccessKey,
-		"region":                   e.Region,
-		"az":                       e.AZ,
-		"default_key_name":         e.DefaultKeyName,
-		"default_security_groups":  e.DefaultSecurityGroups,
-		"private_key":              e.PrivateKey,
-		"subnet_id":                e.PublicSubnetID,
-		"external_ip":              e.ExternalIP,
-		"blobstore_bucket":         e.BlobstoreBucket,
-		"db_ca_cert":               e.DBCACert,
-		"db_host":                  e.DBHost,
-		"db_name":                  e.DBName,
-		"db_password":              e.DBPassword,
-		"db_port":                  e.DBPort,
-		"db_username":              e.DBUsername,
-		"s3_aws_access_key_id":     e.S3AWSAccessKeyID,
-		"s3_aws_secret_access_key": e.S3AWSSecretAccessKey,
-	})
+	operations := AWSDirectorOperations
+	if e.Private {
+		operations = AWSPrivateDirectorOperations
+	}
+	if e.DirectorInstanceProfile != "" {
+		operations += resource.AWSInstanceProfileOps
+	}
+	if e.SessionToken != "" {
+		operations += resource.AWSSessionTokenOps
+	}
+
+	return yaml.Interpolate(resource.DirectorManifest, operations+e.CustomOperations, withCustomVars(map[string]interface{}{
+		"cpi_url":                   cpiResource.URL,
+		"cpi_version":               cpiResource.Version,
+		"cpi_sha1":                  cpiResource.SHA1,
+		"stemcell_url":              stemcellResource.URL,
+		"stemcell_sha1":             stemcellResource.SHA1,
+		"internal_cidr":             e.InternalCIDR,
+		"internal_gw":               e.InternalGateway,
+		"internal_ip":               e.InternalIP,
+		"access_key_id":             e.AccessKeyID,
+		"secret_access_key":         e.SecretAccessKey,
+		"region":                    e.Region,
+		"az":                        e.AZ,
+		"default_key_name":          e.DefaultKeyName,
+		"default_security_groups":   e.DefaultSecurityGroups,
+		"private_key":               e.PrivateKey,
+		"subnet_id":                 e.PublicSubnetID,
+		"external_ip":               e.ExternalIP,
+		"blobstore_bucket":          e.BlobstoreBucket,
+		"db_ca_cert":                e.DBCACert,
+		"db_host":                   e.DBHost,
+		"db_name":                   e.DBName,
+		"db_password":               e.DBPassword,
+		"db_port":                   e.DBPort,
+		"db_username":               e.DBUsername,
+		"s3_aws_access_key_id":      e.S3AWSAccessKeyID,
+		"s3_aws_secret_access_key":  e.S3AWSSecretAccessKey,
+		"session_token":             e.SessionToken,
+		"director_instance_profile": e.DirectorInstanceProfile,
+		"vms_instance_profile":      e.VMsInstanceProfile,
+	}, e.CustomVars))
 }
 
 type awsCloudConfigParams struct {
@@ -109,6 +152,10 @@ type awsCloudConfigParams struct {
 	PrivateCIDR         string
 	PrivateCIDRGateway  string
 	PrivateCIDRReserved string
+	WorkerVMTypes       []workerVMType
+	WebAvailabilityZone string
+	WebSubnets          []WebSubnet
+	WebTargetGroups     []string
 }
 
 // ConfigureDirectorCloudConfig inserts values from the environment into the config template passed as argument
@@ -120,6 +167,7 @@ func (e AWSEnvironment) ConfigureDirectorCloudConfig() (string, error) {
 		PublicSubnetID:      e.PublicSubnetID,
 		PrivateSubnetID:     e.PrivateSubnetID,
 		Spot:                e.Spot,
+		WorkerVMTypes:       workerVMTypes(e.Spot, e.WorkerPoolProvisioningTypes),
 		WorkerType:          e.WorkerType,
 		PublicCIDR:          e.PublicCIDR,
 		PublicCIDRGateway:   e.PublicCIDRGateway,
@@ -128,6 +176,9 @@ func (e AWSEnvironment) ConfigureDirectorCloudConfig() (string, error) {
 		PrivateCIDR:         e.PrivateCIDR,
 		PrivateCIDRGateway:  e.PrivateCIDRGateway,
 		PrivateCIDRReserved: e.PrivateCIDRReserved,
+		WebAvailabilityZone: e.WebAvailabilityZone,
+		WebSubnets:          e.WebSubnets,
+		WebTargetGroups:     e.WebTargetGroups,
 	}
 
 	cc, err := util.RenderTemplate("cloud-config", resource.AWSDirectorCloudConfig, templateParams)
@@ -5,11 +5,13 @@ import (
 	"io/ioutil"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 	"text/template"
 	"text/template/parse"
 
 	"github.com/EngineerBetter/control-tower/resource"
+	"gopkg.in/yaml.v2"
 )
 
 func TestAWSEnvironment_ConfigureDirectorCloudConfig(t *testing.T) {
@@ -112,6 +114,47 @@ func TestAWSEnvironment_ConfigureDirectorCloudConfig(t *testing.T) {
 				return a == b, "m4 worker templating failed"
 			},
 		},
+		{
+			name:    "Success- worker pool VM types rendered",
+			fields:  fullTemplateParams,
+			wantErr: false,
+			init: func(e AWSEnvironment) AWSEnvironment {
+				n := e
+				n.WorkerPoolProvisioningTypes = []string{"spot", "on-demand", "spot"}
+				return n
+			},
+			validate: func(a, b string) (bool, string) {
+				vmTypes, err := cloudConfigVMTypes(a)
+				if err != nil {
+					return false, err.Error()
+				}
+				return vmTypes["concourse-xlarge"]["spot_bid_price"] == nil &&
+					vmTypes["concourse-xlarge-spot"]["spot_bid_price"] == 0.278 &&
+					vmTypes["concourse-xlarge-on-demand"]["instance_type"] == "m4.xlarge" &&
+					vmTypes["concourse-xlarge-on-demand"]["spot_bid_price"] == nil, "worker pool VM types templating failed"
+			},
+		},
+		{
+			name:    "Success- web VMs behind a load balancer rendered",
+			fields:  fullTemplateParams,
+			wantErr: false,
+			init: func(e AWSEnvironment) AWSEnvironment {
+				n := e
+				n.WebAvailabilityZone = "web_az"
+				n.WebSubnets = []WebSubnet{
+					{AZ: "z1", CIDR: "web_a_cidr", Gateway: "web_a_gateway", Reserved: "web_a_reserved", SubnetID: "web_a_subnet_id"},
+					{AZ: "z2", CIDR: "web_b_cidr", Gateway: "web_b_gateway", Reserved: "web_b_reserved", SubnetID: "web_b_subnet_id"},
+				}
+				n.WebTargetGroups = []string{"web-80", "web-443"}
+				return n
+			},
+			validate: func(a, b string) (bool, string) {
+				return strings.Contains(a, "- name: z2\n  cloud_properties:\n    availability_zone: web_az\n") &&
+					strings.Contains(a, "- name: web\n  type: manual\n  subnets:\n  - range: web_a_cidr\n") &&
+					strings.Contains(a, "  - range: web_b_cidr\n    gateway: web_b_gateway\n    az: z2\n    reserved: web_b_reserved\n    cloud_properties:\n      subnet: web_b_subnet_id\n") &&
+					strings.Contains(a, "- name: web-lb\n  cloud_properties:\n    lb_target_groups:\n    - web-80\n    - web-443\n"), "web load balancer templating failed"
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -129,6 +172,26 @@ func TestAWSEnvironment_ConfigureDirectorCloudConfig(t *testing.T) {
 	}
 }
 
+func cloudConfigVMTypes(cloudConfig string) (map[string]map[string]interface{}, error) {
+	var cc struct {
+		VMTypes []struct {
+			Name            string                 `yaml:"name"`
+			CloudProperties map[string]interface{} `yaml:"cloud_properties"`
+		} `yaml:"vm_types"`
+	}
+	if err := yaml.Unmarshal([]byte(cloudConfig), &cc); err != nil {
+		return nil, err
+	}
+	vmTypes := map[string]map[string]interface{}{}
+	for _, vmType := range cc.VMTypes {
+		if _, duplicate := vmTypes[vmType.Name]; duplicate {
+			return nil, fmt.Errorf("duplicate vm_type %s", vmType.Name)
+		}
+		vmTypes[vmType.Name] = vmType.CloudProperties
+	}
+	return vmTypes, nil
+}
+
 func listTemplFields(t *template.Template) map[string]int {
 	m := make(map[string]int)
 	return listNodeFields(t.Tree.Root, m)
@@ -137,7 +200,19 @@ func listTemplFields(t *template.Template) map[string]int {
 func listNodeFields(node parse.Node, res map[string]int) map[string]int {
 	if node.Type() == parse.NodeIf {
 		var re = regexp.MustCompile(`{{(if|if eq)?\s\.(\w+)(}}|\s)`)
-		res[re.FindStringSubmatch(node.String())[2]] = 1
+		for _, match := range re.FindAllStringSubmatch(node.String(), -1) {
+			res[match[2]] = 1
+		}
+	}
+
+	// Fields inside a range belong to the ranged-over items, so only the ranged-over field and
+	// references to the top-level params with $ are recorded
+	if rn, ok := node.(*parse.RangeNode); ok {
+		res[strings.TrimPrefix(rn.Pipe.String(), ".")] = 1
+		var re = regexp.MustCompile(`\$\.(\w+)`)
+		for _, match := range re.FindAllStringSubmatch(rn.List.String(), -1) {
+			res[match[1]] = 1
+		}
 	}
 
 	if node.Type() == parse.NodeAction {
@@ -222,3 +297,107 @@ func TestAWSEnvironment_ConfigureConcourseStemcell(t *testing.T) {
 		})
 	}
 }
+
+func TestAWSEnvironment_ConfigureDirectorManifestCPI_Private(t *testing.T) {
+	env := AWSEnvironment{
+		ExternalIP:  "203.0.113.6",
+		InternalIP:  "10.0.0.6",
+		VersionFile: []byte(`{"cpi": {"url": "cpi-url"}, "stemcell": {"url": "stemcell-url"}}`),
+	}
+
+	public, err := env.ConfigureDirectorManifestCPI()
+	if err != nil {
+		t.Fatalf("ConfigureDirectorManifestCPI() error = %v", err)
+	}
+	if !strings.Contains(public, "203.0.113.6") {
+		t.Errorf("ConfigureDirectorManifestCPI() did not give the director its external IP")
+	}
+
+	env.Private = true
+	private, err := env.ConfigureDirectorManifestCPI()
+	if err != nil {
+		t.Fatalf("ConfigureDirectorManifestCPI() error = %v", err)
+	}
+	if strings.Contains(private, "203.0.113.6") || strings.Contains(private, "type: vip") {
+		t.Errorf("ConfigureDirectorManifestCPI() gave a private director a public IP:\n%s", private)
+	}
+	if !strings.Contains(private, "@10.0.0.6:6868") {
+		t.Errorf("ConfigureDirectorManifestCPI() did not reach a private director on its internal IP:\n%s", private)
+	}
+}
+
+func TestAWSEnvironment_ConfigureDirectorManifestCPI_InstanceProfile(t *testing.T) {
+	env := AWSEnvironment{
+		AccessKeyID:             "operator-key",
+		SecretAccessKey:         "operator-secret",
+		SessionToken:            "operator-token",
+		DirectorInstanceProfile: "director-profile",
+		VMsInstanceProfile:      "vms-profile",
+		VersionFile:             []byte(`{"cpi": {"url": "cpi-url"}, "stemcell": {"url": "stemcell-url"}}`),
+	}
+
+	got, err := env.ConfigureDirectorManifestCPI()
+	if err != nil {
+		t.Fatalf("ConfigureDirectorManifestCPI() error = %v", err)
+	}
+	var manifest struct {
+		CloudProvider struct {
+			Properties struct {
+				AWS map[string]interface{} `yaml:"aws"`
+			} `yaml:"properties"`
+		} `yaml:"cloud_provider"`
+		ResourcePools []struct {
+			CloudProperties map[string]interface{} `yaml:"cloud_properties"`
+		} `yaml:"resource_pools"`
+		InstanceGroups []struct {
+			Properties struct {
+				AWS       map[string]interface{} `yaml:"aws"`
+				Blobstore map[string]interface{} `yaml:"blobstore"`
+			} `yaml:"properties"`
+		} `yaml:"instance_groups"`
+	}
+	if err = yaml.Unmarshal([]byte(got), &manifest); err != nil {
+		t.Fatalf("ConfigureDirectorManifestCPI() rendered invalid YAML: %v", err)
+	}
+
+	createEnv := manifest.CloudProvider.Properties.AWS
+	if createEnv["access_key_id"] != "operator-key" || createEnv["session_token"] != "operator-token" {
+		t.Errorf("ConfigureDirectorManifestCPI() did not give create-env the operator's credentials: %v", createEnv)
+	}
+	if got := manifest.ResourcePools[0].CloudProperties["iam_instance_profile"]; got != "director-profile" {
+		t.Errorf("ConfigureDirectorManifestCPI() gave the director instance profile %v, want director-profile", got)
+	}
+	director := manifest.InstanceGroups[0].Properties
+	if _, ok := director.AWS["access_key_id"]; ok || director.AWS["credentials_source"] != "env_or_profile" {
+		t.Errorf("ConfigureDirectorManifestCPI() did not make the director's CPI use its instance profile: %v", director.AWS)
+	}
+	if director.AWS["default_iam_instance_profile"] != "vms-profile" {
+		t.Errorf("ConfigureDirectorManifestCPI() did not give VMs an instance profile: %v", director.AWS)
+	}
+	if _, ok := director.Blobstore["access_key_id"]; ok || director.Blobstore["credentials_source"] != "env_or_profile" {
+		t.Errorf("ConfigureDirectorManifestCPI() did not make the blobstore use instance profiles: %v", director.Blobstore)
+	}
+	if strings.Count(got, "operator-secret") != 1 {
+		t.Errorf("ConfigureDirectorManifestCPI() gave the director the operator's credentials:\n%s", got)
+	}
+}
+
+func TestAWSEnvironment_ConfigureDirectorManifestCPI_CustomVars(t *testing.T) {
+	env := AWSEnvironment{
+		CustomOperations: "- type: replace\n  path: /instance_groups/name=bosh/properties/director/max_threads?\n  value: ((max_threads))\n",
+		CustomVars:       map[string]interface{}{"max_threads": 8, "region": "us-east-1"},
+		Region:           "eu-west-1",
+		VersionFile:      []byte(`{"cpi": {"url": "cpi-url"}, "stemcell": {"url": "stemcell-url"}}`),
+	}
+
+	got, err := env.ConfigureDirectorManifestCPI()
+	if err != nil {
+		t.Fatalf("ConfigureDirectorManifestCPI() error = %v", err)
+	}
+	if !strings.Contains(got, "max_threads: 8") {
+		t.Errorf("ConfigureDirectorManifestCPI() did not use the custom vars:\n%s", got)
+	}
+	if strings.Contains(got, "us-east-1") {
+		t.Errorf("ConfigureDirectorManifestCPI() let a custom var replace one control-tower sets:\n%s", got)
+	}
+}
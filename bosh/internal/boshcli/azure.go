@@ -0,0 +1,130 @@
+package boshcli
+
+import (
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/util"
+	"github.com/EngineerBetter/control-tower/util/yaml"
+)
+
+// AzureEnvironment holds all the parameters Azure IAAS needs
+type AzureEnvironment struct {
+	ClientID            string
+	ClientSecret        string
+	CustomOperations    string
+	CustomVars          map[string]interface{}
+	DirectorName        string
+	ExternalIP          string
+	InternalCIDR        string
+	InternalGW          string
+	InternalIP          string
+	Network             string
+	PrivateCIDR         string
+	PrivateCIDRGateway  string
+	PrivateCIDRReserved string
+	PrivateSubnetwork   string
+	PublicCIDR          string
+	PublicCIDRGateway   string
+	PublicCIDRReserved  string
+	PublicCIDRStatic    string
+	PublicKey           string
+	PublicSubnetwork    string
+	ResourceGroup       string
+	Spot                bool
+	// WorkerPoolProvisioningTypes are the provisioning types used by worker pools, each of which needs its own worker VM types
+	WorkerPoolProvisioningTypes []string
+	SubscriptionID              string
+	Tags                        string
+	TenantID                    string
+	VersionFile                 []byte
+	Zone                        string
+}
+
+func (e AzureEnvironment) ExtractBOSHandBPM() (util.Resource, util.Resource, error) {
+	resources := util.ParseVersionResources(e.VersionFile)
+
+	boshRelease := util.GetResource("bosh", resources)
+	bpmRelease := util.GetResource("bpm", resources)
+
+	return boshRelease, bpmRelease, nil
+}
+
+// AzureDirectorOperations are the ops files applied to the director manifest on Azure
+var AzureDirectorOperations = resource.AzureCPIOps + resource.AzureExternalIPOps + resource.AzureDirectorCustomOps
+
+// ConfigureDirectorManifestCPI interpolates all the Environment parameters and
+// required release versions into ready to use Director manifest
+func (e AzureEnvironment) ConfigureDirectorManifestCPI() (string, error) {
+	resources := util.ParseVersionResources(e.VersionFile)
+
+	cpiResource := util.GetResource("cpi", resources)
+	stemcellResource := util.GetResource("stemcell", resources)
+
+	return yaml.Interpolate(resource.DirectorManifest, AzureDirectorOperations+e.CustomOperations, withCustomVars(map[string]interface{}{
+		"cpi_url":             cpiResource.URL,
+		"cpi_version":         cpiResource.Version,
+		"cpi_sha1":            cpiResource.SHA1,
+		"stemcell_url":        stemcellResource.URL,
+		"stemcell_sha1":       stemcellResource.SHA1,
+		"internal_cidr":       e.InternalCIDR,
+		"internal_gw":         e.InternalGW,
+		"internal_ip":         e.InternalIP,
+		"director_name":       e.DirectorName,
+		"zone":                e.Zone,
+		"vnet_name":           e.Network,
+		"subnet_name":         e.PublicSubnetwork,
+		"resource_group_name": e.ResourceGroup,
+		"subscription_id":     e.SubscriptionID,
+		"tenant_id":           e.TenantID,
+		"client_id":           e.ClientID,
+		"client_secret":       e.ClientSecret,
+		"external_ip":         e.ExternalIP,
+		"public_key":          e.PublicKey,
+	}, e.CustomVars))
+}
+
+type azureCloudConfigParams struct {
+	Zone                string
+	Spot                bool
+	ResourceGroup       string
+	Network             string
+	PublicSubnetwork    string
+	PrivateSubnetwork   string
+	PublicCIDR          string
+	PublicCIDRGateway   string
+	PublicCIDRStatic    string
+	PublicCIDRReserved  string
+	PrivateCIDR         string
+	PrivateCIDRGateway  string
+	PrivateCIDRReserved string
+	WorkerVMTypes       []workerVMType
+}
+
+// ConfigureDirectorCloudConfig inserts values from the environment into the config template passed as argument
+func (e AzureEnvironment) ConfigureDirectorCloudConfig() (string, error) {
+	templateParams := azureCloudConfigParams{
+		Zone:                e.Zone,
+		Spot:                e.Spot,
+		WorkerVMTypes:       workerVMTypes(e.Spot, e.WorkerPoolProvisioningTypes),
+		ResourceGroup:       e.ResourceGroup,
+		Network:             e.Network,
+		PublicSubnetwork:    e.PublicSubnetwork,
+		PrivateSubnetwork:   e.PrivateSubnetwork,
+		PublicCIDR:          e.PublicCIDR,
+		PublicCIDRGateway:   e.PublicCIDRGateway,
+		PublicCIDRStatic:    e.PublicCIDRStatic,
+		PublicCIDRReserved:  e.PublicCIDRReserved,
+		PrivateCIDR:         e.PrivateCIDR,
+		PrivateCIDRGateway:  e.PrivateCIDRGateway,
+		PrivateCIDRReserved: e.PrivateCIDRReserved,
+	}
+
+	cc, err := util.RenderTemplate("cloud-config", resource.AzureDirectorCloudConfig, templateParams)
+	if cc == nil {
+		return "", err
+	}
+	return string(cc), err
+}
+
+func (e AzureEnvironment) ConcourseStemcellURL() (string, error) {
+	return concourseStemcellURL(resource.AzureReleaseVersions, "https://storage.googleapis.com/bosh-core-stemcells/%s/bosh-stemcell-%s-azure-hyperv-ubuntu-bionic-go_agent.tgz")
+}
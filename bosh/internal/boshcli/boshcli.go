@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/EngineerBetter/control-tower/config"
 	"github.com/EngineerBetter/control-tower/util"
 	"github.com/EngineerBetter/control-tower/util/yaml"
 )
@@ -20,6 +21,7 @@ import (
 //counterfeiter:generate . ICLI
 type ICLI interface {
 	CreateEnv(createEnvFiles *CreateEnvFiles, config IAASEnvironment, password, cert, key, ca string, tags map[string]string) (*CreateEnvFiles, error)
+	DirectorManifest(config IAASEnvironment, password, cert, key, ca string, tags map[string]string) (string, error)
 	RunAuthenticatedCommand(action, ip, password, ca string, detach bool, stdout io.Writer, flags ...string) error
 	Locks(config IAASEnvironment, ip, password, ca string) ([]byte, error)
 	Recreate(config IAASEnvironment, ip, password, ca string) error
@@ -160,15 +162,16 @@ func (c *CLI) Recreate(config IAASEnvironment, ip, password, ca string) error {
 	return cmd.Run()
 }
 
-func (c *CLI) CreateEnv(createEnvFiles *CreateEnvFiles, config IAASEnvironment, password, cert, key, ca string, tags map[string]string) (*CreateEnvFiles, error) {
+// DirectorManifest renders the manifest that create-env would deploy the director with
+func (c *CLI) DirectorManifest(config IAASEnvironment, password, cert, key, ca string, tags map[string]string) (string, error) {
 	manifest, err := config.ConfigureDirectorManifestCPI()
 	if err != nil {
-		return &CreateEnvFiles{}, err
+		return "", err
 	}
 
 	boshResource, bpmResource, err := config.ExtractBOSHandBPM()
 	if err != nil {
-		return &CreateEnvFiles{}, err
+		return "", err
 	}
 
 	vars := map[string]interface{}{
@@ -185,7 +188,11 @@ func (c *CLI) CreateEnv(createEnvFiles *CreateEnvFiles, config IAASEnvironment,
 		"bpm_sha1":                 bpmResource.SHA1,
 		"tags":                     tags,
 	}
-	manifest, err = yaml.Interpolate(manifest, "", vars)
+	return yaml.Interpolate(manifest, "", vars)
+}
+
+func (c *CLI) CreateEnv(createEnvFiles *CreateEnvFiles, config IAASEnvironment, password, cert, key, ca string, tags map[string]string) (*CreateEnvFiles, error) {
+	manifest, err := c.DirectorManifest(config, password, cert, key, ca, tags)
 	if err != nil {
 		return &CreateEnvFiles{}, err
 	}
@@ -309,3 +316,37 @@ func writeTempFile(data []byte) (string, error) {
 	}
 	return name, err
 }
+
+// workerVMType is a set of worker VM types in the cloud config. The default set is named
+// after the worker size alone; worker pools use sets suffixed with their provisioning type.
+type workerVMType struct {
+	Suffix string
+	Spot   bool
+}
+
+func workerVMTypes(spot bool, poolProvisioningTypes []string) []workerVMType {
+	types := []workerVMType{{Spot: spot}}
+	seen := map[string]bool{}
+	for _, provisioningType := range poolProvisioningTypes {
+		if seen[provisioningType] {
+			continue
+		}
+		seen[provisioningType] = true
+		types = append(types, workerVMType{
+			Suffix: "-" + provisioningType,
+			Spot:   provisioningType == config.SPOT,
+		})
+	}
+	return types
+}
+
+// withCustomVars adds the variables of the user's vars files to vars, without replacing any that
+// control-tower sets itself
+func withCustomVars(vars, custom map[string]interface{}) map[string]interface{} {
+	for k, v := range custom {
+		if _, ok := vars[k]; !ok {
+			vars[k] = v
+		}
+	}
+	return vars
+}
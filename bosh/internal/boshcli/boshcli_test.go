@@ -52,6 +52,16 @@ func TestCLI_CreateEnv(t *testing.T) {
 	c.CreateEnv(&boshcli.CreateEnvFiles{}, config, "password", "cert", "key", "ca", map[string]string{})
 }
 
+func TestCLI_DirectorManifest(t *testing.T) {
+	e := fakeexec.New(t)
+	defer e.Finish()
+	c := boshcli.New("bosh", e.Cmd())
+	config := mockIAASConfig{}
+	manifest, err := c.DirectorManifest(config, "password", "cert", "key", "ca", map[string]string{})
+	require.NoError(t, err)
+	require.Contains(t, manifest, "a CPI")
+}
+
 func TestCLI_UpdateCloudConfig(t *testing.T) {
 	e := fakeexec.New(t)
 	defer e.Finish()
@@ -28,6 +28,24 @@ type FakeICLI struct {
 		result1 *boshcli.CreateEnvFiles
 		result2 error
 	}
+	DirectorManifestStub        func(boshcli.IAASEnvironment, string, string, string, string, map[string]string) (string, error)
+	directorManifestMutex       sync.RWMutex
+	directorManifestArgsForCall []struct {
+		arg1 boshcli.IAASEnvironment
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 string
+		arg6 map[string]string
+	}
+	directorManifestReturns struct {
+		result1 string
+		result2 error
+	}
+	directorManifestReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	LocksStub        func(boshcli.IAASEnvironment, string, string, string) ([]byte, error)
 	locksMutex       sync.RWMutex
 	locksArgsForCall []struct {
@@ -119,15 +137,16 @@ func (fake *FakeICLI) CreateEnv(arg1 *boshcli.CreateEnvFiles, arg2 boshcli.IAASE
 		arg6 string
 		arg7 map[string]string
 	}{arg1, arg2, arg3, arg4, arg5, arg6, arg7})
+	stub := fake.CreateEnvStub
+	fakeReturns := fake.createEnvReturns
 	fake.recordInvocation("CreateEnv", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6, arg7})
 	fake.createEnvMutex.Unlock()
-	if fake.CreateEnvStub != nil {
-		return fake.CreateEnvStub(arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5, arg6, arg7)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.createEnvReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -176,6 +195,75 @@ func (fake *FakeICLI) CreateEnvReturnsOnCall(i int, result1 *boshcli.CreateEnvFi
 	}{result1, result2}
 }
 
+func (fake *FakeICLI) DirectorManifest(arg1 boshcli.IAASEnvironment, arg2 string, arg3 string, arg4 string, arg5 string, arg6 map[string]string) (string, error) {
+	fake.directorManifestMutex.Lock()
+	ret, specificReturn := fake.directorManifestReturnsOnCall[len(fake.directorManifestArgsForCall)]
+	fake.directorManifestArgsForCall = append(fake.directorManifestArgsForCall, struct {
+		arg1 boshcli.IAASEnvironment
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 string
+		arg6 map[string]string
+	}{arg1, arg2, arg3, arg4, arg5, arg6})
+	stub := fake.DirectorManifestStub
+	fakeReturns := fake.directorManifestReturns
+	fake.recordInvocation("DirectorManifest", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6})
+	fake.directorManifestMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5, arg6)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeICLI) DirectorManifestCallCount() int {
+	fake.directorManifestMutex.RLock()
+	defer fake.directorManifestMutex.RUnlock()
+	return len(fake.directorManifestArgsForCall)
+}
+
+func (fake *FakeICLI) DirectorManifestCalls(stub func(boshcli.IAASEnvironment, string, string, string, string, map[string]string) (string, error)) {
+	fake.directorManifestMutex.Lock()
+	defer fake.directorManifestMutex.Unlock()
+	fake.DirectorManifestStub = stub
+}
+
+func (fake *FakeICLI) DirectorManifestArgsForCall(i int) (boshcli.IAASEnvironment, string, string, string, string, map[string]string) {
+	fake.directorManifestMutex.RLock()
+	defer fake.directorManifestMutex.RUnlock()
+	argsForCall := fake.directorManifestArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6
+}
+
+func (fake *FakeICLI) DirectorManifestReturns(result1 string, result2 error) {
+	fake.directorManifestMutex.Lock()
+	defer fake.directorManifestMutex.Unlock()
+	fake.DirectorManifestStub = nil
+	fake.directorManifestReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeICLI) DirectorManifestReturnsOnCall(i int, result1 string, result2 error) {
+	fake.directorManifestMutex.Lock()
+	defer fake.directorManifestMutex.Unlock()
+	fake.DirectorManifestStub = nil
+	if fake.directorManifestReturnsOnCall == nil {
+		fake.directorManifestReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.directorManifestReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeICLI) Locks(arg1 boshcli.IAASEnvironment, arg2 string, arg3 string, arg4 string) ([]byte, error) {
 	fake.locksMutex.Lock()
 	ret, specificReturn := fake.locksReturnsOnCall[len(fake.locksArgsForCall)]
@@ -185,15 +273,16 @@ func (fake *FakeICLI) Locks(arg1 boshcli.IAASEnvironment, arg2 string, arg3 stri
 		arg3 string
 		arg4 string
 	}{arg1, arg2, arg3, arg4})
+	stub := fake.LocksStub
+	fakeReturns := fake.locksReturns
 	fake.recordInvocation("Locks", []interface{}{arg1, arg2, arg3, arg4})
 	fake.locksMutex.Unlock()
-	if fake.LocksStub != nil {
-		return fake.LocksStub(arg1, arg2, arg3, arg4)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.locksReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -251,15 +340,16 @@ func (fake *FakeICLI) Recreate(arg1 boshcli.IAASEnvironment, arg2 string, arg3 s
 		arg3 string
 		arg4 string
 	}{arg1, arg2, arg3, arg4})
+	stub := fake.RecreateStub
+	fakeReturns := fake.recreateReturns
 	fake.recordInvocation("Recreate", []interface{}{arg1, arg2, arg3, arg4})
 	fake.recreateMutex.Unlock()
-	if fake.RecreateStub != nil {
-		return fake.RecreateStub(arg1, arg2, arg3, arg4)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.recreateReturns
 	return fakeReturns.result1
 }
 
@@ -317,15 +407,16 @@ func (fake *FakeICLI) RunAuthenticatedCommand(arg1 string, arg2 string, arg3 str
 		arg6 io.Writer
 		arg7 []string
 	}{arg1, arg2, arg3, arg4, arg5, arg6, arg7})
+	stub := fake.RunAuthenticatedCommandStub
+	fakeReturns := fake.runAuthenticatedCommandReturns
 	fake.recordInvocation("RunAuthenticatedCommand", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6, arg7})
 	fake.runAuthenticatedCommandMutex.Unlock()
-	if fake.RunAuthenticatedCommandStub != nil {
-		return fake.RunAuthenticatedCommandStub(arg1, arg2, arg3, arg4, arg5, arg6, arg7...)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5, arg6, arg7...)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.runAuthenticatedCommandReturns
 	return fakeReturns.result1
 }
 
@@ -380,15 +471,16 @@ func (fake *FakeICLI) UpdateCloudConfig(arg1 boshcli.IAASEnvironment, arg2 strin
 		arg3 string
 		arg4 string
 	}{arg1, arg2, arg3, arg4})
+	stub := fake.UpdateCloudConfigStub
+	fakeReturns := fake.updateCloudConfigReturns
 	fake.recordInvocation("UpdateCloudConfig", []interface{}{arg1, arg2, arg3, arg4})
 	fake.updateCloudConfigMutex.Unlock()
-	if fake.UpdateCloudConfigStub != nil {
-		return fake.UpdateCloudConfigStub(arg1, arg2, arg3, arg4)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.updateCloudConfigReturns
 	return fakeReturns.result1
 }
 
@@ -443,15 +535,16 @@ func (fake *FakeICLI) UploadConcourseStemcell(arg1 boshcli.IAASEnvironment, arg2
 		arg3 string
 		arg4 string
 	}{arg1, arg2, arg3, arg4})
+	stub := fake.UploadConcourseStemcellStub
+	fakeReturns := fake.uploadConcourseStemcellReturns
 	fake.recordInvocation("UploadConcourseStemcell", []interface{}{arg1, arg2, arg3, arg4})
 	fake.uploadConcourseStemcellMutex.Unlock()
-	if fake.UploadConcourseStemcellStub != nil {
-		return fake.UploadConcourseStemcellStub(arg1, arg2, arg3, arg4)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.uploadConcourseStemcellReturns
 	return fakeReturns.result1
 }
 
@@ -502,6 +595,8 @@ func (fake *FakeICLI) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.createEnvMutex.RLock()
 	defer fake.createEnvMutex.RUnlock()
+	fake.directorManifestMutex.RLock()
+	defer fake.directorManifestMutex.RUnlock()
 	fake.locksMutex.RLock()
 	defer fake.locksMutex.RUnlock()
 	fake.recreateMutex.RLock()
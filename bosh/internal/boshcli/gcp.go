@@ -11,6 +11,7 @@ import (
 // Environment holds all the parameters GCP IAAS needs
 type GCPEnvironment struct {
 	CustomOperations    string
+	CustomVars          map[string]interface{}
 	DirectorName        string
 	ExternalIP          string
 	GcpCredentialsJSON  string
@@ -30,9 +31,18 @@ type GCPEnvironment struct {
 	PublicKey           string
 	PublicSubnetwork    string
 	Spot                bool
-	Tags                string
-	VersionFile         []byte
-	Zone                string
+	// WorkerPoolProvisioningTypes are the provisioning types used by worker pools, each of which needs its own worker VM types
+	WorkerPoolProvisioningTypes []string
+	Tags                        string
+	VersionFile                 []byte
+	// WebTargetPool and WebZone place several web VMs in two zones behind a load balancer, and are
+	// empty for a single web VM
+	WebTargetPool string
+	WebZone       string
+	Zone          string
+	// DirectorServiceAccount is attached to the director's VM so that it does not need the key in
+	// GcpCredentialsJSON, which is then only used by create-env
+	DirectorServiceAccount string
 }
 
 func (e GCPEnvironment) ExtractBOSHandBPM() (util.Resource, util.Resource, error) {
@@ -44,6 +54,9 @@ func (e GCPEnvironment) ExtractBOSHandBPM() (util.Resource, util.Resource, error
 	return boshRelease, bpmRelease, nil
 }
 
+// GCPDirectorOperations are the ops files applied to the director manifest on GCP
+var GCPDirectorOperations = resource.GCPCPIOps + resource.GCPExternalIPOps + resource.GCPDirectorCustomOps + resource.GCPJumpboxUserOps
+
 // ConfigureDirectorManifestCPI interpolates all the Environment parameters and
 // required release versions into ready to use Director manifest
 func (e GCPEnvironment) ConfigureDirectorManifestCPI() (string, error) {
@@ -57,27 +70,31 @@ func (e GCPEnvironment) ConfigureDirectorManifestCPI() (string, error) {
 		return "", err
 	}
 
-	var allOperations = resource.GCPCPIOps + resource.GCPExternalIPOps + resource.GCPDirectorCustomOps + resource.GCPJumpboxUserOps
-
-	return yaml.Interpolate(resource.DirectorManifest, allOperations+e.CustomOperations, map[string]interface{}{
-		"cpi_url":              cpiResource.URL,
-		"cpi_version":          cpiResource.Version,
-		"cpi_sha1":             cpiResource.SHA1,
-		"stemcell_url":         stemcellResource.URL,
-		"stemcell_sha1":        stemcellResource.SHA1,
-		"internal_cidr":        e.InternalCIDR,
-		"internal_gw":          e.InternalGW,
-		"internal_ip":          e.InternalIP,
-		"director_name":        e.DirectorName,
-		"zone":                 e.Zone,
-		"network":              e.Network,
-		"subnetwork":           e.PublicSubnetwork,
-		"private_subnetwork":   e.PrivateSubnetwork,
-		"project_id":           e.ProjectID,
-		"gcp_credentials_json": string(gcpCreds),
-		"external_ip":          e.ExternalIP,
-		"public_key":           e.PublicKey,
-	})
+	operations := GCPDirectorOperations
+	if e.DirectorServiceAccount != "" {
+		operations += resource.GCPServiceAccountOps
+	}
+
+	return yaml.Interpolate(resource.DirectorManifest, operations+e.CustomOperations, withCustomVars(map[string]interface{}{
+		"cpi_url":                  cpiResource.URL,
+		"cpi_version":              cpiResource.Version,
+		"cpi_sha1":                 cpiResource.SHA1,
+		"stemcell_url":             stemcellResource.URL,
+		"stemcell_sha1":            stemcellResource.SHA1,
+		"internal_cidr":            e.InternalCIDR,
+		"internal_gw":              e.InternalGW,
+		"internal_ip":              e.InternalIP,
+		"director_name":            e.DirectorName,
+		"zone":                     e.Zone,
+		"network":                  e.Network,
+		"subnetwork":               e.PublicSubnetwork,
+		"private_subnetwork":       e.PrivateSubnetwork,
+		"project_id":               e.ProjectID,
+		"gcp_credentials_json":     string(gcpCreds),
+		"external_ip":              e.ExternalIP,
+		"public_key":               e.PublicKey,
+		"director_service_account": e.DirectorServiceAccount,
+	}, e.CustomVars))
 }
 
 type gcpCloudConfigParams struct {
@@ -93,6 +110,9 @@ type gcpCloudConfigParams struct {
 	PrivateCIDR         string
 	PrivateCIDRGateway  string
 	PrivateCIDRReserved string
+	WorkerVMTypes       []workerVMType
+	WebTargetPool       string
+	WebZone             string
 }
 
 // ConfigureDirectorCloudConfig inserts values from the environment into the config template passed as argument
@@ -102,6 +122,7 @@ func (e GCPEnvironment) ConfigureDirectorCloudConfig() (string, error) {
 		PublicSubnetwork:    e.PublicSubnetwork,
 		PrivateSubnetwork:   e.PrivateSubnetwork,
 		Spot:                e.Spot,
+		WorkerVMTypes:       workerVMTypes(e.Spot, e.WorkerPoolProvisioningTypes),
 		Network:             e.Network,
 		PublicCIDR:          e.PublicCIDR,
 		PublicCIDRGateway:   e.PublicCIDRGateway,
@@ -110,6 +131,8 @@ func (e GCPEnvironment) ConfigureDirectorCloudConfig() (string, error) {
 		PrivateCIDR:         e.PrivateCIDR,
 		PrivateCIDRGateway:  e.PrivateCIDRGateway,
 		PrivateCIDRReserved: e.PrivateCIDRReserved,
+		WebTargetPool:       e.WebTargetPool,
+		WebZone:             e.WebZone,
 	}
 
 	cc, err := util.RenderTemplate("cloud-config", resource.GCPDirectorCloudConfig, templateParams)
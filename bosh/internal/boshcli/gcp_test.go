@@ -2,6 +2,8 @@ package boshcli
 
 import (
 	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 	"text/template"
 
@@ -65,6 +67,21 @@ var _ = Describe("GCPEnvironment", func() {
 				Expect(actual).To(Equal(expected))
 			})
 		})
+
+		Context("when there are several web VMs behind a load balancer", func() {
+			BeforeEach(func() {
+				environment.WebZone = "web_zone"
+				environment.WebTargetPool = "web_target_pool"
+			})
+
+			It("adds a second zone to the public network and the target pool as a VM extension", func() {
+				actual, err := environment.ConfigureDirectorCloudConfig()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actual).To(ContainSubstring("- name: z2\n  cloud_properties:\n    zone: web_zone\n"))
+				Expect(actual).To(ContainSubstring("gateway: public_cidr_gateway\n    azs: [z1, z2]\n"))
+				Expect(actual).To(ContainSubstring("- name: web-lb\n  cloud_properties:\n    target_pool: web_target_pool\n"))
+			})
+		})
 	})
 })
 
@@ -118,3 +135,29 @@ func Test_GCPCloudConfigStructureTest(t *testing.T) {
 		}
 	})
 }
+
+func TestGCPEnvironment_ConfigureDirectorManifestCPI_ServiceAccount(t *testing.T) {
+	credentials, err := ioutil.TempFile("", "gcp-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(credentials.Name())
+	credentials.WriteString(`{"private_key": "operator-key"}`)
+	credentials.Close()
+
+	env := GCPEnvironment{
+		DirectorServiceAccount: "bosh@project.iam.gserviceaccount.com",
+		GcpCredentialsJSON:     credentials.Name(),
+		VersionFile:            []byte(`{"cpi": {"url": "cpi-url"}, "stemcell": {"url": "stemcell-url"}}`),
+	}
+	got, err := env.ConfigureDirectorManifestCPI()
+	if err != nil {
+		t.Fatalf("ConfigureDirectorManifestCPI() error = %v", err)
+	}
+	if strings.Count(got, "operator-key") != 1 {
+		t.Errorf("ConfigureDirectorManifestCPI() should only give create-env the operator's key:\n%s", got)
+	}
+	if !strings.Contains(got, "service_account: bosh@project.iam.gserviceaccount.com") {
+		t.Errorf("ConfigureDirectorManifestCPI() did not attach the service account to the director:\n%s", got)
+	}
+}
@@ -0,0 +1,36 @@
+package bosh
+
+import (
+	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir"
+	"github.com/EngineerBetter/control-tower/config"
+)
+
+// concourseWorkerCount returns the number of default workers to deploy, which is reduced while paused
+func concourseWorkerCount(c config.ConfigView) int {
+	if pause := c.GetPause(); pause != nil {
+		return pause.WorkerCount
+	}
+	return c.GetConcourseWorkerCount()
+}
+
+// concourseWorkerPools returns the worker pools to deploy, which have no workers while paused
+func concourseWorkerPools(c config.ConfigView) []config.WorkerPool {
+	pools := c.GetWorkerPools()
+	if c.GetPause() == nil {
+		return pools
+	}
+	paused := make([]config.WorkerPool, len(pools))
+	for i, pool := range pools {
+		pool.Count = 0
+		paused[i] = pool
+	}
+	return paused
+}
+
+// hibernateFlags returns the flags that stop the web instances while hibernating
+func hibernateFlags(workingdir workingdir.IClient, c config.ConfigView) []string {
+	if pause := c.GetPause(); pause == nil || !pause.Hibernate {
+		return nil
+	}
+	return []string{"--ops-file", workingdir.PathInWorkingDir(concourseHibernateFilename)}
+}
@@ -0,0 +1,62 @@
+package bosh
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir/workingdirfakes"
+	"github.com/EngineerBetter/control-tower/config"
+)
+
+func TestPausedDeployment(t *testing.T) {
+	pools := []config.WorkerPool{{Name: "heavy", Count: 3, Size: "4xlarge", VMProvisioningType: config.SPOT}}
+
+	tests := []struct {
+		name        string
+		pause       *config.Pause
+		workerCount int
+		poolCount   int
+		flags       []string
+	}{
+		{
+			name:        "not paused",
+			workerCount: 4,
+			poolCount:   3,
+		},
+		{
+			name:        "scaled down",
+			pause:       &config.Pause{WorkerCount: 1},
+			workerCount: 1,
+			poolCount:   0,
+		},
+		{
+			name:        "hibernating",
+			pause:       &config.Pause{Hibernate: true},
+			workerCount: 0,
+			poolCount:   0,
+			flags:       []string{"--ops-file", "/working/hibernate.yml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.Config{ConcourseWorkerCount: 4, WorkerPools: pools, Pause: tt.pause}
+			workingdir := &workingdirfakes.FakeIClient{}
+			workingdir.PathInWorkingDirStub = func(filename string) string {
+				return "/working/" + filename
+			}
+
+			if got := concourseWorkerCount(conf); got != tt.workerCount {
+				t.Errorf("concourseWorkerCount() = %d, want %d", got, tt.workerCount)
+			}
+			if got := concourseWorkerPools(conf); len(got) != 1 || got[0].Count != tt.poolCount {
+				t.Errorf("concourseWorkerPools() = %+v, want 1 pool of %d", got, tt.poolCount)
+			}
+			if got := hibernateFlags(workingdir, conf); !reflect.DeepEqual(got, tt.flags) {
+				t.Errorf("hibernateFlags() = %v, want %v", got, tt.flags)
+			}
+		})
+	}
+	if pools[0].Count != 3 {
+		t.Errorf("concourseWorkerPools() changed the stored pools")
+	}
+}
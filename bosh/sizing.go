@@ -0,0 +1,155 @@
+package bosh
+
+import (
+	"fmt"
+
+	"github.com/EngineerBetter/control-tower/bosh/internal/boshcli"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/util/yaml"
+	ghodss "github.com/ghodss/yaml"
+)
+
+// VMType describes a VM by what it costs to run: its machine type, whether it is a spot or
+// preemptible instance, and the size and type of its ephemeral or root disk
+type VMType struct {
+	MachineType string
+	Spot        bool
+	DiskGB      float64
+	DiskType    string
+}
+
+// Sizing is the director VM and the cloud config VM types that a config is deployed with
+type Sizing struct {
+	Director                 VMType
+	DirectorPersistentDiskGB float64
+	DirectorPersistentDisk   string
+	VMTypes                  map[string]VMType
+}
+
+type cloudProperties struct {
+	InstanceType  string `json:"instance_type"`
+	MachineType   string `json:"machine_type"`
+	EphemeralDisk struct {
+		Size int    `json:"size"`
+		Type string `json:"type"`
+	} `json:"ephemeral_disk"`
+	RootDisk struct {
+		Size int `json:"size"`
+	} `json:"root_disk"`
+	RootDiskSizeGB  int      `json:"root_disk_size_gb"`
+	RootDiskType    string   `json:"root_disk_type"`
+	SpotBidPrice    *float64 `json:"spot_bid_price"`
+	SpotBidMaxPrice *float64 `json:"spot_bid_max_price"`
+	Preemptible     bool     `json:"preemptible"`
+}
+
+func (p cloudProperties) vmType() VMType {
+	t := VMType{
+		MachineType: p.InstanceType,
+		Spot:        p.SpotBidPrice != nil || p.SpotBidMaxPrice != nil || p.Preemptible,
+		DiskType:    p.EphemeralDisk.Type,
+	}
+	if t.MachineType == "" {
+		t.MachineType = p.MachineType
+	}
+	switch {
+	case p.EphemeralDisk.Size > 0:
+		t.DiskGB = float64(p.EphemeralDisk.Size) / 1024
+	case p.RootDisk.Size > 0:
+		t.DiskGB = float64(p.RootDisk.Size) / 1024
+	case p.RootDiskSizeGB > 0:
+		t.DiskGB = float64(p.RootDiskSizeGB)
+		t.DiskType = p.RootDiskType
+	}
+	return t
+}
+
+// DeploymentSizing renders the director manifest and cloud config for conf, as a deploy would,
+// and returns the VMs and disks they describe
+func DeploymentSizing(iaasName iaas.Name, conf config.ConfigView) (Sizing, error) {
+	pools := config.WorkerPoolProvisioningTypes(conf.GetWorkerPools())
+
+	var env boshcli.IAASEnvironment
+	var directorOperations string
+	switch iaasName {
+	case iaas.AWS:
+		env = boshcli.AWSEnvironment{
+			Spot:                        conf.IsSpot(),
+			WorkerType:                  conf.GetWorkerType(),
+			WorkerPoolProvisioningTypes: pools,
+		}
+		directorOperations = boshcli.AWSDirectorOperations
+	case iaas.GCP:
+		env = boshcli.GCPEnvironment{
+			Spot:                        conf.IsSpot(),
+			WorkerPoolProvisioningTypes: pools,
+		}
+		directorOperations = boshcli.GCPDirectorOperations
+	case iaas.Azure:
+		env = boshcli.AzureEnvironment{
+			Spot:                        conf.IsSpot(),
+			WorkerPoolProvisioningTypes: pools,
+		}
+		directorOperations = boshcli.AzureDirectorOperations
+	default:
+		return Sizing{}, fmt.Errorf("IAAS not supported: %s", iaasName)
+	}
+
+	// Only the sizes are read from the manifest, so its variables are left unset
+	manifest, err := yaml.Interpolate(resource.DirectorManifest, directorOperations, nil)
+	if err != nil {
+		return Sizing{}, fmt.Errorf("error rendering director manifest: [%v]", err)
+	}
+	cloudConfig, err := env.ConfigureDirectorCloudConfig()
+	if err != nil {
+		return Sizing{}, fmt.Errorf("error rendering cloud config: [%v]", err)
+	}
+	return parseSizing([]byte(manifest), []byte(cloudConfig))
+}
+
+func parseSizing(manifest, cloudConfig []byte) (Sizing, error) {
+	var m struct {
+		ResourcePools []struct {
+			CloudProperties cloudProperties `json:"cloud_properties"`
+		} `json:"resource_pools"`
+		DiskPools []struct {
+			DiskSize        int `json:"disk_size"`
+			CloudProperties struct {
+				Type               string `json:"type"`
+				StorageAccountType string `json:"storage_account_type"`
+			} `json:"cloud_properties"`
+		} `json:"disk_pools"`
+	}
+	if err := ghodss.Unmarshal(manifest, &m); err != nil {
+		return Sizing{}, fmt.Errorf("error parsing director manifest: [%v]", err)
+	}
+	if len(m.ResourcePools) != 1 || len(m.DiskPools) != 1 {
+		return Sizing{}, fmt.Errorf("expected the director manifest to have one resource pool and one disk pool, found %d and %d", len(m.ResourcePools), len(m.DiskPools))
+	}
+
+	var cc struct {
+		VMTypes []struct {
+			Name            string          `json:"name"`
+			CloudProperties cloudProperties `json:"cloud_properties"`
+		} `json:"vm_types"`
+	}
+	if err := ghodss.Unmarshal(cloudConfig, &cc); err != nil {
+		return Sizing{}, fmt.Errorf("error parsing cloud config: [%v]", err)
+	}
+
+	s := Sizing{
+		Director:                 m.ResourcePools[0].CloudProperties.vmType(),
+		DirectorPersistentDiskGB: float64(m.DiskPools[0].DiskSize) / 1024,
+		DirectorPersistentDisk:   m.DiskPools[0].CloudProperties.Type,
+		VMTypes:                  map[string]VMType{},
+	}
+	if s.DirectorPersistentDisk == "" {
+		s.DirectorPersistentDisk = m.DiskPools[0].CloudProperties.StorageAccountType
+	}
+	for _, t := range cc.VMTypes {
+		s.VMTypes[t.Name] = t.CloudProperties.vmType()
+	}
+	return s, nil
+}
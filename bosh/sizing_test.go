@@ -0,0 +1,69 @@
+package bosh
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/iaas"
+)
+
+func TestDeploymentSizing(t *testing.T) {
+	conf := config.Config{
+		VMProvisioningType: config.SPOT,
+		WorkerType:         "m5",
+		WorkerPools:        []config.WorkerPool{{Name: "heavy", Count: 2, Size: "2xlarge", VMProvisioningType: config.ON_DEMAND}},
+	}
+	tests := []struct {
+		iaas     iaas.Name
+		director VMType
+		vmTypes  map[string]VMType
+	}{
+		{
+			iaas:     iaas.AWS,
+			director: VMType{MachineType: "t3.small", DiskGB: 25000.0 / 1024, DiskType: "gp2"},
+			vmTypes: map[string]VMType{
+				"concourse-web-small":         {MachineType: "t3.small", DiskGB: 20000.0 / 1024, DiskType: "gp2"},
+				"concourse-xlarge":            {MachineType: "m5.xlarge", Spot: true, DiskGB: 200000.0 / 1024, DiskType: "gp2"},
+				"concourse-2xlarge-on-demand": {MachineType: "m5.2xlarge", DiskGB: 200000.0 / 1024, DiskType: "gp2"},
+			},
+		},
+		{
+			iaas:     iaas.GCP,
+			director: VMType{MachineType: "n1-standard-1", DiskGB: 40, DiskType: "pd-standard"},
+			vmTypes: map[string]VMType{
+				"concourse-web-small":         {MachineType: "n1-standard-1", DiskGB: 20, DiskType: "pd-ssd"},
+				"concourse-xlarge":            {MachineType: "n1-standard-4", Spot: true, DiskGB: 200, DiskType: "pd-ssd"},
+				"concourse-2xlarge-on-demand": {MachineType: "n1-standard-8", DiskGB: 200, DiskType: "pd-ssd"},
+			},
+		},
+		{
+			iaas:     iaas.Azure,
+			director: VMType{MachineType: "Standard_D2s_v3", DiskGB: 40},
+			vmTypes: map[string]VMType{
+				"concourse-web-small":         {MachineType: "Standard_B2s", DiskGB: 20},
+				"concourse-xlarge":            {MachineType: "Standard_D4s_v3", Spot: true, DiskGB: 200},
+				"concourse-2xlarge-on-demand": {MachineType: "Standard_D8s_v3", DiskGB: 200},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.iaas.String(), func(t *testing.T) {
+			got, err := DeploymentSizing(tt.iaas, conf)
+			if err != nil {
+				t.Fatalf("DeploymentSizing() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Director, tt.director) {
+				t.Errorf("DeploymentSizing() director = %+v, want %+v", got.Director, tt.director)
+			}
+			if got.DirectorPersistentDiskGB == 0 || got.DirectorPersistentDisk == "" {
+				t.Errorf("DeploymentSizing() director persistent disk = %vGB %q", got.DirectorPersistentDiskGB, got.DirectorPersistentDisk)
+			}
+			for name, want := range tt.vmTypes {
+				if !reflect.DeepEqual(got.VMTypes[name], want) {
+					t.Errorf("DeploymentSizing() VM type %s = %+v, want %+v", name, got.VMTypes[name], want)
+				}
+			}
+		})
+	}
+}
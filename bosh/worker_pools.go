@@ -0,0 +1,110 @@
+package bosh
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/EngineerBetter/control-tower/bosh/internal/workingdir"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/ghodss/yaml"
+)
+
+const concourseWorkerPoolsFilename = "worker_pools.yml"
+
+// workerPoolsFlags writes the worker pools ops file to the working directory and returns the
+// flags to apply it, or nothing if there are no pools
+func workerPoolsFlags(workingdir workingdir.IClient, pools []config.WorkerPool) ([]string, error) {
+	if len(pools) == 0 {
+		return nil, nil
+	}
+
+	ops, err := workerPoolsOps(concourseManifestContents, pools)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate worker pools ops file: [%v]", err)
+	}
+
+	path, err := workingdir.SaveFileToWorkingDir(concourseWorkerPoolsFilename, ops)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save %s to working directory: [%v]", concourseWorkerPoolsFilename, err)
+	}
+	return []string{"--ops-file", path}, nil
+}
+
+// workerPoolsOps returns an ops file that adds an instance group for each pool, copied from the
+// default worker instance group in manifest so that pools run the same jobs as the default workers
+func workerPoolsOps(manifest []byte, pools []config.WorkerPool) ([]byte, error) {
+	var m struct {
+		InstanceGroups []map[string]interface{} `json:"instance_groups"`
+	}
+	if err := yaml.Unmarshal(manifest, &m); err != nil {
+		return nil, err
+	}
+
+	var worker map[string]interface{}
+	for _, group := range m.InstanceGroups {
+		if group["name"] == "worker" {
+			worker = group
+		}
+	}
+	if worker == nil {
+		return nil, errors.New("the Concourse manifest has no worker instance group")
+	}
+
+	var ops []map[string]interface{}
+	for _, pool := range pools {
+		group, err := copyInstanceGroup(worker)
+		if err != nil {
+			return nil, err
+		}
+		group["name"] = pool.InstanceGroup()
+		group["instances"] = pool.Count
+		group["vm_type"] = pool.VMType()
+
+		properties, err := workerJobProperties(group)
+		if err != nil {
+			return nil, err
+		}
+		properties["ephemeral"] = pool.IsSpot()
+		if len(pool.Tags) > 0 {
+			properties["tags"] = pool.Tags
+		} else {
+			delete(properties, "tags")
+		}
+
+		ops = append(ops, map[string]interface{}{
+			"type":  "replace",
+			"path":  "/instance_groups/-",
+			"value": group,
+		})
+	}
+
+	return yaml.Marshal(ops)
+}
+
+func copyInstanceGroup(group map[string]interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(group)
+	if err != nil {
+		return nil, err
+	}
+	var c map[string]interface{}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
+
+func workerJobProperties(group map[string]interface{}) (map[string]interface{}, error) {
+	jobs, _ := group["jobs"].([]interface{})
+	for _, j := range jobs {
+		job, ok := j.(map[string]interface{})
+		if !ok || job["name"] != "worker" {
+			continue
+		}
+		properties, ok := job["properties"].(map[string]interface{})
+		if !ok {
+			properties = map[string]interface{}{}
+			job["properties"] = properties
+		}
+		return properties, nil
+	}
+	return nil, errors.New("the Concourse manifest's worker instance group has no worker job")
+}
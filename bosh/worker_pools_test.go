@@ -0,0 +1,143 @@
+package bosh
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/util/yaml"
+	ghodss "github.com/ghodss/yaml"
+)
+
+const workerPoolsTestManifest = `---
+name: concourse
+instance_groups:
+- name: web
+  instances: 1
+  jobs:
+  - name: web
+- name: worker
+  instances: ((worker_count))
+  vm_type: ((worker_vm_type))
+  networks:
+  - name: ((worker_network_name))
+  jobs:
+  - name: worker
+    release: concourse
+    properties:
+      drain_timeout: 10m
+  - name: node_exporter
+`
+
+type testInstanceGroup struct {
+	Name      string `json:"name"`
+	Instances int    `json:"instances"`
+	VMType    string `json:"vm_type"`
+	Networks  []struct {
+		Name string `json:"name"`
+	} `json:"networks"`
+	Jobs []struct {
+		Name       string                 `json:"name"`
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"jobs"`
+}
+
+func TestWorkerPoolsOps(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		pools    []config.WorkerPool
+		check    func(groups []testInstanceGroup) string
+		wantErr  string
+	}{
+		{
+			name:     "on-demand pool without tags",
+			manifest: workerPoolsTestManifest,
+			pools:    []config.WorkerPool{{Name: "build", Count: 2, Size: "xlarge", VMProvisioningType: config.ON_DEMAND}},
+			check: func(groups []testInstanceGroup) string {
+				if len(groups) != 3 {
+					return "expected one instance group to be added"
+				}
+				g := groups[2]
+				if g.Name != "worker-build" || g.Instances != 2 || g.VMType != "concourse-xlarge-on-demand" {
+					return "pool name, instances or vm_type not set"
+				}
+				if len(g.Networks) != 1 || g.Networks[0].Name != "((worker_network_name))" {
+					return "networks not copied from the worker instance group"
+				}
+				if len(g.Jobs) != 2 || g.Jobs[1].Name != "node_exporter" {
+					return "jobs not copied from the worker instance group"
+				}
+				p := g.Jobs[0].Properties
+				if p["ephemeral"] != false || p["drain_timeout"] != "10m" || p["tags"] != nil {
+					return "worker job properties not set"
+				}
+				if groups[1].VMType != "((worker_vm_type))" {
+					return "default worker instance group changed"
+				}
+				return ""
+			},
+		},
+		{
+			name:     "tagged spot pools",
+			manifest: workerPoolsTestManifest,
+			pools: []config.WorkerPool{
+				{Name: "heavy", Count: 6, Size: "4xlarge", VMProvisioningType: config.SPOT, Tags: []string{"heavy"}},
+				{Name: "docker", Count: 1, Size: "large", VMProvisioningType: config.ON_DEMAND, Tags: []string{"docker", "privileged"}},
+			},
+			check: func(groups []testInstanceGroup) string {
+				if len(groups) != 4 {
+					return "expected two instance groups to be added"
+				}
+				heavy, docker := groups[2], groups[3]
+				if heavy.Name != "worker-heavy" || heavy.VMType != "concourse-4xlarge-spot" {
+					return "heavy pool not added"
+				}
+				if heavy.Jobs[0].Properties["ephemeral"] != true {
+					return "spot pool workers are not ephemeral"
+				}
+				if tags, _ := heavy.Jobs[0].Properties["tags"].([]interface{}); len(tags) != 1 || tags[0] != "heavy" {
+					return "heavy pool tags not set"
+				}
+				if tags, _ := docker.Jobs[0].Properties["tags"].([]interface{}); len(tags) != 2 || tags[1] != "privileged" {
+					return "docker pool tags not set"
+				}
+				return ""
+			},
+		},
+		{
+			name:     "manifest without workers",
+			manifest: "---\nname: concourse\ninstance_groups: []\n",
+			pools:    []config.WorkerPool{{Name: "build", Count: 1, Size: "xlarge", VMProvisioningType: config.ON_DEMAND}},
+			wantErr:  "no worker instance group",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := workerPoolsOps([]byte(tt.manifest), tt.pools)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("workerPoolsOps() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("workerPoolsOps() error = %v", err)
+			}
+
+			manifest, err := yaml.Interpolate(tt.manifest, string(ops), map[string]interface{}{"worker_count": 1})
+			if err != nil {
+				t.Fatalf("failed to apply ops %s: %v", ops, err)
+			}
+			var m struct {
+				InstanceGroups []testInstanceGroup `json:"instance_groups"`
+			}
+			if err = ghodss.Unmarshal([]byte(manifest), &m); err != nil {
+				t.Fatal(err)
+			}
+			if problem := tt.check(m.InstanceGroups); problem != "" {
+				t.Errorf("workerPoolsOps() %s in:\n%s", problem, manifest)
+			}
+		})
+	}
+}
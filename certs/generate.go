@@ -24,6 +24,7 @@ import (
 	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/challenge"
 	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/providers/dns/azure"
 	"github.com/go-acme/lego/v4/providers/dns/gcloud"
 	"github.com/go-acme/lego/v4/providers/dns/route53"
 	"github.com/go-acme/lego/v4/registration"
@@ -112,6 +113,33 @@ func customNewDNSProviderServiceAccount(saFile string, config *gcloud.Config) (*
 	return gcloud.NewDNSProviderConfig(config)
 }
 
+func azureDNSConfig(provider iaas.Provider, domain string) (*azure.Config, error) {
+	dnsConfig := azure.NewDefaultConfig()
+	dnsConfig.PropagationTimeout = 10 * time.Minute
+	dnsConfig.PollingInterval = 30 * time.Second
+
+	for key, field := range map[string]*string{
+		"subscription_id": &dnsConfig.SubscriptionID,
+		"tenant_id":       &dnsConfig.TenantID,
+		"client_id":       &dnsConfig.ClientID,
+		"client_secret":   &dnsConfig.ClientSecret,
+	} {
+		value, err := provider.Attr(key)
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+
+	_, zoneID, err := provider.FindLongestMatchingHostedZone(domain)
+	if err != nil {
+		return nil, fmt.Errorf("azure: failed to find DNS zone for %s: [%v]", domain, err)
+	}
+	dnsConfig.ResourceGroup = iaas.AzureResourceGroup(zoneID)
+
+	return dnsConfig, nil
+}
+
 // Generate generates certs for use in a bosh director manifest
 func Generate(constructor func(u *User) (*lego.Client, error), caName string, provider iaas.Provider, ipOrDomains ...string) (*Certs, error) {
 
@@ -154,6 +182,19 @@ func Generate(constructor func(u *User) (*lego.Client, error), caName string, pr
 		if err1 != nil {
 			return nil, err1
 		}
+	case iaas.Azure:
+		dnsConfig, err1 := azureDNSConfig(provider, ipOrDomains[0])
+		if err1 != nil {
+			return nil, err1
+		}
+		dnsProvider, err1 := azure.NewDNSProviderConfig(dnsConfig)
+		if err1 != nil {
+			return nil, err1
+		}
+		err1 = c.Challenge.SetDNS01Provider(dnsProvider)
+		if err1 != nil {
+			return nil, err1
+		}
 	}
 	u.r, err = c.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
 	if err != nil {
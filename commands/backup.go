@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/backup"
+	"github.com/EngineerBetter/control-tower/concourse"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/util"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var initialBackupArgs backup.Args
+
+var backupFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       "(optional) AWS region",
+		EnvVar:      "AWS_REGION",
+		Destination: &initialBackupArgs.Region,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
+		EnvVar:      "IAAS",
+		Destination: &initialBackupArgs.IAAS,
+	},
+	cli.StringFlag{
+		Name:        "namespace",
+		Usage:       "(optional) Specify a namespace for deployments in order to group them in a meaningful way",
+		EnvVar:      "NAMESPACE",
+		Destination: &initialBackupArgs.Namespace,
+	},
+	cli.StringFlag{
+		Name:        "output-file",
+		Usage:       "(optional) File to write the backup to. Defaults to <name>-backup-<timestamp>.tgz",
+		EnvVar:      "OUTPUT_FILE",
+		Destination: &initialBackupArgs.OutputFile,
+	},
+	cli.BoolFlag{
+		Name:        "online",
+		Usage:       "(optional) Back up the databases without stopping Concourse. Each database is consistent, but they may not be consistent with each other",
+		EnvVar:      "ONLINE",
+		Destination: &initialBackupArgs.Online,
+	},
+}
+
+func backupAction(c *cli.Context, backupArgs backup.Args, provider iaas.Provider) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return errors.New("Usage is `control-tower backup <name>`")
+	}
+
+	outputFile := backupArgs.OutputFile
+	if !backupArgs.OutputFileIsSet {
+		outputFile = fmt.Sprintf("%s-backup-%s.tgz", name, time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	version := c.App.Version
+
+	client, err := buildBackupClient(name, version, backupArgs, provider)
+	if err != nil {
+		return err
+	}
+
+	// The backup is streamed to a temporary file next to the output file, so a failed backup leaves nothing behind
+	artifact, err := ioutil.TempFile(filepath.Dir(outputFile), "."+filepath.Base(outputFile)+".")
+	if err != nil {
+		return fmt.Errorf("error creating backup file: [%v]", err)
+	}
+	defer os.Remove(artifact.Name())
+	if err = client.Backup(artifact, backupArgs.Online); err != nil {
+		artifact.Close()
+		return err
+	}
+	if err = artifact.Close(); err != nil {
+		return fmt.Errorf("error writing backup to %s: [%v]", outputFile, err)
+	}
+	if err = os.Rename(artifact.Name(), outputFile); err != nil {
+		return fmt.Errorf("error writing backup to %s: [%v]", outputFile, err)
+	}
+	fmt.Printf("Backup written to %s\n", outputFile)
+	return nil
+}
+
+func validateBackupArgs(c *cli.Context, backupArgs backup.Args) (backup.Args, error) {
+	err := backupArgs.MarkSetFlags(c)
+	if err != nil {
+		return backupArgs, fmt.Errorf("failed to mark set Backup flags: [%v]", err)
+	}
+
+	if err = backupArgs.Validate(); err != nil {
+		return backupArgs, fmt.Errorf("failed to validate Backup flags: [%v]", err)
+	}
+
+	return backupArgs, nil
+}
+
+func buildBackupClient(name, version string, backupArgs backup.Args, provider iaas.Provider) (*concourse.Client, error) {
+	versionFile, _ := provider.Choose(iaas.Choice{
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
+	}).([]byte)
+
+	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
+	}
+
+	configClient, err := buildConfigClient(provider, name, backupArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	client := concourse.NewClient(
+		provider,
+		terraformClient,
+		tfInputVarsFactory,
+		bosh.New,
+		fly.NewWithState(selfUpdateState()),
+		certs.Generate,
+		configClient,
+		nil,
+		os.Stdout,
+		os.Stderr,
+		events.Discard,
+		util.FindUserIP,
+		certs.NewAcmeClient,
+		util.GeneratePasswordWithLength,
+		util.EightRandomLetters,
+		util.GenerateSSHKeyPair,
+		version,
+		versionFile,
+	)
+
+	return client, nil
+}
+
+var backupCmd = cli.Command{
+	Name:      "backup",
+	Usage:     "Backs up a Concourse's databases, including CredHub, and its config to a file",
+	ArgsUsage: "<name>",
+	Flags:     backupFlags,
+	Action: func(c *cli.Context) error {
+		backupArgs, err := validateBackupArgs(c, initialBackupArgs)
+		if err != nil {
+			return fmt.Errorf("Error validating args on backup: [%v]", err)
+		}
+		iaasName, err := iaas.Validate(backupArgs.IAAS)
+		if err != nil {
+			return fmt.Errorf("Error mapping to supported IAASes on backup: [%v]", err)
+		}
+		provider, err := iaas.New(iaasName, backupArgs.Region)
+		if err != nil {
+			return fmt.Errorf("Error creating IAAS provider on backup: [%v]", err)
+		}
+		return backupAction(c, backupArgs, provider)
+	},
+}
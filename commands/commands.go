@@ -1,6 +1,14 @@
 package commands
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/util/bincache"
 	cli "gopkg.in/urfave/cli.v1"
 )
 
@@ -10,9 +18,22 @@ var Commands = []cli.Command{
 	destroyCmd,
 	infoCmd,
 	maintainCmd,
+	historyCmd,
+	upgradeCmd,
+	costCmd,
+	pauseCmd,
+	resumeCmd,
+	teamsCmd,
+	rollbackCmd,
+	backupCmd,
+	restoreCmd,
 }
 
 var nonInteractive bool
+var stateBackend config.StateBackend
+var encryption config.Encryption
+var lockTimeout time.Duration
+var forceUnlock bool
 
 // GlobalFlags are the global CLIflags
 var GlobalFlags = []cli.Flag{
@@ -22,9 +43,121 @@ var GlobalFlags = []cli.Flag{
 		Usage:       "Non interactive",
 		Destination: &nonInteractive,
 	},
+	cli.StringFlag{
+		Name:        "state-backend",
+		EnvVar:      "STATE_BACKEND",
+		Usage:       "(optional) Where to keep config and terraform state: iaas, local or s3",
+		Value:       config.IAASBackend,
+		Destination: &stateBackend.Type,
+	},
+	cli.StringFlag{
+		Name:        "state-dir",
+		EnvVar:      "STATE_DIR",
+		Usage:       "(optional) Directory to keep state in when --state-backend is local",
+		Destination: &stateBackend.Dir,
+	},
+	cli.StringFlag{
+		Name:        "state-endpoint",
+		EnvVar:      "STATE_ENDPOINT",
+		Usage:       "(optional) URL of the S3-compatible API to keep state in when --state-backend is s3",
+		Destination: &stateBackend.Endpoint,
+	},
+	cli.StringFlag{
+		Name:        "state-region",
+		EnvVar:      "STATE_REGION",
+		Usage:       "(optional) Region to use when --state-backend is s3",
+		Destination: &stateBackend.Region,
+	},
+	cli.StringFlag{
+		Name:        "state-access-key-id",
+		EnvVar:      "STATE_ACCESS_KEY_ID",
+		Usage:       "(optional) Access key ID to use when --state-backend is s3",
+		Destination: &stateBackend.AccessKeyID,
+	},
+	cli.StringFlag{
+		Name:        "state-secret-access-key",
+		EnvVar:      "STATE_SECRET_ACCESS_KEY",
+		Usage:       "(optional) Secret access key to use when --state-backend is s3",
+		Destination: &stateBackend.SecretAccessKey,
+	},
+	cli.StringFlag{
+		Name:        "encryption",
+		EnvVar:      "ENCRYPTION",
+		Usage:       "(optional) Encrypt stored config and credentials: passphrase, aws-kms or gcp-kms",
+		Destination: &encryption.Type,
+	},
+	cli.StringFlag{
+		Name:        "encryption-passphrase",
+		EnvVar:      "ENCRYPTION_PASSPHRASE",
+		Usage:       "(optional) Passphrase to use when --encryption is passphrase",
+		Destination: &encryption.Passphrase,
+	},
+	cli.StringFlag{
+		Name:        "encryption-kms-key-id",
+		EnvVar:      "ENCRYPTION_KMS_KEY_ID",
+		Usage:       "(optional) KMS key to use when --encryption is aws-kms or gcp-kms",
+		Destination: &encryption.KMSKeyID,
+	},
+	cli.DurationFlag{
+		Name:        "lock-timeout",
+		EnvVar:      "LOCK_TIMEOUT",
+		Usage:       "(optional) How long to wait for another deploy, destroy or maintain of the same deployment to finish, eg 30m",
+		Destination: &lockTimeout,
+	},
+	cli.BoolFlag{
+		Name:        "force-unlock",
+		EnvVar:      "FORCE_UNLOCK",
+		Usage:       "(optional) Take the deployment lock even if it is held, when its holder is known to have died",
+		Destination: &forceUnlock,
+	},
+	cli.StringFlag{
+		Name:        "binary-mirror",
+		EnvVar:      "BINARY_MIRROR",
+		Usage:       "(optional) Base URL or local directory to download the BOSH and Terraform CLIs from instead of their public URLs",
+		Destination: &bincache.Mirror,
+	},
+	cli.StringFlag{
+		Name:        "director-proxy",
+		EnvVar:      "DIRECTOR_PROXY",
+		Usage:       "(optional) Proxy to reach the BOSH director through, as socks5://host:port or ssh+socks5://user@host:port?private-key=path",
+		Destination: &bosh.DirectorProxy,
+	},
+}
+
+// ResolveGlobalFlags settles the global flags that depend on the directory control-tower was run from,
+// before any command uses them
+func ResolveGlobalFlags(*cli.Context) error {
+	var err error
+	stateBackend, err = stateBackend.Resolve()
+	return err
 }
 
 // NonInteractiveModeEnabled returns true if --non-interactive true has been passed in
 func NonInteractiveModeEnabled() bool {
 	return nonInteractive
 }
+
+// selfUpdateState tells the self-update pipeline where the global flags keep the deployment's state,
+// and how they encrypt it
+func selfUpdateState() fly.StateSettings {
+	return fly.StateSettings{Backend: stateBackend, Encryption: encryption}
+}
+
+// buildConfigClient returns a config client that uses the state backend, encryption and locking chosen by the global flags
+func buildConfigClient(provider iaas.Provider, name, namespace string) (*config.Client, error) {
+	backend, err := config.NewBackend(provider, stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating state backend [%v]", err)
+	}
+
+	keyWrapper, err := config.NewKeyWrapper(provider, encryption)
+	if err != nil {
+		return nil, fmt.Errorf("Error configuring encryption [%v]", err)
+	}
+
+	client := config.NewWithBackend(provider, backend, name, namespace)
+	client.KeyWrapper = keyWrapper
+	client.LockTimeout = lockTimeout
+	client.ForceUnlock = forceUnlock
+	return client, nil
+}
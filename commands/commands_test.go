@@ -17,11 +17,22 @@ var _ = Describe("commands", func() {
 				Expect(string(output)).To(ContainSubstring("--tls-cert value"))
 				Expect(string(output)).To(ContainSubstring("--tls-key value"))
 				Expect(string(output)).To(ContainSubstring("--db-size value"))
-				Expect(string(output)).To(MatchRegexp(`--vpc-network-range value\s+\(optional\) VPC network CIDR to deploy into, only required if IAAS is AWS`))
-				Expect(string(output)).To(MatchRegexp(`--public-subnet-range value\s+\(optional\) public network CIDR \(if IAAS is AWS must be within --vpc-network-range\)`))
-				Expect(string(output)).To(MatchRegexp(`--private-subnet-range value\s+\(optional\) private network CIDR \(if IAAS is AWS must be within --vpc-network-range\)`))
+				Expect(string(output)).To(MatchRegexp(`--vpc-network-range value\s+\(optional\) VPC network CIDR to deploy into, only required if IAAS is AWS or Azure`))
+				Expect(string(output)).To(MatchRegexp(`--public-subnet-range value\s+\(optional\) public network CIDR \(if IAAS is AWS or Azure must be within --vpc-network-range\)`))
+				Expect(string(output)).To(MatchRegexp(`--private-subnet-range value\s+\(optional\) private network CIDR \(if IAAS is AWS or Azure must be within --vpc-network-range\)`))
 				Expect(string(output)).To(MatchRegexp(`--rds-subnet-range1 value\s+\(optional\) first rds network CIDR \(if IAAS is AWS must be within --vpc-network-range\)`))
 				Expect(string(output)).To(MatchRegexp(`--rds-subnet-range2 value\s+\(optional\) second rds network CIDR \(if IAAS is AWS must be within --vpc-network-range\)`))
+				Expect(string(output)).To(MatchRegexp(`--private\s+\(optional\) Deploy without public IPs`))
+				Expect(string(output)).To(MatchRegexp(`--instance-identity\s+\(optional\) Give the director IAM instance profiles`))
+				Expect(string(output)).To(MatchRegexp(`--concourse-ops-file value\s+\(optional\) BOSH ops file to apply to the Concourse manifest`))
+				Expect(string(output)).To(MatchRegexp(`--director-ops-file value\s+\(optional\) BOSH ops file to apply to the director manifest`))
+				Expect(string(output)).To(MatchRegexp(`--vars-file value\s+\(optional\) YAML file of variables used by the ops files`))
+				Expect(string(output)).To(MatchRegexp(`--web-count value\s+\(optional\) Number of Concourse web nodes`))
+				Expect(string(output)).To(MatchRegexp(`--release-channel value\s+\(optional\) Releases of control-tower the self-update pipeline deploys`))
+				Expect(string(output)).To(MatchRegexp(`--maintenance-window off\s+\(optional\) Hours as HH:MM-HH:MM`))
+				Expect(string(output)).To(MatchRegexp(`--upgrade-approval\s+\(optional\) Make the self-update pipeline wait for the approve-upgrade job`))
+				Expect(string(output)).To(MatchRegexp(`--notify-slack-webhook value\s+\(optional\) Slack incoming webhook URL to report self-update upgrades to`))
+				Expect(string(output)).To(MatchRegexp(`--notify-email value\s+\(optional\) Comma separated addresses to email self-update upgrade events to`))
 			})
 		})
 
@@ -96,6 +107,14 @@ var _ = Describe("commands", func() {
 				Eventually(string(output)).Should(ContainSubstring("unknown DB size"))
 			})
 		})
+
+		When("the deployment file does not exist", func() {
+			It("shows a meaningful error", func() {
+				output, err := controlTowerCommand("deploy", "abc", "--file", "missing-control-tower.yml").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("failed to read deployment file"))
+			})
+		})
 	})
 
 	Describe("destroy", func() {
@@ -156,6 +175,9 @@ var _ = Describe("commands", func() {
 				output, err := controlTowerCommand("maintain", "--help", "--iaas", "AWS").CombinedOutput()
 				Expect(err).NotTo(HaveOccurred(), string(output))
 				Expect(string(output)).To(ContainSubstring("control-tower maintain - Handles maintenance operations in control-tower"))
+				Expect(string(output)).To(ContainSubstring("--recover-expired-nats-cert"))
+				Expect(string(output)).To(ContainSubstring("--renew-director-cert"))
+				Expect(string(output)).To(ContainSubstring("--rotate-self-update-creds"))
 			})
 		})
 
@@ -175,4 +197,220 @@ var _ = Describe("commands", func() {
 			})
 		})
 	})
+
+	Describe("history", func() {
+		When("using --help", func() {
+			It("displays usage details", func() {
+				output, err := controlTowerCommand("history", "--help").CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("control-tower history - Lists the recorded revisions of a Concourse's config"))
+			})
+		})
+
+		When("the IAAS is not specified", func() {
+			It("shows a meaningful error", func() {
+				output, err := controlTowerCommand("history", "abc").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(MatchRegexp(`Error validating args on history: \[failed to validate History flags: \[--iaas flag not set\]\]`))
+			})
+		})
+
+		When("no name is passed in", func() {
+			It("displays correct usage", func() {
+				output, err := controlTowerCommand("history", "--iaas", "AWS").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("Usage is `control-tower history <name>`"))
+			})
+		})
+	})
+
+	Describe("upgrade", func() {
+		When("using --help", func() {
+			It("displays usage details", func() {
+				output, err := controlTowerCommand("upgrade", "--help").CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("control-tower upgrade - Checks for a newer release of control-tower on a Concourse's release channel"))
+				Expect(string(output)).To(MatchRegexp(`--check\s+\(required\) Report the newest release`))
+			})
+		})
+
+		When("--check is not passed", func() {
+			It("shows a meaningful error", func() {
+				output, err := controlTowerCommand("upgrade", "--iaas", "AWS", "abc").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(MatchRegexp(`Error validating args on upgrade: \[failed to validate Upgrade flags: \[--check flag not set`))
+			})
+		})
+
+		When("no name is passed in", func() {
+			It("displays correct usage", func() {
+				output, err := controlTowerCommand("upgrade", "--iaas", "AWS", "--check").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("Usage is `control-tower upgrade --check <name>`"))
+			})
+		})
+	})
+
+	Describe("cost", func() {
+		When("using --help", func() {
+			It("displays usage details", func() {
+				output, err := controlTowerCommand("cost", "--help").CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("control-tower cost - Estimates what a Concourse costs to run each month"))
+				Expect(string(output)).To(ContainSubstring("--worker-size"))
+			})
+		})
+
+		When("no name is passed in", func() {
+			It("displays correct usage", func() {
+				output, err := controlTowerCommand("cost", "--iaas", "AWS").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("Usage is `control-tower cost <name>`"))
+			})
+		})
+	})
+
+	Describe("pause", func() {
+		When("using --help", func() {
+			It("displays usage details", func() {
+				output, err := controlTowerCommand("pause", "--help").CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("control-tower pause - Scales a Concourse's workers down, or hibernates it, until it is resumed"))
+				Expect(string(output)).To(ContainSubstring("--hibernate"))
+			})
+		})
+
+		When("no name is passed in", func() {
+			It("displays correct usage", func() {
+				output, err := controlTowerCommand("pause", "--iaas", "AWS").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("Usage is `control-tower pause <name>`"))
+			})
+		})
+	})
+
+	Describe("resume", func() {
+		When("using --help", func() {
+			It("displays usage details", func() {
+				output, err := controlTowerCommand("resume", "--help").CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("control-tower resume - Brings back the web and worker VMs of a paused Concourse"))
+			})
+		})
+
+		When("no name is passed in", func() {
+			It("displays correct usage", func() {
+				output, err := controlTowerCommand("resume", "--iaas", "AWS").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("Usage is `control-tower resume <name>`"))
+			})
+		})
+	})
+
+	Describe("teams", func() {
+		When("using --help", func() {
+			It("displays usage details", func() {
+				output, err := controlTowerCommand("teams", "apply", "--help").CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("teams apply - Creates or updates the teams in a teams file"))
+			})
+		})
+
+		When("no teams file is passed to apply", func() {
+			It("displays correct usage", func() {
+				output, err := controlTowerCommand("teams", "apply", "--iaas", "AWS", "my-deployment").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("--file flag not set"))
+			})
+		})
+
+		When("no name is passed in", func() {
+			It("displays correct usage", func() {
+				output, err := controlTowerCommand("teams", "diff", "--iaas", "AWS").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("Usage is `control-tower teams diff [--file <teams.yml>] <name>`"))
+			})
+		})
+	})
+
+	Describe("rollback", func() {
+		When("using --help", func() {
+			It("displays usage details", func() {
+				output, err := controlTowerCommand("rollback", "--help").CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("control-tower rollback - Restores a recorded revision of a Concourse's config and redeploys it"))
+				Expect(string(output)).To(ContainSubstring("--restore-director-state"))
+			})
+		})
+
+		When("the IAAS is not specified", func() {
+			It("shows a meaningful error", func() {
+				output, err := controlTowerCommand("rollback", "abc", "1").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(MatchRegexp(`Error validating args on rollback: \[failed to validate Rollback flags: \[--iaas flag not set\]\]`))
+			})
+		})
+
+		When("no revision is passed in", func() {
+			It("displays correct usage", func() {
+				output, err := controlTowerCommand("rollback", "--iaas", "AWS", "abc").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("Usage is `control-tower rollback <name> <revision>`"))
+			})
+		})
+	})
+
+	Describe("backup", func() {
+		When("using --help", func() {
+			It("displays usage details", func() {
+				output, err := controlTowerCommand("backup", "--help").CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("control-tower backup - Backs up a Concourse's databases, including CredHub, and its config to a file"))
+				Expect(string(output)).To(ContainSubstring("--output-file"))
+			})
+		})
+
+		When("the IAAS is not specified", func() {
+			It("shows a meaningful error", func() {
+				output, err := controlTowerCommand("backup", "abc").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(MatchRegexp(`Error validating args on backup: \[failed to validate Backup flags: \[--iaas flag not set\]\]`))
+			})
+		})
+
+		When("no name is passed in", func() {
+			It("displays correct usage", func() {
+				output, err := controlTowerCommand("backup", "--iaas", "AWS").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("Usage is `control-tower backup <name>`"))
+			})
+		})
+	})
+
+	Describe("restore", func() {
+		When("using --help", func() {
+			It("displays usage details", func() {
+				output, err := controlTowerCommand("restore", "--help").CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("control-tower restore - Deploys a new Concourse from a backup"))
+				Expect(string(output)).To(ContainSubstring("--from-file"))
+			})
+		})
+
+		When("the backup file is not specified", func() {
+			It("shows a meaningful error", func() {
+				output, err := controlTowerCommand("restore", "--iaas", "AWS", "abc").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(MatchRegexp(`Error validating args on restore: \[failed to validate Restore flags: \[--from-file flag not set\]\]`))
+			})
+		})
+
+		When("the backup file does not exist", func() {
+			It("shows a meaningful error", func() {
+				output, err := controlTowerCommand("restore", "--iaas", "AWS", "--from-file", "/no/such/backup.tgz", "abc").CombinedOutput()
+				Expect(err).To(HaveOccurred(), string(output))
+				Expect(string(output)).To(ContainSubstring("error reading backup: [open /no/such/backup.tgz: no such file or directory]"))
+			})
+		})
+	})
 })
@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/cost"
+	"github.com/EngineerBetter/control-tower/concourse"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/util"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var initialCostArgs cost.Args
+
+var costFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       "(optional) AWS region",
+		EnvVar:      "AWS_REGION",
+		Destination: &initialCostArgs.Region,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
+		EnvVar:      "IAAS",
+		Destination: &initialCostArgs.IAAS,
+	},
+	cli.StringFlag{
+		Name:        "namespace",
+		Usage:       "(optional) Specify a namespace for deployments in order to group them in a meaningful way",
+		EnvVar:      "NAMESPACE",
+		Destination: &initialCostArgs.Namespace,
+	},
+	cli.IntFlag{
+		Name:        "workers",
+		Usage:       "(optional) Price this number of Concourse workers instead of the deployed number",
+		Destination: &initialCostArgs.WorkerCount,
+	},
+	cli.StringFlag{
+		Name:        "worker-size",
+		Usage:       "(optional) Price workers of this size instead of the deployed size. Can be medium, large, xlarge, 2xlarge, 4xlarge, 12xlarge or 24xlarge",
+		Destination: &initialCostArgs.WorkerSize,
+	},
+	cli.StringFlag{
+		Name:        "worker-type",
+		Usage:       "(optional) Price workers of this type for aws (m5, m5a, or m4) instead of the deployed type",
+		Destination: &initialCostArgs.WorkerType,
+	},
+	cli.StringFlag{
+		Name:        "web-size",
+		Usage:       "(optional) Price a web node of this size instead of the deployed size. Can be small, medium, large, xlarge, 2xlarge",
+		Destination: &initialCostArgs.WebSize,
+	},
+	cli.IntFlag{
+		Name:        "web-count",
+		Usage:       "(optional) Price this number of Concourse web nodes, and the load balancer in front of more than one, instead of the deployed number",
+		Destination: &initialCostArgs.WebCount,
+	},
+	cli.StringFlag{
+		Name:        "db-size",
+		Usage:       "(optional) Price a database of this size instead of the deployed size. Can be small, medium, large, xlarge, 2xlarge, or 4xlarge",
+		Destination: &initialCostArgs.DBSize,
+	},
+	cli.BoolTFlag{
+		Name:        "spot",
+		Usage:       "(optional) Price workers as spot instances, or as on-demand instances with --spot=false, instead of as deployed",
+		Destination: &initialCostArgs.Spot,
+	},
+	cli.BoolTFlag{
+		Name:        "preemptible",
+		Usage:       "(optional) Price workers as preemptible instances, or as on-demand instances with --preemptible=false, instead of as deployed",
+		Destination: &initialCostArgs.Spot,
+	},
+}
+
+func costAction(c *cli.Context, costArgs cost.Args, provider iaas.Provider) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return errors.New("Usage is `control-tower cost <name>`")
+	}
+
+	version := c.App.Version
+
+	client, err := buildCostClient(name, version, costArgs, provider)
+	if err != nil {
+		return err
+	}
+	return client.Cost(costArgs)
+}
+
+func validateCostArgs(c *cli.Context, costArgs cost.Args) (cost.Args, error) {
+	err := costArgs.MarkSetFlags(c)
+	if err != nil {
+		return costArgs, fmt.Errorf("failed to mark set Cost flags: [%v]", err)
+	}
+
+	if err = costArgs.Validate(); err != nil {
+		return costArgs, fmt.Errorf("failed to validate Cost flags: [%v]", err)
+	}
+
+	return costArgs, nil
+}
+
+func buildCostClient(name, version string, costArgs cost.Args, provider iaas.Provider) (*concourse.Client, error) {
+	versionFile, _ := provider.Choose(iaas.Choice{
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
+	}).([]byte)
+
+	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
+	}
+
+	configClient, err := buildConfigClient(provider, name, costArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	client := concourse.NewClient(
+		provider,
+		terraformClient,
+		tfInputVarsFactory,
+		bosh.New,
+		fly.NewWithState(selfUpdateState()),
+		certs.Generate,
+		configClient,
+		nil,
+		os.Stdout,
+		os.Stderr,
+		events.Discard,
+		util.FindUserIP,
+		certs.NewAcmeClient,
+		util.GeneratePasswordWithLength,
+		util.EightRandomLetters,
+		util.GenerateSSHKeyPair,
+		version,
+		versionFile,
+	)
+
+	return client, nil
+}
+
+var costCmd = cli.Command{
+	Name:      "cost",
+	Usage:     "Estimates what a Concourse costs to run each month, or would cost after a change to its sizes",
+	ArgsUsage: "<name>",
+	Flags:     costFlags,
+	Action: func(c *cli.Context) error {
+		costArgs, err := validateCostArgs(c, initialCostArgs)
+		if err != nil {
+			return fmt.Errorf("Error validating args on cost: [%v]", err)
+		}
+		iaasName, err := iaas.Validate(costArgs.IAAS)
+		if err != nil {
+			return fmt.Errorf("Error mapping to supported IAASes on cost: [%v]", err)
+		}
+		provider, err := iaas.New(iaasName, costArgs.Region)
+		if err != nil {
+			return fmt.Errorf("Error creating IAAS provider on cost: [%v]", err)
+		}
+		return costAction(c, costArgs, provider)
+	},
+}
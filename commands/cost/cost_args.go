@@ -0,0 +1,131 @@
+package cost
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// Args are arguments passed to the cost command. Sizing flags describe a change to price
+// instead of the deployment as it is stored
+type Args struct {
+	Region           string
+	RegionIsSet      bool
+	IAAS             string
+	IAASIsSet        bool
+	Namespace        string
+	NamespaceIsSet   bool
+	WorkerCount      int
+	WorkerCountIsSet bool
+	WorkerSize       string
+	WorkerSizeIsSet  bool
+	WorkerType       string
+	WorkerTypeIsSet  bool
+	WebSize          string
+	WebSizeIsSet     bool
+	WebCount         int
+	WebCountIsSet    bool
+	DBSize           string
+	DBSizeIsSet      bool
+	Spot             bool
+	SpotIsSet        bool
+}
+
+// MarkSetFlags is marking which cost Args have been set
+func (a *Args) MarkSetFlags(c FlagSetChecker) error {
+	for _, f := range c.FlagNames() {
+		if c.IsSet(f) {
+			switch f {
+			case "region":
+				a.RegionIsSet = true
+			case "iaas":
+				a.IAASIsSet = true
+			case "namespace":
+				a.NamespaceIsSet = true
+			case "workers":
+				a.WorkerCountIsSet = true
+			case "worker-size":
+				a.WorkerSizeIsSet = true
+			case "worker-type":
+				a.WorkerTypeIsSet = true
+			case "web-size":
+				a.WebSizeIsSet = true
+			case "web-count":
+				a.WebCountIsSet = true
+			case "db-size":
+				a.DBSizeIsSet = true
+			case "spot", "preemptible":
+				a.SpotIsSet = true
+			default:
+				return fmt.Errorf("flag %q is not supported by cost flags", f)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the sizes to price are ones a deploy accepts
+func (a *Args) Validate() error {
+	if !a.IAASIsSet {
+		return fmt.Errorf("--iaas flag not set")
+	}
+	if a.WorkerCountIsSet && a.WorkerCount < 1 {
+		return fmt.Errorf("minimum number of workers is 1")
+	}
+	if a.WorkerTypeIsSet && strings.ToLower(a.IAAS) != "aws" {
+		return fmt.Errorf("worker-type is only defined on AWS")
+	}
+	if a.WorkerTypeIsSet && !contains([]string{"m4", "m5", "m5a"}, a.WorkerType) {
+		return fmt.Errorf("worker-type %s is invalid: must be one of m4, m5, or m5a", a.WorkerType)
+	}
+	if a.WorkerSizeIsSet && !contains(deploy.WorkerSizes, a.WorkerSize) {
+		return fmt.Errorf("unknown worker size: `%s`. Valid sizes are: %v", a.WorkerSize, deploy.WorkerSizes)
+	}
+	if a.WebSizeIsSet && !contains(deploy.WebSizes, a.WebSize) {
+		return fmt.Errorf("unknown web node size: `%s`. Valid sizes are: %v", a.WebSize, deploy.WebSizes)
+	}
+	if a.WebCountIsSet && a.WebCount < 1 {
+		return fmt.Errorf("minimum number of web nodes is 1")
+	}
+	if a.DBSizeIsSet && !contains(deploy.AllowedDBSizes, a.DBSize) {
+		return fmt.Errorf("unknown DB size: `%s`. Valid sizes are: %v", a.DBSize, deploy.AllowedDBSizes)
+	}
+	return nil
+}
+
+// WhatIf returns true if any sizing flags were given
+func (a *Args) WhatIf() bool {
+	return a.WorkerCountIsSet || a.WorkerSizeIsSet || a.WorkerTypeIsSet || a.WebSizeIsSet || a.WebCountIsSet || a.DBSizeIsSet || a.SpotIsSet
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FlagSetChecker allows us to find out if flags were set, adn what the names of all flags are
+type FlagSetChecker interface {
+	IsSet(name string) bool
+	FlagNames() (names []string)
+}
+
+// ContextWrapper wraps a CLI context for testing
+type ContextWrapper struct {
+	c *cli.Context
+}
+
+// IsSet tells you if a user provided a flag
+func (t *ContextWrapper) IsSet(name string) bool {
+	return t.c.IsSet(name)
+}
+
+// FlagNames lists all flags it's possible for a user to provide
+func (t *ContextWrapper) FlagNames() (names []string) {
+	return t.c.FlagNames()
+}
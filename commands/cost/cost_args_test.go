@@ -0,0 +1,157 @@
+package cost_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/EngineerBetter/control-tower/commands/cost"
+)
+
+func TestCostArgs_Validate(t *testing.T) {
+	defaultFields := Args{
+		Region:    "eu-west-1",
+		IAAS:      "AWS",
+		IAASIsSet: true,
+	}
+	tests := []struct {
+		name         string
+		modification func() Args
+		wantErr      bool
+		expectedErr  string
+	}{
+		{
+			name: "Default args",
+			modification: func() Args {
+				return defaultFields
+			},
+			wantErr: false,
+		},
+		{
+			name: "IAAS not set",
+			modification: func() Args {
+				args := defaultFields
+				args.IAASIsSet = false
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--iaas flag not set",
+		},
+		{
+			name: "What if workers are resized",
+			modification: func() Args {
+				args := defaultFields
+				args.WorkerCount = 6
+				args.WorkerCountIsSet = true
+				args.WorkerSize = "4xlarge"
+				args.WorkerSizeIsSet = true
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "No workers",
+			modification: func() Args {
+				args := defaultFields
+				args.WorkerCountIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "minimum number of workers is 1",
+		},
+		{
+			name: "Unknown worker size",
+			modification: func() Args {
+				args := defaultFields
+				args.WorkerSize = "huge"
+				args.WorkerSizeIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "unknown worker size: `huge`",
+		},
+		{
+			name: "Unknown web size",
+			modification: func() Args {
+				args := defaultFields
+				args.WebSize = "huge"
+				args.WebSizeIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "unknown web node size: `huge`",
+		},
+		{
+			name: "No web nodes",
+			modification: func() Args {
+				args := defaultFields
+				args.WebCount = 0
+				args.WebCountIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "minimum number of web nodes is 1",
+		},
+		{
+			name: "Unknown DB size",
+			modification: func() Args {
+				args := defaultFields
+				args.DBSize = "huge"
+				args.DBSizeIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "unknown DB size: `huge`",
+		},
+		{
+			name: "Worker type on GCP",
+			modification: func() Args {
+				args := defaultFields
+				args.IAAS = "GCP"
+				args.WorkerType = "m5"
+				args.WorkerTypeIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "worker-type is only defined on AWS",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.modification()
+			err := args.Validate()
+			if (err != nil) != tt.wantErr || (err != nil && tt.wantErr && !strings.Contains(err.Error(), tt.expectedErr)) {
+				if err != nil {
+					t.Errorf("CostArgs.Validate() %v test failed.\nFailed with error = %v,\nExpected error = %v,\nShould fail %v\nWith args: %#v", tt.name, err.Error(), tt.expectedErr, tt.wantErr, args)
+				} else {
+					t.Errorf("CostArgs.Validate() %v test failed.\nShould fail %v\nWith args: %#v", tt.name, tt.wantErr, args)
+				}
+			}
+		})
+	}
+}
+
+func TestCostArgs_MarkSetFlags(t *testing.T) {
+	var args Args
+	err := args.MarkSetFlags(&fakeFlagSetChecker{names: []string{"iaas", "workers", "worker-size", "preemptible"}})
+	if err != nil {
+		t.Fatalf("CostArgs.MarkSetFlags() error = %v", err)
+	}
+	if !args.IAASIsSet || !args.WorkerCountIsSet || !args.WorkerSizeIsSet || !args.SpotIsSet || args.WebSizeIsSet {
+		t.Errorf("CostArgs.MarkSetFlags() marked %#v", args)
+	}
+	if !args.WhatIf() {
+		t.Errorf("CostArgs.WhatIf() = false, want true")
+	}
+}
+
+type fakeFlagSetChecker struct {
+	names []string
+}
+
+func (f *fakeFlagSetChecker) IsSet(name string) bool {
+	return true
+}
+
+func (f *fakeFlagSetChecker) FlagNames() []string {
+	return f.names
+}
@@ -3,11 +3,13 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"net"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"gopkg.in/urfave/cli.v1"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/EngineerBetter/control-tower/commands/deploy"
 	"github.com/EngineerBetter/control-tower/concourse"
 	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/iaas"
 	"github.com/EngineerBetter/control-tower/resource"
@@ -28,6 +31,12 @@ const maxAllowedNameLength = 11
 var initialDeployArgs deploy.Args
 
 var deployFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "file, f",
+		Usage:       "(optional) YAML deployment file setting any of these flags. Flags given on the command line take precedence",
+		EnvVar:      "DEPLOYMENT_FILE",
+		Destination: &initialDeployArgs.SpecFile,
+	},
 	cli.StringFlag{
 		Name:        "region",
 		Usage:       "(optional) AWS region",
@@ -80,9 +89,15 @@ var deployFlags = []cli.Flag{
 		Value:       "small",
 		Destination: &initialDeployArgs.WebSize,
 	},
+	cli.IntFlag{
+		Name:        "web-count",
+		Usage:       "(optional) Number of Concourse web nodes. More than 1 spreads them across two availability zones behind a load balancer, so that upgrades have no downtime. Only supported on AWS and GCP (default: 1)",
+		EnvVar:      "WEB_COUNT",
+		Destination: &initialDeployArgs.WebCount,
+	},
 	cli.StringFlag{
 		Name:        "iaas",
-		Usage:       "(required) IAAS, can be AWS or GCP",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
 		EnvVar:      "IAAS",
 		Destination: &initialDeployArgs.IAAS,
 	},
@@ -93,6 +108,19 @@ var deployFlags = []cli.Flag{
 		Hidden:      true,
 		Destination: &initialDeployArgs.SelfUpdate,
 	},
+	cli.BoolFlag{
+		Name:        "dry-run",
+		Usage:       "(optional) Show the terraform, BOSH director and Concourse changes a deploy would make without applying them",
+		EnvVar:      "DRY_RUN",
+		Destination: &initialDeployArgs.DryRun,
+	},
+	cli.StringFlag{
+		Name:        "output",
+		Usage:       "(optional) How to report progress: text, or json-events to write NDJSON phase events to stdout and everything else to stderr",
+		EnvVar:      "OUTPUT",
+		Value:       deploy.OutputText,
+		Destination: &initialDeployArgs.Output,
+	},
 	cli.BoolFlag{
 		Name:        "enable-global-resources",
 		Usage:       "(optional) Enables Concourse global resources. Can be true/false (default: false)",
@@ -132,7 +160,7 @@ var deployFlags = []cli.Flag{
 	},
 	cli.StringFlag{
 		Name:        "allow-ips",
-		Usage:       "(optional) Comma separated list of IP addresses or CIDR ranges to allow access to. Not applied to future manual deploys unless this flag is provided again",
+		Usage:       "(optional) Comma separated list of IP addresses or CIDR ranges to allow access to. Not applied to future manual deploys unless this flag is provided again or set in a deployment file",
 		EnvVar:      "ALLOW_IPS",
 		Value:       "0.0.0.0/0",
 		Destination: &initialDeployArgs.AllowIPs,
@@ -179,11 +207,247 @@ var deployFlags = []cli.Flag{
 		EnvVar:      "MICROSOFT_AUTH_TENANT",
 		Destination: &initialDeployArgs.MicrosoftAuthTenant,
 	},
+	cli.StringFlag{
+		Name:        "oidc-auth-issuer",
+		Usage:       "(optional) Issuer URL of an OpenID Connect provider such as Okta or Keycloak - Used for OIDC Auth",
+		EnvVar:      "OIDC_AUTH_ISSUER",
+		Destination: &initialDeployArgs.OIDCAuthIssuer,
+	},
+	cli.StringFlag{
+		Name:        "oidc-auth-client-id",
+		Usage:       "(optional) Client ID for an OpenID Connect application - Used for OIDC Auth",
+		EnvVar:      "OIDC_AUTH_CLIENT_ID",
+		Destination: &initialDeployArgs.OIDCAuthClientID,
+	},
+	cli.StringFlag{
+		Name:        "oidc-auth-client-secret",
+		Usage:       "(optional) Client Secret for an OpenID Connect application, kept in the BOSH vars store - Used for OIDC Auth",
+		EnvVar:      "OIDC_AUTH_CLIENT_SECRET",
+		Destination: &initialDeployArgs.OIDCAuthClientSecret,
+	},
+	cli.StringFlag{
+		Name:        "oidc-auth-scopes",
+		Usage:       "(optional) Comma separated scopes to request from the OpenID Connect provider (default: profile,email,groups) - Used for OIDC Auth",
+		EnvVar:      "OIDC_AUTH_SCOPES",
+		Destination: &initialDeployArgs.OIDCAuthScopes,
+	},
+	cli.StringFlag{
+		Name:        "oidc-auth-groups-claim",
+		Usage:       "(optional) Claim of the ID token listing a user's groups (default: groups) - Used for OIDC Auth",
+		EnvVar:      "OIDC_AUTH_GROUPS_CLAIM",
+		Destination: &initialDeployArgs.OIDCAuthGroupsClaim,
+	},
+	cli.StringFlag{
+		Name:        "ldap-auth-host",
+		Usage:       "(optional) Host and optional port of an LDAP server - Used for LDAP Auth",
+		EnvVar:      "LDAP_AUTH_HOST",
+		Destination: &initialDeployArgs.LDAPAuthHost,
+	},
+	cli.StringFlag{
+		Name:        "ldap-auth-bind-dn",
+		Usage:       "(optional) DN to bind to the LDAP server with when searching - Used for LDAP Auth",
+		EnvVar:      "LDAP_AUTH_BIND_DN",
+		Destination: &initialDeployArgs.LDAPAuthBindDN,
+	},
+	cli.StringFlag{
+		Name:        "ldap-auth-bind-password",
+		Usage:       "(optional) Password for the bind DN, kept in the BOSH vars store - Used for LDAP Auth",
+		EnvVar:      "LDAP_AUTH_BIND_PASSWORD",
+		Destination: &initialDeployArgs.LDAPAuthBindPassword,
+	},
+	cli.StringFlag{
+		Name:        "ldap-auth-user-search-base-dn",
+		Usage:       "(optional) Base DN to search for users in - Used for LDAP Auth",
+		EnvVar:      "LDAP_AUTH_USER_SEARCH_BASE_DN",
+		Destination: &initialDeployArgs.LDAPAuthUserSearchBaseDN,
+	},
+	cli.StringFlag{
+		Name:        "ldap-auth-user-search-filter",
+		Usage:       "(optional) Filter applied when searching for users, e.g. (objectClass=person) - Used for LDAP Auth",
+		EnvVar:      "LDAP_AUTH_USER_SEARCH_FILTER",
+		Destination: &initialDeployArgs.LDAPAuthUserSearchFilter,
+	},
+	cli.StringFlag{
+		Name:        "ldap-auth-user-search-username",
+		Usage:       "(optional) Attribute matched against the username given at login (default: uid) - Used for LDAP Auth",
+		EnvVar:      "LDAP_AUTH_USER_SEARCH_USERNAME",
+		Destination: &initialDeployArgs.LDAPAuthUserSearchUsername,
+	},
+	cli.StringFlag{
+		Name:        "ldap-auth-group-search-base-dn",
+		Usage:       "(optional) Base DN to search for groups in. Groups are not searched for without it - Used for LDAP Auth",
+		EnvVar:      "LDAP_AUTH_GROUP_SEARCH_BASE_DN",
+		Destination: &initialDeployArgs.LDAPAuthGroupSearchBaseDN,
+	},
+	cli.StringFlag{
+		Name:        "ldap-auth-group-search-filter",
+		Usage:       "(optional) Filter applied when searching for groups, e.g. (objectClass=groupOfNames) - Used for LDAP Auth",
+		EnvVar:      "LDAP_AUTH_GROUP_SEARCH_FILTER",
+		Destination: &initialDeployArgs.LDAPAuthGroupSearchFilter,
+	},
+	cli.StringFlag{
+		Name:        "gitlab-auth-client-id",
+		Usage:       "(optional) Client ID for a GitLab OAuth application - Used for GitLab Auth",
+		EnvVar:      "GITLAB_AUTH_CLIENT_ID",
+		Destination: &initialDeployArgs.GitLabAuthClientID,
+	},
+	cli.StringFlag{
+		Name:        "gitlab-auth-client-secret",
+		Usage:       "(optional) Client Secret for a GitLab OAuth application, kept in the BOSH vars store - Used for GitLab Auth",
+		EnvVar:      "GITLAB_AUTH_CLIENT_SECRET",
+		Destination: &initialDeployArgs.GitLabAuthClientSecret,
+	},
+	cli.StringFlag{
+		Name:        "gitlab-auth-host",
+		Usage:       "(optional) URL of a self-hosted GitLab (default: https://gitlab.com) - Used for GitLab Auth",
+		EnvVar:      "GITLAB_AUTH_HOST",
+		Destination: &initialDeployArgs.GitLabAuthHost,
+	},
+	cli.StringSliceFlag{
+		Name:  "worker-pool",
+		Usage: "(optional) Extra pool of workers in the format name:count:size[:spot|on-demand[:tag,tag...]], e.g. heavy:6:4xlarge:spot:heavy - Multiple pools can be added with multiple uses of this flag, and replace any existing pools",
+		Value: &initialDeployArgs.WorkerPoolSpecs,
+	},
+	cli.StringFlag{
+		Name:        "worker-pools-file",
+		Usage:       "(optional) YAML file listing extra pools of workers, which replace any existing pools",
+		EnvVar:      "WORKER_POOLS_FILE",
+		Destination: &initialDeployArgs.WorkerPoolsFile,
+	},
+	cli.StringFlag{
+		Name:        "schedule",
+		Usage:       "(optional) Working hours as HH:MM-HH:MM, e.g. 07:00-19:00. Outside them the self-update pipeline scales the workers down and stops any worker pools. Use `off` to remove the schedule",
+		EnvVar:      "SCHEDULE",
+		Destination: &initialDeployArgs.Schedule,
+	},
+	cli.StringFlag{
+		Name:        "schedule-days",
+		Usage:       "(optional) Comma separated days that working hours start on (default: Monday,Tuesday,Wednesday,Thursday,Friday)",
+		EnvVar:      "SCHEDULE_DAYS",
+		Destination: &initialDeployArgs.ScheduleDays,
+	},
+	cli.StringFlag{
+		Name:        "schedule-location",
+		Usage:       "(optional) Time zone of the working hours, e.g. Europe/London (default: UTC)",
+		EnvVar:      "SCHEDULE_LOCATION",
+		Destination: &initialDeployArgs.ScheduleLocation,
+	},
+	cli.IntFlag{
+		Name:        "schedule-idle-workers",
+		Usage:       "(optional) Number of workers kept outside working hours, at least 1 (default: 1)",
+		EnvVar:      "SCHEDULE_IDLE_WORKERS",
+		Destination: &initialDeployArgs.ScheduleIdleWorkers,
+	},
+	cli.StringFlag{
+		Name:        "release-channel",
+		Usage:       "(optional) Releases of control-tower the self-update pipeline deploys: stable, pre-release or a version range such as 0.17.x (default: stable)",
+		EnvVar:      "RELEASE_CHANNEL",
+		Destination: &initialDeployArgs.ReleaseChannel,
+	},
+	cli.StringFlag{
+		Name:        "maintenance-window",
+		Usage:       "(optional) Hours as HH:MM-HH:MM, e.g. 01:00-05:00, that the self-update pipeline may upgrade in. Upgrades released outside them wait for the next window. Use `off` to remove the window",
+		EnvVar:      "MAINTENANCE_WINDOW",
+		Destination: &initialDeployArgs.MaintenanceWindow,
+	},
+	cli.StringFlag{
+		Name:        "maintenance-window-days",
+		Usage:       "(optional) Comma separated days that the maintenance window opens on (default: every day)",
+		EnvVar:      "MAINTENANCE_WINDOW_DAYS",
+		Destination: &initialDeployArgs.MaintenanceWindowDays,
+	},
+	cli.StringFlag{
+		Name:        "maintenance-window-location",
+		Usage:       "(optional) Time zone of the maintenance window, e.g. Europe/London (default: UTC)",
+		EnvVar:      "MAINTENANCE_WINDOW_LOCATION",
+		Destination: &initialDeployArgs.MaintenanceWindowLocation,
+	},
+	cli.BoolFlag{
+		Name:        "upgrade-approval",
+		Usage:       "(optional) Make the self-update pipeline wait for the approve-upgrade job to be triggered before upgrading. Use --upgrade-approval=false to stop waiting",
+		EnvVar:      "UPGRADE_APPROVAL",
+		Destination: &initialDeployArgs.UpgradeApproval,
+	},
+	cli.StringFlag{
+		Name:        "notify-slack-webhook",
+		Usage:       "(optional) Slack incoming webhook URL to report self-update upgrades to. Give an empty value to stop",
+		EnvVar:      "NOTIFY_SLACK_WEBHOOK",
+		Destination: &initialDeployArgs.NotifySlackWebhook,
+	},
+	cli.StringFlag{
+		Name:        "notify-webhook",
+		Usage:       "(optional) URL to POST a JSON description of each self-update upgrade event to. Give an empty value to stop",
+		EnvVar:      "NOTIFY_WEBHOOK",
+		Destination: &initialDeployArgs.NotifyWebhook,
+	},
+	cli.StringFlag{
+		Name:        "notify-email",
+		Usage:       "(optional) Comma separated addresses to email self-update upgrade events to. Give an empty value to stop",
+		EnvVar:      "NOTIFY_EMAIL",
+		Destination: &initialDeployArgs.NotifyEmail,
+	},
+	cli.StringFlag{
+		Name:        "notify-email-from",
+		Usage:       "(optional) Address to send notification emails from",
+		EnvVar:      "NOTIFY_EMAIL_FROM",
+		Destination: &initialDeployArgs.NotifyEmailFrom,
+	},
+	cli.StringFlag{
+		Name:        "notify-smtp-url",
+		Usage:       "(optional) SMTP server to send notification emails through, as smtp://host:port or smtps://host:port",
+		EnvVar:      "NOTIFY_SMTP_URL",
+		Destination: &initialDeployArgs.NotifySMTPURL,
+	},
+	cli.StringFlag{
+		Name:        "notify-smtp-username",
+		Usage:       "(optional) Username to log in to the SMTP server with",
+		EnvVar:      "NOTIFY_SMTP_USERNAME",
+		Destination: &initialDeployArgs.NotifySMTPUsername,
+	},
+	cli.StringFlag{
+		Name:        "notify-smtp-password",
+		Usage:       "(optional) Password to log in to the SMTP server with",
+		EnvVar:      "NOTIFY_SMTP_PASSWORD",
+		Destination: &initialDeployArgs.NotifySMTPPassword,
+	},
+	cli.StringFlag{
+		Name:        "pipelines",
+		Usage:       "(optional) Local directory or git URL holding a manifest of pipelines to set and unpause after every deploy. Use `off` to stop setting them",
+		EnvVar:      "PIPELINES",
+		Destination: &initialDeployArgs.Pipelines,
+	},
+	cli.StringFlag{
+		Name:        "pipelines-branch",
+		Usage:       "(optional) Branch of the --pipelines git repository to use (default: its default branch)",
+		EnvVar:      "PIPELINES_BRANCH",
+		Destination: &initialDeployArgs.PipelinesBranch,
+	},
+	cli.StringFlag{
+		Name:        "pipelines-manifest",
+		Usage:       "(optional) Path of the pipelines manifest within --pipelines (default: pipelines.yml)",
+		EnvVar:      "PIPELINES_MANIFEST",
+		Destination: &initialDeployArgs.PipelinesManifest,
+	},
 	cli.StringSliceFlag{
 		Name:  "add-tag",
 		Usage: "(optional) Key=Value pair to tag EC2 instances with - Multiple tags can be applied with multiple uses of this flag",
 		Value: &initialDeployArgs.Tags,
 	},
+	cli.StringSliceFlag{
+		Name:  "concourse-ops-file",
+		Usage: "(optional) BOSH ops file to apply to the Concourse manifest - Multiple files can be applied with multiple uses of this flag, and replace any previously given",
+		Value: &initialDeployArgs.ConcourseOpsFilePaths,
+	},
+	cli.StringSliceFlag{
+		Name:  "director-ops-file",
+		Usage: "(optional) BOSH ops file to apply to the director manifest - Multiple files can be applied with multiple uses of this flag, and replace any previously given",
+		Value: &initialDeployArgs.DirectorOpsFilePaths,
+	},
+	cli.StringSliceFlag{
+		Name:  "vars-file",
+		Usage: "(optional) YAML file of variables used by the ops files - Multiple files can be given with multiple uses of this flag, and replace any previously given",
+		Value: &initialDeployArgs.VarsFilePaths,
+	},
 	cli.StringFlag{
 		Name:        "namespace",
 		Usage:       "(optional) Specify a namespace for deployments in order to group them in a meaningful way",
@@ -198,19 +462,19 @@ var deployFlags = []cli.Flag{
 	},
 	cli.StringFlag{
 		Name:        "vpc-network-range",
-		Usage:       "(optional) VPC network CIDR to deploy into, only required if IAAS is AWS",
+		Usage:       "(optional) VPC network CIDR to deploy into, only required if IAAS is AWS or Azure",
 		EnvVar:      "VPC_NETWORK_RANGE",
 		Destination: &initialDeployArgs.NetworkCIDR,
 	},
 	cli.StringFlag{
 		Name:        "public-subnet-range",
-		Usage:       "(optional) public network CIDR (if IAAS is AWS must be within --vpc-network-range)",
+		Usage:       "(optional) public network CIDR (if IAAS is AWS or Azure must be within --vpc-network-range)",
 		EnvVar:      "PUBLIC_SUBNET_RANGE",
 		Destination: &initialDeployArgs.PublicCIDR,
 	},
 	cli.StringFlag{
 		Name:        "private-subnet-range",
-		Usage:       "(optional) private network CIDR (if IAAS is AWS must be within --vpc-network-range)",
+		Usage:       "(optional) private network CIDR (if IAAS is AWS or Azure must be within --vpc-network-range)",
 		EnvVar:      "PRIVATE_SUBNET_RANGE",
 		Destination: &initialDeployArgs.PrivateCIDR,
 	},
@@ -226,19 +490,52 @@ var deployFlags = []cli.Flag{
 		EnvVar:      "RDS_SUBNET_RANGE2",
 		Destination: &initialDeployArgs.RDS2CIDR,
 	},
+	cli.BoolFlag{
+		Name:        "private",
+		Usage:       "(optional) Deploy without public IPs, so that the director and Concourse are only reachable from within the VPC. Only supported on AWS, and only on the first deploy",
+		EnvVar:      "PRIVATE",
+		Destination: &initialDeployArgs.Private,
+	},
+	cli.BoolFlag{
+		Name:        "instance-identity",
+		Usage:       "(optional) Give the director IAM instance profiles on AWS, or an attached service account on GCP, instead of access keys. Cannot be turned off once used",
+		EnvVar:      "INSTANCE_IDENTITY",
+		Destination: &initialDeployArgs.InstanceIdentity,
+	},
 }
 
 func deployAction(c *cli.Context, deployArgs deploy.Args, provider iaas.Provider) error {
 	name := c.Args().Get(0)
+	if deployArgs.NameIsSet {
+		if name != "" && name != deployArgs.Name {
+			return fmt.Errorf("deployment name %s does not match name %s in deployment file", name, deployArgs.Name)
+		}
+		name = deployArgs.Name
+	}
 	if name == "" {
 		return errors.New("Usage is `control-tower deploy <name>`")
 	}
 
+	recorder := events.Discard
+	if deployArgs.Output == deploy.OutputJSONEvents {
+		// The terraform and BOSH CLIs write straight to os.Stdout, so everything but events is redirected to stderr
+		recorder = events.NewJSONRecorder(os.Stdout, time.Now)
+		os.Stdout = os.Stderr
+	}
+
 	version := c.App.Version
 
-	deployArgs, err := setZoneAndRegion(provider.Region(), deployArgs)
-	if err != nil {
-		return err
+	var err error
+	if provider.IAAS() == iaas.Azure {
+		// Azure zones are numbered within a region rather than named after it
+		if !deployArgs.RegionIsSet {
+			deployArgs.Region = provider.Region()
+		}
+	} else {
+		deployArgs, err = setZoneAndRegion(provider.Region(), deployArgs)
+		if err != nil {
+			return err
+		}
 	}
 
 	err = validateNameLength(name, provider.IAAS())
@@ -251,7 +548,7 @@ func deployAction(c *cli.Context, deployArgs deploy.Args, provider iaas.Provider
 		return err
 	}
 
-	client, err := buildClient(name, version, deployArgs, provider)
+	client, err := buildClient(name, version, deployArgs, provider, recorder)
 	if err != nil {
 		return err
 	}
@@ -265,10 +562,26 @@ func validateDeployArgs(c *cli.Context, deployArgs deploy.Args) (deploy.Args, er
 		return deployArgs, fmt.Errorf("failed to mark set Deploy flags: [%v]", err)
 	}
 
+	if err = deployArgs.LoadWorkerPools(ioutil.ReadFile); err != nil {
+		return deployArgs, fmt.Errorf("failed to load worker pools: [%v]", err)
+	}
+
+	if err = deployArgs.LoadSpec(ioutil.ReadFile); err != nil {
+		return deployArgs, err
+	}
+
+	if err = deployArgs.LoadManifestFiles(ioutil.ReadFile); err != nil {
+		return deployArgs, fmt.Errorf("failed to load ops and vars files: [%v]", err)
+	}
+
 	if err = deployArgs.Validate(); err != nil {
 		return deployArgs, fmt.Errorf("failed to validate Deploy flags: [%v]", err)
 	}
 
+	if stateBackend.Type == config.LocalBackend && deployArgs.ScheduleIsSet && deployArgs.Schedule != deploy.ScheduleOff {
+		return deployArgs, fmt.Errorf("--schedule cannot be used with --state-backend %s, as the self-update pipeline that enforces it cannot read local state", config.LocalBackend)
+	}
+
 	return deployArgs, nil
 }
 
@@ -328,6 +641,18 @@ func validateCidrRanges(provider iaas.Provider, networkCIDR, publicCIDR, private
 		return nil
 	}
 
+	if provider.IAAS() == iaas.Azure {
+		if (privateCIDR != "" || publicCIDR != "") && networkCIDR == "" {
+			return errors.New("error validating CIDR ranges - vpc-network-range must be provided when using Azure")
+		}
+		_, parsedNetworkCidr, err = net.ParseCIDR(networkCIDR)
+		if err != nil {
+			return errors.New("error validating CIDR ranges - vpc-network-range is not a valid CIDR")
+		}
+		if !validateNetworkSize(parsedNetworkCidr) {
+			return errors.New("error validating CIDR ranges - vpc-network-range is not big enough, at least /26 needed.")
+		}
+	}
 	if provider.IAAS() == iaas.AWS {
 		if (privateCIDR != "" || publicCIDR != "" || RDS1CIDR != "" || RDS2CIDR != "") && networkCIDR == "" {
 			return errors.New("error validating CIDR ranges - vpc-network-range must be provided when using AWS")
@@ -400,6 +725,20 @@ func validateCidrRanges(provider iaas.Provider, networkCIDR, publicCIDR, private
 		}
 	}
 
+	if provider.IAAS() == iaas.Azure {
+		if !parsedNetworkCidr.Contains(parsedPublicCidr.IP) {
+			return errors.New("error validating CIDR ranges - public-subnet-range must be within vpc-network-range")
+		}
+
+		if !parsedNetworkCidr.Contains(parsedPrivateCidr.IP) {
+			return errors.New("error validating CIDR ranges - private-subnet-range must be within vpc-network-range")
+		}
+
+		if parsedPublicCidr.Contains(parsedPrivateCidr.IP) || parsedPrivateCidr.Contains(parsedPublicCidr.IP) {
+			return errors.New("error validating CIDR ranges - public-subnet-range must not overlap private-network-range")
+		}
+	}
+
 	return nil
 }
 
@@ -423,10 +762,11 @@ func validateRDSSubnetSize(cidr *net.IPNet) bool {
 	return size > 4
 }
 
-func buildClient(name, version string, deployArgs deploy.Args, provider iaas.Provider) (*concourse.Client, error) {
+func buildClient(name, version string, deployArgs deploy.Args, provider iaas.Provider, recorder events.Recorder) (*concourse.Client, error) {
 	versionFile, _ := provider.Choose(iaas.Choice{
-		AWS: resource.AWSVersionFile,
-		GCP: resource.GCPVersionFile,
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
 	}).([]byte)
 
 	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
@@ -434,22 +774,28 @@ func buildClient(name, version string, deployArgs deploy.Args, provider iaas.Pro
 		return nil, err
 	}
 
-	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider)
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
 	if err != nil {
 		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
 	}
 
+	configClient, err := buildConfigClient(provider, name, deployArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
 	client := concourse.NewClient(
 		provider,
 		terraformClient,
 		tfInputVarsFactory,
 		bosh.New,
-		fly.New,
+		fly.NewWithState(selfUpdateState()),
 		certs.Generate,
-		config.New(provider, name, deployArgs.Namespace),
+		configClient,
 		&deployArgs,
 		os.Stdout,
 		os.Stderr,
+		recorder,
 		util.FindUserIP,
 		certs.NewAcmeClient,
 		util.GeneratePasswordWithLength,
@@ -3,14 +3,23 @@ package deploy
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/EngineerBetter/control-tower/config"
 	"gopkg.in/urfave/cli.v1"
 )
 
 // Args are arguments passed to the deploy command
 type Args struct {
+	// Name is the deployment name given in the deployment file
+	Name      string
+	NameIsSet bool
+	// SpecFile is the deployment file given with --file
+	SpecFile         string
+	SpecFileIsSet    bool
 	IAAS             string
 	IAASIsSet        bool
 	Region           string
@@ -25,11 +34,42 @@ type Args struct {
 	WorkerCountIsSet bool
 	WorkerSize       string
 	WorkerSizeIsSet  bool
-	WebSize          string
-	WebSizeIsSet     bool
-	SelfUpdate       bool
-	SelfUpdateIsSet  bool
-	DBSize           string
+	// WorkerPoolSpecs are the pools given with --worker-pool, in the format name:count:size[:provisioning-type[:tag,tag...]]
+	WorkerPoolSpecs cli.StringSlice
+	// WorkerPoolsFile is a YAML file listing worker pools, as an alternative to --worker-pool
+	WorkerPoolsFile      string
+	WorkerPoolsFileIsSet bool
+	// WorkerPools are the pools loaded from either flag by LoadWorkerPools
+	WorkerPools []config.WorkerPool
+	// WorkerPoolsIsSet is true if the user has specified worker pools with --worker-pool or --worker-pools-file
+	WorkerPoolsIsSet bool
+
+	// ConcourseOpsFilePaths, DirectorOpsFilePaths and VarsFilePaths are the files given with the repeatable
+	// --concourse-ops-file, --director-ops-file and --vars-file flags, which LoadManifestFiles reads into
+	// ConcourseOpsFiles, DirectorOpsFiles and VarsFiles
+	ConcourseOpsFilePaths  cli.StringSlice
+	ConcourseOpsFilesIsSet bool
+	ConcourseOpsFiles      []config.ManifestFile
+	DirectorOpsFilePaths   cli.StringSlice
+	DirectorOpsFilesIsSet  bool
+	DirectorOpsFiles       []config.ManifestFile
+	VarsFilePaths          cli.StringSlice
+	VarsFilesIsSet         bool
+	VarsFiles              []config.ManifestFile
+
+	WebSize      string
+	WebSizeIsSet bool
+	// WebCount above 1 spreads web VMs across availability zones behind a load balancer
+	WebCount        int
+	WebCountIsSet   bool
+	SelfUpdate      bool
+	SelfUpdateIsSet bool
+	DryRun          bool
+	DryRunIsSet     bool
+	// Output is how deploy reports progress, one of OutputFormats
+	Output      string
+	OutputIsSet bool
+	DBSize      string
 	// DBSizeIsSet is true if the user has manually specified the db-size (ie, it's not the default)
 	DBSizeIsSet                    bool
 	EnableGlobalResources          bool
@@ -81,6 +121,103 @@ type Args struct {
 	RDS1CIDRIsSet    bool
 	RDS2CIDR         string
 	RDS2CIDRIsSet    bool
+	// Private deploys without public IPs, so that the director and Concourse are only reachable from within the network
+	Private      bool
+	PrivateIsSet bool
+	// InstanceIdentity gives the director IAM instance profiles or an attached service account instead of access keys
+	InstanceIdentity      bool
+	InstanceIdentityIsSet bool
+
+	// Schedule is the working hours given with --schedule as HH:MM-HH:MM, or ScheduleOff
+	Schedule      string
+	ScheduleIsSet bool
+	// ScheduleDays is a comma separated list of the days working hours start on
+	ScheduleDays             string
+	ScheduleDaysIsSet        bool
+	ScheduleLocation         string
+	ScheduleLocationIsSet    bool
+	ScheduleIdleWorkers      int
+	ScheduleIdleWorkersIsSet bool
+	// ReleaseChannel is the releases of control-tower the self-update pipeline deploys
+	ReleaseChannel      string
+	ReleaseChannelIsSet bool
+	// MaintenanceWindow is when the self-update pipeline may upgrade, given as HH:MM-HH:MM, or MaintenanceWindowOff
+	MaintenanceWindow      string
+	MaintenanceWindowIsSet bool
+	// MaintenanceWindowDays is a comma separated list of the days the maintenance window opens on
+	MaintenanceWindowDays          string
+	MaintenanceWindowDaysIsSet     bool
+	MaintenanceWindowLocation      string
+	MaintenanceWindowLocationIsSet bool
+	// UpgradeApproval makes the self-update pipeline wait for someone to approve each upgrade
+	UpgradeApproval      bool
+	UpgradeApprovalIsSet bool
+
+	NotifySlackWebhook      string
+	NotifySlackWebhookIsSet bool
+	NotifyWebhook           string
+	NotifyWebhookIsSet      bool
+	// NotifyEmail is a comma separated list of the addresses to email notifications to
+	NotifyEmail             string
+	NotifyEmailIsSet        bool
+	NotifyEmailFrom         string
+	NotifyEmailFromIsSet    bool
+	NotifySMTPURL           string
+	NotifySMTPURLIsSet      bool
+	NotifySMTPUsername      string
+	NotifySMTPUsernameIsSet bool
+	NotifySMTPPassword      string
+	NotifySMTPPasswordIsSet bool
+	// NotificationsIsSet is true if the user has specified any of the --notify flags
+	NotificationsIsSet bool
+
+	OIDCAuthIssuer            string
+	OIDCAuthIssuerIsSet       bool
+	OIDCAuthClientID          string
+	OIDCAuthClientIDIsSet     bool
+	OIDCAuthClientSecret      string
+	OIDCAuthClientSecretIsSet bool
+	// OIDCAuthScopes is a comma separated list of the scopes requested from the OIDC provider
+	OIDCAuthScopes           string
+	OIDCAuthScopesIsSet      bool
+	OIDCAuthGroupsClaim      string
+	OIDCAuthGroupsClaimIsSet bool
+	// OIDCAuthIsSet is true if the user has specified the --oidc-auth-issuer, --oidc-auth-client-id and --oidc-auth-client-secret flags
+	OIDCAuthIsSet                   bool
+	LDAPAuthHost                    string
+	LDAPAuthHostIsSet               bool
+	LDAPAuthBindDN                  string
+	LDAPAuthBindDNIsSet             bool
+	LDAPAuthBindPassword            string
+	LDAPAuthBindPasswordIsSet       bool
+	LDAPAuthUserSearchBaseDN        string
+	LDAPAuthUserSearchBaseDNIsSet   bool
+	LDAPAuthUserSearchFilter        string
+	LDAPAuthUserSearchFilterIsSet   bool
+	LDAPAuthUserSearchUsername      string
+	LDAPAuthUserSearchUsernameIsSet bool
+	LDAPAuthGroupSearchBaseDN       string
+	LDAPAuthGroupSearchBaseDNIsSet  bool
+	LDAPAuthGroupSearchFilter       string
+	LDAPAuthGroupSearchFilterIsSet  bool
+	// LDAPAuthIsSet is true if the user has specified the --ldap-auth-host, --ldap-auth-bind-dn, --ldap-auth-bind-password and --ldap-auth-user-search-base-dn flags
+	LDAPAuthIsSet               bool
+	GitLabAuthClientID          string
+	GitLabAuthClientIDIsSet     bool
+	GitLabAuthClientSecret      string
+	GitLabAuthClientSecretIsSet bool
+	GitLabAuthHost              string
+	GitLabAuthHostIsSet         bool
+	// GitLabAuthIsSet is true if the user has specified both the --gitlab-auth-client-id and --gitlab-auth-client-secret flags
+	GitLabAuthIsSet bool
+
+	// Pipelines is the local directory or git URL given with --pipelines, or PipelinesOff
+	Pipelines              string
+	PipelinesIsSet         bool
+	PipelinesBranch        string
+	PipelinesBranchIsSet   bool
+	PipelinesManifest      string
+	PipelinesManifestIsSet bool
 }
 
 // MarkSetFlags is marking the IsSet DeployArgs
@@ -88,6 +225,8 @@ func (a *Args) MarkSetFlags(c FlagSetChecker) error {
 	for _, f := range c.FlagNames() {
 		if c.IsSet(f) {
 			switch f {
+			case "file":
+				a.SpecFileIsSet = true
 			case "region":
 				a.RegionIsSet = true
 			case "enable-global-resources":
@@ -106,12 +245,55 @@ func (a *Args) MarkSetFlags(c FlagSetChecker) error {
 				a.WorkerCountIsSet = true
 			case "worker-size":
 				a.WorkerSizeIsSet = true
+			case "worker-pool":
+				a.WorkerPoolsIsSet = true
+			case "worker-pools-file":
+				a.WorkerPoolsFileIsSet = true
+				a.WorkerPoolsIsSet = true
+			case "schedule":
+				a.ScheduleIsSet = true
+			case "schedule-days":
+				a.ScheduleDaysIsSet = true
+			case "schedule-location":
+				a.ScheduleLocationIsSet = true
+			case "schedule-idle-workers":
+				a.ScheduleIdleWorkersIsSet = true
+			case "release-channel":
+				a.ReleaseChannelIsSet = true
+			case "maintenance-window":
+				a.MaintenanceWindowIsSet = true
+			case "maintenance-window-days":
+				a.MaintenanceWindowDaysIsSet = true
+			case "maintenance-window-location":
+				a.MaintenanceWindowLocationIsSet = true
+			case "upgrade-approval":
+				a.UpgradeApprovalIsSet = true
+			case "notify-slack-webhook":
+				a.NotifySlackWebhookIsSet = true
+			case "notify-webhook":
+				a.NotifyWebhookIsSet = true
+			case "notify-email":
+				a.NotifyEmailIsSet = true
+			case "notify-email-from":
+				a.NotifyEmailFromIsSet = true
+			case "notify-smtp-url":
+				a.NotifySMTPURLIsSet = true
+			case "notify-smtp-username":
+				a.NotifySMTPUsernameIsSet = true
+			case "notify-smtp-password":
+				a.NotifySMTPPasswordIsSet = true
 			case "web-size":
 				a.WebSizeIsSet = true
+			case "web-count":
+				a.WebCountIsSet = true
 			case "iaas":
 				a.IAASIsSet = true
 			case "self-update":
 				a.SelfUpdateIsSet = true
+			case "dry-run":
+				a.DryRunIsSet = true
+			case "output":
+				a.OutputIsSet = true
 			case "db-size":
 				a.DBSizeIsSet = true
 			case "spot", "preemptible":
@@ -132,8 +314,52 @@ func (a *Args) MarkSetFlags(c FlagSetChecker) error {
 				a.MicrosoftAuthClientSecretIsSet = true
 			case "microsoft-auth-tenant":
 				a.MicrosoftAuthTenantIsSet = true
+			case "oidc-auth-issuer":
+				a.OIDCAuthIssuerIsSet = true
+			case "oidc-auth-client-id":
+				a.OIDCAuthClientIDIsSet = true
+			case "oidc-auth-client-secret":
+				a.OIDCAuthClientSecretIsSet = true
+			case "oidc-auth-scopes":
+				a.OIDCAuthScopesIsSet = true
+			case "oidc-auth-groups-claim":
+				a.OIDCAuthGroupsClaimIsSet = true
+			case "ldap-auth-host":
+				a.LDAPAuthHostIsSet = true
+			case "ldap-auth-bind-dn":
+				a.LDAPAuthBindDNIsSet = true
+			case "ldap-auth-bind-password":
+				a.LDAPAuthBindPasswordIsSet = true
+			case "ldap-auth-user-search-base-dn":
+				a.LDAPAuthUserSearchBaseDNIsSet = true
+			case "ldap-auth-user-search-filter":
+				a.LDAPAuthUserSearchFilterIsSet = true
+			case "ldap-auth-user-search-username":
+				a.LDAPAuthUserSearchUsernameIsSet = true
+			case "ldap-auth-group-search-base-dn":
+				a.LDAPAuthGroupSearchBaseDNIsSet = true
+			case "ldap-auth-group-search-filter":
+				a.LDAPAuthGroupSearchFilterIsSet = true
+			case "gitlab-auth-client-id":
+				a.GitLabAuthClientIDIsSet = true
+			case "gitlab-auth-client-secret":
+				a.GitLabAuthClientSecretIsSet = true
+			case "gitlab-auth-host":
+				a.GitLabAuthHostIsSet = true
+			case "pipelines":
+				a.PipelinesIsSet = true
+			case "pipelines-branch":
+				a.PipelinesBranchIsSet = true
+			case "pipelines-manifest":
+				a.PipelinesManifestIsSet = true
 			case "add-tag":
 				a.TagsIsSet = true
+			case "concourse-ops-file":
+				a.ConcourseOpsFilesIsSet = true
+			case "director-ops-file":
+				a.DirectorOpsFilesIsSet = true
+			case "vars-file":
+				a.VarsFilesIsSet = true
 			case "namespace":
 				a.NamespaceIsSet = true
 			case "zone":
@@ -150,6 +376,10 @@ func (a *Args) MarkSetFlags(c FlagSetChecker) error {
 				a.RDS1CIDRIsSet = true
 			case "rds-subnet-range2":
 				a.RDS2CIDRIsSet = true
+			case "private":
+				a.PrivateIsSet = true
+			case "instance-identity":
+				a.InstanceIdentityIsSet = true
 			default:
 				return fmt.Errorf("flag %q is not supported by deployment flags", f)
 			}
@@ -158,10 +388,102 @@ func (a *Args) MarkSetFlags(c FlagSetChecker) error {
 	a.BitbucketAuthIsSet = c.IsSet("bitbucket-auth-client-id") && c.IsSet("bitbucket-auth-client-secret")
 	a.GithubAuthIsSet = c.IsSet("github-auth-client-id") && c.IsSet("github-auth-client-secret")
 	a.MicrosoftAuthIsSet = c.IsSet("microsoft-auth-client-id") && c.IsSet("microsoft-auth-client-secret")
+	a.markAuthSet()
+	a.markNotificationsSet()
 
 	return nil
 }
 
+// markAuthSet marks the OIDC, LDAP and GitLab providers as set when all of their required flags are
+func (a *Args) markAuthSet() {
+	a.OIDCAuthIsSet = a.OIDCAuthIssuerIsSet && a.OIDCAuthClientIDIsSet && a.OIDCAuthClientSecretIsSet
+	a.LDAPAuthIsSet = a.LDAPAuthHostIsSet && a.LDAPAuthBindDNIsSet && a.LDAPAuthBindPasswordIsSet && a.LDAPAuthUserSearchBaseDNIsSet
+	a.GitLabAuthIsSet = a.GitLabAuthClientIDIsSet && a.GitLabAuthClientSecretIsSet
+}
+
+// markNotificationsSet marks the notifications as set when any of their flags are
+func (a *Args) markNotificationsSet() {
+	a.NotificationsIsSet = a.NotifySlackWebhookIsSet || a.NotifyWebhookIsSet || a.NotifyEmailIsSet || a.NotifyEmailFromIsSet ||
+		a.NotifySMTPURLIsSet || a.NotifySMTPUsernameIsSet || a.NotifySMTPPasswordIsSet
+}
+
+// StoredArgs returns the Args that redeploy conf as it is stored, for deploys that are not given
+// flags, such as rollback
+func StoredArgs(conf config.ConfigView) Args {
+	allowIPs := conf.GetAllowIPsUnformatted()
+	if allowIPs == "" {
+		allowIPs = "0.0.0.0/0"
+	}
+	return Args{
+		IAAS:        conf.GetIAAS(),
+		IAASIsSet:   true,
+		Region:      conf.GetRegion(),
+		RegionIsSet: true,
+		Domain:      conf.GetDomain(),
+		DomainIsSet: true,
+		AllowIPs:    allowIPs,
+	}
+}
+
+// LoadWorkerPools parses the pools given with --worker-pool, or reads them from --worker-pools-file
+func (a *Args) LoadWorkerPools(readFile func(string) ([]byte, error)) error {
+	if a.WorkerPoolsFileIsSet {
+		if len(a.WorkerPoolSpecs) > 0 {
+			return errors.New("--worker-pool cannot be used with --worker-pools-file")
+		}
+		contents, err := readFile(a.WorkerPoolsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read worker pools file: [%v]", err)
+		}
+		pools, err := config.ParseWorkerPools(contents)
+		if err != nil {
+			return fmt.Errorf("failed to parse worker pools file %s: [%v]", a.WorkerPoolsFile, err)
+		}
+		a.WorkerPools = pools
+		return nil
+	}
+
+	a.WorkerPools = nil
+	for _, spec := range a.WorkerPoolSpecs {
+		pool, err := config.ParseWorkerPool(spec)
+		if err != nil {
+			return err
+		}
+		a.WorkerPools = append(a.WorkerPools, pool)
+	}
+	return nil
+}
+
+// LoadManifestFiles reads the ops files and vars files given with --concourse-ops-file,
+// --director-ops-file and --vars-file, checking that each is well formed
+func (a *Args) LoadManifestFiles(readFile func(string) ([]byte, error)) error {
+	var err error
+	if a.ConcourseOpsFiles, err = readManifestFiles(a.ConcourseOpsFilePaths, readFile, config.NewOpsFile); err != nil {
+		return err
+	}
+	if a.DirectorOpsFiles, err = readManifestFiles(a.DirectorOpsFilePaths, readFile, config.NewOpsFile); err != nil {
+		return err
+	}
+	a.VarsFiles, err = readManifestFiles(a.VarsFilePaths, readFile, config.NewVarsFile)
+	return err
+}
+
+func readManifestFiles(paths []string, readFile func(string) ([]byte, error), parse func(string, []byte) (config.ManifestFile, error)) ([]config.ManifestFile, error) {
+	var files []config.ManifestFile
+	for _, path := range paths {
+		contents, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: [%v]", path, err)
+		}
+		file, err := parse(path, contents)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
 // WorkerSizes are the permitted concourse worker sizes
 var WorkerSizes = []string{"medium", "large", "xlarge", "2xlarge", "4xlarge", "12xlarge", "24xlarge"}
 
@@ -185,6 +507,28 @@ func (a Args) Validate() error {
 		return err
 	}
 
+	if err := config.ValidateWorkerPools(a.WorkerPools, WorkerSizes); err != nil {
+		return err
+	}
+
+	if err := a.validateSchedule(); err != nil {
+		return err
+	}
+
+	if a.ReleaseChannelIsSet {
+		if err := config.ValidateReleaseChannel(a.ReleaseChannel); err != nil {
+			return err
+		}
+	}
+
+	if err := a.validateMaintenanceWindow(); err != nil {
+		return err
+	}
+
+	if err := a.validatePipelines(); err != nil {
+		return err
+	}
+
 	if err := a.validateWebFields(); err != nil {
 		return err
 	}
@@ -197,6 +541,10 @@ func (a Args) Validate() error {
 		return err
 	}
 
+	if err := a.validateAuthProviders(); err != nil {
+		return err
+	}
+
 	if err := a.validateNetworkRanges(); err != nil {
 		return err
 	}
@@ -205,9 +553,261 @@ func (a Args) Validate() error {
 		return err
 	}
 
+	if a.Private && strings.ToLower(a.IAAS) != "aws" {
+		return errors.New("--private is only supported on AWS")
+	}
+
+	if a.InstanceIdentity && strings.ToLower(a.IAAS) != "aws" && strings.ToLower(a.IAAS) != "gcp" {
+		return errors.New("--instance-identity is only supported on AWS and GCP")
+	}
+
+	if a.DryRun && a.SelfUpdate {
+		return errors.New("--dry-run cannot be used with --self-update")
+	}
+
+	if err := a.validateOutput(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PipelinesOff is the value of --pipelines that stops deploys setting pipelines
+const PipelinesOff = "off"
+
+// UserPipelines returns the pipelines source given with --pipelines and the flags that refine it,
+// or nil if pipelines are no longer being set
+func (a Args) UserPipelines() *config.PipelinesSource {
+	if a.Pipelines == PipelinesOff {
+		return nil
+	}
+	source := &config.PipelinesSource{
+		Source:   a.Pipelines,
+		Branch:   a.PipelinesBranch,
+		Manifest: config.DefaultPipelinesManifest,
+	}
+	if a.PipelinesManifestIsSet {
+		source.Manifest = a.PipelinesManifest
+	}
+	// Later deploys may be run from another directory
+	if !source.IsGit() && source.Source != "" {
+		if dir, err := filepath.Abs(source.Source); err == nil {
+			source.Source = dir
+		}
+	}
+	return source
+}
+
+func (a Args) validatePipelines() error {
+	refined := a.PipelinesBranchIsSet || a.PipelinesManifestIsSet
+	if !a.PipelinesIsSet {
+		if refined {
+			return errors.New("--pipelines-branch and --pipelines-manifest require --pipelines")
+		}
+		return nil
+	}
+	if a.Pipelines == PipelinesOff {
+		if refined {
+			return fmt.Errorf("--pipelines-branch and --pipelines-manifest cannot be used with --pipelines %s", PipelinesOff)
+		}
+		return nil
+	}
+
+	source := a.UserPipelines()
+	if source.Source == "" {
+		return errors.New("--pipelines must be a local directory, a git URL or `off`")
+	}
+	if a.PipelinesBranchIsSet && !source.IsGit() {
+		return errors.New("--pipelines-branch can only be used when --pipelines is a git URL")
+	}
+	if filepath.IsAbs(source.Manifest) || strings.HasPrefix(filepath.Clean(source.Manifest), "..") {
+		return fmt.Errorf("--pipelines-manifest `%s` must be a path within --pipelines", source.Manifest)
+	}
+	return nil
+}
+
+// ScheduleOff is the value of --schedule that removes the schedule
+const ScheduleOff = "off"
+
+// WorkerSchedule returns the schedule given with --schedule and the flags that refine it, or nil
+// if the schedule is being removed
+func (a Args) WorkerSchedule() (*config.Schedule, error) {
+	if a.Schedule == ScheduleOff {
+		return nil, nil
+	}
+
+	start, stop, err := config.ParseScheduleHours(a.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	schedule := config.Schedule{
+		Start:           start,
+		Stop:            stop,
+		Days:            config.Weekdays,
+		Location:        "UTC",
+		IdleWorkerCount: 1,
+	}
+	if a.ScheduleDaysIsSet {
+		schedule.Days = nil
+		for _, day := range strings.Split(a.ScheduleDays, ",") {
+			schedule.Days = append(schedule.Days, strings.TrimSpace(day))
+		}
+	}
+	if a.ScheduleLocationIsSet {
+		schedule.Location = a.ScheduleLocation
+	}
+	if a.ScheduleIdleWorkersIsSet {
+		schedule.IdleWorkerCount = a.ScheduleIdleWorkers
+	}
+	return &schedule, nil
+}
+
+func (a Args) validateSchedule() error {
+	refined := a.ScheduleDaysIsSet || a.ScheduleLocationIsSet || a.ScheduleIdleWorkersIsSet
+	if !a.ScheduleIsSet {
+		if refined {
+			return errors.New("--schedule-days, --schedule-location and --schedule-idle-workers require --schedule")
+		}
+		return nil
+	}
+	if a.Schedule == ScheduleOff {
+		if refined {
+			return fmt.Errorf("--schedule-days, --schedule-location and --schedule-idle-workers cannot be used with --schedule %s", ScheduleOff)
+		}
+		return nil
+	}
+
+	schedule, err := a.WorkerSchedule()
+	if err != nil {
+		return err
+	}
+	if err = config.ValidateSchedule(*schedule); err != nil {
+		return err
+	}
+	if a.WorkerCountIsSet && schedule.IdleWorkerCount >= a.WorkerCount {
+		return fmt.Errorf("--schedule-idle-workers must be fewer than --workers to scale anything down")
+	}
 	return nil
 }
 
+// MaintenanceWindowOff is the value of --maintenance-window that removes the maintenance window
+const MaintenanceWindowOff = "off"
+
+// UpgradeMaintenanceWindow returns the maintenance window given with --maintenance-window and the
+// flags that refine it, or nil if the window is being removed
+func (a Args) UpgradeMaintenanceWindow() (*config.MaintenanceWindow, error) {
+	if a.MaintenanceWindow == MaintenanceWindowOff {
+		return nil, nil
+	}
+
+	start, stop, err := config.ParseScheduleHours(a.MaintenanceWindow)
+	if err != nil {
+		return nil, fmt.Errorf("maintenance window `%s` is not in the format `HH:MM-HH:MM`", a.MaintenanceWindow)
+	}
+	window := config.MaintenanceWindow{
+		Start:    start,
+		Stop:     stop,
+		Days:     config.EveryDay,
+		Location: "UTC",
+	}
+	if a.MaintenanceWindowDaysIsSet {
+		window.Days = nil
+		for _, day := range strings.Split(a.MaintenanceWindowDays, ",") {
+			window.Days = append(window.Days, strings.TrimSpace(day))
+		}
+	}
+	if a.MaintenanceWindowLocationIsSet {
+		window.Location = a.MaintenanceWindowLocation
+	}
+	return &window, nil
+}
+
+func (a Args) validateMaintenanceWindow() error {
+	refined := a.MaintenanceWindowDaysIsSet || a.MaintenanceWindowLocationIsSet
+	if !a.MaintenanceWindowIsSet {
+		if refined {
+			return errors.New("--maintenance-window-days and --maintenance-window-location require --maintenance-window")
+		}
+		return nil
+	}
+	if a.MaintenanceWindow == MaintenanceWindowOff {
+		if refined {
+			return fmt.Errorf("--maintenance-window-days and --maintenance-window-location cannot be used with --maintenance-window %s", MaintenanceWindowOff)
+		}
+		return nil
+	}
+
+	window, err := a.UpgradeMaintenanceWindow()
+	if err != nil {
+		return err
+	}
+	return config.ValidateMaintenanceWindow(*window)
+}
+
+// ApplyNotifications returns existing with the settings given by the --notify flags, or nil if no
+// notifications are left. A flag given an empty value removes its setting.
+func (a Args) ApplyNotifications(existing *config.Notifications) *config.Notifications {
+	var n config.Notifications
+	if existing != nil {
+		n = *existing
+	}
+	if a.NotifySlackWebhookIsSet {
+		n.SlackWebhook = a.NotifySlackWebhook
+	}
+	if a.NotifyWebhookIsSet {
+		n.Webhook = a.NotifyWebhook
+	}
+	if a.NotifyEmailIsSet {
+		n.EmailTo = nil
+		for _, address := range strings.Split(a.NotifyEmail, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				n.EmailTo = append(n.EmailTo, address)
+			}
+		}
+	}
+	if a.NotifyEmailFromIsSet {
+		n.EmailFrom = a.NotifyEmailFrom
+	}
+	if a.NotifySMTPURLIsSet {
+		n.SMTPURL = a.NotifySMTPURL
+	}
+	if a.NotifySMTPUsernameIsSet {
+		n.SMTPUsername = a.NotifySMTPUsername
+	}
+	if a.NotifySMTPPasswordIsSet {
+		n.SMTPPassword = a.NotifySMTPPassword
+	}
+	if n.IsEmpty() {
+		return nil
+	}
+	return &n
+}
+
+// OutputText is the default output of deploy: free-form text and the raw output of terraform and bosh
+const OutputText = "text"
+
+// OutputJSONEvents writes an NDJSON event to stdout at the start and end of each deploy phase,
+// sending all other output to stderr
+const OutputJSONEvents = "json-events"
+
+// OutputFormats are the permitted values of --output
+var OutputFormats = []string{OutputText, OutputJSONEvents}
+
+func (a Args) validateOutput() error {
+	if a.Output == "" {
+		return nil
+	}
+	for _, format := range OutputFormats {
+		if a.Output == format {
+			if a.Output == OutputJSONEvents && a.DryRun {
+				return errors.New("--output json-events cannot be used with --dry-run")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output `%s`. Valid outputs are: %v", a.Output, OutputFormats)
+}
+
 func (a Args) validateCertFields() error {
 	if a.TLSKey != "" && a.TLSCert == "" {
 		return errors.New("--tls-key requires --tls-cert to also be provided")
@@ -246,6 +846,18 @@ func (a Args) validateWorkerFields() error {
 }
 
 func (a Args) validateWebFields() error {
+	if a.WebCountIsSet {
+		if a.WebCount < 1 {
+			return errors.New("minimum number of web nodes is 1")
+		}
+		if a.WebCount > 1 && strings.ToLower(a.IAAS) == "azure" {
+			return errors.New("--web-count above 1 is only supported on AWS and GCP")
+		}
+		if a.WebCount > 1 && a.Private {
+			return errors.New("--web-count above 1 cannot be used with --private")
+		}
+	}
+
 	for _, size := range WebSizes {
 		if size == a.WebSize {
 			return nil
@@ -274,6 +886,99 @@ func (a Args) validateGithubFields() error {
 	return nil
 }
 
+// DefaultOIDCAuthScopes are requested from an OIDC provider unless --oidc-auth-scopes is given
+var DefaultOIDCAuthScopes = []string{"profile", "email", "groups"}
+
+// DefaultGitLabAuthHost is used for GitLab auth unless --gitlab-auth-host is given
+const DefaultGitLabAuthHost = "https://gitlab.com"
+
+// OIDCAuth returns the OIDC provider given with the --oidc-auth flags, without its client secret
+func (a Args) OIDCAuth() *config.OIDCAuth {
+	auth := config.OIDCAuth{
+		Issuer:      a.OIDCAuthIssuer,
+		ClientID:    a.OIDCAuthClientID,
+		Scopes:      DefaultOIDCAuthScopes,
+		GroupsClaim: "groups",
+	}
+	if a.OIDCAuthScopesIsSet {
+		auth.Scopes = nil
+		for _, scope := range strings.Split(a.OIDCAuthScopes, ",") {
+			auth.Scopes = append(auth.Scopes, strings.TrimSpace(scope))
+		}
+	}
+	if a.OIDCAuthGroupsClaimIsSet {
+		auth.GroupsClaim = a.OIDCAuthGroupsClaim
+	}
+	return &auth
+}
+
+// LDAPAuth returns the LDAP server given with the --ldap-auth flags, without its bind password
+func (a Args) LDAPAuth() *config.LDAPAuth {
+	auth := config.LDAPAuth{
+		Host:               a.LDAPAuthHost,
+		BindDN:             a.LDAPAuthBindDN,
+		UserSearchBaseDN:   a.LDAPAuthUserSearchBaseDN,
+		UserSearchFilter:   a.LDAPAuthUserSearchFilter,
+		UserSearchUsername: "uid",
+		GroupSearchBaseDN:  a.LDAPAuthGroupSearchBaseDN,
+		GroupSearchFilter:  a.LDAPAuthGroupSearchFilter,
+	}
+	if a.LDAPAuthUserSearchUsernameIsSet {
+		auth.UserSearchUsername = a.LDAPAuthUserSearchUsername
+	}
+	return &auth
+}
+
+// GitLabAuth returns the GitLab provider given with the --gitlab-auth flags, without its client secret
+func (a Args) GitLabAuth() *config.GitLabAuth {
+	auth := config.GitLabAuth{
+		ClientID: a.GitLabAuthClientID,
+		Host:     DefaultGitLabAuthHost,
+	}
+	if a.GitLabAuthHostIsSet {
+		auth.Host = strings.TrimSuffix(a.GitLabAuthHost, "/")
+	}
+	return &auth
+}
+
+func (a Args) validateAuthProviders() error {
+	oidcFlagIsSet := a.OIDCAuthIssuerIsSet || a.OIDCAuthClientIDIsSet || a.OIDCAuthClientSecretIsSet || a.OIDCAuthScopesIsSet || a.OIDCAuthGroupsClaimIsSet
+	if oidcFlagIsSet && !a.OIDCAuthIsSet {
+		return errors.New("--oidc-auth-issuer, --oidc-auth-client-id and --oidc-auth-client-secret must all be provided to use OIDC auth")
+	}
+	if a.OIDCAuthIsSet {
+		if err := validateHTTPSURL("--oidc-auth-issuer", a.OIDCAuthIssuer); err != nil {
+			return err
+		}
+	}
+
+	ldapFlagIsSet := a.LDAPAuthHostIsSet || a.LDAPAuthBindDNIsSet || a.LDAPAuthBindPasswordIsSet || a.LDAPAuthUserSearchBaseDNIsSet ||
+		a.LDAPAuthUserSearchFilterIsSet || a.LDAPAuthUserSearchUsernameIsSet || a.LDAPAuthGroupSearchBaseDNIsSet || a.LDAPAuthGroupSearchFilterIsSet
+	if ldapFlagIsSet && !a.LDAPAuthIsSet {
+		return errors.New("--ldap-auth-host, --ldap-auth-bind-dn, --ldap-auth-bind-password and --ldap-auth-user-search-base-dn must all be provided to use LDAP auth")
+	}
+
+	gitlabFlagIsSet := a.GitLabAuthClientIDIsSet || a.GitLabAuthClientSecretIsSet || a.GitLabAuthHostIsSet
+	if gitlabFlagIsSet && !a.GitLabAuthIsSet {
+		return errors.New("--gitlab-auth-client-id and --gitlab-auth-client-secret must both be provided to use GitLab auth")
+	}
+	if a.GitLabAuthHostIsSet {
+		if err := validateHTTPSURL("--gitlab-auth-host", a.GitLabAuthHost); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateHTTPSURL(flag, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%s `%s` must be an https URL", flag, value)
+	}
+	return nil
+}
+
 func (a Args) validateNetworkRanges() error {
 	if a.PublicCIDR != "" || a.PrivateCIDR != "" {
 		if a.PublicCIDR == "" || a.PrivateCIDR == "" {
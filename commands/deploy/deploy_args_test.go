@@ -2,10 +2,13 @@ package deploy_test
 
 import (
 	"fmt"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	. "github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/config"
 )
 
 func TestDeployArgs_Validate(t *testing.T) {
@@ -131,6 +134,42 @@ func TestDeployArgs_Validate(t *testing.T) {
 			wantErr:     true,
 			expectedErr: fmt.Sprintf("unknown web node size: `bananas`. Valid sizes are: %v", WebSizes),
 		},
+		{
+			name: "Web count must be at least 1",
+			modification: func() Args {
+				args := defaultFields
+				args.WebCount = 0
+				args.WebCountIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "minimum number of web nodes is 1",
+		},
+		{
+			name: "Several web nodes are not supported on Azure",
+			modification: func() Args {
+				args := defaultFields
+				args.WebCount = 2
+				args.WebCountIsSet = true
+				args.IAAS = "Azure"
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--web-count above 1 is only supported on AWS and GCP",
+		},
+		{
+			name: "Several web nodes cannot be private",
+			modification: func() Args {
+				args := defaultFields
+				args.WebCount = 2
+				args.WebCountIsSet = true
+				args.Private = true
+				args.PrivateIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--web-count above 1 cannot be used with --private",
+		},
 		{
 			name: "DB size must be a known value",
 			modification: func() Args {
@@ -180,6 +219,38 @@ func TestDeployArgs_Validate(t *testing.T) {
 			wantErr:     true,
 			expectedErr: "`not a real tag` is not in the format `key=value`",
 		},
+		{
+			name: "Dry run cannot be combined with self update",
+			modification: func() Args {
+				args := defaultFields
+				args.DryRun = true
+				args.SelfUpdate = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--dry-run cannot be used with --self-update",
+		},
+		{
+			name: "Output must be a known format",
+			modification: func() Args {
+				args := defaultFields
+				args.Output = "xml"
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "unknown output `xml`. Valid outputs are: [text json-events]",
+		},
+		{
+			name: "JSON events cannot be combined with dry run",
+			modification: func() Args {
+				args := defaultFields
+				args.Output = OutputJSONEvents
+				args.DryRun = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--output json-events cannot be used with --dry-run",
+		},
 		{
 			name: "Both public-subnet-range and private-subnet-range are required when either is provided",
 			modification: func() Args {
@@ -223,6 +294,357 @@ func TestDeployArgs_Validate(t *testing.T) {
 			wantErr:     true,
 			expectedErr: "worker-type is only defined on AWS",
 		},
+		{
+			name: "Private deployments are only supported on AWS",
+			modification: func() Args {
+				args := defaultFields
+				args.Private = true
+				args.PrivateIsSet = true
+				args.IAAS = "GCP"
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--private is only supported on AWS",
+		},
+		{
+			name: "Instance identity is supported on GCP",
+			modification: func() Args {
+				args := defaultFields
+				args.InstanceIdentity = true
+				args.InstanceIdentityIsSet = true
+				args.IAAS = "GCP"
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "Instance identity is not supported on Azure",
+			modification: func() Args {
+				args := defaultFields
+				args.InstanceIdentity = true
+				args.InstanceIdentityIsSet = true
+				args.IAAS = "Azure"
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--instance-identity is only supported on AWS and GCP",
+		},
+		{
+			name: "Valid worker pools",
+			modification: func() Args {
+				args := defaultFields
+				args.WorkerPools = []config.WorkerPool{
+					{Name: "heavy", Count: 6, Size: "4xlarge", VMProvisioningType: config.SPOT, Tags: []string{"heavy"}},
+					{Name: "docker", Count: 1, Size: "large", VMProvisioningType: config.ON_DEMAND},
+				}
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "Worker pools with an unknown size should throw a helpful error",
+			modification: func() Args {
+				args := defaultFields
+				args.WorkerPools = []config.WorkerPool{{Name: "heavy", Count: 1, Size: "huge", VMProvisioningType: config.SPOT}}
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "worker pool `heavy` has unknown size `huge`",
+		},
+		{
+			name: "Worker pools with duplicate names should throw a helpful error",
+			modification: func() Args {
+				args := defaultFields
+				args.WorkerPools = []config.WorkerPool{
+					{Name: "heavy", Count: 1, Size: "large", VMProvisioningType: config.SPOT},
+					{Name: "heavy", Count: 2, Size: "large", VMProvisioningType: config.SPOT},
+				}
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "worker pool `heavy` is defined more than once",
+		},
+		{
+			name: "Worker pools with an unknown provisioning type should throw a helpful error",
+			modification: func() Args {
+				args := defaultFields
+				args.WorkerPools = []config.WorkerPool{{Name: "heavy", Count: 1, Size: "large", VMProvisioningType: "reserved"}}
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "unknown provisioning type `reserved`",
+		},
+		{
+			name: "Valid schedule",
+			modification: func() Args {
+				args := defaultFields
+				args.Schedule = "07:00-19:00"
+				args.ScheduleIsSet = true
+				args.ScheduleDays = "Monday, Wednesday"
+				args.ScheduleDaysIsSet = true
+				args.ScheduleLocation = "Europe/London"
+				args.ScheduleLocationIsSet = true
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "Removing the schedule",
+			modification: func() Args {
+				args := defaultFields
+				args.Schedule = ScheduleOff
+				args.ScheduleIsSet = true
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "Schedule must be a range of times",
+			modification: func() Args {
+				args := defaultFields
+				args.Schedule = "7am"
+				args.ScheduleIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "schedule `7am` is not in the format `HH:MM-HH:MM`",
+		},
+		{
+			name: "Schedule times must be valid",
+			modification: func() Args {
+				args := defaultFields
+				args.Schedule = "07:00-25:00"
+				args.ScheduleIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "schedule stop `25:00` is not a time in the format HH:MM",
+		},
+		{
+			name: "Schedule days must be known",
+			modification: func() Args {
+				args := defaultFields
+				args.Schedule = "07:00-19:00"
+				args.ScheduleIsSet = true
+				args.ScheduleDays = "Mon"
+				args.ScheduleDaysIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "schedule day `Mon` is invalid",
+		},
+		{
+			name: "Schedule location must be a known time zone",
+			modification: func() Args {
+				args := defaultFields
+				args.Schedule = "07:00-19:00"
+				args.ScheduleIsSet = true
+				args.ScheduleLocation = "Europe/Nowhere"
+				args.ScheduleLocationIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "schedule location `Europe/Nowhere` is not a known time zone",
+		},
+		{
+			name: "Schedule must keep a worker",
+			modification: func() Args {
+				args := defaultFields
+				args.Schedule = "07:00-19:00"
+				args.ScheduleIsSet = true
+				args.ScheduleIdleWorkers = 0
+				args.ScheduleIdleWorkersIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "schedule must keep at least 1 worker outside working hours",
+		},
+		{
+			name: "Schedule must keep fewer workers than it scales up to",
+			modification: func() Args {
+				args := defaultFields
+				args.WorkerCount = 2
+				args.WorkerCountIsSet = true
+				args.Schedule = "07:00-19:00"
+				args.ScheduleIsSet = true
+				args.ScheduleIdleWorkers = 2
+				args.ScheduleIdleWorkersIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--schedule-idle-workers must be fewer than --workers",
+		},
+		{
+			name: "Schedule flags require a schedule",
+			modification: func() Args {
+				args := defaultFields
+				args.ScheduleDays = "Monday"
+				args.ScheduleDaysIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "require --schedule",
+		},
+		{
+			name: "Release channel pinned to a version range",
+			modification: func() Args {
+				args := defaultFields
+				args.ReleaseChannel, args.ReleaseChannelIsSet = "0.17.x", true
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "Unknown release channel",
+			modification: func() Args {
+				args := defaultFields
+				args.ReleaseChannel, args.ReleaseChannelIsSet = "nightly", true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: `release channel "nightly" must be stable, pre-release or a version range such as 0.17.x`,
+		},
+		{
+			name: "Maintenance window",
+			modification: func() Args {
+				args := defaultFields
+				args.MaintenanceWindow, args.MaintenanceWindowIsSet = "22:00-02:00", true
+				args.MaintenanceWindowDays, args.MaintenanceWindowDaysIsSet = "Saturday,Sunday", true
+				args.MaintenanceWindowLocation, args.MaintenanceWindowLocationIsSet = "Europe/London", true
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "Maintenance window with an unknown day",
+			modification: func() Args {
+				args := defaultFields
+				args.MaintenanceWindow, args.MaintenanceWindowIsSet = "22:00-02:00", true
+				args.MaintenanceWindowDays, args.MaintenanceWindowDaysIsSet = "Caturday", true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "Caturday",
+		},
+		{
+			name: "Maintenance window flags require a maintenance window",
+			modification: func() Args {
+				args := defaultFields
+				args.MaintenanceWindowLocation, args.MaintenanceWindowLocationIsSet = "UTC", true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "require --maintenance-window",
+		},
+		{
+			name: "Pipelines from a git repository",
+			modification: func() Args {
+				args := defaultFields
+				args.Pipelines, args.PipelinesIsSet = "https://github.com/EngineerBetter/pipelines.git", true
+				args.PipelinesBranch, args.PipelinesBranchIsSet = "main", true
+				args.PipelinesManifest, args.PipelinesManifestIsSet = "ci/pipelines.yml", true
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "Pipelines branch requires a git repository",
+			modification: func() Args {
+				args := defaultFields
+				args.Pipelines, args.PipelinesIsSet = "./pipelines", true
+				args.PipelinesBranch, args.PipelinesBranchIsSet = "main", true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--pipelines-branch can only be used when --pipelines is a git URL",
+		},
+		{
+			name: "Pipelines manifest must be within the source",
+			modification: func() Args {
+				args := defaultFields
+				args.Pipelines, args.PipelinesIsSet = "./pipelines", true
+				args.PipelinesManifest, args.PipelinesManifestIsSet = "../pipelines.yml", true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "must be a path within --pipelines",
+		},
+		{
+			name: "Pipelines flags require pipelines",
+			modification: func() Args {
+				args := defaultFields
+				args.PipelinesManifest, args.PipelinesManifestIsSet = "ci/pipelines.yml", true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "require --pipelines",
+		},
+		{
+			name: "Valid OIDC, LDAP and GitLab auth",
+			modification: func() Args {
+				args := defaultFields
+				args.OIDCAuthIssuer, args.OIDCAuthIssuerIsSet = "https://sso.example.com", true
+				args.OIDCAuthClientID, args.OIDCAuthClientIDIsSet = "concourse", true
+				args.OIDCAuthClientSecret, args.OIDCAuthClientSecretIsSet = "secret", true
+				args.OIDCAuthIsSet = true
+				args.LDAPAuthHost, args.LDAPAuthHostIsSet = "ldap.example.com:636", true
+				args.LDAPAuthBindDN, args.LDAPAuthBindDNIsSet = "cn=concourse", true
+				args.LDAPAuthBindPassword, args.LDAPAuthBindPasswordIsSet = "password", true
+				args.LDAPAuthUserSearchBaseDN, args.LDAPAuthUserSearchBaseDNIsSet = "ou=people", true
+				args.LDAPAuthIsSet = true
+				args.GitLabAuthClientID, args.GitLabAuthClientIDIsSet = "id", true
+				args.GitLabAuthClientSecret, args.GitLabAuthClientSecretIsSet = "secret", true
+				args.GitLabAuthHost, args.GitLabAuthHostIsSet = "https://gitlab.example.com", true
+				args.GitLabAuthIsSet = true
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "OIDC auth requires an issuer, client ID and client secret",
+			modification: func() Args {
+				args := defaultFields
+				args.OIDCAuthClientID, args.OIDCAuthClientIDIsSet = "concourse", true
+				args.OIDCAuthScopesIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--oidc-auth-issuer, --oidc-auth-client-id and --oidc-auth-client-secret must all be provided to use OIDC auth",
+		},
+		{
+			name: "OIDC issuer must be an https URL",
+			modification: func() Args {
+				args := defaultFields
+				args.OIDCAuthIssuer, args.OIDCAuthIssuerIsSet = "sso.example.com", true
+				args.OIDCAuthClientID, args.OIDCAuthClientIDIsSet = "concourse", true
+				args.OIDCAuthClientSecret, args.OIDCAuthClientSecretIsSet = "secret", true
+				args.OIDCAuthIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--oidc-auth-issuer `sso.example.com` must be an https URL",
+		},
+		{
+			name: "LDAP auth requires a host, bind DN, bind password and user search base DN",
+			modification: func() Args {
+				args := defaultFields
+				args.LDAPAuthHost, args.LDAPAuthHostIsSet = "ldap.example.com:636", true
+				args.LDAPAuthGroupSearchBaseDNIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "must all be provided to use LDAP auth",
+		},
+		{
+			name: "GitLab host requires a GitLab client",
+			modification: func() Args {
+				args := defaultFields
+				args.GitLabAuthHost, args.GitLabAuthHostIsSet = "https://gitlab.example.com", true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--gitlab-auth-client-id and --gitlab-auth-client-secret must both be provided to use GitLab auth",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -244,6 +666,268 @@ func TestDeployArgs_Validate(t *testing.T) {
 	}
 }
 
+func TestDeployArgs_WorkerSchedule(t *testing.T) {
+	args := Args{Schedule: "07:00-19:00", ScheduleIsSet: true}
+	schedule, err := args.WorkerSchedule()
+	if err != nil {
+		t.Fatalf("Args.WorkerSchedule() error = %v", err)
+	}
+	want := &config.Schedule{Start: "07:00", Stop: "19:00", Days: config.Weekdays, Location: "UTC", IdleWorkerCount: 1}
+	if !reflect.DeepEqual(schedule, want) {
+		t.Errorf("Args.WorkerSchedule() = %+v, want defaults %+v", schedule, want)
+	}
+
+	args = Args{Schedule: ScheduleOff, ScheduleIsSet: true}
+	if schedule, err = args.WorkerSchedule(); schedule != nil || err != nil {
+		t.Errorf("Args.WorkerSchedule() = %+v, %v, want no schedule", schedule, err)
+	}
+}
+
+func TestDeployArgs_UpgradeMaintenanceWindow(t *testing.T) {
+	args := Args{MaintenanceWindow: "01:00-05:00", MaintenanceWindowIsSet: true}
+	window, err := args.UpgradeMaintenanceWindow()
+	if err != nil {
+		t.Fatalf("Args.UpgradeMaintenanceWindow() error = %v", err)
+	}
+	want := &config.MaintenanceWindow{Start: "01:00", Stop: "05:00", Days: config.EveryDay, Location: "UTC"}
+	if !reflect.DeepEqual(window, want) {
+		t.Errorf("Args.UpgradeMaintenanceWindow() = %+v, want defaults %+v", window, want)
+	}
+
+	args = Args{MaintenanceWindow: MaintenanceWindowOff, MaintenanceWindowIsSet: true}
+	if window, err = args.UpgradeMaintenanceWindow(); window != nil || err != nil {
+		t.Errorf("Args.UpgradeMaintenanceWindow() = %+v, %v, want no maintenance window", window, err)
+	}
+}
+
+func TestDeployArgs_ApplyNotifications(t *testing.T) {
+	existing := &config.Notifications{SlackWebhook: "https://hooks.slack.com/services/T0/B0/secret", EmailTo: []string{"ops@example.com"}}
+	args := Args{NotifyEmail: "ops@example.com, ci@example.com", NotifyEmailIsSet: true}
+	want := &config.Notifications{SlackWebhook: "https://hooks.slack.com/services/T0/B0/secret", EmailTo: []string{"ops@example.com", "ci@example.com"}}
+	if got := args.ApplyNotifications(existing); !reflect.DeepEqual(got, want) {
+		t.Errorf("Args.ApplyNotifications() = %+v, want %+v", got, want)
+	}
+
+	args = Args{NotifySlackWebhookIsSet: true, NotifyEmailIsSet: true}
+	if got := args.ApplyNotifications(existing); got != nil {
+		t.Errorf("Args.ApplyNotifications() = %+v, want no notifications", got)
+	}
+}
+
+func TestStoredArgs(t *testing.T) {
+	conf := config.Config{IAAS: "AWS", Region: "eu-west-1", Domain: "ci.example.com", AllowIPsUnformatted: "10.0.0.0/8, 192.0.2.1"}
+	want := Args{IAAS: "AWS", IAASIsSet: true, Region: "eu-west-1", RegionIsSet: true, Domain: "ci.example.com", DomainIsSet: true, AllowIPs: "10.0.0.0/8, 192.0.2.1"}
+	if got := StoredArgs(conf); !reflect.DeepEqual(got, want) {
+		t.Errorf("StoredArgs() = %+v, want %+v", got, want)
+	}
+
+	if got := StoredArgs(config.Config{}); got.AllowIPs != "0.0.0.0/0" {
+		t.Errorf("StoredArgs() allowed %q from a config without stored allowed IPs, want 0.0.0.0/0", got.AllowIPs)
+	}
+}
+
+func TestDeployArgs_UserPipelines(t *testing.T) {
+	args := Args{Pipelines: "git@github.com:EngineerBetter/pipelines.git", PipelinesIsSet: true}
+	want := &config.PipelinesSource{Source: "git@github.com:EngineerBetter/pipelines.git", Manifest: config.DefaultPipelinesManifest}
+	if got := args.UserPipelines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Args.UserPipelines() = %+v, want %+v", got, want)
+	}
+
+	args = Args{Pipelines: "pipelines", PipelinesIsSet: true}
+	if got := args.UserPipelines(); !filepath.IsAbs(got.Source) {
+		t.Errorf("Args.UserPipelines() = %+v, want an absolute local directory", got)
+	}
+
+	args = Args{Pipelines: PipelinesOff, PipelinesIsSet: true}
+	if got := args.UserPipelines(); got != nil {
+		t.Errorf("Args.UserPipelines() = %+v, want no pipelines", got)
+	}
+}
+
+func TestDeployArgs_AuthProviders(t *testing.T) {
+	args := Args{OIDCAuthIssuer: "https://sso.example.com", OIDCAuthClientID: "concourse", LDAPAuthHost: "ldap.example.com", GitLabAuthClientID: "id"}
+	if got, want := args.OIDCAuth(), (&config.OIDCAuth{Issuer: "https://sso.example.com", ClientID: "concourse", Scopes: DefaultOIDCAuthScopes, GroupsClaim: "groups"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Args.OIDCAuth() = %+v, want defaults %+v", got, want)
+	}
+	if got := args.LDAPAuth(); got.Host != "ldap.example.com" || got.UserSearchUsername != "uid" {
+		t.Errorf("Args.LDAPAuth() = %+v, want default username attribute uid", got)
+	}
+	if got := args.GitLabAuth(); got.Host != DefaultGitLabAuthHost {
+		t.Errorf("Args.GitLabAuth() = %+v, want default host %s", got, DefaultGitLabAuthHost)
+	}
+
+	args.OIDCAuthScopes, args.OIDCAuthScopesIsSet = "profile, groups:read", true
+	args.GitLabAuthHost, args.GitLabAuthHostIsSet = "https://gitlab.example.com/", true
+	if got := args.OIDCAuth().Scopes; !reflect.DeepEqual(got, []string{"profile", "groups:read"}) {
+		t.Errorf("Args.OIDCAuth() scopes = %v", got)
+	}
+	if got := args.GitLabAuth().Host; got != "https://gitlab.example.com" {
+		t.Errorf("Args.GitLabAuth() host = %v", got)
+	}
+}
+
+func TestDeployArgs_LoadWorkerPools(t *testing.T) {
+	files := map[string]string{
+		"pools.yml": "- name: heavy\n  count: 6\n  size: 4xlarge\n  vm_provisioning_type: spot\n  tags: [heavy]\n- name: docker\n  count: 1\n  size: large\n",
+		"empty.yml": "[]",
+		"bad.yml":   "name: heavy",
+	}
+	readFile := func(name string) ([]byte, error) {
+		contents, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("open %s: no such file or directory", name)
+		}
+		return []byte(contents), nil
+	}
+
+	tests := []struct {
+		name        string
+		args        Args
+		want        []config.WorkerPool
+		expectedErr string
+	}{
+		{
+			name: "No pools",
+		},
+		{
+			name: "Pools from flags",
+			args: Args{WorkerPoolSpecs: []string{"heavy:6:4xlarge:spot:heavy,big", "build:2:xlarge"}},
+			want: []config.WorkerPool{
+				{Name: "heavy", Count: 6, Size: "4xlarge", VMProvisioningType: config.SPOT, Tags: []string{"heavy", "big"}},
+				{Name: "build", Count: 2, Size: "xlarge", VMProvisioningType: config.ON_DEMAND},
+			},
+		},
+		{
+			name:        "Badly formatted flag",
+			args:        Args{WorkerPoolSpecs: []string{"heavy:6"}},
+			expectedErr: "worker pool `heavy:6` is not in the format",
+		},
+		{
+			name:        "Non-numeric count",
+			args:        Args{WorkerPoolSpecs: []string{"heavy:six:large"}},
+			expectedErr: "worker pool `heavy:six:large` has an invalid count `six`",
+		},
+		{
+			name: "Pools from a file",
+			args: Args{WorkerPoolsFile: "pools.yml", WorkerPoolsFileIsSet: true},
+			want: []config.WorkerPool{
+				{Name: "heavy", Count: 6, Size: "4xlarge", VMProvisioningType: config.SPOT, Tags: []string{"heavy"}},
+				{Name: "docker", Count: 1, Size: "large", VMProvisioningType: config.ON_DEMAND},
+			},
+		},
+		{
+			name: "Empty file removes pools",
+			args: Args{WorkerPoolsFile: "empty.yml", WorkerPoolsFileIsSet: true},
+			want: []config.WorkerPool{},
+		},
+		{
+			name:        "File that is not a list",
+			args:        Args{WorkerPoolsFile: "bad.yml", WorkerPoolsFileIsSet: true},
+			expectedErr: "failed to parse worker pools file bad.yml",
+		},
+		{
+			name:        "Missing file",
+			args:        Args{WorkerPoolsFile: "missing.yml", WorkerPoolsFileIsSet: true},
+			expectedErr: "failed to read worker pools file",
+		},
+		{
+			name:        "Both flags",
+			args:        Args{WorkerPoolSpecs: []string{"build:2:xlarge"}, WorkerPoolsFile: "pools.yml", WorkerPoolsFileIsSet: true},
+			expectedErr: "--worker-pool cannot be used with --worker-pools-file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			err := args.LoadWorkerPools(readFile)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Errorf("DeployArgs.LoadWorkerPools() error = %v, expected error containing %q", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeployArgs.LoadWorkerPools() error = %v", err)
+			}
+			if !reflect.DeepEqual(args.WorkerPools, tt.want) {
+				t.Errorf("DeployArgs.LoadWorkerPools() loaded %#v, want %#v", args.WorkerPools, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeployArgs_LoadManifestFiles(t *testing.T) {
+	files := map[string]string{
+		"garden.yml": "- type: replace\n  path: /instance_groups/name=worker/jobs/name=worker/properties/garden?/max_containers\n  value: ((max_containers))\n",
+		"vars.yml":   "max_containers: 500\n",
+		"bad-op.yml": "- type: replace\n  value: 1\n",
+		"list.yml":   "- max_containers\n",
+	}
+	readFile := func(name string) ([]byte, error) {
+		contents, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("open %s: no such file or directory", name)
+		}
+		return []byte(contents), nil
+	}
+
+	tests := []struct {
+		name        string
+		args        Args
+		want        Args
+		expectedErr string
+	}{
+		{
+			name: "No files",
+		},
+		{
+			name: "Ops and vars files",
+			args: Args{ConcourseOpsFilePaths: []string{"garden.yml"}, DirectorOpsFilePaths: []string{"garden.yml"}, VarsFilePaths: []string{"vars.yml"}},
+			want: Args{
+				ConcourseOpsFilePaths: []string{"garden.yml"},
+				ConcourseOpsFiles:     []config.ManifestFile{{Name: "garden.yml", Contents: files["garden.yml"]}},
+				DirectorOpsFilePaths:  []string{"garden.yml"},
+				DirectorOpsFiles:      []config.ManifestFile{{Name: "garden.yml", Contents: files["garden.yml"]}},
+				VarsFilePaths:         []string{"vars.yml"},
+				VarsFiles:             []config.ManifestFile{{Name: "vars.yml", Contents: files["vars.yml"]}},
+			},
+		},
+		{
+			name:        "Missing file",
+			args:        Args{ConcourseOpsFilePaths: []string{"missing.yml"}},
+			expectedErr: "failed to read missing.yml",
+		},
+		{
+			name:        "Operation without a path",
+			args:        Args{DirectorOpsFilePaths: []string{"bad-op.yml"}},
+			expectedErr: "operation 1 in ops file bad-op.yml must have a type and a path",
+		},
+		{
+			name:        "Vars file that is not a map",
+			args:        Args{VarsFilePaths: []string{"list.yml"}},
+			expectedErr: "vars file list.yml is not a map of variables",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			err := args.LoadManifestFiles(readFile)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Errorf("DeployArgs.LoadManifestFiles() error = %v, expected error containing %q", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeployArgs.LoadManifestFiles() error = %v", err)
+			}
+			if !reflect.DeepEqual(args, tt.want) {
+				t.Errorf("DeployArgs.LoadManifestFiles() loaded %#v, want %#v", args, tt.want)
+			}
+		})
+	}
+}
+
 func TestDeployArgs_MarkSetFlags(t *testing.T) {
 	tests := []struct {
 		name                    string
@@ -0,0 +1,274 @@
+package deploy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/ghodss/yaml"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Spec is a deployment file given to deploy with --file. Its keys are the names of the deploy
+// flags, plus name, tags, worker-pools, concourse-ops-files, director-ops-files and vars-files. A
+// value in the file is used unless the flag is also given.
+type Spec struct {
+	Name      *string `json:"name"`
+	IAAS      *string `json:"iaas"`
+	Region    *string `json:"region"`
+	Namespace *string `json:"namespace"`
+	Zone      *string `json:"zone"`
+
+	Domain  *string `json:"domain"`
+	TLSCert *string `json:"tls-cert"`
+	TLSKey  *string `json:"tls-key"`
+
+	Workers     *int                 `json:"workers"`
+	WorkerSize  *string              `json:"worker-size"`
+	WorkerType  *string              `json:"worker-type"`
+	WorkerPools *[]config.WorkerPool `json:"worker-pools"`
+	Spot        *bool                `json:"spot"`
+	Preemptible *bool                `json:"preemptible"`
+	WebSize     *string              `json:"web-size"`
+	WebCount    *int                 `json:"web-count"`
+
+	Schedule            *string `json:"schedule"`
+	ScheduleDays        *string `json:"schedule-days"`
+	ScheduleLocation    *string `json:"schedule-location"`
+	ScheduleIdleWorkers *int    `json:"schedule-idle-workers"`
+	ReleaseChannel      *string `json:"release-channel"`
+	DBSize              *string `json:"db-size"`
+
+	MaintenanceWindow         *string `json:"maintenance-window"`
+	MaintenanceWindowDays     *string `json:"maintenance-window-days"`
+	MaintenanceWindowLocation *string `json:"maintenance-window-location"`
+	UpgradeApproval           *bool   `json:"upgrade-approval"`
+	NotifySlackWebhook        *string `json:"notify-slack-webhook"`
+	NotifyWebhook             *string `json:"notify-webhook"`
+	NotifyEmail               *string `json:"notify-email"`
+	NotifyEmailFrom           *string `json:"notify-email-from"`
+	NotifySMTPURL             *string `json:"notify-smtp-url"`
+	NotifySMTPUsername        *string `json:"notify-smtp-username"`
+	NotifySMTPPassword        *string `json:"notify-smtp-password"`
+
+	EnableGlobalResources   *bool   `json:"enable-global-resources"`
+	EnablePipelineInstances *bool   `json:"enable-pipeline-instances"`
+	InfluxDbRetentionPeriod *string `json:"influxdb-retention-period"`
+
+	AllowIPs                  *string   `json:"allow-ips"`
+	BitbucketAuthClientID     *string   `json:"bitbucket-auth-client-id"`
+	BitbucketAuthClientSecret *string   `json:"bitbucket-auth-client-secret"`
+	GithubAuthClientID        *string   `json:"github-auth-client-id"`
+	GithubAuthClientSecret    *string   `json:"github-auth-client-secret"`
+	MicrosoftAuthClientID     *string   `json:"microsoft-auth-client-id"`
+	MicrosoftAuthClientSecret *string   `json:"microsoft-auth-client-secret"`
+	MicrosoftAuthTenant       *string   `json:"microsoft-auth-tenant"`
+	Tags                      *[]string `json:"tags"`
+
+	ConcourseOpsFiles *[]string `json:"concourse-ops-files"`
+	DirectorOpsFiles  *[]string `json:"director-ops-files"`
+	VarsFiles         *[]string `json:"vars-files"`
+
+	OIDCAuthIssuer             *string `json:"oidc-auth-issuer"`
+	OIDCAuthClientID           *string `json:"oidc-auth-client-id"`
+	OIDCAuthClientSecret       *string `json:"oidc-auth-client-secret"`
+	OIDCAuthScopes             *string `json:"oidc-auth-scopes"`
+	OIDCAuthGroupsClaim        *string `json:"oidc-auth-groups-claim"`
+	LDAPAuthHost               *string `json:"ldap-auth-host"`
+	LDAPAuthBindDN             *string `json:"ldap-auth-bind-dn"`
+	LDAPAuthBindPassword       *string `json:"ldap-auth-bind-password"`
+	LDAPAuthUserSearchBaseDN   *string `json:"ldap-auth-user-search-base-dn"`
+	LDAPAuthUserSearchFilter   *string `json:"ldap-auth-user-search-filter"`
+	LDAPAuthUserSearchUsername *string `json:"ldap-auth-user-search-username"`
+	LDAPAuthGroupSearchBaseDN  *string `json:"ldap-auth-group-search-base-dn"`
+	LDAPAuthGroupSearchFilter  *string `json:"ldap-auth-group-search-filter"`
+	GitLabAuthClientID         *string `json:"gitlab-auth-client-id"`
+	GitLabAuthClientSecret     *string `json:"gitlab-auth-client-secret"`
+	GitLabAuthHost             *string `json:"gitlab-auth-host"`
+
+	Pipelines         *string `json:"pipelines"`
+	PipelinesBranch   *string `json:"pipelines-branch"`
+	PipelinesManifest *string `json:"pipelines-manifest"`
+
+	VPCNetworkRange    *string `json:"vpc-network-range"`
+	PublicSubnetRange  *string `json:"public-subnet-range"`
+	PrivateSubnetRange *string `json:"private-subnet-range"`
+	RDSSubnetRange1    *string `json:"rds-subnet-range1"`
+	RDSSubnetRange2    *string `json:"rds-subnet-range2"`
+	Private            *bool   `json:"private"`
+	InstanceIdentity   *bool   `json:"instance-identity"`
+}
+
+// ParseSpec parses a YAML deployment file, rejecting keys it does not know about
+func ParseSpec(contents []byte) (Spec, error) {
+	var spec Spec
+	j, err := yaml.YAMLToJSON(contents)
+	if err != nil {
+		return spec, err
+	}
+	if bytes.Equal(bytes.TrimSpace(j), []byte("null")) {
+		return spec, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(j))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&spec); err != nil {
+		return spec, err
+	}
+
+	if spec.Spot != nil && spec.Preemptible != nil && *spec.Spot != *spec.Preemptible {
+		return spec, fmt.Errorf("spot and preemptible must not disagree")
+	}
+	if spec.WorkerPools != nil {
+		config.DefaultWorkerPools(*spec.WorkerPools)
+	}
+	return spec, nil
+}
+
+// LoadSpec reads the deployment file given with --file and applies it to any options that were not given as flags
+func (a *Args) LoadSpec(readFile func(string) ([]byte, error)) error {
+	if !a.SpecFileIsSet {
+		return nil
+	}
+
+	contents, err := readFile(a.SpecFile)
+	if err != nil {
+		return fmt.Errorf("failed to read deployment file: [%v]", err)
+	}
+	spec, err := ParseSpec(contents)
+	if err != nil {
+		return fmt.Errorf("failed to parse deployment file %s: [%v]", a.SpecFile, err)
+	}
+
+	a.ApplySpec(spec)
+	return nil
+}
+
+// ApplySpec sets every option given in spec, unless it was already set by a flag
+func (a *Args) ApplySpec(spec Spec) {
+	applyString(spec.Name, &a.Name, &a.NameIsSet)
+	applyString(spec.IAAS, &a.IAAS, &a.IAASIsSet)
+	applyString(spec.Region, &a.Region, &a.RegionIsSet)
+	applyString(spec.Namespace, &a.Namespace, &a.NamespaceIsSet)
+	applyString(spec.Zone, &a.Zone, &a.ZoneIsSet)
+
+	applyString(spec.Domain, &a.Domain, &a.DomainIsSet)
+	applyString(spec.TLSCert, &a.TLSCert, &a.TLSCertIsSet)
+	applyString(spec.TLSKey, &a.TLSKey, &a.TLSKeyIsSet)
+
+	if spec.Workers != nil && !a.WorkerCountIsSet {
+		a.WorkerCount = *spec.Workers
+		a.WorkerCountIsSet = true
+	}
+	applyString(spec.WorkerSize, &a.WorkerSize, &a.WorkerSizeIsSet)
+	applyString(spec.WorkerType, &a.WorkerType, &a.WorkerTypeIsSet)
+	if spec.WorkerPools != nil && !a.WorkerPoolsIsSet {
+		a.WorkerPools = *spec.WorkerPools
+		a.WorkerPoolsIsSet = true
+	}
+	applyBool(spec.Spot, &a.Spot, &a.SpotIsSet)
+	applyBool(spec.Preemptible, &a.Spot, &a.SpotIsSet)
+	applyString(spec.WebSize, &a.WebSize, &a.WebSizeIsSet)
+	if spec.WebCount != nil && !a.WebCountIsSet {
+		a.WebCount = *spec.WebCount
+		a.WebCountIsSet = true
+	}
+	applyString(spec.Schedule, &a.Schedule, &a.ScheduleIsSet)
+	applyString(spec.ScheduleDays, &a.ScheduleDays, &a.ScheduleDaysIsSet)
+	applyString(spec.ScheduleLocation, &a.ScheduleLocation, &a.ScheduleLocationIsSet)
+	if spec.ScheduleIdleWorkers != nil && !a.ScheduleIdleWorkersIsSet {
+		a.ScheduleIdleWorkers = *spec.ScheduleIdleWorkers
+		a.ScheduleIdleWorkersIsSet = true
+	}
+	applyString(spec.ReleaseChannel, &a.ReleaseChannel, &a.ReleaseChannelIsSet)
+	applyString(spec.DBSize, &a.DBSize, &a.DBSizeIsSet)
+
+	applyString(spec.MaintenanceWindow, &a.MaintenanceWindow, &a.MaintenanceWindowIsSet)
+	applyString(spec.MaintenanceWindowDays, &a.MaintenanceWindowDays, &a.MaintenanceWindowDaysIsSet)
+	applyString(spec.MaintenanceWindowLocation, &a.MaintenanceWindowLocation, &a.MaintenanceWindowLocationIsSet)
+	applyBool(spec.UpgradeApproval, &a.UpgradeApproval, &a.UpgradeApprovalIsSet)
+	applyString(spec.NotifySlackWebhook, &a.NotifySlackWebhook, &a.NotifySlackWebhookIsSet)
+	applyString(spec.NotifyWebhook, &a.NotifyWebhook, &a.NotifyWebhookIsSet)
+	applyString(spec.NotifyEmail, &a.NotifyEmail, &a.NotifyEmailIsSet)
+	applyString(spec.NotifyEmailFrom, &a.NotifyEmailFrom, &a.NotifyEmailFromIsSet)
+	applyString(spec.NotifySMTPURL, &a.NotifySMTPURL, &a.NotifySMTPURLIsSet)
+	applyString(spec.NotifySMTPUsername, &a.NotifySMTPUsername, &a.NotifySMTPUsernameIsSet)
+	applyString(spec.NotifySMTPPassword, &a.NotifySMTPPassword, &a.NotifySMTPPasswordIsSet)
+
+	applyBool(spec.EnableGlobalResources, &a.EnableGlobalResources, &a.EnableGlobalResourcesIsSet)
+	applyBool(spec.EnablePipelineInstances, &a.EnablePipelineInstances, &a.EnablePipelineInstancesIsSet)
+	applyString(spec.InfluxDbRetentionPeriod, &a.InfluxDbRetention, &a.InfluxDbRetentionIsSet)
+
+	applyString(spec.AllowIPs, &a.AllowIPs, &a.AllowIPsIsSet)
+	applyString(spec.BitbucketAuthClientID, &a.BitbucketAuthClientID, &a.BitbucketAuthClientIDIsSet)
+	applyString(spec.BitbucketAuthClientSecret, &a.BitbucketAuthClientSecret, &a.BitbucketAuthClientSecretIsSet)
+	applyString(spec.GithubAuthClientID, &a.GithubAuthClientID, &a.GithubAuthClientIDIsSet)
+	applyString(spec.GithubAuthClientSecret, &a.GithubAuthClientSecret, &a.GithubAuthClientSecretIsSet)
+	applyString(spec.MicrosoftAuthClientID, &a.MicrosoftAuthClientID, &a.MicrosoftAuthClientIDIsSet)
+	applyString(spec.MicrosoftAuthClientSecret, &a.MicrosoftAuthClientSecret, &a.MicrosoftAuthClientSecretIsSet)
+	applyString(spec.MicrosoftAuthTenant, &a.MicrosoftAuthTenant, &a.MicrosoftAuthTenantIsSet)
+	if spec.Tags != nil && !a.TagsIsSet {
+		a.Tags = *spec.Tags
+		a.TagsIsSet = true
+	}
+	applyStrings(spec.ConcourseOpsFiles, &a.ConcourseOpsFilePaths, &a.ConcourseOpsFilesIsSet)
+	applyStrings(spec.DirectorOpsFiles, &a.DirectorOpsFilePaths, &a.DirectorOpsFilesIsSet)
+	applyStrings(spec.VarsFiles, &a.VarsFilePaths, &a.VarsFilesIsSet)
+
+	applyString(spec.OIDCAuthIssuer, &a.OIDCAuthIssuer, &a.OIDCAuthIssuerIsSet)
+	applyString(spec.OIDCAuthClientID, &a.OIDCAuthClientID, &a.OIDCAuthClientIDIsSet)
+	applyString(spec.OIDCAuthClientSecret, &a.OIDCAuthClientSecret, &a.OIDCAuthClientSecretIsSet)
+	applyString(spec.OIDCAuthScopes, &a.OIDCAuthScopes, &a.OIDCAuthScopesIsSet)
+	applyString(spec.OIDCAuthGroupsClaim, &a.OIDCAuthGroupsClaim, &a.OIDCAuthGroupsClaimIsSet)
+	applyString(spec.LDAPAuthHost, &a.LDAPAuthHost, &a.LDAPAuthHostIsSet)
+	applyString(spec.LDAPAuthBindDN, &a.LDAPAuthBindDN, &a.LDAPAuthBindDNIsSet)
+	applyString(spec.LDAPAuthBindPassword, &a.LDAPAuthBindPassword, &a.LDAPAuthBindPasswordIsSet)
+	applyString(spec.LDAPAuthUserSearchBaseDN, &a.LDAPAuthUserSearchBaseDN, &a.LDAPAuthUserSearchBaseDNIsSet)
+	applyString(spec.LDAPAuthUserSearchFilter, &a.LDAPAuthUserSearchFilter, &a.LDAPAuthUserSearchFilterIsSet)
+	applyString(spec.LDAPAuthUserSearchUsername, &a.LDAPAuthUserSearchUsername, &a.LDAPAuthUserSearchUsernameIsSet)
+	applyString(spec.LDAPAuthGroupSearchBaseDN, &a.LDAPAuthGroupSearchBaseDN, &a.LDAPAuthGroupSearchBaseDNIsSet)
+	applyString(spec.LDAPAuthGroupSearchFilter, &a.LDAPAuthGroupSearchFilter, &a.LDAPAuthGroupSearchFilterIsSet)
+	applyString(spec.GitLabAuthClientID, &a.GitLabAuthClientID, &a.GitLabAuthClientIDIsSet)
+	applyString(spec.GitLabAuthClientSecret, &a.GitLabAuthClientSecret, &a.GitLabAuthClientSecretIsSet)
+	applyString(spec.GitLabAuthHost, &a.GitLabAuthHost, &a.GitLabAuthHostIsSet)
+
+	applyString(spec.Pipelines, &a.Pipelines, &a.PipelinesIsSet)
+	applyString(spec.PipelinesBranch, &a.PipelinesBranch, &a.PipelinesBranchIsSet)
+	applyString(spec.PipelinesManifest, &a.PipelinesManifest, &a.PipelinesManifestIsSet)
+
+	applyString(spec.VPCNetworkRange, &a.NetworkCIDR, &a.NetworkCIDRIsSet)
+	applyString(spec.PublicSubnetRange, &a.PublicCIDR, &a.PublicCIDRIsSet)
+	applyString(spec.PrivateSubnetRange, &a.PrivateCIDR, &a.PrivateCIDRIsSet)
+	applyString(spec.RDSSubnetRange1, &a.RDS1CIDR, &a.RDS1CIDRIsSet)
+	applyString(spec.RDSSubnetRange2, &a.RDS2CIDR, &a.RDS2CIDRIsSet)
+	applyBool(spec.Private, &a.Private, &a.PrivateIsSet)
+	applyBool(spec.InstanceIdentity, &a.InstanceIdentity, &a.InstanceIdentityIsSet)
+
+	a.BitbucketAuthIsSet = a.BitbucketAuthClientIDIsSet && a.BitbucketAuthClientSecretIsSet
+	a.GithubAuthIsSet = a.GithubAuthClientIDIsSet && a.GithubAuthClientSecretIsSet
+	a.MicrosoftAuthIsSet = a.MicrosoftAuthClientIDIsSet && a.MicrosoftAuthClientSecretIsSet
+	a.markAuthSet()
+	a.markNotificationsSet()
+}
+
+func applyString(value *string, field *string, isSet *bool) {
+	if value != nil && !*isSet {
+		*field = *value
+		*isSet = true
+	}
+}
+
+func applyBool(value *bool, field *bool, isSet *bool) {
+	if value != nil && !*isSet {
+		*field = *value
+		*isSet = true
+	}
+}
+
+func applyStrings(value *[]string, field *cli.StringSlice, isSet *bool) {
+	if value != nil && !*isSet {
+		*field = *value
+		*isSet = true
+	}
+}
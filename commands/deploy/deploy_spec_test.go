@@ -0,0 +1,192 @@
+package deploy_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/config"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		name        string
+		contents    string
+		expectedErr string
+	}{
+		{
+			name:     "Empty file",
+			contents: "",
+		},
+		{
+			name: "Every kind of option",
+			contents: `name: ci
+iaas: AWS
+workers: 3
+spot: false
+tags: [team=ci]
+concourse-ops-files: [ops/garden.yml]
+vars-files: [ops/vars.yml]
+release-channel: 0.17.x
+maintenance-window: 22:00-02:00
+maintenance-window-days: Saturday,Sunday
+upgrade-approval: true
+notify-email: ops@example.com
+worker-pools:
+- name: heavy
+  count: 2
+  size: 4xlarge
+`,
+		},
+		{
+			name:        "Unknown key",
+			contents:    "worker_count: 3",
+			expectedErr: `unknown field "worker_count"`,
+		},
+		{
+			name:        "Unknown worker pool key",
+			contents:    "worker-pools:\n- name: heavy\n  instances: 2\n",
+			expectedErr: `unknown field "instances"`,
+		},
+		{
+			name:        "Wrong type",
+			contents:    "workers: lots",
+			expectedErr: "cannot unmarshal string",
+		},
+		{
+			name:        "Spot and preemptible disagree",
+			contents:    "spot: true\npreemptible: false",
+			expectedErr: "spot and preemptible must not disagree",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSpec([]byte(tt.contents))
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Errorf("ParseSpec() error = %v, expected error containing %q", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("ParseSpec() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDeployArgs_LoadSpec(t *testing.T) {
+	files := map[string]string{
+		"control-tower.yml": `name: ci
+iaas: AWS
+region: eu-west-1
+workers: 3
+worker-size: large
+spot: false
+allow-ips: 10.0.0.0/8
+github-auth-client-id: id
+github-auth-client-secret: secret
+tags: [team=ci]
+worker-pools:
+- name: heavy
+  count: 2
+  size: 4xlarge
+`,
+		"bad.yml": "workers: [3]",
+	}
+	readFile := func(name string) ([]byte, error) {
+		contents, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("open %s: no such file or directory", name)
+		}
+		return []byte(contents), nil
+	}
+
+	tests := []struct {
+		name        string
+		args        Args
+		want        Args
+		expectedErr string
+	}{
+		{
+			name: "No file",
+			args: Args{WorkerCount: 1},
+			want: Args{WorkerCount: 1},
+		},
+		{
+			name: "File sets options that were not given as flags",
+			args: Args{SpecFile: "control-tower.yml", SpecFileIsSet: true, WorkerCount: 1, WorkerSize: "xlarge", Spot: true, AllowIPs: "0.0.0.0/0"},
+			want: Args{
+				SpecFile: "control-tower.yml", SpecFileIsSet: true,
+				Name: "ci", NameIsSet: true,
+				IAAS: "AWS", IAASIsSet: true,
+				Region: "eu-west-1", RegionIsSet: true,
+				WorkerCount: 3, WorkerCountIsSet: true,
+				WorkerSize: "large", WorkerSizeIsSet: true,
+				Spot: false, SpotIsSet: true,
+				AllowIPs: "10.0.0.0/8", AllowIPsIsSet: true,
+				GithubAuthClientID: "id", GithubAuthClientIDIsSet: true,
+				GithubAuthClientSecret: "secret", GithubAuthClientSecretIsSet: true,
+				GithubAuthIsSet: true,
+				Tags:            []string{"team=ci"}, TagsIsSet: true,
+				WorkerPools:      []config.WorkerPool{{Name: "heavy", Count: 2, Size: "4xlarge", VMProvisioningType: config.ON_DEMAND}},
+				WorkerPoolsIsSet: true,
+			},
+		},
+		{
+			name: "Flags take precedence over the file",
+			args: Args{
+				SpecFile: "control-tower.yml", SpecFileIsSet: true,
+				WorkerCount: 5, WorkerCountIsSet: true,
+				AllowIPs: "1.2.3.4", AllowIPsIsSet: true,
+				GithubAuthClientID: "flag-id", GithubAuthClientIDIsSet: true,
+				WorkerPoolsIsSet: true,
+			},
+			want: Args{
+				SpecFile: "control-tower.yml", SpecFileIsSet: true,
+				Name: "ci", NameIsSet: true,
+				IAAS: "AWS", IAASIsSet: true,
+				Region: "eu-west-1", RegionIsSet: true,
+				WorkerCount: 5, WorkerCountIsSet: true,
+				WorkerSize: "large", WorkerSizeIsSet: true,
+				Spot: false, SpotIsSet: true,
+				AllowIPs: "1.2.3.4", AllowIPsIsSet: true,
+				GithubAuthClientID: "flag-id", GithubAuthClientIDIsSet: true,
+				GithubAuthClientSecret: "secret", GithubAuthClientSecretIsSet: true,
+				GithubAuthIsSet: true,
+				Tags:            []string{"team=ci"}, TagsIsSet: true,
+				WorkerPoolsIsSet: true,
+			},
+		},
+		{
+			name:        "Missing file",
+			args:        Args{SpecFile: "missing.yml", SpecFileIsSet: true},
+			expectedErr: "failed to read deployment file",
+		},
+		{
+			name:        "Invalid file",
+			args:        Args{SpecFile: "bad.yml", SpecFileIsSet: true},
+			expectedErr: "failed to parse deployment file bad.yml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			err := args.LoadSpec(readFile)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Errorf("DeployArgs.LoadSpec() error = %v, expected error containing %q", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeployArgs.LoadSpec() error = %v", err)
+			}
+			if !reflect.DeepEqual(args, tt.want) {
+				t.Errorf("DeployArgs.LoadSpec() got %+v, want %+v", args, tt.want)
+			}
+		})
+	}
+}
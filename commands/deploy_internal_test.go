@@ -209,6 +209,11 @@ func Test_validateCidrRanges(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error creating AWS provider in test: [%v]", err)
 	}
+	testsupport.SetupFakeCredsForAzureProvider(t)
+	azureProvider, err := iaas.New(iaas.Azure, "westeurope")
+	if err != nil {
+		t.Fatalf("Error creating Azure provider in test: [%v]", err)
+	}
 
 	type args struct {
 		provider    iaas.Provider
@@ -478,6 +483,37 @@ func Test_validateCidrRanges(t *testing.T) {
 			wantErr:       true,
 			desiredErrMsg: "error validating CIDR ranges - rds2-subnet-range is not big enough, at least /29 needed.",
 		},
+		{
+			name: "errs if network range is not provided on Azure",
+			args: args{
+				provider:    azureProvider,
+				privateCidr: "10.0.1.0/24",
+				publicCidr:  "10.0.0.0/24",
+			},
+			wantErr:       true,
+			desiredErrMsg: "error validating CIDR ranges - vpc-network-range must be provided when using Azure",
+		},
+		{
+			name: "errs if public range is outside the network range on Azure",
+			args: args{
+				provider:    azureProvider,
+				networkCidr: "10.0.0.0/16",
+				privateCidr: "10.0.1.0/24",
+				publicCidr:  "10.1.0.0/24",
+			},
+			wantErr:       true,
+			desiredErrMsg: "error validating CIDR ranges - public-subnet-range must be within vpc-network-range",
+		},
+		{
+			name: "does not err with valid ranges on Azure",
+			args: args{
+				provider:    azureProvider,
+				networkCidr: "10.0.0.0/16",
+				privateCidr: "10.0.1.0/24",
+				publicCidr:  "10.0.0.0/24",
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -9,7 +9,7 @@ import (
 	"github.com/EngineerBetter/control-tower/certs"
 	"github.com/EngineerBetter/control-tower/commands/destroy"
 	"github.com/EngineerBetter/control-tower/concourse"
-	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/iaas"
 	"github.com/EngineerBetter/control-tower/resource"
@@ -31,7 +31,7 @@ var destroyFlags = []cli.Flag{
 	},
 	cli.StringFlag{
 		Name:        "iaas",
-		Usage:       "(required) IAAS, can be AWS or GCP",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
 		EnvVar:      "IAAS",
 		Destination: &initialDestroyArgs.IAAS,
 	},
@@ -85,8 +85,9 @@ func validateDestroyArgs(c *cli.Context, destroyArgs destroy.Args) (destroy.Args
 
 func buildDestroyClient(name, version string, destroyArgs destroy.Args, provider iaas.Provider) (*concourse.Client, error) {
 	versionFile, _ := provider.Choose(iaas.Choice{
-		AWS: resource.AWSVersionFile,
-		GCP: resource.GCPVersionFile,
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
 	}).([]byte)
 
 	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
@@ -94,22 +95,28 @@ func buildDestroyClient(name, version string, destroyArgs destroy.Args, provider
 		return nil, err
 	}
 
-	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider)
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
 	if err != nil {
 		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
 	}
 
+	configClient, err := buildConfigClient(provider, name, destroyArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
 	client := concourse.NewClient(
 		provider,
 		terraformClient,
 		tfInputVarsFactory,
 		bosh.New,
-		fly.New,
+		fly.NewWithState(selfUpdateState()),
 		certs.Generate,
-		config.New(provider, name, destroyArgs.Namespace),
+		configClient,
 		nil,
 		os.Stdout,
 		os.Stderr,
+		events.Discard,
 		util.FindUserIP,
 		certs.NewAcmeClient,
 		util.GeneratePasswordWithLength,
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/history"
+	"github.com/EngineerBetter/control-tower/concourse"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/util"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var initialHistoryArgs history.Args
+
+var historyFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       "(optional) AWS region",
+		EnvVar:      "AWS_REGION",
+		Destination: &initialHistoryArgs.Region,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
+		EnvVar:      "IAAS",
+		Destination: &initialHistoryArgs.IAAS,
+	},
+	cli.StringFlag{
+		Name:        "namespace",
+		Usage:       "(optional) Specify a namespace for deployments in order to group them in a meaningful way",
+		EnvVar:      "NAMESPACE",
+		Destination: &initialHistoryArgs.Namespace,
+	},
+}
+
+func historyAction(c *cli.Context, historyArgs history.Args, provider iaas.Provider) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return errors.New("Usage is `control-tower history <name>`")
+	}
+
+	version := c.App.Version
+
+	client, err := buildHistoryClient(name, version, historyArgs, provider)
+	if err != nil {
+		return err
+	}
+	return client.History()
+}
+
+func validateHistoryArgs(c *cli.Context, historyArgs history.Args) (history.Args, error) {
+	err := historyArgs.MarkSetFlags(c)
+	if err != nil {
+		return historyArgs, fmt.Errorf("failed to mark set History flags: [%v]", err)
+	}
+
+	if err = historyArgs.Validate(); err != nil {
+		return historyArgs, fmt.Errorf("failed to validate History flags: [%v]", err)
+	}
+
+	return historyArgs, nil
+}
+
+func buildHistoryClient(name, version string, historyArgs history.Args, provider iaas.Provider) (*concourse.Client, error) {
+	versionFile, _ := provider.Choose(iaas.Choice{
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
+	}).([]byte)
+
+	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
+	}
+
+	configClient, err := buildConfigClient(provider, name, historyArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	client := concourse.NewClient(
+		provider,
+		terraformClient,
+		tfInputVarsFactory,
+		bosh.New,
+		fly.NewWithState(selfUpdateState()),
+		certs.Generate,
+		configClient,
+		nil,
+		os.Stdout,
+		os.Stderr,
+		events.Discard,
+		util.FindUserIP,
+		certs.NewAcmeClient,
+		util.GeneratePasswordWithLength,
+		util.EightRandomLetters,
+		util.GenerateSSHKeyPair,
+		version,
+		versionFile,
+	)
+
+	return client, nil
+}
+
+var historyCmd = cli.Command{
+	Name:      "history",
+	Usage:     "Lists the recorded revisions of a Concourse's config",
+	ArgsUsage: "<name>",
+	Flags:     historyFlags,
+	Action: func(c *cli.Context) error {
+		historyArgs, err := validateHistoryArgs(c, initialHistoryArgs)
+		if err != nil {
+			return fmt.Errorf("Error validating args on history: [%v]", err)
+		}
+		iaasName, err := iaas.Validate(historyArgs.IAAS)
+		if err != nil {
+			return fmt.Errorf("Error mapping to supported IAASes on history: [%v]", err)
+		}
+		provider, err := iaas.New(iaasName, historyArgs.Region)
+		if err != nil {
+			return fmt.Errorf("Error creating IAAS provider on history: [%v]", err)
+		}
+		return historyAction(c, historyArgs, provider)
+	},
+}
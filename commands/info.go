@@ -12,7 +12,7 @@ import (
 	"github.com/EngineerBetter/control-tower/certs"
 	"github.com/EngineerBetter/control-tower/commands/info"
 	"github.com/EngineerBetter/control-tower/concourse"
-	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/iaas"
 	"github.com/EngineerBetter/control-tower/resource"
@@ -47,7 +47,7 @@ var infoFlags = []cli.Flag{
 	},
 	cli.StringFlag{
 		Name:        "iaas",
-		Usage:       "(required) IAAS, can be AWS or GCP",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
 		EnvVar:      "IAAS",
 		Destination: &initialInfoArgs.IAAS,
 	},
@@ -109,8 +109,9 @@ func validateInfoArgs(c *cli.Context, infoArgs info.Args) (info.Args, error) {
 
 func buildInfoClient(name, version string, infoArgs info.Args, provider iaas.Provider) (*concourse.Client, error) {
 	versionFile, _ := provider.Choose(iaas.Choice{
-		AWS: resource.AWSVersionFile,
-		GCP: resource.GCPVersionFile,
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
 	}).([]byte)
 
 	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
@@ -118,22 +119,28 @@ func buildInfoClient(name, version string, infoArgs info.Args, provider iaas.Pro
 		return nil, err
 	}
 
-	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider)
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
 	if err != nil {
 		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
 	}
 
+	configClient, err := buildConfigClient(provider, name, infoArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
 	client := concourse.NewClient(
 		provider,
 		terraformClient,
 		tfInputVarsFactory,
 		bosh.New,
-		fly.New,
+		fly.NewWithState(selfUpdateState()),
 		certs.Generate,
-		config.New(provider, name, infoArgs.Namespace),
+		configClient,
 		nil,
 		os.Stdout,
 		os.Stderr,
+		events.Discard,
 		util.FindUserIP,
 		certs.NewAcmeClient,
 		util.GeneratePasswordWithLength,
@@ -9,9 +9,10 @@ import (
 
 	"github.com/EngineerBetter/control-tower/bosh"
 	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/deploy"
 	"github.com/EngineerBetter/control-tower/commands/maintain"
 	"github.com/EngineerBetter/control-tower/concourse"
-	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/iaas"
 	"github.com/EngineerBetter/control-tower/resource"
@@ -33,9 +34,24 @@ var maintainFlags = []cli.Flag{
 		Usage:       "(optional) Rotate nats certificate",
 		Destination: &initialMaintainArgs.RenewNatsCert,
 	},
+	cli.BoolFlag{
+		Name:        "recover-expired-nats-cert",
+		Usage:       "(optional) Replace a nats certificate that has already expired",
+		Destination: &initialMaintainArgs.RecoverExpiredNatsCert,
+	},
+	cli.BoolFlag{
+		Name:        "renew-director-cert",
+		Usage:       "(optional) Replace the BOSH director certificate and its CA, including when they have already expired",
+		Destination: &initialMaintainArgs.RenewDirectorCert,
+	},
+	cli.BoolFlag{
+		Name:        "rotate-self-update-creds",
+		Usage:       "(optional) Replace the IAAS credentials kept in CredHub for the self-update pipeline with those control-tower is run with",
+		Destination: &initialMaintainArgs.RotateSelfUpdateCreds,
+	},
 	cli.StringFlag{
 		Name:        "iaas",
-		Usage:       "(required) IAAS, can be AWS or GCP",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
 		EnvVar:      "IAAS",
 		Destination: &initialMaintainArgs.IAAS,
 	},
@@ -47,7 +63,7 @@ var maintainFlags = []cli.Flag{
 	},
 	cli.IntFlag{
 		Name:        "stage",
-		Usage:       "(optional) Set the desired stage for nats rotation tasks",
+		Usage:       "(optional) Set the desired stage for nats rotation or recovery tasks",
 		EnvVar:      "STAGE",
 		Destination: &initialMaintainArgs.Stage,
 	},
@@ -88,8 +104,9 @@ func validateMaintainArgs(c *cli.Context, maintainArgs maintain.Args) (maintain.
 
 func buildMaintainClient(name, version string, maintainArgs maintain.Args, provider iaas.Provider) (*concourse.Client, error) {
 	versionFile, _ := provider.Choose(iaas.Choice{
-		AWS: resource.AWSVersionFile,
-		GCP: resource.GCPVersionFile,
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
 	}).([]byte)
 
 	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
@@ -97,22 +114,28 @@ func buildMaintainClient(name, version string, maintainArgs maintain.Args, provi
 		return nil, err
 	}
 
-	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider)
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
 	if err != nil {
 		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
 	}
 
+	configClient, err := buildConfigClient(provider, name, maintainArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
 	client := concourse.NewClient(
 		provider,
 		terraformClient,
 		tfInputVarsFactory,
 		bosh.New,
-		fly.New,
+		fly.NewWithState(selfUpdateState()),
 		certs.Generate,
-		config.New(provider, name, maintainArgs.Namespace),
-		nil,
+		configClient,
+		&deploy.Args{},
 		os.Stdout,
 		os.Stderr,
+		events.Discard,
 		util.FindUserIP,
 		certs.NewAcmeClient,
 		util.GeneratePasswordWithLength,
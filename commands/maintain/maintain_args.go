@@ -12,15 +12,24 @@ type Args struct {
 	RegionIsSet        bool
 	RenewNatsCert      bool
 	RenewNatsCertIsSet bool
-	Namespace          string
-	NamespaceIsSet     bool
-	IAAS               string
-	IAASIsSet          bool
-	Stage              int
-	StageIsSet         bool
+	// RecoverExpiredNatsCert replaces a NATS CA that has already expired
+	RecoverExpiredNatsCert      bool
+	RecoverExpiredNatsCertIsSet bool
+	// RenewDirectorCert replaces the certificate the director API is served with
+	RenewDirectorCert      bool
+	RenewDirectorCertIsSet bool
+	// RotateSelfUpdateCreds writes the current IAAS credentials to CredHub for the self-update pipeline
+	RotateSelfUpdateCreds      bool
+	RotateSelfUpdateCredsIsSet bool
+	Namespace                  string
+	NamespaceIsSet             bool
+	IAAS                       string
+	IAASIsSet                  bool
+	Stage                      int
+	StageIsSet                 bool
 }
 
-//MarkSetFlags is marking which info Args have been set
+// MarkSetFlags is marking which info Args have been set
 func (a *Args) MarkSetFlags(c FlagSetChecker) error {
 	for _, f := range c.FlagNames() {
 		if c.IsSet(f) {
@@ -31,6 +40,12 @@ func (a *Args) MarkSetFlags(c FlagSetChecker) error {
 				a.NamespaceIsSet = true
 			case "renew-nats-cert":
 				a.RenewNatsCertIsSet = true
+			case "recover-expired-nats-cert":
+				a.RecoverExpiredNatsCertIsSet = true
+			case "renew-director-cert":
+				a.RenewDirectorCertIsSet = true
+			case "rotate-self-update-creds":
+				a.RotateSelfUpdateCredsIsSet = true
 			case "stage":
 				a.StageIsSet = true
 			case "iaas":
@@ -47,6 +62,18 @@ func (a *Args) Validate() error {
 	if !a.IAASIsSet {
 		return fmt.Errorf("--iaas flag not set")
 	}
+	if a.RenewNatsCertIsSet && a.RecoverExpiredNatsCertIsSet {
+		return fmt.Errorf("--renew-nats-cert and --recover-expired-nats-cert cannot be used together")
+	}
+	if a.RenewDirectorCertIsSet && (a.RenewNatsCertIsSet || a.RecoverExpiredNatsCertIsSet) {
+		return fmt.Errorf("--renew-director-cert cannot be used together with --renew-nats-cert or --recover-expired-nats-cert")
+	}
+	if a.RenewDirectorCertIsSet && a.StageIsSet {
+		return fmt.Errorf("--stage cannot be used with --renew-director-cert")
+	}
+	if a.RotateSelfUpdateCredsIsSet && (a.RenewNatsCertIsSet || a.RecoverExpiredNatsCertIsSet || a.RenewDirectorCertIsSet || a.StageIsSet) {
+		return fmt.Errorf("--rotate-self-update-creds cannot be used together with other maintenance operations or --stage")
+	}
 	return nil
 }
 
@@ -37,6 +37,77 @@ func TestMaintainArgs_Validate(t *testing.T) {
 			wantErr:     true,
 			expectedErr: "--iaas flag not set",
 		},
+		{
+			name: "Recovering an expired NATS cert",
+			modification: func() Args {
+				args := defaultFields
+				args.RecoverExpiredNatsCertIsSet = true
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "Renewing and recovering the NATS cert together",
+			modification: func() Args {
+				args := defaultFields
+				args.RenewNatsCertIsSet = true
+				args.RecoverExpiredNatsCertIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--renew-nats-cert and --recover-expired-nats-cert cannot be used together",
+		},
+		{
+			name: "Renewing the director cert",
+			modification: func() Args {
+				args := defaultFields
+				args.RenewDirectorCertIsSet = true
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "Renewing the director and NATS certs together",
+			modification: func() Args {
+				args := defaultFields
+				args.RenewDirectorCertIsSet = true
+				args.RenewNatsCertIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--renew-director-cert cannot be used together with --renew-nats-cert or --recover-expired-nats-cert",
+		},
+		{
+			name: "Renewing the director cert from a stage",
+			modification: func() Args {
+				args := defaultFields
+				args.RenewDirectorCertIsSet = true
+				args.StageIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--stage cannot be used with --renew-director-cert",
+		},
+		{
+			name: "Rotating the self-update credentials",
+			modification: func() Args {
+				args := defaultFields
+				args.RotateSelfUpdateCredsIsSet = true
+				return args
+			},
+			wantErr: false,
+		},
+		{
+			name: "Rotating the self-update credentials and renewing the director cert together",
+			modification: func() Args {
+				args := defaultFields
+				args.RotateSelfUpdateCredsIsSet = true
+				args.RenewDirectorCertIsSet = true
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--rotate-self-update-creds cannot be used together with other maintenance operations or --stage",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
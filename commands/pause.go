@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/commands/pause"
+	"github.com/EngineerBetter/control-tower/concourse"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/util"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var initialPauseArgs pause.Args
+
+var pauseFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       "(optional) AWS region",
+		EnvVar:      "AWS_REGION",
+		Destination: &initialPauseArgs.Region,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
+		EnvVar:      "IAAS",
+		Destination: &initialPauseArgs.IAAS,
+	},
+	cli.StringFlag{
+		Name:        "namespace",
+		Usage:       "(optional) Specify a namespace for deployments in order to group them in a meaningful way",
+		EnvVar:      "NAMESPACE",
+		Destination: &initialPauseArgs.Namespace,
+	},
+	cli.IntFlag{
+		Name:        "workers",
+		Usage:       "(optional) Number of workers to keep running. Worker pools are always stopped",
+		Destination: &initialPauseArgs.WorkerCount,
+	},
+	cli.BoolFlag{
+		Name:        "hibernate",
+		Usage:       "(optional) Stop the web VM and every worker, keeping only the BOSH director and the database",
+		Destination: &initialPauseArgs.Hibernate,
+	},
+	cli.BoolFlag{
+		Name:        "self-update",
+		Usage:       "(optional) Causes Control-Tower to exit as soon as the BOSH deployment starts. Used by the self-update pipeline",
+		EnvVar:      "SELF_UPDATE",
+		Hidden:      true,
+		Destination: &initialPauseArgs.SelfUpdate,
+	},
+}
+
+func pauseAction(c *cli.Context, pauseArgs pause.Args, provider iaas.Provider) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return errors.New("Usage is `control-tower pause <name>`")
+	}
+
+	if pauseArgs.Hibernate && !NonInteractiveModeEnabled() {
+		confirm, err := util.CheckConfirmation(os.Stdin, os.Stdout, name)
+		if err != nil {
+			return err
+		}
+
+		if !confirm {
+			fmt.Println("Bailing out...")
+			return nil
+		}
+	}
+
+	version := c.App.Version
+
+	client, err := buildPauseClient(name, version, pauseArgs, provider)
+	if err != nil {
+		return err
+	}
+	return client.Pause(pauseArgs.WorkerCount, pauseArgs.Hibernate)
+}
+
+func validatePauseArgs(c *cli.Context, pauseArgs pause.Args) (pause.Args, error) {
+	err := pauseArgs.MarkSetFlags(c)
+	if err != nil {
+		return pauseArgs, fmt.Errorf("failed to mark set Pause flags: [%v]", err)
+	}
+
+	if err = pauseArgs.Validate(); err != nil {
+		return pauseArgs, fmt.Errorf("failed to validate Pause flags: [%v]", err)
+	}
+
+	return pauseArgs, nil
+}
+
+func buildPauseClient(name, version string, pauseArgs pause.Args, provider iaas.Provider) (*concourse.Client, error) {
+	versionFile, _ := provider.Choose(iaas.Choice{
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
+	}).([]byte)
+
+	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
+	}
+
+	configClient, err := buildConfigClient(provider, name, pauseArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	client := concourse.NewClient(
+		provider,
+		terraformClient,
+		tfInputVarsFactory,
+		bosh.New,
+		fly.NewWithState(selfUpdateState()),
+		certs.Generate,
+		configClient,
+		&deploy.Args{SelfUpdate: pauseArgs.SelfUpdate},
+		os.Stdout,
+		os.Stderr,
+		events.Discard,
+		util.FindUserIP,
+		certs.NewAcmeClient,
+		util.GeneratePasswordWithLength,
+		util.EightRandomLetters,
+		util.GenerateSSHKeyPair,
+		version,
+		versionFile,
+	)
+
+	return client, nil
+}
+
+var pauseCmd = cli.Command{
+	Name:      "pause",
+	Usage:     "Scales a Concourse's workers down, or hibernates it, until it is resumed",
+	ArgsUsage: "<name>",
+	Flags:     pauseFlags,
+	Action: func(c *cli.Context) error {
+		pauseArgs, err := validatePauseArgs(c, initialPauseArgs)
+		if err != nil {
+			return fmt.Errorf("Error validating args on pause: [%v]", err)
+		}
+		iaasName, err := iaas.Validate(pauseArgs.IAAS)
+		if err != nil {
+			return fmt.Errorf("Error mapping to supported IAASes on pause: [%v]", err)
+		}
+		provider, err := iaas.New(iaasName, pauseArgs.Region)
+		if err != nil {
+			return fmt.Errorf("Error creating IAAS provider on pause: [%v]", err)
+		}
+		return pauseAction(c, pauseArgs, provider)
+	},
+}
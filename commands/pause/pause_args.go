@@ -0,0 +1,89 @@
+package pause
+
+import (
+	"errors"
+	"fmt"
+
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// Args are arguments passed to the pause command
+type Args struct {
+	Region         string
+	RegionIsSet    bool
+	IAAS           string
+	Namespace      string
+	NamespaceIsSet bool
+	IAASIsSet      bool
+	// WorkerCount is the number of default workers to keep running
+	WorkerCount      int
+	WorkerCountIsSet bool
+	// Hibernate stops the web VM and every worker, keeping only the director and database
+	Hibernate      bool
+	HibernateIsSet bool
+	// SelfUpdate detaches from the BOSH deploy once it starts, as the self-update pipeline runs pause on a worker
+	SelfUpdate      bool
+	SelfUpdateIsSet bool
+}
+
+// MarkSetFlags is marking which pause Args have been set
+func (a *Args) MarkSetFlags(c FlagSetChecker) error {
+	for _, f := range c.FlagNames() {
+		if c.IsSet(f) {
+			switch f {
+			case "region":
+				a.RegionIsSet = true
+			case "namespace":
+				a.NamespaceIsSet = true
+			case "iaas":
+				a.IAASIsSet = true
+			case "workers":
+				a.WorkerCountIsSet = true
+			case "hibernate":
+				a.HibernateIsSet = true
+			case "self-update":
+				a.SelfUpdateIsSet = true
+			default:
+				return fmt.Errorf("flag %q is not supported by pause flags", f)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *Args) Validate() error {
+	if !a.IAASIsSet {
+		return fmt.Errorf("--iaas flag not set")
+	}
+	if a.WorkerCount < 0 {
+		return errors.New("--workers cannot be negative")
+	}
+	if a.Hibernate && a.WorkerCountIsSet {
+		return errors.New("--workers cannot be used with --hibernate, which stops every worker")
+	}
+	if a.Hibernate && a.SelfUpdate {
+		return errors.New("--hibernate cannot be used with --self-update, as the pipeline could not resume a hibernating Concourse")
+	}
+	return nil
+}
+
+// FlagSetChecker allows us to find out if flags were set, adn what the names of all flags are
+type FlagSetChecker interface {
+	IsSet(name string) bool
+	FlagNames() (names []string)
+}
+
+// ContextWrapper wraps a CLI context for testing
+type ContextWrapper struct {
+	c *cli.Context
+}
+
+// IsSet tells you if a user provided a flag
+func (t *ContextWrapper) IsSet(name string) bool {
+	return t.c.IsSet(name)
+}
+
+// FlagNames lists all flags it's possible for a user to provide
+func (t *ContextWrapper) FlagNames() (names []string) {
+	return t.c.FlagNames()
+}
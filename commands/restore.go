@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/commands/restore"
+	"github.com/EngineerBetter/control-tower/concourse"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/util"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var initialRestoreArgs restore.Args
+
+var restoreFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       "(optional) AWS region",
+		EnvVar:      "AWS_REGION",
+		Destination: &initialRestoreArgs.Region,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
+		EnvVar:      "IAAS",
+		Destination: &initialRestoreArgs.IAAS,
+	},
+	cli.StringFlag{
+		Name:        "namespace",
+		Usage:       "(optional) Specify a namespace for deployments in order to group them in a meaningful way",
+		EnvVar:      "NAMESPACE",
+		Destination: &initialRestoreArgs.Namespace,
+	},
+	cli.StringFlag{
+		Name:        "from-file",
+		Usage:       "(required) Backup file created by control-tower backup",
+		EnvVar:      "FROM_FILE",
+		Destination: &initialRestoreArgs.FromFile,
+	},
+	cli.StringFlag{
+		Name:        "zone",
+		Usage:       "(optional) Availability zone to deploy to. Defaults to the zone of the backed up deployment when restoring to the same region",
+		EnvVar:      "ZONE",
+		Destination: &initialRestoreArgs.Zone,
+	},
+}
+
+func restoreAction(c *cli.Context, restoreArgs restore.Args, provider iaas.Provider) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return errors.New("Usage is `control-tower restore <name>`")
+	}
+
+	artifact, err := os.Open(restoreArgs.FromFile)
+	if err != nil {
+		return fmt.Errorf("error reading backup: [%v]", err)
+	}
+	defer artifact.Close()
+
+	version := c.App.Version
+
+	client, err := buildRestoreClient(name, version, restoreArgs, provider)
+	if err != nil {
+		return err
+	}
+	return client.Restore(artifact, restoreArgs.Zone)
+}
+
+func validateRestoreArgs(c *cli.Context, restoreArgs restore.Args) (restore.Args, error) {
+	err := restoreArgs.MarkSetFlags(c)
+	if err != nil {
+		return restoreArgs, fmt.Errorf("failed to mark set Restore flags: [%v]", err)
+	}
+
+	if err = restoreArgs.Validate(); err != nil {
+		return restoreArgs, fmt.Errorf("failed to validate Restore flags: [%v]", err)
+	}
+
+	return restoreArgs, nil
+}
+
+func buildRestoreClient(name, version string, restoreArgs restore.Args, provider iaas.Provider) (*concourse.Client, error) {
+	versionFile, _ := provider.Choose(iaas.Choice{
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
+	}).([]byte)
+
+	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
+	}
+
+	configClient, err := buildConfigClient(provider, name, restoreArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	client := concourse.NewClient(
+		provider,
+		terraformClient,
+		tfInputVarsFactory,
+		bosh.New,
+		fly.NewWithState(selfUpdateState()),
+		certs.Generate,
+		configClient,
+		&deploy.Args{},
+		os.Stdout,
+		os.Stderr,
+		events.Discard,
+		util.FindUserIP,
+		certs.NewAcmeClient,
+		util.GeneratePasswordWithLength,
+		util.EightRandomLetters,
+		util.GenerateSSHKeyPair,
+		version,
+		versionFile,
+	)
+
+	return client, nil
+}
+
+var restoreCmd = cli.Command{
+	Name:      "restore",
+	Usage:     "Deploys a new Concourse from a backup, which may be under another name or in another region",
+	ArgsUsage: "<name>",
+	Flags:     restoreFlags,
+	Action: func(c *cli.Context) error {
+		restoreArgs, err := validateRestoreArgs(c, initialRestoreArgs)
+		if err != nil {
+			return fmt.Errorf("Error validating args on restore: [%v]", err)
+		}
+		iaasName, err := iaas.Validate(restoreArgs.IAAS)
+		if err != nil {
+			return fmt.Errorf("Error mapping to supported IAASes on restore: [%v]", err)
+		}
+		provider, err := iaas.New(iaasName, restoreArgs.Region)
+		if err != nil {
+			return fmt.Errorf("Error creating IAAS provider on restore: [%v]", err)
+		}
+		return restoreAction(c, restoreArgs, provider)
+	},
+}
@@ -0,0 +1,76 @@
+package restore
+
+import (
+	"fmt"
+
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// Args are arguments passed to the restore command
+type Args struct {
+	Region         string
+	RegionIsSet    bool
+	IAAS           string
+	Namespace      string
+	NamespaceIsSet bool
+	IAASIsSet      bool
+	// FromFile is the backup to restore
+	FromFile      string
+	FromFileIsSet bool
+	Zone          string
+	ZoneIsSet     bool
+}
+
+// MarkSetFlags is marking which restore Args have been set
+func (a *Args) MarkSetFlags(c FlagSetChecker) error {
+	for _, f := range c.FlagNames() {
+		if c.IsSet(f) {
+			switch f {
+			case "region":
+				a.RegionIsSet = true
+			case "namespace":
+				a.NamespaceIsSet = true
+			case "iaas":
+				a.IAASIsSet = true
+			case "from-file":
+				a.FromFileIsSet = true
+			case "zone":
+				a.ZoneIsSet = true
+			default:
+				return fmt.Errorf("flag %q is not supported by restore flags", f)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *Args) Validate() error {
+	if !a.IAASIsSet {
+		return fmt.Errorf("--iaas flag not set")
+	}
+	if !a.FromFileIsSet {
+		return fmt.Errorf("--from-file flag not set")
+	}
+	return nil
+}
+
+// FlagSetChecker allows us to find out if flags were set, adn what the names of all flags are
+type FlagSetChecker interface {
+	IsSet(name string) bool
+	FlagNames() (names []string)
+}
+
+// ContextWrapper wraps a CLI context for testing
+type ContextWrapper struct {
+	c *cli.Context
+}
+
+// IsSet tells you if a user provided a flag
+func (t *ContextWrapper) IsSet(name string) bool {
+	return t.c.IsSet(name)
+}
+
+// FlagNames lists all flags it's possible for a user to provide
+func (t *ContextWrapper) FlagNames() (names []string) {
+	return t.c.FlagNames()
+}
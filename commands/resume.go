@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/commands/resume"
+	"github.com/EngineerBetter/control-tower/concourse"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/util"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var initialResumeArgs resume.Args
+
+var resumeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       "(optional) AWS region",
+		EnvVar:      "AWS_REGION",
+		Destination: &initialResumeArgs.Region,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
+		EnvVar:      "IAAS",
+		Destination: &initialResumeArgs.IAAS,
+	},
+	cli.StringFlag{
+		Name:        "namespace",
+		Usage:       "(optional) Specify a namespace for deployments in order to group them in a meaningful way",
+		EnvVar:      "NAMESPACE",
+		Destination: &initialResumeArgs.Namespace,
+	},
+	cli.BoolFlag{
+		Name:        "self-update",
+		Usage:       "(optional) Causes Control-Tower to exit as soon as the BOSH deployment starts. Used by the self-update pipeline",
+		EnvVar:      "SELF_UPDATE",
+		Hidden:      true,
+		Destination: &initialResumeArgs.SelfUpdate,
+	},
+}
+
+func resumeAction(c *cli.Context, resumeArgs resume.Args, provider iaas.Provider) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return errors.New("Usage is `control-tower resume <name>`")
+	}
+
+	version := c.App.Version
+
+	client, err := buildResumeClient(name, version, resumeArgs, provider)
+	if err != nil {
+		return err
+	}
+	return client.Resume()
+}
+
+func validateResumeArgs(c *cli.Context, resumeArgs resume.Args) (resume.Args, error) {
+	err := resumeArgs.MarkSetFlags(c)
+	if err != nil {
+		return resumeArgs, fmt.Errorf("failed to mark set Resume flags: [%v]", err)
+	}
+
+	if err = resumeArgs.Validate(); err != nil {
+		return resumeArgs, fmt.Errorf("failed to validate Resume flags: [%v]", err)
+	}
+
+	return resumeArgs, nil
+}
+
+func buildResumeClient(name, version string, resumeArgs resume.Args, provider iaas.Provider) (*concourse.Client, error) {
+	versionFile, _ := provider.Choose(iaas.Choice{
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
+	}).([]byte)
+
+	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
+	}
+
+	configClient, err := buildConfigClient(provider, name, resumeArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	client := concourse.NewClient(
+		provider,
+		terraformClient,
+		tfInputVarsFactory,
+		bosh.New,
+		fly.NewWithState(selfUpdateState()),
+		certs.Generate,
+		configClient,
+		&deploy.Args{SelfUpdate: resumeArgs.SelfUpdate},
+		os.Stdout,
+		os.Stderr,
+		events.Discard,
+		util.FindUserIP,
+		certs.NewAcmeClient,
+		util.GeneratePasswordWithLength,
+		util.EightRandomLetters,
+		util.GenerateSSHKeyPair,
+		version,
+		versionFile,
+	)
+
+	return client, nil
+}
+
+var resumeCmd = cli.Command{
+	Name:      "resume",
+	Usage:     "Brings back the web and worker VMs of a paused Concourse",
+	ArgsUsage: "<name>",
+	Flags:     resumeFlags,
+	Action: func(c *cli.Context) error {
+		resumeArgs, err := validateResumeArgs(c, initialResumeArgs)
+		if err != nil {
+			return fmt.Errorf("Error validating args on resume: [%v]", err)
+		}
+		iaasName, err := iaas.Validate(resumeArgs.IAAS)
+		if err != nil {
+			return fmt.Errorf("Error mapping to supported IAASes on resume: [%v]", err)
+		}
+		provider, err := iaas.New(iaasName, resumeArgs.Region)
+		if err != nil {
+			return fmt.Errorf("Error creating IAAS provider on resume: [%v]", err)
+		}
+		return resumeAction(c, resumeArgs, provider)
+	},
+}
@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/commands/rollback"
+	"github.com/EngineerBetter/control-tower/concourse"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/util"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var initialRollbackArgs rollback.Args
+
+var rollbackFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       "(optional) AWS region",
+		EnvVar:      "AWS_REGION",
+		Destination: &initialRollbackArgs.Region,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
+		EnvVar:      "IAAS",
+		Destination: &initialRollbackArgs.IAAS,
+	},
+	cli.StringFlag{
+		Name:        "namespace",
+		Usage:       "(optional) Specify a namespace for deployments in order to group them in a meaningful way",
+		EnvVar:      "NAMESPACE",
+		Destination: &initialRollbackArgs.Namespace,
+	},
+	cli.BoolFlag{
+		Name:        "restore-director-state",
+		Usage:       "(optional) Also restore the BOSH director state and credentials from the revision. Only use this if the director itself needs rolling back",
+		EnvVar:      "RESTORE_DIRECTOR_STATE",
+		Destination: &initialRollbackArgs.RestoreDirectorState,
+	},
+}
+
+func rollbackAction(c *cli.Context, rollbackArgs rollback.Args, provider iaas.Provider) error {
+	name := c.Args().Get(0)
+	revision, err := strconv.Atoi(c.Args().Get(1))
+	if name == "" || err != nil {
+		return errors.New("Usage is `control-tower rollback <name> <revision>`")
+	}
+
+	if !NonInteractiveModeEnabled() {
+		confirm, err := util.CheckConfirmation(os.Stdin, os.Stdout, name)
+		if err != nil {
+			return err
+		}
+
+		if !confirm {
+			fmt.Println("Bailing out...")
+			return nil
+		}
+	}
+
+	version := c.App.Version
+
+	client, err := buildRollbackClient(name, version, revision, rollbackArgs, provider)
+	if err != nil {
+		return err
+	}
+	return client.Rollback(revision, rollbackArgs.RestoreDirectorState)
+}
+
+func validateRollbackArgs(c *cli.Context, rollbackArgs rollback.Args) (rollback.Args, error) {
+	err := rollbackArgs.MarkSetFlags(c)
+	if err != nil {
+		return rollbackArgs, fmt.Errorf("failed to mark set Rollback flags: [%v]", err)
+	}
+
+	if err = rollbackArgs.Validate(); err != nil {
+		return rollbackArgs, fmt.Errorf("failed to validate Rollback flags: [%v]", err)
+	}
+
+	return rollbackArgs, nil
+}
+
+func buildRollbackClient(name, version string, revision int, rollbackArgs rollback.Args, provider iaas.Provider) (*concourse.Client, error) {
+	versionFile, _ := provider.Choose(iaas.Choice{
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
+	}).([]byte)
+
+	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
+	}
+
+	configClient, err := buildConfigClient(provider, name, rollbackArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	deployArgs, err := revisionDeployArgs(configClient, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	client := concourse.NewClient(
+		provider,
+		terraformClient,
+		tfInputVarsFactory,
+		bosh.New,
+		fly.NewWithState(selfUpdateState()),
+		certs.Generate,
+		configClient,
+		deployArgs,
+		os.Stdout,
+		os.Stderr,
+		events.Discard,
+		util.FindUserIP,
+		certs.NewAcmeClient,
+		util.GeneratePasswordWithLength,
+		util.EightRandomLetters,
+		util.GenerateSSHKeyPair,
+		version,
+		versionFile,
+	)
+
+	return client, nil
+}
+
+// revisionDeployArgs returns the args that redeploy the config recorded at revision as it was deployed
+func revisionDeployArgs(configClient config.IClient, revision int) (*deploy.Args, error) {
+	contents, err := configClient.LoadRevision(revision, config.ConfigFilename)
+	if err != nil {
+		return nil, fmt.Errorf("error loading config from revision %d: [%v]", revision, err)
+	}
+	var conf config.Config
+	if err := json.Unmarshal(contents, &conf); err != nil {
+		return nil, fmt.Errorf("error reading config from revision %d: [%v]", revision, err)
+	}
+	deployArgs := deploy.StoredArgs(conf)
+	return &deployArgs, nil
+}
+
+var rollbackCmd = cli.Command{
+	Name:      "rollback",
+	Usage:     "Restores a recorded revision of a Concourse's config and redeploys it",
+	ArgsUsage: "<name> <revision>",
+	Flags:     rollbackFlags,
+	Action: func(c *cli.Context) error {
+		rollbackArgs, err := validateRollbackArgs(c, initialRollbackArgs)
+		if err != nil {
+			return fmt.Errorf("Error validating args on rollback: [%v]", err)
+		}
+		iaasName, err := iaas.Validate(rollbackArgs.IAAS)
+		if err != nil {
+			return fmt.Errorf("Error mapping to supported IAASes on rollback: [%v]", err)
+		}
+		provider, err := iaas.New(iaasName, rollbackArgs.Region)
+		if err != nil {
+			return fmt.Errorf("Error creating IAAS provider on rollback: [%v]", err)
+		}
+		return rollbackAction(c, rollbackArgs, provider)
+	},
+}
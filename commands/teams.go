@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/commands/teams"
+	"github.com/EngineerBetter/control-tower/concourse"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/util"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var initialTeamsArgs teams.Args
+
+var teamsFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       "(optional) AWS region",
+		EnvVar:      "AWS_REGION",
+		Destination: &initialTeamsArgs.Region,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
+		EnvVar:      "IAAS",
+		Destination: &initialTeamsArgs.IAAS,
+	},
+	cli.StringFlag{
+		Name:        "namespace",
+		Usage:       "(optional) Specify a namespace for deployments in order to group them in a meaningful way",
+		EnvVar:      "NAMESPACE",
+		Destination: &initialTeamsArgs.Namespace,
+	},
+	cli.StringFlag{
+		Name:        "file, f",
+		Usage:       "YAML list of teams and the users and groups granted each of their roles. Required by apply",
+		EnvVar:      "TEAMS_FILE",
+		Destination: &initialTeamsArgs.File,
+	},
+}
+
+func teamsApplyAction(c *cli.Context, teamsArgs teams.Args, provider iaas.Provider) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return errors.New("Usage is `control-tower teams apply --file <teams.yml> <name>`")
+	}
+
+	teamsList, err := readTeamsFile(teamsArgs.File)
+	if err != nil {
+		return err
+	}
+
+	client, err := buildTeamsClient(name, c.App.Version, teamsArgs, provider)
+	if err != nil {
+		return err
+	}
+	return client.ApplyTeams(teamsList)
+}
+
+func teamsDiffAction(c *cli.Context, teamsArgs teams.Args, provider iaas.Provider) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return errors.New("Usage is `control-tower teams diff [--file <teams.yml>] <name>`")
+	}
+
+	var teamsList []config.Team
+	if teamsArgs.FileIsSet {
+		var err error
+		if teamsList, err = readTeamsFile(teamsArgs.File); err != nil {
+			return err
+		}
+	}
+
+	client, err := buildTeamsClient(name, c.App.Version, teamsArgs, provider)
+	if err != nil {
+		return err
+	}
+	return client.TeamsDiff(teamsList)
+}
+
+func readTeamsFile(path string) ([]config.Team, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading teams file: [%v]", err)
+	}
+	teamsList, err := config.ParseTeams(contents)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing teams file %s: [%v]", path, err)
+	}
+	if teamsList == nil {
+		teamsList = []config.Team{}
+	}
+	return teamsList, nil
+}
+
+func validateTeamsArgs(c *cli.Context, teamsArgs teams.Args, apply bool) (teams.Args, error) {
+	err := teamsArgs.MarkSetFlags(c)
+	if err != nil {
+		return teamsArgs, fmt.Errorf("failed to mark set Teams flags: [%v]", err)
+	}
+
+	validate := teamsArgs.Validate
+	if apply {
+		validate = teamsArgs.ValidateApply
+	}
+	if err = validate(); err != nil {
+		return teamsArgs, fmt.Errorf("failed to validate Teams flags: [%v]", err)
+	}
+
+	return teamsArgs, nil
+}
+
+func buildTeamsClient(name, version string, teamsArgs teams.Args, provider iaas.Provider) (*concourse.Client, error) {
+	versionFile, _ := provider.Choose(iaas.Choice{
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
+	}).([]byte)
+
+	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
+	}
+
+	configClient, err := buildConfigClient(provider, name, teamsArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	client := concourse.NewClient(
+		provider,
+		terraformClient,
+		tfInputVarsFactory,
+		bosh.New,
+		fly.NewWithState(selfUpdateState()),
+		certs.Generate,
+		configClient,
+		&deploy.Args{},
+		os.Stdout,
+		os.Stderr,
+		events.Discard,
+		util.FindUserIP,
+		certs.NewAcmeClient,
+		util.GeneratePasswordWithLength,
+		util.EightRandomLetters,
+		util.GenerateSSHKeyPair,
+		version,
+		versionFile,
+	)
+
+	return client, nil
+}
+
+func teamsSubcommand(name, usage, argsUsage string, action func(*cli.Context, teams.Args, iaas.Provider) error) cli.Command {
+	return cli.Command{
+		Name:      name,
+		Usage:     usage,
+		ArgsUsage: argsUsage,
+		Flags:     teamsFlags,
+		Action: func(c *cli.Context) error {
+			teamsArgs, err := validateTeamsArgs(c, initialTeamsArgs, name == "apply")
+			if err != nil {
+				return fmt.Errorf("Error validating args on teams %s: [%v]", name, err)
+			}
+			iaasName, err := iaas.Validate(teamsArgs.IAAS)
+			if err != nil {
+				return fmt.Errorf("Error mapping to supported IAASes on teams %s: [%v]", name, err)
+			}
+			provider, err := iaas.New(iaasName, teamsArgs.Region)
+			if err != nil {
+				return fmt.Errorf("Error creating IAAS provider on teams %s: [%v]", name, err)
+			}
+			return action(c, teamsArgs, provider)
+		},
+	}
+}
+
+var teamsCmd = cli.Command{
+	Name:  "teams",
+	Usage: "Manages the teams of a Concourse and the roles granted to their users",
+	Subcommands: []cli.Command{
+		teamsSubcommand("apply", "Creates or updates the teams in a teams file, and keeps them set on later deploys", "<name>", teamsApplyAction),
+		teamsSubcommand("diff", "Reports how the teams in Concourse differ from a teams file, or from the teams last applied", "<name>", teamsDiffAction),
+	},
+}
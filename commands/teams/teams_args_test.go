@@ -0,0 +1,83 @@
+package teams_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/EngineerBetter/control-tower/commands/teams"
+)
+
+func TestTeamsArgs_Validate(t *testing.T) {
+	defaultFields := Args{
+		Region:    "eu-west-1",
+		IAAS:      "AWS",
+		IAASIsSet: true,
+		File:      "teams.yml",
+		FileIsSet: true,
+	}
+	tests := []struct {
+		name         string
+		modification func() Args
+		apply        bool
+		wantErr      bool
+		expectedErr  string
+	}{
+		{
+			name: "Default args",
+			modification: func() Args {
+				return defaultFields
+			},
+			apply:   true,
+			wantErr: false,
+		},
+		{
+			name: "IAAS not set",
+			modification: func() Args {
+				args := defaultFields
+				args.IAASIsSet = false
+				return args
+			},
+			wantErr:     true,
+			expectedErr: "--iaas flag not set",
+		},
+		{
+			name: "File not set on apply",
+			modification: func() Args {
+				args := defaultFields
+				args.File = ""
+				args.FileIsSet = false
+				return args
+			},
+			apply:       true,
+			wantErr:     true,
+			expectedErr: "--file flag not set",
+		},
+		{
+			name: "File not set on diff",
+			modification: func() Args {
+				args := defaultFields
+				args.File = ""
+				args.FileIsSet = false
+				return args
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.modification()
+			validate := args.Validate
+			if tt.apply {
+				validate = args.ValidateApply
+			}
+			err := validate()
+			if (err != nil) != tt.wantErr || (err != nil && tt.wantErr && !strings.Contains(err.Error(), tt.expectedErr)) {
+				if err != nil {
+					t.Errorf("TeamsArgs.Validate() %v test failed.\nFailed with error = %v,\nExpected error = %v,\nShould fail %v\nWith args: %#v", tt.name, err.Error(), tt.expectedErr, tt.wantErr, args)
+				} else {
+					t.Errorf("TeamsArgs.Validate() %v test failed.\nShould fail %v\nWith args: %#v", tt.name, tt.wantErr, args)
+				}
+			}
+		})
+	}
+}
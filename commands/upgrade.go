@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/upgrade"
+	"github.com/EngineerBetter/control-tower/concourse"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/releases"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/util"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var initialUpgradeArgs upgrade.Args
+
+var upgradeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "region",
+		Usage:       "(optional) AWS region",
+		EnvVar:      "AWS_REGION",
+		Destination: &initialUpgradeArgs.Region,
+	},
+	cli.StringFlag{
+		Name:        "iaas",
+		Usage:       "(required) IAAS, can be AWS, GCP or Azure",
+		EnvVar:      "IAAS",
+		Destination: &initialUpgradeArgs.IAAS,
+	},
+	cli.StringFlag{
+		Name:        "namespace",
+		Usage:       "(optional) Specify a namespace for deployments in order to group them in a meaningful way",
+		EnvVar:      "NAMESPACE",
+		Destination: &initialUpgradeArgs.Namespace,
+	},
+	cli.BoolFlag{
+		Name:        "check",
+		Usage:       "(required) Report the newest release on the deployment's release channel and the Concourse and BOSH versions it would change, without deploying it",
+		Destination: &initialUpgradeArgs.Check,
+	},
+}
+
+func upgradeAction(c *cli.Context, upgradeArgs upgrade.Args, provider iaas.Provider) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return errors.New("Usage is `control-tower upgrade --check <name>`")
+	}
+
+	version := c.App.Version
+
+	client, err := buildUpgradeClient(name, version, upgradeArgs, provider)
+	if err != nil {
+		return err
+	}
+	return client.CheckUpgrade(releases.New())
+}
+
+func validateUpgradeArgs(c *cli.Context, upgradeArgs upgrade.Args) (upgrade.Args, error) {
+	err := upgradeArgs.MarkSetFlags(c)
+	if err != nil {
+		return upgradeArgs, fmt.Errorf("failed to mark set Upgrade flags: [%v]", err)
+	}
+
+	if err = upgradeArgs.Validate(); err != nil {
+		return upgradeArgs, fmt.Errorf("failed to validate Upgrade flags: [%v]", err)
+	}
+
+	return upgradeArgs, nil
+}
+
+func buildUpgradeClient(name, version string, upgradeArgs upgrade.Args, provider iaas.Provider) (*concourse.Client, error) {
+	versionFile, _ := provider.Choose(iaas.Choice{
+		AWS:   resource.AWSVersionFile,
+		GCP:   resource.GCPVersionFile,
+		Azure: resource.AzureVersionFile,
+	}).([]byte)
+
+	terraformClient, err := terraform.New(provider.IAAS(), terraform.DownloadTerraform(versionFile))
+	if err != nil {
+		return nil, err
+	}
+
+	tfInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TFInputVarsFactory [%v]", err)
+	}
+
+	configClient, err := buildConfigClient(provider, name, upgradeArgs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	client := concourse.NewClient(
+		provider,
+		terraformClient,
+		tfInputVarsFactory,
+		bosh.New,
+		fly.NewWithState(selfUpdateState()),
+		certs.Generate,
+		configClient,
+		nil,
+		os.Stdout,
+		os.Stderr,
+		events.Discard,
+		util.FindUserIP,
+		certs.NewAcmeClient,
+		util.GeneratePasswordWithLength,
+		util.EightRandomLetters,
+		util.GenerateSSHKeyPair,
+		version,
+		versionFile,
+	)
+
+	return client, nil
+}
+
+var upgradeCmd = cli.Command{
+	Name:      "upgrade",
+	Usage:     "Checks for a newer release of control-tower on a Concourse's release channel",
+	ArgsUsage: "<name>",
+	Flags:     upgradeFlags,
+	Action: func(c *cli.Context) error {
+		upgradeArgs, err := validateUpgradeArgs(c, initialUpgradeArgs)
+		if err != nil {
+			return fmt.Errorf("Error validating args on upgrade: [%v]", err)
+		}
+		iaasName, err := iaas.Validate(upgradeArgs.IAAS)
+		if err != nil {
+			return fmt.Errorf("Error mapping to supported IAASes on upgrade: [%v]", err)
+		}
+		provider, err := iaas.New(iaasName, upgradeArgs.Region)
+		if err != nil {
+			return fmt.Errorf("Error creating IAAS provider on upgrade: [%v]", err)
+		}
+		return upgradeAction(c, upgradeArgs, provider)
+	},
+}
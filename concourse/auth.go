@@ -0,0 +1,34 @@
+package concourse
+
+import (
+	"fmt"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/util/yaml"
+)
+
+// withAuthSecrets adds the secrets of any OIDC, LDAP or GitLab auth provider given to deploy to the
+// BOSH vars store, which is where they are kept instead of the config. Secrets that were not given
+// are left as they were stored by a previous deploy.
+func withAuthSecrets(boshCredsBytes []byte, deployArgs *deploy.Args) ([]byte, error) {
+	secrets := map[string]interface{}{}
+	if deployArgs.OIDCAuthIsSet {
+		secrets[bosh.OIDCClientSecretVar] = deployArgs.OIDCAuthClientSecret
+	}
+	if deployArgs.LDAPAuthIsSet {
+		secrets[bosh.LDAPBindPasswordVar] = deployArgs.LDAPAuthBindPassword
+	}
+	if deployArgs.GitLabAuthIsSet {
+		secrets[bosh.GitLabClientSecretVar] = deployArgs.GitLabAuthClientSecret
+	}
+	if len(secrets) == 0 {
+		return boshCredsBytes, nil
+	}
+
+	boshCredsBytes, err := yaml.SetVariables(boshCredsBytes, secrets)
+	if err != nil {
+		return nil, fmt.Errorf("error adding auth secrets to %s: [%v]", bosh.CredsFilename, err)
+	}
+	return boshCredsBytes, nil
+}
@@ -0,0 +1,30 @@
+package concourse
+
+import (
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+)
+
+func TestWithAuthSecrets(t *testing.T) {
+	creds := []byte("atc_password: admin\noidc_client_secret: old\n")
+
+	got, err := withAuthSecrets(creds, &deploy.Args{})
+	if err != nil || string(got) != string(creds) {
+		t.Errorf("withAuthSecrets() with no providers = %q, %v, want the vars store unchanged", got, err)
+	}
+
+	got, err = withAuthSecrets(creds, &deploy.Args{
+		OIDCAuthIsSet:          true,
+		OIDCAuthClientSecret:   "new",
+		GitLabAuthIsSet:        true,
+		GitLabAuthClientSecret: "gitlab",
+	})
+	if err != nil {
+		t.Fatalf("withAuthSecrets() error = %v", err)
+	}
+	want := "atc_password: admin\ngitlab_client_secret: gitlab\noidc_client_secret: new\n"
+	if string(got) != want {
+		t.Errorf("withAuthSecrets() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,480 @@
+package concourse
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/asaskevich/govalidator"
+)
+
+const backupFormat = "control-tower-backup-v2"
+
+// backupChunkSize is the most of a database dump that is held in memory while it is backed up
+const backupChunkSize = 4 * 1024 * 1024
+
+// backupArtifactName is what a backup is bound to when it is encrypted
+const backupArtifactName = "backup"
+
+const backupManifestFilename = "manifest.json"
+
+// backupFilenames are the files from the config bucket that go into a backup
+var backupFilenames = []string{config.ConfigFilename, bosh.CredsFilename, bosh.StateFilename}
+
+// backupManifest describes where a backup came from and what it holds
+type backupManifest struct {
+	Format     string    `json:"format"`
+	Version    string    `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	IAAS       string    `json:"iaas"`
+	Deployment string    `json:"deployment"`
+	Region     string    `json:"region"`
+	Databases  []string  `json:"databases"`
+	Files      []string  `json:"files"`
+}
+
+type backup struct {
+	manifest  backupManifest
+	databases map[string][]byte
+	files     map[string][]byte
+}
+
+// Backup writes the Concourse, CredHub and UAA databases, the config and the director's credentials
+// and state to w as a single artifact, encrypted if encryption is enabled. Unless online is true the
+// web instances are stopped while the databases are dumped, so that they are consistent with each other.
+// The databases are streamed to w as they are dumped rather than held in memory.
+func (client *Client) Backup(w io.Writer, online bool) error {
+	return client.withLock("backup", func() error {
+		conf, err := client.configClient.Load()
+		if err != nil {
+			return fmt.Errorf("error loading config: [%v]", err)
+		}
+
+		manifest := backupManifest{
+			Format:     backupFormat,
+			Version:    client.version,
+			CreatedAt:  time.Now().UTC(),
+			IAAS:       conf.IAAS,
+			Deployment: conf.Deployment,
+			Region:     conf.Region,
+			Databases:  append([]string(nil), bosh.ConcourseDatabases...),
+		}
+		files := map[string][]byte{}
+		for _, filename := range backupFilenames {
+			contents, err := client.configClient.LoadAsset(filename)
+			if err != nil {
+				return fmt.Errorf("error loading %s: [%v]", filename, err)
+			}
+			manifest.Files = append(manifest.Files, filename)
+			files[filename] = contents
+		}
+
+		tfOutputs, err := client.tfCLI.BuildOutput(client.tfInputVarsFactory.NewInputVars(conf))
+		if err != nil {
+			return fmt.Errorf("error getting terraform outputs: [%v]", err)
+		}
+		boshClient, err := client.buildBoshClient(conf, tfOutputs)
+		if err != nil {
+			return err
+		}
+		defer boshClient.Cleanup()
+
+		sealed, err := client.configClient.SealWriter(backupArtifactName, w)
+		if err != nil {
+			return fmt.Errorf("error encrypting backup: [%v]", err)
+		}
+		bw, err := newBackupWriter(sealed, manifest, files)
+		if err != nil {
+			return fmt.Errorf("error writing backup: [%v]", err)
+		}
+
+		if !online {
+			fmt.Fprintln(client.stdout, "\nSTOPPING CONCOURSE WEB WHILE THE DATABASES ARE BACKED UP")
+			if err = boshClient.StopConcourseWeb(); err != nil {
+				return fmt.Errorf("error stopping Concourse web: [%v]", err)
+			}
+		}
+		err = client.dumpDatabases(boshClient, bw)
+		if !online {
+			if startErr := boshClient.StartConcourseWeb(); startErr != nil && err == nil {
+				err = fmt.Errorf("error starting Concourse web after backup: [%v]", startErr)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = bw.Close(); err != nil {
+			return fmt.Errorf("error writing backup: [%v]", err)
+		}
+		if err = sealed.Close(); err != nil {
+			return fmt.Errorf("error encrypting backup: [%v]", err)
+		}
+
+		fmt.Fprintf(client.stdout, "\nBACKED UP %s: %s\n", conf.Deployment, formatBackupContents(manifest))
+		return nil
+	})
+}
+
+func (client *Client) dumpDatabases(boshClient bosh.IClient, bw *backupWriter) error {
+	for _, name := range bw.manifest.Databases {
+		fmt.Fprintf(client.stdout, "BACKING UP DATABASE %s\n", name)
+		dump := bw.database(name)
+		err := boshClient.DumpDatabase(name, dump)
+		if err == nil {
+			err = dump.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("error backing up database %s: [%v]", name, err)
+		}
+	}
+	return nil
+}
+
+// Restore deploys a new Concourse from a backup artifact, then replaces its databases with the backed up
+// ones. The deployment must not exist yet; it may have a different name or region to the one backed up.
+// The availability zone is kept when restoring to the same region, unless zone is given. The databases
+// are streamed from r as they are restored, so r is read until the restore finishes.
+func (client *Client) Restore(r io.Reader, zone string) error {
+	opened, err := client.configClient.OpenReader(backupArtifactName, r)
+	if err != nil {
+		return fmt.Errorf("error decrypting backup: [%v]", err)
+	}
+	b, err := readBackup(opened)
+	if err != nil {
+		return fmt.Errorf("error reading backup: [%v]", err)
+	}
+	if b.manifest.IAAS != client.provider.IAAS().String() {
+		return fmt.Errorf("backup is of a deployment on %s and cannot be restored to %s", b.manifest.IAAS, client.provider.IAAS())
+	}
+	if b.manifest.Version != client.version {
+		fmt.Fprintf(client.stderr, "WARNING: backup was taken by control-tower %s, but this is %s. "+
+			"Its databases will be migrated by the Concourse version bundled in %s.\n", b.manifest.Version, client.version, client.version)
+	}
+
+	var backedUp config.Config
+	if err = json.Unmarshal(b.files[config.ConfigFilename], &backedUp); err != nil {
+		return fmt.Errorf("error parsing config in backup: [%v]", err)
+	}
+	conf := restoredConfig(backedUp, client.configClient.NewConfig())
+	if zone != "" || conf.Region != backedUp.Region {
+		conf.AvailabilityZone = client.provider.Zone(zone, conf.ConcourseWorkerSize)
+	}
+
+	exists, err := client.configClient.ConfigExists()
+	if err != nil {
+		return fmt.Errorf("error determining if config already exists [%v]", err)
+	}
+	if exists {
+		return fmt.Errorf("deployment %s already exists. Restore only creates new deployments, so destroy it first or restore under another name", conf.Deployment)
+	}
+	if err = client.configClient.EnsureBucketExists(); err != nil {
+		return fmt.Errorf("error ensuring config bucket exists before restore: [%v]", err)
+	}
+
+	return client.withLock("restore", func() error {
+		if err := client.configClient.Update(conf); err != nil {
+			return fmt.Errorf("error storing restored config: [%v]", err)
+		}
+		if err := client.configClient.StoreAsset(bosh.CredsFilename, b.files[bosh.CredsFilename]); err != nil {
+			return fmt.Errorf("error storing restored %s: [%v]", bosh.CredsFilename, err)
+		}
+		if err := client.keepStoredAllowIPs(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(client.stdout, "\nRESTORING BACKUP OF %s TAKEN AT %s, DEPLOYING %s\n\n",
+			b.manifest.Deployment, b.manifest.CreatedAt.Format(time.RFC3339), conf.Deployment)
+		if err := client.deploy("restore"); err != nil {
+			return err
+		}
+		return client.restoreDatabases(b)
+	})
+}
+
+func (client *Client) restoreDatabases(b *backupReader) error {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return fmt.Errorf("error loading config: [%v]", err)
+	}
+	tfOutputs, err := client.tfCLI.BuildOutput(client.tfInputVarsFactory.NewInputVars(conf))
+	if err != nil {
+		return fmt.Errorf("error getting terraform outputs: [%v]", err)
+	}
+	boshClient, err := client.buildBoshClient(conf, tfOutputs)
+	if err != nil {
+		return err
+	}
+	defer boshClient.Cleanup()
+
+	fmt.Fprintln(client.stdout, "\nSTOPPING CONCOURSE WEB TO RESTORE THE DATABASES")
+	if err = boshClient.StopConcourseWeb(); err != nil {
+		return fmt.Errorf("error stopping Concourse web: [%v]", err)
+	}
+	for _, name := range b.manifest.Databases {
+		fmt.Fprintf(client.stdout, "RESTORING DATABASE %s\n", name)
+		if err = boshClient.RestoreDatabase(name, b.database(name)); err != nil {
+			return fmt.Errorf("error restoring database %s: [%v]", name, err)
+		}
+	}
+	if err = boshClient.StartConcourseWeb(); err != nil {
+		return fmt.Errorf("error starting Concourse web after restore: [%v]", err)
+	}
+
+	// The restored database holds the self-update pipeline of the deployment that was backed up
+	flyClient, err := client.buildFlyClient(conf)
+	if err != nil {
+		return err
+	}
+	defer flyClient.Cleanup()
+	if err = client.setPipeline(flyClient, conf, false); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(client.stdout, "\nRESTORED %s: %s\n", conf.Deployment, formatBackupContents(b.manifest))
+	return err
+}
+
+// restoredConfig keeps the settings and secrets of a backed up config, but takes its identity from fresh and
+// drops everything that describes the old infrastructure, so that it is created and filled in again
+func restoredConfig(backedUp, fresh config.Config) config.Config {
+	conf := backedUp
+
+	conf.ConfigBucket = fresh.ConfigBucket
+	conf.Deployment = fresh.Deployment
+	conf.Namespace = fresh.Namespace
+	conf.Project = fresh.Project
+	conf.Region = fresh.Region
+	conf.TFStatePath = fresh.TFStatePath
+
+	conf.CredhubURL = ""
+	conf.DirectorCACert = ""
+	conf.DirectorCert = ""
+	conf.DirectorKey = ""
+	conf.DirectorPublicIP = ""
+	conf.HostedZoneID = ""
+	conf.HostedZoneRecordPrefix = ""
+	conf.RDSDefaultDatabaseName = ""
+	conf.SourceAccessIP = ""
+
+	// Without a custom domain Concourse is reached on its IP, which will change
+	if conf.Domain == "" || govalidator.IsIPv4(conf.Domain) {
+		conf.Domain = ""
+		conf.ConcourseCACert = ""
+		conf.ConcourseCert = ""
+		conf.ConcourseKey = ""
+	}
+	return conf
+}
+
+func formatBackupContents(m backupManifest) string {
+	return fmt.Sprintf("databases %v, files %v", m.Databases, m.Files)
+}
+
+// backupWriter writes a backup as a gzipped tarball: the manifest, then the files, then each database
+// split into entries of at most chunkSize bytes, as tar needs the size of an entry before its contents
+type backupWriter struct {
+	gz        *gzip.Writer
+	tw        *tar.Writer
+	manifest  backupManifest
+	chunkSize int
+}
+
+func newBackupWriter(w io.Writer, manifest backupManifest, files map[string][]byte) (*backupWriter, error) {
+	gz := gzip.NewWriter(w)
+	bw := &backupWriter{gz: gz, tw: tar.NewWriter(gz), manifest: manifest, chunkSize: backupChunkSize}
+
+	contents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = writeTarEntry(bw.tw, backupManifestFilename, contents); err != nil {
+		return nil, err
+	}
+	for _, filename := range manifest.Files {
+		if err = writeTarEntry(bw.tw, path.Join("files", filename), files[filename]); err != nil {
+			return nil, err
+		}
+	}
+	return bw, nil
+}
+
+// database returns a writer for the dump of the named database, which must be closed before the next one
+func (bw *backupWriter) database(name string) *databaseWriter {
+	return &databaseWriter{bw: bw, name: name}
+}
+
+// Close finishes the tarball, without closing the underlying writer
+func (bw *backupWriter) Close() error {
+	if err := bw.tw.Close(); err != nil {
+		return err
+	}
+	return bw.gz.Close()
+}
+
+type databaseWriter struct {
+	bw     *backupWriter
+	name   string
+	buf    []byte
+	chunks int
+}
+
+func (d *databaseWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(d.buf)+len(p) >= d.bw.chunkSize {
+		n := d.bw.chunkSize - len(d.buf)
+		d.buf = append(d.buf, p[:n]...)
+		p = p[n:]
+		if err := d.writeChunk(); err != nil {
+			return 0, err
+		}
+	}
+	d.buf = append(d.buf, p...)
+	return written, nil
+}
+
+// Close writes what is left of the dump, and always at least one entry so that an empty dump is not mistaken for a missing one
+func (d *databaseWriter) Close() error {
+	if len(d.buf) > 0 || d.chunks == 0 {
+		return d.writeChunk()
+	}
+	return nil
+}
+
+func (d *databaseWriter) writeChunk() error {
+	err := writeTarEntry(d.bw.tw, databaseChunkName(d.name, d.chunks), d.buf)
+	d.buf = d.buf[:0]
+	d.chunks++
+	return err
+}
+
+func databaseChunkName(name string, chunk int) string {
+	return path.Join("databases", name+".dump", fmt.Sprintf("%06d", chunk))
+}
+
+func writeTarEntry(tw *tar.Writer, name string, contents []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(contents)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(contents)
+	return err
+}
+
+// backupReader reads a backup written by backupWriter. The manifest and files are read up front, and
+// the databases are read from the tarball as they are restored, in the order of the manifest.
+type backupReader struct {
+	manifest backupManifest
+	files    map[string][]byte
+	tr       *tar.Reader
+	next     *tar.Header
+}
+
+func readBackup(r io.Reader) (*backupReader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a control-tower backup [%v]", err)
+	}
+	b := &backupReader{files: map[string][]byte{}, tr: tar.NewReader(gz)}
+
+	manifest, err := b.readEntry(backupManifestFilename)
+	if err != nil {
+		return nil, fmt.Errorf("not a control-tower backup [%v]", err)
+	}
+	if err = json.Unmarshal(manifest, &b.manifest); err != nil {
+		return nil, fmt.Errorf("error parsing %s [%v]", backupManifestFilename, err)
+	}
+	if b.manifest.Format != backupFormat {
+		return nil, fmt.Errorf("unsupported backup format [%s]", b.manifest.Format)
+	}
+	for _, filename := range b.manifest.Files {
+		if b.files[filename], err = b.readEntry(path.Join("files", filename)); err != nil {
+			return nil, err
+		}
+	}
+	for _, filename := range []string{config.ConfigFilename, bosh.CredsFilename} {
+		if _, ok := b.files[filename]; !ok {
+			return nil, fmt.Errorf("file %s is missing", filename)
+		}
+	}
+	return b, nil
+}
+
+// readEntry reads the next entry, which must have the given name
+func (b *backupReader) readEntry(name string) ([]byte, error) {
+	header, err := b.tr.Next()
+	if err == io.EOF || (err == nil && header.Name != name) {
+		return nil, fmt.Errorf("%s is missing", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(b.tr)
+}
+
+// database returns a reader for the dump of the named database, which must be read to the end before the next one
+func (b *backupReader) database(name string) io.Reader {
+	return &databaseReader{b: b, name: name}
+}
+
+type databaseReader struct {
+	b       *backupReader
+	name    string
+	chunks  int
+	inChunk bool
+}
+
+func (d *databaseReader) Read(p []byte) (int, error) {
+	for {
+		if d.inChunk {
+			n, err := d.b.tr.Read(p)
+			if err != io.EOF {
+				return n, err
+			}
+			d.inChunk = false
+			if n > 0 {
+				return n, nil
+			}
+		}
+
+		header, err := d.b.nextHeader()
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if err == io.EOF || !strings.HasPrefix(header.Name, path.Join("databases", d.name+".dump")+"/") {
+			d.b.next = header
+			if d.chunks == 0 {
+				return 0, fmt.Errorf("database %s is missing", d.name)
+			}
+			return 0, io.EOF
+		}
+		if header.Name != databaseChunkName(d.name, d.chunks) {
+			return 0, fmt.Errorf("database %s is incomplete, expected %s but found %s", d.name, databaseChunkName(d.name, d.chunks), header.Name)
+		}
+		d.chunks++
+		d.inChunk = true
+	}
+}
+
+// nextHeader returns the entry a database reader stopped at, if any, before moving on to the next one
+func (b *backupReader) nextHeader() (*tar.Header, error) {
+	if b.next != nil {
+		header := b.next
+		b.next = nil
+		return header, nil
+	}
+	return b.tr.Next()
+}
@@ -0,0 +1,311 @@
+package concourse
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/bosh/boshfakes"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/config/configfakes"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/iaas/iaasfakes"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/terraform/terraformfakes"
+)
+
+func TestBackup_RoundTrip(t *testing.T) {
+	manifest := backupManifest{
+		Format:     backupFormat,
+		Version:    "0.1.0",
+		CreatedAt:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		IAAS:       "AWS",
+		Deployment: "control-tower-ci",
+		Region:     "eu-west-1",
+		Databases:  []string{"concourse_atc", "credhub", "uaa"},
+		Files:      []string{"config.json", "director-creds.yml"},
+	}
+	files := map[string][]byte{"config.json": []byte("{}"), "director-creds.yml": []byte("creds")}
+	dumps := map[string][]byte{
+		"concourse_atc": []byte("a dump that is split into several entries"),
+		"credhub":       []byte("dump"),
+		"uaa":           {},
+	}
+
+	writeBackup := func(manifest backupManifest, databases []string) []byte {
+		var artifact bytes.Buffer
+		bw, err := newBackupWriter(&artifact, manifest, files)
+		if err != nil {
+			t.Fatalf("newBackupWriter() error = %v", err)
+		}
+		bw.chunkSize = 8
+		for _, name := range databases {
+			dump := bw.database(name)
+			// Write in pieces that do not line up with the chunks
+			for contents := dumps[name]; len(contents) > 0; {
+				n := 3
+				if n > len(contents) {
+					n = len(contents)
+				}
+				if _, err = dump.Write(contents[:n]); err != nil {
+					t.Fatalf("databaseWriter.Write() error = %v", err)
+				}
+				contents = contents[n:]
+			}
+			if err = dump.Close(); err != nil {
+				t.Fatalf("databaseWriter.Close() error = %v", err)
+			}
+		}
+		if err = bw.Close(); err != nil {
+			t.Fatalf("backupWriter.Close() error = %v", err)
+		}
+		return artifact.Bytes()
+	}
+
+	b, err := readBackup(bytes.NewReader(writeBackup(manifest, manifest.Databases)))
+	if err != nil {
+		t.Fatalf("readBackup() error = %v", err)
+	}
+	if !reflect.DeepEqual(b.manifest, manifest) {
+		t.Errorf("readBackup() got manifest %+v, want %+v", b.manifest, manifest)
+	}
+	if !reflect.DeepEqual(b.files, files) {
+		t.Errorf("readBackup() got files %+v, want %+v", b.files, files)
+	}
+	for _, name := range manifest.Databases {
+		got, err := ioutil.ReadAll(b.database(name))
+		if err != nil {
+			t.Fatalf("reading database %s error = %v", name, err)
+		}
+		if !bytes.Equal(got, dumps[name]) {
+			t.Errorf("reading database %s got %q, want %q", name, got, dumps[name])
+		}
+	}
+
+	b, err = readBackup(bytes.NewReader(writeBackup(manifest, []string{"concourse_atc", "uaa"})))
+	if err != nil {
+		t.Fatalf("readBackup() error = %v", err)
+	}
+	if _, err = ioutil.ReadAll(b.database("concourse_atc")); err != nil {
+		t.Fatalf("reading database concourse_atc error = %v", err)
+	}
+	if _, err = ioutil.ReadAll(b.database("credhub")); err == nil || err.Error() != "database credhub is missing" {
+		t.Errorf("reading database credhub error = %v, want database credhub is missing", err)
+	}
+
+	withoutCreds := manifest
+	withoutCreds.Files = []string{"config.json"}
+	if _, err = readBackup(bytes.NewReader(writeBackup(withoutCreds, nil))); err == nil || err.Error() != "file director-creds.yml is missing" {
+		t.Errorf("readBackup() error = %v, want file director-creds.yml is missing", err)
+	}
+
+	if _, err = readBackup(strings.NewReader("{}")); err == nil || !strings.Contains(err.Error(), "not a control-tower backup") {
+		t.Errorf("readBackup() error = %v, want not a control-tower backup", err)
+	}
+}
+
+func TestRestoredConfig(t *testing.T) {
+	fresh := config.Config{
+		ConfigBucket: "control-tower-dr-eu-west-2-config",
+		Deployment:   "control-tower-dr",
+		Project:      "dr",
+		Region:       "eu-west-2",
+		TFStatePath:  "terraform.tfstate",
+	}
+	backedUp := config.Config{
+		ConfigBucket:           "control-tower-ci-eu-west-1-config",
+		Deployment:             "control-tower-ci",
+		Project:                "ci",
+		Region:                 "eu-west-1",
+		TFStatePath:            "terraform.tfstate",
+		AvailabilityZone:       "eu-west-1a",
+		DirectorCACert:         "director-ca",
+		DirectorPublicIP:       "1.2.3.4",
+		Domain:                 "5.6.7.8",
+		ConcourseCert:          "concourse-cert",
+		EncryptionKey:          "encryption-key",
+		RDSDefaultDatabaseName: "bosh_abcdefgh",
+		RDSPassword:            "rds-password",
+		ConcourseWorkerCount:   3,
+	}
+
+	got := restoredConfig(backedUp, fresh)
+	want := config.Config{
+		ConfigBucket:         "control-tower-dr-eu-west-2-config",
+		Deployment:           "control-tower-dr",
+		Project:              "dr",
+		Region:               "eu-west-2",
+		TFStatePath:          "terraform.tfstate",
+		AvailabilityZone:     "eu-west-1a",
+		EncryptionKey:        "encryption-key",
+		RDSPassword:          "rds-password",
+		ConcourseWorkerCount: 3,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("restoredConfig() got %+v, want %+v", got, want)
+	}
+
+	backedUp.Domain = "ci.example.com"
+	got = restoredConfig(backedUp, fresh)
+	if got.Domain != "ci.example.com" || got.ConcourseCert != "concourse-cert" {
+		t.Errorf("restoredConfig() dropped the custom domain and its certificate: %+v", got)
+	}
+}
+
+func TestClient_Backup(t *testing.T) {
+	tests := []struct {
+		name      string
+		online    bool
+		wantCalls []string
+	}{
+		{
+			name:      "offline",
+			wantCalls: []string{"stop", "dump concourse_atc", "dump credhub", "dump uaa", "start"},
+		},
+		{
+			name:      "online",
+			online:    true,
+			wantCalls: []string{"dump concourse_atc", "dump credhub", "dump uaa"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configClient := &configfakes.FakeIClient{}
+			configClient.LoadReturns(config.Config{IAAS: "AWS", Deployment: "control-tower-ci", Region: "eu-west-1"}, nil)
+			configClient.LoadAssetStub = func(filename string) ([]byte, error) {
+				return []byte("contents of " + filename), nil
+			}
+			configClient.SealWriterStub = func(name string, w io.Writer) (io.WriteCloser, error) {
+				return nopWriteCloser{w}, nil
+			}
+
+			var calls []string
+			boshClient := &boshfakes.FakeIClient{}
+			boshClient.StopConcourseWebStub = func() error {
+				calls = append(calls, "stop")
+				return nil
+			}
+			boshClient.StartConcourseWebStub = func() error {
+				calls = append(calls, "start")
+				return nil
+			}
+			boshClient.DumpDatabaseStub = func(name string, w io.Writer) error {
+				calls = append(calls, "dump "+name)
+				_, err := w.Write([]byte("dump of " + name))
+				return err
+			}
+
+			client := &Client{
+				configClient:       configClient,
+				tfCLI:              &terraformfakes.FakeCLIInterface{},
+				tfInputVarsFactory: &AWSInputVarsFactory{},
+				boshClientFactory: func(config.ConfigView, terraform.Outputs, io.Writer, io.Writer, events.Recorder, iaas.Provider, []byte) (bosh.IClient, error) {
+					return boshClient, nil
+				},
+				stdout:  ioutil.Discard,
+				version: "0.1.0",
+			}
+
+			var artifact bytes.Buffer
+			if err := client.Backup(&artifact, tt.online); err != nil {
+				t.Fatalf("Client.Backup() error = %v", err)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("Client.Backup() made calls %v, want %v", calls, tt.wantCalls)
+			}
+			if name, _ := configClient.SealWriterArgsForCall(0); name != backupArtifactName {
+				t.Errorf("Client.Backup() sealed the backup as %q", name)
+			}
+			if configClient.LockCallCount() != 1 || configClient.UnlockCallCount() != 1 {
+				t.Errorf("Client.Backup() did not hold the lock")
+			}
+
+			b, err := readBackup(&artifact)
+			if err != nil {
+				t.Fatalf("readBackup() error = %v", err)
+			}
+			manifest, _ := json.Marshal(b.manifest)
+			if b.manifest.Deployment != "control-tower-ci" || b.manifest.Version != "0.1.0" || len(b.manifest.Files) != 3 {
+				t.Errorf("Client.Backup() wrote manifest %s", manifest)
+			}
+			for _, name := range b.manifest.Databases {
+				got, err := ioutil.ReadAll(b.database(name))
+				if err != nil {
+					t.Fatalf("reading database %s error = %v", name, err)
+				}
+				if string(got) != "dump of "+name {
+					t.Errorf("Client.Backup() wrote %s dump %q", name, got)
+				}
+			}
+			if got := string(b.files[bosh.CredsFilename]); got != "contents of director-creds.yml" {
+				t.Errorf("Client.Backup() wrote director-creds.yml %q", got)
+			}
+		})
+	}
+}
+
+func TestClient_Restore_Refuses(t *testing.T) {
+	var artifact bytes.Buffer
+	bw, err := newBackupWriter(&artifact,
+		backupManifest{Format: backupFormat, IAAS: "AWS", Files: []string{"config.json", "director-creds.yml"}},
+		map[string][]byte{"config.json": []byte("{}"), "director-creds.yml": []byte("creds")})
+	if err != nil {
+		t.Fatalf("newBackupWriter() error = %v", err)
+	}
+	if err = bw.Close(); err != nil {
+		t.Fatalf("backupWriter.Close() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		iaas         iaas.Name
+		configExists bool
+		expectedErr  string
+	}{
+		{
+			name:        "another IAAS",
+			iaas:        iaas.GCP,
+			expectedErr: "backup is of a deployment on AWS and cannot be restored to GCP",
+		},
+		{
+			name:         "an existing deployment",
+			iaas:         iaas.AWS,
+			configExists: true,
+			expectedErr:  "deployment control-tower-dr already exists",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &iaasfakes.FakeProvider{}
+			provider.IAASReturns(tt.iaas)
+			configClient := &configfakes.FakeIClient{}
+			configClient.OpenReaderStub = func(name string, r io.Reader) (io.Reader, error) {
+				return r, nil
+			}
+			configClient.NewConfigReturns(config.Config{Deployment: "control-tower-dr"})
+			configClient.ConfigExistsReturns(tt.configExists, nil)
+
+			client := &Client{configClient: configClient, provider: provider, stdout: ioutil.Discard, stderr: ioutil.Discard}
+			err := client.Restore(bytes.NewReader(artifact.Bytes()), "")
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Client.Restore() error = %v, expected error containing %q", err, tt.expectedErr)
+			}
+			if configClient.UpdateCallCount() != 0 || configClient.LockCallCount() != 0 {
+				t.Errorf("Client.Restore() changed the deployment")
+			}
+		})
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -1,6 +1,7 @@
 package concourse
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/EngineerBetter/control-tower/commands/maintain"
@@ -9,6 +10,8 @@ import (
 	"github.com/EngineerBetter/control-tower/certs"
 	"github.com/EngineerBetter/control-tower/commands/deploy"
 	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/credhub"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/iaas"
 	"github.com/EngineerBetter/control-tower/terraform"
@@ -24,6 +27,7 @@ type Client struct {
 	configClient          config.IClient
 	deployArgs            *deploy.Args
 	eightRandomLetters    func() string
+	events                events.Recorder
 	flyClientFactory      func(iaas.Provider, fly.Credentials, io.Writer, io.Writer, []byte) (fly.IClient, error)
 	ipChecker             func() (string, error)
 	passwordGenerator     func(int) string
@@ -39,10 +43,15 @@ type Client struct {
 
 // IClient represents a control-tower client
 type IClient interface {
+	Backup(w io.Writer, online bool) error
 	Deploy() error
 	Destroy() error
 	FetchInfo() (*Info, error)
+	History() error
+	CheckUpgrade(finder ReleaseFinder) error
 	Maintain(maintain.Args) error
+	Restore(r io.Reader, zone string) error
+	Rollback(revision int, restoreDirectorState bool) error
 }
 
 // New returns a new client
@@ -56,6 +65,7 @@ func NewClient(
 	configClient config.IClient,
 	deployArgs *deploy.Args,
 	stdout, stderr io.Writer,
+	recorder events.Recorder,
 	ipChecker func() (string, error),
 	acmeClientConstructor func(u *certs.User) (*lego.Client, error),
 	passwordGenerator func(int) string,
@@ -70,6 +80,7 @@ func NewClient(
 		configClient:          configClient,
 		deployArgs:            deployArgs,
 		eightRandomLetters:    eightRandomLetters,
+		events:                recorder,
 		flyClientFactory:      flyClientFactory,
 		ipChecker:             ipChecker,
 		passwordGenerator:     passwordGenerator,
@@ -91,7 +102,40 @@ func (client *Client) buildBoshClient(config config.ConfigView, tfOutputs terraf
 		tfOutputs,
 		client.stdout,
 		client.stderr,
+		client.events,
 		client.provider,
 		client.versionFile,
 	)
 }
+
+// buildFlyClient creates a fly client for the deployment described by a saved config
+func (client *Client) buildFlyClient(conf config.Config) (fly.IClient, error) {
+	return client.flyClientFactory(client.provider, fly.Credentials{
+		Target:   conf.Deployment,
+		API:      fmt.Sprintf("https://%s", conf.Domain),
+		Username: conf.ConcourseUsername,
+		Password: conf.ConcoursePassword,
+		CredHub: credhub.Credentials{
+			URL:          conf.CredhubURL,
+			CACert:       conf.CredhubCACert,
+			ClientSecret: conf.CredhubAdminClientSecret,
+		},
+	},
+		client.stdout,
+		client.stderr,
+		client.versionFile,
+	)
+}
+
+// withLock runs action while holding the lock on the deployment's config bucket
+func (client *Client) withLock(operation string, action func() error) error {
+	if err := client.configClient.Lock(operation); err != nil {
+		return fmt.Errorf("error locking deployment for %s: [%v]", operation, err)
+	}
+
+	err := action()
+	if unlockErr := client.configClient.Unlock(); unlockErr != nil && err == nil {
+		err = fmt.Errorf("error unlocking deployment after %s: [%v]", operation, unlockErr)
+	}
+	return err
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 
 	"github.com/go-acme/lego/v4/lego"
 	. "github.com/onsi/ginkgo"
@@ -21,6 +23,7 @@ import (
 	"github.com/EngineerBetter/control-tower/concourse/concoursefakes"
 	"github.com/EngineerBetter/control-tower/config"
 	"github.com/EngineerBetter/control-tower/config/configfakes"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/fly/flyfakes"
 	"github.com/EngineerBetter/control-tower/iaas"
@@ -83,7 +86,7 @@ var _ = Describe("client", func() {
 
 		provider, err := iaas.New(iaas.AWS, "eu-west-1")
 		Expect(err).ToNot(HaveOccurred())
-		awsInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider)
+		awsInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, config.StateBackend{})
 		Expect(err).ToNot(HaveOccurred())
 		tfInputVarsFactory.NewInputVarsStub = func(i config.ConfigView) terraform.InputVars {
 			actions = append(actions, "converting config.Config to TFInputVars")
@@ -202,6 +205,7 @@ var _ = Describe("client", func() {
 			RDSInstanceClass:         "db.t3.medium",
 			RDSPassword:              "s3cret",
 			RDSUsername:              "admin",
+			ReleaseChannel:           "stable",
 			Region:                   "eu-west-1",
 			Spot:                     true,
 			TFStatePath:              "example-path",
@@ -236,7 +240,7 @@ var _ = Describe("client", func() {
 
 		terraformCLI = setupFakeTerraformCLI(terraformOutputs)
 
-		boshClientFactory := func(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.Writer, provider iaas.Provider, versionFile []byte) (bosh.IClient, error) {
+		boshClientFactory := func(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.Writer, recorder events.Recorder, provider iaas.Provider, versionFile []byte) (bosh.IClient, error) {
 			boshClient = &boshfakes.FakeIClient{}
 			boshClient.DeployStub = func(stateFileBytes, credsFileBytes []byte, detach bool) ([]byte, []byte, error) {
 				if detach {
@@ -281,6 +285,7 @@ var _ = Describe("client", func() {
 				args,
 				stdout,
 				stderr,
+				events.Discard,
 				ipChecker,
 				certsfakes.NewFakeAcmeClient,
 				func(size int) string { return fmt.Sprintf("generatedPassword%d", size) },
@@ -323,10 +328,39 @@ var _ = Describe("client", func() {
 			Expect(actions).To(ContainElement("deleting config"))
 		})
 
+		It("Holds the deployment lock throughout", func() {
+			Expect(buildClient().Destroy()).To(Succeed())
+			Expect(configClient).To(HaveReceived("Lock").With("destroy"))
+			Expect(configClient).To(HaveReceived("Unlock"))
+		})
+
 		It("Prints a destroy success message", func() {
 			Expect(buildClient().Destroy()).To(Succeed())
 			Eventually(stdout).Should(gbytes.Say("DESTROY SUCCESSFUL"))
 		})
+
+		It("Leaves nothing behind in the state backend, not even the lock", func() {
+			root, err := ioutil.TempDir("", "control-tower-destroy")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(root)
+
+			provider := &iaasfakes.FakeProvider{}
+			provider.RegionReturns("eu-west-1")
+			stateClient := config.NewWithBackend(provider, config.NewLocal(root), "happymeal", "")
+			Expect(stateClient.EnsureBucketExists()).To(Succeed())
+			Expect(stateClient.Update(configInBucket)).To(Succeed())
+
+			configInBucket.ConfigBucket = stateClient.BucketName
+			configClient.LockStub = stateClient.Lock
+			configClient.UnlockStub = stateClient.Unlock
+			configClient.DeleteAllStub = stateClient.DeleteAll
+
+			Expect(buildClient().Destroy()).To(Succeed())
+
+			entries, err := ioutil.ReadDir(root)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
 	})
 
 	Describe("FetchInfo", func() {
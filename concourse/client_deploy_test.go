@@ -1,10 +1,13 @@
 package concourse_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"time"
 
 	"github.com/EngineerBetter/control-tower/bosh"
 	"github.com/EngineerBetter/control-tower/bosh/boshfakes"
@@ -15,10 +18,12 @@ import (
 	"github.com/EngineerBetter/control-tower/concourse/concoursefakes"
 	"github.com/EngineerBetter/control-tower/config"
 	"github.com/EngineerBetter/control-tower/config/configfakes"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/fly/flyfakes"
 	"github.com/EngineerBetter/control-tower/iaas"
 	"github.com/EngineerBetter/control-tower/iaas/iaasfakes"
+	"github.com/EngineerBetter/control-tower/resource"
 	"github.com/EngineerBetter/control-tower/terraform"
 	"github.com/EngineerBetter/control-tower/terraform/terraformfakes"
 	"github.com/go-acme/lego/v4/lego"
@@ -46,6 +51,7 @@ var _ = Describe("client", func() {
 	var terraformCLI *terraformfakes.FakeCLIInterface
 	var configClient *configfakes.FakeIClient
 	var boshClient *boshfakes.FakeIClient
+	var recorder events.Recorder
 
 	var setupFakeAwsProvider = func() *iaasfakes.FakeProvider {
 		provider := &iaasfakes.FakeProvider{}
@@ -83,7 +89,7 @@ var _ = Describe("client", func() {
 
 		provider, err := iaas.New(iaas.AWS, "eu-west-1")
 		Expect(err).ToNot(HaveOccurred())
-		awsInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider)
+		awsInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, config.StateBackend{})
 		Expect(err).ToNot(HaveOccurred())
 		tfInputVarsFactory.NewInputVarsStub = func(i config.ConfigView) terraform.InputVars {
 			return awsInputVarsFactory.NewInputVars(i)
@@ -104,6 +110,8 @@ var _ = Describe("client", func() {
 		directorCredsFixture, err = ioutil.ReadFile("fixtures/director-creds.yml")
 		Expect(err).ToNot(HaveOccurred())
 
+		recorder = events.Discard
+
 		//At the time of writing, these are defaults from the CLI flags
 		args = &deploy.Args{
 			AllowIPs:         "0.0.0.0/0",
@@ -196,6 +204,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 			RDSInstanceClass:       "db.t3.medium",
 			RDSPassword:            "s3cret",
 			RDSUsername:            "admin",
+			ReleaseChannel:         "stable",
 			Region:                 "eu-west-1",
 			Spot:                   true,
 			TFStatePath:            "example-path",
@@ -224,9 +233,11 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 		configClient = &configfakes.FakeIClient{}
 		terraformCLI = setupFakeTerraformCLI(terraformOutputs)
 
-		boshClientFactory := func(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.Writer, provider iaas.Provider, versionFile []byte) (bosh.IClient, error) {
+		boshClientFactory := func(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.Writer, recorder events.Recorder, provider iaas.Provider, versionFile []byte) (bosh.IClient, error) {
 			boshClient = &boshfakes.FakeIClient{}
 			boshClient.DeployReturns(directorStateFixture, directorCredsFixture, nil)
+			boshClient.DirectorManifestReturns(fmt.Sprintf("version: %q\n", config.GetVersion()), nil)
+			boshClient.DeployDryRunReturns("  worker_count: 1 -> 2\n", nil)
 			return boshClient, nil
 		}
 
@@ -253,6 +264,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 				args,
 				stdout,
 				stderr,
+				recorder,
 				ipChecker,
 				certsfakes.NewFakeAcmeClient,
 				func(size int) string { return fmt.Sprintf("generatedPassword%d", size) },
@@ -277,6 +289,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 				args,
 				stdout,
 				stderr,
+				events.Discard,
 				ipChecker,
 				certsfakes.NewFakeAcmeClient,
 				func(size int) string { return fmt.Sprintf("generatedPassword%d", size) },
@@ -328,6 +341,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 						Region:                 configAfterLoad.Region,
 						SourceAccessIP:         configAfterLoad.SourceAccessIP,
 						TFStatePath:            configAfterLoad.TFStatePath,
+						WebCount:               1,
 					}
 
 					//Mutations we expect to have been done after deploying the director
@@ -391,6 +405,8 @@ wEW5QkylaPEkbVDhJWeR1I8=
 					tfInputVarsFactory.NewInputVarsReturns(terraformInputVars)
 
 					Expect(configClient).To(HaveReceived("EnsureBucketExists"))
+					Expect(configClient).To(HaveReceived("Lock").With("deploy"))
+					Expect(configClient).To(HaveReceived("EncryptPlaintextAssets").With([]string{"director-state.json", "director-creds.yml", "director-creds-backup.yml"}))
 					Expect(configClient).To(HaveReceived("ConfigExists"))
 					Expect(configClient).To(HaveReceived("Load"))
 					Expect(tfInputVarsFactory).To(HaveReceived("NewInputVars").With(configAfterLoad))
@@ -416,6 +432,8 @@ wEW5QkylaPEkbVDhJWeR1I8=
 					Expect(boshClient).To(HaveReceived("Cleanup"))
 					Expect(flyClient).To(HaveReceived("SetDefaultPipeline").With(configAfterCreateEnv, false))
 					Expect(configClient).To(HaveReceived("Update").With(configAfterConcourseDeploy))
+					Expect(configClient).To(HaveReceived("RecordRevision").With("deploy", []string{"director-state.json", "director-creds.yml"}))
+					Expect(configClient).To(HaveReceived("Unlock"))
 				})
 
 				It("Warns about access to local machine", func() {
@@ -472,6 +490,80 @@ wEW5QkylaPEkbVDhJWeR1I8=
 				})
 			})
 
+			Context("and --private was changed", func() {
+				BeforeEach(func() {
+					args.Private = true
+					args.PrivateIsSet = true
+				})
+
+				JustBeforeEach(func() {
+					configClient.LoadReturns(configInBucket, nil)
+					configClient.ConfigExistsReturns(true, nil)
+				})
+
+				It("fails because an existing deployment cannot be made private", func() {
+					client := buildClient()
+					err := client.Deploy()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("--private can only be set on the initial deploy"))
+				})
+			})
+
+			Context("and --instance-identity was turned on", func() {
+				BeforeEach(func() {
+					args.InstanceIdentity = true
+					args.InstanceIdentityIsSet = true
+				})
+
+				JustBeforeEach(func() {
+					configClient.LoadReturns(configInBucket, nil)
+					configClient.ConfigExistsReturns(true, nil)
+					configClient.HasAssetReturnsOnCall(0, true, nil)
+					configClient.LoadAssetReturnsOnCall(0, directorStateFixture, nil)
+					configClient.HasAssetReturnsOnCall(1, true, nil)
+					configClient.LoadAssetReturnsOnCall(1, directorCredsFixture, nil)
+				})
+
+				It("keeps the access keys until the deployment has been recreated, then removes them", func() {
+					client := buildClient()
+					err := client.Deploy()
+					Expect(err).ToNot(HaveOccurred())
+
+					migrating := tfInputVarsFactory.NewInputVarsArgsForCall(0)
+					Expect(migrating.UsesInstanceIdentity()).To(BeTrue())
+					Expect(migrating.HasLegacyAccessKeys()).To(BeTrue())
+
+					Expect(terraformCLI.ApplyCallCount()).To(Equal(2))
+					migrated := tfInputVarsFactory.NewInputVarsArgsForCall(tfInputVarsFactory.NewInputVarsCallCount() - 1)
+					Expect(migrated.UsesInstanceIdentity()).To(BeTrue())
+					Expect(migrated.HasLegacyAccessKeys()).To(BeFalse())
+
+					stored := configClient.UpdateArgsForCall(configClient.UpdateCallCount() - 1)
+					Expect(stored.InstanceIdentity).To(BeTrue())
+					Expect(stored.LegacyAccessKeys).To(BeFalse())
+				})
+			})
+
+			Context("and --instance-identity was turned off", func() {
+				BeforeEach(func() {
+					configInBucket.InstanceIdentity = true
+					args.InstanceIdentity = false
+					args.InstanceIdentityIsSet = true
+				})
+
+				JustBeforeEach(func() {
+					configClient.LoadReturns(configInBucket, nil)
+					configClient.ConfigExistsReturns(true, nil)
+				})
+
+				It("fails because access keys cannot be brought back", func() {
+					client := buildClient()
+					err := client.Deploy()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("--instance-identity cannot be turned off once a deployment uses it"))
+				})
+			})
+
 			Context("and all the CLI args were provided", func() {
 				BeforeEach(func() {
 					// Set all changeable arguments (IE, not IAAS, Region, Namespace, AZ, et al)
@@ -552,6 +644,7 @@ wEW5QkylaPEkbVDhJWeR1I8=
 						Region:                 configAfterLoad.Region,
 						SourceAccessIP:         configAfterLoad.SourceAccessIP,
 						TFStatePath:            configAfterLoad.TFStatePath,
+						WebCount:               1,
 					}
 
 					configAfterCreateEnv = configAfterLoad
@@ -642,6 +735,7 @@ wEW5QkylaPEkbVDhJWeR1I8=
 					RDSInstanceClass:         "db.t3.small",
 					RDSPassword:              "generatedPassword20",
 					RDSUsername:              "admingeneratedPassword7",
+					ReleaseChannel:           "stable",
 					Region:                   "eu-west-1",
 					SourceAccessIP:           "192.0.2.0",
 					TFStatePath:              "terraform.tfstate",
@@ -740,6 +834,7 @@ wEW5QkylaPEkbVDhJWeR1I8=
 					Region:                 defaultGeneratedConfig.Region,
 					SourceAccessIP:         defaultGeneratedConfig.SourceAccessIP,
 					TFStatePath:            defaultGeneratedConfig.TFStatePath,
+					WebCount:               1,
 				}
 
 				tfInputVarsFactory.NewInputVarsReturns(terraformInputVars)
@@ -822,6 +917,62 @@ wEW5QkylaPEkbVDhJWeR1I8=
 			})
 		})
 
+		Context("When a private deployment has a custom domain", func() {
+			BeforeEach(func() {
+				configInBucket.Domain = "ci.google.com"
+				configInBucket.Private = true
+				configInBucket.HostedZoneID = "ABC123"
+			})
+
+			JustBeforeEach(func() {
+				configClient.LoadReturns(configInBucket, nil)
+				configClient.ConfigExistsReturns(true, nil)
+			})
+
+			It("Keeps the record out of the public DNS zone", func() {
+				client := buildClient()
+				err := client.Deploy()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(stderr).To(gbytes.Say("WARNING: adding record ci.google.com to a private DNS zone"))
+				Expect(tfInputVarsFactory.NewInputVarsArgsForCall(0).GetHostedZoneID()).To(BeEmpty())
+			})
+		})
+
+		Context("When the deployment is locked by someone else", func() {
+			JustBeforeEach(func() {
+				configClient.LoadReturns(configInBucket, nil)
+				configClient.ConfigExistsReturns(true, nil)
+				configClient.LockReturns(errors.New("deployment is locked by someone"))
+			})
+
+			It("Returns the error without changing anything", func() {
+				client := buildClient()
+				err := client.Deploy()
+				Expect(err).To(MatchError("error locking deployment for deploy: [deployment is locked by someone]"))
+
+				Expect(terraformCLI).ToNot(HaveReceived("Apply"))
+				Expect(configClient).ToNot(HaveReceived("Update"))
+				Expect(configClient).ToNot(HaveReceived("Unlock"))
+			})
+		})
+
+		Context("When the lock is lost during the deploy", func() {
+			JustBeforeEach(func() {
+				configClient.LoadReturns(configInBucket, nil)
+				configClient.ConfigExistsReturns(true, nil)
+				configClient.HasAssetReturns(true, nil)
+				configClient.LoadAssetReturns(directorCredsFixture, nil)
+				configClient.UnlockReturns(errors.New("lock was taken over by someone"))
+			})
+
+			It("Returns the error", func() {
+				client := buildClient()
+				err := client.Deploy()
+				Expect(err).To(MatchError("error unlocking deployment after deploy: [lock was taken over by someone]"))
+			})
+		})
+
 		Context("When the user tries to change the region of an existing deployment", func() {
 			BeforeEach(func() {
 				args.Region = "eu-central-1"
@@ -868,7 +1019,7 @@ wEW5QkylaPEkbVDhJWeR1I8=
 			It("Does not override the existing DB size", func() {
 				provider, err := iaas.New(iaas.AWS, "eu-west-1")
 				Expect(err).ToNot(HaveOccurred())
-				awsInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider)
+				awsInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, config.StateBackend{})
 				Expect(err).ToNot(HaveOccurred())
 
 				var passedDBSize string
@@ -899,5 +1050,157 @@ wEW5QkylaPEkbVDhJWeR1I8=
 				Expect(boshClient).To(HaveReceived("Deploy").With([]byte{}, []byte{}, true))
 			})
 		})
+
+		Context("When reporting progress as events", func() {
+			var eventsOutput *bytes.Buffer
+
+			BeforeEach(func() {
+				eventsOutput = new(bytes.Buffer)
+				recorder = events.NewJSONRecorder(eventsOutput, time.Now)
+			})
+
+			decodeEvents := func() []events.Event {
+				var emitted []events.Event
+				decoder := json.NewDecoder(eventsOutput)
+				for decoder.More() {
+					var event events.Event
+					Expect(decoder.Decode(&event)).To(Succeed())
+					emitted = append(emitted, event)
+				}
+				return emitted
+			}
+
+			It("emits the start and end of each phase", func() {
+				client := buildClient()
+				err := client.Deploy()
+				Expect(err).ToNot(HaveOccurred())
+
+				var phases []string
+				for _, event := range decodeEvents() {
+					phases = append(phases, event.Type+" "+event.Phase)
+					if event.Type == events.PhaseEnd {
+						Expect(event.DurationMS).ToNot(BeNil())
+						Expect(event.Error).To(BeEmpty())
+					}
+					if event.Type == events.PhaseEnd && event.Phase == events.SetPipeline {
+						Expect(event.Resources).To(HaveKeyWithValue("pipeline", "control-tower-self-update"))
+					}
+				}
+				Expect(phases).To(Equal([]string{
+					"phase-start config-load", "phase-end config-load",
+					"phase-start terraform-apply", "phase-end terraform-apply",
+					"phase-start cert-generation", "phase-end cert-generation",
+					"phase-start set-pipeline", "phase-end set-pipeline",
+				}))
+			})
+
+			It("includes the error in the end event of the phase that failed", func() {
+				terraformCLI.ApplyReturns(errors.New("quota exceeded"))
+
+				client := buildClient()
+				err := client.Deploy()
+				Expect(err).To(MatchError("quota exceeded"))
+
+				emitted := decodeEvents()
+				last := emitted[len(emitted)-1]
+				Expect(last.Type).To(Equal(events.PhaseEnd))
+				Expect(last.Phase).To(Equal(events.TerraformApply))
+				Expect(last.Error).To(Equal("quota exceeded"))
+			})
+		})
+
+		Context("When running in dry-run mode", func() {
+			BeforeEach(func() {
+				args.DryRun = true
+				args.WorkerCount = 2
+				args.WorkerCountIsSet = true
+			})
+
+			JustBeforeEach(func() {
+				terraformCLI.PlanReturns("Plan: 0 to add, 1 to change, 0 to destroy.", nil)
+			})
+
+			Context("and there is an existing deployment", func() {
+				BeforeEach(func() {
+					configInBucket.Version = "0.1.0"
+				})
+
+				JustBeforeEach(func() {
+					configClient.LoadReturns(configInBucket, nil)
+					configClient.ConfigExistsReturns(true, nil)
+					configClient.HasAssetReturns(true, nil)
+					configClient.LoadAssetReturns(directorCredsFixture, nil)
+				})
+
+				It("plans instead of applying anything", func() {
+					client := buildClient()
+					err := client.Deploy()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(terraformCLI).To(HaveReceived("Plan"))
+					Expect(terraformCLI).ToNot(HaveReceived("Apply"))
+					Expect(configClient).ToNot(HaveReceived("Update"))
+					Expect(configClient).ToNot(HaveReceived("StoreAsset"))
+					Expect(configClient).ToNot(HaveReceived("EncryptPlaintextAssets"))
+					Expect(configClient).ToNot(HaveReceived("Lock"))
+					Expect(boshClient).ToNot(HaveReceived("Deploy"))
+					Expect(boshClient).To(HaveReceived("DeployDryRun").With(directorCredsFixture))
+					Expect(flyClient).ToNot(HaveReceived("SetDefaultPipeline"))
+					Expect(certGenerationActions).To(BeEmpty())
+				})
+
+				It("prints a combined report of the changes", func() {
+					client := buildClient()
+					err := client.Deploy()
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(stdout).Should(gbytes.Say("DRY RUN: NO CHANGES HAVE BEEN APPLIED"))
+					Eventually(stdout).Should(gbytes.Say("Plan: 0 to add, 1 to change, 0 to destroy."))
+					Eventually(stdout).Should(gbytes.Say(`Control Tower version: "0.1.0" -> "some version"`))
+					Eventually(stdout).Should(gbytes.Say(`Worker count: "1" -> "2"`))
+					Eventually(stdout).Should(gbytes.Say("BOSH director certificate will be generated"))
+					Eventually(stdout).Should(gbytes.Say("Concourse certificate will be generated"))
+					Eventually(stdout).Should(gbytes.Say(`~ /version: 0.1.0 -> some version`))
+					Eventually(stdout).Should(gbytes.Say("worker_count: 1 -> 2"))
+				})
+			})
+
+			Context("and there is no existing deployment", func() {
+				JustBeforeEach(func() {
+					configClient.ConfigExistsReturns(false, nil)
+					configClient.NewConfigReturns(config.Config{
+						ConfigBucket: "control-tower-initial-deployment-eu-west-1-config",
+						Deployment:   "control-tower-initial-deployment",
+						Namespace:    "eu-west-1",
+						Project:      "initial-deployment",
+						TFStatePath:  "terraform.tfstate",
+					})
+				})
+
+				It("only reports the terraform plan and does not persist config", func() {
+					client := buildClient()
+					err := client.Deploy()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(configClient).ToNot(HaveReceived("EnsureBucketExists"))
+					Expect(configClient).ToNot(HaveReceived("Update"))
+					Expect(terraformCLI).ToNot(HaveReceived("BuildOutput"))
+					Eventually(stdout).Should(gbytes.Say("No existing deployment was found"))
+					Eventually(stdout).Should(gbytes.Say("Plan: 0 to add, 1 to change, 0 to destroy."))
+				})
+
+				It("plans with terraform state kept locally, as the bucket that would hold it does not exist", func() {
+					client := buildClient()
+					err := client.Deploy()
+					Expect(err).ToNot(HaveOccurred())
+
+					tfConfig, err := terraformCLI.PlanArgsForCall(0).ConfigureTerraform(resource.AWSTerraformConfig)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(tfConfig).To(ContainSubstring("backend \"local\" {\n\t\tpath = \"terraform.tfstate\""))
+					Expect(tfConfig).ToNot(ContainSubstring("backend \"s3\""))
+					Expect(tfConfig).ToNot(ContainSubstring("control-tower-initial-deployment-eu-west-1-config"))
+				})
+			})
+		})
 	})
 })
@@ -14,6 +14,7 @@ import (
 	"github.com/EngineerBetter/control-tower/concourse/concoursefakes"
 	"github.com/EngineerBetter/control-tower/config"
 	"github.com/EngineerBetter/control-tower/config/configfakes"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/fly/flyfakes"
 	"github.com/EngineerBetter/control-tower/iaas"
@@ -74,7 +75,7 @@ var _ = Describe("client", func() {
 
 		// provider, err := iaas.New(iaas.GCP, "europe-west1")
 		// Expect(err).ToNot(HaveOccurred())
-		gcpInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider)
+		gcpInputVarsFactory, err := concourse.NewTFInputVarsFactory(provider, config.StateBackend{})
 		Expect(err).ToNot(HaveOccurred())
 		tfInputVarsFactory.NewInputVarsStub = func(i config.ConfigView) terraform.InputVars {
 			actions = append(actions, "converting config.Config to TFInputVars")
@@ -252,6 +253,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 			RDSInstanceClass:       "db-g1-small",
 			RDSPassword:            "s3cret",
 			RDSUsername:            "admin",
+			ReleaseChannel:         "stable",
 			Region:                 "europe-west1",
 			Spot:                   true,
 			TFStatePath:            "example-path",
@@ -283,7 +285,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 
 		terraformCLI = setupFakeTerraformCLI(terraformOutputs)
 
-		boshClientFactory := func(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.Writer, provider iaas.Provider, versionFile []byte) (bosh.IClient, error) {
+		boshClientFactory := func(config config.ConfigView, outputs terraform.Outputs, stdout, stderr io.Writer, recorder events.Recorder, provider iaas.Provider, versionFile []byte) (bosh.IClient, error) {
 			boshClient = &boshfakes.FakeIClient{}
 			boshClient.DeployStub = func(stateFileBytes, credsFileBytes []byte, detach bool) ([]byte, []byte, error) {
 				if detach {
@@ -328,6 +330,7 @@ sWbB3FCIsym1FXB+eRnVF3Y15RwBWWKA5RfwUNpEXFxtv24tQ8jrdA==
 				args,
 				stdout,
 				stderr,
+				events.Discard,
 				ipChecker,
 				certsfakes.NewFakeAcmeClient,
 				func(size int) string { return fmt.Sprintf("generatedPassword%d", size) },
@@ -45,10 +45,18 @@ func (client *Client) getInitialConfig() (config.Config, bool, error) {
 			return config.Config{}, false, err
 		}
 
+		usedInstanceIdentity := conf.UsesInstanceIdentity()
 		conf, isDomainUpdated, err = applyArgumentsToConfig(conf, client.deployArgs, client.provider)
 		if err != nil {
 			return config.Config{}, false, fmt.Errorf("error merging new options with existing config: [%v]", err)
 		}
+		// The access keys the deployment was using are kept until its VMs have been recreated without them
+		if conf.UsesInstanceIdentity() && !usedInstanceIdentity {
+			conf.LegacyAccessKeys = true
+		}
+		if err = checkNetwork(conf, client.provider); err != nil {
+			return config.Config{}, false, err
+		}
 	} else {
 		conf, _, err = applyArgumentsToConfig(defaultConf, client.deployArgs, client.provider)
 		if err != nil {
@@ -56,10 +64,15 @@ func (client *Client) getInitialConfig() (config.Config, bool, error) {
 		}
 
 		conf = applyImmutableArgumentsToConfig(conf, client.deployArgs, client.provider)
+		if err = checkNetwork(conf, client.provider); err != nil {
+			return config.Config{}, false, err
+		}
 
-		err = client.configClient.Update(conf)
-		if err != nil {
-			return config.Config{}, false, fmt.Errorf("error persisting new config after setting values [%v]", err)
+		if !client.deployArgs.DryRun {
+			err = client.configClient.Update(conf)
+			if err != nil {
+				return config.Config{}, false, fmt.Errorf("error persisting new config after setting values [%v]", err)
+			}
 		}
 
 		isDomainUpdated = true
@@ -73,6 +86,14 @@ func assertImmutableFieldsNotChanging(deployArgs *deploy.Args, conf config.Confi
 		return fmt.Errorf("custom CIDRs cannot be applied after intial deploy")
 	}
 
+	if deployArgs.PrivateIsSet && deployArgs.Private != conf.IsPrivate() {
+		return fmt.Errorf("--private can only be set on the initial deploy")
+	}
+
+	if deployArgs.InstanceIdentityIsSet && !deployArgs.InstanceIdentity && conf.UsesInstanceIdentity() {
+		return fmt.Errorf("--instance-identity cannot be turned off once a deployment uses it")
+	}
+
 	// This is a safeguard for a redeployment where zone does not belong to the region where the original deployment has happened
 	if deployArgs.ZoneIsSet && deployArgs.Zone != conf.GetAvailabilityZone() {
 		return fmt.Errorf("Existing deployment uses zone %s and cannot change to zone %s", conf.GetAvailabilityZone(), deployArgs.Zone)
@@ -106,6 +127,7 @@ func populateConfigWithDefaults(conf config.Config, provider iaas.Provider, pass
 	conf.RDSInstanceClass = provider.DBType("small")
 	conf.RDSPassword = passwordGenerator(defaultPasswordLength)
 	conf.RDSUsername = "admin" + passwordGenerator(7)
+	conf.ReleaseChannel = config.ReleaseChannelStable
 	conf.VMProvisioningType = config.SPOT
 	conf.WorkerType = "m4"
 	conf = populateConfigWithDefaultCIDRs(conf, provider)
@@ -115,6 +137,10 @@ func populateConfigWithDefaults(conf config.Config, provider iaas.Provider, pass
 		conf.RDSDefaultDatabaseName = fmt.Sprintf("bosh_%s", eightRandomLetters())
 	case iaas.GCP:
 		conf.RDSDefaultDatabaseName = fmt.Sprintf("bosh-%s", eightRandomLetters())
+	case iaas.Azure:
+		conf.RDSDefaultDatabaseName = fmt.Sprintf("bosh-%s", eightRandomLetters())
+		// Azure Database for PostgreSQL rejects passwords without upper case, lower case and digits
+		conf.RDSPassword = "Pa" + passwordGenerator(defaultPasswordLength-3) + "0"
 	}
 
 	return conf, nil
@@ -143,9 +169,55 @@ func applyArgumentsToConfig(conf config.Config, deployArgs *deploy.Args, provide
 	if deployArgs.WorkerSizeIsSet {
 		conf.ConcourseWorkerSize = deployArgs.WorkerSize
 	}
+	if deployArgs.WorkerPoolsIsSet {
+		conf.WorkerPools = deployArgs.WorkerPools
+	}
+	if deployArgs.ConcourseOpsFilesIsSet {
+		conf.ConcourseOpsFiles = deployArgs.ConcourseOpsFiles
+	}
+	if deployArgs.DirectorOpsFilesIsSet {
+		conf.DirectorOpsFiles = deployArgs.DirectorOpsFiles
+	}
+	if deployArgs.VarsFilesIsSet {
+		conf.VarsFiles = deployArgs.VarsFiles
+	}
+	if deployArgs.ScheduleIsSet {
+		schedule, err := deployArgs.WorkerSchedule()
+		if err != nil {
+			return config.Config{}, false, fmt.Errorf("error reading worker schedule: [%v]", err)
+		}
+		conf.Schedule = schedule
+	}
+	if deployArgs.ReleaseChannelIsSet {
+		conf.ReleaseChannel = deployArgs.ReleaseChannel
+	}
+	if deployArgs.MaintenanceWindowIsSet {
+		window, err := deployArgs.UpgradeMaintenanceWindow()
+		if err != nil {
+			return config.Config{}, false, fmt.Errorf("error reading maintenance window: [%v]", err)
+		}
+		conf.MaintenanceWindow = window
+	}
+	if deployArgs.UpgradeApprovalIsSet {
+		conf.UpgradeApproval = deployArgs.UpgradeApproval
+	}
+	if deployArgs.NotificationsIsSet {
+		conf.Notifications = deployArgs.ApplyNotifications(conf.Notifications)
+		if conf.Notifications != nil {
+			if err := config.ValidateNotifications(*conf.Notifications); err != nil {
+				return config.Config{}, false, err
+			}
+		}
+	}
 	if deployArgs.WebSizeIsSet {
 		conf.ConcourseWebSize = deployArgs.WebSize
 	}
+	if deployArgs.WebCountIsSet {
+		if deployArgs.WebCount > 1 && conf.IsPrivate() {
+			return config.Config{}, false, fmt.Errorf("--web-count above 1 cannot be used with a private deployment")
+		}
+		conf.ConcourseWebCount = deployArgs.WebCount
+	}
 	if deployArgs.DBSizeIsSet {
 		conf.RDSInstanceClass = provider.DBType(deployArgs.DBSize)
 	}
@@ -162,6 +234,18 @@ func applyArgumentsToConfig(conf config.Config, deployArgs *deploy.Args, provide
 		conf.MicrosoftClientSecret = deployArgs.MicrosoftAuthClientSecret
 		conf.MicrosoftTenant = deployArgs.MicrosoftAuthTenant
 	}
+	if deployArgs.OIDCAuthIsSet {
+		conf.OIDCAuth = deployArgs.OIDCAuth()
+	}
+	if deployArgs.LDAPAuthIsSet {
+		conf.LDAPAuth = deployArgs.LDAPAuth()
+	}
+	if deployArgs.GitLabAuthIsSet {
+		conf.GitLabAuth = deployArgs.GitLabAuth()
+	}
+	if deployArgs.PipelinesIsSet {
+		conf.Pipelines = deployArgs.UserPipelines()
+	}
 	if deployArgs.TagsIsSet {
 		conf.Tags = deployArgs.Tags
 	}
@@ -171,6 +255,9 @@ func applyArgumentsToConfig(conf config.Config, deployArgs *deploy.Args, provide
 	if deployArgs.WorkerTypeIsSet {
 		conf.WorkerType = deployArgs.WorkerType
 	}
+	if deployArgs.InstanceIdentityIsSet && deployArgs.InstanceIdentity {
+		conf.InstanceIdentity = true
+	}
 
 	if deployArgs.EnableGlobalResourcesIsSet {
 		conf.EnableGlobalResources = deployArgs.EnableGlobalResources
@@ -197,6 +284,20 @@ func applyArgumentsToConfig(conf config.Config, deployArgs *deploy.Args, provide
 	return conf, isDomainUpdated, nil
 }
 
+// keepStoredAllowIPs makes a deploy without --allow-ips keep the allowed IPs in the stored config,
+// rather than resetting them as a manual deploy would
+func (client *Client) keepStoredAllowIPs() error {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return fmt.Errorf("error loading config: [%v]", err)
+	}
+	client.deployArgs.AllowIPs = conf.AllowIPsUnformatted
+	if client.deployArgs.AllowIPs == "" {
+		client.deployArgs.AllowIPs = "0.0.0.0/0"
+	}
+	return nil
+}
+
 // Set config fields that are only valid on first deployment
 func applyImmutableArgumentsToConfig(conf config.Config, deployArgs *deploy.Args, provider iaas.Provider) config.Config {
 	if hasCIDRFlagsSet(deployArgs, provider) {
@@ -204,6 +305,7 @@ func applyImmutableArgumentsToConfig(conf config.Config, deployArgs *deploy.Args
 	}
 
 	conf.AvailabilityZone = provider.Zone(deployArgs.Zone, conf.ConcourseWorkerSize)
+	conf.Private = deployArgs.Private
 	return conf
 }
 
@@ -211,6 +313,8 @@ func hasCIDRFlagsSet(deployArgs *deploy.Args, provider iaas.Provider) bool {
 	switch provider.IAAS() {
 	case iaas.AWS:
 		return deployArgs.NetworkCIDRIsSet && deployArgs.PublicCIDRIsSet && deployArgs.PrivateCIDRIsSet
+	case iaas.Azure:
+		return deployArgs.NetworkCIDRIsSet && deployArgs.PublicCIDRIsSet && deployArgs.PrivateCIDRIsSet
 	case iaas.GCP:
 		return deployArgs.PublicCIDRIsSet && deployArgs.PrivateCIDRIsSet
 	default:
@@ -229,6 +333,10 @@ func populateConfigWithDeployArgsCIDRs(conf config.Config, deployArgs *deploy.Ar
 	case iaas.GCP:
 		conf.PublicCIDR = deployArgs.PublicCIDR
 		conf.PrivateCIDR = deployArgs.PrivateCIDR
+	case iaas.Azure:
+		conf.NetworkCIDR = deployArgs.NetworkCIDR
+		conf.PublicCIDR = deployArgs.PublicCIDR
+		conf.PrivateCIDR = deployArgs.PrivateCIDR
 	}
 	return conf
 }
@@ -244,6 +352,10 @@ func populateConfigWithDefaultCIDRs(conf config.Config, provider iaas.Provider)
 	case iaas.GCP:
 		conf.PrivateCIDR = "10.0.1.0/24"
 		conf.PublicCIDR = "10.0.0.0/24"
+	case iaas.Azure:
+		conf.NetworkCIDR = "10.0.0.0/16"
+		conf.PrivateCIDR = "10.0.1.0/24"
+		conf.PublicCIDR = "10.0.0.0/24"
 	}
 	return conf
 }
@@ -0,0 +1,271 @@
+package concourse
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/commands/cost"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/resource"
+	"github.com/ghodss/yaml"
+)
+
+// priceCatalogue is the parsed form of resource.PriceCatalogue
+type priceCatalogue struct {
+	Updated       string     `json:"updated"`
+	HoursPerMonth float64    `json:"hours_per_month"`
+	AWS           iaasPrices `json:"aws"`
+	GCP           iaasPrices `json:"gcp"`
+	Azure         iaasPrices `json:"azure"`
+}
+
+type iaasPrices struct {
+	Region            string             `json:"region"`
+	RegionMultipliers map[string]float64 `json:"region_multipliers"`
+	SpotMultiplier    float64            `json:"spot_multiplier"`
+	Machines          map[string]float64 `json:"machines"`
+	Databases         map[string]float64 `json:"databases"`
+	NATGateway        float64            `json:"nat_gateway"`
+	LoadBalancer      float64            `json:"load_balancer"`
+	Disks             map[string]float64 `json:"disks"`
+}
+
+func loadPriceCatalogue(contents []byte) (priceCatalogue, error) {
+	var c priceCatalogue
+	if err := yaml.Unmarshal(contents, &c); err != nil {
+		return c, fmt.Errorf("error parsing price catalogue: [%v]", err)
+	}
+	return c, nil
+}
+
+func (c priceCatalogue) forIAAS(name iaas.Name) (iaasPrices, error) {
+	switch name {
+	case iaas.AWS:
+		return c.AWS, nil
+	case iaas.GCP:
+		return c.GCP, nil
+	case iaas.Azure:
+		return c.Azure, nil
+	}
+	return iaasPrices{}, fmt.Errorf("IAAS not supported: %s", name)
+}
+
+// disk returns the price per GB-month of a disk type, using the default price for disks whose type is not set
+func (p iaasPrices) disk(diskType string) (float64, bool) {
+	if diskType == "" {
+		diskType = "default"
+	}
+	price, ok := p.Disks[diskType]
+	return price, ok
+}
+
+// CostItem is a line of a cost estimate
+type CostItem struct {
+	Component string
+	Size      string
+	Count     int
+	Monthly   float64
+}
+
+// CostEstimate is an itemised estimate of what a deployment costs to run each month, in USD
+type CostEstimate struct {
+	Deployment string
+	Region     string
+	WhatIf     bool
+	Items      []CostItem
+	Total      float64
+	Notes      []string
+}
+
+// Cost writes an estimate of what the deployment costs to run each month. Sizes set in whatIf
+// replace the stored ones, to price a change before deploying it
+func (client *Client) Cost(whatIf cost.Args) error {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return fmt.Errorf("error loading config: [%v]", err)
+	}
+	conf = applyCostWhatIf(conf, whatIf, client.provider)
+
+	sizing, err := bosh.DeploymentSizing(client.provider.IAAS(), conf)
+	if err != nil {
+		return err
+	}
+
+	catalogue, err := loadPriceCatalogue(resource.PriceCatalogue)
+	if err != nil {
+		return err
+	}
+
+	estimate, err := estimateCost(client.provider.IAAS(), conf, sizing, catalogue)
+	if err != nil {
+		return err
+	}
+	estimate.WhatIf = whatIf.WhatIf()
+	return writeCostEstimate(estimate, client.stdout)
+}
+
+func applyCostWhatIf(conf config.Config, whatIf cost.Args, provider iaas.Provider) config.Config {
+	if whatIf.WorkerCountIsSet {
+		conf.ConcourseWorkerCount = whatIf.WorkerCount
+	}
+	if whatIf.WorkerSizeIsSet {
+		conf.ConcourseWorkerSize = whatIf.WorkerSize
+	}
+	if whatIf.WorkerTypeIsSet {
+		conf.WorkerType = whatIf.WorkerType
+	}
+	if whatIf.WebSizeIsSet {
+		conf.ConcourseWebSize = whatIf.WebSize
+	}
+	if whatIf.WebCountIsSet {
+		conf.ConcourseWebCount = whatIf.WebCount
+	}
+	if whatIf.DBSizeIsSet {
+		conf.RDSInstanceClass = provider.DBType(whatIf.DBSize)
+	}
+	if whatIf.SpotIsSet {
+		conf.VMProvisioningType = config.ConvertSpotBoolToVMProvisioningType(whatIf.Spot)
+	}
+	return conf
+}
+
+func estimateCost(iaasName iaas.Name, conf config.ConfigView, sizing bosh.Sizing, catalogue priceCatalogue) (CostEstimate, error) {
+	prices, err := catalogue.forIAAS(iaasName)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+
+	e := CostEstimate{Deployment: conf.GetDeployment(), Region: conf.GetRegion()}
+	multiplier, ok := prices.RegionMultipliers[conf.GetRegion()]
+	if !ok {
+		multiplier = 1
+		e.Notes = append(e.Notes, fmt.Sprintf("The price catalogue has no prices for %s, so prices for %s are used", conf.GetRegion(), prices.Region))
+	}
+	monthly := func(hourly float64) float64 {
+		return hourly * multiplier * catalogue.HoursPerMonth
+	}
+
+	vmPrice := func(t bosh.VMType) (float64, error) {
+		hourly, ok := prices.Machines[t.MachineType]
+		if !ok {
+			return 0, fmt.Errorf("the price catalogue has no price for %s machine type %s", iaasName, t.MachineType)
+		}
+		if t.Spot {
+			hourly *= prices.SpotMultiplier
+		}
+		return monthly(hourly), nil
+	}
+	diskPrice := func(diskType string, sizeGB float64) (float64, error) {
+		perGB, ok := prices.disk(diskType)
+		if !ok {
+			return 0, fmt.Errorf("the price catalogue has no price for %s disk type %q", iaasName, diskType)
+		}
+		return perGB * sizeGB * multiplier, nil
+	}
+
+	type vm struct {
+		component string
+		vmType    bosh.VMType
+		count     int
+	}
+	vms := []vm{{"BOSH director", sizing.Director, 1}}
+	for _, g := range costedInstanceGroups(conf) {
+		t, ok := sizing.VMTypes[g.vmType]
+		if !ok {
+			return CostEstimate{}, fmt.Errorf("the cloud config has no VM type %s", g.vmType)
+		}
+		vms = append(vms, vm{g.component, t, g.count})
+	}
+
+	for _, v := range vms {
+		price, err := vmPrice(v.vmType)
+		if err != nil {
+			return CostEstimate{}, err
+		}
+		size := v.vmType.MachineType
+		if v.vmType.Spot {
+			size += " (" + spotName(iaasName) + ")"
+		}
+		e.add(CostItem{v.component, size, v.count, price * float64(v.count)})
+	}
+
+	dbHourly, ok := prices.Databases[conf.GetRDSInstanceClass()]
+	if !ok {
+		return CostEstimate{}, fmt.Errorf("the price catalogue has no price for %s database %s", iaasName, conf.GetRDSInstanceClass())
+	}
+	e.add(CostItem{"Database", conf.GetRDSInstanceClass(), 1, monthly(dbHourly)})
+	e.add(CostItem{"NAT gateway", "-", 1, monthly(prices.NATGateway)})
+	if conf.GetConcourseWebCount() > 1 {
+		e.add(CostItem{"Web load balancer", "-", 1, monthly(prices.LoadBalancer)})
+	}
+
+	directorDisk, err := diskPrice(sizing.Director.DiskType, sizing.Director.DiskGB)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	directorPersistentDisk, err := diskPrice(sizing.DirectorPersistentDisk, sizing.DirectorPersistentDiskGB)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	e.add(CostItem{"BOSH director disks", fmt.Sprintf("%.0fGB + %.0fGB", sizing.Director.DiskGB, sizing.DirectorPersistentDiskGB), 1, directorDisk + directorPersistentDisk})
+	for _, v := range vms[1:] {
+		price, err := diskPrice(v.vmType.DiskType, v.vmType.DiskGB)
+		if err != nil {
+			return CostEstimate{}, err
+		}
+		e.add(CostItem{v.component + " disk", fmt.Sprintf("%.0fGB", v.vmType.DiskGB), v.count, price * float64(v.count)})
+	}
+
+	e.Notes = append(e.Notes,
+		fmt.Sprintf("VMs marked %s are priced at %.0f%% of the on-demand price, as their price varies", spotName(iaasName), prices.SpotMultiplier*100),
+		fmt.Sprintf("Data transfer and NAT gateway data processing are not included. Prices are from the catalogue updated %s", catalogue.Updated),
+	)
+	return e, nil
+}
+
+type costedInstanceGroup struct {
+	component string
+	vmType    string
+	count     int
+}
+
+// costedInstanceGroups returns the Concourse instance groups of conf with their cloud config VM types
+func costedInstanceGroups(conf config.ConfigView) []costedInstanceGroup {
+	groups := []costedInstanceGroup{
+		{"Web", "concourse-web-" + conf.GetConcourseWebSize(), conf.GetConcourseWebCount()},
+		{"Worker", "concourse-" + conf.GetConcourseWorkerSize(), conf.GetConcourseWorkerCount()},
+	}
+	for _, pool := range conf.GetWorkerPools() {
+		groups = append(groups, costedInstanceGroup{"Worker pool " + pool.Name, pool.VMType(), pool.Count})
+	}
+	return groups
+}
+
+func (e *CostEstimate) add(item CostItem) {
+	e.Items = append(e.Items, item)
+	e.Total += item.Monthly
+}
+
+func spotName(iaasName iaas.Name) string {
+	if iaasName == iaas.GCP {
+		return "preemptible"
+	}
+	return "spot"
+}
+
+const costTemplate = `{{if .WhatIf}}WHAT IF: {{end}}Estimated monthly cost of {{.Deployment}} in {{.Region}} (USD):
+
+{{printf "%-28s %-32s %5s %10s" "Component" "Size" "Count" "Price"}}
+{{range .Items}}{{printf "%-28s %-32s %5d %10.2f" .Component .Size .Count .Monthly}}
+{{end}}{{printf "%-28s %-32s %5s %10.2f" "Total" "" "" .Total}}
+{{range .Notes}}
+* {{.}}{{end}}
+`
+
+func writeCostEstimate(e CostEstimate, stdout io.Writer) error {
+	t := template.Must(template.New("cost").Parse(costTemplate))
+	return t.Execute(stdout, e)
+}
@@ -0,0 +1,206 @@
+package concourse
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/commands/cost"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/config/configfakes"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/iaas/iaasfakes"
+	"github.com/EngineerBetter/control-tower/resource"
+)
+
+func TestPriceCatalogue_PricesEverySize(t *testing.T) {
+	catalogue, err := loadPriceCatalogue(resource.PriceCatalogue)
+	if err != nil {
+		t.Fatalf("loadPriceCatalogue() error = %v", err)
+	}
+
+	tests := []struct {
+		iaas        iaas.Name
+		workerTypes []string
+		dbSizes     map[string]string
+	}{
+		{iaas.AWS, []string{"m4", "m5", "m5a"}, iaas.AWSDBSizes},
+		{iaas.GCP, []string{""}, iaas.GCPDBSizes},
+		{iaas.Azure, []string{""}, iaas.AzureDBSizes},
+	}
+	for _, tt := range tests {
+		prices, err := catalogue.forIAAS(tt.iaas)
+		if err != nil {
+			t.Fatalf("priceCatalogue.forIAAS() error = %v", err)
+		}
+		if _, ok := prices.RegionMultipliers[prices.Region]; !ok {
+			t.Errorf("%s prices have no multiplier for their own region %s", tt.iaas, prices.Region)
+		}
+		for _, workerType := range tt.workerTypes {
+			sizing, err := bosh.DeploymentSizing(tt.iaas, config.Config{WorkerType: workerType, VMProvisioningType: config.SPOT})
+			if err != nil {
+				t.Fatalf("bosh.DeploymentSizing() error = %v", err)
+			}
+			vmTypes := []bosh.VMType{sizing.Director}
+			for _, vmType := range sizing.VMTypes {
+				vmTypes = append(vmTypes, vmType)
+			}
+			for _, vmType := range vmTypes {
+				if _, ok := prices.Machines[vmType.MachineType]; !ok {
+					t.Errorf("%s prices have no price for machine type %s", tt.iaas, vmType.MachineType)
+				}
+				if _, ok := prices.disk(vmType.DiskType); !ok && vmType.DiskGB > 0 {
+					t.Errorf("%s prices have no price for disk type %q", tt.iaas, vmType.DiskType)
+				}
+			}
+			if _, ok := prices.disk(sizing.DirectorPersistentDisk); !ok {
+				t.Errorf("%s prices have no price for disk type %q", tt.iaas, sizing.DirectorPersistentDisk)
+			}
+		}
+		for _, class := range tt.dbSizes {
+			if _, ok := prices.Databases[class]; !ok {
+				t.Errorf("%s prices have no price for database %s", tt.iaas, class)
+			}
+		}
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	catalogue := priceCatalogue{
+		Updated:       "2020-01",
+		HoursPerMonth: 100,
+		AWS: iaasPrices{
+			Region:            "eu-west-1",
+			RegionMultipliers: map[string]float64{"eu-west-1": 1, "us-east-1": 0.5},
+			SpotMultiplier:    0.5,
+			Machines:          map[string]float64{"small": 0.1, "big": 1},
+			Databases:         map[string]float64{"db.small": 0.2},
+			NATGateway:        0.05,
+			LoadBalancer:      0.02,
+			Disks:             map[string]float64{"gp2": 0.1},
+		},
+	}
+	sizing := bosh.Sizing{
+		Director:                 bosh.VMType{MachineType: "small", DiskGB: 10, DiskType: "gp2"},
+		DirectorPersistentDiskGB: 20,
+		DirectorPersistentDisk:   "gp2",
+		VMTypes: map[string]bosh.VMType{
+			"concourse-web-small":       {MachineType: "small", DiskGB: 10, DiskType: "gp2"},
+			"concourse-xlarge":          {MachineType: "big", Spot: true, DiskGB: 100, DiskType: "gp2"},
+			"concourse-large-spot":      {MachineType: "big", Spot: true, DiskGB: 100, DiskType: "gp2"},
+			"concourse-large-on-demand": {MachineType: "big", DiskGB: 100, DiskType: "gp2"},
+		},
+	}
+	conf := config.Config{
+		Deployment:           "control-tower-ci",
+		Region:               "eu-west-1",
+		ConcourseWebSize:     "small",
+		ConcourseWorkerSize:  "xlarge",
+		ConcourseWorkerCount: 2,
+		RDSInstanceClass:     "db.small",
+		WorkerPools:          []config.WorkerPool{{Name: "heavy", Count: 3, Size: "large", VMProvisioningType: config.ON_DEMAND}},
+	}
+
+	got, err := estimateCost(iaas.AWS, conf, sizing, catalogue)
+	if err != nil {
+		t.Fatalf("estimateCost() error = %v", err)
+	}
+	want := []CostItem{
+		{"BOSH director", "small", 1, 10},
+		{"Web", "small", 1, 10},
+		{"Worker", "big (spot)", 2, 100},
+		{"Worker pool heavy", "big", 3, 300},
+		{"Database", "db.small", 1, 20},
+		{"NAT gateway", "-", 1, 5},
+		{"BOSH director disks", "10GB + 20GB", 1, 3},
+		{"Web disk", "10GB", 1, 1},
+		{"Worker disk", "100GB", 2, 20},
+		{"Worker pool heavy disk", "100GB", 3, 30},
+	}
+	if len(got.Items) != len(want) {
+		t.Fatalf("estimateCost() got items %+v, want %+v", got.Items, want)
+	}
+	for i := range want {
+		if got.Items[i].Component != want[i].Component || got.Items[i].Size != want[i].Size || got.Items[i].Count != want[i].Count || math.Abs(got.Items[i].Monthly-want[i].Monthly) > 0.001 {
+			t.Errorf("estimateCost() item %d = %+v, want %+v", i, got.Items[i], want[i])
+		}
+	}
+	if math.Abs(got.Total-499) > 0.001 {
+		t.Errorf("estimateCost() total = %v, want 499", got.Total)
+	}
+
+	conf.Region = "us-east-1"
+	got, err = estimateCost(iaas.AWS, conf, sizing, catalogue)
+	if err != nil {
+		t.Fatalf("estimateCost() error = %v", err)
+	}
+	if math.Abs(got.Total-249.5) > 0.001 {
+		t.Errorf("estimateCost() total in us-east-1 = %v, want 249.5", got.Total)
+	}
+
+	conf.Region = "ap-east-1"
+	got, err = estimateCost(iaas.AWS, conf, sizing, catalogue)
+	if err != nil {
+		t.Fatalf("estimateCost() error = %v", err)
+	}
+	if math.Abs(got.Total-499) > 0.001 || !strings.Contains(got.Notes[0], "no prices for ap-east-1, so prices for eu-west-1 are used") {
+		t.Errorf("estimateCost() in an unknown region got total %v and notes %v", got.Total, got.Notes)
+	}
+
+	conf.Region = "eu-west-1"
+	conf.ConcourseWebCount = 3
+	got, err = estimateCost(iaas.AWS, conf, sizing, catalogue)
+	if err != nil {
+		t.Fatalf("estimateCost() error = %v", err)
+	}
+	if got.Items[1].Count != 3 || got.Items[6].Component != "Web load balancer" || math.Abs(got.Items[6].Monthly-2) > 0.001 || math.Abs(got.Total-523) > 0.001 {
+		t.Errorf("estimateCost() with 3 web nodes got items %+v and total %v", got.Items, got.Total)
+	}
+
+	conf.RDSInstanceClass = "db.huge"
+	if _, err = estimateCost(iaas.AWS, conf, sizing, catalogue); err == nil || err.Error() != "the price catalogue has no price for AWS database db.huge" {
+		t.Errorf("estimateCost() error = %v, want no price for database", err)
+	}
+}
+
+func TestClient_Cost(t *testing.T) {
+	configClient := &configfakes.FakeIClient{}
+	configClient.LoadReturns(config.Config{
+		Deployment:           "control-tower-ci",
+		Region:               "eu-west-1",
+		ConcourseWebSize:     "small",
+		ConcourseWorkerSize:  "xlarge",
+		ConcourseWorkerCount: 1,
+		RDSInstanceClass:     "db.t3.small",
+		VMProvisioningType:   config.SPOT,
+		WorkerType:           "m4",
+	}, nil)
+	provider := &iaasfakes.FakeProvider{}
+	provider.IAASReturns(iaas.AWS)
+	provider.DBTypeStub = func(size string) string {
+		return iaas.AWSDBSizes[size]
+	}
+
+	var stdout bytes.Buffer
+	client := &Client{configClient: configClient, provider: provider, stdout: &stdout}
+	err := client.Cost(cost.Args{WorkerCount: 6, WorkerCountIsSet: true, WorkerSize: "4xlarge", WorkerSizeIsSet: true, DBSize: "medium", DBSizeIsSet: true})
+	if err != nil {
+		t.Fatalf("Client.Cost() error = %v", err)
+	}
+	for _, want := range []string{
+		"WHAT IF: Estimated monthly cost of control-tower-ci in eu-west-1 (USD)",
+		"BOSH director                t3.small",
+		"Worker                       m4.4xlarge (spot)                    6",
+		"Database                     db.t3.medium",
+		"Total",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Client.Cost() wrote\n%s\nwant it to contain %q", stdout.String(), want)
+		}
+	}
+	if configClient.LockCallCount() != 0 || configClient.UpdateCallCount() != 0 {
+		t.Errorf("Client.Cost() changed the deployment")
+	}
+}
@@ -16,6 +16,8 @@ import (
 	"github.com/EngineerBetter/control-tower/bosh"
 	"github.com/EngineerBetter/control-tower/certs"
 	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/credhub"
+	"github.com/EngineerBetter/control-tower/events"
 	"github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/terraform"
 	"github.com/go-acme/lego/v4/lego"
@@ -49,39 +51,72 @@ func stripVersion(tags []string) []string {
 
 // Deploy deploys a concourse instance
 func (client *Client) Deploy() error {
+	// A dry run must not create the config bucket of a new deployment
+	if client.deployArgs.DryRun {
+		return client.plan()
+	}
+
 	err := client.configClient.EnsureBucketExists()
 	if err != nil {
 		return fmt.Errorf("error ensuring config bucket exists before deploy: [%v]", err)
 	}
 
-	conf, isDomainUpdated, err := client.getInitialConfig()
-	if err != nil {
-		return fmt.Errorf("error getting initial config before deploy: [%v]", err)
-	}
+	return client.withLock("deploy", func() error {
+		return client.deploy("deploy")
+	})
+}
 
-	r, err := client.checkPreTerraformConfigRequirements(conf, client.deployArgs.SelfUpdate)
-	if err != nil {
-		return err
-	}
-	conf.Region = r.Region
-	conf.SourceAccessIP = r.SourceAccessIP
-	conf.HostedZoneID = r.HostedZoneID
-	conf.HostedZoneRecordPrefix = r.HostedZoneRecordPrefix
-	conf.Domain = r.Domain
+// deploy applies the stored config and arguments, recording a revision named after operation on success
+func (client *Client) deploy(operation string) error {
+	var conf config.Config
+	var isDomainUpdated bool
+	err := client.events.Phase(events.ConfigLoad, func(resources events.Resources) error {
+		err := client.configClient.EncryptPlaintextAssets(bosh.StateFilename, bosh.CredsFilename, directorCredsBackupFilename)
+		if err != nil {
+			return fmt.Errorf("error encrypting existing assets before deploy: [%v]", err)
+		}
 
-	tfInputVars := client.tfInputVarsFactory.NewInputVars(conf)
+		conf, isDomainUpdated, err = client.getInitialConfig()
+		if err != nil {
+			return fmt.Errorf("error getting initial config before deploy: [%v]", err)
+		}
+		resources["deployment"] = conf.Deployment
 
-	err = client.tfCLI.Apply(tfInputVars)
+		r, err := client.checkPreTerraformConfigRequirements(conf, client.deployArgs.SelfUpdate)
+		if err != nil {
+			return err
+		}
+		conf.Region = r.Region
+		conf.SourceAccessIP = r.SourceAccessIP
+		conf.HostedZoneID = r.HostedZoneID
+		conf.HostedZoneRecordPrefix = r.HostedZoneRecordPrefix
+		conf.Domain = r.Domain
+		resources["region"] = conf.Region
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	tfOutputs, err := client.tfCLI.BuildOutput(tfInputVars)
-	if err != nil {
-		return err
-	}
+	var tfOutputs terraform.Outputs
+	err = client.events.Phase(events.TerraformApply, func(resources events.Resources) error {
+		tfInputVars := client.tfInputVarsFactory.NewInputVars(conf)
 
-	err = client.configClient.Update(conf)
+		err := client.tfCLI.Apply(tfInputVars)
+		if err != nil {
+			return err
+		}
+
+		tfOutputs, err = client.tfCLI.BuildOutput(tfInputVars)
+		if err != nil {
+			return err
+		}
+		if ip, err := tfOutputs.Get("DirectorPublicIP"); err == nil {
+			resources["director_ip"] = ip
+		}
+
+		return client.configClient.Update(conf)
+	})
 	if err != nil {
 		return err
 	}
@@ -90,7 +125,13 @@ func (client *Client) Deploy() error {
 
 	conf.Version = client.version
 
-	cr, err := client.checkPreDeployConfigRequirements(client.acmeClientConstructor, isDomainUpdated, conf, tfOutputs)
+	var cr Requirements
+	err = client.events.Phase(events.CertGeneration, func(resources events.Resources) error {
+		var err error
+		cr, err = client.checkPreDeployConfigRequirements(client.acmeClientConstructor, isDomainUpdated, conf, tfOutputs)
+		resources["domain"] = cr.Domain
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -127,7 +168,21 @@ func (client *Client) Deploy() error {
 	if err == nil {
 		err = err1
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	if conf.LegacyAccessKeys && !client.deployArgs.SelfUpdate {
+		if err = client.removeLegacyAccessKeys(conf); err != nil {
+			return err
+		}
+	}
+
+	_, err = client.configClient.RecordRevision(operation, bosh.StateFilename, bosh.CredsFilename)
+	if err != nil {
+		return fmt.Errorf("error recording revision after %s: [%v]", operation, err)
+	}
+	return nil
 }
 
 func (client *Client) deployBoshAndPipeline(c config.ConfigView, tfOutputs terraform.Outputs) (BoshParams, error) {
@@ -154,11 +209,21 @@ func (client *Client) deployBoshAndPipeline(c config.ConfigView, tfOutputs terra
 		return bp, err
 	}
 
+	if pause := c.GetPause(); pause != nil && pause.Hibernate {
+		_, err = client.stdout.Write([]byte("\nHIBERNATING, the pipeline will be set by control-tower resume\n\n"))
+		return bp, err
+	}
+
 	flyClient, err := client.flyClientFactory(client.provider, fly.Credentials{
 		Target:   c.GetDeployment(),
 		API:      fmt.Sprintf("https://%s", c.GetDomain()),
 		Username: bp.ConcourseUsername,
 		Password: bp.ConcoursePassword,
+		CredHub: credhub.Credentials{
+			URL:          bp.CredhubURL,
+			CACert:       bp.CredhubCACert,
+			ClientSecret: bp.CredhubAdminClientSecret,
+		},
 	},
 		client.stdout,
 		client.stderr,
@@ -169,7 +234,13 @@ func (client *Client) deployBoshAndPipeline(c config.ConfigView, tfOutputs terra
 	}
 	defer flyClient.Cleanup()
 
-	if err := flyClient.SetDefaultPipeline(c, false); err != nil {
+	if err := client.setPipeline(flyClient, c, false); err != nil {
+		return bp, err
+	}
+	if err := client.setTeams(flyClient, c); err != nil {
+		return bp, err
+	}
+	if err := client.setUserPipelines(flyClient, c); err != nil {
 		return bp, err
 	}
 
@@ -211,6 +282,11 @@ func (client *Client) updateBoshAndPipeline(c config.ConfigView, tfOutputs terra
 		API:      fmt.Sprintf("https://%s", c.GetDomain()),
 		Username: c.GetConcourseUsername(),
 		Password: c.GetConcoursePassword(),
+		CredHub: credhub.Credentials{
+			URL:          c.GetCredhubURL(),
+			CACert:       c.GetCredhubCACert(),
+			ClientSecret: c.GetCredhubAdminClientSecret(),
+		},
 	},
 		client.stdout,
 		client.stderr,
@@ -231,7 +307,13 @@ func (client *Client) updateBoshAndPipeline(c config.ConfigView, tfOutputs terra
 	}
 
 	// Allow a fly version discrepancy since we might be targetting an older Concourse
-	if err = flyClient.SetDefaultPipeline(c, true); err != nil {
+	if err = client.setPipeline(flyClient, c, true); err != nil {
+		return bp, err
+	}
+	if err = client.setTeams(flyClient, c); err != nil {
+		return bp, err
+	}
+	if err = client.setUserPipelines(flyClient, c); err != nil {
 		return bp, err
 	}
 
@@ -245,6 +327,14 @@ func (client *Client) updateBoshAndPipeline(c config.ConfigView, tfOutputs terra
 	return bp, err
 }
 
+func (client *Client) setPipeline(flyClient fly.IClient, c config.ConfigView, allowFlyVersionDiscrepancy bool) error {
+	return client.events.Phase(events.SetPipeline, func(resources events.Resources) error {
+		resources["target"] = c.GetDeployment()
+		resources["pipeline"] = fly.SelfUpdatePipelineName
+		return flyClient.SetDefaultPipeline(c, allowFlyVersionDiscrepancy)
+	})
+}
+
 // TerraformRequirements represents the required values for running terraform
 type TerraformRequirements struct {
 	Region                 string
@@ -272,8 +362,9 @@ func (client *Client) checkPreTerraformConfigRequirements(conf config.ConfigView
 
 	r.Region = region
 
-	// When in self-update mode do not override the user IP, since we already have access to the worker
-	if !selfUpdate {
+	// When in self-update mode do not override the user IP, since we already have access to the worker.
+	// Private deployments are reached from within the network, so have no use for it either
+	if !selfUpdate && !conf.IsPrivate() {
 		var err error
 		r.SourceAccessIP, err = client.setUserIP(conf)
 		if err != nil {
@@ -402,16 +493,41 @@ func (client *Client) ensureDirectorCerts(c func(u *certs.User) (*lego.Client, e
 	return certs, nil
 }
 
+// concourseCertRenewalPeriod is how close to expiry a Concourse certificate is renewed
+const concourseCertRenewalPeriod = 28 * 24 * time.Hour
+
 func timeTillExpiry(cert string) time.Duration {
+	notAfter, ok := certNotAfter(cert)
+	if !ok {
+		return 0
+	}
+	return time.Until(notAfter)
+}
+
+// certCoversDomain returns true if a PEM encoded certificate is valid for domain, which is a hostname or an IP
+func certCoversDomain(cert, domain string) bool {
 	block, _ := pem.Decode([]byte(cert))
 	if block == nil {
-		return 0
+		return false
 	}
 	c, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
-		return 0
+		return false
 	}
-	return time.Until(c.NotAfter)
+	return c.VerifyHostname(domain) == nil
+}
+
+// certNotAfter returns when a PEM encoded certificate expires, or false if it cannot be parsed
+func certNotAfter(cert string) (time.Time, bool) {
+	block, _ := pem.Decode([]byte(cert))
+	if block == nil {
+		return time.Time{}, false
+	}
+	c, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return c.NotAfter, true
 }
 
 func (client *Client) ensureConcourseCerts(c func(u *certs.User) (*lego.Client, error), domainUpdated bool, cc Certs, deployment, domain string) (Certs, error) {
@@ -423,9 +539,9 @@ func (client *Client) ensureConcourseCerts(c func(u *certs.User) (*lego.Client,
 		return certs, nil
 	}
 
-	// Skip concourse re-deploy if certs have already been set,
-	// unless domain has changed
-	if certs.ConcourseCert != "" && !domainUpdated && timeTillExpiry(certs.ConcourseCert) > 28*24*time.Hour {
+	// Skip concourse re-deploy if certs have already been set, unless domain has changed or the
+	// cert no longer covers it, as happens when web VMs move behind a load balancer with its own IP
+	if certs.ConcourseCert != "" && !domainUpdated && certCoversDomain(certs.ConcourseCert, domain) && timeTillExpiry(certs.ConcourseCert) > concourseCertRenewalPeriod {
 		return certs, nil
 	}
 
@@ -471,6 +587,10 @@ func (client *Client) deployBosh(config config.ConfigView, tfOutputs terraform.O
 	if err != nil {
 		return bp, err
 	}
+	boshCredsBytes, err = withAuthSecrets(boshCredsBytes, client.deployArgs)
+	if err != nil {
+		return bp, err
+	}
 
 	boshStateBytes, boshCredsBytes, err = boshClient.Deploy(boshStateBytes, boshCredsBytes, detach)
 	err1 := client.configClient.StoreAsset(bosh.StateFilename, boshStateBytes)
@@ -549,6 +669,17 @@ func (client *Client) setHostedZone(c config.ConfigView, domain string) (HostedZ
 		return zone, nil
 	}
 
+	// A private deployment keeps the record of its private IP in a hosted zone of its own that only
+	// its VPC can see, rather than publishing it in the public zone of the domain
+	if c.IsPrivate() {
+		zone.HostedZoneID = ""
+		zone.HostedZoneRecordPrefix = ""
+		zone.Domain = domain
+		_, err := client.stderr.Write([]byte(fmt.Sprintf(
+			"\nWARNING: adding record %s to a private DNS zone that can only be resolved from within the VPC\n\n", domain)))
+		return zone, err
+	}
+
 	hostedZoneName, hostedZoneID, err := client.provider.FindLongestMatchingHostedZone(domain)
 	if err != nil {
 		return zone, err
@@ -628,3 +759,16 @@ func loadDirectorCreds(configClient config.IClient) ([]byte, error) {
 
 	return configClient.LoadAsset(bosh.CredsFilename)
 }
+
+// removeLegacyAccessKeys deletes the access keys a deployment used before it switched to instance
+// identity, once its director and VMs have been recreated without them
+func (client *Client) removeLegacyAccessKeys(conf config.Config) error {
+	conf.LegacyAccessKeys = false
+	err := client.events.Phase(events.TerraformApply, func(events.Resources) error {
+		return client.tfCLI.Apply(client.tfInputVarsFactory.NewInputVars(conf))
+	})
+	if err != nil {
+		return fmt.Errorf("error removing access keys replaced by instance identity: [%v]", err)
+	}
+	return client.configClient.Update(conf)
+}
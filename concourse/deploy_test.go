@@ -0,0 +1,28 @@
+package concourse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCertCoversDomain(t *testing.T) {
+	expiry := time.Now().Add(24 * time.Hour)
+	tests := []struct {
+		name   string
+		cert   string
+		domain string
+		want   bool
+	}{
+		{name: "same IP", cert: selfSignedCert(t, expiry, "99.99.99.99"), domain: "99.99.99.99", want: true},
+		{name: "IP of the load balancer", cert: selfSignedCert(t, expiry, "99.99.99.99"), domain: "88.88.88.88", want: false},
+		{name: "domain", cert: selfSignedCert(t, expiry, "ci.example.com"), domain: "ci.example.com", want: true},
+		{name: "unparseable", cert: "----EXAMPLE CERT----", domain: "ci.example.com", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := certCoversDomain(tt.cert, tt.domain); got != tt.want {
+				t.Errorf("certCoversDomain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,7 +9,10 @@ import (
 
 // Destroy destroys a concourse instance
 func (client *Client) Destroy() error {
+	return client.withLock("destroy", client.destroy)
+}
 
+func (client *Client) destroy() error {
 	conf, err := client.configClient.Load()
 	if err != nil {
 		return err
@@ -45,6 +48,12 @@ func (client *Client) Destroy() error {
 		if err1 != nil {
 			return err1
 		}
+
+	case iaas.Azure:
+		err1 := client.provider.DeleteVMsInDeployment(client.provider.Zone("", ""), "", conf.GetDeployment())
+		if err1 != nil {
+			return err1
+		}
 	}
 
 	err = client.tfCLI.Destroy(tfInputVars)
@@ -0,0 +1,89 @@
+package concourse
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/util/yaml"
+)
+
+// History writes every recorded revision, newest first, with the config changes it made
+func (client *Client) History() error {
+	revisions, err := client.configClient.Revisions()
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		_, err = fmt.Fprintln(client.stdout, "No revisions have been recorded yet. A revision is recorded after every successful deploy.")
+		return err
+	}
+
+	var previous []byte
+	changes := make([][]string, len(revisions))
+	for i, revision := range revisions {
+		current, err := client.configClient.LoadRevision(revision.Number, config.ConfigFilename)
+		if err != nil {
+			return fmt.Errorf("error loading config from revision %d: [%v]", revision.Number, err)
+		}
+		if previous != nil {
+			changes[i], err = yaml.Diff(previous, current)
+			if err != nil {
+				return fmt.Errorf("error comparing revisions %d and %d: [%v]", revisions[i-1].Number, revision.Number, err)
+			}
+		}
+		previous = current
+	}
+
+	for i := len(revisions) - 1; i >= 0; i-- {
+		writeRevision(client.stdout, revisions[i], changes[i], i == 0)
+	}
+	return nil
+}
+
+func writeRevision(stdout io.Writer, r config.Revision, changes []string, first bool) {
+	fmt.Fprintf(stdout, "REVISION %d\t%s\tcontrol-tower %s\t%s\n", r.Number, r.CreatedAt.Format(time.RFC3339), r.Version, r.Operation)
+	fmt.Fprintf(stdout, "  files: %s\n", strings.Join(r.Files, ", "))
+	switch {
+	case first:
+		fmt.Fprintln(stdout, "  first recorded revision")
+	case len(changes) == 0:
+		fmt.Fprintln(stdout, "  no config changes")
+	default:
+		for _, change := range changes {
+			fmt.Fprintf(stdout, "  %s\n", change)
+		}
+	}
+	fmt.Fprintln(stdout)
+}
+
+// Rollback restores the config recorded at revision, and optionally the director state, then redeploys
+// it. The client's deploy args should be the revision's StoredArgs, so that it is deployed as recorded.
+func (client *Client) Rollback(revision int, restoreDirectorState bool) error {
+	revisions, err := client.configClient.Revisions()
+	if err != nil {
+		return err
+	}
+	for _, r := range revisions {
+		if r.Number == revision && r.Version != client.version {
+			fmt.Fprintf(client.stderr, "WARNING: revision %d was deployed by control-tower %s, but this is %s. "+
+				"Its config will be deployed with the Concourse version bundled in %s.\n", revision, r.Version, client.version, client.version)
+		}
+	}
+
+	return client.withLock("rollback", func() error {
+		var filenames []string
+		if restoreDirectorState {
+			filenames = []string{bosh.StateFilename, bosh.CredsFilename}
+		}
+		if err := client.configClient.RestoreRevision(revision, filenames...); err != nil {
+			return fmt.Errorf("error restoring revision %d: [%v]", revision, err)
+		}
+		fmt.Fprintf(client.stdout, "\nRESTORED REVISION %d, REDEPLOYING\n\n", revision)
+
+		return client.deploy(fmt.Sprintf("rollback to %d", revision))
+	})
+}
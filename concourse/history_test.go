@@ -0,0 +1,151 @@
+package concourse
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/config/configfakes"
+)
+
+func TestClient_History(t *testing.T) {
+	createdAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	configs := map[int]string{
+		1: `{"version":"0.1.0","worker_count":1}`,
+		2: `{"version":"0.2.0","worker_count":1}`,
+		3: `{"version":"0.2.0","worker_count":3}`,
+	}
+
+	tests := []struct {
+		name      string
+		revisions []config.Revision
+		want      []string
+	}{
+		{
+			name: "no revisions",
+			want: []string{"No revisions have been recorded yet"},
+		},
+		{
+			name: "several revisions",
+			revisions: []config.Revision{
+				{Number: 1, CreatedAt: createdAt, Version: "0.1.0", Operation: "deploy", Files: []string{"config.json"}},
+				{Number: 2, CreatedAt: createdAt, Version: "0.2.0", Operation: "deploy", Files: []string{"config.json", "director-state.json"}},
+				{Number: 3, CreatedAt: createdAt, Version: "0.2.0", Operation: "rollback to 1", Files: []string{"config.json"}},
+			},
+			want: []string{
+				"REVISION 3\t2020-01-02T03:04:05Z\tcontrol-tower 0.2.0\trollback to 1\n  files: config.json\n  ~ /worker_count: 1 -> 3\n",
+				"REVISION 2\t2020-01-02T03:04:05Z\tcontrol-tower 0.2.0\tdeploy\n  files: config.json, director-state.json\n  ~ /version: 0.1.0 -> 0.2.0\n",
+				"REVISION 1\t2020-01-02T03:04:05Z\tcontrol-tower 0.1.0\tdeploy\n  files: config.json\n  first recorded revision\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configClient := &configfakes.FakeIClient{}
+			configClient.RevisionsReturns(tt.revisions, nil)
+			configClient.LoadRevisionStub = func(number int, filename string) ([]byte, error) {
+				return []byte(configs[number]), nil
+			}
+
+			stdout := &bytes.Buffer{}
+			client := &Client{configClient: configClient, stdout: stdout}
+			if err := client.History(); err != nil {
+				t.Fatalf("Client.History() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !bytes.Contains(stdout.Bytes(), []byte(want)) {
+					t.Errorf("Client.History() output = %q, want it to contain %q", stdout.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Rollback_RestoreFails(t *testing.T) {
+	tests := []struct {
+		name                 string
+		restoreDirectorState bool
+		wantFiles            []string
+		wantWarning          bool
+	}{
+		{
+			name: "config only",
+		},
+		{
+			name:                 "with director state",
+			restoreDirectorState: true,
+			wantFiles:            []string{"director-state.json", "director-creds.yml"},
+			wantWarning:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configClient := &configfakes.FakeIClient{}
+			version := "0.2.0"
+			if tt.wantWarning {
+				version = "0.3.0"
+			}
+			configClient.RevisionsReturns([]config.Revision{{Number: 1, Version: "0.2.0"}}, nil)
+			configClient.RestoreRevisionReturns(errors.New("boom"))
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			client := &Client{configClient: configClient, stdout: stdout, stderr: stderr, version: version}
+			err := client.Rollback(1, tt.restoreDirectorState)
+			if err == nil || err.Error() != "error restoring revision 1: [boom]" {
+				t.Fatalf("Client.Rollback() error = %v", err)
+			}
+
+			if configClient.LockCallCount() != 1 || configClient.LockArgsForCall(0) != "rollback" {
+				t.Errorf("Client.Rollback() did not lock the deployment for rollback")
+			}
+			if configClient.UnlockCallCount() != 1 {
+				t.Errorf("Client.Rollback() did not unlock the deployment")
+			}
+			number, files := configClient.RestoreRevisionArgsForCall(0)
+			if number != 1 || len(files) != len(tt.wantFiles) {
+				t.Errorf("Client.Rollback() restored revision %d with files %v, want %v", number, files, tt.wantFiles)
+			}
+			if configClient.UpdateCallCount() != 0 {
+				t.Errorf("Client.Rollback() deployed after a failed restore")
+			}
+			if gotWarning := stderr.Len() > 0; gotWarning != tt.wantWarning {
+				t.Errorf("Client.Rollback() warning = %q, want warning %v", stderr.String(), tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestClient_keepStoredAllowIPs(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   string
+		expected string
+	}{
+		{
+			name:     "stored allowed IPs",
+			stored:   "10.0.0.0/8, 192.0.2.1",
+			expected: "10.0.0.0/8, 192.0.2.1",
+		},
+		{
+			name:     "config from before allowed IPs were stored",
+			expected: "0.0.0.0/0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configClient := &configfakes.FakeIClient{}
+			configClient.LoadReturns(config.Config{AllowIPsUnformatted: tt.stored}, nil)
+
+			client := &Client{configClient: configClient, deployArgs: &deploy.Args{}}
+			if err := client.keepStoredAllowIPs(); err != nil {
+				t.Fatalf("Client.keepStoredAllowIPs() error = %v", err)
+			}
+			if client.deployArgs.AllowIPs != tt.expected {
+				t.Errorf("Client.keepStoredAllowIPs() set AllowIPs to %q, want %q", client.deployArgs.AllowIPs, tt.expected)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/EngineerBetter/control-tower/iaas"
 
@@ -20,11 +21,12 @@ import (
 
 // Info represents the compound fields for info templates
 type Info struct {
-	Terraform   TerraformInfo   `json:"terraform"`
-	Config      config.Config   `json:"config"`
-	Instances   []bosh.Instance `json:"instances"`
-	CertExpiry  string          `json:"cert_expiry"`
-	GatewayUser string
+	Terraform          TerraformInfo   `json:"terraform"`
+	Config             config.Config   `json:"config"`
+	Instances          []bosh.Instance `json:"instances"`
+	CertExpiry         string          `json:"cert_expiry"`
+	DirectorCertExpiry string          `json:"director_cert_expiry"`
+	GatewayUser        string
 }
 
 // TerraformInfo represents the terraform output fields needed for the info templates
@@ -70,6 +72,12 @@ func (client *Client) FetchInfo() (*Info, error) {
 		}
 	}
 
+	var directorCertExpiry string
+	directorCertNotAfter, directorCertParsed := directorCertsNotAfter(conf)
+	if directorCertParsed {
+		directorCertExpiry = directorCertNotAfter.UTC().Format(opensslDateFormat)
+	}
+
 	tfInputVars := client.tfInputVarsFactory.NewInputVars(conf)
 
 	switch client.provider.IAAS() {
@@ -77,6 +85,8 @@ func (client *Client) FetchInfo() (*Info, error) {
 		gatewayUser = "vcap"
 	case iaas.GCP:
 		gatewayUser = "jumpbox"
+	case iaas.Azure:
+		gatewayUser = "vcap"
 	}
 
 	tfOutputs, err := client.tfCLI.BuildOutput(tfInputVars)
@@ -99,23 +109,26 @@ func (client *Client) FetchInfo() (*Info, error) {
 		NatGatewayIP:     natGatewayIP,
 	}
 
-	userIP, err1 := client.ipChecker()
-	if err1 != nil {
-		return nil, err1
-	}
+	// The director of a private deployment is reached through the network rather than by whitelisting the user's IP
+	if !conf.IsPrivate() {
+		userIP, err1 := client.ipChecker()
+		if err1 != nil {
+			return nil, err1
+		}
 
-	directorSecurityGroupID, err1 := tfOutputs.Get("DirectorSecurityGroupID")
-	if err1 != nil {
-		return nil, err1
-	}
-	whitelisted, err1 := client.provider.CheckForWhitelistedIP(userIP, directorSecurityGroupID)
-	if err1 != nil {
-		return nil, err1
-	}
+		directorSecurityGroupID, err1 := tfOutputs.Get("DirectorSecurityGroupID")
+		if err1 != nil {
+			return nil, err1
+		}
+		whitelisted, err1 := client.provider.CheckForWhitelistedIP(userIP, directorSecurityGroupID)
+		if err1 != nil {
+			return nil, err1
+		}
 
-	if !whitelisted {
-		err1 = fmt.Errorf("Do you need to add your IP %s to the %s-director security group/source range entry for director firewall (for ports 22, 6868, and 25555)?", userIP, conf.Deployment)
-		return nil, err1
+		if !whitelisted {
+			err1 = fmt.Errorf("Do you need to add your IP %s to the %s-director security group/source range entry for director firewall (for ports 22, 6868, and 25555)?", userIP, conf.Deployment)
+			return nil, err1
+		}
 	}
 
 	boshClient, err := client.buildBoshClient(conf, tfOutputs)
@@ -126,28 +139,63 @@ func (client *Client) FetchInfo() (*Info, error) {
 
 	instances, err := boshClient.Instances()
 	if err != nil {
+		if directorCertParsed && time.Now().After(directorCertNotAfter) {
+			return nil, fmt.Errorf("Error getting BOSH instances: %s. The BOSH director certificate expired on %s, run `control-tower maintain --renew-director-cert` to replace it", err, directorCertExpiry)
+		}
 		return nil, fmt.Errorf("Error getting BOSH instances: %s", err)
 	}
 
 	return &Info{
-		Terraform:   terraformInfo,
-		Config:      conf,
-		Instances:   instances,
-		GatewayUser: gatewayUser,
-		CertExpiry:  certExpiry,
+		Terraform:          terraformInfo,
+		Config:             conf,
+		Instances:          instances,
+		GatewayUser:        gatewayUser,
+		CertExpiry:         certExpiry,
+		DirectorCertExpiry: directorCertExpiry,
 	}, nil
 }
 
+// opensslDateFormat matches the dates printed by openssl x509, which are used for the NATS certificate expiry
+const opensslDateFormat = "Jan _2 15:04:05 2006 GMT"
+
+// directorCertsNotAfter returns when the director certificate or its CA expires, whichever is sooner
+func directorCertsNotAfter(conf config.ConfigView) (time.Time, bool) {
+	var notAfter time.Time
+	var parsed bool
+	for _, cert := range []string{conf.GetDirectorCert(), conf.GetDirectorCACert()} {
+		expiry, ok := certNotAfter(cert)
+		if ok && (!parsed || expiry.Before(notAfter)) {
+			notAfter, parsed = expiry, true
+		}
+	}
+	return notAfter, parsed
+}
+
 const infoTemplate = `Deployment:
 	Namespace: {{.Config.Namespace}}
 	IAAS:      {{.Config.IAAS}}
 	Region:    {{.Config.Region}}
+{{- if .Config.Private}}
+	Network:   private, no public IPs
+{{- end}}
 
 Workers:
 	Count:              {{.Config.ConcourseWorkerCount}}
 	Size:               {{.Config.ConcourseWorkerSize}}
 	Outbound Public IP: {{.Terraform.NatGatewayIP}}
-
+{{- if .Config.Schedule}}
+	Schedule:           {{.Config.Schedule}}
+{{- end}}
+{{- if .Config.Pause}}
+	Paused:             {{.Config.Pause}}
+{{- end}}
+{{range .Config.WorkerPools}}
+Worker pool {{.Name}}:
+	Count:              {{.Count}}
+	Size:               {{.Size}}
+	Provisioning:       {{.VMProvisioningType}}
+	Tags:               {{join .Tags ", "}}
+{{end}}
 Instances:
 {{range .Instances}}
 	{{.Name}} {{.IP | replace "\n" ","}} {{.State}}
@@ -157,6 +205,9 @@ Concourse credentials:
 	username: {{.Config.ConcourseUsername}}
 	password: {{.Config.ConcoursePassword}}
 	URL:      https://{{.Config.Domain}}
+{{- if .Config.Pipelines}}
+	pipelines: {{.Config.Pipelines}}
+{{- end}}
 
 Credhub credentials:
 	username: {{.Config.CredhubUsername}}
@@ -177,6 +228,7 @@ Bosh credentials:
 	CA Cert:
 		{{ .Config.DirectorCACert | replace "\n" "\n\t\t"}}
 
+BOSH director certificate will expire on: {{ .DirectorCertExpiry }}
 BOSH-generated NAT certs will expire on: {{ .CertExpiry }}
 
 Uses Control-Tower version {{.Config.Version}}
@@ -190,6 +242,7 @@ func (info *Info) String() string {
 			return strings.Replace(s, old, new, -1)
 		},
 		"blue": color.New(color.FgCyan, color.Bold).Sprint,
+		"join": strings.Join,
 	}).Parse(infoTemplate))
 	var buf bytes.Buffer
 	err := t.Execute(&buf, info)
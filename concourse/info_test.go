@@ -1,8 +1,15 @@
 package concourse
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/EngineerBetter/control-tower/bosh"
 	"github.com/EngineerBetter/control-tower/config"
@@ -10,21 +17,23 @@ import (
 
 func TestInfo_String(t *testing.T) {
 	type fields struct {
-		Terraform   TerraformInfo
-		Config      config.Config
-		Instances   []bosh.Instance
-		CertExpiry  string
-		GatewayUser string
+		Terraform          TerraformInfo
+		Config             config.Config
+		Instances          []bosh.Instance
+		CertExpiry         string
+		DirectorCertExpiry string
+		GatewayUser        string
 	}
 	defaultFields := fields{
 		Terraform: TerraformInfo{
 			DirectorPublicIP: "4.3.2.1",
 			NatGatewayIP:     "1.2.3.4",
 		},
-		Config:      config.Config{},
-		Instances:   []bosh.Instance{},
-		CertExpiry:  "2019-02-01",
-		GatewayUser: "gateway user",
+		Config:             config.Config{},
+		Instances:          []bosh.Instance{},
+		CertExpiry:         "2019-02-01",
+		DirectorCertExpiry: "2020-03-04",
+		GatewayUser:        "gateway user",
 	}
 	tests := []struct {
 		name   string
@@ -49,16 +58,62 @@ func TestInfo_String(t *testing.T) {
 			},
 			want: "IAAS:      aCloudProvider",
 		},
+		{
+			name:   "worker pool templating",
+			fields: defaultFields,
+			init: func(f fields) fields {
+				f.Config.WorkerPools = []config.WorkerPool{{Name: "heavy", Count: 6, Size: "4xlarge", VMProvisioningType: "spot", Tags: []string{"heavy", "big"}}}
+				return f
+			},
+			want: "Worker pool heavy:\n\tCount:              6\n\tSize:               4xlarge\n\tProvisioning:       spot\n\tTags:               heavy, big\n",
+		},
+		{
+			name:   "schedule and pause templating",
+			fields: defaultFields,
+			init: func(f fields) fields {
+				f.Config.Schedule = &config.Schedule{Start: "07:00", Stop: "19:00", Days: []string{"Monday", "Friday"}, Location: "UTC", IdleWorkerCount: 1}
+				f.Config.Pause = &config.Pause{WorkerCount: 1}
+				return f
+			},
+			want: "Outbound Public IP: 1.2.3.4\n\tSchedule:           07:00-19:00 UTC on Monday,Friday, 1 workers outside these hours\n\tPaused:             scaled down to 1 workers, worker pools stopped\n",
+		},
+		{
+			name:   "pipelines templating",
+			fields: defaultFields,
+			init: func(f fields) fields {
+				f.Config.Pipelines = &config.PipelinesSource{Source: "https://github.com/EngineerBetter/pipelines.git", Branch: "live", Manifest: "pipelines.yml"}
+				return f
+			},
+			want: "\tpipelines: pipelines.yml from https://github.com/EngineerBetter/pipelines.git#live\n\nCredhub credentials:",
+		},
+		{
+			name:   "private templating",
+			fields: defaultFields,
+			init: func(f fields) fields {
+				f.Config.Private = true
+				return f
+			},
+			want: "\tRegion:    \n\tNetwork:   private, no public IPs\n",
+		},
+		{
+			name:   "certificate expiry templating",
+			fields: defaultFields,
+			init: func(f fields) fields {
+				return f
+			},
+			want: "BOSH director certificate will expire on: 2020-03-04\nBOSH-generated NAT certs will expire on: 2019-02-01",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.fields = tt.init(tt.fields)
 			info := &Info{
-				Terraform:   tt.fields.Terraform,
-				Config:      tt.fields.Config,
-				Instances:   tt.fields.Instances,
-				CertExpiry:  tt.fields.CertExpiry,
-				GatewayUser: tt.fields.GatewayUser,
+				Terraform:          tt.fields.Terraform,
+				Config:             tt.fields.Config,
+				Instances:          tt.fields.Instances,
+				CertExpiry:         tt.fields.CertExpiry,
+				DirectorCertExpiry: tt.fields.DirectorCertExpiry,
+				GatewayUser:        tt.fields.GatewayUser,
 			}
 			if got := info.String(); !strings.Contains(got, tt.want) {
 				t.Errorf("Info.String() = %v, want %v", got, tt.want)
@@ -66,3 +121,53 @@ func TestInfo_String(t *testing.T) {
 		})
 	}
 }
+
+func TestDirectorCertsNotAfter(t *testing.T) {
+	caExpiry := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	certExpiry := time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	conf := config.Config{
+		DirectorCACert: selfSignedCert(t, caExpiry),
+		DirectorCert:   selfSignedCert(t, certExpiry),
+	}
+
+	got, ok := directorCertsNotAfter(conf)
+	if !ok || !got.Equal(certExpiry) {
+		t.Errorf("directorCertsNotAfter() = %v, %v, want %v", got, ok, certExpiry)
+	}
+	if formatted := got.UTC().Format(opensslDateFormat); formatted != "Jun  7 08:09:10 2021 GMT" {
+		t.Errorf("directorCertsNotAfter() formatted as %q", formatted)
+	}
+
+	conf.DirectorCert = "not a certificate"
+	if got, ok = directorCertsNotAfter(conf); !ok || !got.Equal(caExpiry) {
+		t.Errorf("directorCertsNotAfter() = %v, %v, want %v", got, ok, caExpiry)
+	}
+
+	if _, ok = directorCertsNotAfter(config.Config{}); ok {
+		t.Errorf("directorCertsNotAfter() parsed an empty config")
+	}
+}
+
+func selfSignedCert(t *testing.T, notAfter time.Time, hosts ...string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
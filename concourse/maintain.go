@@ -21,7 +21,17 @@ type tasks struct {
 
 // Maintenance is a struct representing values used by the maintenance command
 type Maintenance struct {
-	StatusIndex int `json:"status_index"`
+	StatusIndex int    `json:"status_index"`
+	Operation   string `json:"operation,omitempty"`
+}
+
+// operation returns the maintenance operation the stage belongs to. Files written before
+// operations were recorded belong to the NATS certificate rotation.
+func (m *Maintenance) operation() string {
+	if m.Operation == "" {
+		return renewNatsCertOperation
+	}
+	return m.Operation
 }
 
 // Tables represents the output of bosh locks
@@ -36,12 +46,28 @@ type Table struct {
 }
 
 const maintenanceFilename = "maintenance.json"
+const directorCredsBackupFilename = "director-creds-backup.yml"
+
+const (
+	renewNatsCertOperation          = "renew-nats-cert"
+	recoverExpiredNatsCertOperation = "recover-expired-nats-cert"
+)
 
 // Maintain fetches and builds the info
 func (client *Client) Maintain(m maintain.Args) error {
 	switch {
 	case m.RenewNatsCertIsSet:
-		return client.renewCert(m)
+		return client.withLock("maintain", func() error {
+			return client.renewCert(m)
+		})
+	case m.RecoverExpiredNatsCertIsSet:
+		return client.withLock("maintain", func() error {
+			return client.recoverExpiredCert(m)
+		})
+	case m.RenewDirectorCertIsSet:
+		return client.withLock("maintain", client.renewDirectorCert)
+	case m.RotateSelfUpdateCredsIsSet:
+		return client.withLock("maintain", client.rotateSelfUpdateCreds)
 	}
 	return nil
 }
@@ -50,6 +76,100 @@ func (client *Client) renewCert(m maintain.Args) error {
 
 	_ = client.waitForBOSHLocks(10 * time.Minute)
 
+	return client.runStages(renewNatsCertOperation, m, []tasks{
+		{"Adding new CA", resource.AddNewCa, client.createEnv},
+		{"Recreating VMs for the first time", "first", client.recreate},
+		{"Removing old CA", resource.RemoveOldCa, client.createEnv},
+		{"Recreating VMs for the second time", "second", client.recreate},
+		{"Cleaning up director-creds.yml", "", client.cleanup},
+	})
+}
+
+// recoverExpiredCert follows the BOSH procedure for a NATS CA that has already expired: every director
+// certificate is generated again, then the VMs whose agents no longer trust the director are recreated
+func (client *Client) recoverExpiredCert(m maintain.Args) error {
+
+	_ = client.waitForBOSHLocks(10 * time.Minute)
+
+	return client.runStages(recoverExpiredNatsCertOperation, m, []tasks{
+		{"Backing up director-creds.yml", "", client.backupDirectorCreds},
+		{"Removing certificates from director-creds.yml", "", client.removeDirectorCerts},
+		{"Recreating the director with new certificates", "", client.createEnv},
+		{"Recreating VMs with unresponsive agents", "", client.recreateUnresponsive},
+		{"Redeploying Concourse", "recover expired nats cert", client.redeploy},
+	})
+}
+
+// renewDirectorCert replaces the director certificate, which deploy generates once and never renews, and runs
+// create-env so that the director serves it. The CA's private key is not kept, so a new CA is generated too.
+// Only the director is recreated: the Concourse VMs do not use this certificate and keep running
+func (client *Client) renewDirectorCert() error {
+	maintenance, err := client.retrieveStage()
+	if err != nil {
+		return err
+	}
+	if maintenance.StatusIndex != -1 {
+		return fmt.Errorf("%s stopped after stage %d. Finish it before renewing the director certificate", maintenance.operation(), maintenance.StatusIndex)
+	}
+
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	tfOutputs, err := client.tfCLI.BuildOutput(client.tfInputVarsFactory.NewInputVars(conf))
+	if err != nil {
+		return err
+	}
+
+	directorCerts, err := client.ensureDirectorCerts(client.acmeClientConstructor, DirectorCerts{}, conf.Deployment, tfOutputs, conf.PublicCIDR)
+	if err != nil {
+		return err
+	}
+	if directorCerts.DirectorCACert == "" {
+		return fmt.Errorf("error generating director certificate: public CIDR %q is not valid", conf.PublicCIDR)
+	}
+
+	conf.DirectorCACert = directorCerts.DirectorCACert
+	conf.DirectorCert = directorCerts.DirectorCert
+	conf.DirectorKey = directorCerts.DirectorKey
+	if err = client.configClient.Update(conf); err != nil {
+		return err
+	}
+
+	fmt.Println("current action: Recreating the director with the new certificate")
+	if err = client.createEnv("", ""); err != nil {
+		return fmt.Errorf("the new director certificate has been saved but the director was not recreated, run this command again: [%v]", err)
+	}
+
+	_, err = client.configClient.RecordRevision("renew director cert", bosh.StateFilename, bosh.CredsFilename)
+	return err
+}
+
+// rotateSelfUpdateCreds sets the self-update pipeline again, which replaces the IAAS credentials kept in
+// CredHub for it with those control-tower is running with, eg after the old ones have been revoked
+func (client *Client) rotateSelfUpdateCreds() error {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return err
+	}
+
+	flyClient, err := client.buildFlyClient(conf)
+	if err != nil {
+		return err
+	}
+	defer flyClient.Cleanup()
+
+	if err = client.setPipeline(flyClient, conf, false); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(client.stdout, "\nSELF-UPDATE CREDENTIALS ROTATED\n\n")
+	return err
+}
+
+// runStages runs tasks in order, recording each completed stage in maintenance.json so that
+// an interrupted operation resumes where it stopped
+func (client *Client) runStages(operation string, m maintain.Args, tasks []tasks) error {
 	maintenance, err := client.retrieveStage()
 	if err != nil {
 		return err
@@ -59,24 +179,20 @@ func (client *Client) renewCert(m maintain.Args) error {
 	if m.StageIsSet {
 		stageIndex = m.Stage
 	} else {
+		if maintenance.StatusIndex != -1 && maintenance.operation() != operation {
+			return fmt.Errorf("%s stopped after stage %d. Finish it first, or pass --stage to start %s from a given stage", maintenance.operation(), maintenance.StatusIndex, operation)
+		}
 		stageIndex, err = client.determineStage(maintenance)
 		if err != nil {
 			return err
 		}
 	}
 
-	tasks := []tasks{
-		{"Adding new CA", resource.AddNewCa, client.createEnv},
-		{"Recreating VMs for the first time", "first", client.recreate},
-		{"Removing old CA", resource.RemoveOldCa, client.createEnv},
-		{"Recreating VMs for the second time", "second", client.recreate},
-		{"Cleaning up director-creds.yml", "", client.cleanup},
-	}
-
 	if stageIndex >= len(tasks) {
 		return fmt.Errorf("Invalid stage index")
 	}
 
+	maintenance.Operation = operation
 	for i := stageIndex; i < len(tasks); i++ {
 		fmt.Printf("current action: %s\n", tasks[i].description)
 		err1 := tasks[i].action(tasks[i].description, tasks[i].operation)
@@ -245,6 +361,51 @@ func (client *Client) recreate(description, operation string) error {
 	return nil
 }
 
+// recreateUnresponsive runs bosh recreate --fix
+func (client *Client) recreateUnresponsive(description, operation string) error {
+	boshClientPointer, err := client.constructBoshClient()
+	if err != nil {
+		return err
+	}
+	boshClient := *boshClientPointer
+	defer boshClient.Cleanup()
+
+	return boshClient.RecreateUnresponsive()
+}
+
+// backupDirectorCreds copies director-creds.yml to director-creds-backup.yml
+func (client *Client) backupDirectorCreds(description, operation string) error {
+	directorCredsBytes, err := loadDirectorCreds(client.configClient)
+	if err != nil {
+		return err
+	}
+	if directorCredsBytes == nil {
+		return fmt.Errorf("%s not found", bosh.CredsFilename)
+	}
+	return client.configClient.StoreAsset(directorCredsBackupFilename, directorCredsBytes)
+}
+
+// removeDirectorCerts removes every certificate from director-creds.yml, so that create-env generates them again
+func (client *Client) removeDirectorCerts(description, operation string) error {
+	directorCredsBytes, err := loadDirectorCreds(client.configClient)
+	if err != nil {
+		return err
+	}
+	correctedCreds, err := yaml.RemoveCertificates(directorCredsBytes)
+	if err != nil {
+		return fmt.Errorf("error removing certificates from %s: [%v]", bosh.CredsFilename, err)
+	}
+	return client.configClient.StoreAsset(bosh.CredsFilename, correctedCreds)
+}
+
+// redeploy deploys the stored config again, recording a revision named after operation
+func (client *Client) redeploy(description, operation string) error {
+	if err := client.keepStoredAllowIPs(); err != nil {
+		return err
+	}
+	return client.deploy(operation)
+}
+
 // cleanup cleans up the director-creds.yml file
 func (client *Client) cleanup(description, operation string) error {
 	directorCredsBytes, err := loadDirectorCreds(client.configClient)
@@ -319,7 +480,7 @@ func (client *Client) cleanup(description, operation string) error {
 	if err != nil {
 		return err
 	}
-	err = client.configClient.StoreAsset("director-creds-backup.yml", directorCredsBytes)
+	err = client.configClient.StoreAsset(directorCredsBackupFilename, directorCredsBytes)
 	if err != nil {
 		return err
 	}
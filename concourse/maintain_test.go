@@ -0,0 +1,200 @@
+package concourse
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/bosh"
+	"github.com/EngineerBetter/control-tower/bosh/boshfakes"
+	"github.com/EngineerBetter/control-tower/certs"
+	"github.com/EngineerBetter/control-tower/commands/maintain"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/config/configfakes"
+	"github.com/EngineerBetter/control-tower/credhub"
+	"github.com/EngineerBetter/control-tower/events"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/fly/flyfakes"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/terraform/terraformfakes"
+	"github.com/go-acme/lego/v4/lego"
+)
+
+func TestClient_Maintain_RecoverExpiredNatsCert(t *testing.T) {
+	const creds = "admin_password: secret\nnats_ca:\n  ca: ca-cert\n  certificate: ca-cert\n  private_key: ca-key\n"
+
+	newClient := func(maintenance string) (*Client, *configfakes.FakeIClient) {
+		configClient := &configfakes.FakeIClient{}
+		configClient.HasAssetStub = func(filename string) (bool, error) {
+			return filename == bosh.CredsFilename || (filename == maintenanceFilename && maintenance != ""), nil
+		}
+		configClient.LoadAssetStub = func(filename string) ([]byte, error) {
+			if filename == maintenanceFilename {
+				return []byte(maintenance), nil
+			}
+			return []byte(creds), nil
+		}
+		return &Client{
+			configClient:       configClient,
+			tfCLI:              &terraformfakes.FakeCLIInterface{},
+			tfInputVarsFactory: &AWSInputVarsFactory{},
+			boshClientFactory: func(config.ConfigView, terraform.Outputs, io.Writer, io.Writer, events.Recorder, iaas.Provider, []byte) (bosh.IClient, error) {
+				return nil, errors.New("director unavailable")
+			},
+		}, configClient
+	}
+
+	t.Run("backs up the creds and removes their certificates before recreating the director", func(t *testing.T) {
+		client, configClient := newClient("")
+		err := client.Maintain(maintain.Args{RecoverExpiredNatsCertIsSet: true})
+		if err == nil || err.Error() != "director unavailable" {
+			t.Fatalf("Client.Maintain() error = %v, want director unavailable", err)
+		}
+
+		var stored []string
+		for i := 0; i < configClient.StoreAssetCallCount(); i++ {
+			filename, contents := configClient.StoreAssetArgsForCall(i)
+			stored = append(stored, filename+": "+string(contents))
+		}
+		want := []string{
+			directorCredsBackupFilename + ": " + creds,
+			maintenanceFilename + `: {"status_index":0,"operation":"recover-expired-nats-cert"}`,
+			bosh.CredsFilename + ": admin_password: secret\n",
+			maintenanceFilename + `: {"status_index":1,"operation":"recover-expired-nats-cert"}`,
+		}
+		if strings.Join(stored, "\n") != strings.Join(want, "\n") {
+			t.Errorf("Client.Maintain() stored\n%s\nwant\n%s", strings.Join(stored, "\n"), strings.Join(want, "\n"))
+		}
+		if configClient.LockCallCount() != 1 || configClient.UnlockCallCount() != 1 {
+			t.Errorf("Client.Maintain() did not hold the lock")
+		}
+	})
+
+	t.Run("refuses to resume a rotation that is in progress", func(t *testing.T) {
+		client, configClient := newClient(`{"status_index":2}`)
+		err := client.Maintain(maintain.Args{RecoverExpiredNatsCertIsSet: true})
+		if err == nil || !strings.Contains(err.Error(), "renew-nats-cert stopped after stage 2") {
+			t.Errorf("Client.Maintain() error = %v, want renew-nats-cert stopped after stage 2", err)
+		}
+		if configClient.StoreAssetCallCount() != 0 {
+			t.Errorf("Client.Maintain() changed the deployment")
+		}
+	})
+}
+
+func TestClient_Maintain_RenewDirectorCert(t *testing.T) {
+	newClient := func(maintenance string) (*Client, *configfakes.FakeIClient, *[]string) {
+		var calls []string
+		configClient := &configfakes.FakeIClient{}
+		configClient.LoadReturns(config.Config{
+			Deployment:     "control-tower-ci",
+			PublicCIDR:     "10.0.0.0/24",
+			DirectorCACert: "old-ca",
+			DirectorCert:   "old-cert",
+			DirectorKey:    "old-key",
+		}, nil)
+		configClient.HasAssetStub = func(filename string) (bool, error) {
+			return filename == maintenanceFilename && maintenance != "", nil
+		}
+		configClient.LoadAssetReturns([]byte(maintenance), nil)
+		configClient.UpdateStub = func(c config.Config) error {
+			calls = append(calls, "update "+c.DirectorCACert+" "+c.DirectorCert+" "+c.DirectorKey)
+			return nil
+		}
+		configClient.RecordRevisionStub = func(operation string, filenames ...string) (config.Revision, error) {
+			calls = append(calls, "record "+operation)
+			return config.Revision{}, nil
+		}
+
+		boshClient := &boshfakes.FakeIClient{}
+		boshClient.CreateEnvStub = func(state, creds []byte, customOps string) ([]byte, []byte, error) {
+			calls = append(calls, "create-env")
+			return state, creds, nil
+		}
+
+		return &Client{
+			configClient: configClient,
+			tfCLI: &terraformfakes.FakeCLIInterface{BuildOutputStub: func(terraform.InputVars) (terraform.Outputs, error) {
+				return &terraform.AWSOutputs{DirectorPublicIP: terraform.MetadataStringValue{Value: "1.2.3.4"}}, nil
+			}},
+			tfInputVarsFactory: &AWSInputVarsFactory{},
+			boshClientFactory: func(config.ConfigView, terraform.Outputs, io.Writer, io.Writer, events.Recorder, iaas.Provider, []byte) (bosh.IClient, error) {
+				return boshClient, nil
+			},
+			certGenerator: func(c func(u *certs.User) (*lego.Client, error), caName string, provider iaas.Provider, ip ...string) (*certs.Certs, error) {
+				calls = append(calls, "generate "+caName+" "+strings.Join(ip, " "))
+				return &certs.Certs{CACert: []byte("new-ca"), Cert: []byte("new-cert"), Key: []byte("new-key")}, nil
+			},
+			stdout: ioutil.Discard,
+		}, configClient, &calls
+	}
+
+	t.Run("stores new certificates before recreating the director", func(t *testing.T) {
+		client, configClient, calls := newClient("")
+		if err := client.Maintain(maintain.Args{RenewDirectorCertIsSet: true}); err != nil {
+			t.Fatalf("Client.Maintain() error = %v", err)
+		}
+		want := []string{
+			"generate control-tower-ci 1.2.3.4 10.0.0.6",
+			"update new-ca new-cert new-key",
+			"create-env",
+			"record renew director cert",
+		}
+		if strings.Join(*calls, "\n") != strings.Join(want, "\n") {
+			t.Errorf("Client.Maintain() made calls\n%s\nwant\n%s", strings.Join(*calls, "\n"), strings.Join(want, "\n"))
+		}
+		if configClient.LockCallCount() != 1 || configClient.UnlockCallCount() != 1 {
+			t.Errorf("Client.Maintain() did not hold the lock")
+		}
+	})
+
+	t.Run("refuses while a NATS certificate rotation is in progress", func(t *testing.T) {
+		client, _, calls := newClient(`{"status_index":1,"operation":"recover-expired-nats-cert"}`)
+		err := client.Maintain(maintain.Args{RenewDirectorCertIsSet: true})
+		if err == nil || !strings.Contains(err.Error(), "recover-expired-nats-cert stopped after stage 1") {
+			t.Errorf("Client.Maintain() error = %v, want recover-expired-nats-cert stopped after stage 1", err)
+		}
+		if len(*calls) != 0 {
+			t.Errorf("Client.Maintain() changed the deployment: %v", *calls)
+		}
+	})
+}
+
+func TestClient_Maintain_RotateSelfUpdateCreds(t *testing.T) {
+	configClient := &configfakes.FakeIClient{}
+	configClient.LoadReturns(config.Config{
+		Deployment:               "control-tower-ci",
+		Domain:                   "ci.example.com",
+		CredhubURL:               "https://ci.example.com:8844/",
+		CredhubCACert:            "credhub-ca",
+		CredhubAdminClientSecret: "credhub-secret",
+	}, nil)
+	flyClient := &flyfakes.FakeIClient{}
+	var flyCreds fly.Credentials
+	client := &Client{
+		configClient: configClient,
+		flyClientFactory: func(_ iaas.Provider, creds fly.Credentials, _ io.Writer, _ io.Writer, _ []byte) (fly.IClient, error) {
+			flyCreds = creds
+			return flyClient, nil
+		},
+		events: events.Discard,
+		stdout: ioutil.Discard,
+	}
+
+	if err := client.Maintain(maintain.Args{RotateSelfUpdateCredsIsSet: true}); err != nil {
+		t.Fatalf("Client.Maintain() error = %v", err)
+	}
+	want := credhub.Credentials{URL: "https://ci.example.com:8844/", CACert: "credhub-ca", ClientSecret: "credhub-secret"}
+	if flyCreds.CredHub != want {
+		t.Errorf("fly client can write to CredHub with %+v, want %+v", flyCreds.CredHub, want)
+	}
+	if flyClient.SetDefaultPipelineCallCount() != 1 {
+		t.Errorf("did not set the self-update pipeline with the current credentials")
+	}
+	if configClient.LockCallCount() != 1 || configClient.UnlockCallCount() != 1 {
+		t.Errorf("did not hold the lock while rotating the credentials")
+	}
+}
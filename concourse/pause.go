@@ -0,0 +1,55 @@
+package concourse
+
+import (
+	"fmt"
+
+	"github.com/EngineerBetter/control-tower/config"
+)
+
+// Pause scales the default workers down to workerCount and stops any worker pools, or with
+// hibernate stops the web VM and every worker. It redeploys with the pause recorded in the config,
+// so later deploys keep the Concourse paused until Resume is called.
+func (client *Client) Pause(workerCount int, hibernate bool) error {
+	pause := &config.Pause{WorkerCount: workerCount, Hibernate: hibernate}
+	if hibernate {
+		pause.WorkerCount = 0
+	}
+
+	return client.withLock("pause", func() error {
+		conf, err := client.configClient.Load()
+		if err != nil {
+			return fmt.Errorf("error loading config: [%v]", err)
+		}
+		conf.Pause = pause
+		if err = client.configClient.Update(conf); err != nil {
+			return fmt.Errorf("error saving pause to config: [%v]", err)
+		}
+		if err = client.keepStoredAllowIPs(); err != nil {
+			return err
+		}
+		fmt.Fprintf(client.stdout, "\nPAUSING %s: %s\n\n", conf.Deployment, pause)
+
+		return client.deploy("pause")
+	})
+}
+
+// Resume redeploys a paused Concourse with all of its web and worker VMs. Like Pause it always
+// redeploys, so that it can be run again if the deploy fails
+func (client *Client) Resume() error {
+	return client.withLock("resume", func() error {
+		conf, err := client.configClient.Load()
+		if err != nil {
+			return fmt.Errorf("error loading config: [%v]", err)
+		}
+		conf.Pause = nil
+		if err = client.configClient.Update(conf); err != nil {
+			return fmt.Errorf("error removing pause from config: [%v]", err)
+		}
+		if err = client.keepStoredAllowIPs(); err != nil {
+			return err
+		}
+		fmt.Fprintf(client.stdout, "\nRESUMING %s\n\n", conf.Deployment)
+
+		return client.deploy("resume")
+	})
+}
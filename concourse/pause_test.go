@@ -0,0 +1,75 @@
+package concourse
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/config/configfakes"
+	"github.com/EngineerBetter/control-tower/events"
+)
+
+func TestClient_PauseAndResume(t *testing.T) {
+	tests := []struct {
+		name      string
+		stored    *config.Pause
+		operation string
+		run       func(*Client) error
+		wantPause *config.Pause
+	}{
+		{
+			name:      "scale down",
+			operation: "pause",
+			run:       func(c *Client) error { return c.Pause(2, false) },
+			wantPause: &config.Pause{WorkerCount: 2},
+		},
+		{
+			name:      "hibernate",
+			stored:    &config.Pause{WorkerCount: 2},
+			operation: "pause",
+			run:       func(c *Client) error { return c.Pause(2, true) },
+			wantPause: &config.Pause{Hibernate: true},
+		},
+		{
+			name:      "resume",
+			stored:    &config.Pause{Hibernate: true},
+			operation: "resume",
+			run:       func(c *Client) error { return c.Resume() },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configClient := &configfakes.FakeIClient{}
+			configClient.LoadReturns(config.Config{Deployment: "control-tower-ci", ConcourseWorkerCount: 4, AllowIPsUnformatted: "10.0.0.0/8", Pause: tt.stored}, nil)
+			configClient.EncryptPlaintextAssetsReturns(errors.New("boom"))
+
+			client := &Client{configClient: configClient, deployArgs: &deploy.Args{}, events: events.Discard, stdout: &bytes.Buffer{}}
+			err := tt.run(client)
+			if err == nil {
+				t.Fatalf("expected the failed deploy to be returned")
+			}
+
+			if configClient.LockCallCount() != 1 || configClient.LockArgsForCall(0) != tt.operation {
+				t.Errorf("did not lock the deployment for %s", tt.operation)
+			}
+			if configClient.UnlockCallCount() != 1 {
+				t.Errorf("did not unlock the deployment")
+			}
+			if configClient.UpdateCallCount() != 1 {
+				t.Fatalf("updated the config %d times, want once before deploying", configClient.UpdateCallCount())
+			}
+			if got := configClient.UpdateArgsForCall(0).Pause; !reflect.DeepEqual(got, tt.wantPause) {
+				t.Errorf("saved pause %+v, want %+v", got, tt.wantPause)
+			}
+			if configClient.EncryptPlaintextAssetsCallCount() != 1 {
+				t.Errorf("did not redeploy")
+			}
+			if client.deployArgs.AllowIPs != "10.0.0.0/8" {
+				t.Errorf("redeployed with allowed IPs %q, want the stored ones", client.deployArgs.AllowIPs)
+			}
+		})
+	}
+}
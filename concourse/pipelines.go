@@ -0,0 +1,100 @@
+package concourse
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/util"
+)
+
+// gitClone makes a shallow clone of a branch of a git repository, or of its default branch if
+// branch is empty
+var gitClone = func(url, branch, dir string, stderr io.Writer) error {
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	cmd := exec.Command("git", append(args, url, dir)...)
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// setUserPipelines sets the pipelines listed in the manifest of the deployment's pipelines source,
+// reporting those that were created or changed. A local directory can only be read when deploying
+// from the machine it is on, so the self-update pipeline leaves its pipelines as they are.
+func (client *Client) setUserPipelines(flyClient fly.IClient, c config.ConfigView) error {
+	source := c.GetPipelines()
+	if source == nil {
+		return nil
+	}
+	if !source.IsGit() && client.deployArgs.SelfUpdate {
+		_, err := fmt.Fprintf(client.stdout, "\nSKIPPING PIPELINES: %s is a local directory\n\n", source.Source)
+		return err
+	}
+
+	tempDir, err := util.NewTempDir()
+	if err != nil {
+		return err
+	}
+	defer tempDir.Cleanup()
+
+	pipelines, err := client.readPipelinesManifest(*source, tempDir)
+	if err != nil {
+		return err
+	}
+
+	changed, err := flyClient.SetPipelines(pipelines)
+	if err != nil {
+		return fmt.Errorf("error setting pipelines: [%v]", err)
+	}
+	if len(changed) == 0 {
+		_, err = fmt.Fprintf(client.stdout, "\nPIPELINES UNCHANGED\n\n")
+		return err
+	}
+	_, err = fmt.Fprintf(client.stdout, "\nPIPELINES CHANGED: %s\n\n", strings.Join(changed, ", "))
+	return err
+}
+
+// readPipelinesManifest reads the manifest of a pipelines source, cloning it into tempDir if it is a
+// git repository, and returns its pipelines with the paths of their files made absolute
+func (client *Client) readPipelinesManifest(source config.PipelinesSource, tempDir *util.TempDir) ([]config.UserPipeline, error) {
+	dir := source.Source
+	if source.IsGit() {
+		dir = tempDir.Path("pipelines")
+		if err := gitClone(source.Source, source.Branch, dir, client.stderr); err != nil {
+			return nil, fmt.Errorf("error cloning pipelines from %s: [%v]", source.Source, err)
+		}
+	}
+
+	manifestPath := filepath.Join(dir, source.Manifest)
+	contents, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pipelines manifest: [%v]", err)
+	}
+	pipelines, err := config.ParsePipelinesManifest(contents)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing pipelines manifest %s: [%v]", source.Manifest, err)
+	}
+	if err = config.ValidatePipelines(pipelines); err != nil {
+		return nil, err
+	}
+
+	manifestDir, err := filepath.Abs(filepath.Dir(manifestPath))
+	if err != nil {
+		return nil, err
+	}
+	for i, pipeline := range pipelines {
+		pipelines[i].Config = filepath.Join(manifestDir, pipeline.Config)
+		pipelines[i].Vars = nil
+		for _, vars := range pipeline.Vars {
+			pipelines[i].Vars = append(pipelines[i].Vars, filepath.Join(manifestDir, vars))
+		}
+	}
+	return pipelines, nil
+}
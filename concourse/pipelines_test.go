@@ -0,0 +1,99 @@
+package concourse
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/commands/deploy"
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/fly/flyfakes"
+)
+
+const testPipelinesManifest = `
+- pipeline: hello
+  config: hello.yml
+- team: platform
+  pipeline: deploy
+  config: deploy/pipeline.yml
+  vars: [deploy/vars.yml]
+`
+
+func TestClient_SetUserPipelines(t *testing.T) {
+	realGitClone := gitClone
+	defer func() { gitClone = realGitClone }()
+
+	var clonedBranch string
+	gitClone = func(url, branch, dir string, stderr io.Writer) error {
+		clonedBranch = branch
+		if err := os.MkdirAll(filepath.Join(dir, "ci"), 0700); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(dir, "ci", "pipelines.yml"), []byte(testPipelinesManifest), 0600)
+	}
+
+	flyClient := &flyfakes.FakeIClient{}
+	flyClient.SetPipelinesReturns([]string{"platform/deploy"}, nil)
+	stdout := &bytes.Buffer{}
+	client := &Client{deployArgs: &deploy.Args{}, stdout: stdout}
+	conf := config.Config{Pipelines: &config.PipelinesSource{Source: "https://github.com/EngineerBetter/pipelines.git", Branch: "live", Manifest: "ci/pipelines.yml"}}
+
+	if err := client.setUserPipelines(flyClient, conf); err != nil {
+		t.Fatalf("setUserPipelines() error = %v", err)
+	}
+	if clonedBranch != "live" {
+		t.Errorf("cloned branch %q, want live", clonedBranch)
+	}
+	pipelines := flyClient.SetPipelinesArgsForCall(0)
+	if len(pipelines) != 2 || pipelines[0].String() != "main/hello" || pipelines[1].String() != "platform/deploy" {
+		t.Fatalf("set pipelines %+v, want main/hello and platform/deploy", pipelines)
+	}
+	if !filepath.IsAbs(pipelines[1].Config) || !strings.HasSuffix(pipelines[1].Config, filepath.Join("ci", "deploy", "pipeline.yml")) {
+		t.Errorf("set pipeline config %s, want an absolute path next to the manifest", pipelines[1].Config)
+	}
+	if len(pipelines[1].Vars) != 1 || !strings.HasSuffix(pipelines[1].Vars[0], filepath.Join("ci", "deploy", "vars.yml")) {
+		t.Errorf("set pipeline vars %v, want an absolute path next to the manifest", pipelines[1].Vars)
+	}
+	if !strings.Contains(stdout.String(), "PIPELINES CHANGED: platform/deploy") {
+		t.Errorf("did not report the changed pipelines:\n%s", stdout.String())
+	}
+}
+
+func TestClient_SetUserPipelinesFromLocalDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipelines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "pipelines.yml"), []byte(testPipelinesManifest), 0600); err != nil {
+		t.Fatal(err)
+	}
+	conf := config.Config{Pipelines: &config.PipelinesSource{Source: dir, Manifest: config.DefaultPipelinesManifest}}
+
+	flyClient := &flyfakes.FakeIClient{}
+	stdout := &bytes.Buffer{}
+	client := &Client{deployArgs: &deploy.Args{}, stdout: stdout}
+	if err = client.setUserPipelines(flyClient, conf); err != nil {
+		t.Fatalf("setUserPipelines() error = %v", err)
+	}
+	if got := flyClient.SetPipelinesArgsForCall(0)[0].Config; got != filepath.Join(dir, "hello.yml") {
+		t.Errorf("set pipeline config %s, want %s", got, filepath.Join(dir, "hello.yml"))
+	}
+	if !strings.Contains(stdout.String(), "PIPELINES UNCHANGED") {
+		t.Errorf("did not report that no pipelines changed:\n%s", stdout.String())
+	}
+
+	// The self-update pipeline runs on a worker, which cannot see the directory
+	flyClient = &flyfakes.FakeIClient{}
+	client.deployArgs.SelfUpdate = true
+	if err = client.setUserPipelines(flyClient, conf); err != nil {
+		t.Fatalf("setUserPipelines() error = %v", err)
+	}
+	if flyClient.SetPipelinesCallCount() != 0 {
+		t.Errorf("set pipelines from a local directory during a self-update")
+	}
+}
@@ -0,0 +1,235 @@
+package concourse
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/EngineerBetter/control-tower/util/yaml"
+)
+
+// deployPlan describes the changes a deploy would make without applying them
+type deployPlan struct {
+	NewDeployment    bool
+	Terraform        string
+	ConfigChanges    []string
+	CertRotations    []string
+	DirectorChanges  []string
+	ConcourseChanges string
+}
+
+// plan runs the read-only parts of a deploy and reports what would change
+func (client *Client) plan() error {
+	priorConfigExists, err := client.configClient.ConfigExists()
+	if err != nil {
+		return fmt.Errorf("error determining if config already exists [%v]", err)
+	}
+
+	var priorConf config.Config
+	if priorConfigExists {
+		priorConf, err = client.configClient.Load()
+		if err != nil {
+			return fmt.Errorf("error loading existing config [%v]", err)
+		}
+	}
+
+	conf, isDomainUpdated, err := client.getInitialConfig()
+	if err != nil {
+		return fmt.Errorf("error getting initial config before dry run: [%v]", err)
+	}
+
+	r, err := client.checkPreTerraformConfigRequirements(conf, false)
+	if err != nil {
+		return err
+	}
+	conf.Region = r.Region
+	conf.SourceAccessIP = r.SourceAccessIP
+	conf.HostedZoneID = r.HostedZoneID
+	conf.HostedZoneRecordPrefix = r.HostedZoneRecordPrefix
+	conf.Domain = r.Domain
+
+	p := deployPlan{NewDeployment: !priorConfigExists}
+
+	tfInputVars := client.tfInputVarsFactory.NewInputVars(conf)
+	planInputVars := tfInputVars
+	if p.NewDeployment {
+		// The bucket that would hold terraform state is only created by a real deploy
+		planInputVars = terraform.WithoutStoredState(tfInputVars)
+	}
+	p.Terraform, err = client.tfCLI.Plan(planInputVars)
+	if err != nil {
+		return fmt.Errorf("error running terraform plan: [%v]", err)
+	}
+
+	if p.NewDeployment {
+		return writePlan(p, client.stdout)
+	}
+
+	conf.Tags = stripVersion(conf.Tags)
+	conf.Tags = append([]string{fmt.Sprintf("control-tower-version=%s", client.version)}, conf.Tags...)
+	conf.Version = client.version
+
+	p.ConfigChanges = configChanges(priorConf, conf)
+	p.CertRotations = client.certRotations(isDomainUpdated, conf)
+
+	// Outputs describe the infrastructure as it is now, since the plan has not been applied
+	tfOutputs, err := client.tfCLI.BuildOutput(tfInputVars)
+	if err != nil {
+		return err
+	}
+
+	p.DirectorChanges, p.ConcourseChanges, err = client.boshChanges(priorConf, conf, tfOutputs)
+	if err != nil {
+		return err
+	}
+
+	return writePlan(p, client.stdout)
+}
+
+func configChanges(before, after config.ConfigView) []string {
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"Control Tower version", before.GetVersion(), after.GetVersion()},
+		{"Domain", before.GetDomain(), after.GetDomain()},
+		{"Allowed IPs", before.GetAllowIPsUnformatted(), after.GetAllowIPsUnformatted()},
+		{"Web VM size", before.GetConcourseWebSize(), after.GetConcourseWebSize()},
+		{"Web count", strconv.Itoa(before.GetConcourseWebCount()), strconv.Itoa(after.GetConcourseWebCount())},
+		{"Worker VM size", before.GetConcourseWorkerSize(), after.GetConcourseWorkerSize()},
+		{"Worker VM type", before.GetWorkerType(), after.GetWorkerType()},
+		{"Worker count", strconv.Itoa(before.GetConcourseWorkerCount()), strconv.Itoa(after.GetConcourseWorkerCount())},
+		{"VM provisioning", config.ConvertSpotBoolToVMProvisioningType(before.IsSpot()), config.ConvertSpotBoolToVMProvisioningType(after.IsSpot())},
+		{"Worker pools", config.FormatWorkerPools(before.GetWorkerPools()), config.FormatWorkerPools(after.GetWorkerPools())},
+		{"Worker schedule", config.FormatSchedule(before.GetSchedule()), config.FormatSchedule(after.GetSchedule())},
+		{"Release channel", before.GetReleaseChannel(), after.GetReleaseChannel()},
+		{"Maintenance window", config.FormatMaintenanceWindow(before.GetMaintenanceWindow()), config.FormatMaintenanceWindow(after.GetMaintenanceWindow())},
+		{"Upgrade approval", strconv.FormatBool(before.RequiresUpgradeApproval()), strconv.FormatBool(after.RequiresUpgradeApproval())},
+		{"Notifications", config.FormatNotifications(before.GetNotifications()), config.FormatNotifications(after.GetNotifications())},
+		{"OIDC auth", config.FormatOIDCAuth(before.GetOIDCAuth()), config.FormatOIDCAuth(after.GetOIDCAuth())},
+		{"LDAP auth", config.FormatLDAPAuth(before.GetLDAPAuth()), config.FormatLDAPAuth(after.GetLDAPAuth())},
+		{"GitLab auth", config.FormatGitLabAuth(before.GetGitLabAuth()), config.FormatGitLabAuth(after.GetGitLabAuth())},
+		{"Pipelines", config.FormatPipelinesSource(before.GetPipelines()), config.FormatPipelinesSource(after.GetPipelines())},
+		{"Concourse ops files", config.FormatManifestFiles(before.GetConcourseOpsFiles()), config.FormatManifestFiles(after.GetConcourseOpsFiles())},
+		{"Director ops files", config.FormatManifestFiles(before.GetDirectorOpsFiles()), config.FormatManifestFiles(after.GetDirectorOpsFiles())},
+		{"Vars files", config.FormatManifestFiles(before.GetVarsFiles()), config.FormatManifestFiles(after.GetVarsFiles())},
+		{"Database instance class", before.GetRDSInstanceClass(), after.GetRDSInstanceClass()},
+		{"Network CIDR", before.GetNetworkCIDR(), after.GetNetworkCIDR()},
+		{"Public subnet CIDR", before.GetPublicCIDR(), after.GetPublicCIDR()},
+		{"Private subnet CIDR", before.GetPrivateCIDR(), after.GetPrivateCIDR()},
+		{"RDS subnet CIDR 1", before.GetRDS1CIDR(), after.GetRDS1CIDR()},
+		{"RDS subnet CIDR 2", before.GetRDS2CIDR(), after.GetRDS2CIDR()},
+		{"Private", strconv.FormatBool(before.IsPrivate()), strconv.FormatBool(after.IsPrivate())},
+		{"Instance identity", strconv.FormatBool(before.UsesInstanceIdentity()), strconv.FormatBool(after.UsesInstanceIdentity())},
+	}
+
+	var changes []string
+	for _, f := range fields {
+		if f.before != f.after {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", f.name, f.before, f.after))
+		}
+	}
+	return changes
+}
+
+// certRotations mirrors the decisions made by ensureDirectorCerts and ensureConcourseCerts
+func (client *Client) certRotations(isDomainUpdated bool, conf config.ConfigView) []string {
+	var rotations []string
+
+	if conf.GetDirectorCACert() == "" {
+		rotations = append(rotations, "BOSH director certificate will be generated")
+	}
+
+	switch {
+	case client.deployArgs.TLSCert != "":
+		if client.deployArgs.TLSCert != conf.GetConcourseCert() {
+			rotations = append(rotations, "Concourse certificate will be replaced with the one provided by --tls-cert")
+		}
+	case conf.GetConcourseCert() == "":
+		rotations = append(rotations, "Concourse certificate will be generated")
+	case isDomainUpdated:
+		rotations = append(rotations, "Concourse certificate will be regenerated because the domain has changed")
+	default:
+		if expiry := timeTillExpiry(conf.GetConcourseCert()); expiry <= concourseCertRenewalPeriod {
+			rotations = append(rotations, fmt.Sprintf("Concourse certificate will be renewed as it expires in %s", expiry.Round(time.Hour)))
+		}
+	}
+
+	return rotations
+}
+
+func (client *Client) boshChanges(priorConf, conf config.ConfigView, tfOutputs terraform.Outputs) ([]string, string, error) {
+	priorBoshClient, err := client.buildBoshClient(priorConf, tfOutputs)
+	if err != nil {
+		return nil, "", err
+	}
+	defer priorBoshClient.Cleanup()
+
+	boshClient, err := client.buildBoshClient(conf, tfOutputs)
+	if err != nil {
+		return nil, "", err
+	}
+	defer boshClient.Cleanup()
+
+	priorManifest, err := priorBoshClient.DirectorManifest()
+	if err != nil {
+		return nil, "", fmt.Errorf("error rendering current director manifest: [%v]", err)
+	}
+	manifest, err := boshClient.DirectorManifest()
+	if err != nil {
+		return nil, "", fmt.Errorf("error rendering new director manifest: [%v]", err)
+	}
+	directorChanges, err := yaml.Diff([]byte(priorManifest), []byte(manifest))
+	if err != nil {
+		return nil, "", fmt.Errorf("error comparing director manifests: [%v]", err)
+	}
+
+	boshCredsBytes, err := loadDirectorCreds(client.configClient)
+	if err != nil {
+		return nil, "", err
+	}
+	boshCredsBytes, err = withAuthSecrets(boshCredsBytes, client.deployArgs)
+	if err != nil {
+		return nil, "", err
+	}
+	concourseChanges, err := boshClient.DeployDryRun(boshCredsBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("error running bosh deploy --dry-run: [%v]", err)
+	}
+
+	return directorChanges, concourseChanges, nil
+}
+
+const planMsg = `
+DRY RUN: NO CHANGES HAVE BEEN APPLIED
+{{if .NewDeployment}}
+No existing deployment was found. Everything will be created, including new certificates.
+{{end}}
+TERRAFORM PLAN:
+{{.Terraform}}
+{{- if not .NewDeployment}}
+CONFIGURATION CHANGES:
+{{range .ConfigChanges}}  {{.}}
+{{else}}  none
+{{end}}
+CERTIFICATES TO BE ROTATED:
+{{range .CertRotations}}  {{.}}
+{{else}}  none
+{{end}}
+BOSH DIRECTOR (create-env) CHANGES:
+{{range .DirectorChanges}}  {{.}}
+{{else}}  none
+{{end}}
+CONCOURSE (bosh deploy --dry-run) CHANGES:
+{{.ConcourseChanges}}
+{{- end}}
+`
+
+func writePlan(p deployPlan, stdout io.Writer) error {
+	t := template.Must(template.New("plan").Parse(planMsg))
+	return t.Execute(stdout, p)
+}
@@ -0,0 +1,135 @@
+package concourse
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/fly"
+)
+
+// ApplyTeams sets the roles of each team in Concourse, creating teams that do not exist yet. The
+// teams are stored in the config, so that later deploys set them again.
+func (client *Client) ApplyTeams(teams []config.Team) error {
+	if err := config.ValidateTeams(teams); err != nil {
+		return err
+	}
+
+	return client.withLock("teams", func() error {
+		conf, err := client.configClient.Load()
+		if err != nil {
+			return fmt.Errorf("error loading config: [%v]", err)
+		}
+		if err = checkTeamConnectors(conf, teams); err != nil {
+			return err
+		}
+
+		flyClient, err := client.buildFlyClient(conf)
+		if err != nil {
+			return err
+		}
+		defer flyClient.Cleanup()
+
+		fmt.Fprintf(client.stdout, "\nSETTING TEAMS ON %s: %s\n\n", conf.Deployment, config.FormatTeams(teams))
+		if err = flyClient.SetTeams(teams); err != nil {
+			return fmt.Errorf("error setting teams: [%v]", err)
+		}
+
+		conf.Teams = teams
+		if err = client.configClient.Update(conf); err != nil {
+			return fmt.Errorf("error saving teams to config: [%v]", err)
+		}
+		return nil
+	})
+}
+
+// TeamsDiff reports how the teams in Concourse differ from teams, or from the teams stored by the
+// last apply when teams is nil. Teams in Concourse that are not managed by control-tower are listed
+// too, other than main.
+func (client *Client) TeamsDiff(teams []config.Team) error {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return fmt.Errorf("error loading config: [%v]", err)
+	}
+	if teams == nil {
+		teams = conf.Teams
+	} else if err = config.ValidateTeams(teams); err != nil {
+		return err
+	}
+
+	flyClient, err := client.buildFlyClient(conf)
+	if err != nil {
+		return err
+	}
+	defer flyClient.Cleanup()
+
+	live, err := flyClient.TeamAuth()
+	if err != nil {
+		return fmt.Errorf("error getting teams from Concourse: [%v]", err)
+	}
+
+	fmt.Fprintf(client.stdout, "\nTEAM DRIFT ON %s\n\n", conf.Deployment)
+	drift := teamsDrift(teams, live)
+	if len(drift) == 0 {
+		_, err = fmt.Fprintln(client.stdout, "none")
+		return err
+	}
+	for _, line := range drift {
+		fmt.Fprintln(client.stdout, line)
+	}
+	return nil
+}
+
+func teamsDrift(teams []config.Team, live map[string]config.TeamAuth) []string {
+	var drift []string
+	managed := map[string]bool{config.MainTeam: true}
+	for _, team := range teams {
+		managed[team.Name] = true
+		liveAuth, ok := live[team.Name]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("+ %s: not in Concourse", team.Name))
+			continue
+		}
+		drift = append(drift, config.DiffTeamAuth(team.Name, liveAuth, team.Auth())...)
+	}
+
+	var unmanaged []string
+	for name := range live {
+		if !managed[name] {
+			unmanaged = append(unmanaged, fmt.Sprintf("- %s: not managed by control-tower", name))
+		}
+	}
+	sort.Strings(unmanaged)
+	return append(drift, unmanaged...)
+}
+
+// checkTeamConnectors makes sure every auth provider that teams grant roles through is configured
+func checkTeamConnectors(conf config.Config, teams []config.Team) error {
+	configured := map[string]bool{
+		"local":  true,
+		"github": conf.IsGithubAuthSet(),
+		"oidc":   conf.GetOIDCAuth() != nil,
+		"ldap":   conf.GetLDAPAuth() != nil,
+		"gitlab": conf.GetGitLabAuth() != nil,
+	}
+	for _, team := range teams {
+		for _, connector := range team.Connectors() {
+			if !configured[connector] {
+				return fmt.Errorf("team `%s` grants roles to %s users, but %s auth is not configured. Deploy with %s auth first", team.Name, connector, connector, connector)
+			}
+		}
+	}
+	return nil
+}
+
+// setTeams sets the teams stored by ApplyTeams again, so that a deploy does not undo them
+func (client *Client) setTeams(flyClient fly.IClient, c config.ConfigView) error {
+	teams := c.GetTeams()
+	if len(teams) == 0 {
+		return nil
+	}
+	if err := flyClient.SetTeams(teams); err != nil {
+		return fmt.Errorf("error setting teams: [%v]", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package concourse
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/config/configfakes"
+	"github.com/EngineerBetter/control-tower/fly"
+	"github.com/EngineerBetter/control-tower/fly/flyfakes"
+	"github.com/EngineerBetter/control-tower/iaas"
+)
+
+var platformTeam = config.Team{
+	Name: "platform",
+	Roles: []config.TeamRole{
+		{Name: "owner", GitHub: &config.TeamGitHubUsers{Teams: []string{"EngineerBetter:platform"}}},
+		{Name: "viewer", Local: &config.TeamLocalUsers{Users: []string{"admin"}}},
+	},
+}
+
+func teamsTestClient(conf config.Config, flyClient *flyfakes.FakeIClient) (*Client, *configfakes.FakeIClient, *bytes.Buffer) {
+	configClient := &configfakes.FakeIClient{}
+	configClient.LoadReturns(conf, nil)
+	stdout := &bytes.Buffer{}
+	client := &Client{
+		configClient: configClient,
+		flyClientFactory: func(iaas.Provider, fly.Credentials, io.Writer, io.Writer, []byte) (fly.IClient, error) {
+			return flyClient, nil
+		},
+		stdout: stdout,
+	}
+	return client, configClient, stdout
+}
+
+func TestClient_ApplyTeams(t *testing.T) {
+	flyClient := &flyfakes.FakeIClient{}
+	client, configClient, _ := teamsTestClient(config.Config{Deployment: "control-tower-ci", GithubClientID: "id", GithubClientSecret: "secret"}, flyClient)
+
+	if err := client.ApplyTeams([]config.Team{platformTeam}); err != nil {
+		t.Fatalf("ApplyTeams() error = %v", err)
+	}
+	if flyClient.SetTeamsCallCount() != 1 || !reflect.DeepEqual(flyClient.SetTeamsArgsForCall(0), []config.Team{platformTeam}) {
+		t.Errorf("did not set the teams in Concourse")
+	}
+	if configClient.UpdateCallCount() != 1 || !reflect.DeepEqual(configClient.UpdateArgsForCall(0).Teams, []config.Team{platformTeam}) {
+		t.Errorf("did not store the teams in the config")
+	}
+	if configClient.LockCallCount() != 1 || configClient.LockArgsForCall(0) != "teams" || configClient.UnlockCallCount() != 1 {
+		t.Errorf("did not hold the lock while setting teams")
+	}
+}
+
+func TestClient_ApplyTeamsWithoutConnector(t *testing.T) {
+	flyClient := &flyfakes.FakeIClient{}
+	client, configClient, _ := teamsTestClient(config.Config{Deployment: "control-tower-ci"}, flyClient)
+
+	err := client.ApplyTeams([]config.Team{platformTeam})
+	if err == nil || !strings.Contains(err.Error(), "github auth is not configured") {
+		t.Fatalf("ApplyTeams() error = %v, want github auth to be required", err)
+	}
+	if flyClient.SetTeamsCallCount() != 0 || configClient.UpdateCallCount() != 0 {
+		t.Errorf("set teams that use an auth provider that is not configured")
+	}
+}
+
+func TestClient_TeamsDiff(t *testing.T) {
+	dev := config.Team{Name: "dev", Roles: []config.TeamRole{{Name: "member", Local: &config.TeamLocalUsers{Users: []string{"admin"}}}}}
+
+	tests := []struct {
+		name  string
+		teams []config.Team
+		live  map[string]config.TeamAuth
+		want  []string
+	}{
+		{
+			name:  "no drift",
+			teams: []config.Team{platformTeam},
+			live: map[string]config.TeamAuth{
+				"main":     {"owner": {Users: []string{"local:admin"}}},
+				"platform": platformTeam.Auth(),
+			},
+			want: []string{"none"},
+		},
+		{
+			name:  "drift",
+			teams: []config.Team{platformTeam, dev},
+			live: map[string]config.TeamAuth{
+				"main":     {"owner": {Users: []string{"local:admin"}}},
+				"platform": {"owner": {Groups: []string{"github:engineerbetter"}}, "viewer": {Users: []string{"local:admin"}}},
+				"legacy":   {"owner": {Users: []string{"local:admin"}}},
+			},
+			want: []string{
+				"~ platform/owner: missing github:engineerbetter:platform; unexpected github:engineerbetter",
+				"+ dev: not in Concourse",
+				"- legacy: not managed by control-tower",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flyClient := &flyfakes.FakeIClient{}
+			flyClient.TeamAuthReturns(tt.live, nil)
+			client, configClient, stdout := teamsTestClient(config.Config{Deployment: "control-tower-ci", Teams: tt.teams}, flyClient)
+
+			if err := client.TeamsDiff(nil); err != nil {
+				t.Fatalf("TeamsDiff() error = %v", err)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(stdout.String(), line+"\n") {
+					t.Errorf("TeamsDiff() output missing %q:\n%s", line, stdout.String())
+				}
+			}
+			if configClient.LockCallCount() != 0 || configClient.UpdateCallCount() != 0 || flyClient.SetTeamsCallCount() != 0 {
+				t.Errorf("TeamsDiff() changed the deployment")
+			}
+		})
+	}
+}
@@ -2,10 +2,13 @@ package concourse
 
 import (
 	"fmt"
+	"path"
+	"path/filepath"
 
 	"github.com/EngineerBetter/control-tower/config"
 	"github.com/EngineerBetter/control-tower/iaas"
 	"github.com/EngineerBetter/control-tower/terraform"
+	"github.com/asaskevich/govalidator"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -15,9 +18,11 @@ type TFInputVarsFactory interface {
 	NewInputVars(conf config.ConfigView) terraform.InputVars
 }
 
-func NewTFInputVarsFactory(provider iaas.Provider) (TFInputVarsFactory, error) {
+func NewTFInputVarsFactory(provider iaas.Provider, stateBackend config.StateBackend) (TFInputVarsFactory, error) {
 	if provider.IAAS() == iaas.AWS {
-		return &AWSInputVarsFactory{}, nil
+		return &AWSInputVarsFactory{
+			stateBackend: stateBackend,
+		}, nil
 	} else if provider.IAAS() == iaas.GCP {
 		credentialsPath, err := provider.Attr("credentials_path")
 		if err != nil {
@@ -34,15 +39,66 @@ func NewTFInputVarsFactory(provider iaas.Provider) (TFInputVarsFactory, error) {
 			project:         project,
 			region:          provider.Region(),
 			zone:            provider.Zone("", ""),
+			stateBackend:    stateBackend,
+		}, nil
+	} else if provider.IAAS() == iaas.Azure {
+		attrs := map[string]string{}
+		for _, key := range []string{"subscription_id", "tenant_id", "client_id", "client_secret", "storage_account", "storage_resource_group"} {
+			value, err := provider.Attr(key)
+			if err != nil {
+				return &AzureInputVarsFactory{}, fmt.Errorf("Error finding attribute [%s]: [%v]", key, err)
+			}
+			attrs[key] = value
+		}
+
+		return &AzureInputVarsFactory{
+			subscriptionID:       attrs["subscription_id"],
+			tenantID:             attrs["tenant_id"],
+			clientID:             attrs["client_id"],
+			clientSecret:         attrs["client_secret"],
+			storageAccount:       attrs["storage_account"],
+			storageResourceGroup: attrs["storage_resource_group"],
+			region:               provider.Region(),
+			stateBackend:         stateBackend,
 		}, nil
 	}
 
 	return nil, fmt.Errorf("IAAS not supported [%s]", provider.IAAS())
 }
 
-type AWSInputVarsFactory struct{}
+// tfStateBackend describes where terraform should keep the state of the deployment in c
+func tfStateBackend(stateBackend config.StateBackend, c config.ConfigView) terraform.StateBackend {
+	switch stateBackend.Type {
+	case config.LocalBackend:
+		return terraform.StateBackend{
+			Type: config.LocalBackend,
+			Path: filepath.Join(stateBackend.Dir, c.GetConfigBucket(), c.GetTFStatePath()),
+		}
+	case config.S3Backend:
+		region := stateBackend.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		return terraform.StateBackend{
+			Type:            config.S3Backend,
+			Endpoint:        stateBackend.Endpoint,
+			Region:          region,
+			AccessKeyID:     stateBackend.AccessKeyID,
+			SecretAccessKey: stateBackend.SecretAccessKey,
+		}
+	}
+	return terraform.StateBackend{}
+}
+
+type AWSInputVarsFactory struct {
+	stateBackend config.StateBackend
+}
 
 func (f *AWSInputVarsFactory) NewInputVars(c config.ConfigView) terraform.InputVars {
+	// The network was checked by checkNetwork as the config was built
+	natSubnet, _ := natCIDR(c)
+	webSubnets, _ := webCIDRs(c)
+
 	return &terraform.AWSInputVars{
 		NetworkCIDR:            c.GetNetworkCIDR(),
 		PublicCIDR:             c.GetPublicCIDR(),
@@ -53,7 +109,12 @@ func (f *AWSInputVarsFactory) NewInputVars(c config.ConfigView) terraform.InputV
 		Deployment:             c.GetDeployment(),
 		HostedZoneID:           c.GetHostedZoneID(),
 		HostedZoneRecordPrefix: c.GetHostedZoneRecordPrefix(),
+		InstanceIdentity:       c.UsesInstanceIdentity(),
+		KeepAccessKeys:         c.HasLegacyAccessKeys(),
 		Namespace:              c.GetNamespace(),
+		NATCIDR:                natSubnet,
+		Private:                c.IsPrivate(),
+		PrivateDomain:          privateDomain(c),
 		Project:                c.GetProject(),
 		PublicKey:              c.GetPublicKey(),
 		RDSDefaultDatabaseName: c.GetRDSDefaultDatabaseName(),
@@ -64,8 +125,88 @@ func (f *AWSInputVarsFactory) NewInputVars(c config.ConfigView) terraform.InputV
 		RDS2CIDR:               c.GetRDS2CIDR(),
 		Region:                 c.GetRegion(),
 		SourceAccessIP:         c.GetSourceAccessIP(),
+		StateBackend:           tfStateBackend(f.stateBackend, c),
 		TFStatePath:            c.GetTFStatePath(),
+		WebCIDRs:               webSubnets,
+		WebCount:               c.GetConcourseWebCount(),
+	}
+}
+
+// natCIDR returns the subnet that the NAT gateway of a private deployment is placed in. Other
+// deployments keep the NAT gateway in the public subnet.
+func natCIDR(c config.ConfigView) (string, error) {
+	if !c.IsPrivate() {
+		return "", nil
+	}
+	subnet, err := config.NATCIDR(c.GetNetworkCIDR())
+	if err != nil {
+		return "", err
 	}
+	if err = checkSubnetsFree("NAT gateway", []string{subnet}, c); err != nil {
+		return "", err
+	}
+	return subnet, nil
+}
+
+// privateDomain returns the domain that a private deployment is reached on, if it has one. Without
+// one the domain holds the private IP of the web VM, which needs no DNS record.
+func privateDomain(c config.ConfigView) string {
+	if !c.IsPrivate() || govalidator.IsIPv4(c.GetDomain()) {
+		return ""
+	}
+	return c.GetDomain()
+}
+
+// webCIDRs returns the subnets that the web VMs of a deployment with more than one of them are
+// spread across. A single web VM stays in the public subnet.
+func webCIDRs(c config.ConfigView) ([]string, error) {
+	if c.GetConcourseWebCount() < 2 {
+		return nil, nil
+	}
+	subnets, err := config.WebCIDRs(c.GetNetworkCIDR())
+	if err != nil {
+		return nil, err
+	}
+	if err = checkSubnetsFree("web", subnets, c); err != nil {
+		return nil, err
+	}
+	return subnets, nil
+}
+
+// checkSubnetsFree returns an error if any of the subnets taken from the end of the network range
+// overlaps the public, private or RDS subnets of c, which custom CIDRs may have placed there
+func checkSubnetsFree(purpose string, subnets []string, c config.ConfigView) error {
+	inUse := []struct {
+		name string
+		cidr string
+	}{
+		{"public", c.GetPublicCIDR()},
+		{"private", c.GetPrivateCIDR()},
+		{"first RDS", c.GetRDS1CIDR()},
+		{"second RDS", c.GetRDS2CIDR()},
+	}
+	for _, subnet := range subnets {
+		for _, used := range inUse {
+			if config.SubnetsOverlap(subnet, used.cidr) {
+				return fmt.Errorf("the %s subnet %s overlaps the %s subnet %s, leave the last four /28s of network range %s free",
+					purpose, subnet, used.name, used.cidr, c.GetNetworkCIDR())
+			}
+		}
+	}
+	return nil
+}
+
+// checkNetwork returns an error if the subnets that terraform adds to an AWS deployment do not fit
+// in its network range alongside the ones it was given
+func checkNetwork(c config.ConfigView, provider iaas.Provider) error {
+	if provider.IAAS() != iaas.AWS {
+		return nil
+	}
+	if _, err := natCIDR(c); err != nil {
+		return err
+	}
+	_, err := webCIDRs(c)
+	return err
 }
 
 type GCPInputVarsFactory struct {
@@ -73,6 +214,7 @@ type GCPInputVarsFactory struct {
 	project         string
 	region          string
 	zone            string
+	stateBackend    config.StateBackend
 }
 
 func (f *GCPInputVarsFactory) NewInputVars(c config.ConfigView) terraform.InputVars {
@@ -88,12 +230,62 @@ func (f *GCPInputVarsFactory) NewInputVars(c config.ConfigView) terraform.InputV
 		DNSRecordSetPrefix: c.GetHostedZoneRecordPrefix(),
 		ExternalIP:         c.GetSourceAccessIP(),
 		GCPCredentialsJSON: f.credentialsPath,
+		InstanceIdentity:   c.UsesInstanceIdentity(),
+		KeepAccessKeys:     c.HasLegacyAccessKeys(),
 		Namespace:          c.GetNamespace(),
 		Project:            f.project,
 		Region:             f.region,
+		StateBackend:       tfStateBackend(f.stateBackend, c),
 		Tags:               "",
+		TFStatePath:        c.GetTFStatePath(),
 		Zone:               f.zone,
 		PublicCIDR:         c.GetPublicCIDR(),
 		PrivateCIDR:        c.GetPrivateCIDR(),
+		WebCount:           c.GetConcourseWebCount(),
+	}
+}
+
+type AzureInputVarsFactory struct {
+	subscriptionID       string
+	tenantID             string
+	clientID             string
+	clientSecret         string
+	storageAccount       string
+	storageResourceGroup string
+	region               string
+	stateBackend         config.StateBackend
+}
+
+func (f *AzureInputVarsFactory) NewInputVars(c config.ConfigView) terraform.InputVars {
+	var dnsZoneName string
+	if c.GetHostedZoneID() != "" {
+		dnsZoneName = path.Base(c.GetHostedZoneID())
+	}
+
+	return &terraform.AzureInputVars{
+		AllowIPs:             c.GetAllowIPs(),
+		ClientID:             f.clientID,
+		ClientSecret:         f.clientSecret,
+		ConfigBucket:         c.GetConfigBucket(),
+		DBName:               c.GetRDSDefaultDatabaseName(),
+		DBPassword:           c.GetRDSPassword(),
+		DBTier:               c.GetRDSInstanceClass(),
+		DBUsername:           c.GetRDSUsername(),
+		Deployment:           c.GetDeployment(),
+		DNSRecordSetPrefix:   c.GetHostedZoneRecordPrefix(),
+		DNSZoneName:          dnsZoneName,
+		DNSZoneResourceGroup: iaas.AzureResourceGroup(c.GetHostedZoneID()),
+		ExternalIP:           c.GetSourceAccessIP(),
+		Namespace:            c.GetNamespace(),
+		NetworkCIDR:          c.GetNetworkCIDR(),
+		PrivateCIDR:          c.GetPrivateCIDR(),
+		PublicCIDR:           c.GetPublicCIDR(),
+		Region:               f.region,
+		StorageAccount:       f.storageAccount,
+		StorageResourceGroup: f.storageResourceGroup,
+		StateBackend:         tfStateBackend(f.stateBackend, c),
+		SubscriptionID:       f.subscriptionID,
+		TenantID:             f.tenantID,
+		TFStatePath:          c.GetTFStatePath(),
 	}
 }
@@ -0,0 +1,86 @@
+package concourse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/config"
+)
+
+func TestNATCIDR(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    config.Config
+		want    string
+		wantErr string
+	}{
+		{name: "public", conf: config.Config{NetworkCIDR: "10.0.0.0/16"}, want: ""},
+		{name: "private", conf: config.Config{NetworkCIDR: "10.0.0.0/16", Private: true}, want: "10.0.255.240/28"},
+		{name: "small network", conf: config.Config{NetworkCIDR: "192.168.1.0/24", Private: true}, want: "192.168.1.240/28"},
+		{name: "network too small", conf: config.Config{NetworkCIDR: "192.168.1.0/28", Private: true}, wantErr: "network range 192.168.1.0/28 is too small for the subnet of the NAT gateway"},
+		{name: "custom CIDRs in the way", conf: config.Config{NetworkCIDR: "192.168.1.0/24", PublicCIDR: "192.168.1.0/25", PrivateCIDR: "192.168.1.128/26", RDS1CIDR: "192.168.1.192/27", RDS2CIDR: "192.168.1.224/27", Private: true}, wantErr: "the NAT gateway subnet 192.168.1.240/28 overlaps the second RDS subnet 192.168.1.224/27"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := natCIDR(tt.conf)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("natCIDR() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrivateDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		conf config.Config
+		want string
+	}{
+		{name: "public", conf: config.Config{Domain: "ci.example.com"}, want: ""},
+		{name: "private with a domain", conf: config.Config{Domain: "ci.example.com", Private: true}, want: "ci.example.com"},
+		{name: "private without a domain", conf: config.Config{Domain: "10.0.0.8", Private: true}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := privateDomain(tt.conf); got != tt.want {
+				t.Errorf("privateDomain() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebCIDRs(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    config.Config
+		want    []string
+		wantErr string
+	}{
+		{name: "one web node", conf: config.Config{NetworkCIDR: "10.0.0.0/16"}, want: nil},
+		{name: "several web nodes", conf: config.Config{NetworkCIDR: "10.0.0.0/16", ConcourseWebCount: 2}, want: []string{"10.0.255.224/28", "10.0.255.208/28", "10.0.255.192/28"}},
+		{name: "network too small", conf: config.Config{NetworkCIDR: "10.0.0.0/27", ConcourseWebCount: 2}, wantErr: "network range 10.0.0.0/27 is too small for the subnets of several web VMs"},
+		{name: "custom CIDRs in the way", conf: config.Config{NetworkCIDR: "10.0.0.0/24", PublicCIDR: "10.0.0.0/25", PrivateCIDR: "10.0.0.128/25", ConcourseWebCount: 2}, wantErr: "the web subnet 10.0.0.224/28 overlaps the private subnet 10.0.0.128/25"},
+		{name: "custom CIDRs leaving the end free", conf: config.Config{NetworkCIDR: "10.0.0.0/24", PublicCIDR: "10.0.0.0/26", PrivateCIDR: "10.0.0.64/26", ConcourseWebCount: 2}, want: []string{"10.0.0.224/28", "10.0.0.208/28", "10.0.0.192/28"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := webCIDRs(tt.conf)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("webCIDRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package concourse
+
+import (
+	"fmt"
+
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/releases"
+	"github.com/EngineerBetter/control-tower/resource"
+)
+
+// ReleaseFinder finds the releases of control-tower a deployment can upgrade to
+type ReleaseFinder interface {
+	Latest(channel string) (releases.Release, error)
+	Tagged(tag string) (releases.Release, error)
+	Versions(release releases.Release, iaas string) (map[string]string, error)
+}
+
+// CheckUpgrade writes the newest release on the deployment's release channel, and the versions of
+// Concourse and BOSH components that upgrading to it would change
+func (client *Client) CheckUpgrade(finder ReleaseFinder) error {
+	conf, err := client.configClient.Load()
+	if err != nil {
+		return fmt.Errorf("error loading existing config [%v]", err)
+	}
+	channel := conf.GetReleaseChannel()
+	if channel == "" {
+		channel = config.ReleaseChannelStable
+	}
+
+	latest, err := finder.Latest(channel)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(client.stdout, "Deployed: control-tower %s\n", conf.GetVersion())
+	fmt.Fprintf(client.stdout, "Release channel: %s\n", channel)
+	fmt.Fprintf(client.stdout, "Available: control-tower %s\n", latest.Tag)
+	if !releases.Newer(latest.Tag, conf.GetVersion()) {
+		_, err = fmt.Fprintln(client.stdout, "The deployment is up to date.")
+		return err
+	}
+
+	deployed, err := client.deployedVersions(finder, conf.GetVersion())
+	if err != nil {
+		return err
+	}
+	available, err := finder.Versions(latest, client.provider.IAAS().String())
+	if err != nil {
+		return err
+	}
+	changes := releases.Changes(deployed, available)
+	if len(changes) == 0 {
+		_, err = fmt.Fprintln(client.stdout, "Upgrading would not change the versions of Concourse or BOSH.")
+		return err
+	}
+	fmt.Fprintln(client.stdout, "Upgrading would change:")
+	for _, change := range changes {
+		fmt.Fprintf(client.stdout, "  %s\n", change)
+	}
+	return nil
+}
+
+// deployedVersions reads the component versions of the deployed release from the version files
+// bundled in this control-tower when it is that release, and from control-tower-ops otherwise
+func (client *Client) deployedVersions(finder ReleaseFinder, version string) (map[string]string, error) {
+	if version == client.version {
+		concourseVersions, _ := client.provider.Choose(iaas.Choice{
+			AWS:   resource.AWSReleaseVersions,
+			GCP:   resource.GCPReleaseVersions,
+			Azure: resource.AzureReleaseVersions,
+		}).(string)
+		return releases.ParseVersions([]byte(concourseVersions), client.versionFile)
+	}
+
+	deployed, err := finder.Tagged(version)
+	if err != nil {
+		return nil, err
+	}
+	return finder.Versions(deployed, client.provider.IAAS().String())
+}
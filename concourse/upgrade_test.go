@@ -0,0 +1,106 @@
+package concourse
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/config/configfakes"
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/iaas/iaasfakes"
+	"github.com/EngineerBetter/control-tower/releases"
+)
+
+type fakeReleaseFinder struct {
+	latest   releases.Release
+	versions map[string]map[string]string
+	channel  string
+}
+
+func (f *fakeReleaseFinder) Latest(channel string) (releases.Release, error) {
+	f.channel = channel
+	return f.latest, nil
+}
+
+func (f *fakeReleaseFinder) Tagged(tag string) (releases.Release, error) {
+	if _, ok := f.versions[tag]; !ok {
+		return releases.Release{}, fmt.Errorf("no release %s", tag)
+	}
+	return releases.Release{Tag: tag}, nil
+}
+
+func (f *fakeReleaseFinder) Versions(release releases.Release, iaas string) (map[string]string, error) {
+	return f.versions[release.Tag], nil
+}
+
+func TestClient_CheckUpgrade(t *testing.T) {
+	finder := &fakeReleaseFinder{
+		latest: releases.Release{Tag: "0.17.10"},
+		versions: map[string]map[string]string{
+			"0.17.9":  {"concourse": "7.4.0", "director bosh": "270.1.0"},
+			"0.17.10": {"concourse": "7.5.0", "director bosh": "270.1.0"},
+		},
+	}
+	tests := []struct {
+		name        string
+		conf        config.Config
+		version     string
+		wantChannel string
+		want        []string
+	}{
+		{
+			name:        "up to date",
+			conf:        config.Config{Version: "0.17.10", ReleaseChannel: "0.17.x"},
+			version:     "0.17.10",
+			wantChannel: "0.17.x",
+			want:        []string{"Deployed: control-tower 0.17.10\nRelease channel: 0.17.x\nAvailable: control-tower 0.17.10\nThe deployment is up to date.\n"},
+		},
+		{
+			name:        "deployed by an older control-tower",
+			conf:        config.Config{Version: "0.17.9"},
+			version:     "0.17.10",
+			wantChannel: "stable",
+			want:        []string{"Available: control-tower 0.17.10\n", "Upgrading would change:\n  concourse: 7.4.0 -> 7.5.0\n"},
+		},
+		{
+			name:        "deployed by this control-tower",
+			conf:        config.Config{Version: "0.17.8", ReleaseChannel: "pre-release"},
+			version:     "0.17.8",
+			wantChannel: "pre-release",
+			want:        []string{"Upgrading would change:\n  concourse: 7.3.0 -> 7.5.0\n  director bosh: 270.0.0 -> 270.1.0\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configClient := &configfakes.FakeIClient{}
+			configClient.LoadReturns(tt.conf, nil)
+			provider := &iaasfakes.FakeProvider{}
+			provider.IAASReturns(iaas.AWS)
+			provider.ChooseStub = func(c iaas.Choice) interface{} {
+				return `[{"type": "replace", "path": "/releases/name=concourse", "value": {"name": "concourse", "version": "7.3.0"}}]`
+			}
+
+			stdout := &bytes.Buffer{}
+			client := &Client{
+				configClient: configClient,
+				provider:     provider,
+				stdout:       stdout,
+				version:      tt.version,
+				versionFile:  []byte(`{"bosh": {"version": "270.0.0"}}`),
+			}
+			if err := client.CheckUpgrade(finder); err != nil {
+				t.Fatalf("Client.CheckUpgrade() error = %v", err)
+			}
+			if finder.channel != tt.wantChannel {
+				t.Errorf("Client.CheckUpgrade() looked for releases on %q, want %q", finder.channel, tt.wantChannel)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Client.CheckUpgrade() output = %q, want it to contain %q", stdout.String(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OIDCAuth logs users in to Concourse with a generic OpenID Connect provider, such as Okta or Keycloak
+type OIDCAuth struct {
+	Issuer   string `json:"issuer"`
+	ClientID string `json:"client_id"`
+	// Scopes are requested from the provider in addition to openid
+	Scopes []string `json:"scopes"`
+	// GroupsClaim is the claim of the ID token that lists the groups a user belongs to
+	GroupsClaim string `json:"groups_claim"`
+}
+
+func (a OIDCAuth) String() string {
+	return fmt.Sprintf("%s as %s, scopes %s, groups from %s", a.Issuer, a.ClientID, strings.Join(a.Scopes, ","), a.GroupsClaim)
+}
+
+// FormatOIDCAuth describes an OIDC provider on one line, or returns nothing if there is none
+func FormatOIDCAuth(a *OIDCAuth) string {
+	if a == nil {
+		return ""
+	}
+	return a.String()
+}
+
+// LDAPAuth logs users in to Concourse with an LDAP server
+type LDAPAuth struct {
+	// Host is the host and optional port of the LDAP server
+	Host   string `json:"host"`
+	BindDN string `json:"bind_dn"`
+	// UserSearchBaseDN, UserSearchFilter and UserSearchUsername find the entry of a user logging in
+	UserSearchBaseDN   string `json:"user_search_base_dn"`
+	UserSearchFilter   string `json:"user_search_filter,omitempty"`
+	UserSearchUsername string `json:"user_search_username"`
+	// GroupSearchBaseDN and GroupSearchFilter find the groups of a user. Groups are not searched for
+	// without a base DN
+	GroupSearchBaseDN string `json:"group_search_base_dn,omitempty"`
+	GroupSearchFilter string `json:"group_search_filter,omitempty"`
+}
+
+func (a LDAPAuth) String() string {
+	s := fmt.Sprintf("%s as %s, users in %s", a.Host, a.BindDN, a.UserSearchBaseDN)
+	if a.GroupSearchBaseDN != "" {
+		s += fmt.Sprintf(", groups in %s", a.GroupSearchBaseDN)
+	}
+	return s
+}
+
+// FormatLDAPAuth describes an LDAP server on one line, or returns nothing if there is none
+func FormatLDAPAuth(a *LDAPAuth) string {
+	if a == nil {
+		return ""
+	}
+	return a.String()
+}
+
+// GitLabAuth logs users in to Concourse with gitlab.com or a self-hosted GitLab
+type GitLabAuth struct {
+	ClientID string `json:"client_id"`
+	Host     string `json:"host"`
+}
+
+func (a GitLabAuth) String() string {
+	return fmt.Sprintf("%s as %s", a.Host, a.ClientID)
+}
+
+// FormatGitLabAuth describes a GitLab provider on one line, or returns nothing if there is none
+func FormatGitLabAuth(a *GitLabAuth) string {
+	if a == nil {
+		return ""
+	}
+	return a.String()
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/EngineerBetter/control-tower/iaas"
+)
+
+const (
+	// IAASBackend keeps state in a bucket belonging to the IAAS being deployed to
+	IAASBackend = "iaas"
+	// LocalBackend keeps state in a directory on the local filesystem
+	LocalBackend = "local"
+	// S3Backend keeps state in any S3-compatible object store, such as MinIO
+	S3Backend = "s3"
+)
+
+// Backend stores the config, director state and terraform state of a deployment
+//
+//counterfeiter:generate . Backend
+type Backend interface {
+	BucketExists(name string) (bool, error)
+	CreateBucket(name string) error
+	DeleteVersionedBucket(name string) error
+	HasFile(bucket, path string) (bool, error)
+	LoadFile(bucket, path string) ([]byte, error)
+	WriteFile(bucket, path string, contents []byte) error
+}
+
+// StateBackend selects where state is stored and how to reach it
+type StateBackend struct {
+	Type            string
+	Dir             string
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Validate returns an error if the settings for the chosen backend are incomplete
+func (s StateBackend) Validate() error {
+	switch s.Type {
+	case "", IAASBackend:
+		return nil
+	case LocalBackend:
+		if s.Dir == "" {
+			return fmt.Errorf("--state-dir is required when --state-backend is %s", LocalBackend)
+		}
+		return nil
+	case S3Backend:
+		if s.Endpoint == "" {
+			return fmt.Errorf("--state-endpoint is required when --state-backend is %s", S3Backend)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown state backend [%s], must be one of %s, %s or %s", s.Type, IAASBackend, LocalBackend, S3Backend)
+}
+
+// Resolve returns the settings with Dir made absolute, so that state stays where it was asked for
+// whichever directory terraform and the other tools are run from
+func (s StateBackend) Resolve() (StateBackend, error) {
+	if s.Dir == "" {
+		return s, nil
+	}
+	dir, err := filepath.Abs(s.Dir)
+	if err != nil {
+		return s, fmt.Errorf("error resolving --state-dir [%s]: [%v]", s.Dir, err)
+	}
+	s.Dir = dir
+	return s, nil
+}
+
+// NewBackend returns the Backend described by settings, falling back to the IAAS provider
+func NewBackend(provider iaas.Provider, settings StateBackend) (Backend, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch settings.Type {
+	case LocalBackend:
+		return NewLocal(settings.Dir), nil
+	case S3Backend:
+		return NewS3(settings.Endpoint, settings.Region, settings.AccessKeyID, settings.SecretAccessKey)
+	}
+	return provider, nil
+}
@@ -0,0 +1,35 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/EngineerBetter/control-tower/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateBackend", func() {
+	Describe("Resolve", func() {
+		It("makes a relative state directory absolute", func() {
+			wd, err := os.Getwd()
+			Expect(err).ToNot(HaveOccurred())
+
+			resolved, err := StateBackend{Type: LocalBackend, Dir: "./state"}.Resolve()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resolved.Dir).To(Equal(filepath.Join(wd, "state")))
+			Expect(resolved.Type).To(Equal(LocalBackend))
+		})
+
+		It("leaves an absolute state directory alone", func() {
+			resolved, err := StateBackend{Type: LocalBackend, Dir: "/var/lib/control-tower"}.Resolve()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resolved.Dir).To(Equal("/var/lib/control-tower"))
+		})
+
+		It("leaves settings without a state directory alone", func() {
+			settings := StateBackend{Type: S3Backend, Endpoint: "https://minio.example.com"}
+			Expect(settings.Resolve()).To(Equal(settings))
+		})
+	})
+})
@@ -3,16 +3,21 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/EngineerBetter/control-tower/iaas"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 
-const terraformStateFileName = "terraform.tfstate"
-const configFilePath = "config.json"
+const (
+	terraformStateFileName = "terraform.tfstate"
+	// ConfigFilename is the name of the config file in the config bucket
+	ConfigFilename = "config.json"
+)
 
-//counterfeiter: generate . IClient
+//counterfeiter:generate . IClient
 type IClient interface {
 	Load() (Config, error)
 	DeleteAll(config ConfigView) error
@@ -21,62 +26,117 @@ type IClient interface {
 	HasAsset(filename string) (bool, error)
 	ConfigExists() (bool, error)
 	LoadAsset(filename string) ([]byte, error)
+	SealWriter(name string, w io.Writer) (io.WriteCloser, error)
+	OpenReader(name string, r io.Reader) (io.Reader, error)
 	NewConfig() Config
 	EnsureBucketExists() error
+	EncryptPlaintextAssets(filenames ...string) error
+	Lock(operation string) error
+	Unlock() error
+	Revisions() ([]Revision, error)
+	RecordRevision(operation string, filenames ...string) (Revision, error)
+	LoadRevision(number int, filename string) ([]byte, error)
+	RestoreRevision(number int, filenames ...string) error
 }
 
 // Client is a client for loading the config file  from S3
 type Client struct {
 	Iaas         iaas.Provider
+	Backend      Backend
 	Project      string
 	Namespace    string
 	BucketName   string
 	BucketExists bool
 	BucketError  error
+	KeyWrapper   KeyWrapper
+	LockTimeout  time.Duration
+	ForceUnlock  bool
+	lock         lockState
 }
 
-// New instantiates a new client
+// New instantiates a new client that keeps state in the IAAS' own storage
 func New(iaas iaas.Provider, project, namespace string) *Client {
+	return NewWithBackend(iaas, iaas, project, namespace)
+}
+
+// NewWithBackend instantiates a new client that keeps state in backend
+func NewWithBackend(iaas iaas.Provider, backend Backend, project, namespace string) *Client {
 	namespace = determineNamespace(namespace, iaas.Region())
-	bucketName, exists, err := determineBucketName(iaas, namespace, project)
+	bucketName, exists, err := determineBucketName(backend, iaas.Region(), namespace, project)
 
 	return &Client{
-		iaas,
-		project,
-		namespace,
-		bucketName,
-		exists,
-		err,
+		Iaas:         iaas,
+		Backend:      backend,
+		Project:      project,
+		Namespace:    namespace,
+		BucketName:   bucketName,
+		BucketExists: exists,
+		BucketError:  err,
 	}
 }
 
-// StoreAsset stores an associated configuration file
+// StoreAsset stores an associated configuration file, encrypting it if a KeyWrapper is set
 func (client *Client) StoreAsset(filename string, contents []byte) error {
-	return client.Iaas.WriteFile(client.configBucket(),
+	if client.KeyWrapper != nil {
+		var err error
+		contents, err = sealAsset(client.KeyWrapper, filename, contents)
+		if err != nil {
+			return fmt.Errorf("error encrypting [%v]: [%v]", filename, err)
+		}
+	}
+
+	return client.backend().WriteFile(client.configBucket(),
 		filename,
 		contents,
 	)
 }
 
-// LoadAsset loads an associated configuration file
+// LoadAsset loads an associated configuration file, decrypting it if it was stored encrypted
 func (client *Client) LoadAsset(filename string) ([]byte, error) {
-	return client.Iaas.LoadFile(
+	contents, err := client.backend().LoadFile(
 		client.configBucket(),
 		filename,
 	)
+	if err != nil || !IsEncrypted(contents) {
+		return contents, err
+	}
+
+	return openAsset(client.KeyWrapper, filename, contents)
+}
+
+// SealWriter returns a writer that encrypts what is written to it into w when encryption is enabled,
+// for artifacts kept outside the config bucket such as backups. It must be closed to finish the
+// stream, and the name must be given again to OpenReader.
+func (client *Client) SealWriter(name string, w io.Writer) (io.WriteCloser, error) {
+	if client.KeyWrapper == nil {
+		return nopWriteCloser{w}, nil
+	}
+	return newSealWriter(client.KeyWrapper, name, w)
+}
+
+// OpenReader reverses SealWriter, reading streams that were never encrypted unchanged
+func (client *Client) OpenReader(name string, r io.Reader) (io.Reader, error) {
+	return newOpenReader(client.KeyWrapper, name, r)
 }
 
 // HasAsset returns true if an associated configuration file exists
 func (client *Client) HasAsset(filename string) (bool, error) {
-	return client.Iaas.HasFile(
+	return client.backend().HasFile(
 		client.configBucket(),
 		filename,
 	)
 }
 
-// ConfigExists returns true if the configuration file exists
+// ConfigExists returns true if the configuration file exists. When the bucket did not exist as the
+// client was created it is looked for first, as not every backend can look for files in a missing bucket.
 func (client *Client) ConfigExists() (bool, error) {
-	return client.HasAsset(configFilePath)
+	if !client.BucketExists {
+		exists, err := client.backend().BucketExists(client.configBucket())
+		if err != nil || !exists {
+			return false, err
+		}
+	}
+	return client.HasAsset(ConfigFilename)
 }
 
 // Update stores the control-tower config file to S3
@@ -86,12 +146,26 @@ func (client *Client) Update(config Config) error {
 		return err
 	}
 
-	return client.Iaas.WriteFile(client.configBucket(), configFilePath, bytes)
+	return client.StoreAsset(ConfigFilename, bytes)
 }
 
-// DeleteAll deletes the entire configuration bucket
+// DeleteAll deletes the entire configuration bucket, including any lease held by this client.
+// Once the bucket is gone the lease is dropped, so that neither the heartbeat nor Unlock writes
+// it back; if the bucket could not be deleted the lease is kept alive as before.
 func (client *Client) DeleteAll(config ConfigView) error {
-	return client.Iaas.DeleteVersionedBucket(config.GetConfigBucket())
+	held := client.stopHeartbeat()
+
+	if err := client.backend().DeleteVersionedBucket(config.GetConfigBucket()); err != nil {
+		if held != nil {
+			client.startHeartbeat()
+		}
+		return err
+	}
+
+	client.lock.mutex.Lock()
+	client.lock.lease = nil
+	client.lock.mutex.Unlock()
+	return nil
 }
 
 // Load loads an existing config file from S3
@@ -100,10 +174,7 @@ func (client *Client) Load() (Config, error) {
 		return Config{}, client.BucketError
 	}
 
-	configBytes, err := client.Iaas.LoadFile(
-		client.configBucket(),
-		configFilePath,
-	)
+	configBytes, err := client.LoadAsset(ConfigFilename)
 	if err != nil {
 		return Config{}, err
 	}
@@ -118,6 +189,38 @@ func (client *Client) Load() (Config, error) {
 	return conf, nil
 }
 
+// EncryptPlaintextAssets re-stores the config file and any of filenames that exist unencrypted,
+// so that deployments created before encryption was enabled are migrated
+func (client *Client) EncryptPlaintextAssets(filenames ...string) error {
+	if client.KeyWrapper == nil {
+		return nil
+	}
+
+	for _, filename := range append([]string{ConfigFilename}, filenames...) {
+		exists, err := client.HasAsset(filename)
+		if err != nil {
+			return fmt.Errorf("error determining if [%v] exists: [%v]", filename, err)
+		}
+		if !exists {
+			continue
+		}
+
+		contents, err := client.backend().LoadFile(client.configBucket(), filename)
+		if err != nil {
+			return fmt.Errorf("error loading [%v]: [%v]", filename, err)
+		}
+		if IsEncrypted(contents) {
+			continue
+		}
+
+		if err = client.StoreAsset(filename, contents); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (client *Client) NewConfig() Config {
 	return Config{
 		ConfigBucket: client.configBucket(),
@@ -134,14 +237,14 @@ func (client *Client) EnsureBucketExists() error {
 		return fmt.Errorf("client failed to configure properly: [%v]", client.BucketError)
 	}
 
-	exists, err := client.Iaas.BucketExists(client.BucketName)
+	exists, err := client.backend().BucketExists(client.BucketName)
 
 	if err != nil {
 		return fmt.Errorf("error determining if bucket [%v] exists: [%v]", client.BucketName, err)
 	}
 
 	if !exists {
-		err = client.Iaas.CreateBucket(client.BucketName)
+		err = client.backend().CreateBucket(client.BucketName)
 
 		if err != nil {
 			return fmt.Errorf("error creating config bucket [%v]: [%v]", client.BucketName, err)
@@ -151,6 +254,14 @@ func (client *Client) EnsureBucketExists() error {
 	return nil
 }
 
+// backend falls back to the IAAS for clients built without one
+func (client *Client) backend() Backend {
+	if client.Backend == nil {
+		return client.Iaas
+	}
+	return client.Backend
+}
+
 func (client *Client) configBucket() string {
 	return client.BucketName
 }
@@ -163,14 +274,14 @@ func createBucketName(deployment, extension string) string {
 	return fmt.Sprintf("%s-%s-config", deployment, extension)
 }
 
-func determineBucketName(iaas iaas.Provider, namespace, project string) (string, bool, error) {
-	regionBucketName := createBucketName(deployment(project), iaas.Region())
+func determineBucketName(backend Backend, region, namespace, project string) (string, bool, error) {
+	regionBucketName := createBucketName(deployment(project), region)
 	namespaceBucketName := createBucketName(deployment(project), namespace)
 
-	foundRegionNamedBucket, err := iaas.BucketExists(regionBucketName)
+	foundRegionNamedBucket, err := backend.BucketExists(regionBucketName)
 	var foundNamespacedBucket bool
 	if err != nil {
-		foundNamespacedBucket, err = iaas.BucketExists(namespaceBucketName)
+		foundNamespacedBucket, err = backend.BucketExists(namespaceBucketName)
 		if err != nil {
 			return "", false, fmt.Errorf("error looking for possible config buckets [%v] or [%v]: [%v]", regionBucketName, namespaceBucketName, err)
 		}
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/EngineerBetter/control-tower/config/configfakes"
 	"github.com/EngineerBetter/control-tower/iaas/iaasfakes"
 
 	. "github.com/EngineerBetter/control-tower/config"
@@ -126,6 +127,7 @@ func TestNew(t *testing.T) {
 			},
 			want: &Client{
 				Iaas:         provider,
+				Backend:      provider,
 				Project:      "aProject",
 				Namespace:    "eu-west-1",
 				BucketName:   "control-tower-aProject-eu-west-1-config",
@@ -145,6 +147,7 @@ func TestNew(t *testing.T) {
 			},
 			want: &Client{
 				Iaas:         provider,
+				Backend:      provider,
 				Project:      "aProject",
 				Namespace:    "someNamespace",
 				BucketName:   "control-tower-aProject-someNamespace-config",
@@ -164,6 +167,7 @@ func TestNew(t *testing.T) {
 			},
 			want: &Client{
 				Iaas:         provider,
+				Backend:      provider,
 				Project:      "aProject",
 				Namespace:    "someNamespace",
 				BucketName:   "control-tower-aProject-eu-west-1-config",
@@ -186,6 +190,7 @@ func TestNew(t *testing.T) {
 			},
 			want: &Client{
 				Iaas:         provider,
+				Backend:      provider,
 				Project:      "aProject",
 				Namespace:    "someNamespace",
 				BucketName:   "control-tower-aProject-someNamespace-config",
@@ -208,6 +213,7 @@ func TestNew(t *testing.T) {
 			},
 			want: &Client{
 				Iaas:         provider,
+				Backend:      provider,
 				Project:      "aProject",
 				Namespace:    "eu-west-1",
 				BucketName:   "control-tower-aProject-eu-west-1-config",
@@ -361,3 +367,54 @@ func TestClient_HasConfig(t *testing.T) {
 		})
 	}
 }
+
+var _ = Describe("Client with a separate state backend", func() {
+	var provider *iaasfakes.FakeProvider
+	var backend *configfakes.FakeBackend
+	var client *Client
+
+	BeforeEach(func() {
+		provider = &iaasfakes.FakeProvider{}
+		provider.RegionReturns("eu-west-1")
+		backend = &configfakes.FakeBackend{}
+		backend.BucketExistsReturns(true, nil)
+
+		client = NewWithBackend(provider, backend, "test", "")
+	})
+
+	It("looks for the bucket in the backend", func() {
+		Expect(client.BucketName).To(Equal("control-tower-test-eu-west-1-config"))
+		Expect(client.BucketExists).To(BeTrue())
+		Expect(backend.BucketExistsCallCount()).To(Equal(1))
+		Expect(provider.BucketExistsCallCount()).To(BeZero())
+	})
+
+	It("stores and loads assets in the backend", func() {
+		backend.LoadFileReturns([]byte("contents"), nil)
+
+		Expect(client.StoreAsset("director-state.json", []byte("contents"))).To(Succeed())
+		Expect(client.LoadAsset("director-state.json")).To(Equal([]byte("contents")))
+
+		bucket, path, contents := backend.WriteFileArgsForCall(0)
+		Expect(bucket).To(Equal("control-tower-test-eu-west-1-config"))
+		Expect(path).To(Equal("director-state.json"))
+		Expect(contents).To(Equal([]byte("contents")))
+		Expect(provider.WriteFileCallCount()).To(BeZero())
+		Expect(provider.LoadFileCallCount()).To(BeZero())
+	})
+
+	It("finds no config without reading a bucket that does not exist", func() {
+		backend.BucketExistsReturns(false, nil)
+		client = NewWithBackend(provider, backend, "test", "")
+
+		Expect(client.ConfigExists()).To(BeFalse())
+		Expect(backend.HasFileCallCount()).To(BeZero())
+		Expect(backend.CreateBucketCallCount()).To(BeZero())
+	})
+
+	It("deletes the bucket from the backend", func() {
+		Expect(client.DeleteAll(Config{ConfigBucket: "control-tower-test-eu-west-1-config"})).To(Succeed())
+		Expect(backend.DeleteVersionedBucketArgsForCall(0)).To(Equal("control-tower-test-eu-west-1-config"))
+		Expect(provider.DeleteVersionedBucketCallCount()).To(BeZero())
+	})
+})
@@ -23,6 +23,7 @@ type Config struct {
 	ConcourseKey             string `json:"concourse_key"`
 	ConcoursePassword        string `json:"concourse_password"`
 	ConcourseUsername        string `json:"concourse_username"`
+	ConcourseWebCount        int    `json:"concourse_web_count,omitempty"`
 	ConcourseWebSize         string `json:"concourse_web_size"`
 	ConcourseWorkerCount     int    `json:"concourse_worker_count"`
 	ConcourseWorkerSize      string `json:"concourse_worker_size"`
@@ -79,6 +80,42 @@ type Config struct {
 	Version            string   `json:"version"`
 	VMProvisioningType string   `json:"vm_provisioning_type"`
 	WorkerType         string   `json:"worker_type"`
+	// WorkerPools are extra groups of workers deployed alongside the default workers
+	WorkerPools []WorkerPool `json:"worker_pools,omitempty"`
+	// ConcourseOpsFiles and DirectorOpsFiles are applied to the Concourse and director manifests after
+	// control-tower's own ops files, and VarsFiles give values to the variables they use
+	ConcourseOpsFiles []ManifestFile `json:"concourse_ops_files,omitempty"`
+	DirectorOpsFiles  []ManifestFile `json:"director_ops_files,omitempty"`
+	VarsFiles         []ManifestFile `json:"vars_files,omitempty"`
+	// Schedule scales the workers down outside working hours
+	Schedule *Schedule `json:"schedule,omitempty"`
+	// ReleaseChannel is the releases of control-tower the self-update pipeline deploys: stable,
+	// pre-release or a version range such as 0.17.x
+	ReleaseChannel string `json:"release_channel,omitempty"`
+	// MaintenanceWindow is when the self-update pipeline may upgrade the deployment, and UpgradeApproval
+	// makes it wait for someone to approve each upgrade. Notifications report the upgrades it makes.
+	MaintenanceWindow *MaintenanceWindow `json:"maintenance_window,omitempty"`
+	UpgradeApproval   bool               `json:"upgrade_approval,omitempty"`
+	Notifications     *Notifications     `json:"notifications,omitempty"`
+	// Pause is set while the Concourse is scaled down or hibernated by control-tower pause
+	Pause *Pause `json:"pause,omitempty"`
+	// OIDCAuth, LDAPAuth and GitLabAuth are the settings of the optional auth providers. Their
+	// secrets are kept in the BOSH vars store rather than here
+	OIDCAuth   *OIDCAuth   `json:"oidc_auth,omitempty"`
+	LDAPAuth   *LDAPAuth   `json:"ldap_auth,omitempty"`
+	GitLabAuth *GitLabAuth `json:"gitlab_auth,omitempty"`
+	// Teams are the Concourse teams set by control-tower teams apply, which are set again by every deploy
+	Teams []Team `json:"teams,omitempty"`
+	// Pipelines is where the pipelines set by every deploy after the self-update pipeline are listed
+	Pipelines *PipelinesSource `json:"pipelines,omitempty"`
+	// Private deployments have no public IPs, and are reached from within the network or through a proxy
+	Private bool `json:"private,omitempty"`
+	// InstanceIdentity gives the director and its blobstore the credentials of the VMs they run on, an IAM
+	// instance profile on AWS or an attached service account on GCP, rather than long-lived access keys
+	InstanceIdentity bool `json:"instance_identity,omitempty"`
+	// LegacyAccessKeys keeps the access keys of a deployment that is moving to InstanceIdentity until all of
+	// its VMs have been recreated without them
+	LegacyAccessKeys bool `json:"legacy_access_keys,omitempty"`
 }
 
 type ConfigView interface {
@@ -92,6 +129,7 @@ type ConfigView interface {
 	GetConcourseKey() string
 	GetConcoursePassword() string
 	GetConcourseUsername() string
+	GetConcourseWebCount() int
 	GetConcourseWebSize() string
 	GetConcourseWorkerCount() int
 	GetConcourseWorkerSize() string
@@ -117,17 +155,23 @@ type ConfigView interface {
 	GetEnablePipelineInstances() bool
 	GetInfluxDbRetention() string
 	GetEncryptionKey() string
+	GetGitLabAuth() *GitLabAuth
 	GetGithubClientID() string
 	GetGithubClientSecret() string
 	GetGrafanaPassword() string
 	GetHostedZoneID() string
 	GetHostedZoneRecordPrefix() string
 	GetIAAS() string
+	GetLDAPAuth() *LDAPAuth
+	GetMaintenanceWindow() *MaintenanceWindow
 	GetMicrosoftClientID() string
 	GetMicrosoftClientSecret() string
 	GetMicrosoftTenant() string
+	GetNotifications() *Notifications
 	GetNamespace() string
 	GetNetworkCIDR() string
+	GetOIDCAuth() *OIDCAuth
+	GetPause() *Pause
 	GetPrivateCIDR() string
 	GetPrivateKey() string
 	GetProject() string
@@ -140,15 +184,27 @@ type ConfigView interface {
 	GetRDSPassword() string
 	GetRDSUsername() string
 	GetRegion() string
+	GetReleaseChannel() string
+	GetSchedule() *Schedule
 	GetSourceAccessIP() string
 	GetTags() []string
+	GetTeams() []Team
+	GetPipelines() *PipelinesSource
 	GetTFStatePath() string
 	GetVersion() string
+	GetWorkerPools() []WorkerPool
+	GetConcourseOpsFiles() []ManifestFile
+	GetDirectorOpsFiles() []ManifestFile
+	GetVarsFiles() []ManifestFile
 	GetWorkerType() string
 	IsBitbucketAuthSet() bool
 	IsGithubAuthSet() bool
 	IsMicrosoftAuthSet() bool
+	HasLegacyAccessKeys() bool
+	IsPrivate() bool
 	IsSpot() bool
+	RequiresUpgradeApproval() bool
+	UsesInstanceIdentity() bool
 }
 
 func (c Config) GetAllowIPs() string {
@@ -191,6 +247,14 @@ func (c Config) GetConcourseUsername() string {
 	return c.ConcourseUsername
 }
 
+// GetConcourseWebCount returns the number of web VMs, which is 1 for configs written before it could be set
+func (c Config) GetConcourseWebCount() int {
+	if c.ConcourseWebCount < 1 {
+		return 1
+	}
+	return c.ConcourseWebCount
+}
+
 func (c Config) GetConcourseWebSize() string {
 	return c.ConcourseWebSize
 }
@@ -291,6 +355,10 @@ func (c Config) GetEncryptionKey() string {
 	return c.EncryptionKey
 }
 
+func (c Config) GetGitLabAuth() *GitLabAuth {
+	return c.GitLabAuth
+}
+
 func (c Config) GetGithubClientID() string {
 	return c.GithubClientID
 }
@@ -315,6 +383,18 @@ func (c Config) GetIAAS() string {
 	return c.IAAS
 }
 
+func (c Config) GetLDAPAuth() *LDAPAuth {
+	return c.LDAPAuth
+}
+
+func (c Config) GetMaintenanceWindow() *MaintenanceWindow {
+	return c.MaintenanceWindow
+}
+
+func (c Config) GetNotifications() *Notifications {
+	return c.Notifications
+}
+
 func (c Config) GetMicrosoftClientID() string {
 	return c.MicrosoftClientID
 }
@@ -335,6 +415,14 @@ func (c Config) GetNetworkCIDR() string {
 	return c.NetworkCIDR
 }
 
+func (c Config) GetOIDCAuth() *OIDCAuth {
+	return c.OIDCAuth
+}
+
+func (c Config) GetPause() *Pause {
+	return c.Pause
+}
+
 func (c Config) GetPrivateCIDR() string {
 	return c.PrivateCIDR
 }
@@ -383,6 +471,14 @@ func (c Config) GetRegion() string {
 	return c.Region
 }
 
+func (c Config) GetReleaseChannel() string {
+	return c.ReleaseChannel
+}
+
+func (c Config) GetSchedule() *Schedule {
+	return c.Schedule
+}
+
 func (c Config) GetSourceAccessIP() string {
 	return c.SourceAccessIP
 }
@@ -391,6 +487,14 @@ func (c Config) GetTags() []string {
 	return c.Tags
 }
 
+func (c Config) GetTeams() []Team {
+	return c.Teams
+}
+
+func (c Config) GetPipelines() *PipelinesSource {
+	return c.Pipelines
+}
+
 func (c Config) GetTFStatePath() string {
 	return c.TFStatePath
 }
@@ -399,6 +503,22 @@ func (c Config) GetVersion() string {
 	return c.Version
 }
 
+func (c Config) GetWorkerPools() []WorkerPool {
+	return c.WorkerPools
+}
+
+func (c Config) GetConcourseOpsFiles() []ManifestFile {
+	return c.ConcourseOpsFiles
+}
+
+func (c Config) GetDirectorOpsFiles() []ManifestFile {
+	return c.DirectorOpsFiles
+}
+
+func (c Config) GetVarsFiles() []ManifestFile {
+	return c.VarsFiles
+}
+
 func (c Config) GetWorkerType() string {
 	return c.WorkerType
 }
@@ -415,6 +535,22 @@ func (c Config) IsMicrosoftAuthSet() bool {
 	return c.MicrosoftClientID != "" && c.MicrosoftClientSecret != ""
 }
 
+func (c Config) IsPrivate() bool {
+	return c.Private
+}
+
 func (c Config) IsSpot() bool {
 	return c.VMProvisioningType == SPOT
 }
+
+func (c Config) RequiresUpgradeApproval() bool {
+	return c.UpgradeApproval
+}
+
+func (c Config) UsesInstanceIdentity() bool {
+	return c.InstanceIdentity
+}
+
+func (c Config) HasLegacyAccessKeys() bool {
+	return c.LegacyAccessKeys
+}
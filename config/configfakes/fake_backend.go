@@ -0,0 +1,509 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package configfakes
+
+import (
+	"sync"
+
+	"github.com/EngineerBetter/control-tower/config"
+)
+
+type FakeBackend struct {
+	BucketExistsStub        func(string) (bool, error)
+	bucketExistsMutex       sync.RWMutex
+	bucketExistsArgsForCall []struct {
+		arg1 string
+	}
+	bucketExistsReturns struct {
+		result1 bool
+		result2 error
+	}
+	bucketExistsReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	CreateBucketStub        func(string) error
+	createBucketMutex       sync.RWMutex
+	createBucketArgsForCall []struct {
+		arg1 string
+	}
+	createBucketReturns struct {
+		result1 error
+	}
+	createBucketReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteVersionedBucketStub        func(string) error
+	deleteVersionedBucketMutex       sync.RWMutex
+	deleteVersionedBucketArgsForCall []struct {
+		arg1 string
+	}
+	deleteVersionedBucketReturns struct {
+		result1 error
+	}
+	deleteVersionedBucketReturnsOnCall map[int]struct {
+		result1 error
+	}
+	HasFileStub        func(string, string) (bool, error)
+	hasFileMutex       sync.RWMutex
+	hasFileArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	hasFileReturns struct {
+		result1 bool
+		result2 error
+	}
+	hasFileReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	LoadFileStub        func(string, string) ([]byte, error)
+	loadFileMutex       sync.RWMutex
+	loadFileArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	loadFileReturns struct {
+		result1 []byte
+		result2 error
+	}
+	loadFileReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	WriteFileStub        func(string, string, []byte) error
+	writeFileMutex       sync.RWMutex
+	writeFileArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}
+	writeFileReturns struct {
+		result1 error
+	}
+	writeFileReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeBackend) BucketExists(arg1 string) (bool, error) {
+	fake.bucketExistsMutex.Lock()
+	ret, specificReturn := fake.bucketExistsReturnsOnCall[len(fake.bucketExistsArgsForCall)]
+	fake.bucketExistsArgsForCall = append(fake.bucketExistsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.BucketExistsStub
+	fakeReturns := fake.bucketExistsReturns
+	fake.recordInvocation("BucketExists", []interface{}{arg1})
+	fake.bucketExistsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBackend) BucketExistsCallCount() int {
+	fake.bucketExistsMutex.RLock()
+	defer fake.bucketExistsMutex.RUnlock()
+	return len(fake.bucketExistsArgsForCall)
+}
+
+func (fake *FakeBackend) BucketExistsCalls(stub func(string) (bool, error)) {
+	fake.bucketExistsMutex.Lock()
+	defer fake.bucketExistsMutex.Unlock()
+	fake.BucketExistsStub = stub
+}
+
+func (fake *FakeBackend) BucketExistsArgsForCall(i int) string {
+	fake.bucketExistsMutex.RLock()
+	defer fake.bucketExistsMutex.RUnlock()
+	argsForCall := fake.bucketExistsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBackend) BucketExistsReturns(result1 bool, result2 error) {
+	fake.bucketExistsMutex.Lock()
+	defer fake.bucketExistsMutex.Unlock()
+	fake.BucketExistsStub = nil
+	fake.bucketExistsReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) BucketExistsReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.bucketExistsMutex.Lock()
+	defer fake.bucketExistsMutex.Unlock()
+	fake.BucketExistsStub = nil
+	if fake.bucketExistsReturnsOnCall == nil {
+		fake.bucketExistsReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.bucketExistsReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) CreateBucket(arg1 string) error {
+	fake.createBucketMutex.Lock()
+	ret, specificReturn := fake.createBucketReturnsOnCall[len(fake.createBucketArgsForCall)]
+	fake.createBucketArgsForCall = append(fake.createBucketArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CreateBucketStub
+	fakeReturns := fake.createBucketReturns
+	fake.recordInvocation("CreateBucket", []interface{}{arg1})
+	fake.createBucketMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBackend) CreateBucketCallCount() int {
+	fake.createBucketMutex.RLock()
+	defer fake.createBucketMutex.RUnlock()
+	return len(fake.createBucketArgsForCall)
+}
+
+func (fake *FakeBackend) CreateBucketCalls(stub func(string) error) {
+	fake.createBucketMutex.Lock()
+	defer fake.createBucketMutex.Unlock()
+	fake.CreateBucketStub = stub
+}
+
+func (fake *FakeBackend) CreateBucketArgsForCall(i int) string {
+	fake.createBucketMutex.RLock()
+	defer fake.createBucketMutex.RUnlock()
+	argsForCall := fake.createBucketArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBackend) CreateBucketReturns(result1 error) {
+	fake.createBucketMutex.Lock()
+	defer fake.createBucketMutex.Unlock()
+	fake.CreateBucketStub = nil
+	fake.createBucketReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) CreateBucketReturnsOnCall(i int, result1 error) {
+	fake.createBucketMutex.Lock()
+	defer fake.createBucketMutex.Unlock()
+	fake.CreateBucketStub = nil
+	if fake.createBucketReturnsOnCall == nil {
+		fake.createBucketReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createBucketReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) DeleteVersionedBucket(arg1 string) error {
+	fake.deleteVersionedBucketMutex.Lock()
+	ret, specificReturn := fake.deleteVersionedBucketReturnsOnCall[len(fake.deleteVersionedBucketArgsForCall)]
+	fake.deleteVersionedBucketArgsForCall = append(fake.deleteVersionedBucketArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteVersionedBucketStub
+	fakeReturns := fake.deleteVersionedBucketReturns
+	fake.recordInvocation("DeleteVersionedBucket", []interface{}{arg1})
+	fake.deleteVersionedBucketMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBackend) DeleteVersionedBucketCallCount() int {
+	fake.deleteVersionedBucketMutex.RLock()
+	defer fake.deleteVersionedBucketMutex.RUnlock()
+	return len(fake.deleteVersionedBucketArgsForCall)
+}
+
+func (fake *FakeBackend) DeleteVersionedBucketCalls(stub func(string) error) {
+	fake.deleteVersionedBucketMutex.Lock()
+	defer fake.deleteVersionedBucketMutex.Unlock()
+	fake.DeleteVersionedBucketStub = stub
+}
+
+func (fake *FakeBackend) DeleteVersionedBucketArgsForCall(i int) string {
+	fake.deleteVersionedBucketMutex.RLock()
+	defer fake.deleteVersionedBucketMutex.RUnlock()
+	argsForCall := fake.deleteVersionedBucketArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBackend) DeleteVersionedBucketReturns(result1 error) {
+	fake.deleteVersionedBucketMutex.Lock()
+	defer fake.deleteVersionedBucketMutex.Unlock()
+	fake.DeleteVersionedBucketStub = nil
+	fake.deleteVersionedBucketReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) DeleteVersionedBucketReturnsOnCall(i int, result1 error) {
+	fake.deleteVersionedBucketMutex.Lock()
+	defer fake.deleteVersionedBucketMutex.Unlock()
+	fake.DeleteVersionedBucketStub = nil
+	if fake.deleteVersionedBucketReturnsOnCall == nil {
+		fake.deleteVersionedBucketReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteVersionedBucketReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) HasFile(arg1 string, arg2 string) (bool, error) {
+	fake.hasFileMutex.Lock()
+	ret, specificReturn := fake.hasFileReturnsOnCall[len(fake.hasFileArgsForCall)]
+	fake.hasFileArgsForCall = append(fake.hasFileArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.HasFileStub
+	fakeReturns := fake.hasFileReturns
+	fake.recordInvocation("HasFile", []interface{}{arg1, arg2})
+	fake.hasFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBackend) HasFileCallCount() int {
+	fake.hasFileMutex.RLock()
+	defer fake.hasFileMutex.RUnlock()
+	return len(fake.hasFileArgsForCall)
+}
+
+func (fake *FakeBackend) HasFileCalls(stub func(string, string) (bool, error)) {
+	fake.hasFileMutex.Lock()
+	defer fake.hasFileMutex.Unlock()
+	fake.HasFileStub = stub
+}
+
+func (fake *FakeBackend) HasFileArgsForCall(i int) (string, string) {
+	fake.hasFileMutex.RLock()
+	defer fake.hasFileMutex.RUnlock()
+	argsForCall := fake.hasFileArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBackend) HasFileReturns(result1 bool, result2 error) {
+	fake.hasFileMutex.Lock()
+	defer fake.hasFileMutex.Unlock()
+	fake.HasFileStub = nil
+	fake.hasFileReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) HasFileReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.hasFileMutex.Lock()
+	defer fake.hasFileMutex.Unlock()
+	fake.HasFileStub = nil
+	if fake.hasFileReturnsOnCall == nil {
+		fake.hasFileReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.hasFileReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) LoadFile(arg1 string, arg2 string) ([]byte, error) {
+	fake.loadFileMutex.Lock()
+	ret, specificReturn := fake.loadFileReturnsOnCall[len(fake.loadFileArgsForCall)]
+	fake.loadFileArgsForCall = append(fake.loadFileArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.LoadFileStub
+	fakeReturns := fake.loadFileReturns
+	fake.recordInvocation("LoadFile", []interface{}{arg1, arg2})
+	fake.loadFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBackend) LoadFileCallCount() int {
+	fake.loadFileMutex.RLock()
+	defer fake.loadFileMutex.RUnlock()
+	return len(fake.loadFileArgsForCall)
+}
+
+func (fake *FakeBackend) LoadFileCalls(stub func(string, string) ([]byte, error)) {
+	fake.loadFileMutex.Lock()
+	defer fake.loadFileMutex.Unlock()
+	fake.LoadFileStub = stub
+}
+
+func (fake *FakeBackend) LoadFileArgsForCall(i int) (string, string) {
+	fake.loadFileMutex.RLock()
+	defer fake.loadFileMutex.RUnlock()
+	argsForCall := fake.loadFileArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBackend) LoadFileReturns(result1 []byte, result2 error) {
+	fake.loadFileMutex.Lock()
+	defer fake.loadFileMutex.Unlock()
+	fake.LoadFileStub = nil
+	fake.loadFileReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) LoadFileReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.loadFileMutex.Lock()
+	defer fake.loadFileMutex.Unlock()
+	fake.LoadFileStub = nil
+	if fake.loadFileReturnsOnCall == nil {
+		fake.loadFileReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.loadFileReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) WriteFile(arg1 string, arg2 string, arg3 []byte) error {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.writeFileMutex.Lock()
+	ret, specificReturn := fake.writeFileReturnsOnCall[len(fake.writeFileArgsForCall)]
+	fake.writeFileArgsForCall = append(fake.writeFileArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}{arg1, arg2, arg3Copy})
+	stub := fake.WriteFileStub
+	fakeReturns := fake.writeFileReturns
+	fake.recordInvocation("WriteFile", []interface{}{arg1, arg2, arg3Copy})
+	fake.writeFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBackend) WriteFileCallCount() int {
+	fake.writeFileMutex.RLock()
+	defer fake.writeFileMutex.RUnlock()
+	return len(fake.writeFileArgsForCall)
+}
+
+func (fake *FakeBackend) WriteFileCalls(stub func(string, string, []byte) error) {
+	fake.writeFileMutex.Lock()
+	defer fake.writeFileMutex.Unlock()
+	fake.WriteFileStub = stub
+}
+
+func (fake *FakeBackend) WriteFileArgsForCall(i int) (string, string, []byte) {
+	fake.writeFileMutex.RLock()
+	defer fake.writeFileMutex.RUnlock()
+	argsForCall := fake.writeFileArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeBackend) WriteFileReturns(result1 error) {
+	fake.writeFileMutex.Lock()
+	defer fake.writeFileMutex.Unlock()
+	fake.WriteFileStub = nil
+	fake.writeFileReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) WriteFileReturnsOnCall(i int, result1 error) {
+	fake.writeFileMutex.Lock()
+	defer fake.writeFileMutex.Unlock()
+	fake.WriteFileStub = nil
+	if fake.writeFileReturnsOnCall == nil {
+		fake.writeFileReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.writeFileReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.bucketExistsMutex.RLock()
+	defer fake.bucketExistsMutex.RUnlock()
+	fake.createBucketMutex.RLock()
+	defer fake.createBucketMutex.RUnlock()
+	fake.deleteVersionedBucketMutex.RLock()
+	defer fake.deleteVersionedBucketMutex.RUnlock()
+	fake.hasFileMutex.RLock()
+	defer fake.hasFileMutex.RUnlock()
+	fake.loadFileMutex.RLock()
+	defer fake.loadFileMutex.RUnlock()
+	fake.writeFileMutex.RLock()
+	defer fake.writeFileMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeBackend) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ config.Backend = new(FakeBackend)
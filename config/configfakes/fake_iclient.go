@@ -2,6 +2,7 @@
 package configfakes
 
 import (
+	"io"
 	"sync"
 
 	"github.com/EngineerBetter/control-tower/config"
@@ -31,6 +32,17 @@ type FakeIClient struct {
 	deleteAllReturnsOnCall map[int]struct {
 		result1 error
 	}
+	EncryptPlaintextAssetsStub        func(...string) error
+	encryptPlaintextAssetsMutex       sync.RWMutex
+	encryptPlaintextAssetsArgsForCall []struct {
+		arg1 []string
+	}
+	encryptPlaintextAssetsReturns struct {
+		result1 error
+	}
+	encryptPlaintextAssetsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	EnsureBucketExistsStub        func() error
 	ensureBucketExistsMutex       sync.RWMutex
 	ensureBucketExistsArgsForCall []struct {
@@ -79,6 +91,31 @@ type FakeIClient struct {
 		result1 []byte
 		result2 error
 	}
+	LoadRevisionStub        func(int, string) ([]byte, error)
+	loadRevisionMutex       sync.RWMutex
+	loadRevisionArgsForCall []struct {
+		arg1 int
+		arg2 string
+	}
+	loadRevisionReturns struct {
+		result1 []byte
+		result2 error
+	}
+	loadRevisionReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	LockStub        func(string) error
+	lockMutex       sync.RWMutex
+	lockArgsForCall []struct {
+		arg1 string
+	}
+	lockReturns struct {
+		result1 error
+	}
+	lockReturnsOnCall map[int]struct {
+		result1 error
+	}
 	NewConfigStub        func() config.Config
 	newConfigMutex       sync.RWMutex
 	newConfigArgsForCall []struct {
@@ -89,6 +126,72 @@ type FakeIClient struct {
 	newConfigReturnsOnCall map[int]struct {
 		result1 config.Config
 	}
+	OpenReaderStub        func(string, io.Reader) (io.Reader, error)
+	openReaderMutex       sync.RWMutex
+	openReaderArgsForCall []struct {
+		arg1 string
+		arg2 io.Reader
+	}
+	openReaderReturns struct {
+		result1 io.Reader
+		result2 error
+	}
+	openReaderReturnsOnCall map[int]struct {
+		result1 io.Reader
+		result2 error
+	}
+	RecordRevisionStub        func(string, ...string) (config.Revision, error)
+	recordRevisionMutex       sync.RWMutex
+	recordRevisionArgsForCall []struct {
+		arg1 string
+		arg2 []string
+	}
+	recordRevisionReturns struct {
+		result1 config.Revision
+		result2 error
+	}
+	recordRevisionReturnsOnCall map[int]struct {
+		result1 config.Revision
+		result2 error
+	}
+	RestoreRevisionStub        func(int, ...string) error
+	restoreRevisionMutex       sync.RWMutex
+	restoreRevisionArgsForCall []struct {
+		arg1 int
+		arg2 []string
+	}
+	restoreRevisionReturns struct {
+		result1 error
+	}
+	restoreRevisionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RevisionsStub        func() ([]config.Revision, error)
+	revisionsMutex       sync.RWMutex
+	revisionsArgsForCall []struct {
+	}
+	revisionsReturns struct {
+		result1 []config.Revision
+		result2 error
+	}
+	revisionsReturnsOnCall map[int]struct {
+		result1 []config.Revision
+		result2 error
+	}
+	SealWriterStub        func(string, io.Writer) (io.WriteCloser, error)
+	sealWriterMutex       sync.RWMutex
+	sealWriterArgsForCall []struct {
+		arg1 string
+		arg2 io.Writer
+	}
+	sealWriterReturns struct {
+		result1 io.WriteCloser
+		result2 error
+	}
+	sealWriterReturnsOnCall map[int]struct {
+		result1 io.WriteCloser
+		result2 error
+	}
 	StoreAssetStub        func(string, []byte) error
 	storeAssetMutex       sync.RWMutex
 	storeAssetArgsForCall []struct {
@@ -101,6 +204,16 @@ type FakeIClient struct {
 	storeAssetReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UnlockStub        func() error
+	unlockMutex       sync.RWMutex
+	unlockArgsForCall []struct {
+	}
+	unlockReturns struct {
+		result1 error
+	}
+	unlockReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UpdateStub        func(config.Config) error
 	updateMutex       sync.RWMutex
 	updateArgsForCall []struct {
@@ -121,15 +234,16 @@ func (fake *FakeIClient) ConfigExists() (bool, error) {
 	ret, specificReturn := fake.configExistsReturnsOnCall[len(fake.configExistsArgsForCall)]
 	fake.configExistsArgsForCall = append(fake.configExistsArgsForCall, struct {
 	}{})
+	stub := fake.ConfigExistsStub
+	fakeReturns := fake.configExistsReturns
 	fake.recordInvocation("ConfigExists", []interface{}{})
 	fake.configExistsMutex.Unlock()
-	if fake.ConfigExistsStub != nil {
-		return fake.ConfigExistsStub()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.configExistsReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -177,15 +291,16 @@ func (fake *FakeIClient) DeleteAll(arg1 config.ConfigView) error {
 	fake.deleteAllArgsForCall = append(fake.deleteAllArgsForCall, struct {
 		arg1 config.ConfigView
 	}{arg1})
+	stub := fake.DeleteAllStub
+	fakeReturns := fake.deleteAllReturns
 	fake.recordInvocation("DeleteAll", []interface{}{arg1})
 	fake.deleteAllMutex.Unlock()
-	if fake.DeleteAllStub != nil {
-		return fake.DeleteAllStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.deleteAllReturns
 	return fakeReturns.result1
 }
 
@@ -231,20 +346,82 @@ func (fake *FakeIClient) DeleteAllReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeIClient) EncryptPlaintextAssets(arg1 ...string) error {
+	fake.encryptPlaintextAssetsMutex.Lock()
+	ret, specificReturn := fake.encryptPlaintextAssetsReturnsOnCall[len(fake.encryptPlaintextAssetsArgsForCall)]
+	fake.encryptPlaintextAssetsArgsForCall = append(fake.encryptPlaintextAssetsArgsForCall, struct {
+		arg1 []string
+	}{arg1})
+	stub := fake.EncryptPlaintextAssetsStub
+	fakeReturns := fake.encryptPlaintextAssetsReturns
+	fake.recordInvocation("EncryptPlaintextAssets", []interface{}{arg1})
+	fake.encryptPlaintextAssetsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1...)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIClient) EncryptPlaintextAssetsCallCount() int {
+	fake.encryptPlaintextAssetsMutex.RLock()
+	defer fake.encryptPlaintextAssetsMutex.RUnlock()
+	return len(fake.encryptPlaintextAssetsArgsForCall)
+}
+
+func (fake *FakeIClient) EncryptPlaintextAssetsCalls(stub func(...string) error) {
+	fake.encryptPlaintextAssetsMutex.Lock()
+	defer fake.encryptPlaintextAssetsMutex.Unlock()
+	fake.EncryptPlaintextAssetsStub = stub
+}
+
+func (fake *FakeIClient) EncryptPlaintextAssetsArgsForCall(i int) []string {
+	fake.encryptPlaintextAssetsMutex.RLock()
+	defer fake.encryptPlaintextAssetsMutex.RUnlock()
+	argsForCall := fake.encryptPlaintextAssetsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeIClient) EncryptPlaintextAssetsReturns(result1 error) {
+	fake.encryptPlaintextAssetsMutex.Lock()
+	defer fake.encryptPlaintextAssetsMutex.Unlock()
+	fake.EncryptPlaintextAssetsStub = nil
+	fake.encryptPlaintextAssetsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) EncryptPlaintextAssetsReturnsOnCall(i int, result1 error) {
+	fake.encryptPlaintextAssetsMutex.Lock()
+	defer fake.encryptPlaintextAssetsMutex.Unlock()
+	fake.EncryptPlaintextAssetsStub = nil
+	if fake.encryptPlaintextAssetsReturnsOnCall == nil {
+		fake.encryptPlaintextAssetsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.encryptPlaintextAssetsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeIClient) EnsureBucketExists() error {
 	fake.ensureBucketExistsMutex.Lock()
 	ret, specificReturn := fake.ensureBucketExistsReturnsOnCall[len(fake.ensureBucketExistsArgsForCall)]
 	fake.ensureBucketExistsArgsForCall = append(fake.ensureBucketExistsArgsForCall, struct {
 	}{})
+	stub := fake.EnsureBucketExistsStub
+	fakeReturns := fake.ensureBucketExistsReturns
 	fake.recordInvocation("EnsureBucketExists", []interface{}{})
 	fake.ensureBucketExistsMutex.Unlock()
-	if fake.EnsureBucketExistsStub != nil {
-		return fake.EnsureBucketExistsStub()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.ensureBucketExistsReturns
 	return fakeReturns.result1
 }
 
@@ -289,15 +466,16 @@ func (fake *FakeIClient) HasAsset(arg1 string) (bool, error) {
 	fake.hasAssetArgsForCall = append(fake.hasAssetArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.HasAssetStub
+	fakeReturns := fake.hasAssetReturns
 	fake.recordInvocation("HasAsset", []interface{}{arg1})
 	fake.hasAssetMutex.Unlock()
-	if fake.HasAssetStub != nil {
-		return fake.HasAssetStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.hasAssetReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -351,15 +529,16 @@ func (fake *FakeIClient) Load() (config.Config, error) {
 	ret, specificReturn := fake.loadReturnsOnCall[len(fake.loadArgsForCall)]
 	fake.loadArgsForCall = append(fake.loadArgsForCall, struct {
 	}{})
+	stub := fake.LoadStub
+	fakeReturns := fake.loadReturns
 	fake.recordInvocation("Load", []interface{}{})
 	fake.loadMutex.Unlock()
-	if fake.LoadStub != nil {
-		return fake.LoadStub()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.loadReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -407,15 +586,16 @@ func (fake *FakeIClient) LoadAsset(arg1 string) ([]byte, error) {
 	fake.loadAssetArgsForCall = append(fake.loadAssetArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.LoadAssetStub
+	fakeReturns := fake.loadAssetReturns
 	fake.recordInvocation("LoadAsset", []interface{}{arg1})
 	fake.loadAssetMutex.Unlock()
-	if fake.LoadAssetStub != nil {
-		return fake.LoadAssetStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.loadAssetReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -464,20 +644,147 @@ func (fake *FakeIClient) LoadAssetReturnsOnCall(i int, result1 []byte, result2 e
 	}{result1, result2}
 }
 
+func (fake *FakeIClient) LoadRevision(arg1 int, arg2 string) ([]byte, error) {
+	fake.loadRevisionMutex.Lock()
+	ret, specificReturn := fake.loadRevisionReturnsOnCall[len(fake.loadRevisionArgsForCall)]
+	fake.loadRevisionArgsForCall = append(fake.loadRevisionArgsForCall, struct {
+		arg1 int
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.LoadRevisionStub
+	fakeReturns := fake.loadRevisionReturns
+	fake.recordInvocation("LoadRevision", []interface{}{arg1, arg2})
+	fake.loadRevisionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeIClient) LoadRevisionCallCount() int {
+	fake.loadRevisionMutex.RLock()
+	defer fake.loadRevisionMutex.RUnlock()
+	return len(fake.loadRevisionArgsForCall)
+}
+
+func (fake *FakeIClient) LoadRevisionCalls(stub func(int, string) ([]byte, error)) {
+	fake.loadRevisionMutex.Lock()
+	defer fake.loadRevisionMutex.Unlock()
+	fake.LoadRevisionStub = stub
+}
+
+func (fake *FakeIClient) LoadRevisionArgsForCall(i int) (int, string) {
+	fake.loadRevisionMutex.RLock()
+	defer fake.loadRevisionMutex.RUnlock()
+	argsForCall := fake.loadRevisionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeIClient) LoadRevisionReturns(result1 []byte, result2 error) {
+	fake.loadRevisionMutex.Lock()
+	defer fake.loadRevisionMutex.Unlock()
+	fake.LoadRevisionStub = nil
+	fake.loadRevisionReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) LoadRevisionReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.loadRevisionMutex.Lock()
+	defer fake.loadRevisionMutex.Unlock()
+	fake.LoadRevisionStub = nil
+	if fake.loadRevisionReturnsOnCall == nil {
+		fake.loadRevisionReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.loadRevisionReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) Lock(arg1 string) error {
+	fake.lockMutex.Lock()
+	ret, specificReturn := fake.lockReturnsOnCall[len(fake.lockArgsForCall)]
+	fake.lockArgsForCall = append(fake.lockArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.LockStub
+	fakeReturns := fake.lockReturns
+	fake.recordInvocation("Lock", []interface{}{arg1})
+	fake.lockMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIClient) LockCallCount() int {
+	fake.lockMutex.RLock()
+	defer fake.lockMutex.RUnlock()
+	return len(fake.lockArgsForCall)
+}
+
+func (fake *FakeIClient) LockCalls(stub func(string) error) {
+	fake.lockMutex.Lock()
+	defer fake.lockMutex.Unlock()
+	fake.LockStub = stub
+}
+
+func (fake *FakeIClient) LockArgsForCall(i int) string {
+	fake.lockMutex.RLock()
+	defer fake.lockMutex.RUnlock()
+	argsForCall := fake.lockArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeIClient) LockReturns(result1 error) {
+	fake.lockMutex.Lock()
+	defer fake.lockMutex.Unlock()
+	fake.LockStub = nil
+	fake.lockReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) LockReturnsOnCall(i int, result1 error) {
+	fake.lockMutex.Lock()
+	defer fake.lockMutex.Unlock()
+	fake.LockStub = nil
+	if fake.lockReturnsOnCall == nil {
+		fake.lockReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.lockReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeIClient) NewConfig() config.Config {
 	fake.newConfigMutex.Lock()
 	ret, specificReturn := fake.newConfigReturnsOnCall[len(fake.newConfigArgsForCall)]
 	fake.newConfigArgsForCall = append(fake.newConfigArgsForCall, struct {
 	}{})
+	stub := fake.NewConfigStub
+	fakeReturns := fake.newConfigReturns
 	fake.recordInvocation("NewConfig", []interface{}{})
 	fake.newConfigMutex.Unlock()
-	if fake.NewConfigStub != nil {
-		return fake.NewConfigStub()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.newConfigReturns
 	return fakeReturns.result1
 }
 
@@ -516,6 +823,319 @@ func (fake *FakeIClient) NewConfigReturnsOnCall(i int, result1 config.Config) {
 	}{result1}
 }
 
+func (fake *FakeIClient) OpenReader(arg1 string, arg2 io.Reader) (io.Reader, error) {
+	fake.openReaderMutex.Lock()
+	ret, specificReturn := fake.openReaderReturnsOnCall[len(fake.openReaderArgsForCall)]
+	fake.openReaderArgsForCall = append(fake.openReaderArgsForCall, struct {
+		arg1 string
+		arg2 io.Reader
+	}{arg1, arg2})
+	stub := fake.OpenReaderStub
+	fakeReturns := fake.openReaderReturns
+	fake.recordInvocation("OpenReader", []interface{}{arg1, arg2})
+	fake.openReaderMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeIClient) OpenReaderCallCount() int {
+	fake.openReaderMutex.RLock()
+	defer fake.openReaderMutex.RUnlock()
+	return len(fake.openReaderArgsForCall)
+}
+
+func (fake *FakeIClient) OpenReaderCalls(stub func(string, io.Reader) (io.Reader, error)) {
+	fake.openReaderMutex.Lock()
+	defer fake.openReaderMutex.Unlock()
+	fake.OpenReaderStub = stub
+}
+
+func (fake *FakeIClient) OpenReaderArgsForCall(i int) (string, io.Reader) {
+	fake.openReaderMutex.RLock()
+	defer fake.openReaderMutex.RUnlock()
+	argsForCall := fake.openReaderArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeIClient) OpenReaderReturns(result1 io.Reader, result2 error) {
+	fake.openReaderMutex.Lock()
+	defer fake.openReaderMutex.Unlock()
+	fake.OpenReaderStub = nil
+	fake.openReaderReturns = struct {
+		result1 io.Reader
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) OpenReaderReturnsOnCall(i int, result1 io.Reader, result2 error) {
+	fake.openReaderMutex.Lock()
+	defer fake.openReaderMutex.Unlock()
+	fake.OpenReaderStub = nil
+	if fake.openReaderReturnsOnCall == nil {
+		fake.openReaderReturnsOnCall = make(map[int]struct {
+			result1 io.Reader
+			result2 error
+		})
+	}
+	fake.openReaderReturnsOnCall[i] = struct {
+		result1 io.Reader
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) RecordRevision(arg1 string, arg2 ...string) (config.Revision, error) {
+	fake.recordRevisionMutex.Lock()
+	ret, specificReturn := fake.recordRevisionReturnsOnCall[len(fake.recordRevisionArgsForCall)]
+	fake.recordRevisionArgsForCall = append(fake.recordRevisionArgsForCall, struct {
+		arg1 string
+		arg2 []string
+	}{arg1, arg2})
+	stub := fake.RecordRevisionStub
+	fakeReturns := fake.recordRevisionReturns
+	fake.recordInvocation("RecordRevision", []interface{}{arg1, arg2})
+	fake.recordRevisionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeIClient) RecordRevisionCallCount() int {
+	fake.recordRevisionMutex.RLock()
+	defer fake.recordRevisionMutex.RUnlock()
+	return len(fake.recordRevisionArgsForCall)
+}
+
+func (fake *FakeIClient) RecordRevisionCalls(stub func(string, ...string) (config.Revision, error)) {
+	fake.recordRevisionMutex.Lock()
+	defer fake.recordRevisionMutex.Unlock()
+	fake.RecordRevisionStub = stub
+}
+
+func (fake *FakeIClient) RecordRevisionArgsForCall(i int) (string, []string) {
+	fake.recordRevisionMutex.RLock()
+	defer fake.recordRevisionMutex.RUnlock()
+	argsForCall := fake.recordRevisionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeIClient) RecordRevisionReturns(result1 config.Revision, result2 error) {
+	fake.recordRevisionMutex.Lock()
+	defer fake.recordRevisionMutex.Unlock()
+	fake.RecordRevisionStub = nil
+	fake.recordRevisionReturns = struct {
+		result1 config.Revision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) RecordRevisionReturnsOnCall(i int, result1 config.Revision, result2 error) {
+	fake.recordRevisionMutex.Lock()
+	defer fake.recordRevisionMutex.Unlock()
+	fake.RecordRevisionStub = nil
+	if fake.recordRevisionReturnsOnCall == nil {
+		fake.recordRevisionReturnsOnCall = make(map[int]struct {
+			result1 config.Revision
+			result2 error
+		})
+	}
+	fake.recordRevisionReturnsOnCall[i] = struct {
+		result1 config.Revision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) RestoreRevision(arg1 int, arg2 ...string) error {
+	fake.restoreRevisionMutex.Lock()
+	ret, specificReturn := fake.restoreRevisionReturnsOnCall[len(fake.restoreRevisionArgsForCall)]
+	fake.restoreRevisionArgsForCall = append(fake.restoreRevisionArgsForCall, struct {
+		arg1 int
+		arg2 []string
+	}{arg1, arg2})
+	stub := fake.RestoreRevisionStub
+	fakeReturns := fake.restoreRevisionReturns
+	fake.recordInvocation("RestoreRevision", []interface{}{arg1, arg2})
+	fake.restoreRevisionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2...)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIClient) RestoreRevisionCallCount() int {
+	fake.restoreRevisionMutex.RLock()
+	defer fake.restoreRevisionMutex.RUnlock()
+	return len(fake.restoreRevisionArgsForCall)
+}
+
+func (fake *FakeIClient) RestoreRevisionCalls(stub func(int, ...string) error) {
+	fake.restoreRevisionMutex.Lock()
+	defer fake.restoreRevisionMutex.Unlock()
+	fake.RestoreRevisionStub = stub
+}
+
+func (fake *FakeIClient) RestoreRevisionArgsForCall(i int) (int, []string) {
+	fake.restoreRevisionMutex.RLock()
+	defer fake.restoreRevisionMutex.RUnlock()
+	argsForCall := fake.restoreRevisionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeIClient) RestoreRevisionReturns(result1 error) {
+	fake.restoreRevisionMutex.Lock()
+	defer fake.restoreRevisionMutex.Unlock()
+	fake.RestoreRevisionStub = nil
+	fake.restoreRevisionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) RestoreRevisionReturnsOnCall(i int, result1 error) {
+	fake.restoreRevisionMutex.Lock()
+	defer fake.restoreRevisionMutex.Unlock()
+	fake.RestoreRevisionStub = nil
+	if fake.restoreRevisionReturnsOnCall == nil {
+		fake.restoreRevisionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.restoreRevisionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) Revisions() ([]config.Revision, error) {
+	fake.revisionsMutex.Lock()
+	ret, specificReturn := fake.revisionsReturnsOnCall[len(fake.revisionsArgsForCall)]
+	fake.revisionsArgsForCall = append(fake.revisionsArgsForCall, struct {
+	}{})
+	stub := fake.RevisionsStub
+	fakeReturns := fake.revisionsReturns
+	fake.recordInvocation("Revisions", []interface{}{})
+	fake.revisionsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeIClient) RevisionsCallCount() int {
+	fake.revisionsMutex.RLock()
+	defer fake.revisionsMutex.RUnlock()
+	return len(fake.revisionsArgsForCall)
+}
+
+func (fake *FakeIClient) RevisionsCalls(stub func() ([]config.Revision, error)) {
+	fake.revisionsMutex.Lock()
+	defer fake.revisionsMutex.Unlock()
+	fake.RevisionsStub = stub
+}
+
+func (fake *FakeIClient) RevisionsReturns(result1 []config.Revision, result2 error) {
+	fake.revisionsMutex.Lock()
+	defer fake.revisionsMutex.Unlock()
+	fake.RevisionsStub = nil
+	fake.revisionsReturns = struct {
+		result1 []config.Revision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) RevisionsReturnsOnCall(i int, result1 []config.Revision, result2 error) {
+	fake.revisionsMutex.Lock()
+	defer fake.revisionsMutex.Unlock()
+	fake.RevisionsStub = nil
+	if fake.revisionsReturnsOnCall == nil {
+		fake.revisionsReturnsOnCall = make(map[int]struct {
+			result1 []config.Revision
+			result2 error
+		})
+	}
+	fake.revisionsReturnsOnCall[i] = struct {
+		result1 []config.Revision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) SealWriter(arg1 string, arg2 io.Writer) (io.WriteCloser, error) {
+	fake.sealWriterMutex.Lock()
+	ret, specificReturn := fake.sealWriterReturnsOnCall[len(fake.sealWriterArgsForCall)]
+	fake.sealWriterArgsForCall = append(fake.sealWriterArgsForCall, struct {
+		arg1 string
+		arg2 io.Writer
+	}{arg1, arg2})
+	stub := fake.SealWriterStub
+	fakeReturns := fake.sealWriterReturns
+	fake.recordInvocation("SealWriter", []interface{}{arg1, arg2})
+	fake.sealWriterMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeIClient) SealWriterCallCount() int {
+	fake.sealWriterMutex.RLock()
+	defer fake.sealWriterMutex.RUnlock()
+	return len(fake.sealWriterArgsForCall)
+}
+
+func (fake *FakeIClient) SealWriterCalls(stub func(string, io.Writer) (io.WriteCloser, error)) {
+	fake.sealWriterMutex.Lock()
+	defer fake.sealWriterMutex.Unlock()
+	fake.SealWriterStub = stub
+}
+
+func (fake *FakeIClient) SealWriterArgsForCall(i int) (string, io.Writer) {
+	fake.sealWriterMutex.RLock()
+	defer fake.sealWriterMutex.RUnlock()
+	argsForCall := fake.sealWriterArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeIClient) SealWriterReturns(result1 io.WriteCloser, result2 error) {
+	fake.sealWriterMutex.Lock()
+	defer fake.sealWriterMutex.Unlock()
+	fake.SealWriterStub = nil
+	fake.sealWriterReturns = struct {
+		result1 io.WriteCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) SealWriterReturnsOnCall(i int, result1 io.WriteCloser, result2 error) {
+	fake.sealWriterMutex.Lock()
+	defer fake.sealWriterMutex.Unlock()
+	fake.SealWriterStub = nil
+	if fake.sealWriterReturnsOnCall == nil {
+		fake.sealWriterReturnsOnCall = make(map[int]struct {
+			result1 io.WriteCloser
+			result2 error
+		})
+	}
+	fake.sealWriterReturnsOnCall[i] = struct {
+		result1 io.WriteCloser
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeIClient) StoreAsset(arg1 string, arg2 []byte) error {
 	var arg2Copy []byte
 	if arg2 != nil {
@@ -528,15 +1148,16 @@ func (fake *FakeIClient) StoreAsset(arg1 string, arg2 []byte) error {
 		arg1 string
 		arg2 []byte
 	}{arg1, arg2Copy})
+	stub := fake.StoreAssetStub
+	fakeReturns := fake.storeAssetReturns
 	fake.recordInvocation("StoreAsset", []interface{}{arg1, arg2Copy})
 	fake.storeAssetMutex.Unlock()
-	if fake.StoreAssetStub != nil {
-		return fake.StoreAssetStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.storeAssetReturns
 	return fakeReturns.result1
 }
 
@@ -582,21 +1203,75 @@ func (fake *FakeIClient) StoreAssetReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeIClient) Unlock() error {
+	fake.unlockMutex.Lock()
+	ret, specificReturn := fake.unlockReturnsOnCall[len(fake.unlockArgsForCall)]
+	fake.unlockArgsForCall = append(fake.unlockArgsForCall, struct {
+	}{})
+	stub := fake.UnlockStub
+	fakeReturns := fake.unlockReturns
+	fake.recordInvocation("Unlock", []interface{}{})
+	fake.unlockMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIClient) UnlockCallCount() int {
+	fake.unlockMutex.RLock()
+	defer fake.unlockMutex.RUnlock()
+	return len(fake.unlockArgsForCall)
+}
+
+func (fake *FakeIClient) UnlockCalls(stub func() error) {
+	fake.unlockMutex.Lock()
+	defer fake.unlockMutex.Unlock()
+	fake.UnlockStub = stub
+}
+
+func (fake *FakeIClient) UnlockReturns(result1 error) {
+	fake.unlockMutex.Lock()
+	defer fake.unlockMutex.Unlock()
+	fake.UnlockStub = nil
+	fake.unlockReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) UnlockReturnsOnCall(i int, result1 error) {
+	fake.unlockMutex.Lock()
+	defer fake.unlockMutex.Unlock()
+	fake.UnlockStub = nil
+	if fake.unlockReturnsOnCall == nil {
+		fake.unlockReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.unlockReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeIClient) Update(arg1 config.Config) error {
 	fake.updateMutex.Lock()
 	ret, specificReturn := fake.updateReturnsOnCall[len(fake.updateArgsForCall)]
 	fake.updateArgsForCall = append(fake.updateArgsForCall, struct {
 		arg1 config.Config
 	}{arg1})
+	stub := fake.UpdateStub
+	fakeReturns := fake.updateReturns
 	fake.recordInvocation("Update", []interface{}{arg1})
 	fake.updateMutex.Unlock()
-	if fake.UpdateStub != nil {
-		return fake.UpdateStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.updateReturns
 	return fakeReturns.result1
 }
 
@@ -649,6 +1324,8 @@ func (fake *FakeIClient) Invocations() map[string][][]interface{} {
 	defer fake.configExistsMutex.RUnlock()
 	fake.deleteAllMutex.RLock()
 	defer fake.deleteAllMutex.RUnlock()
+	fake.encryptPlaintextAssetsMutex.RLock()
+	defer fake.encryptPlaintextAssetsMutex.RUnlock()
 	fake.ensureBucketExistsMutex.RLock()
 	defer fake.ensureBucketExistsMutex.RUnlock()
 	fake.hasAssetMutex.RLock()
@@ -657,10 +1334,26 @@ func (fake *FakeIClient) Invocations() map[string][][]interface{} {
 	defer fake.loadMutex.RUnlock()
 	fake.loadAssetMutex.RLock()
 	defer fake.loadAssetMutex.RUnlock()
+	fake.loadRevisionMutex.RLock()
+	defer fake.loadRevisionMutex.RUnlock()
+	fake.lockMutex.RLock()
+	defer fake.lockMutex.RUnlock()
 	fake.newConfigMutex.RLock()
 	defer fake.newConfigMutex.RUnlock()
+	fake.openReaderMutex.RLock()
+	defer fake.openReaderMutex.RUnlock()
+	fake.recordRevisionMutex.RLock()
+	defer fake.recordRevisionMutex.RUnlock()
+	fake.restoreRevisionMutex.RLock()
+	defer fake.restoreRevisionMutex.RUnlock()
+	fake.revisionsMutex.RLock()
+	defer fake.revisionsMutex.RUnlock()
+	fake.sealWriterMutex.RLock()
+	defer fake.sealWriterMutex.RUnlock()
 	fake.storeAssetMutex.RLock()
 	defer fake.storeAssetMutex.RUnlock()
+	fake.unlockMutex.RLock()
+	defer fake.unlockMutex.RUnlock()
 	fake.updateMutex.RLock()
 	defer fake.updateMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/EngineerBetter/control-tower/iaas"
+)
+
+const (
+	// PassphraseEncryption wraps data keys with a key derived from a passphrase
+	PassphraseEncryption = "passphrase"
+	// AWSKMSEncryption wraps data keys with an AWS KMS key
+	AWSKMSEncryption = "aws-kms"
+	// GCPKMSEncryption wraps data keys with a GCP Cloud KMS key
+	GCPKMSEncryption = "gcp-kms"
+)
+
+const envelopeFormat = "control-tower-envelope-v1"
+
+// envelopePrefix is how every envelope starts, as Format is its first field
+var envelopePrefix = []byte(`{"format":"` + envelopeFormat + `"`)
+
+// KeyWrapper encrypts and decrypts the data keys that protect stored assets
+type KeyWrapper interface {
+	Name() string
+	WrapKey(dataKey []byte) ([]byte, error)
+	UnwrapKey(wrappedKey []byte) ([]byte, error)
+}
+
+// Encryption selects how assets are encrypted before they are stored
+type Encryption struct {
+	Type       string
+	Passphrase string
+	KMSKeyID   string
+}
+
+// Validate returns an error if the settings for the chosen encryption are incomplete
+func (e Encryption) Validate() error {
+	switch e.Type {
+	case "":
+		return nil
+	case PassphraseEncryption:
+		if e.Passphrase == "" {
+			return fmt.Errorf("--encryption-passphrase is required when --encryption is %s", PassphraseEncryption)
+		}
+		return nil
+	case AWSKMSEncryption, GCPKMSEncryption:
+		if e.KMSKeyID == "" {
+			return fmt.Errorf("--encryption-kms-key-id is required when --encryption is %s", e.Type)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown encryption [%s], must be one of %s, %s or %s", e.Type, PassphraseEncryption, AWSKMSEncryption, GCPKMSEncryption)
+}
+
+// NewKeyWrapper returns the KeyWrapper described by settings, or nil if assets should be stored in the clear
+func NewKeyWrapper(provider iaas.Provider, settings Encryption) (KeyWrapper, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch settings.Type {
+	case PassphraseEncryption:
+		return NewPassphraseKeyWrapper(settings.Passphrase), nil
+	case AWSKMSEncryption:
+		return NewAWSKMSKeyWrapper(provider.Region(), settings.KMSKeyID)
+	case GCPKMSEncryption:
+		return NewGCPKMSKeyWrapper(settings.KMSKeyID)
+	}
+	return nil, nil
+}
+
+// envelope holds an asset encrypted with a data key, alongside that data key encrypted with a KeyWrapper
+type envelope struct {
+	Format     string `json:"format"`
+	KeyWrapper string `json:"key_wrapper"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// IsEncrypted returns true if contents is an encrypted envelope
+func IsEncrypted(contents []byte) bool {
+	return bytes.HasPrefix(contents, envelopePrefix)
+}
+
+// sealAsset encrypts plaintext with a new data key, binding it to filename so envelopes cannot be swapped
+func sealAsset(wrapper KeyWrapper, filename string, plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := wrapper.WrapKey(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("error wrapping data key with %s [%v]", wrapper.Name(), err)
+	}
+
+	return json.Marshal(envelope{
+		Format:     envelopeFormat,
+		KeyWrapper: wrapper.Name(),
+		WrappedKey: wrappedKey,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, []byte(filename)),
+	})
+}
+
+// openAsset decrypts an envelope created by sealAsset
+func openAsset(wrapper KeyWrapper, filename string, contents []byte) ([]byte, error) {
+	if wrapper == nil {
+		return nil, fmt.Errorf("[%s] is encrypted but no --encryption was given", filename)
+	}
+
+	var e envelope
+	if err := json.Unmarshal(contents, &e); err != nil {
+		return nil, fmt.Errorf("error parsing encrypted [%s] [%v]", filename, err)
+	}
+	if e.KeyWrapper != wrapper.Name() {
+		return nil, fmt.Errorf("[%s] was encrypted with %s but --encryption is %s", filename, e.KeyWrapper, wrapper.Name())
+	}
+
+	dataKey, err := wrapper.UnwrapKey(e.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping data key for [%s] with %s [%v]", filename, wrapper.Name(), err)
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("error decrypting [%s] [invalid nonce]", filename)
+	}
+
+	plaintext, err := gcm.Open(nil, e.Nonce, e.Ciphertext, []byte(filename))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting [%s] [%v]", filename, err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("data key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,172 @@
+package config_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/iaas/iaasfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encryption", func() {
+	var root string
+	var client *Client
+
+	const bucket = "control-tower-test-eu-west-1-config"
+
+	rawFile := func(filename string) []byte {
+		contents, err := ioutil.ReadFile(filepath.Join(root, bucket, filename))
+		Expect(err).ToNot(HaveOccurred())
+		return contents
+	}
+
+	BeforeEach(func() {
+		var err error
+		root, err = ioutil.TempDir("", "control-tower-state")
+		Expect(err).ToNot(HaveOccurred())
+
+		provider := &iaasfakes.FakeProvider{}
+		provider.RegionReturns("eu-west-1")
+
+		client = NewWithBackend(provider, NewLocal(root), "test", "")
+		client.KeyWrapper = NewPassphraseKeyWrapper("correct horse battery staple")
+		Expect(client.EnsureBucketExists()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(root)
+	})
+
+	It("encrypts the config file", func() {
+		Expect(client.Update(Config{DirectorPassword: "s3cr3t"})).To(Succeed())
+
+		Expect(IsEncrypted(rawFile("config.json"))).To(BeTrue())
+		Expect(string(rawFile("config.json"))).ToNot(ContainSubstring("s3cr3t"))
+
+		conf, err := client.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conf.DirectorPassword).To(Equal("s3cr3t"))
+	})
+
+	It("encrypts assets", func() {
+		Expect(client.StoreAsset("director-creds.yml", []byte("admin_password: s3cr3t"))).To(Succeed())
+
+		Expect(string(rawFile("director-creds.yml"))).ToNot(ContainSubstring("s3cr3t"))
+		Expect(client.LoadAsset("director-creds.yml")).To(Equal([]byte("admin_password: s3cr3t")))
+	})
+
+	It("loads assets stored before encryption was enabled", func() {
+		plaintextClient := NewWithBackend(client.Iaas, client.Backend, "test", "")
+		Expect(plaintextClient.StoreAsset("director-creds.yml", []byte("admin_password: s3cr3t"))).To(Succeed())
+
+		Expect(client.LoadAsset("director-creds.yml")).To(Equal([]byte("admin_password: s3cr3t")))
+	})
+
+	It("refuses to load encrypted assets without the key", func() {
+		Expect(client.StoreAsset("director-creds.yml", []byte("admin_password: s3cr3t"))).To(Succeed())
+
+		plaintextClient := NewWithBackend(client.Iaas, client.Backend, "test", "")
+		_, err := plaintextClient.LoadAsset("director-creds.yml")
+		Expect(err).To(MatchError("[director-creds.yml] is encrypted but no --encryption was given"))
+
+		client.KeyWrapper = NewPassphraseKeyWrapper("wrong")
+		_, err = client.LoadAsset("director-creds.yml")
+		Expect(err).To(MatchError("error unwrapping data key for [director-creds.yml] with passphrase [incorrect passphrase]"))
+	})
+
+	It("refuses to load an asset that was stored under another name", func() {
+		Expect(client.StoreAsset("director-creds.yml", []byte("admin_password: s3cr3t"))).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(root, bucket, "director-state.json"), rawFile("director-creds.yml"), 0600)).To(Succeed())
+
+		_, err := client.LoadAsset("director-state.json")
+		Expect(err).To(MatchError(ContainSubstring("error decrypting [director-state.json]")))
+	})
+
+	It("seals and opens streams kept outside the bucket", func() {
+		plaintext := bytes.Repeat([]byte("admin_password: s3cr3t\n"), 10000)
+		var sealed bytes.Buffer
+		w, err := client.SealWriter("backup", &sealed)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = io.Copy(w, bytes.NewReader(plaintext))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+		Expect(sealed.Bytes()).ToNot(ContainSubstring("s3cr3t"))
+
+		openAll := func(name string, contents []byte) ([]byte, error) {
+			r, err := client.OpenReader(name, bytes.NewReader(contents))
+			if err != nil {
+				return nil, err
+			}
+			return ioutil.ReadAll(r)
+		}
+
+		Expect(openAll("backup", sealed.Bytes())).To(Equal(plaintext))
+		_, err = openAll("other", sealed.Bytes())
+		Expect(err).To(MatchError(ContainSubstring("error decrypting [other]")))
+		_, err = openAll("backup", sealed.Bytes()[:sealed.Len()-1000])
+		Expect(err).To(MatchError("error decrypting [backup] [stream is truncated]"))
+
+		plaintextClient := NewWithBackend(client.Iaas, client.Backend, "test", "")
+		_, err = plaintextClient.OpenReader("backup", bytes.NewReader(sealed.Bytes()))
+		Expect(err).To(MatchError("[backup] is encrypted but no --encryption was given"))
+
+		var plain bytes.Buffer
+		w, err = plaintextClient.SealWriter("backup", &plain)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = w.Write([]byte("plain"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+		Expect(plain.String()).To(Equal("plain"))
+		Expect(openAll("backup", plain.Bytes())).To(Equal([]byte("plain")))
+	})
+
+	Describe("EncryptPlaintextAssets", func() {
+		It("encrypts existing plaintext assets and leaves encrypted ones alone", func() {
+			plaintextConfig, err := json.Marshal(Config{DirectorPassword: "s3cr3t"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.Backend.WriteFile(bucket, "config.json", plaintextConfig)).To(Succeed())
+			Expect(client.Backend.WriteFile(bucket, "director-creds.yml", []byte("admin_password: s3cr3t"))).To(Succeed())
+			Expect(client.StoreAsset("director-state.json", []byte("{}"))).To(Succeed())
+			alreadyEncrypted := rawFile("director-state.json")
+
+			Expect(client.EncryptPlaintextAssets("director-creds.yml", "director-state.json", "missing.yml")).To(Succeed())
+
+			Expect(IsEncrypted(rawFile("config.json"))).To(BeTrue())
+			Expect(IsEncrypted(rawFile("director-creds.yml"))).To(BeTrue())
+			Expect(rawFile("director-state.json")).To(Equal(alreadyEncrypted))
+			Expect(client.HasAsset("missing.yml")).To(BeFalse())
+
+			conf, err := client.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.DirectorPassword).To(Equal("s3cr3t"))
+		})
+
+		It("does nothing when encryption is not enabled", func() {
+			Expect(client.Backend.WriteFile(bucket, "config.json", []byte("{}"))).To(Succeed())
+			client.KeyWrapper = nil
+
+			Expect(client.EncryptPlaintextAssets()).To(Succeed())
+			Expect(rawFile("config.json")).To(Equal([]byte("{}")))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts complete settings", func() {
+			Expect(Encryption{}.Validate()).To(Succeed())
+			Expect(Encryption{Type: PassphraseEncryption, Passphrase: "p"}.Validate()).To(Succeed())
+			Expect(Encryption{Type: AWSKMSEncryption, KMSKeyID: "alias/control-tower"}.Validate()).To(Succeed())
+		})
+
+		It("rejects incomplete settings", func() {
+			Expect(Encryption{Type: PassphraseEncryption}.Validate()).To(MatchError("--encryption-passphrase is required when --encryption is passphrase"))
+			Expect(Encryption{Type: GCPKMSEncryption}.Validate()).To(MatchError("--encryption-kms-key-id is required when --encryption is gcp-kms"))
+			Expect(Encryption{Type: "rot13"}.Validate()).To(MatchError("unknown encryption [rot13], must be one of passphrase, aws-kms or gcp-kms"))
+		})
+	})
+})
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const historyIndexFilename = "history.json"
+
+// Revision is a snapshot of the config and director state taken after a successful deploy
+type Revision struct {
+	Number    int       `json:"number"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   string    `json:"version"`
+	Operation string    `json:"operation"`
+	Files     []string  `json:"files"`
+}
+
+// Revisions returns every recorded revision, oldest first
+func (client *Client) Revisions() ([]Revision, error) {
+	exists, err := client.HasAsset(historyIndexFilename)
+	if err != nil {
+		return nil, fmt.Errorf("error determining if [%v] exists: [%v]", historyIndexFilename, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	contents, err := client.LoadAsset(historyIndexFilename)
+	if err != nil {
+		return nil, fmt.Errorf("error loading [%v]: [%v]", historyIndexFilename, err)
+	}
+
+	var revisions []Revision
+	if err = json.Unmarshal(contents, &revisions); err != nil {
+		return nil, fmt.Errorf("error parsing [%v]: [%v]", historyIndexFilename, err)
+	}
+	return revisions, nil
+}
+
+// RecordRevision copies the current config file, and any of filenames that exist, into a new revision
+func (client *Client) RecordRevision(operation string, filenames ...string) (Revision, error) {
+	conf, err := client.Load()
+	if err != nil {
+		return Revision{}, fmt.Errorf("error loading config to record revision: [%v]", err)
+	}
+
+	revisions, err := client.Revisions()
+	if err != nil {
+		return Revision{}, err
+	}
+
+	revision := Revision{
+		Number:    1,
+		CreatedAt: time.Now().UTC(),
+		Version:   conf.GetVersion(),
+		Operation: operation,
+	}
+	if len(revisions) > 0 {
+		revision.Number = revisions[len(revisions)-1].Number + 1
+	}
+
+	for _, filename := range append([]string{ConfigFilename}, filenames...) {
+		exists, err := client.HasAsset(filename)
+		if err != nil {
+			return Revision{}, fmt.Errorf("error determining if [%v] exists: [%v]", filename, err)
+		}
+		if !exists {
+			continue
+		}
+
+		contents, err := client.LoadAsset(filename)
+		if err != nil {
+			return Revision{}, fmt.Errorf("error loading [%v]: [%v]", filename, err)
+		}
+		if err = client.StoreAsset(revisionPath(revision.Number, filename), contents); err != nil {
+			return Revision{}, fmt.Errorf("error storing [%v] in revision %d: [%v]", filename, revision.Number, err)
+		}
+		revision.Files = append(revision.Files, filename)
+	}
+
+	index, err := json.Marshal(append(revisions, revision))
+	if err != nil {
+		return Revision{}, err
+	}
+	if err = client.StoreAsset(historyIndexFilename, index); err != nil {
+		return Revision{}, fmt.Errorf("error storing [%v]: [%v]", historyIndexFilename, err)
+	}
+
+	return revision, nil
+}
+
+// LoadRevision returns filename as it was at revision number
+func (client *Client) LoadRevision(number int, filename string) ([]byte, error) {
+	revision, err := client.findRevision(number)
+	if err != nil {
+		return nil, err
+	}
+	if !revision.has(filename) {
+		return nil, fmt.Errorf("revision %d does not include [%v]", number, filename)
+	}
+	return client.LoadAsset(revisionPath(number, filename))
+}
+
+// RestoreRevision replaces the current config file, and any of filenames, with their contents at revision number
+func (client *Client) RestoreRevision(number int, filenames ...string) error {
+	revision, err := client.findRevision(number)
+	if err != nil {
+		return err
+	}
+
+	for _, filename := range append([]string{ConfigFilename}, filenames...) {
+		if !revision.has(filename) {
+			return fmt.Errorf("revision %d does not include [%v]", number, filename)
+		}
+	}
+
+	for _, filename := range append([]string{ConfigFilename}, filenames...) {
+		contents, err := client.LoadAsset(revisionPath(number, filename))
+		if err != nil {
+			return fmt.Errorf("error loading [%v] from revision %d: [%v]", filename, number, err)
+		}
+		if err = client.StoreAsset(filename, contents); err != nil {
+			return fmt.Errorf("error restoring [%v] from revision %d: [%v]", filename, number, err)
+		}
+	}
+
+	return nil
+}
+
+func (client *Client) findRevision(number int) (Revision, error) {
+	revisions, err := client.Revisions()
+	if err != nil {
+		return Revision{}, err
+	}
+	for _, revision := range revisions {
+		if revision.Number == number {
+			return revision, nil
+		}
+	}
+	return Revision{}, fmt.Errorf("revision %d not found, run `control-tower history` to list revisions", number)
+}
+
+func (r Revision) has(filename string) bool {
+	for _, f := range r.Files {
+		if f == filename {
+			return true
+		}
+	}
+	return false
+}
+
+func revisionPath(number int, filename string) string {
+	return fmt.Sprintf("history/%d/%s", number, filename)
+}
@@ -0,0 +1,103 @@
+package config_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	. "github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/iaas/iaasfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	var root string
+	var client *Client
+
+	storeConfig := func(version string) {
+		conf := Config{Version: version, Deployment: "control-tower-test"}
+		contents, err := json.Marshal(conf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.StoreAsset(ConfigFilename, contents)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		root, err = ioutil.TempDir("", "control-tower-history")
+		Expect(err).ToNot(HaveOccurred())
+
+		provider := &iaasfakes.FakeProvider{}
+		provider.RegionReturns("eu-west-1")
+		client = NewWithBackend(provider, NewLocal(root), "test", "")
+		Expect(client.EnsureBucketExists()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(root)
+	})
+
+	It("has no revisions until one is recorded", func() {
+		Expect(client.Revisions()).To(BeEmpty())
+	})
+
+	It("records revisions of the config and any existing files", func() {
+		storeConfig("0.1.0")
+		Expect(client.StoreAsset("director-state.json", []byte("state 1"))).To(Succeed())
+
+		first, err := client.RecordRevision("deploy", "director-state.json", "director-creds.yml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(first.Number).To(Equal(1))
+		Expect(first.Version).To(Equal("0.1.0"))
+		Expect(first.Operation).To(Equal("deploy"))
+		Expect(first.Files).To(Equal([]string{ConfigFilename, "director-state.json"}))
+
+		storeConfig("0.2.0")
+		second, err := client.RecordRevision("deploy", "director-state.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second.Number).To(Equal(2))
+
+		revisions, err := client.Revisions()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(revisions).To(HaveLen(2))
+		Expect(revisions[0].Version).To(Equal("0.1.0"))
+		Expect(revisions[1].Version).To(Equal("0.2.0"))
+
+		Expect(client.LoadRevision(1, "director-state.json")).To(Equal([]byte("state 1")))
+		_, err = client.LoadRevision(1, "director-creds.yml")
+		Expect(err).To(MatchError("revision 1 does not include [director-creds.yml]"))
+	})
+
+	It("restores a revision", func() {
+		storeConfig("0.1.0")
+		Expect(client.StoreAsset("director-state.json", []byte("state 1"))).To(Succeed())
+		_, err := client.RecordRevision("deploy", "director-state.json")
+		Expect(err).ToNot(HaveOccurred())
+
+		storeConfig("0.2.0")
+		Expect(client.StoreAsset("director-state.json", []byte("state 2"))).To(Succeed())
+
+		Expect(client.RestoreRevision(1)).To(Succeed())
+		conf, err := client.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conf.Version).To(Equal("0.1.0"))
+		Expect(client.LoadAsset("director-state.json")).To(Equal([]byte("state 2")))
+
+		Expect(client.RestoreRevision(1, "director-state.json")).To(Succeed())
+		Expect(client.LoadAsset("director-state.json")).To(Equal([]byte("state 1")))
+	})
+
+	It("refuses to restore files a revision does not include", func() {
+		storeConfig("0.1.0")
+		_, err := client.RecordRevision("deploy")
+		Expect(err).ToNot(HaveOccurred())
+		storeConfig("0.2.0")
+
+		Expect(client.RestoreRevision(1, "director-state.json")).To(MatchError("revision 1 does not include [director-state.json]"))
+		Expect(client.RestoreRevision(3)).To(MatchError(ContainSubstring("revision 3 not found")))
+
+		conf, err := client.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conf.Version).To(Equal("0.2.0"))
+	})
+})
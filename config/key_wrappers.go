@@ -0,0 +1,177 @@
+package config
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"golang.org/x/crypto/scrypt"
+	cloudkms "google.golang.org/api/cloudkms/v1"
+)
+
+// scrypt parameters recommended for interactive logins
+const (
+	scryptN       = 32768
+	scryptR       = 8
+	scryptP       = 1
+	scryptSaltLen = 16
+)
+
+// PassphraseKeyWrapper wraps data keys with a key derived from a passphrase using scrypt
+type PassphraseKeyWrapper struct {
+	passphrase []byte
+}
+
+// NewPassphraseKeyWrapper returns a KeyWrapper for passphrase
+func NewPassphraseKeyWrapper(passphrase string) *PassphraseKeyWrapper {
+	return &PassphraseKeyWrapper{passphrase: []byte(passphrase)}
+}
+
+// Name identifies the KeyWrapper in stored envelopes
+func (w *PassphraseKeyWrapper) Name() string {
+	return PassphraseEncryption
+}
+
+// WrapKey returns salt, nonce and dataKey encrypted with a key derived from the passphrase and salt
+func (w *PassphraseKeyWrapper) WrapKey(dataKey []byte) ([]byte, error) {
+	salt := make([]byte, scryptSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := w.gcm(salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	wrapped := append(salt, nonce...)
+	return gcm.Seal(wrapped, nonce, dataKey, nil), nil
+}
+
+// UnwrapKey reverses WrapKey
+func (w *PassphraseKeyWrapper) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	if len(wrappedKey) < scryptSaltLen {
+		return nil, errors.New("wrapped key is too short")
+	}
+	salt, rest := wrappedKey[:scryptSaltLen], wrappedKey[scryptSaltLen:]
+
+	gcm, err := w.gcm(salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < gcm.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+
+	dataKey, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("incorrect passphrase")
+	}
+	return dataKey, nil
+}
+
+func (w *PassphraseKeyWrapper) gcm(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(w.passphrase, salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	return newGCM(key)
+}
+
+// AWSKMSKeyWrapper wraps data keys with an AWS KMS key
+type AWSKMSKeyWrapper struct {
+	client *kms.KMS
+	keyID  string
+}
+
+// NewAWSKMSKeyWrapper returns a KeyWrapper for the KMS key keyID, which may be an ID, ARN or alias
+func NewAWSKMSKeyWrapper(region, keyID string) (*AWSKMSKeyWrapper, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("error creating session for AWS KMS [%v]", err)
+	}
+	return &AWSKMSKeyWrapper{client: kms.New(sess), keyID: keyID}, nil
+}
+
+// Name identifies the KeyWrapper in stored envelopes
+func (w *AWSKMSKeyWrapper) Name() string {
+	return AWSKMSEncryption
+}
+
+// WrapKey encrypts dataKey with the KMS key
+func (w *AWSKMSKeyWrapper) WrapKey(dataKey []byte) ([]byte, error) {
+	output, err := w.client.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String(w.keyID),
+		Plaintext: dataKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.CiphertextBlob, nil
+}
+
+// UnwrapKey decrypts a data key encrypted by WrapKey
+func (w *AWSKMSKeyWrapper) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	output, err := w.client.Decrypt(&kms.DecryptInput{
+		KeyId:          aws.String(w.keyID),
+		CiphertextBlob: wrappedKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Plaintext, nil
+}
+
+// GCPKMSKeyWrapper wraps data keys with a GCP Cloud KMS key
+type GCPKMSKeyWrapper struct {
+	keys    *cloudkms.ProjectsLocationsKeyRingsCryptoKeysService
+	keyName string
+}
+
+// NewGCPKMSKeyWrapper returns a KeyWrapper for the Cloud KMS key
+// projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>
+func NewGCPKMSKeyWrapper(keyName string) (*GCPKMSKeyWrapper, error) {
+	service, err := cloudkms.NewService(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error creating Cloud KMS client [%v]", err)
+	}
+	return &GCPKMSKeyWrapper{keys: service.Projects.Locations.KeyRings.CryptoKeys, keyName: keyName}, nil
+}
+
+// Name identifies the KeyWrapper in stored envelopes
+func (w *GCPKMSKeyWrapper) Name() string {
+	return GCPKMSEncryption
+}
+
+// WrapKey encrypts dataKey with the Cloud KMS key
+func (w *GCPKMSKeyWrapper) WrapKey(dataKey []byte) ([]byte, error) {
+	response, err := w.keys.Encrypt(w.keyName, &cloudkms.EncryptRequest{
+		Plaintext: base64.StdEncoding.EncodeToString(dataKey),
+	}).Do()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(response.Ciphertext)
+}
+
+// UnwrapKey decrypts a data key encrypted by WrapKey
+func (w *GCPKMSKeyWrapper) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	response, err := w.keys.Decrypt(w.keyName, &cloudkms.DecryptRequest{
+		Ciphertext: base64.StdEncoding.EncodeToString(wrappedKey),
+	}).Do()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(response.Plaintext)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Local is a Backend that keeps each bucket as a directory under Root
+type Local struct {
+	Root string
+}
+
+// NewLocal returns a Backend rooted at dir
+func NewLocal(dir string) *Local {
+	return &Local{Root: dir}
+}
+
+// BucketExists returns true if the bucket directory exists
+func (l *Local) BucketExists(name string) (bool, error) {
+	info, err := os.Stat(l.bucketPath(name))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return info.IsDir(), nil
+}
+
+// CreateBucket creates the bucket directory
+func (l *Local) CreateBucket(name string) error {
+	return os.MkdirAll(l.bucketPath(name), 0700)
+}
+
+// DeleteVersionedBucket removes the bucket directory and everything in it
+func (l *Local) DeleteVersionedBucket(name string) error {
+	return os.RemoveAll(l.bucketPath(name))
+}
+
+// HasFile returns true if the file exists in the bucket
+func (l *Local) HasFile(bucket, path string) (bool, error) {
+	_, err := os.Stat(l.filePath(bucket, path))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// LoadFile reads a file from the bucket
+func (l *Local) LoadFile(bucket, path string) ([]byte, error) {
+	return ioutil.ReadFile(l.filePath(bucket, path))
+}
+
+// WriteFile replaces a file in the bucket, so readers never see a partial write
+func (l *Local) WriteFile(bucket, path string, contents []byte) error {
+	target := l.filePath(bucket, path)
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(target), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(contents)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), target)
+}
+
+func (l *Local) bucketPath(name string) string {
+	return filepath.Join(l.Root, filepath.Base(name))
+}
+
+// filePath keeps path inside the bucket, even when it contains ..
+func (l *Local) filePath(bucket, path string) string {
+	return filepath.Join(l.bucketPath(bucket), filepath.Clean("/"+path))
+}
@@ -0,0 +1,81 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/EngineerBetter/control-tower/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Local", func() {
+	var root string
+	var backend *Local
+
+	BeforeEach(func() {
+		var err error
+		root, err = ioutil.TempDir("", "control-tower-state")
+		Expect(err).ToNot(HaveOccurred())
+		backend = NewLocal(root)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(root)
+	})
+
+	It("creates, detects and deletes buckets", func() {
+		Expect(backend.BucketExists("bucket")).To(BeFalse())
+		Expect(backend.CreateBucket("bucket")).To(Succeed())
+		Expect(backend.BucketExists("bucket")).To(BeTrue())
+
+		Expect(backend.DeleteVersionedBucket("bucket")).To(Succeed())
+		Expect(backend.BucketExists("bucket")).To(BeFalse())
+	})
+
+	It("writes and loads files", func() {
+		Expect(backend.CreateBucket("bucket")).To(Succeed())
+		Expect(backend.HasFile("bucket", "config.json")).To(BeFalse())
+
+		Expect(backend.WriteFile("bucket", "config.json", []byte("first"))).To(Succeed())
+		Expect(backend.WriteFile("bucket", "config.json", []byte("second"))).To(Succeed())
+
+		Expect(backend.HasFile("bucket", "config.json")).To(BeTrue())
+		Expect(backend.LoadFile("bucket", "config.json")).To(Equal([]byte("second")))
+
+		info, err := os.Stat(filepath.Join(root, "bucket", "config.json"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		entries, err := ioutil.ReadDir(filepath.Join(root, "bucket"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	})
+
+	It("keeps files inside the bucket", func() {
+		Expect(backend.WriteFile("bucket", "../../escaped", []byte("contents"))).To(Succeed())
+		Expect(filepath.Join(root, "bucket", "escaped")).To(BeAnExistingFile())
+		Expect(filepath.Join(filepath.Dir(root), "escaped")).ToNot(BeAnExistingFile())
+	})
+})
+
+var _ = Describe("StateBackend", func() {
+	Describe("Validate", func() {
+		It("accepts complete settings", func() {
+			Expect(StateBackend{}.Validate()).To(Succeed())
+			Expect(StateBackend{Type: IAASBackend}.Validate()).To(Succeed())
+			Expect(StateBackend{Type: LocalBackend, Dir: "/state"}.Validate()).To(Succeed())
+			Expect(StateBackend{Type: S3Backend, Endpoint: "http://localhost:9000"}.Validate()).To(Succeed())
+		})
+
+		It("rejects incomplete settings", func() {
+			Expect(StateBackend{Type: LocalBackend}.Validate()).To(MatchError("--state-dir is required when --state-backend is local"))
+			Expect(StateBackend{Type: S3Backend}.Validate()).To(MatchError("--state-endpoint is required when --state-backend is s3"))
+		})
+
+		It("rejects unknown backends", func() {
+			Expect(StateBackend{Type: "floppy"}.Validate()).To(MatchError("unknown state backend [floppy], must be one of iaas, local or s3"))
+		})
+	})
+})
@@ -0,0 +1,309 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+const lockFilename = "control-tower.lock"
+
+const (
+	defaultLockTTL        = 5 * time.Minute
+	defaultLockPoll       = 15 * time.Second
+	defaultLockSettleTime = 2 * time.Second
+)
+
+// lease is the lock object stored in the config bucket. A lease is held until it expires,
+// and its holder keeps extending it for as long as the operation runs.
+type lease struct {
+	ID         string    `json:"id"`
+	Holder     string    `json:"holder"`
+	Operation  string    `json:"operation"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (l *lease) heldAt(t time.Time) bool {
+	return l != nil && t.Before(l.ExpiresAt)
+}
+
+// LockedError is returned when another holder has a lease on the deployment
+type LockedError struct {
+	Holder     string
+	Operation  string
+	AcquiredAt time.Time
+	ExpiresAt  time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("deployment is locked by %s running %s since %s (lease expires %s), use --force-unlock if it is no longer running",
+		e.Holder, e.Operation, e.AcquiredAt.Format(time.RFC3339), e.ExpiresAt.Format(time.RFC3339))
+}
+
+// lockState tracks the lease held by a Client
+type lockState struct {
+	mutex    sync.Mutex
+	lease    *lease
+	lost     *lease
+	stop     chan struct{}
+	finished chan struct{}
+	ttl      time.Duration
+	poll     time.Duration
+	settle   time.Duration
+}
+
+// Lock takes a lease on the deployment for operation, waiting up to LockTimeout for another
+// holder to release it. The lease is kept alive in the background until Unlock is called.
+func (client *Client) Lock(operation string) error {
+	deadline := time.Now().Add(client.LockTimeout)
+	forceUnlock := client.ForceUnlock
+
+	for {
+		err := client.tryLock(operation, forceUnlock)
+		if err == nil {
+			break
+		}
+		if _, locked := err.(*LockedError); !locked || !time.Now().Before(deadline) {
+			return err
+		}
+		time.Sleep(client.lock.pollInterval())
+	}
+
+	client.startHeartbeat()
+	return nil
+}
+
+// Unlock releases the lease taken by Lock. It returns an error if the lease was taken over
+// by someone else in the meantime, for instance with --force-unlock.
+func (client *Client) Unlock() error {
+	held := client.stopHeartbeat()
+
+	client.lock.mutex.Lock()
+	lost := client.lock.lost
+	client.lock.lease = nil
+	client.lock.lost = nil
+	client.lock.mutex.Unlock()
+
+	if lost != nil {
+		return fmt.Errorf("lock was taken over by %s running %s", lost.Holder, lost.Operation)
+	}
+	if held == nil {
+		return nil
+	}
+
+	current, err := client.readLease()
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return nil
+	}
+	if current.ID != held.ID {
+		return fmt.Errorf("lock was taken over by %s running %s", current.Holder, current.Operation)
+	}
+
+	released := *held
+	released.ExpiresAt = time.Now()
+	return client.writeLease(&released)
+}
+
+func (client *Client) tryLock(operation string, force bool) error {
+	current, err := client.readLease()
+	if err != nil {
+		return err
+	}
+	if current.heldAt(time.Now()) && !force {
+		return lockedError(current)
+	}
+
+	now := time.Now()
+	mine := &lease{
+		ID:         newLeaseID(),
+		Holder:     lockHolder(),
+		Operation:  operation,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(client.lock.leaseTTL()),
+	}
+	if err = client.writeLease(mine); err != nil {
+		return err
+	}
+
+	// Object stores offer no compare-and-swap, so give a concurrent writer time to land
+	// and then check that ours is the lease that won
+	time.Sleep(client.lock.settleTime())
+	current, err = client.readLease()
+	if err != nil {
+		return err
+	}
+	if current == nil || current.ID != mine.ID {
+		return lockedError(current)
+	}
+
+	client.lock.mutex.Lock()
+	client.lock.lease = mine
+	client.lock.mutex.Unlock()
+	return nil
+}
+
+func (client *Client) startHeartbeat() {
+	client.lock.mutex.Lock()
+	defer client.lock.mutex.Unlock()
+
+	stop := make(chan struct{})
+	finished := make(chan struct{})
+	client.lock.stop = stop
+	client.lock.finished = finished
+
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(client.lock.leaseTTL() / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if !client.renewLease() {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// renewLease extends the lease, returning false if it has been lost
+func (client *Client) renewLease() bool {
+	client.lock.mutex.Lock()
+	held := client.lock.lease
+	client.lock.mutex.Unlock()
+	if held == nil {
+		return false
+	}
+
+	// A failed read or write is retried on the next tick, which is well within the TTL
+	current, err := client.readLease()
+	if err != nil {
+		return true
+	}
+	if current == nil || current.ID != held.ID {
+		client.lock.mutex.Lock()
+		client.lock.lost = current
+		client.lock.lease = nil
+		client.lock.mutex.Unlock()
+		return false
+	}
+
+	renewed := *held
+	renewed.ExpiresAt = time.Now().Add(client.lock.leaseTTL())
+	if client.writeLease(&renewed) == nil {
+		client.lock.mutex.Lock()
+		client.lock.lease = &renewed
+		client.lock.mutex.Unlock()
+	}
+	return true
+}
+
+// stopHeartbeat stops renewing the lease and returns it, if it is still held
+func (client *Client) stopHeartbeat() *lease {
+	client.lock.mutex.Lock()
+	stop, finished := client.lock.stop, client.lock.finished
+	client.lock.stop, client.lock.finished = nil, nil
+	client.lock.mutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-finished
+	}
+
+	client.lock.mutex.Lock()
+	defer client.lock.mutex.Unlock()
+	return client.lock.lease
+}
+
+func (client *Client) readLease() (*lease, error) {
+	exists, err := client.HasAsset(lockFilename)
+	if err != nil {
+		return nil, fmt.Errorf("error determining if [%v] exists: [%v]", lockFilename, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	contents, err := client.backend().LoadFile(client.configBucket(), lockFilename)
+	if err != nil {
+		return nil, fmt.Errorf("error loading [%v]: [%v]", lockFilename, err)
+	}
+
+	var l lease
+	if err = json.Unmarshal(contents, &l); err != nil {
+		return nil, fmt.Errorf("error parsing [%v]: [%v]", lockFilename, err)
+	}
+	return &l, nil
+}
+
+// writeLease stores the lease unencrypted, so that holders are visible without the key
+func (client *Client) writeLease(l *lease) error {
+	contents, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return client.backend().WriteFile(client.configBucket(), lockFilename, contents)
+}
+
+func lockedError(l *lease) error {
+	if l == nil {
+		return &LockedError{Holder: "an unknown holder", Operation: "an unknown operation"}
+	}
+	return &LockedError{
+		Holder:     l.Holder,
+		Operation:  l.Operation,
+		AcquiredAt: l.AcquiredAt,
+		ExpiresAt:  l.ExpiresAt,
+	}
+}
+
+func lockHolder() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s@%s (pid %d)", name, host, os.Getpid())
+}
+
+func newLeaseID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (s *lockState) leaseTTL() time.Duration {
+	if s.ttl == 0 {
+		return defaultLockTTL
+	}
+	return s.ttl
+}
+
+func (s *lockState) pollInterval() time.Duration {
+	if s.poll == 0 {
+		return defaultLockPoll
+	}
+	return s.poll
+}
+
+func (s *lockState) settleTime() time.Duration {
+	if s.settle == 0 {
+		return defaultLockSettleTime
+	}
+	return s.settle
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EngineerBetter/control-tower/iaas/iaasfakes"
+)
+
+func newLockTestClient(t *testing.T, root string) *Client {
+	provider := &iaasfakes.FakeProvider{}
+	provider.RegionReturns("eu-west-1")
+
+	client := NewWithBackend(provider, NewLocal(root), "test", "")
+	client.lock.ttl = 300 * time.Millisecond
+	client.lock.poll = 20 * time.Millisecond
+	client.lock.settle = time.Millisecond
+	if err := client.EnsureBucketExists(); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestClient_Lock(t *testing.T) {
+	root, err := ioutil.TempDir("", "control-tower-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	tests := []struct {
+		name    string
+		run     func(first, second *Client) error
+		wantErr string
+	}{
+		{
+			name: "lock can be taken again once released",
+			run: func(first, second *Client) error {
+				if err := first.Lock("deploy"); err != nil {
+					return err
+				}
+				if err := first.Unlock(); err != nil {
+					return err
+				}
+				if err := second.Lock("destroy"); err != nil {
+					return err
+				}
+				return second.Unlock()
+			},
+		},
+		{
+			name: "lock held by someone else is refused",
+			run: func(first, second *Client) error {
+				if err := first.Lock("deploy"); err != nil {
+					return err
+				}
+				defer first.Unlock()
+				return second.Lock("destroy")
+			},
+			wantErr: "deployment is locked by",
+		},
+		{
+			name: "waits for the lock to be released",
+			run: func(first, second *Client) error {
+				if err := first.Lock("deploy"); err != nil {
+					return err
+				}
+				time.AfterFunc(100*time.Millisecond, func() { first.Unlock() })
+
+				second.LockTimeout = 5 * time.Second
+				if err := second.Lock("deploy"); err != nil {
+					return err
+				}
+				return second.Unlock()
+			},
+		},
+		{
+			name: "heartbeat keeps the lease alive past its TTL",
+			run: func(first, second *Client) error {
+				if err := first.Lock("deploy"); err != nil {
+					return err
+				}
+				defer first.Unlock()
+				time.Sleep(3 * first.lock.ttl)
+				return second.Lock("destroy")
+			},
+			wantErr: "deployment is locked by",
+		},
+		{
+			name: "expired lease from a crashed holder is ignored",
+			run: func(first, second *Client) error {
+				if err := first.Lock("deploy"); err != nil {
+					return err
+				}
+				first.stopHeartbeat()
+				time.Sleep(2 * first.lock.ttl)
+				if err := second.Lock("destroy"); err != nil {
+					return err
+				}
+				return second.Unlock()
+			},
+		},
+		{
+			name: "force unlock takes the lease over",
+			run: func(first, second *Client) error {
+				if err := first.Lock("deploy"); err != nil {
+					return err
+				}
+				second.ForceUnlock = true
+				if err := second.Lock("destroy"); err != nil {
+					return err
+				}
+				defer second.Unlock()
+				return first.Unlock()
+			},
+			wantErr: "lock was taken over by",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.RemoveAll(root); err != nil {
+				t.Fatal(err)
+			}
+			first := newLockTestClient(t, root)
+			second := newLockTestClient(t, root)
+
+			err := tt.run(first, second)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
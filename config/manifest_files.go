@@ -0,0 +1,93 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// ManifestFile is an ops file or vars file given to deploy. It is stored with its contents so that
+// deploys which cannot read the original, such as those run by the self-update pipeline, still apply it.
+type ManifestFile struct {
+	Name     string `json:"name"`
+	Contents string `json:"contents"`
+}
+
+// NewOpsFile checks that contents is a YAML list of BOSH operations
+func NewOpsFile(name string, contents []byte) (ManifestFile, error) {
+	var ops []struct {
+		Type string `json:"type"`
+		Path string `json:"path"`
+	}
+	if err := yaml.Unmarshal(contents, &ops); err != nil {
+		return ManifestFile{}, fmt.Errorf("ops file %s is not a list of operations: [%v]", name, err)
+	}
+	for i, op := range ops {
+		if op.Type == "" || op.Path == "" {
+			return ManifestFile{}, fmt.Errorf("operation %d in ops file %s must have a type and a path", i+1, name)
+		}
+	}
+	return ManifestFile{Name: name, Contents: string(contents)}, nil
+}
+
+// NewVarsFile checks that contents is a YAML map of variables
+func NewVarsFile(name string, contents []byte) (ManifestFile, error) {
+	if _, err := varsFromFile(ManifestFile{Name: name, Contents: string(contents)}); err != nil {
+		return ManifestFile{}, err
+	}
+	return ManifestFile{Name: name, Contents: string(contents)}, nil
+}
+
+// FormatManifestFiles describes files by their names and the start of the SHA256 of their contents,
+// so that a changed file is told apart from an unchanged one
+func FormatManifestFiles(files []ManifestFile) string {
+	var descriptions []string
+	for _, file := range files {
+		sum := sha256.Sum256([]byte(file.Contents))
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", file.Name, hex.EncodeToString(sum[:4])))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// ConcatOpsFiles joins ops files into one list of operations, in the order they were given
+func ConcatOpsFiles(files []ManifestFile) (string, error) {
+	var ops []interface{}
+	for _, file := range files {
+		var fileOps []interface{}
+		if err := yaml.Unmarshal([]byte(file.Contents), &fileOps); err != nil {
+			return "", fmt.Errorf("ops file %s is not a list of operations: [%v]", file.Name, err)
+		}
+		ops = append(ops, fileOps...)
+	}
+	if len(ops) == 0 {
+		return "", nil
+	}
+	b, err := yaml.Marshal(ops)
+	return string(b), err
+}
+
+// MergeVarsFiles returns the variables set by vars files, with later files taking precedence
+func MergeVarsFiles(files []ManifestFile) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+	for _, file := range files {
+		fileVars, err := varsFromFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+	return vars, nil
+}
+
+func varsFromFile(file ManifestFile) (map[string]interface{}, error) {
+	var vars map[string]interface{}
+	if err := yaml.Unmarshal([]byte(file.Contents), &vars); err != nil {
+		return nil, fmt.Errorf("vars file %s is not a map of variables: [%v]", file.Name, err)
+	}
+	return vars, nil
+}
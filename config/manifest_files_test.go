@@ -0,0 +1,51 @@
+package config_test
+
+import (
+	. "github.com/EngineerBetter/control-tower/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ManifestFiles", func() {
+	It("accepts ops files that are lists of operations", func() {
+		file, err := NewOpsFile("garden.yml", []byte("- type: replace\n  path: /garden?\n  value: ((max_containers))\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Name).To(Equal("garden.yml"))
+
+		_, err = NewOpsFile("vars.yml", []byte("max_containers: 500\n"))
+		Expect(err).To(MatchError(ContainSubstring("ops file vars.yml is not a list of operations")))
+		_, err = NewOpsFile("remove.yml", []byte("- type: remove\n"))
+		Expect(err).To(MatchError("operation 1 in ops file remove.yml must have a type and a path"))
+	})
+
+	It("accepts vars files that are maps of variables", func() {
+		_, err := NewVarsFile("vars.yml", []byte("max_containers: 500\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = NewVarsFile("list.yml", []byte("- max_containers\n"))
+		Expect(err).To(MatchError(ContainSubstring("vars file list.yml is not a map of variables")))
+	})
+
+	It("joins ops files and merges vars files in order", func() {
+		ops, err := ConcatOpsFiles([]ManifestFile{
+			{Name: "a.yml", Contents: "---\n- type: remove\n  path: /a\n"},
+			{Name: "b.yml", Contents: "- type: remove\n  path: /b"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ops).To(Equal("- path: /a\n  type: remove\n- path: /b\n  type: remove\n"))
+
+		vars, err := MergeVarsFiles([]ManifestFile{
+			{Name: "a.yml", Contents: "first: a\nsecond: a\n"},
+			{Name: "b.yml", Contents: "second: b\n"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vars).To(Equal(map[string]interface{}{"first": "a", "second": "b"}))
+	})
+
+	It("tells changed files apart when describing them", func() {
+		before := FormatManifestFiles([]ManifestFile{{Name: "garden.yml", Contents: "a"}})
+		after := FormatManifestFiles([]ManifestFile{{Name: "garden.yml", Contents: "b"}})
+		Expect(before).To(HavePrefix("garden.yml ("))
+		Expect(before).ToNot(Equal(after))
+	})
+})
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/apparentlymart/go-cidr/cidr"
+)
+
+// NATCIDR returns the subnet that the NAT gateway of a private deployment is placed in, which is the
+// last /28 of the network range
+func NATCIDR(networkCIDR string) (string, error) {
+	subnet := tailSubnet(networkCIDR, 0)
+	if subnet == "" {
+		return "", fmt.Errorf("network range %s is too small for the subnet of the NAT gateway", networkCIDR)
+	}
+	return subnet, nil
+}
+
+// WebCIDRs returns the subnets that the web VMs of a deployment with more than one of them are
+// spread across, one in each of two availability zones, followed by the public subnet that the load
+// balancer in front of them needs in the second zone. They are the three /28s before NATCIDR.
+func WebCIDRs(networkCIDR string) ([]string, error) {
+	var subnets []string
+	for n := 1; n <= 3; n++ {
+		subnet := tailSubnet(networkCIDR, n)
+		if subnet == "" {
+			return nil, fmt.Errorf("network range %s is too small for the subnets of several web VMs", networkCIDR)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+// SubnetsOverlap returns true if the ranges a and b share any address. Ranges that cannot be
+// parsed, such as those left empty, overlap nothing.
+func SubnetsOverlap(a, b string) bool {
+	_, first, err := net.ParseCIDR(a)
+	if err != nil {
+		return false
+	}
+	_, second, err := net.ParseCIDR(b)
+	if err != nil {
+		return false
+	}
+	return first.Contains(second.IP) || second.Contains(first.IP)
+}
+
+// tailSubnet returns the nth /28 from the end of the network range, or nothing if the range is
+// invalid or too small. The public, private and RDS subnets are expected to leave these free.
+func tailSubnet(networkCIDR string, n int) string {
+	_, network, err := net.ParseCIDR(networkCIDR)
+	if err != nil {
+		return ""
+	}
+	ones, bits := network.Mask.Size()
+	newBits := bits - 4 - ones
+	if newBits <= 0 || 1<<uint(newBits) <= n {
+		return ""
+	}
+	subnet, err := cidr.Subnet(network, newBits, 1<<uint(newBits)-1-n)
+	if err != nil {
+		return ""
+	}
+	return subnet.String()
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// DefaultPipelinesManifest is the manifest deploy looks for in a pipelines source unless told otherwise
+const DefaultPipelinesManifest = "pipelines.yml"
+
+var pipelineNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// PipelinesSource is where deploy finds the manifest of the pipelines it sets after the
+// self-update pipeline
+type PipelinesSource struct {
+	// Source is a local directory or the URL of a git repository
+	Source string `json:"source"`
+	// Branch is the branch of a git repository to use, or its default branch if empty
+	Branch string `json:"branch,omitempty"`
+	// Manifest is the path of the manifest within Source
+	Manifest string `json:"manifest"`
+}
+
+// IsGit returns true if the source is a git repository rather than a local directory
+func (s PipelinesSource) IsGit() bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "git@"} {
+		if strings.HasPrefix(s.Source, prefix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(s.Source, ".git")
+}
+
+func (s PipelinesSource) String() string {
+	source := s.Source
+	if s.Branch != "" {
+		source += "#" + s.Branch
+	}
+	return fmt.Sprintf("%s from %s", s.Manifest, source)
+}
+
+// FormatPipelinesSource describes a pipelines source on one line, or returns nothing if there is none
+func FormatPipelinesSource(s *PipelinesSource) string {
+	if s == nil {
+		return ""
+	}
+	return s.String()
+}
+
+// UserPipeline is a pipeline listed in a pipelines manifest. Config and Vars are paths relative to
+// the manifest.
+type UserPipeline struct {
+	Team     string   `json:"team"`
+	Pipeline string   `json:"pipeline"`
+	Config   string   `json:"config"`
+	Vars     []string `json:"vars,omitempty"`
+	// Paused leaves the pipeline paused once it is set
+	Paused bool `json:"paused,omitempty"`
+}
+
+func (p UserPipeline) String() string {
+	return p.Team + "/" + p.Pipeline
+}
+
+// ParsePipelinesManifest parses a YAML or JSON list of pipelines, rejecting keys it does not know
+// about. Pipelines without a team are set in the main team.
+func ParsePipelinesManifest(contents []byte) ([]UserPipeline, error) {
+	var pipelines []UserPipeline
+	j, err := yaml.YAMLToJSON(contents)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(j))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&pipelines); err != nil {
+		return nil, err
+	}
+	for i := range pipelines {
+		if pipelines[i].Team == "" {
+			pipelines[i].Team = MainTeam
+		}
+	}
+	return pipelines, nil
+}
+
+// ValidatePipelines checks that pipelines have valid names that are unique within their team, and
+// that their files are within the manifest's directory
+func ValidatePipelines(pipelines []UserPipeline) error {
+	names := map[string]bool{}
+	for _, pipeline := range pipelines {
+		if !teamNameRegexp.MatchString(pipeline.Team) {
+			return fmt.Errorf("pipeline `%s` has an invalid team name `%s`", pipeline.Pipeline, pipeline.Team)
+		}
+		if !pipelineNameRegexp.MatchString(pipeline.Pipeline) {
+			return fmt.Errorf("pipeline name `%s` is invalid: must start with a letter or digit and contain only letters, digits, hyphens, underscores and dots", pipeline.Pipeline)
+		}
+		if names[pipeline.String()] {
+			return fmt.Errorf("pipeline `%s` is listed more than once", pipeline)
+		}
+		names[pipeline.String()] = true
+
+		if pipeline.Config == "" {
+			return fmt.Errorf("pipeline `%s` has no config", pipeline)
+		}
+		for _, path := range append([]string{pipeline.Config}, pipeline.Vars...) {
+			if filepath.IsAbs(path) || strings.HasPrefix(filepath.Clean(path), "..") {
+				return fmt.Errorf("pipeline `%s` has file `%s` outside the manifest's directory", pipeline, path)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config_test
+
+import (
+	. "github.com/EngineerBetter/control-tower/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pipelines", func() {
+	It("parses a pipelines manifest, setting pipelines in main by default", func() {
+		pipelines, err := ParsePipelinesManifest([]byte(`
+- pipeline: hello
+  config: ci/hello.yml
+- team: platform
+  pipeline: deploy
+  config: ci/deploy.yml
+  vars: [ci/common.yml, ci/deploy-vars.yml]
+  paused: true
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pipelines).To(Equal([]UserPipeline{
+			{Team: "main", Pipeline: "hello", Config: "ci/hello.yml"},
+			{Team: "platform", Pipeline: "deploy", Config: "ci/deploy.yml", Vars: []string{"ci/common.yml", "ci/deploy-vars.yml"}, Paused: true},
+		}))
+		Expect(ValidatePipelines(pipelines)).To(Succeed())
+	})
+
+	It("rejects keys it does not know about", func() {
+		_, err := ParsePipelinesManifest([]byte("- pipeline: hello\n  config: ci/hello.yml\n  load_vars_from: [ci/vars.yml]\n"))
+		Expect(err).To(MatchError(ContainSubstring(`unknown field "load_vars_from"`)))
+	})
+
+	It("rejects invalid pipelines", func() {
+		cases := []struct {
+			pipelines []UserPipeline
+			message   string
+		}{
+			{[]UserPipeline{{Team: "main", Pipeline: "hello world", Config: "hello.yml"}}, "pipeline name `hello world` is invalid"},
+			{[]UserPipeline{{Team: "Main", Pipeline: "hello", Config: "hello.yml"}}, "invalid team name `Main`"},
+			{[]UserPipeline{{Team: "main", Pipeline: "hello", Config: "a.yml"}, {Team: "main", Pipeline: "hello", Config: "b.yml"}}, "pipeline `main/hello` is listed more than once"},
+			{[]UserPipeline{{Team: "main", Pipeline: "hello"}}, "pipeline `main/hello` has no config"},
+			{[]UserPipeline{{Team: "main", Pipeline: "hello", Config: "hello.yml", Vars: []string{"../secrets.yml"}}}, "outside the manifest's directory"},
+			{[]UserPipeline{{Team: "main", Pipeline: "hello", Config: "/etc/hello.yml"}}, "outside the manifest's directory"},
+		}
+		for _, c := range cases {
+			Expect(ValidatePipelines(c.pipelines)).To(MatchError(ContainSubstring(c.message)))
+		}
+	})
+
+	It("tells git repositories from local directories", func() {
+		Expect(PipelinesSource{Source: "https://github.com/EngineerBetter/pipelines"}.IsGit()).To(BeTrue())
+		Expect(PipelinesSource{Source: "git@github.com:EngineerBetter/pipelines.git"}.IsGit()).To(BeTrue())
+		Expect(PipelinesSource{Source: "/home/ci/pipelines"}.IsGit()).To(BeFalse())
+	})
+})
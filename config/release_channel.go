@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ReleaseChannelStable follows final releases of control-tower
+	ReleaseChannelStable = "stable"
+	// ReleaseChannelPreRelease follows final releases and pre-releases of control-tower
+	ReleaseChannelPreRelease = "pre-release"
+)
+
+var pinnedReleaseChannel = regexp.MustCompile(`^\d+(\.x|\.\d+\.x|\.\d+\.\d+)$`)
+
+// ValidateReleaseChannel checks that channel is stable, pre-release or a version range such as 0.17.x
+func ValidateReleaseChannel(channel string) error {
+	if channel == ReleaseChannelStable || channel == ReleaseChannelPreRelease || pinnedReleaseChannel.MatchString(channel) {
+		return nil
+	}
+	return fmt.Errorf("release channel %q must be %s, %s or a version range such as 0.17.x", channel, ReleaseChannelStable, ReleaseChannelPreRelease)
+}
+
+// ReleaseTagFilter returns a regular expression matching the release tags in the version range a
+// pinned channel names, or an empty string for the other channels
+func ReleaseTagFilter(channel string) string {
+	if !pinnedReleaseChannel.MatchString(channel) {
+		return ""
+	}
+	parts := strings.Split(channel, ".")
+	if parts[len(parts)-1] == "x" {
+		// x stands for the remaining minor and patch versions
+		parts[len(parts)-1] = `\d+`
+		for len(parts) < 3 {
+			parts = append(parts, `\d+`)
+		}
+	}
+	return `^v?` + strings.Join(parts, `\.`) + `$`
+}
+
+// ReleaseChannelIncludes reports whether the release tagged tag is followed by channel
+func ReleaseChannelIncludes(channel, tag string, preRelease bool) bool {
+	if channel == ReleaseChannelPreRelease {
+		return true
+	}
+	if preRelease {
+		return false
+	}
+	filter := ReleaseTagFilter(channel)
+	return filter == "" || regexp.MustCompile(filter).MatchString(tag)
+}
@@ -0,0 +1,35 @@
+package config_test
+
+import (
+	. "github.com/EngineerBetter/control-tower/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReleaseChannel", func() {
+	It("accepts stable, pre-release and version ranges", func() {
+		for _, channel := range []string{"stable", "pre-release", "0.17.x", "1.x", "0.17.3"} {
+			Expect(ValidateReleaseChannel(channel)).To(Succeed(), channel)
+		}
+		for _, channel := range []string{"", "nightly", "0.17", "0.x.1", "latest"} {
+			Expect(ValidateReleaseChannel(channel)).ToNot(Succeed(), channel)
+		}
+	})
+
+	It("filters tags only for version ranges", func() {
+		Expect(ReleaseTagFilter("stable")).To(BeEmpty())
+		Expect(ReleaseTagFilter("pre-release")).To(BeEmpty())
+		Expect(ReleaseTagFilter("0.17.x")).To(Equal(`^v?0\.17\.\d+$`))
+		Expect(ReleaseTagFilter("1.x")).To(Equal(`^v?1\.\d+\.\d+$`))
+		Expect(ReleaseTagFilter("0.17.3")).To(Equal(`^v?0\.17\.3$`))
+	})
+
+	It("includes pre-releases only on the pre-release channel", func() {
+		Expect(ReleaseChannelIncludes("stable", "0.18.0", false)).To(BeTrue())
+		Expect(ReleaseChannelIncludes("stable", "0.18.0", true)).To(BeFalse())
+		Expect(ReleaseChannelIncludes("pre-release", "0.18.0", true)).To(BeTrue())
+		Expect(ReleaseChannelIncludes("0.17.x", "0.17.4", false)).To(BeTrue())
+		Expect(ReleaseChannelIncludes("0.17.x", "0.18.0", false)).To(BeFalse())
+		Expect(ReleaseChannelIncludes("0.17.x", "0.17.5", true)).To(BeFalse())
+	})
+})
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const defaultS3Region = "us-east-1"
+
+// S3 is a Backend for any S3-compatible object store, such as MinIO
+type S3 struct {
+	client *s3.S3
+}
+
+// NewS3 returns a Backend that talks to the S3-compatible API at endpoint.
+// Credentials are taken from the environment when accessKeyID is empty.
+func NewS3(endpoint, region, accessKeyID, secretAccessKey string) (*S3, error) {
+	if region == "" {
+		region = defaultS3Region
+	}
+
+	awsConfig := aws.NewConfig().
+		WithEndpoint(endpoint).
+		WithRegion(region).
+		WithS3ForcePathStyle(true)
+	if accessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(accessKeyID, secretAccessKey, ""))
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating session for state backend [%v]: [%v]", endpoint, err)
+	}
+
+	return &S3{client: s3.New(sess)}, nil
+}
+
+// BucketExists checks if the named bucket exists
+func (b *S3) BucketExists(name string) (bool, error) {
+	_, err := b.client.HeadBucket(&s3.HeadBucketInput{Bucket: &name})
+	if err == nil {
+		return true, nil
+	}
+	if isS3NotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// CreateBucket creates the named bucket with versioning enabled
+func (b *S3) CreateBucket(name string) error {
+	if _, err := b.client.CreateBucket(&s3.CreateBucketInput{Bucket: &name}); err != nil {
+		return fmt.Errorf("error creating bucket [%v]: [%v]", name, err)
+	}
+
+	_, err := b.client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket: &name,
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(s3.BucketVersioningStatusEnabled),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error enabling versioning on bucket [%v]: [%v]", name, err)
+	}
+
+	return nil
+}
+
+// DeleteVersionedBucket deletes every version and delete marker of every object and then the bucket
+// itself, which S3 refuses to delete while either remain
+func (b *S3) DeleteVersionedBucket(name string) error {
+	var objects []*s3.ObjectIdentifier
+	err := b.client.ListObjectVersionsPages(&s3.ListObjectVersionsInput{Bucket: &name},
+		func(output *s3.ListObjectVersionsOutput, _ bool) bool {
+			for _, version := range output.Versions {
+				objects = append(objects, &s3.ObjectIdentifier{Key: version.Key, VersionId: version.VersionId})
+			}
+			for _, marker := range output.DeleteMarkers {
+				objects = append(objects, &s3.ObjectIdentifier{Key: marker.Key, VersionId: marker.VersionId})
+			}
+			return true
+		})
+	if err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		_, err = b.client.DeleteObject(&s3.DeleteObjectInput{
+			Bucket:    &name,
+			Key:       object.Key,
+			VersionId: object.VersionId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = b.client.DeleteBucket(&s3.DeleteBucketInput{Bucket: &name})
+	return err
+}
+
+// HasFile returns true if the specified object exists
+func (b *S3) HasFile(bucket, path string) (bool, error) {
+	_, err := b.client.HeadObject(&s3.HeadObjectInput{Bucket: &bucket, Key: &path})
+	if err == nil {
+		return true, nil
+	}
+	if isS3NotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// LoadFile loads the specified object
+func (b *S3) LoadFile(bucket, path string) ([]byte, error) {
+	output, err := b.client.GetObject(&s3.GetObjectInput{Bucket: &bucket, Key: &path})
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	return ioutil.ReadAll(output.Body)
+}
+
+// WriteFile writes the specified object
+func (b *S3) WriteFile(bucket, path string, contents []byte) error {
+	_, err := b.client.PutObject(&s3.PutObjectInput{
+		Bucket: &bucket,
+		Key:    &path,
+		Body:   bytes.NewReader(contents),
+	})
+	return err
+}
+
+func isS3NotFound(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+	switch awsErr.Code() {
+	case "NotFound", s3.ErrCodeNoSuchBucket, s3.ErrCodeNoSuchKey:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package config_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/EngineerBetter/control-tower/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("S3", func() {
+	var server *httptest.Server
+	var mutex sync.Mutex
+	var deleted []string
+
+	BeforeEach(func() {
+		deleted = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case r.Method == http.MethodGet && r.URL.Query()["versions"] != nil:
+				w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<ListVersionsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>bucket</Name>
+  <IsTruncated>false</IsTruncated>
+  <Version><Key>config.json</Key><VersionId>v1</VersionId></Version>
+  <DeleteMarker><Key>lock.json</Key><VersionId>v2</VersionId></DeleteMarker>
+</ListVersionsResult>`))
+			case r.Method == http.MethodDelete:
+				deleted = append(deleted, r.URL.Path+"?"+r.URL.Query().Get("versionId"))
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				w.WriteHeader(http.StatusNotImplemented)
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("deletes every version and delete marker before the bucket", func() {
+		backend, err := NewS3(server.URL, "", "access-key", "secret-key")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(backend.DeleteVersionedBucket("bucket")).To(Succeed())
+		Expect(deleted).To(Equal([]string{"/bucket/config.json?v1", "/bucket/lock.json?v2", "/bucket?"}))
+	})
+})
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Weekdays are the days a Schedule has working hours on unless others are given
+var Weekdays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
+
+var daysOfTheWeek = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+const scheduleTimeFormat = "15:04"
+
+// Schedule scales the default Concourse workers down outside working hours, and back up when they
+// start. It is enforced by jobs in the self-update pipeline.
+type Schedule struct {
+	// Start and Stop are the times of day working hours start and stop, as HH:MM
+	Start string `json:"start"`
+	Stop  string `json:"stop"`
+	// Days are the days of the week working hours start on
+	Days []string `json:"days"`
+	// Location is the IANA time zone Start and Stop are in
+	Location string `json:"location"`
+	// IdleWorkerCount is the number of default workers kept outside working hours
+	IdleWorkerCount int `json:"idle_worker_count"`
+}
+
+func (s Schedule) String() string {
+	return fmt.Sprintf("%s-%s %s on %s, %d workers outside these hours", s.Start, s.Stop, s.Location, strings.Join(s.Days, ","), s.IdleWorkerCount)
+}
+
+// FormatSchedule describes a schedule on one line, or returns nothing if there is none
+func FormatSchedule(s *Schedule) string {
+	if s == nil {
+		return ""
+	}
+	return s.String()
+}
+
+// ParseScheduleHours parses working hours given as HH:MM-HH:MM
+func ParseScheduleHours(hours string) (start, stop string, err error) {
+	parts := strings.Split(hours, "-")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("schedule `%s` is not in the format `HH:MM-HH:MM`", hours)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
+
+// ValidateSchedule checks that a schedule has valid times, days and time zone, and keeps a worker
+// running to enforce it
+func ValidateSchedule(s Schedule) error {
+	if err := validateHours("schedule", s.Start, s.Stop, s.Days, s.Location); err != nil {
+		return err
+	}
+
+	// The scheduled jobs run on a worker, so one has to be kept for the job that scales back up
+	if s.IdleWorkerCount < 1 {
+		return fmt.Errorf("schedule must keep at least 1 worker outside working hours to run the job that scales the workers back up")
+	}
+	return nil
+}
+
+// validateHours checks the times, days and time zone of hours that start on days, such as those of
+// a schedule. what names the hours in errors.
+func validateHours(what, startTime, stopTime string, days []string, location string) error {
+	start, err := time.Parse(scheduleTimeFormat, startTime)
+	if err != nil {
+		return fmt.Errorf("%s start `%s` is not a time in the format HH:MM", what, startTime)
+	}
+	stop, err := time.Parse(scheduleTimeFormat, stopTime)
+	if err != nil {
+		return fmt.Errorf("%s stop `%s` is not a time in the format HH:MM", what, stopTime)
+	}
+	if start.Equal(stop) {
+		return fmt.Errorf("%s start and stop cannot both be %s", what, startTime)
+	}
+
+	if len(days) == 0 {
+		return fmt.Errorf("%s must have at least one day", what)
+	}
+	for i, day := range days {
+		if !contains(daysOfTheWeek, day) {
+			return fmt.Errorf("%s day `%s` is invalid. Valid days are: %v", what, day, daysOfTheWeek)
+		}
+		if contains(days[:i], day) {
+			return fmt.Errorf("%s day `%s` is given more than once", what, day)
+		}
+	}
+
+	if _, err := time.LoadLocation(location); err != nil {
+		return fmt.Errorf("%s location `%s` is not a known time zone: [%v]", what, location, err)
+	}
+	return nil
+}
+
+// Pause records how a Concourse has been scaled down by control-tower pause. Deploys keep it
+// scaled down until control-tower resume clears it.
+type Pause struct {
+	// WorkerCount is the number of default workers kept running. Worker pools are always stopped
+	WorkerCount int `json:"worker_count"`
+	// Hibernate stops the web VM as well as every worker, keeping only the director and database
+	Hibernate bool `json:"hibernate,omitempty"`
+}
+
+func (p Pause) String() string {
+	if p.Hibernate {
+		return "hibernating, web and worker VMs stopped"
+	}
+	return fmt.Sprintf("scaled down to %d workers, worker pools stopped", p.WorkerCount)
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// EveryDay are the days a MaintenanceWindow opens on unless others are given
+var EveryDay = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// MaintenanceWindow is when the self-update pipeline may upgrade the deployment. A new release that
+// appears outside it is deployed when it next opens.
+type MaintenanceWindow struct {
+	// Start and Stop are the times of day the window opens and closes, as HH:MM
+	Start string `json:"start"`
+	Stop  string `json:"stop"`
+	// Days are the days of the week the window opens on
+	Days []string `json:"days"`
+	// Location is the IANA time zone Start and Stop are in
+	Location string `json:"location"`
+}
+
+func (w MaintenanceWindow) String() string {
+	return fmt.Sprintf("%s-%s %s on %s", w.Start, w.Stop, w.Location, strings.Join(w.Days, ","))
+}
+
+// FormatMaintenanceWindow describes a maintenance window on one line, or returns nothing if there is none
+func FormatMaintenanceWindow(w *MaintenanceWindow) string {
+	if w == nil {
+		return ""
+	}
+	return w.String()
+}
+
+// ValidateMaintenanceWindow checks that a maintenance window has valid times, days and time zone
+func ValidateMaintenanceWindow(w MaintenanceWindow) error {
+	return validateHours("maintenance window", w.Start, w.Stop, w.Days, w.Location)
+}
+
+// Notifications are where the self-update pipeline reports that an upgrade has started, succeeded
+// or failed. The webhook URLs and SMTP password are secrets, so the pipeline reads them from CredHub.
+type Notifications struct {
+	// SlackWebhook is the URL of a Slack incoming webhook
+	SlackWebhook string `json:"slack_webhook,omitempty"`
+	// Webhook is a URL that is sent a JSON object describing each event
+	Webhook string `json:"webhook,omitempty"`
+	// EmailTo are the addresses emailed from EmailFrom, through the SMTP server at SMTPURL
+	EmailTo      []string `json:"email_to,omitempty"`
+	EmailFrom    string   `json:"email_from,omitempty"`
+	SMTPURL      string   `json:"smtp_url,omitempty"`
+	SMTPUsername string   `json:"smtp_username,omitempty"`
+	SMTPPassword string   `json:"smtp_password,omitempty"`
+}
+
+// IsEmpty reports whether no notifications are sent
+func (n Notifications) IsEmpty() bool {
+	return n.SlackWebhook == "" && n.Webhook == "" && len(n.EmailTo) == 0
+}
+
+func (n Notifications) String() string {
+	var targets []string
+	if n.SlackWebhook != "" {
+		targets = append(targets, "Slack")
+	}
+	if n.Webhook != "" {
+		targets = append(targets, "webhook "+redactURL(n.Webhook))
+	}
+	if len(n.EmailTo) > 0 {
+		targets = append(targets, "email to "+strings.Join(n.EmailTo, ","))
+	}
+	return strings.Join(targets, ", ")
+}
+
+// FormatNotifications describes where notifications are sent without revealing any secrets, or
+// returns nothing if there are none
+func FormatNotifications(n *Notifications) string {
+	if n == nil {
+		return ""
+	}
+	return n.String()
+}
+
+// ValidateNotifications checks that the webhooks are URLs, and that email notifications have
+// addresses to send from and to and an SMTP server to send through
+func ValidateNotifications(n Notifications) error {
+	for _, webhook := range []struct{ name, value string }{{"Slack webhook", n.SlackWebhook}, {"webhook", n.Webhook}} {
+		if webhook.value == "" {
+			continue
+		}
+		u, err := url.Parse(webhook.value)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("notification %s must be an http or https URL", webhook.name)
+		}
+	}
+
+	email := n.EmailFrom != "" || n.SMTPURL != "" || n.SMTPUsername != "" || n.SMTPPassword != ""
+	if len(n.EmailTo) == 0 {
+		if email {
+			return fmt.Errorf("email notification settings require addresses to send them to")
+		}
+		return nil
+	}
+	for _, address := range append([]string{n.EmailFrom}, n.EmailTo...) {
+		if parsed, err := mail.ParseAddress(address); err != nil || parsed.Address != address {
+			return fmt.Errorf("email notification address `%s` is not a valid address such as ci@example.com", address)
+		}
+	}
+	u, err := url.Parse(n.SMTPURL)
+	if err != nil || (u.Scheme != "smtp" && u.Scheme != "smtps") || u.Host == "" {
+		return fmt.Errorf("email notifications require an SMTP server URL such as smtp://smtp.example.com:587 or smtps://smtp.example.com:465")
+	}
+	if n.SMTPPassword != "" && n.SMTPUsername == "" {
+		return fmt.Errorf("an SMTP password requires an SMTP username")
+	}
+	return nil
+}
+
+// redactURL returns the scheme and host of a URL, which may carry a token in its path or query
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,72 @@
+package config_test
+
+import (
+	. "github.com/EngineerBetter/control-tower/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Self-update", func() {
+	Describe("MaintenanceWindow", func() {
+		It("accepts windows that cross midnight", func() {
+			Expect(ValidateMaintenanceWindow(MaintenanceWindow{Start: "22:00", Stop: "02:00", Days: []string{"Saturday"}, Location: "Europe/London"})).To(Succeed())
+		})
+
+		It("rejects invalid times, days and time zones", func() {
+			Expect(ValidateMaintenanceWindow(MaintenanceWindow{Start: "25:00", Stop: "02:00", Days: EveryDay, Location: "UTC"})).ToNot(Succeed())
+			Expect(ValidateMaintenanceWindow(MaintenanceWindow{Start: "22:00", Stop: "02:00", Days: []string{"Caturday"}, Location: "UTC"})).ToNot(Succeed())
+			Expect(ValidateMaintenanceWindow(MaintenanceWindow{Start: "22:00", Stop: "02:00", Days: EveryDay, Location: "Mars/Olympus"})).ToNot(Succeed())
+		})
+
+		It("is described on one line", func() {
+			Expect(FormatMaintenanceWindow(nil)).To(BeEmpty())
+			Expect(FormatMaintenanceWindow(&MaintenanceWindow{Start: "22:00", Stop: "02:00", Days: []string{"Saturday", "Sunday"}, Location: "UTC"})).To(Equal("22:00-02:00 UTC on Saturday,Sunday"))
+		})
+	})
+
+	Describe("Notifications", func() {
+		var email Notifications
+
+		BeforeEach(func() {
+			email = Notifications{
+				EmailTo:      []string{"ops@example.com"},
+				EmailFrom:    "control-tower@example.com",
+				SMTPURL:      "smtp://smtp.example.com:587",
+				SMTPUsername: "control-tower",
+				SMTPPassword: "password",
+			}
+		})
+
+		It("accepts webhooks and email", func() {
+			Expect(ValidateNotifications(Notifications{SlackWebhook: "https://hooks.slack.com/services/T0/B0/secret", Webhook: "http://example.com/hook"})).To(Succeed())
+			Expect(ValidateNotifications(email)).To(Succeed())
+		})
+
+		It("rejects webhooks that are not http or https URLs", func() {
+			Expect(ValidateNotifications(Notifications{SlackWebhook: "hooks.slack.com/services"})).To(MatchError("notification Slack webhook must be an http or https URL"))
+			Expect(ValidateNotifications(Notifications{Webhook: "ftp://example.com"})).To(MatchError("notification webhook must be an http or https URL"))
+		})
+
+		It("rejects incomplete email settings", func() {
+			Expect(ValidateNotifications(Notifications{SMTPURL: "smtp://smtp.example.com"})).To(MatchError("email notification settings require addresses to send them to"))
+
+			email.EmailFrom = "Control Tower <control-tower@example.com>"
+			Expect(ValidateNotifications(email)).To(MatchError(ContainSubstring("is not a valid address")))
+
+			email.EmailFrom = "control-tower@example.com"
+			email.SMTPURL = "https://smtp.example.com"
+			Expect(ValidateNotifications(email)).To(MatchError(ContainSubstring("require an SMTP server URL")))
+
+			email.SMTPURL = "smtps://smtp.example.com:465"
+			email.SMTPUsername = ""
+			Expect(ValidateNotifications(email)).To(MatchError("an SMTP password requires an SMTP username"))
+		})
+
+		It("is described without its secrets", func() {
+			email.SlackWebhook = "https://hooks.slack.com/services/T0/B0/secret"
+			email.Webhook = "https://example.com/hook?token=secret"
+			Expect(FormatNotifications(&email)).To(Equal("Slack, webhook https://example.com, email to ops@example.com"))
+			Expect(FormatNotifications(nil)).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const streamFormat = "control-tower-stream-v1"
+
+// streamFrameSize is the most plaintext sealed into a single frame of a stream
+const streamFrameSize = 64 * 1024
+
+// finalFrame marks the length of the last frame of a stream, so truncated streams can be told apart
+const finalFrame = uint32(1) << 31
+
+// streamPrefix is how every sealed stream starts, as Format is the first field of its header
+var streamPrefix = []byte(`{"format":"` + streamFormat + `"`)
+
+// streamHeader starts a sealed stream, on a line of its own ahead of the frames. Each frame is a
+// big-endian length followed by that much ciphertext, sealed with the data key and a nonce derived
+// from Nonce and the frame's position.
+type streamHeader struct {
+	Format     string `json:"format"`
+	KeyWrapper string `json:"key_wrapper"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Nonce      []byte `json:"nonce"`
+}
+
+// sealWriter encrypts what is written to it frame by frame, so that large artifacts never have to be held in memory
+type sealWriter struct {
+	w       io.Writer
+	gcm     cipher.AEAD
+	name    string
+	nonce   []byte
+	counter uint64
+	buf     []byte
+}
+
+// newSealWriter writes the header of a stream sealed with a new data key to w
+func newSealWriter(wrapper KeyWrapper, name string, w io.Writer) (*sealWriter, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := wrapper.WrapKey(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("error wrapping data key with %s [%v]", wrapper.Name(), err)
+	}
+
+	header, err := json.Marshal(streamHeader{
+		Format:     streamFormat,
+		KeyWrapper: wrapper.Name(),
+		WrappedKey: wrappedKey,
+		Nonce:      nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(append(header, '\n')); err != nil {
+		return nil, err
+	}
+
+	return &sealWriter{w: w, gcm: gcm, name: name, nonce: nonce}, nil
+}
+
+func (s *sealWriter) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	// Keep the remainder back so that Close always has a final frame to write
+	written := 0
+	for len(s.buf)-written > streamFrameSize {
+		if err := s.writeFrame(s.buf[written:written+streamFrameSize], false); err != nil {
+			return 0, err
+		}
+		written += streamFrameSize
+	}
+	if written > 0 {
+		s.buf = append(s.buf[:0], s.buf[written:]...)
+	}
+	return len(p), nil
+}
+
+// Close writes the final frame, without closing the underlying writer
+func (s *sealWriter) Close() error {
+	err := s.writeFrame(s.buf, true)
+	s.buf = nil
+	return err
+}
+
+func (s *sealWriter) writeFrame(plaintext []byte, final bool) error {
+	ciphertext := s.gcm.Seal(nil, frameNonce(s.nonce, s.counter), plaintext, frameData(s.name, final))
+	s.counter++
+
+	length := uint32(len(ciphertext))
+	if final {
+		length |= finalFrame
+	}
+	if err := binary.Write(s.w, binary.BigEndian, length); err != nil {
+		return err
+	}
+	_, err := s.w.Write(ciphertext)
+	return err
+}
+
+// openReader decrypts a stream written by sealWriter
+type openReader struct {
+	r       io.Reader
+	gcm     cipher.AEAD
+	name    string
+	nonce   []byte
+	counter uint64
+	buf     []byte
+	done    bool
+}
+
+// newOpenReader returns a reader for the plaintext of the sealed stream in r, or r itself if it was never sealed
+func newOpenReader(wrapper KeyWrapper, name string, r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	prefix, _ := br.Peek(len(streamPrefix))
+	if !bytes.Equal(prefix, streamPrefix) {
+		return br, nil
+	}
+	if wrapper == nil {
+		return nil, fmt.Errorf("[%s] is encrypted but no --encryption was given", name)
+	}
+
+	line, err := br.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("error reading encrypted [%s] [%v]", name, err)
+	}
+	var header streamHeader
+	if err = json.Unmarshal(line, &header); err != nil {
+		return nil, fmt.Errorf("error parsing encrypted [%s] [%v]", name, err)
+	}
+	if header.KeyWrapper != wrapper.Name() {
+		return nil, fmt.Errorf("[%s] was encrypted with %s but --encryption is %s", name, header.KeyWrapper, wrapper.Name())
+	}
+
+	dataKey, err := wrapper.UnwrapKey(header.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping data key for [%s] with %s [%v]", name, wrapper.Name(), err)
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(header.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("error decrypting [%s] [invalid nonce]", name)
+	}
+
+	return &openReader{r: br, gcm: gcm, name: name, nonce: header.Nonce}, nil
+}
+
+func (o *openReader) Read(p []byte) (int, error) {
+	for len(o.buf) == 0 {
+		if o.done {
+			return 0, io.EOF
+		}
+		if err := o.readFrame(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, o.buf)
+	o.buf = o.buf[n:]
+	return n, nil
+}
+
+func (o *openReader) readFrame() error {
+	var length uint32
+	if err := binary.Read(o.r, binary.BigEndian, &length); err != nil {
+		return o.truncated(err)
+	}
+	final := length&finalFrame != 0
+	length &^= finalFrame
+	if length > streamFrameSize+uint32(o.gcm.Overhead()) {
+		return fmt.Errorf("error decrypting [%s] [frame of %d bytes is too large]", o.name, length)
+	}
+
+	ciphertext := make([]byte, length)
+	if _, err := io.ReadFull(o.r, ciphertext); err != nil {
+		return o.truncated(err)
+	}
+
+	plaintext, err := o.gcm.Open(nil, frameNonce(o.nonce, o.counter), ciphertext, frameData(o.name, final))
+	if err != nil {
+		return fmt.Errorf("error decrypting [%s] [%v]", o.name, err)
+	}
+	o.counter++
+	o.buf = plaintext
+	o.done = final
+	return nil
+}
+
+func (o *openReader) truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("error decrypting [%s] [stream is truncated]", o.name)
+	}
+	return err
+}
+
+// frameNonce makes each frame's nonce unique by mixing its position into the last bytes of the stream's nonce
+func frameNonce(nonce []byte, counter uint64) []byte {
+	n := append([]byte(nil), nonce...)
+	var position [8]byte
+	binary.BigEndian.PutUint64(position[:], counter)
+	for i := range position {
+		n[len(n)-len(position)+i] ^= position[i]
+	}
+	return n
+}
+
+// frameData binds a frame to the stream's name and to whether it is the last one
+func frameData(name string, final bool) []byte {
+	if final {
+		return append([]byte(name), 1)
+	}
+	return append([]byte(name), 0)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// MainTeam is the Concourse team control-tower logs in to. It is not managed by teams files, so
+// that the admin user cannot be locked out.
+const MainTeam = "main"
+
+// TeamRoles are the roles a Concourse team can grant, from most to least privileged
+var TeamRoles = []string{"owner", "member", "pipeline-operator", "viewer"}
+
+var teamNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Team is a Concourse team and the users and groups granted each of its roles
+type Team struct {
+	Name  string     `json:"name"`
+	Roles []TeamRole `json:"roles"`
+}
+
+// TeamRole grants a role of a team to users and groups of each auth provider, in the format of a
+// `fly set-team` config file
+type TeamRole struct {
+	Name   string           `json:"name"`
+	Local  *TeamLocalUsers  `json:"local,omitempty"`
+	GitHub *TeamGitHubUsers `json:"github,omitempty"`
+	OIDC   *TeamGroupUsers  `json:"oidc,omitempty"`
+	LDAP   *TeamGroupUsers  `json:"ldap,omitempty"`
+	GitLab *TeamGroupUsers  `json:"gitlab,omitempty"`
+}
+
+// TeamLocalUsers are local Concourse users
+type TeamLocalUsers struct {
+	Users []string `json:"users,omitempty"`
+}
+
+// TeamGitHubUsers are GitHub users, organisations and teams. Teams are given as org:team
+type TeamGitHubUsers struct {
+	Users []string `json:"users,omitempty"`
+	Orgs  []string `json:"orgs,omitempty"`
+	Teams []string `json:"teams,omitempty"`
+}
+
+// TeamGroupUsers are the users and groups of a provider that has groups, such as OIDC or LDAP
+type TeamGroupUsers struct {
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// RoleAuth is who a role is granted to, as reported by Concourse. Each user and group is prefixed
+// with the name of its auth provider, e.g. github:org:team
+type RoleAuth struct {
+	Users  []string `json:"users"`
+	Groups []string `json:"groups"`
+}
+
+// TeamAuth is the RoleAuth of each role of a team
+type TeamAuth map[string]RoleAuth
+
+// Auth returns who each role of the team is granted to in the form Concourse reports it in, so
+// that it can be compared with the team in Concourse
+func (t Team) Auth() TeamAuth {
+	auth := TeamAuth{}
+	for _, role := range t.Roles {
+		var users, groups []string
+		if role.Local != nil {
+			users = append(users, prefixed("local", role.Local.Users)...)
+		}
+		if role.GitHub != nil {
+			users = append(users, prefixed("github", role.GitHub.Users)...)
+			groups = append(groups, prefixed("github", role.GitHub.Orgs)...)
+			groups = append(groups, prefixed("github", role.GitHub.Teams)...)
+		}
+		for connector, members := range map[string]*TeamGroupUsers{"oidc": role.OIDC, "ldap": role.LDAP, "gitlab": role.GitLab} {
+			if members != nil {
+				users = append(users, prefixed(connector, members.Users)...)
+				groups = append(groups, prefixed(connector, members.Groups)...)
+			}
+		}
+		auth[role.Name] = RoleAuth{Users: users, Groups: groups}.normalised()
+	}
+	return auth
+}
+
+// Connectors returns the names of the auth providers the team's roles are granted through
+func (t Team) Connectors() []string {
+	used := map[string]bool{}
+	for _, role := range t.Roles {
+		used["local"] = used["local"] || role.Local != nil
+		used["github"] = used["github"] || role.GitHub != nil
+		used["oidc"] = used["oidc"] || role.OIDC != nil
+		used["ldap"] = used["ldap"] || role.LDAP != nil
+		used["gitlab"] = used["gitlab"] || role.GitLab != nil
+	}
+	var connectors []string
+	for connector, isUsed := range used {
+		if isUsed {
+			connectors = append(connectors, connector)
+		}
+	}
+	sort.Strings(connectors)
+	return connectors
+}
+
+func prefixed(connector string, names []string) []string {
+	var p []string
+	for _, name := range names {
+		p = append(p, connector+":"+name)
+	}
+	return p
+}
+
+// normalised lower cases and sorts users and groups, as Concourse compares them case-insensitively
+func (r RoleAuth) normalised() RoleAuth {
+	normalise := func(names []string) []string {
+		n := []string{}
+		for _, name := range names {
+			n = append(n, strings.ToLower(name))
+		}
+		sort.Strings(n)
+		return n
+	}
+	return RoleAuth{Users: normalise(r.Users), Groups: normalise(r.Groups)}
+}
+
+// DiffTeamAuth compares the roles of a team in Concourse with those wanted, returning one line per
+// role that differs
+func DiffTeamAuth(team string, live, want TeamAuth) []string {
+	var diffs []string
+	for _, role := range TeamRoles {
+		l := live[role].normalised()
+		w := want[role].normalised()
+		missing := append(difference(w.Users, l.Users), difference(w.Groups, l.Groups)...)
+		unexpected := append(difference(l.Users, w.Users), difference(l.Groups, w.Groups)...)
+		if len(missing) == 0 && len(unexpected) == 0 {
+			continue
+		}
+		diff := fmt.Sprintf("~ %s/%s:", team, role)
+		if len(missing) > 0 {
+			diff += fmt.Sprintf(" missing %s", strings.Join(missing, ", "))
+		}
+		if len(unexpected) > 0 {
+			if len(missing) > 0 {
+				diff += ";"
+			}
+			diff += fmt.Sprintf(" unexpected %s", strings.Join(unexpected, ", "))
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+func difference(a, b []string) []string {
+	var d []string
+	for _, s := range a {
+		if !contains(b, s) {
+			d = append(d, s)
+		}
+	}
+	return d
+}
+
+// ParseTeams parses a YAML or JSON list of teams, rejecting keys it does not know about
+func ParseTeams(contents []byte) ([]Team, error) {
+	var teams []Team
+	j, err := yaml.YAMLToJSON(contents)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(j))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&teams); err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// ValidateTeams checks that teams have unique valid names, and grant known roles to at least one
+// user or group. The main team cannot be managed.
+func ValidateTeams(teams []Team) error {
+	names := map[string]bool{}
+	for _, team := range teams {
+		if !teamNameRegexp.MatchString(team.Name) {
+			return fmt.Errorf("team name `%s` is invalid: must contain only lower case letters, digits, hyphens and underscores", team.Name)
+		}
+		if team.Name == MainTeam {
+			return fmt.Errorf("the %s team is managed by control-tower and cannot be set in a teams file", MainTeam)
+		}
+		if names[team.Name] {
+			return fmt.Errorf("team `%s` is defined more than once", team.Name)
+		}
+		names[team.Name] = true
+
+		if len(team.Roles) == 0 {
+			return fmt.Errorf("team `%s` must grant at least one role", team.Name)
+		}
+		roles := map[string]bool{}
+		for _, role := range team.Roles {
+			if !contains(TeamRoles, role.Name) {
+				return fmt.Errorf("team `%s` has unknown role `%s`. Valid roles are: %v", team.Name, role.Name, TeamRoles)
+			}
+			if roles[role.Name] {
+				return fmt.Errorf("team `%s` grants role `%s` more than once", team.Name, role.Name)
+			}
+			roles[role.Name] = true
+
+			if role.GitHub != nil {
+				for _, githubTeam := range role.GitHub.Teams {
+					if parts := strings.Split(githubTeam, ":"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+						return fmt.Errorf("team `%s` has GitHub team `%s` that is not in the format `org:team`", team.Name, githubTeam)
+					}
+				}
+			}
+			if auth := team.Auth()[role.Name]; len(auth.Users) == 0 && len(auth.Groups) == 0 {
+				return fmt.Errorf("team `%s` grants role `%s` to nobody", team.Name, role.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// FormatTeams describes teams on one line
+func FormatTeams(teams []Team) string {
+	var names []string
+	for _, team := range teams {
+		names = append(names, team.Name)
+	}
+	return strings.Join(names, ", ")
+}
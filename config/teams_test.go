@@ -0,0 +1,82 @@
+package config_test
+
+import (
+	. "github.com/EngineerBetter/control-tower/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Teams", func() {
+	const teamsFile = `
+- name: platform
+  roles:
+  - name: owner
+    github:
+      teams: [EngineerBetter:platform]
+  - name: viewer
+    oidc:
+      groups: [everyone]
+    local:
+      users: [admin]
+`
+
+	It("parses a teams file", func() {
+		teams, err := ParseTeams([]byte(teamsFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(teams).To(HaveLen(1))
+		Expect(teams[0].Name).To(Equal("platform"))
+		Expect(teams[0].Roles[0].GitHub.Teams).To(Equal([]string{"EngineerBetter:platform"}))
+		Expect(ValidateTeams(teams)).To(Succeed())
+		Expect(teams[0].Connectors()).To(Equal([]string{"github", "local", "oidc"}))
+	})
+
+	It("rejects keys it does not know about", func() {
+		_, err := ParseTeams([]byte("- name: platform\n  roles:\n  - name: owner\n    github:\n      organisations: [engineerbetter]\n"))
+		Expect(err).To(MatchError(ContainSubstring(`unknown field "organisations"`)))
+	})
+
+	It("describes roles as Concourse reports them", func() {
+		teams, err := ParseTeams([]byte(teamsFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(teams[0].Auth()).To(Equal(TeamAuth{
+			"owner":  {Users: []string{}, Groups: []string{"github:engineerbetter:platform"}},
+			"viewer": {Users: []string{"local:admin"}, Groups: []string{"oidc:everyone"}},
+		}))
+	})
+
+	It("rejects invalid teams", func() {
+		local := &TeamLocalUsers{Users: []string{"admin"}}
+		cases := []struct {
+			teams   []Team
+			message string
+		}{
+			{[]Team{{Name: "Platform"}}, "team name `Platform` is invalid"},
+			{[]Team{{Name: "main"}}, "the main team is managed by control-tower"},
+			{[]Team{{Name: "a", Roles: []TeamRole{{Name: "owner", Local: local}}}, {Name: "a"}}, "team `a` is defined more than once"},
+			{[]Team{{Name: "a"}}, "team `a` must grant at least one role"},
+			{[]Team{{Name: "a", Roles: []TeamRole{{Name: "admin"}}}}, "team `a` has unknown role `admin`"},
+			{[]Team{{Name: "a", Roles: []TeamRole{{Name: "owner", Local: local}, {Name: "owner", Local: local}}}}, "team `a` grants role `owner` more than once"},
+			{[]Team{{Name: "a", Roles: []TeamRole{{Name: "owner", GitHub: &TeamGitHubUsers{Teams: []string{"platform"}}}}}}, "not in the format `org:team`"},
+			{[]Team{{Name: "a", Roles: []TeamRole{{Name: "owner", OIDC: &TeamGroupUsers{}}}}}, "team `a` grants role `owner` to nobody"},
+		}
+		for _, c := range cases {
+			Expect(ValidateTeams(c.teams)).To(MatchError(ContainSubstring(c.message)))
+		}
+	})
+
+	It("reports roles that have drifted", func() {
+		want := TeamAuth{
+			"owner":  {Groups: []string{"github:engineerbetter:platform"}},
+			"viewer": {Groups: []string{"oidc:everyone"}},
+		}
+		live := TeamAuth{
+			"owner":  {Users: []string{"github:someone"}, Groups: []string{"github:EngineerBetter:platform"}},
+			"member": {Users: []string{}, Groups: []string{}},
+		}
+		Expect(DiffTeamAuth("platform", live, want)).To(Equal([]string{
+			"~ platform/owner: unexpected github:someone",
+			"~ platform/viewer: missing oidc:everyone",
+		}))
+		Expect(DiffTeamAuth("platform", want, want)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+var workerPoolNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// WorkerPool is a named group of Concourse workers that share a size, provisioning type and set
+// of Concourse worker tags. Each pool is deployed as its own instance group.
+type WorkerPool struct {
+	Name               string   `json:"name"`
+	Count              int      `json:"count"`
+	Size               string   `json:"size"`
+	VMProvisioningType string   `json:"vm_provisioning_type,omitempty"`
+	Tags               []string `json:"tags,omitempty"`
+}
+
+// InstanceGroup returns the name of the pool's instance group in the Concourse manifest
+func (p WorkerPool) InstanceGroup() string {
+	return "worker-" + p.Name
+}
+
+// VMType returns the name of the cloud config VM type the pool's workers use
+func (p WorkerPool) VMType() string {
+	return fmt.Sprintf("concourse-%s-%s", p.Size, p.VMProvisioningType)
+}
+
+// IsSpot returns true if the pool's workers are spot or preemptible instances
+func (p WorkerPool) IsSpot() bool {
+	return p.VMProvisioningType == SPOT
+}
+
+func (p WorkerPool) String() string {
+	s := fmt.Sprintf("%s: %d x %s %s", p.Name, p.Count, p.Size, p.VMProvisioningType)
+	if len(p.Tags) > 0 {
+		s += fmt.Sprintf(" tagged %s", strings.Join(p.Tags, ","))
+	}
+	return s
+}
+
+// FormatWorkerPools describes pools on one line
+func FormatWorkerPools(pools []WorkerPool) string {
+	var descriptions []string
+	for _, pool := range pools {
+		descriptions = append(descriptions, pool.String())
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// ParseWorkerPool parses a pool given as NAME:COUNT:SIZE[:PROVISIONING-TYPE[:TAG,TAG...]]
+func ParseWorkerPool(spec string) (WorkerPool, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 3 || len(parts) > 5 {
+		return WorkerPool{}, fmt.Errorf("worker pool `%s` is not in the format `name:count:size[:provisioning-type[:tag,tag...]]`", spec)
+	}
+
+	count, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return WorkerPool{}, fmt.Errorf("worker pool `%s` has an invalid count `%s`", spec, parts[1])
+	}
+
+	pool := WorkerPool{
+		Name:               parts[0],
+		Count:              count,
+		Size:               parts[2],
+		VMProvisioningType: ON_DEMAND,
+	}
+	if len(parts) > 3 && parts[3] != "" {
+		pool.VMProvisioningType = parts[3]
+	}
+	if len(parts) > 4 && parts[4] != "" {
+		pool.Tags = strings.Split(parts[4], ",")
+	}
+	return pool, nil
+}
+
+// ParseWorkerPools parses a YAML or JSON list of worker pools
+func ParseWorkerPools(contents []byte) ([]WorkerPool, error) {
+	var pools []WorkerPool
+	if err := yaml.Unmarshal(contents, &pools); err != nil {
+		return nil, err
+	}
+	DefaultWorkerPools(pools)
+	return pools, nil
+}
+
+// DefaultWorkerPools makes pools that do not give a provisioning type on-demand
+func DefaultWorkerPools(pools []WorkerPool) {
+	for i := range pools {
+		if pools[i].VMProvisioningType == "" {
+			pools[i].VMProvisioningType = ON_DEMAND
+		}
+	}
+}
+
+// ValidateWorkerPools checks that pools have unique valid names, known sizes and provisioning types
+func ValidateWorkerPools(pools []WorkerPool, sizes []string) error {
+	names := map[string]bool{}
+	for _, pool := range pools {
+		if !workerPoolNameRegexp.MatchString(pool.Name) {
+			return fmt.Errorf("worker pool name `%s` is invalid: must start with a letter and contain only lower case letters, digits and hyphens", pool.Name)
+		}
+		if names[pool.Name] {
+			return fmt.Errorf("worker pool `%s` is defined more than once", pool.Name)
+		}
+		names[pool.Name] = true
+
+		if pool.Count < 1 {
+			return fmt.Errorf("worker pool `%s` must have at least 1 worker", pool.Name)
+		}
+		if !contains(sizes, pool.Size) {
+			return fmt.Errorf("worker pool `%s` has unknown size `%s`. Valid sizes are: %v", pool.Name, pool.Size, sizes)
+		}
+		if pool.VMProvisioningType != SPOT && pool.VMProvisioningType != ON_DEMAND {
+			return fmt.Errorf("worker pool `%s` has unknown provisioning type `%s`: must be %s or %s", pool.Name, pool.VMProvisioningType, SPOT, ON_DEMAND)
+		}
+		for _, tag := range pool.Tags {
+			if tag == "" {
+				return fmt.Errorf("worker pool `%s` has an empty tag", pool.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// WorkerPoolProvisioningTypes returns the distinct provisioning types used by pools
+func WorkerPoolProvisioningTypes(pools []WorkerPool) []string {
+	var types []string
+	for _, pool := range pools {
+		if !contains(types, pool.VMProvisioningType) {
+			types = append(types, pool.VMProvisioningType)
+		}
+	}
+	return types
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package credhub
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AdminClient is the UAA client that control-tower deploys CredHub with
+const AdminClient = "credhub_admin"
+
+// Credentials are what is needed to write to the CredHub of a deployment as its admin client
+type Credentials struct {
+	URL          string
+	CACert       string
+	ClientSecret string
+}
+
+// Client writes values to CredHub using its API
+type Client struct {
+	creds Credentials
+	http  *http.Client
+}
+
+// New returns a Client that trusts the CA of the deployment's CredHub
+func New(creds Credentials) (*Client, error) {
+	if creds.URL == "" || creds.ClientSecret == "" {
+		return nil, fmt.Errorf("CredHub URL and admin client secret must be known before writing to CredHub")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(creds.CACert)) {
+		return nil, fmt.Errorf("failed to parse CredHub CA certificate")
+	}
+	return &Client{
+		creds: creds,
+		http: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+// SetValues writes each value to CredHub under path, replacing what was there
+func (client *Client) SetValues(path string, values map[string]string) error {
+	token, err := client.token()
+	if err != nil {
+		return err
+	}
+	for name, value := range values {
+		body, err := json.Marshal(map[string]string{
+			"name":  strings.TrimSuffix(path, "/") + "/" + name,
+			"type":  "value",
+			"value": value,
+		})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPut, client.endpoint("/api/v1/data"), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if err = client.do(req, nil); err != nil {
+			return fmt.Errorf("failed to write %s to CredHub: [%v]", name, err)
+		}
+	}
+	return nil
+}
+
+// token logs in to the UAA that CredHub names in its info as the admin client
+func (client *Client) token() (string, error) {
+	req, err := http.NewRequest(http.MethodGet, client.endpoint("/info"), nil)
+	if err != nil {
+		return "", err
+	}
+	var info struct {
+		AuthServer struct {
+			URL string `json:"url"`
+		} `json:"auth-server"`
+	}
+	if err = client.do(req, &info); err != nil {
+		return "", fmt.Errorf("failed to read CredHub info: [%v]", err)
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err = http.NewRequest(http.MethodPost, strings.TrimSuffix(info.AuthServer.URL, "/")+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(AdminClient, client.creds.ClientSecret)
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err = client.do(req, &token); err != nil {
+		return "", fmt.Errorf("failed to log in to CredHub: [%v]", err)
+	}
+	return token.AccessToken, nil
+}
+
+func (client *Client) endpoint(path string) string {
+	return strings.TrimSuffix(client.creds.URL, "/") + path
+}
+
+// do sends req and decodes its JSON response into v, unless v is nil
+func (client *Client) do(req *http.Request, v interface{}) error {
+	resp, err := client.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
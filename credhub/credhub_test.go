@@ -0,0 +1,74 @@
+package credhub
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestClient_SetValues(t *testing.T) {
+	written := map[string]string{}
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			json.NewEncoder(w).Encode(map[string]interface{}{"auth-server": map[string]string{"url": server.URL}})
+		case "/oauth/token":
+			if user, pass, _ := r.BasicAuth(); user != AdminClient || pass != "secret" || r.FormValue("grant_type") != "client_credentials" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "token"})
+		case "/api/v1/data":
+			if r.Method != http.MethodPut || r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["type"] != "value" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			written[body["name"]] = body["value"]
+			w.Write([]byte("{}"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	client, err := New(Credentials{URL: server.URL + "/", CACert: caCert, ClientSecret: "secret"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	err = client.SetValues("/concourse/main/pipeline", map[string]string{"key": "value", "other_key": "other value"})
+	if err != nil {
+		t.Fatalf("SetValues() error = %v", err)
+	}
+	want := map[string]string{"/concourse/main/pipeline/key": "value", "/concourse/main/pipeline/other_key": "other value"}
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("SetValues() wrote %v, want %v", written, want)
+	}
+
+	client, err = New(Credentials{URL: server.URL, CACert: caCert, ClientSecret: "wrong"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = client.SetValues("/concourse/main/pipeline", map[string]string{"key": "value"}); err == nil {
+		t.Errorf("SetValues() succeeded with the wrong client secret")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Credentials{CACert: "not a cert", ClientSecret: "secret"}); err == nil {
+		t.Errorf("New() accepted credentials without a URL")
+	}
+	if _, err := New(Credentials{URL: "https://ci.example.com:8844", CACert: "not a cert", ClientSecret: "secret"}); err == nil {
+		t.Errorf("New() accepted an invalid CA certificate")
+	}
+}
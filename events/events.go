@@ -0,0 +1,92 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Phases of a deploy that emit events
+const (
+	ConfigLoad     = "config-load"
+	TerraformApply = "terraform-apply"
+	CertGeneration = "cert-generation"
+	CreateEnv      = "create-env"
+	CloudConfig    = "cloud-config"
+	StemcellUpload = "stemcell-upload"
+	BoshDeploy     = "bosh-deploy"
+	SetPipeline    = "set-pipeline"
+)
+
+// Types of event
+const (
+	PhaseStart = "phase-start"
+	PhaseEnd   = "phase-end"
+)
+
+// Resources identifies the resources a phase created or acted on, such as the director IP
+type Resources map[string]string
+
+// Event is a single progress event, written as one line of JSON
+type Event struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	Phase      string    `json:"phase"`
+	DurationMS *int64    `json:"duration_ms,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Resources  Resources `json:"resources,omitempty"`
+}
+
+// Recorder records the start and end of each phase of an operation
+type Recorder interface {
+	// Phase runs action between start and end events for phase. Any resources action adds are
+	// included in the end event, as is the error it returns.
+	Phase(phase string, action func(Resources) error) error
+}
+
+type discard struct{}
+
+func (discard) Phase(phase string, action func(Resources) error) error {
+	return action(Resources{})
+}
+
+// Discard is a Recorder that runs each phase without emitting anything
+var Discard Recorder = discard{}
+
+// JSONRecorder writes events to a writer as newline-delimited JSON
+type JSONRecorder struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+// NewJSONRecorder returns a Recorder that writes NDJSON events to w
+func NewJSONRecorder(w io.Writer, now func() time.Time) *JSONRecorder {
+	return &JSONRecorder{w: w, now: now}
+}
+
+// Phase implements Recorder
+func (r *JSONRecorder) Phase(phase string, action func(Resources) error) error {
+	start := r.now()
+	r.write(Event{Time: start, Type: PhaseStart, Phase: phase})
+
+	resources := Resources{}
+	err := action(resources)
+
+	end := r.now()
+	duration := end.Sub(start).Milliseconds()
+	event := Event{Time: end, Type: PhaseEnd, Phase: phase, DurationMS: &duration, Resources: resources}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	r.write(event)
+	return err
+}
+
+func (r *JSONRecorder) write(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Events are best effort, so a failure to write one must not fail the deploy
+	_ = json.NewEncoder(r.w).Encode(event)
+}
@@ -0,0 +1,85 @@
+package events_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/EngineerBetter/control-tower/events"
+)
+
+func TestJSONRecorder_Phase(t *testing.T) {
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name      string
+		actionErr error
+		want      []Event
+	}{
+		{
+			name: "Successful phase",
+			want: []Event{
+				{Time: start, Type: PhaseStart, Phase: TerraformApply},
+				{Time: start.Add(1500 * time.Millisecond), Type: PhaseEnd, Phase: TerraformApply, DurationMS: int64Ptr(1500), Resources: Resources{"director_ip": "192.0.2.1"}},
+			},
+		},
+		{
+			name:      "Failed phase",
+			actionErr: errors.New("quota exceeded"),
+			want: []Event{
+				{Time: start, Type: PhaseStart, Phase: TerraformApply},
+				{Time: start.Add(1500 * time.Millisecond), Type: PhaseEnd, Phase: TerraformApply, DurationMS: int64Ptr(1500), Error: "quota exceeded", Resources: Resources{"director_ip": "192.0.2.1"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			clock := func() time.Time {
+				current := now
+				now = now.Add(1500 * time.Millisecond)
+				return current
+			}
+			output := new(bytes.Buffer)
+			recorder := NewJSONRecorder(output, clock)
+
+			err := recorder.Phase(TerraformApply, func(resources Resources) error {
+				resources["director_ip"] = "192.0.2.1"
+				return tt.actionErr
+			})
+			if err != tt.actionErr {
+				t.Errorf("JSONRecorder.Phase() error = %v, want %v", err, tt.actionErr)
+			}
+
+			var got []Event
+			decoder := json.NewDecoder(output)
+			for decoder.More() {
+				var event Event
+				if err := decoder.Decode(&event); err != nil {
+					t.Fatalf("invalid event: %v", err)
+				}
+				got = append(got, event)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JSONRecorder.Phase() wrote %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscard_Phase(t *testing.T) {
+	ran := false
+	err := Discard.Phase(CreateEnv, func(Resources) error {
+		ran = true
+		return errors.New("failed")
+	})
+	if !ran || err == nil || err.Error() != "failed" {
+		t.Errorf("Discard.Phase() ran = %v, error = %v", ran, err)
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -1,8 +1,6 @@
 package fly
 
 import (
-	"strings"
-
 	"github.com/aws/aws-sdk-go/aws/session"
 )
 
@@ -35,53 +33,46 @@ var getCredsFromSession = func() (string, string, error) {
 	return creds.AccessKeyID, creds.SecretAccessKey, nil
 }
 
-//BuildPipelineParams builds params for AWS control-tower self update pipeline
-func (a AWSPipeline) BuildPipelineParams(deployment, namespace, region, domain, allowIps, iaas string) (Pipeline, error) {
+// BuildPipelineParams builds params for AWS control-tower self update pipeline
+func (a AWSPipeline) BuildPipelineParams(deployment, namespace, region, domain, allowIps, iaas string, options SelfUpdateOptions) (Pipeline, error) {
 	accessKeyID, secretAccessKey, err := a.credsGetter()
 	if err != nil {
 		return nil, err
 	}
 
 	return AWSPipeline{
-		PipelineTemplateParams: PipelineTemplateParams{
-			ControlTowerVersion: ControlTowerVersion,
-			Deployment:          strings.TrimPrefix(deployment, "control-tower-"),
-			Domain:              domain,
-			AllowIPs:            allowIps,
-			Namespace:           namespace,
-			Region:              region,
-			IaaS:                iaas,
-		},
-		AWSAccessKeyID:     accessKeyID,
-		AWSSecretAccessKey: secretAccessKey,
+		PipelineTemplateParams: newPipelineTemplateParams(deployment, namespace, region, domain, allowIps, iaas, options),
+		AWSAccessKeyID:         accessKeyID,
+		AWSSecretAccessKey:     secretAccessKey,
 	}, nil
 }
 
+// GetSecrets returns the AWS credentials and state backend credentials the self-update pipeline looks
+// up in CredHub
+func (a AWSPipeline) GetSecrets() map[string]string {
+	secrets := a.stateSecrets()
+	secrets["aws_access_key_id"] = a.AWSAccessKeyID
+	secrets["aws_secret_access_key"] = a.AWSSecretAccessKey
+	return secrets
+}
+
 // GetConfigTemplate returns template for AWS Control-Tower self update pipeline
 func (a AWSPipeline) GetConfigTemplate() string {
 	return awsPipelineTemplate
 
 }
 
-const awsPipelineTemplate = `
+var awsPipelineTemplate = `
 ---` + selfUpdateResources + `
-jobs:
+jobs:` + approveUpgradeJob + `
 - name: self-update
   serial_groups: [cup]
   serial: true
   plan:
-  - get: control-tower-release
-    trigger: true
+` + selfUpdateGets + `
   - task: update
     params:
-      AWS_ACCESS_KEY_ID: "{{ .AWSAccessKeyID }}"
-      AWS_REGION: "{{ .Region }}"
-      AWS_SECRET_ACCESS_KEY: "{{ .AWSSecretAccessKey }}"
-      DEPLOYMENT: "{{ .Deployment }}"
-      IAAS: "{{ .IaaS }}"
-      NAMESPACE: "{{ .Namespace }}"
-      ALLOW_IPS: "{{ .AllowIPs }}"
-      SELF_UPDATE: true
+` + awsTaskParams + notificationParams + `
     config:
       platform: linux
       image_resource:
@@ -98,8 +89,8 @@ jobs:
           set -eux
 
           cd control-tower-release
-          chmod +x control-tower-linux-amd64
-          ./control-tower-linux-amd64 deploy $DEPLOYMENT
+          chmod +x control-tower-linux-amd64` + beforeSelfUpdate + `
+          ./control-tower-linux-amd64 deploy $DEPLOYMENT` + afterSelfUpdate + `
 - name: renew-https-cert
   serial_groups: [cup]
   serial: true
@@ -110,14 +101,7 @@ jobs:
     trigger: true
   - task: update
     params:
-      AWS_ACCESS_KEY_ID: "{{ .AWSAccessKeyID }}"
-      AWS_REGION: "{{ .Region }}"
-      AWS_SECRET_ACCESS_KEY: "{{ .AWSSecretAccessKey }}"
-      DEPLOYMENT: "{{ .Deployment }}"
-      IAAS: "{{ .IaaS }}"
-      NAMESPACE: "{{ .Namespace }}"
-      ALLOW_IPS: "{{ .AllowIPs }}"
-      SELF_UPDATE: true
+` + awsTaskParams + notificationParams + `
     config:
       platform: linux
       image_resource:
@@ -134,7 +118,17 @@ jobs:
           set -euxo pipefail
           cd control-tower-release
           chmod +x control-tower-linux-amd64
-` + renewCertsDateCheck + `
+` + renewCertsDateCheck + beforeCertRenewal + `
           echo Certificates expire in $days_until_expiry days, redeploying to renew them
           ./control-tower-linux-amd64 deploy $DEPLOYMENT
-`
+` + scheduleJobs(awsTaskParams, "")
+
+const awsTaskParams = `      AWS_ACCESS_KEY_ID: ((aws_access_key_id))
+      AWS_REGION: "{{ .Region }}"
+      AWS_SECRET_ACCESS_KEY: ((aws_secret_access_key))
+      DEPLOYMENT: "{{ .Deployment }}"
+      IAAS: "{{ .IaaS }}"
+      NAMESPACE: "{{ .Namespace }}"
+      ALLOW_IPS: "{{ .AllowIPs }}"
+      SELF_UPDATE: true
+      LOCK_TIMEOUT: 1h` + stateParams
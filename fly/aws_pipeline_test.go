@@ -1,8 +1,10 @@
 package fly_test
 
 import (
+	"github.com/EngineerBetter/control-tower/config"
 	. "github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/util"
+	"github.com/ghodss/yaml"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -18,7 +20,6 @@ resources:
   source:
     user: engineerbetter
     repository: control-tower
-    pre_release: true
 - name: every-day
   type: time
   icon: clock
@@ -33,14 +34,15 @@ jobs:
     trigger: true
   - task: update
     params:
-      AWS_ACCESS_KEY_ID: "access-key"
+      AWS_ACCESS_KEY_ID: ((aws_access_key_id))
       AWS_REGION: "eu-west-1"
-      AWS_SECRET_ACCESS_KEY: "secret-key"
+      AWS_SECRET_ACCESS_KEY: ((aws_secret_access_key))
       DEPLOYMENT: "my-deployment"
       IAAS: "AWS"
       NAMESPACE: "prod"
       ALLOW_IPS: "10.0.0.0"
       SELF_UPDATE: true
+      LOCK_TIMEOUT: 1h
     config:
       platform: linux
       image_resource:
@@ -69,14 +71,15 @@ jobs:
     trigger: true
   - task: update
     params:
-      AWS_ACCESS_KEY_ID: "access-key"
+      AWS_ACCESS_KEY_ID: ((aws_access_key_id))
       AWS_REGION: "eu-west-1"
-      AWS_SECRET_ACCESS_KEY: "secret-key"
+      AWS_SECRET_ACCESS_KEY: ((aws_secret_access_key))
       DEPLOYMENT: "my-deployment"
       IAAS: "AWS"
       NAMESPACE: "prod"
       ALLOW_IPS: "10.0.0.0"
       SELF_UPDATE: true
+      LOCK_TIMEOUT: 1h
     config:
       platform: linux
       image_resource:
@@ -121,7 +124,7 @@ jobs:
 
 			pipeline := NewAWSPipeline(fakeCredsGetter)
 
-			params, err := pipeline.BuildPipelineParams("my-deployment", "prod", "eu-west-1", "ci.engineerbetter.com", "10.0.0.0", "AWS")
+			params, err := pipeline.BuildPipelineParams("my-deployment", "prod", "eu-west-1", "ci.engineerbetter.com", "10.0.0.0", "AWS", SelfUpdateOptions{ReleaseChannel: config.ReleaseChannelStable})
 			Expect(err).ToNot(HaveOccurred())
 
 			yamlBytes, err := util.RenderTemplate("self-update pipeline", pipeline.GetConfigTemplate(), params)
@@ -129,6 +132,189 @@ jobs:
 
 			actual := string(yamlBytes)
 			Expect(actual).To(Equal(expected))
+			Expect(params.GetSecrets()).To(Equal(map[string]string{
+				"aws_access_key_id":     "access-key",
+				"aws_secret_access_key": "secret-key",
+			}))
+		})
+
+		It("Adds jobs that scale the workers on a schedule", func() {
+			fakeCredsGetter := func() (string, string, error) {
+				return "access-key", "secret-key", nil
+			}
+
+			pipeline := NewAWSPipeline(fakeCredsGetter)
+
+			schedule := &config.Schedule{Start: "07:00", Stop: "23:30", Days: []string{"Monday", "Friday"}, Location: "Europe/London", IdleWorkerCount: 2}
+			params, err := pipeline.BuildPipelineParams("my-deployment", "prod", "eu-west-1", "ci.engineerbetter.com", "10.0.0.0", "AWS", SelfUpdateOptions{Schedule: schedule, ReleaseChannel: config.ReleaseChannelStable})
+			Expect(err).ToNot(HaveOccurred())
+
+			yamlBytes, err := util.RenderTemplate("self-update pipeline", pipeline.GetConfigTemplate(), params)
+			Expect(err).ToNot(HaveOccurred())
+
+			var rendered struct {
+				Resources []struct {
+					Name   string                 `json:"name"`
+					Source map[string]interface{} `json:"source"`
+				} `json:"resources"`
+				Jobs []struct {
+					Name string `json:"name"`
+				} `json:"jobs"`
+			}
+			Expect(yaml.Unmarshal(yamlBytes, &rendered)).To(Succeed())
+			Expect(rendered.Resources).To(HaveLen(4))
+			Expect(rendered.Resources[3].Name).To(Equal("working-hours-stop"))
+			Expect(rendered.Resources[3].Source).To(Equal(map[string]interface{}{
+				"start":    "23:30",
+				"stop":     "00:30",
+				"days":     []interface{}{"Monday", "Friday"},
+				"location": "Europe/London",
+			}))
+			Expect(rendered.Jobs).To(HaveLen(4))
+			Expect(rendered.Jobs[2].Name).To(Equal("scale-down-workers"))
+			Expect(rendered.Jobs[3].Name).To(Equal("scale-up-workers"))
+			Expect(string(yamlBytes)).To(ContainSubstring("./control-tower-linux-amd64 pause --workers 2 $DEPLOYMENT"))
+			Expect(string(yamlBytes)).To(ContainSubstring("./control-tower-linux-amd64 resume $DEPLOYMENT"))
+		})
+
+		It("Follows only the releases of a pinned release channel", func() {
+			fakeCredsGetter := func() (string, string, error) {
+				return "access-key", "secret-key", nil
+			}
+
+			pipeline := NewAWSPipeline(fakeCredsGetter)
+
+			params, err := pipeline.BuildPipelineParams("my-deployment", "prod", "eu-west-1", "ci.engineerbetter.com", "10.0.0.0", "AWS", SelfUpdateOptions{ReleaseChannel: "0.17.x"})
+			Expect(err).ToNot(HaveOccurred())
+
+			yamlBytes, err := util.RenderTemplate("self-update pipeline", pipeline.GetConfigTemplate(), params)
+			Expect(err).ToNot(HaveOccurred())
+
+			var rendered struct {
+				Resources []struct {
+					Name   string                 `json:"name"`
+					Source map[string]interface{} `json:"source"`
+				} `json:"resources"`
+			}
+			Expect(yaml.Unmarshal(yamlBytes, &rendered)).To(Succeed())
+			Expect(rendered.Resources[0].Name).To(Equal("control-tower-release"))
+			Expect(rendered.Resources[0].Source).To(Equal(map[string]interface{}{
+				"user":       "engineerbetter",
+				"repository": "control-tower",
+				"tag_filter": `^v?0\.17\.\d+$`,
+			}))
+		})
+
+		It("Tells the tasks where state is kept when it is not with the IAAS", func() {
+			fakeCredsGetter := func() (string, string, error) {
+				return "access-key", "secret-key", nil
+			}
+
+			pipeline := NewAWSPipeline(fakeCredsGetter)
+
+			schedule := &config.Schedule{Start: "07:00", Stop: "19:00", Days: config.Weekdays, Location: "UTC", IdleWorkerCount: 1}
+			state := StateSettings{Backend: config.StateBackend{Type: config.S3Backend, Endpoint: "https://minio.example.com", Region: "eu-west-1", AccessKeyID: "state-key", SecretAccessKey: "state-secret"}}
+			params, err := pipeline.BuildPipelineParams("my-deployment", "prod", "eu-west-1", "ci.engineerbetter.com", "10.0.0.0", "AWS", SelfUpdateOptions{Schedule: schedule, ReleaseChannel: config.ReleaseChannelStable, State: state})
+			Expect(err).ToNot(HaveOccurred())
+
+			yamlBytes, err := util.RenderTemplate("self-update pipeline", pipeline.GetConfigTemplate(), params)
+			Expect(err).ToNot(HaveOccurred())
+
+			var rendered struct {
+				Jobs []struct {
+					Name string `json:"name"`
+					Plan []struct {
+						Task   string                 `json:"task"`
+						Params map[string]interface{} `json:"params"`
+					} `json:"plan"`
+				} `json:"jobs"`
+			}
+			Expect(yaml.Unmarshal(yamlBytes, &rendered)).To(Succeed())
+			Expect(rendered.Jobs).To(HaveLen(4))
+			for _, job := range rendered.Jobs {
+				task := job.Plan[len(job.Plan)-1]
+				Expect(task.Task).ToNot(BeEmpty(), job.Name)
+				Expect(task.Params).To(HaveKeyWithValue("STATE_BACKEND", "s3"), job.Name)
+				Expect(task.Params).To(HaveKeyWithValue("STATE_ENDPOINT", "https://minio.example.com"), job.Name)
+				Expect(task.Params).To(HaveKeyWithValue("STATE_REGION", "eu-west-1"), job.Name)
+				Expect(task.Params).To(HaveKeyWithValue("STATE_ACCESS_KEY_ID", "((state_access_key_id))"), job.Name)
+				Expect(task.Params).To(HaveKeyWithValue("STATE_SECRET_ACCESS_KEY", "((state_secret_access_key))"), job.Name)
+			}
+			Expect(params.GetSecrets()).To(Equal(map[string]string{
+				"aws_access_key_id":       "access-key",
+				"aws_secret_access_key":   "secret-key",
+				"state_access_key_id":     "state-key",
+				"state_secret_access_key": "state-secret",
+			}))
+		})
+
+		It("Upgrades within a maintenance window, after approval, and notifies", func() {
+			fakeCredsGetter := func() (string, string, error) {
+				return "access-key", "secret-key", nil
+			}
+
+			pipeline := NewAWSPipeline(fakeCredsGetter)
+
+			params, err := pipeline.BuildPipelineParams("my-deployment", "prod", "eu-west-1", "ci.engineerbetter.com", "10.0.0.0", "AWS", SelfUpdateOptions{
+				ReleaseChannel:    config.ReleaseChannelStable,
+				MaintenanceWindow: &config.MaintenanceWindow{Start: "22:00", Stop: "02:00", Days: []string{"Saturday", "Sunday"}, Location: "Europe/London"},
+				UpgradeApproval:   true,
+				Notifications: &config.Notifications{
+					SlackWebhook: "https://hooks.slack.com/services/T0/B0/secret",
+					EmailTo:      []string{"ops@example.com", "ci@example.com"},
+					EmailFrom:    "control-tower@example.com",
+					SMTPURL:      "smtp://smtp.example.com:587",
+					SMTPUsername: "control-tower",
+					SMTPPassword: "password",
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			yamlBytes, err := util.RenderTemplate("self-update pipeline", pipeline.GetConfigTemplate(), params)
+			Expect(err).ToNot(HaveOccurred())
+
+			var rendered struct {
+				Resources []struct {
+					Name   string                 `json:"name"`
+					Source map[string]interface{} `json:"source"`
+				} `json:"resources"`
+				Jobs []struct {
+					Name string `json:"name"`
+					Plan []struct {
+						Get     string                 `json:"get"`
+						Passed  []string               `json:"passed"`
+						Trigger bool                   `json:"trigger"`
+						Params  map[string]interface{} `json:"params"`
+					} `json:"plan"`
+				} `json:"jobs"`
+			}
+			Expect(yaml.Unmarshal(yamlBytes, &rendered)).To(Succeed())
+			Expect(rendered.Resources).To(HaveLen(3))
+			Expect(rendered.Resources[2].Name).To(Equal("maintenance-window"))
+			Expect(rendered.Resources[2].Source).To(Equal(map[string]interface{}{
+				"start":    "22:00",
+				"stop":     "02:00",
+				"days":     []interface{}{"Saturday", "Sunday"},
+				"location": "Europe/London",
+			}))
+
+			Expect(rendered.Jobs).To(HaveLen(3))
+			Expect(rendered.Jobs[0].Name).To(Equal("approve-upgrade"))
+			selfUpdate := rendered.Jobs[1]
+			Expect(selfUpdate.Name).To(Equal("self-update"))
+			Expect(selfUpdate.Plan[0].Get).To(Equal("control-tower-release"))
+			Expect(selfUpdate.Plan[0].Passed).To(Equal([]string{"approve-upgrade"}))
+			Expect(selfUpdate.Plan[1].Get).To(Equal("maintenance-window"))
+			Expect(selfUpdate.Plan[1].Trigger).To(BeTrue())
+			Expect(selfUpdate.Plan[2].Params).To(HaveKeyWithValue("NOTIFICATION_SLACK_WEBHOOK", "((notify_slack_webhook))"))
+			Expect(selfUpdate.Plan[2].Params).To(HaveKeyWithValue("NOTIFICATION_EMAIL_TO", "ops@example.com,ci@example.com"))
+			Expect(selfUpdate.Plan[2].Params).To(HaveKeyWithValue("NOTIFICATION_SMTP_PASSWORD", "((notify_smtp_password))"))
+			Expect(selfUpdate.Plan[2].Params).ToNot(HaveKey("NOTIFICATION_WEBHOOK"))
+
+			Expect(string(yamlBytes)).To(ContainSubstring(`window_days=",Saturday,Sunday,"`))
+			Expect(string(yamlBytes)).To(ContainSubstring(`[ "$now" -lt 0200 ]`))
+			Expect(string(yamlBytes)).To(ContainSubstring(`notify succeeded "Upgraded $DEPLOYMENT to control-tower $(cat tag)"`))
+			Expect(string(yamlBytes)).ToNot(ContainSubstring("hooks.slack.com"))
 		})
 	})
 })
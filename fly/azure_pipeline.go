@@ -0,0 +1,133 @@
+package fly
+
+// AzurePipeline is Azure specific implementation of Pipeline interface
+type AzurePipeline struct {
+	PipelineTemplateParams
+	SubscriptionID       string
+	TenantID             string
+	ClientID             string
+	ClientSecret         string
+	StorageAccount       string
+	StorageResourceGroup string
+}
+
+// NewAzurePipeline return AzurePipeline, reading credentials with the given
+// attribute lookup (usually the Azure provider's Attr)
+func NewAzurePipeline(attr func(string) (string, error)) (Pipeline, error) {
+	values := map[string]string{}
+	for _, key := range []string{"subscription_id", "tenant_id", "client_id", "client_secret", "storage_account", "storage_resource_group"} {
+		value, err := attr(key)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return AzurePipeline{
+		SubscriptionID:       values["subscription_id"],
+		TenantID:             values["tenant_id"],
+		ClientID:             values["client_id"],
+		ClientSecret:         values["client_secret"],
+		StorageAccount:       values["storage_account"],
+		StorageResourceGroup: values["storage_resource_group"],
+	}, nil
+}
+
+// BuildPipelineParams builds params for Azure control-tower self update pipeline
+func (a AzurePipeline) BuildPipelineParams(deployment, namespace, region, domain, allowIps, iaas string, options SelfUpdateOptions) (Pipeline, error) {
+	return AzurePipeline{
+		PipelineTemplateParams: newPipelineTemplateParams(deployment, namespace, region, domain, allowIps, iaas, options),
+		SubscriptionID:         a.SubscriptionID,
+		TenantID:               a.TenantID,
+		ClientID:               a.ClientID,
+		ClientSecret:           a.ClientSecret,
+		StorageAccount:         a.StorageAccount,
+		StorageResourceGroup:   a.StorageResourceGroup,
+	}, nil
+}
+
+// GetSecrets returns the client secret and state backend credentials the self-update pipeline looks up
+// in CredHub
+func (a AzurePipeline) GetSecrets() map[string]string {
+	secrets := a.stateSecrets()
+	secrets["azure_client_secret"] = a.ClientSecret
+	return secrets
+}
+
+// GetConfigTemplate returns template for Azure Control-Tower self update pipeline
+func (a AzurePipeline) GetConfigTemplate() string {
+	return azurePipelineTemplate
+}
+
+var azurePipelineTemplate = `
+---` + selfUpdateResources + `
+jobs:` + approveUpgradeJob + `
+- name: self-update
+  serial_groups: [cup]
+  serial: true
+  plan:
+` + selfUpdateGets + `
+  - task: update
+    params:
+` + azureTaskParams + notificationParams + `
+    config:
+      platform: linux
+      image_resource:
+        type: docker-image
+        source:
+          repository: engineerbetter/pcf-ops
+      inputs:
+      - name: control-tower-release
+      run:
+        path: bash
+        args:
+        - -c
+        - |
+          set -eux
+          cd control-tower-release
+          chmod +x control-tower-linux-amd64` + beforeSelfUpdate + `
+          ./control-tower-linux-amd64 deploy $DEPLOYMENT` + afterSelfUpdate + `
+- name: renew-https-cert
+  serial_groups: [cup]
+  serial: true
+  plan:
+  - get: control-tower-release
+    version: {tag: "{{ .ControlTowerVersion }}" }
+  - get: every-day
+    trigger: true
+  - task: update
+    params:
+` + azureTaskParams + notificationParams + `
+    config:
+      platform: linux
+      image_resource:
+        type: docker-image
+        source:
+          repository: engineerbetter/pcf-ops
+      inputs:
+      - name: control-tower-release
+      run:
+        path: bash
+        args:
+        - -c
+        - |
+          set -euxo pipefail
+          cd control-tower-release
+          chmod +x control-tower-linux-amd64
+` + renewCertsDateCheck + beforeCertRenewal + `
+          echo Certificates expire in $days_until_expiry days, redeploying to renew them
+          ./control-tower-linux-amd64 deploy $DEPLOYMENT
+` + scheduleJobs(azureTaskParams, "")
+
+const azureTaskParams = `      AWS_REGION: "{{ .Region }}"
+      AZURE_SUBSCRIPTION_ID: "{{ .SubscriptionID }}"
+      AZURE_TENANT_ID: "{{ .TenantID }}"
+      AZURE_CLIENT_ID: "{{ .ClientID }}"
+      AZURE_CLIENT_SECRET: ((azure_client_secret))
+      AZURE_STORAGE_ACCOUNT: "{{ .StorageAccount }}"
+      AZURE_STORAGE_RESOURCE_GROUP: "{{ .StorageResourceGroup }}"
+      DEPLOYMENT: "{{ .Deployment }}"
+      IAAS: "{{ .IaaS }}"
+      NAMESPACE: "{{ .Namespace }}"
+      ALLOW_IPS: "{{ .AllowIPs }}"
+      SELF_UPDATE: true
+      LOCK_TIMEOUT: 1h` + stateParams
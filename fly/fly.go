@@ -3,6 +3,7 @@ package fly
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -17,11 +18,20 @@ import (
 	"github.com/EngineerBetter/control-tower/iaas"
 
 	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/credhub"
 	"github.com/EngineerBetter/control-tower/util"
+	"github.com/ghodss/yaml"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 
+// SelfUpdatePipelineName is the name of the pipeline that keeps the deployment up to date
+const SelfUpdatePipelineName = "control-tower-self-update"
+
+// SelfUpdateCredsPath is where the IAAS credentials of the self-update pipeline are kept in CredHub,
+// which Concourse looks up the pipeline's vars in
+const SelfUpdateCredsPath = "/concourse/main/" + SelfUpdatePipelineName
+
 // ControlTowerVersion is a compile-time variable set with -ldflags
 var ControlTowerVersion = "COMPILE_TIME_VARIABLE_fly_control_tower_version"
 
@@ -29,6 +39,9 @@ var ControlTowerVersion = "COMPILE_TIME_VARIABLE_fly_control_tower_version"
 type IClient interface {
 	CanConnect() (bool, error)
 	SetDefaultPipeline(config config.ConfigView, allowFlyVersionDiscrepancy bool) error
+	SetTeams(teams []config.Team) error
+	TeamAuth() (map[string]config.TeamAuth, error)
+	SetPipelines(pipelines []config.UserPipeline) ([]string, error)
 	Cleanup() error
 }
 
@@ -40,19 +53,43 @@ type Client struct {
 	stdout      io.Writer
 	stderr      io.Writer
 	versionFile []byte
+	state       StateSettings
 }
 
-// Credentials represents credentials needed to connect to concourse
+// Credentials represents credentials needed to connect to concourse, and to the CredHub
+// that the self-update pipeline's credentials are written to
 type Credentials struct {
 	Target   string
 	API      string
 	Username string
 	Password string
 	CACert   string
+	CredHub  credhub.Credentials
+}
+
+// setCredHubValues writes values to the deployment's CredHub
+var setCredHubValues = func(creds credhub.Credentials, path string, values map[string]string) error {
+	client, err := credhub.New(creds)
+	if err != nil {
+		return err
+	}
+	return client.SetValues(path, values)
 }
 
-// New returns a new fly client
+// New returns a new fly client for a deployment whose state is kept with the IAAS
 func New(provider iaas.Provider, creds Credentials, stdout, stderr io.Writer, versionFile []byte) (IClient, error) {
+	return newClient(provider, creds, stdout, stderr, versionFile, StateSettings{})
+}
+
+// NewWithState returns a constructor of fly clients, like New, whose self-update pipeline finds the
+// deployment's state as state describes
+func NewWithState(state StateSettings) func(iaas.Provider, Credentials, io.Writer, io.Writer, []byte) (IClient, error) {
+	return func(provider iaas.Provider, creds Credentials, stdout, stderr io.Writer, versionFile []byte) (IClient, error) {
+		return newClient(provider, creds, stdout, stderr, versionFile, state)
+	}
+}
+
+func newClient(provider iaas.Provider, creds Credentials, stdout, stderr io.Writer, versionFile []byte, state StateSettings) (IClient, error) {
 	tempDir, err := util.NewTempDir()
 	if err != nil {
 		return nil, err
@@ -105,6 +142,11 @@ func New(provider iaas.Provider, creds Credentials, stdout, stderr io.Writer, ve
 		if err != nil {
 			return nil, errors.New("fly.go: failed to read credentials file")
 		}
+	case iaas.Azure:
+		pipeline, err = NewAzurePipeline(provider.Attr)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("fly.go: IAAS not recognised")
 
@@ -116,6 +158,7 @@ func New(provider iaas.Provider, creds Credentials, stdout, stderr io.Writer, ve
 		stdout,
 		stderr,
 		versionFile,
+		state,
 	}, nil
 }
 
@@ -123,6 +166,18 @@ var (
 	execCommand = exec.Command
 )
 
+// keepsLocalState reports whether the deployment's state is in a local directory, which the
+// self-update pipeline's jobs cannot reach
+func (client *Client) keepsLocalState() bool {
+	return client.state.Backend.Type == config.LocalBackend
+}
+
+func (client *Client) selfUpdateOptions(c config.ConfigView) SelfUpdateOptions {
+	options := NewSelfUpdateOptions(c)
+	options.State = client.state
+	return options
+}
+
 func (client *Client) runFly(args ...string) *exec.Cmd {
 	return execCommand(client.tempDir.Path("fly"), args...)
 }
@@ -168,8 +223,17 @@ func (client *Client) CanConnect() (bool, error) {
 	return false, runErr
 }
 
-// SetDefaultPipeline sets the default pipeline against a given concourse
+// SetDefaultPipeline sets the default pipeline against a given concourse, after writing the IAAS
+// credentials that it refers to into CredHub
 func (client *Client) SetDefaultPipeline(config config.ConfigView, allowFlyVersionDiscrepancy bool) error {
+	if client.keepsLocalState() {
+		if config.GetSchedule() != nil {
+			return errors.New("the worker schedule is enforced by the self-update pipeline, which cannot read state kept in a local directory")
+		}
+		_, err := fmt.Fprintln(client.stderr, "WARNING: the self-update pipeline is not set, as its jobs cannot read state kept in a local directory")
+		return err
+	}
+
 	if err := client.login(); err != nil {
 		return err
 	}
@@ -183,10 +247,22 @@ func (client *Client) SetDefaultPipeline(config config.ConfigView, allowFlyVersi
 		}
 	}
 
+	params, err := client.pipeline.BuildPipelineParams(config.GetDeployment(), config.GetNamespace(), config.GetRegion(), config.GetDomain(), config.GetAllowIPsUnformatted(), config.GetIAAS(), client.selfUpdateOptions(config))
+	if err != nil {
+		return err
+	}
+	secrets := params.GetSecrets()
+	for name, value := range notificationSecrets(config.GetNotifications()) {
+		secrets[name] = value
+	}
+	if err = setCredHubValues(client.creds.CredHub, SelfUpdateCredsPath, secrets); err != nil {
+		return fmt.Errorf("failed to store self-update pipeline credentials in CredHub: [%v]", err)
+	}
+
 	pipelinePath := client.tempDir.Path("default-pipeline.yml")
-	pipelineName := "control-tower-self-update"
+	pipelineName := SelfUpdatePipelineName
 
-	if err := client.writePipelineConfig(pipelinePath, config); err != nil {
+	if err := client.writePipelineConfig(pipelinePath, params); err != nil {
 		return err
 	}
 
@@ -205,17 +281,13 @@ func (client *Client) SetDefaultPipeline(config config.ConfigView, allowFlyVersi
 	return client.run("unpause-pipeline", "--pipeline", pipelineName)
 }
 
-func (client *Client) writePipelineConfig(pipelinePath string, config config.ConfigView) error {
+func (client *Client) writePipelineConfig(pipelinePath string, params Pipeline) error {
 	fileHandler, err := os.Create(pipelinePath)
 	if err != nil {
 		return err
 	}
 	defer fileHandler.Close()
 
-	params, err := client.pipeline.BuildPipelineParams(config.GetDeployment(), config.GetNamespace(), config.GetRegion(), config.GetDomain(), config.GetAllowIPsUnformatted(), config.GetIAAS())
-	if err != nil {
-		return err
-	}
 	pipelineTemplate := client.pipeline.GetConfigTemplate()
 	pipelineConfig, err := util.RenderTemplate("self-update pipeline", pipelineTemplate, params)
 	if err != nil {
@@ -233,6 +305,109 @@ func (client *Client) writePipelineConfig(pipelinePath string, config config.Con
 	return nil
 }
 
+// SetTeams creates each team, or updates its roles if it already exists
+func (client *Client) SetTeams(teams []config.Team) error {
+	if err := client.login(); err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		teamConfig, err := teamConfig(team)
+		if err != nil {
+			return err
+		}
+		teamConfigPath := client.tempDir.Path("team.yml")
+		if err = ioutil.WriteFile(teamConfigPath, teamConfig, 0600); err != nil {
+			return err
+		}
+		if err = client.run("set-team", "--team-name", team.Name, "--config", teamConfigPath, "--non-interactive"); err != nil {
+			return fmt.Errorf("failed to set team %s: [%v]", team.Name, err)
+		}
+		if err = os.Remove(teamConfigPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TeamAuth returns who the roles of each team in Concourse are granted to
+func (client *Client) TeamAuth() (map[string]config.TeamAuth, error) {
+	if err := client.login(); err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := client.runFly("--target", client.creds.Target, "teams", "--details", "--json")
+	cmd.Stdout = &stdout
+	cmd.Stderr = client.stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list teams: [%v]", err)
+	}
+	return parseTeamAuth(stdout.Bytes())
+}
+
+// SetPipelines sets and unpauses each pipeline, whose Config and Vars must be absolute paths. It
+// returns the pipelines that were created or changed.
+func (client *Client) SetPipelines(pipelines []config.UserPipeline) ([]string, error) {
+	if err := client.login(); err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, pipeline := range pipelines {
+		var stdout bytes.Buffer
+		cmd := client.runFly(setPipelineArgs(client.creds.Target, pipeline)...)
+		cmd.Stdout = io.MultiWriter(client.stdout, &stdout)
+		cmd.Stderr = client.stderr
+		if err := cmd.Run(); err != nil {
+			return changed, fmt.Errorf("failed to set pipeline %s: [%v]", pipeline, err)
+		}
+		if !strings.Contains(stdout.String(), "no changes to apply") {
+			changed = append(changed, pipeline.String())
+		}
+
+		if pipeline.Paused {
+			continue
+		}
+		if err := client.run("unpause-pipeline", "--team", pipeline.Team, "--pipeline", pipeline.Pipeline); err != nil {
+			return changed, fmt.Errorf("failed to unpause pipeline %s: [%v]", pipeline, err)
+		}
+	}
+	return changed, nil
+}
+
+func setPipelineArgs(target string, pipeline config.UserPipeline) []string {
+	args := []string{"--target", target, "set-pipeline", "--team", pipeline.Team, "--pipeline", pipeline.Pipeline, "--config", pipeline.Config}
+	for _, vars := range pipeline.Vars {
+		args = append(args, "--load-vars-from", vars)
+	}
+	return append(args, "--non-interactive")
+}
+
+// teamConfig renders the roles of a team as a `fly set-team` config file
+func teamConfig(team config.Team) ([]byte, error) {
+	return yaml.Marshal(struct {
+		Roles []config.TeamRole `json:"roles"`
+	}{team.Roles})
+}
+
+// parseTeamAuth parses the output of `fly teams --details --json`
+func parseTeamAuth(output []byte) (map[string]config.TeamAuth, error) {
+	var teams []struct {
+		Name string          `json:"name"`
+		Auth config.TeamAuth `json:"auth"`
+	}
+	if err := json.Unmarshal(output, &teams); err != nil {
+		return nil, fmt.Errorf("failed to parse teams: [%v]", err)
+	}
+
+	auth := map[string]config.TeamAuth{}
+	for _, team := range teams {
+		auth[team.Name] = team.Auth
+	}
+	return auth, nil
+}
+
 // Cleanup removes tempfiles
 func (client *Client) Cleanup() error {
 	return client.tempDir.Cleanup()
@@ -1,13 +1,18 @@
 package fly
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/EngineerBetter/control-tower/config"
+	"github.com/EngineerBetter/control-tower/credhub"
 	"github.com/EngineerBetter/control-tower/util"
 )
 
@@ -82,3 +87,144 @@ func TestClient_runFly(t *testing.T) {
 		})
 	}
 }
+
+func TestTeamConfig(t *testing.T) {
+	team := config.Team{
+		Name: "platform",
+		Roles: []config.TeamRole{
+			{Name: "owner", GitHub: &config.TeamGitHubUsers{Teams: []string{"engineerbetter:platform"}}},
+			{Name: "viewer", OIDC: &config.TeamGroupUsers{Groups: []string{"everyone"}}, Local: &config.TeamLocalUsers{Users: []string{"admin"}}},
+		},
+	}
+	got, err := teamConfig(team)
+	if err != nil {
+		t.Fatalf("teamConfig() error = %v", err)
+	}
+	want := `roles:
+- github:
+    teams:
+    - engineerbetter:platform
+  name: owner
+- local:
+    users:
+    - admin
+  name: viewer
+  oidc:
+    groups:
+    - everyone
+`
+	if string(got) != want {
+		t.Errorf("teamConfig() = %s, want %s", got, want)
+	}
+}
+
+func TestParseTeamAuth(t *testing.T) {
+	output := `[{"id":1,"name":"main","auth":{"owner":{"users":["local:admin"],"groups":[]}}},
+{"id":2,"name":"platform","auth":{"owner":{"users":[],"groups":["github:engineerbetter:platform"]},"viewer":{"users":[],"groups":["oidc:everyone"]}}}]`
+
+	got, err := parseTeamAuth([]byte(output))
+	if err != nil {
+		t.Fatalf("parseTeamAuth() error = %v", err)
+	}
+	want := map[string]config.TeamAuth{
+		"main":     {"owner": {Users: []string{"local:admin"}, Groups: []string{}}},
+		"platform": {"owner": {Users: []string{}, Groups: []string{"github:engineerbetter:platform"}}, "viewer": {Users: []string{}, Groups: []string{"oidc:everyone"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTeamAuth() = %+v, want %+v", got, want)
+	}
+
+	if _, err = parseTeamAuth([]byte("not json")); err == nil {
+		t.Errorf("parseTeamAuth() parsed invalid output")
+	}
+}
+
+func TestSetPipelineArgs(t *testing.T) {
+	pipeline := config.UserPipeline{Team: "platform", Pipeline: "hello", Config: "/tmp/ci/hello.yml", Vars: []string{"/tmp/ci/common.yml", "/tmp/ci/hello-vars.yml"}}
+
+	want := []string{"--target", "ci", "set-pipeline", "--team", "platform", "--pipeline", "hello", "--config", "/tmp/ci/hello.yml",
+		"--load-vars-from", "/tmp/ci/common.yml", "--load-vars-from", "/tmp/ci/hello-vars.yml", "--non-interactive"}
+	if got := setPipelineArgs("ci", pipeline); !reflect.DeepEqual(got, want) {
+		t.Errorf("setPipelineArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestClient_SetDefaultPipeline(t *testing.T) {
+	execCommand = fakeExecCommand
+	defer func() { execCommand = exec.Command }()
+
+	originalSetCredHubValues := setCredHubValues
+	defer func() { setCredHubValues = originalSetCredHubValues }()
+
+	var gotCreds credhub.Credentials
+	var gotPath string
+	var gotValues map[string]string
+	setCredHubValues = func(creds credhub.Credentials, path string, values map[string]string) error {
+		gotCreds, gotPath, gotValues = creds, path, values
+		return nil
+	}
+
+	tmpDir, _ := util.NewTempDir()
+	defer tmpDir.Cleanup()
+	creds := credhub.Credentials{URL: "https://ci.example.com:8844/", CACert: "ca", ClientSecret: "secret"}
+	client := &Client{
+		pipeline: NewAWSPipeline(func() (string, string, error) { return "access-key", "secret-key", nil }),
+		tempDir:  tmpDir,
+		creds:    Credentials{Target: "ci", CredHub: creds},
+		stdout:   ioutil.Discard,
+		stderr:   ioutil.Discard,
+	}
+	if err := client.SetDefaultPipeline(config.Config{Deployment: "control-tower-ci", IAAS: "AWS"}, false); err != nil {
+		t.Fatalf("SetDefaultPipeline() error = %v", err)
+	}
+	if gotCreds != creds || gotPath != "/concourse/main/control-tower-self-update" {
+		t.Errorf("SetDefaultPipeline() wrote to %s with %+v", gotPath, gotCreds)
+	}
+	want := map[string]string{"aws_access_key_id": "access-key", "aws_secret_access_key": "secret-key"}
+	if !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("SetDefaultPipeline() wrote %v, want %v", gotValues, want)
+	}
+
+	notifications := &config.Notifications{Webhook: "https://example.com/hook", EmailTo: []string{"ops@example.com"}, SMTPPassword: "password"}
+	if err := client.SetDefaultPipeline(config.Config{Deployment: "control-tower-ci", IAAS: "AWS", Notifications: notifications}, false); err != nil {
+		t.Fatalf("SetDefaultPipeline() error = %v", err)
+	}
+	want["notify_webhook"], want["notify_smtp_password"] = "https://example.com/hook", "password"
+	if !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("SetDefaultPipeline() wrote %v, want notification secrets %v", gotValues, want)
+	}
+
+	setCredHubValues = func(credhub.Credentials, string, map[string]string) error {
+		return fmt.Errorf("unreachable")
+	}
+	if err := client.SetDefaultPipeline(config.Config{Deployment: "control-tower-ci", IAAS: "AWS"}, false); err == nil {
+		t.Errorf("SetDefaultPipeline() set the pipeline without storing its credentials")
+	}
+}
+
+func TestClient_SetDefaultPipeline_LocalState(t *testing.T) {
+	execCommand = func(string, ...string) *exec.Cmd {
+		t.Fatalf("SetDefaultPipeline() ran fly for a deployment with local state")
+		return nil
+	}
+	defer func() { execCommand = exec.Command }()
+
+	stderr := &bytes.Buffer{}
+	client := &Client{
+		pipeline: NewAWSPipeline(func() (string, string, error) { return "access-key", "secret-key", nil }),
+		stdout:   ioutil.Discard,
+		stderr:   stderr,
+		state:    StateSettings{Backend: config.StateBackend{Type: config.LocalBackend, Dir: "state"}},
+	}
+	if err := client.SetDefaultPipeline(config.Config{Deployment: "control-tower-ci", IAAS: "AWS"}, false); err != nil {
+		t.Fatalf("SetDefaultPipeline() error = %v", err)
+	}
+	if !strings.Contains(stderr.String(), "self-update pipeline is not set") {
+		t.Errorf("SetDefaultPipeline() warned %q, want a warning that the pipeline is not set", stderr.String())
+	}
+
+	conf := config.Config{Deployment: "control-tower-ci", IAAS: "AWS", Schedule: &config.Schedule{Start: "07:00", Stop: "19:00"}}
+	if err := client.SetDefaultPipeline(conf, false); err == nil {
+		t.Errorf("SetDefaultPipeline() accepted a worker schedule that no pipeline would enforce")
+	}
+}
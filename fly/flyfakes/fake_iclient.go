@@ -43,6 +43,42 @@ type FakeIClient struct {
 	setDefaultPipelineReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetPipelinesStub        func([]config.UserPipeline) ([]string, error)
+	setPipelinesMutex       sync.RWMutex
+	setPipelinesArgsForCall []struct {
+		arg1 []config.UserPipeline
+	}
+	setPipelinesReturns struct {
+		result1 []string
+		result2 error
+	}
+	setPipelinesReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	SetTeamsStub        func([]config.Team) error
+	setTeamsMutex       sync.RWMutex
+	setTeamsArgsForCall []struct {
+		arg1 []config.Team
+	}
+	setTeamsReturns struct {
+		result1 error
+	}
+	setTeamsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	TeamAuthStub        func() (map[string]config.TeamAuth, error)
+	teamAuthMutex       sync.RWMutex
+	teamAuthArgsForCall []struct {
+	}
+	teamAuthReturns struct {
+		result1 map[string]config.TeamAuth
+		result2 error
+	}
+	teamAuthReturnsOnCall map[int]struct {
+		result1 map[string]config.TeamAuth
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -218,6 +254,197 @@ func (fake *FakeIClient) SetDefaultPipelineReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeIClient) SetPipelines(arg1 []config.UserPipeline) ([]string, error) {
+	var arg1Copy []config.UserPipeline
+	if arg1 != nil {
+		arg1Copy = make([]config.UserPipeline, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.setPipelinesMutex.Lock()
+	ret, specificReturn := fake.setPipelinesReturnsOnCall[len(fake.setPipelinesArgsForCall)]
+	fake.setPipelinesArgsForCall = append(fake.setPipelinesArgsForCall, struct {
+		arg1 []config.UserPipeline
+	}{arg1Copy})
+	stub := fake.SetPipelinesStub
+	fakeReturns := fake.setPipelinesReturns
+	fake.recordInvocation("SetPipelines", []interface{}{arg1Copy})
+	fake.setPipelinesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeIClient) SetPipelinesCallCount() int {
+	fake.setPipelinesMutex.RLock()
+	defer fake.setPipelinesMutex.RUnlock()
+	return len(fake.setPipelinesArgsForCall)
+}
+
+func (fake *FakeIClient) SetPipelinesCalls(stub func([]config.UserPipeline) ([]string, error)) {
+	fake.setPipelinesMutex.Lock()
+	defer fake.setPipelinesMutex.Unlock()
+	fake.SetPipelinesStub = stub
+}
+
+func (fake *FakeIClient) SetPipelinesArgsForCall(i int) []config.UserPipeline {
+	fake.setPipelinesMutex.RLock()
+	defer fake.setPipelinesMutex.RUnlock()
+	argsForCall := fake.setPipelinesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeIClient) SetPipelinesReturns(result1 []string, result2 error) {
+	fake.setPipelinesMutex.Lock()
+	defer fake.setPipelinesMutex.Unlock()
+	fake.SetPipelinesStub = nil
+	fake.setPipelinesReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) SetPipelinesReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.setPipelinesMutex.Lock()
+	defer fake.setPipelinesMutex.Unlock()
+	fake.SetPipelinesStub = nil
+	if fake.setPipelinesReturnsOnCall == nil {
+		fake.setPipelinesReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.setPipelinesReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) SetTeams(arg1 []config.Team) error {
+	var arg1Copy []config.Team
+	if arg1 != nil {
+		arg1Copy = make([]config.Team, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.setTeamsMutex.Lock()
+	ret, specificReturn := fake.setTeamsReturnsOnCall[len(fake.setTeamsArgsForCall)]
+	fake.setTeamsArgsForCall = append(fake.setTeamsArgsForCall, struct {
+		arg1 []config.Team
+	}{arg1Copy})
+	stub := fake.SetTeamsStub
+	fakeReturns := fake.setTeamsReturns
+	fake.recordInvocation("SetTeams", []interface{}{arg1Copy})
+	fake.setTeamsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIClient) SetTeamsCallCount() int {
+	fake.setTeamsMutex.RLock()
+	defer fake.setTeamsMutex.RUnlock()
+	return len(fake.setTeamsArgsForCall)
+}
+
+func (fake *FakeIClient) SetTeamsCalls(stub func([]config.Team) error) {
+	fake.setTeamsMutex.Lock()
+	defer fake.setTeamsMutex.Unlock()
+	fake.SetTeamsStub = stub
+}
+
+func (fake *FakeIClient) SetTeamsArgsForCall(i int) []config.Team {
+	fake.setTeamsMutex.RLock()
+	defer fake.setTeamsMutex.RUnlock()
+	argsForCall := fake.setTeamsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeIClient) SetTeamsReturns(result1 error) {
+	fake.setTeamsMutex.Lock()
+	defer fake.setTeamsMutex.Unlock()
+	fake.SetTeamsStub = nil
+	fake.setTeamsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) SetTeamsReturnsOnCall(i int, result1 error) {
+	fake.setTeamsMutex.Lock()
+	defer fake.setTeamsMutex.Unlock()
+	fake.SetTeamsStub = nil
+	if fake.setTeamsReturnsOnCall == nil {
+		fake.setTeamsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setTeamsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIClient) TeamAuth() (map[string]config.TeamAuth, error) {
+	fake.teamAuthMutex.Lock()
+	ret, specificReturn := fake.teamAuthReturnsOnCall[len(fake.teamAuthArgsForCall)]
+	fake.teamAuthArgsForCall = append(fake.teamAuthArgsForCall, struct {
+	}{})
+	stub := fake.TeamAuthStub
+	fakeReturns := fake.teamAuthReturns
+	fake.recordInvocation("TeamAuth", []interface{}{})
+	fake.teamAuthMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeIClient) TeamAuthCallCount() int {
+	fake.teamAuthMutex.RLock()
+	defer fake.teamAuthMutex.RUnlock()
+	return len(fake.teamAuthArgsForCall)
+}
+
+func (fake *FakeIClient) TeamAuthCalls(stub func() (map[string]config.TeamAuth, error)) {
+	fake.teamAuthMutex.Lock()
+	defer fake.teamAuthMutex.Unlock()
+	fake.TeamAuthStub = stub
+}
+
+func (fake *FakeIClient) TeamAuthReturns(result1 map[string]config.TeamAuth, result2 error) {
+	fake.teamAuthMutex.Lock()
+	defer fake.teamAuthMutex.Unlock()
+	fake.TeamAuthStub = nil
+	fake.teamAuthReturns = struct {
+		result1 map[string]config.TeamAuth
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeIClient) TeamAuthReturnsOnCall(i int, result1 map[string]config.TeamAuth, result2 error) {
+	fake.teamAuthMutex.Lock()
+	defer fake.teamAuthMutex.Unlock()
+	fake.TeamAuthStub = nil
+	if fake.teamAuthReturnsOnCall == nil {
+		fake.teamAuthReturnsOnCall = make(map[int]struct {
+			result1 map[string]config.TeamAuth
+			result2 error
+		})
+	}
+	fake.teamAuthReturnsOnCall[i] = struct {
+		result1 map[string]config.TeamAuth
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeIClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -227,6 +454,12 @@ func (fake *FakeIClient) Invocations() map[string][][]interface{} {
 	defer fake.cleanupMutex.RUnlock()
 	fake.setDefaultPipelineMutex.RLock()
 	defer fake.setDefaultPipelineMutex.RUnlock()
+	fake.setPipelinesMutex.RLock()
+	defer fake.setPipelinesMutex.RUnlock()
+	fake.setTeamsMutex.RLock()
+	defer fake.setTeamsMutex.RUnlock()
+	fake.teamAuthMutex.RLock()
+	defer fake.teamAuthMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -2,7 +2,6 @@ package fly
 
 import (
 	"io/ioutil"
-	"strings"
 )
 
 // GCPPipeline is GCP specific implementation of Pipeline interface
@@ -22,22 +21,22 @@ func NewGCPPipeline(credsPath string) (Pipeline, error) {
 	}, nil
 }
 
-//BuildPipelineParams builds params for AWS control-tower self update pipeline
-func (a GCPPipeline) BuildPipelineParams(deployment, namespace, region, domain, allowIps, iaas string) (Pipeline, error) {
+// BuildPipelineParams builds params for AWS control-tower self update pipeline
+func (a GCPPipeline) BuildPipelineParams(deployment, namespace, region, domain, allowIps, iaas string, options SelfUpdateOptions) (Pipeline, error) {
 	return GCPPipeline{
-		PipelineTemplateParams: PipelineTemplateParams{
-			ControlTowerVersion: ControlTowerVersion,
-			Deployment:          strings.TrimPrefix(deployment, "control-tower-"),
-			AllowIPs:            allowIps,
-			Domain:              domain,
-			Namespace:           namespace,
-			Region:              region,
-			IaaS:                iaas,
-		},
-		GCPCreds: a.GCPCreds,
+		PipelineTemplateParams: newPipelineTemplateParams(deployment, namespace, region, domain, allowIps, iaas, options),
+		GCPCreds:               a.GCPCreds,
 	}, nil
 }
 
+// GetSecrets returns the service account key and state backend credentials the self-update pipeline
+// looks up in CredHub
+func (a GCPPipeline) GetSecrets() map[string]string {
+	secrets := a.stateSecrets()
+	secrets["gcp_credentials"] = a.GCPCreds
+	return secrets
+}
+
 // GetConfigTemplate returns template for AWS Control-Tower self update pipeline
 func (a GCPPipeline) GetConfigTemplate() string {
 	return gcpPipelineTemplate
@@ -52,24 +51,17 @@ func readFileContents(path string) (string, error) {
 	return string(content), nil
 }
 
-const gcpPipelineTemplate = `
+var gcpPipelineTemplate = `
 ---` + selfUpdateResources + `
-jobs:
+jobs:` + approveUpgradeJob + `
 - name: self-update
   serial_groups: [cup]
   serial: true
   plan:
-  - get: control-tower-release
-    trigger: true
+` + selfUpdateGets + `
   - task: update
     params:
-      AWS_REGION: "{{ .Region }}"
-      DEPLOYMENT: "{{ .Deployment }}"
-      GCPCreds: '{{ .GCPCreds }}'
-      IAAS: "{{ .IaaS }}"
-      NAMESPACE: "{{ .Namespace }}"
-      ALLOW_IPS: "{{ .AllowIPs }}"
-      SELF_UPDATE: true
+` + gcpTaskParams + notificationParams + `
     config:
       platform: linux
       image_resource:
@@ -87,8 +79,8 @@ jobs:
           echo "${GCPCreds}" > googlecreds.json
           export GOOGLE_APPLICATION_CREDENTIALS=$PWD/googlecreds.json
           set -eux
-          chmod +x control-tower-linux-amd64
-          ./control-tower-linux-amd64 deploy $DEPLOYMENT
+          chmod +x control-tower-linux-amd64` + beforeSelfUpdate + `
+          ./control-tower-linux-amd64 deploy $DEPLOYMENT` + afterSelfUpdate + `
 - name: renew-https-cert
   serial_groups: [cup]
   serial: true
@@ -99,13 +91,7 @@ jobs:
     trigger: true
   - task: update
     params:
-      AWS_REGION: "{{ .Region }}"
-      DEPLOYMENT: "{{ .Deployment }}"
-      GCPCreds: '{{ .GCPCreds }}'
-      IAAS: "{{ .IaaS }}"
-      NAMESPACE: "{{ .Namespace }}"
-      ALLOW_IPS: "{{ .AllowIPs }}"
-      SELF_UPDATE: true
+` + gcpTaskParams + notificationParams + `
     config:
       platform: linux
       image_resource:
@@ -124,7 +110,18 @@ jobs:
           set -euxo pipefail
           cd control-tower-release
           chmod +x control-tower-linux-amd64
-` + renewCertsDateCheck + `
+` + renewCertsDateCheck + beforeCertRenewal + `
           echo Certificates expire in $days_until_expiry days, redeploying to renew them
           ./control-tower-linux-amd64 deploy $DEPLOYMENT
-`
+` + scheduleJobs(gcpTaskParams, `
+          echo "${GCPCreds}" > googlecreds.json
+          export GOOGLE_APPLICATION_CREDENTIALS=$PWD/googlecreds.json`)
+
+const gcpTaskParams = `      AWS_REGION: "{{ .Region }}"
+      DEPLOYMENT: "{{ .Deployment }}"
+      GCPCreds: ((gcp_credentials))
+      IAAS: "{{ .IaaS }}"
+      NAMESPACE: "{{ .Namespace }}"
+      ALLOW_IPS: "{{ .AllowIPs }}"
+      SELF_UPDATE: true
+      LOCK_TIMEOUT: 1h` + stateParams
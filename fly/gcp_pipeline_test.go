@@ -4,8 +4,10 @@ import (
 	"io/ioutil"
 	"os"
 
+	"github.com/EngineerBetter/control-tower/config"
 	. "github.com/EngineerBetter/control-tower/fly"
 	"github.com/EngineerBetter/control-tower/util"
+	"github.com/ghodss/yaml"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -38,11 +40,12 @@ jobs:
     params:
       AWS_REGION: "europe-west1"
       DEPLOYMENT: "my-deployment"
-      GCPCreds: 'creds-content'
+      GCPCreds: ((gcp_credentials))
       IAAS: "GCP"
       NAMESPACE: "prod"
       ALLOW_IPS: "10.0.0.0"
       SELF_UPDATE: true
+      LOCK_TIMEOUT: 1h
     config:
       platform: linux
       image_resource:
@@ -74,11 +77,12 @@ jobs:
     params:
       AWS_REGION: "europe-west1"
       DEPLOYMENT: "my-deployment"
-      GCPCreds: 'creds-content'
+      GCPCreds: ((gcp_credentials))
       IAAS: "GCP"
       NAMESPACE: "prod"
       ALLOW_IPS: "10.0.0.0"
       SELF_UPDATE: true
+      LOCK_TIMEOUT: 1h
     config:
       platform: linux
       image_resource:
@@ -130,7 +134,7 @@ jobs:
 			pipeline, err := NewGCPPipeline(tempFile.Name())
 			Expect(err).ToNot(HaveOccurred())
 
-			params, err := pipeline.BuildPipelineParams("my-deployment", "prod", "europe-west1", "ci.engineerbetter.com", "10.0.0.0", "GCP")
+			params, err := pipeline.BuildPipelineParams("my-deployment", "prod", "europe-west1", "ci.engineerbetter.com", "10.0.0.0", "GCP", SelfUpdateOptions{ReleaseChannel: "pre-release"})
 			Expect(err).ToNot(HaveOccurred())
 
 			yamlBytes, err := util.RenderTemplate("self-update pipeline", pipeline.GetConfigTemplate(), params)
@@ -138,6 +142,70 @@ jobs:
 
 			actual := string(yamlBytes)
 			Expect(actual).To(Equal(expected))
+			Expect(params.GetSecrets()).To(Equal(map[string]string{"gcp_credentials": "creds-content"}))
+		})
+
+		It("Tells the tasks how state is encrypted", func() {
+			tempFile, err := ioutil.TempFile("", "gcp-creds")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(tempFile.Name())
+			_, err = tempFile.Write([]byte("creds-content"))
+			Expect(err).ToNot(HaveOccurred())
+
+			pipeline, err := NewGCPPipeline(tempFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+
+			keyID := "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+			schedule := &config.Schedule{Start: "07:00", Stop: "19:00", Days: config.Weekdays, Location: "UTC", IdleWorkerCount: 1}
+			state := StateSettings{Encryption: config.Encryption{Type: config.GCPKMSEncryption, KMSKeyID: keyID}}
+			params, err := pipeline.BuildPipelineParams("my-deployment", "prod", "europe-west1", "ci.engineerbetter.com", "10.0.0.0", "GCP", SelfUpdateOptions{Schedule: schedule, State: state})
+			Expect(err).ToNot(HaveOccurred())
+
+			yamlBytes, err := util.RenderTemplate("self-update pipeline", pipeline.GetConfigTemplate(), params)
+			Expect(err).ToNot(HaveOccurred())
+
+			var rendered struct {
+				Jobs []struct {
+					Name string `json:"name"`
+					Plan []struct {
+						Params map[string]interface{} `json:"params"`
+					} `json:"plan"`
+				} `json:"jobs"`
+			}
+			Expect(yaml.Unmarshal(yamlBytes, &rendered)).To(Succeed())
+			Expect(rendered.Jobs).To(HaveLen(4))
+			for _, job := range rendered.Jobs {
+				taskParams := job.Plan[len(job.Plan)-1].Params
+				Expect(taskParams).To(HaveKeyWithValue("ENCRYPTION", "((encryption))"), job.Name)
+				Expect(taskParams).To(HaveKeyWithValue("ENCRYPTION_KMS_KEY_ID", "((encryption_kms_key_id))"), job.Name)
+				Expect(taskParams).ToNot(HaveKey("ENCRYPTION_PASSPHRASE"), job.Name)
+				Expect(taskParams).ToNot(HaveKey("STATE_BACKEND"), job.Name)
+			}
+			Expect(params.GetSecrets()).To(Equal(map[string]string{
+				"gcp_credentials":       "creds-content",
+				"encryption":            "gcp-kms",
+				"encryption_kms_key_id": keyID,
+			}))
+		})
+
+		It("Keeps the encryption passphrase in CredHub", func() {
+			tempFile, err := ioutil.TempFile("", "gcp-creds")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(tempFile.Name())
+
+			pipeline, err := NewGCPPipeline(tempFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+
+			state := StateSettings{Encryption: config.Encryption{Type: config.PassphraseEncryption, Passphrase: "correct horse"}}
+			params, err := pipeline.BuildPipelineParams("my-deployment", "prod", "europe-west1", "ci.engineerbetter.com", "10.0.0.0", "GCP", SelfUpdateOptions{State: state})
+			Expect(err).ToNot(HaveOccurred())
+
+			yamlBytes, err := util.RenderTemplate("self-update pipeline", pipeline.GetConfigTemplate(), params)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(yamlBytes)).To(ContainSubstring("ENCRYPTION_PASSPHRASE: ((encryption_passphrase))"))
+			Expect(string(yamlBytes)).ToNot(ContainSubstring("correct horse"))
+			Expect(params.GetSecrets()).To(HaveKeyWithValue("encryption_passphrase", "correct horse"))
 		})
 	})
 })
@@ -1,9 +1,18 @@
 package fly
 
+import (
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/control-tower/config"
+)
+
 // Pipeline is interface for self update pipeline
 type Pipeline interface {
-	BuildPipelineParams(deployment, namespace, region, domain, allowIps, iaas string) (Pipeline, error)
+	BuildPipelineParams(deployment, namespace, region, domain, allowIps, iaas string, options SelfUpdateOptions) (Pipeline, error)
 	GetConfigTemplate() string
+	// GetSecrets returns the IAAS and state backend credentials the template refers to as ((vars)), keyed by var name
+	GetSecrets() map[string]string
 }
 
 type PipelineTemplateParams struct {
@@ -14,6 +23,209 @@ type PipelineTemplateParams struct {
 	Namespace           string
 	Region              string
 	IaaS                string
+	Schedule            *ScheduleParams
+	// PreRelease and TagFilter choose the releases of control-tower the pipeline deploys
+	PreRelease        bool
+	TagFilter         string
+	MaintenanceWindow *MaintenanceWindowParams
+	UpgradeApproval   bool
+	Notifications     *NotificationParams
+	// StateBackend is where the deployment's state is kept, or nil if it is kept with the IAAS
+	StateBackend *config.StateBackend
+	// Encryption is how the deployment's state is encrypted, or nil if it is not
+	Encryption *config.Encryption
+}
+
+// SelfUpdateOptions are the settings of a deployment that change what its self-update pipeline does
+type SelfUpdateOptions struct {
+	Schedule          *config.Schedule
+	ReleaseChannel    string
+	MaintenanceWindow *config.MaintenanceWindow
+	UpgradeApproval   bool
+	Notifications     *config.Notifications
+	State             StateSettings
+}
+
+// StateSettings are where a deployment's state is kept and how it is encrypted, which the deploys run
+// by its self-update pipeline must be told in order to read it
+type StateSettings struct {
+	Backend    config.StateBackend
+	Encryption config.Encryption
+}
+
+// NewSelfUpdateOptions returns the self-update settings of a deployment's config
+func NewSelfUpdateOptions(c config.ConfigView) SelfUpdateOptions {
+	return SelfUpdateOptions{
+		Schedule:          c.GetSchedule(),
+		ReleaseChannel:    c.GetReleaseChannel(),
+		MaintenanceWindow: c.GetMaintenanceWindow(),
+		UpgradeApproval:   c.RequiresUpgradeApproval(),
+		Notifications:     c.GetNotifications(),
+	}
+}
+
+func newPipelineTemplateParams(deployment, namespace, region, domain, allowIps, iaas string, options SelfUpdateOptions) PipelineTemplateParams {
+	return PipelineTemplateParams{
+		ControlTowerVersion: ControlTowerVersion,
+		Deployment:          strings.TrimPrefix(deployment, "control-tower-"),
+		Domain:              domain,
+		AllowIPs:            allowIps,
+		Namespace:           namespace,
+		Region:              region,
+		IaaS:                iaas,
+		Schedule:            newScheduleParams(options.Schedule),
+		PreRelease:          options.ReleaseChannel == config.ReleaseChannelPreRelease,
+		TagFilter:           config.ReleaseTagFilter(options.ReleaseChannel),
+		MaintenanceWindow:   newMaintenanceWindowParams(options.MaintenanceWindow),
+		UpgradeApproval:     options.UpgradeApproval,
+		Notifications:       newNotificationParams(options.Notifications),
+		StateBackend:        newStateBackendParams(options.State.Backend),
+		Encryption:          newEncryptionParams(options.State.Encryption),
+	}
+}
+
+func newStateBackendParams(backend config.StateBackend) *config.StateBackend {
+	if backend.Type != config.S3Backend {
+		return nil
+	}
+	return &backend
+}
+
+func newEncryptionParams(encryption config.Encryption) *config.Encryption {
+	if encryption.Type == "" {
+		return nil
+	}
+	return &encryption
+}
+
+// stateSecrets returns the state backend credentials and encryption settings the pipeline looks up in
+// CredHub, keyed by var name
+func (p PipelineTemplateParams) stateSecrets() map[string]string {
+	secrets := map[string]string{}
+	if p.StateBackend != nil && p.StateBackend.AccessKeyID != "" {
+		secrets["state_access_key_id"] = p.StateBackend.AccessKeyID
+		secrets["state_secret_access_key"] = p.StateBackend.SecretAccessKey
+	}
+	if p.Encryption != nil {
+		secrets["encryption"] = p.Encryption.Type
+		if p.Encryption.Type == config.PassphraseEncryption {
+			secrets["encryption_passphrase"] = p.Encryption.Passphrase
+		} else {
+			secrets["encryption_kms_key_id"] = p.Encryption.KMSKeyID
+		}
+	}
+	return secrets
+}
+
+// ScheduleParams are the windows the scheduled jobs of the self-update pipeline trigger in
+type ScheduleParams struct {
+	Start           string
+	StartBy         string
+	Stop            string
+	StopBy          string
+	Days            string
+	Location        string
+	IdleWorkerCount int
+}
+
+// scheduleWindow is how long after the scheduled time a scheduled job can still trigger
+const scheduleWindow = time.Hour
+
+func newScheduleParams(schedule *config.Schedule) *ScheduleParams {
+	if schedule == nil {
+		return nil
+	}
+	return &ScheduleParams{
+		Start:           schedule.Start,
+		StartBy:         scheduleWindowEnd(schedule.Start),
+		Stop:            schedule.Stop,
+		StopBy:          scheduleWindowEnd(schedule.Stop),
+		Days:            strings.Join(schedule.Days, ", "),
+		Location:        schedule.Location,
+		IdleWorkerCount: schedule.IdleWorkerCount,
+	}
+}
+
+// MaintenanceWindowParams are the hours the self-update job may upgrade in. StartHHMM and StopHHMM
+// are compared with the output of date +%H%M by the job's script
+type MaintenanceWindowParams struct {
+	Start           string
+	Stop            string
+	StartHHMM       string
+	StopHHMM        string
+	Days            string
+	DayList         string
+	Location        string
+	CrossesMidnight bool
+}
+
+func newMaintenanceWindowParams(window *config.MaintenanceWindow) *MaintenanceWindowParams {
+	if window == nil {
+		return nil
+	}
+	return &MaintenanceWindowParams{
+		Start:           window.Start,
+		Stop:            window.Stop,
+		StartHHMM:       strings.Replace(window.Start, ":", "", 1),
+		StopHHMM:        strings.Replace(window.Stop, ":", "", 1),
+		Days:            strings.Join(window.Days, ", "),
+		DayList:         strings.Join(window.Days, ","),
+		Location:        window.Location,
+		CrossesMidnight: window.Stop < window.Start,
+	}
+}
+
+// NotificationParams are where the self-update jobs report upgrades. The webhooks and SMTP password
+// are only flagged, as the pipeline reads them from CredHub
+type NotificationParams struct {
+	Slack        bool
+	Webhook      bool
+	EmailTo      string
+	EmailFrom    string
+	SMTPURL      string
+	SMTPUsername string
+	SMTPPassword bool
+}
+
+func newNotificationParams(n *config.Notifications) *NotificationParams {
+	if n == nil || n.IsEmpty() {
+		return nil
+	}
+	return &NotificationParams{
+		Slack:        n.SlackWebhook != "",
+		Webhook:      n.Webhook != "",
+		EmailTo:      strings.Join(n.EmailTo, ","),
+		EmailFrom:    n.EmailFrom,
+		SMTPURL:      n.SMTPURL,
+		SMTPUsername: n.SMTPUsername,
+		SMTPPassword: n.SMTPPassword != "",
+	}
+}
+
+// notificationSecrets returns the notification settings the pipeline looks up in CredHub, keyed by var name
+func notificationSecrets(n *config.Notifications) map[string]string {
+	secrets := map[string]string{}
+	if n == nil {
+		return secrets
+	}
+	if n.SlackWebhook != "" {
+		secrets["notify_slack_webhook"] = n.SlackWebhook
+	}
+	if n.Webhook != "" {
+		secrets["notify_webhook"] = n.Webhook
+	}
+	if n.SMTPPassword != "" {
+		secrets["notify_smtp_password"] = n.SMTPPassword
+	}
+	return secrets
+}
+
+func scheduleWindowEnd(at string) string {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return at
+	}
+	return t.Add(scheduleWindow).Format("15:04")
 }
 
 const selfUpdateResources = `
@@ -23,14 +235,134 @@ resources:
   icon: github
   source:
     user: engineerbetter
-    repository: control-tower
-    pre_release: true
+    repository: control-tower{{ if .PreRelease }}
+    pre_release: true{{ end }}{{ if .TagFilter }}
+    tag_filter: '{{ .TagFilter }}'{{ end }}
 - name: every-day
   type: time
   icon: clock
-  source: {interval: 24h}
+  source: {interval: 24h}{{ if .Schedule }}
+- name: working-hours-start
+  type: time
+  icon: clock-start
+  source:
+    start: "{{ .Schedule.Start }}"
+    stop: "{{ .Schedule.StartBy }}"
+    days: [{{ .Schedule.Days }}]
+    location: "{{ .Schedule.Location }}"
+- name: working-hours-stop
+  type: time
+  icon: clock-end
+  source:
+    start: "{{ .Schedule.Stop }}"
+    stop: "{{ .Schedule.StopBy }}"
+    days: [{{ .Schedule.Days }}]
+    location: "{{ .Schedule.Location }}"{{ end }}{{ if .MaintenanceWindow }}
+- name: maintenance-window
+  type: time
+  icon: calendar-clock
+  source:
+    start: "{{ .MaintenanceWindow.Start }}"
+    stop: "{{ .MaintenanceWindow.Stop }}"
+    days: [{{ .MaintenanceWindow.Days }}]
+    location: "{{ .MaintenanceWindow.Location }}"{{ end }}
 `
 
+// stateParams tell the deploys run by the pipeline's tasks where state is kept when it is not with the
+// IAAS, and how to decrypt it. Credentials the backend is given none of are taken from the task's environment.
+const stateParams = `{{ if .StateBackend }}
+      STATE_BACKEND: "{{ .StateBackend.Type }}"
+      STATE_ENDPOINT: "{{ .StateBackend.Endpoint }}"
+      STATE_REGION: "{{ .StateBackend.Region }}"{{ if .StateBackend.AccessKeyID }}
+      STATE_ACCESS_KEY_ID: ((state_access_key_id))
+      STATE_SECRET_ACCESS_KEY: ((state_secret_access_key)){{ end }}{{ end }}{{ if .Encryption }}
+      ENCRYPTION: ((encryption)){{ if eq .Encryption.Type "passphrase" }}
+      ENCRYPTION_PASSPHRASE: ((encryption_passphrase)){{ else }}
+      ENCRYPTION_KMS_KEY_ID: ((encryption_kms_key_id)){{ end }}{{ end }}`
+
+// approveUpgradeJob is triggered by hand to let the self-update job deploy the newest release
+const approveUpgradeJob = `{{ if .UpgradeApproval }}
+- name: approve-upgrade
+  plan:
+  - get: control-tower-release{{ end }}`
+
+// selfUpdateGets are the inputs of the self-update job, which runs on every new release that has been
+// approved, and whenever the maintenance window opens
+const selfUpdateGets = `  - get: control-tower-release
+    trigger: true{{ if .UpgradeApproval }}
+    passed: [approve-upgrade]{{ end }}{{ if .MaintenanceWindow }}
+  - get: maintenance-window
+    trigger: true{{ end }}`
+
+// notificationParams are the task params the notify function reads
+const notificationParams = `{{ if .Notifications }}{{ if .Notifications.Slack }}
+      NOTIFICATION_SLACK_WEBHOOK: ((notify_slack_webhook)){{ end }}{{ if .Notifications.Webhook }}
+      NOTIFICATION_WEBHOOK: ((notify_webhook)){{ end }}{{ if .Notifications.EmailTo }}
+      NOTIFICATION_EMAIL_TO: "{{ .Notifications.EmailTo }}"
+      NOTIFICATION_EMAIL_FROM: "{{ .Notifications.EmailFrom }}"
+      NOTIFICATION_SMTP_URL: "{{ .Notifications.SMTPURL }}"
+      NOTIFICATION_SMTP_USERNAME: "{{ .Notifications.SMTPUsername }}"{{ if .Notifications.SMTPPassword }}
+      NOTIFICATION_SMTP_PASSWORD: ((notify_smtp_password)){{ end }}{{ end }}{{ end }}`
+
+// notifyFunction defines notify EVENT MESSAGE, which reports MESSAGE to every notification target
+// without tracing the secrets it uses. Failing to notify does not fail the job.
+const notifyFunction = `
+          notify() {
+            { restore_xtrace=$(set +o | grep xtrace); set +x; } 2>/dev/null
+            if [ -n "${NOTIFICATION_SLACK_WEBHOOK:-}" ]; then
+              jq -n --arg text "$2" '{text: $text}' |
+                curl -sS -X POST -H 'Content-Type: application/json' --data @- "$NOTIFICATION_SLACK_WEBHOOK" || true
+            fi
+            if [ -n "${NOTIFICATION_WEBHOOK:-}" ]; then
+              jq -n --arg deployment "$DEPLOYMENT" --arg event "$1" --arg version "$(cat tag)" --arg message "$2" \
+                '{deployment: $deployment, event: $event, version: $version, message: $message}' |
+                curl -sS -X POST -H 'Content-Type: application/json' --data @- "$NOTIFICATION_WEBHOOK" || true
+            fi
+            if [ -n "${NOTIFICATION_EMAIL_TO:-}" ]; then
+              smtp_args=(--ssl-reqd --url "$NOTIFICATION_SMTP_URL" --mail-from "$NOTIFICATION_EMAIL_FROM" --upload-file -)
+              for address in ${NOTIFICATION_EMAIL_TO//,/ }; do
+                smtp_args+=(--mail-rcpt "$address")
+              done
+              if [ -n "${NOTIFICATION_SMTP_USERNAME:-}" ]; then
+                smtp_args+=(--user "$NOTIFICATION_SMTP_USERNAME:${NOTIFICATION_SMTP_PASSWORD:-}")
+              fi
+              printf 'From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n' "$NOTIFICATION_EMAIL_FROM" "$NOTIFICATION_EMAIL_TO" "$2" "$2" |
+                curl -sS "${smtp_args[@]}" || true
+            fi
+            eval "$restore_xtrace"
+          }`
+
+// beforeSelfUpdate skips the upgrade outside the maintenance window, then reports that it has started
+// and reports if it fails
+const beforeSelfUpdate = `{{ if .MaintenanceWindow }}
+          if [ "$(cat tag)" = "{{ .ControlTowerVersion }}" ]; then
+            echo "control-tower $(cat tag) is already deployed"
+            exit 0
+          fi
+          window_days=",{{ .MaintenanceWindow.DayList }},"
+          now=$(TZ="{{ .MaintenanceWindow.Location }}" date +%H%M)
+          today=$(TZ="{{ .MaintenanceWindow.Location }}" date +%A){{ if .MaintenanceWindow.CrossesMidnight }}
+          yesterday=$(TZ="{{ .MaintenanceWindow.Location }}" date --date=yesterday +%A)
+          if { [[ $window_days == *",$today,"* ]] && [ "$now" -ge {{ .MaintenanceWindow.StartHHMM }} ]; } ||
+            { [[ $window_days == *",$yesterday,"* ]] && [ "$now" -lt {{ .MaintenanceWindow.StopHHMM }} ]; }; then{{ else }}
+          if [[ $window_days == *",$today,"* ]] && [ "$now" -ge {{ .MaintenanceWindow.StartHHMM }} ] && [ "$now" -lt {{ .MaintenanceWindow.StopHHMM }} ]; then{{ end }}
+            echo "Within the maintenance window"
+          else
+            echo "Outside the maintenance window, so control-tower $(cat tag) will be deployed when it next opens"
+            exit 0
+          fi{{ end }}{{ if .Notifications }}` + notifyFunction + `
+          notify started "Upgrading $DEPLOYMENT to control-tower $(cat tag)"
+          trap 'if [ $? -ne 0 ]; then notify failed "Upgrading $DEPLOYMENT to control-tower $(cat tag) failed"; fi' EXIT{{ end }}`
+
+// afterSelfUpdate reports that the upgrade succeeded
+const afterSelfUpdate = `{{ if .Notifications }}
+          notify succeeded "Upgraded $DEPLOYMENT to control-tower $(cat tag)"{{ end }}`
+
+// beforeCertRenewal reports if renewing the certificates fails. Checks that find nothing to renew
+// are not reported, as they run every day.
+const beforeCertRenewal = `{{ if .Notifications }}` + notifyFunction + `
+          trap 'if [ $? -ne 0 ]; then notify failed "Renewing the HTTPS certificates of $DEPLOYMENT failed"; fi' EXIT{{ end }}`
+
 const renewCertsDateCheck = `
           now_seconds=$(date +%s)
           not_after=$(echo | openssl s_client -connect {{.Domain}}:443 2>/dev/null | openssl x509 -noout -enddate)
@@ -48,3 +380,42 @@ const renewCertsDateCheck = `
             exit 0
           fi
 `
+
+// scheduleJobs returns the jobs that scale the workers down when working hours stop and back up
+// when they start. params are the IAAS specific task params, and setup is run before control-tower
+func scheduleJobs(params, setup string) string {
+	return `{{ if .Schedule }}` + scheduleJob("scale-down-workers", "working-hours-stop", params, setup, "pause --workers {{ .Schedule.IdleWorkerCount }}") +
+		scheduleJob("scale-up-workers", "working-hours-start", params, setup, "resume") + `{{ end }}`
+}
+
+func scheduleJob(name, trigger, params, setup, command string) string {
+	return `- name: ` + name + `
+  serial_groups: [cup]
+  serial: true
+  plan:
+  - get: control-tower-release
+    version: {tag: "{{ .ControlTowerVersion }}" }
+  - get: ` + trigger + `
+    trigger: true
+  - task: ` + strings.Fields(command)[0] + `
+    params:
+` + params + `
+    config:
+      platform: linux
+      image_resource:
+        type: docker-image
+        source:
+          repository: engineerbetter/pcf-ops
+      inputs:
+      - name: control-tower-release
+      run:
+        path: bash
+        args:
+        - -c
+        - |
+          cd control-tower-release` + setup + `
+          set -eux
+          chmod +x control-tower-linux-amd64
+          ./control-tower-linux-amd64 ` + command + ` $DEPLOYMENT
+`
+}
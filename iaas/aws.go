@@ -83,8 +83,21 @@ func (a *AWSProvider) Zone(requestedZone, workerSize string) string {
 	return fmt.Sprintf("%sa", a.Region())
 }
 
-// Attr returns an attribute of the provider
+// Attr returns an attribute of the provider. The credentials of the session the provider was created
+// with are available as access_key_id, secret_access_key and session_token.
 func (a *AWSProvider) Attr(name string) (string, error) {
+	switch name {
+	case "access_key_id", "secret_access_key", "session_token":
+		creds, err := a.sess.Config.Credentials.Get()
+		if err != nil {
+			return "", fmt.Errorf("iaas:aws: failed to read credentials: [%v]", err)
+		}
+		return map[string]string{
+			"access_key_id":     creds.AccessKeyID,
+			"secret_access_key": creds.SecretAccessKey,
+			"session_token":     creds.SessionToken,
+		}[name], nil
+	}
 	return "", nil
 }
 
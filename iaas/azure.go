@@ -0,0 +1,407 @@
+package iaas
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	// PostgreSQL driver required at runtime
+	_ "github.com/lib/pq"
+)
+
+// AzureProvider is the concrete implementation of the Azure Provider
+type AzureProvider struct {
+	ctx       context.Context
+	storage   AzureStorageClient
+	resources AzureResourceClient
+	region    string
+	attrs     map[string]string
+}
+
+// AzureOption configures an AzureProvider
+type AzureOption func(*AzureProvider) error
+
+// AzureStorageClient is the subset of blob storage operations used by the AzureProvider.
+// Buckets map onto blob containers within a single storage account.
+//
+//counterfeiter:generate . AzureStorageClient
+type AzureStorageClient interface {
+	ContainerExists(name string) (bool, error)
+	CreateContainer(name string) error
+	DeleteContainer(name string) error
+	BlobExists(container, path string) (bool, error)
+	GetBlob(container, path string) ([]byte, error)
+	PutBlob(container, path string, contents []byte) error
+}
+
+// AzureResourceClient is the subset of Azure Resource Manager operations used by the AzureProvider
+//
+//counterfeiter:generate . AzureResourceClient
+type AzureResourceClient interface {
+	ListDNSZones() ([]AzureDNSZone, error)
+	ListVMs(resourceGroup string) ([]string, error)
+	DeleteVM(resourceGroup, name string) error
+	SecurityRules(securityGroupID string) ([]AzureSecurityRule, error)
+}
+
+// AzureDNSZone is an Azure DNS zone
+type AzureDNSZone struct {
+	ID   string
+	Name string
+}
+
+// AzureSecurityRule is a rule within an Azure network security group
+type AzureSecurityRule struct {
+	Access                string
+	Direction             string
+	SourceAddressPrefixes []string
+	DestinationPortRanges []string
+}
+
+// ErrAzureBlobNotFound is returned when the requested blob does not exist
+var ErrAzureBlobNotFound = errors.New("iaas:azure: blob not found")
+
+// AzureClients returns an option function with the storage and resource manager clients initialised
+func AzureClients() AzureOption {
+	return func(a *AzureProvider) error {
+		a.storage = newAzureStorageClient(a.ctx, a.attrs)
+		a.resources = newAzureResourceClient(a.ctx, a.attrs)
+		return nil
+	}
+}
+
+// azureCredentialEnvVars maps provider attributes onto the environment variables they are read from
+var azureCredentialEnvVars = map[string]string{
+	"subscription_id":        "AZURE_SUBSCRIPTION_ID",
+	"tenant_id":              "AZURE_TENANT_ID",
+	"client_id":              "AZURE_CLIENT_ID",
+	"client_secret":          "AZURE_CLIENT_SECRET",
+	"storage_account":        "AZURE_STORAGE_ACCOUNT",
+	"storage_resource_group": "AZURE_STORAGE_RESOURCE_GROUP",
+}
+
+func newAzure(region string, ops ...AzureOption) (Provider, error) {
+	attrs, err := getAzureCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &AzureProvider{
+		ctx:    context.Background(),
+		region: region,
+		attrs:  attrs,
+	}
+	for _, op := range ops {
+		if err := op(a); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func getAzureCredentials() (map[string]string, error) {
+	attrs := make(map[string]string)
+	for attr, envVar := range azureCredentialEnvVars {
+		value, exists := os.LookupEnv(envVar)
+		if !exists || value == "" {
+			return nil, fmt.Errorf("%s is not set", envVar)
+		}
+		attrs[attr] = value
+	}
+	return attrs, nil
+}
+
+// AzureDBSizes maps user set size to Azure Database for PostgreSQL SKUs
+var AzureDBSizes = map[string]string{
+	"small":   "B_Gen5_2",
+	"medium":  "GP_Gen5_2",
+	"large":   "GP_Gen5_4",
+	"xlarge":  "GP_Gen5_8",
+	"2xlarge": "GP_Gen5_16",
+	"4xlarge": "GP_Gen5_32",
+}
+
+// DBType gets the correct Azure Database for PostgreSQL SKU
+func (a *AzureProvider) DBType(name string) string {
+	return AzureDBSizes[name]
+}
+
+// Attr returns Azure specific attribute
+func (a *AzureProvider) Attr(key string) (string, error) {
+	v, ok := a.attrs[key]
+	if !ok {
+		return "", fmt.Errorf("iaas:azure: key %s not found", key)
+	}
+	return v, nil
+}
+
+// Choose for the consumer the appropriate output based on the provider
+func (a *AzureProvider) Choose(c Choice) interface{} {
+	return c.Azure
+}
+
+// IAAS returns the iaas to operate against
+func (a *AzureProvider) IAAS() Name {
+	return Azure
+}
+
+// Region returns the region to operate against
+func (a *AzureProvider) Region() string {
+	return a.region
+}
+
+// Zone returns the requested availability zone, defaulting to the first zone of the region
+func (a *AzureProvider) Zone(requestedZone, workerSizeNotUsedInAzure string) string {
+	if requestedZone != "" {
+		return requestedZone
+	}
+	return "1"
+}
+
+// BucketExists checks whether the named blob container exists
+func (a *AzureProvider) BucketExists(name string) (bool, error) {
+	return a.storage.ContainerExists(name)
+}
+
+// CreateBucket creates a blob container in the configured storage account
+func (a *AzureProvider) CreateBucket(name string) error {
+	return a.storage.CreateContainer(name)
+}
+
+// DeleteVersionedBucket deletes a blob container, along with all blobs and their versions
+func (a *AzureProvider) DeleteVersionedBucket(name string) error {
+	if err := a.storage.DeleteContainer(name); err != nil {
+		return fmt.Errorf("error deleting container [%v]: [%v]", name, err)
+	}
+	return nil
+}
+
+// HasFile returns true if the specified blob exists
+func (a *AzureProvider) HasFile(bucket, path string) (bool, error) {
+	return a.storage.BlobExists(bucket, path)
+}
+
+// LoadFile loads a blob from a container
+func (a *AzureProvider) LoadFile(bucket, path string) ([]byte, error) {
+	return a.storage.GetBlob(bucket, path)
+}
+
+// WriteFile writes the specified blob to a container
+func (a *AzureProvider) WriteFile(bucket, path string, contents []byte) error {
+	if err := a.storage.PutBlob(bucket, path, contents); err != nil {
+		return fmt.Errorf("failed to write %s to container: [%s]", path, err)
+	}
+	return nil
+}
+
+// EnsureFileExists checks for the named blob and creates it if it doesn't exist
+// Second argument is true if new file was created
+func (a *AzureProvider) EnsureFileExists(bucket, path string, defaultContents []byte) ([]byte, bool, error) {
+	contents, err := a.LoadFile(bucket, path)
+
+	if err == nil {
+		return contents, false, nil
+	}
+
+	if err != ErrAzureBlobNotFound {
+		return nil, false, err
+	}
+
+	err = a.WriteFile(bucket, path, defaultContents)
+	if err != nil {
+		return nil, false, err
+	}
+	return defaultContents, true, nil
+}
+
+// DeleteVolumes is a placeholder function used with AWS deployments
+func (a *AzureProvider) DeleteVolumes(volumesToDelete []string, deleteVolume func(ec2Client IEC2, volumeID *string) error) error {
+	return errors.New("DeleteVolumes Not Implemented Yet")
+}
+
+// DeleteVMsInVPC is a placeholder function used with AWS deployments
+func (a *AzureProvider) DeleteVMsInVPC(vpcID string) ([]string, error) {
+	return []string{}, nil
+}
+
+// DeleteVMsInDeployment deletes all VMs in the deployment's resource group and waits for them to go away
+func (a *AzureProvider) DeleteVMsInDeployment(zone, project, deployment string) error {
+	vms, err := a.resources.ListVMs(deployment)
+	if err != nil {
+		return err
+	}
+
+	for _, vm := range vms {
+		fmt.Printf("Deleting instance %+v\n", vm)
+		if err := a.resources.DeleteVM(deployment, vm); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now().UTC()
+	for {
+		vms, err := a.resources.ListVMs(deployment)
+		if err != nil {
+			return err
+		}
+		if len(vms) == 0 {
+			return nil
+		}
+		for _, vm := range vms {
+			fmt.Printf("Waiting for instance %s to be deleted\n", vm)
+		}
+		if time.Since(start) > time.Minute*10 {
+			return fmt.Errorf("Instances not deleted after 10 minutes")
+		}
+		time.Sleep(time.Second * 10)
+	}
+}
+
+// CheckForWhitelistedIP checks if the specified IP is allowed into the director by the network security group
+func (a *AzureProvider) CheckForWhitelistedIP(ip, securityGroupID string) (bool, error) {
+	parsedIP := net.ParseIP(ip)
+
+	rules, err := a.resources.SecurityRules(securityGroupID)
+	if err != nil {
+		return false, err
+	}
+
+	port22, port6868, port25555 := false, false, false
+	for _, rule := range rules {
+		if !strings.EqualFold(rule.Access, "Allow") || !strings.EqualFold(rule.Direction, "Inbound") {
+			continue
+		}
+		matched, err := azurePrefixesContain(rule.SourceAddressPrefixes, parsedIP)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			continue
+		}
+		for _, portRange := range rule.DestinationPortRanges {
+			from, to, err := parseAzurePortRange(portRange)
+			if err != nil {
+				return false, err
+			}
+			port22 = port22 || between(22, from, to)
+			port6868 = port6868 || between(6868, from, to)
+			port25555 = port25555 || between(25555, from, to)
+		}
+	}
+
+	return port22 && port6868 && port25555, nil
+}
+
+func azurePrefixesContain(prefixes []string, ip net.IP) (bool, error) {
+	for _, prefix := range prefixes {
+		switch prefix {
+		case "*", "Internet", "0.0.0.0/0":
+			return true, nil
+		}
+		if !strings.Contains(prefix, "/") {
+			if net.ParseIP(prefix).Equal(ip) {
+				return true, nil
+			}
+			continue
+		}
+		_, parsedCIDR, err := net.ParseCIDR(prefix)
+		if err != nil {
+			return false, err
+		}
+		if parsedCIDR.Contains(ip) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func parseAzurePortRange(portRange string) (int64, int64, error) {
+	if portRange == "*" {
+		return 0, 65535, nil
+	}
+	bounds := strings.SplitN(portRange, "-", 2)
+	from, err := strconv.ParseInt(bounds[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot parse port range [%s]: [%v]", portRange, err)
+	}
+	if len(bounds) == 1 {
+		return from, from, nil
+	}
+	to, err := strconv.ParseInt(bounds[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot parse port range [%s]: [%v]", portRange, err)
+	}
+	return from, to, nil
+}
+
+// FindLongestMatchingHostedZone finds the longest Azure DNS zone that matches the given domain
+// and returns its name and resource ID
+func (a *AzureProvider) FindLongestMatchingHostedZone(domain string) (string, string, error) {
+	zones, err := a.resources.ListDNSZones()
+	if err != nil {
+		return "", "", err
+	}
+
+	var zoneName, zoneID string
+	for _, zone := range zones {
+		name := strings.TrimRight(zone.Name, ".")
+		if strings.HasSuffix(domain, name) && len(name) > len(zoneName) {
+			zoneName = name
+			zoneID = zone.ID
+		}
+	}
+
+	if zoneName == "" {
+		return "", "", fmt.Errorf("dns zone for domain '%s' was not found in Azure DNS", domain)
+	}
+
+	return zoneName, zoneID, nil
+}
+
+// AzureResourceGroup extracts the resource group name from an Azure resource ID
+func AzureResourceGroup(resourceID string) string {
+	parts := strings.Split(resourceID, "/")
+	for i := 0; i < len(parts)-1; i++ {
+		if strings.EqualFold(parts[i], "resourceGroups") {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// AzureDBHost returns the hostname of the named Azure Database for PostgreSQL server
+func AzureDBHost(name string) string {
+	return fmt.Sprintf("%s.postgres.database.azure.com", name)
+}
+
+// AzureDBUsername returns the login Azure Database for PostgreSQL expects for a user on the named server
+func AzureDBUsername(username, name string) string {
+	return fmt.Sprintf("%s@%s", username, name)
+}
+
+// CreateDatabases creates the databases Concourse needs on the named server
+func (a *AzureProvider) CreateDatabases(name, username, password string) error {
+	conn := fmt.Sprintf("host=%s port=5432 user=%s dbname=postgres password=%s sslmode=require", AzureDBHost(name), AzureDBUsername(username, name), password)
+
+	azureDB, err := sql.Open("postgres", conn)
+	if err != nil {
+		return err
+	}
+	defer azureDB.Close()
+	dbNames := []string{"concourse_atc", "uaa", "credhub"}
+	for _, dbName := range dbNames {
+		_, err := azureDB.Exec("CREATE DATABASE " + dbName)
+		if err != nil && !strings.Contains(err.Error(),
+			fmt.Sprintf(`pq: database "%s" already exists`, dbName)) {
+			return err
+		}
+	}
+	return nil
+}
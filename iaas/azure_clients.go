@@ -0,0 +1,306 @@
+package iaas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	azureStorageAPIVersion = "2020-10-02"
+	azureComputeAPIVersion = "2021-03-01"
+	azureNetworkAPIVersion = "2020-11-01"
+	azureDNSAPIVersion     = "2018-05-01"
+	azureManagementURL     = "https://management.azure.com"
+)
+
+func azureHTTPClient(ctx context.Context, attrs map[string]string, scope string) *http.Client {
+	conf := clientcredentials.Config{
+		ClientID:     attrs["client_id"],
+		ClientSecret: attrs["client_secret"],
+		TokenURL:     fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", attrs["tenant_id"]),
+		Scopes:       []string{scope},
+	}
+	return conf.Client(ctx)
+}
+
+type azureStorageClient struct {
+	http    *http.Client
+	baseURL string
+}
+
+func newAzureStorageClient(ctx context.Context, attrs map[string]string) AzureStorageClient {
+	return &azureStorageClient{
+		http:    azureHTTPClient(ctx, attrs, "https://storage.azure.com/.default"),
+		baseURL: fmt.Sprintf("https://%s.blob.core.windows.net", attrs["storage_account"]),
+	}
+}
+
+func (s *azureStorageClient) do(method, path string, query url.Values, body []byte, headers map[string]string) (*http.Response, error) {
+	u := s.baseURL + "/" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", azureStorageAPIVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return s.http.Do(req)
+}
+
+var containerQuery = url.Values{"restype": []string{"container"}}
+
+func (s *azureStorageClient) ContainerExists(name string) (bool, error) {
+	resp, err := s.do(http.MethodGet, name, containerQuery, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, azureResponseError(resp)
+}
+
+func (s *azureStorageClient) CreateContainer(name string) error {
+	resp, err := s.do(http.MethodPut, name, containerQuery, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return azureResponseError(resp)
+	}
+	return nil
+}
+
+func (s *azureStorageClient) DeleteContainer(name string) error {
+	resp, err := s.do(http.MethodDelete, name, containerQuery, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return azureResponseError(resp)
+	}
+	return nil
+}
+
+func (s *azureStorageClient) BlobExists(container, path string) (bool, error) {
+	resp, err := s.do(http.MethodHead, container+"/"+path, nil, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, azureResponseError(resp)
+}
+
+func (s *azureStorageClient) GetBlob(container, path string) ([]byte, error) {
+	resp, err := s.do(http.MethodGet, container+"/"+path, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return ioutil.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrAzureBlobNotFound
+	}
+	return nil, azureResponseError(resp)
+}
+
+func (s *azureStorageClient) PutBlob(container, path string, contents []byte) error {
+	resp, err := s.do(http.MethodPut, container+"/"+path, nil, contents, map[string]string{
+		"x-ms-blob-type": "BlockBlob",
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return azureResponseError(resp)
+	}
+	return nil
+}
+
+type azureResourceClient struct {
+	http           *http.Client
+	subscriptionID string
+}
+
+func newAzureResourceClient(ctx context.Context, attrs map[string]string) AzureResourceClient {
+	return &azureResourceClient{
+		http:           azureHTTPClient(ctx, attrs, azureManagementURL+"/.default"),
+		subscriptionID: attrs["subscription_id"],
+	}
+}
+
+// get follows nextLink pagination, calling page with the body of each response
+func (r *azureResourceClient) get(path, apiVersion string, page func([]byte) (string, error)) error {
+	next := fmt.Sprintf("%s%s?api-version=%s", azureManagementURL, path, apiVersion)
+	for next != "" {
+		resp, err := r.http.Get(next)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = azureResponseError(resp)
+			resp.Body.Close()
+			return err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		next, err = page(body)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *azureResourceClient) ListDNSZones() ([]AzureDNSZone, error) {
+	var zones []AzureDNSZone
+	err := r.get(fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Network/dnszones", r.subscriptionID), azureDNSAPIVersion, func(body []byte) (string, error) {
+		var list struct {
+			Value    []AzureDNSZone `json:"value"`
+			NextLink string         `json:"nextLink"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return "", err
+		}
+		zones = append(zones, list.Value...)
+		return list.NextLink, nil
+	})
+	return zones, err
+}
+
+func (r *azureResourceClient) vmsPath(resourceGroup string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/virtualMachines", r.subscriptionID, resourceGroup)
+}
+
+func (r *azureResourceClient) ListVMs(resourceGroup string) ([]string, error) {
+	var names []string
+	err := r.get(r.vmsPath(resourceGroup), azureComputeAPIVersion, func(body []byte) (string, error) {
+		var list struct {
+			Value []struct {
+				Name string `json:"name"`
+			} `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return "", err
+		}
+		for _, vm := range list.Value {
+			names = append(names, vm.Name)
+		}
+		return list.NextLink, nil
+	})
+	if rerr, ok := err.(*azureError); ok && rerr.StatusCode == http.StatusNotFound {
+		return []string{}, nil
+	}
+	return names, err
+}
+
+func (r *azureResourceClient) DeleteVM(resourceGroup, name string) error {
+	u := fmt.Sprintf("%s%s/%s?api-version=%s", azureManagementURL, r.vmsPath(resourceGroup), name, azureComputeAPIVersion)
+	req, err := http.NewRequest(http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return nil
+	}
+	return azureResponseError(resp)
+}
+
+func (r *azureResourceClient) SecurityRules(securityGroupID string) ([]AzureSecurityRule, error) {
+	var rules []AzureSecurityRule
+	err := r.get(securityGroupID, azureNetworkAPIVersion, func(body []byte) (string, error) {
+		var nsg struct {
+			Properties struct {
+				SecurityRules []struct {
+					Properties struct {
+						Access                string   `json:"access"`
+						Direction             string   `json:"direction"`
+						SourceAddressPrefix   string   `json:"sourceAddressPrefix"`
+						SourceAddressPrefixes []string `json:"sourceAddressPrefixes"`
+						DestinationPortRange  string   `json:"destinationPortRange"`
+						DestinationPortRanges []string `json:"destinationPortRanges"`
+					} `json:"properties"`
+				} `json:"securityRules"`
+			} `json:"properties"`
+		}
+		if err := json.Unmarshal(body, &nsg); err != nil {
+			return "", err
+		}
+		for _, rule := range nsg.Properties.SecurityRules {
+			p := rule.Properties
+			sources := p.SourceAddressPrefixes
+			if p.SourceAddressPrefix != "" {
+				sources = append(sources, p.SourceAddressPrefix)
+			}
+			ports := p.DestinationPortRanges
+			if p.DestinationPortRange != "" {
+				ports = append(ports, p.DestinationPortRange)
+			}
+			rules = append(rules, AzureSecurityRule{
+				Access:                p.Access,
+				Direction:             p.Direction,
+				SourceAddressPrefixes: sources,
+				DestinationPortRanges: ports,
+			})
+		}
+		return "", nil
+	})
+	return rules, err
+}
+
+type azureError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *azureError) Error() string {
+	return fmt.Sprintf("azure request failed with status %d: [%s]", e.StatusCode, e.Body)
+}
+
+func azureResponseError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(resp.Body)
+	return &azureError{StatusCode: resp.StatusCode, Body: string(body)}
+}
@@ -0,0 +1,8 @@
+package iaas
+
+import "golang.org/x/net/context"
+
+// NewAzureWithClients returns an AzureProvider backed by the given clients, for use in tests
+func NewAzureWithClients(region string, storage AzureStorageClient, resources AzureResourceClient, attrs map[string]string) *AzureProvider {
+	return &AzureProvider{context.Background(), storage, resources, region, attrs}
+}
@@ -0,0 +1,190 @@
+package iaas_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/EngineerBetter/control-tower/iaas"
+	"github.com/EngineerBetter/control-tower/iaas/iaasfakes"
+)
+
+func TestAzureProvider_FindLongestMatchingHostedZone(t *testing.T) {
+	zones := []iaas.AzureDNSZone{
+		{ID: "/subscriptions/sub/resourceGroups/dns/providers/Microsoft.Network/dnszones/example.com", Name: "example.com"},
+		{ID: "/subscriptions/sub/resourceGroups/dns/providers/Microsoft.Network/dnszones/ci.example.com", Name: "ci.example.com"},
+	}
+	tests := []struct {
+		name     string
+		domain   string
+		wantName string
+		wantID   string
+		wantErr  bool
+	}{
+		{
+			name:     "picks the longest matching zone",
+			domain:   "concourse.ci.example.com",
+			wantName: "ci.example.com",
+			wantID:   zones[1].ID,
+		},
+		{
+			name:     "matches a shorter zone",
+			domain:   "concourse.example.com",
+			wantName: "example.com",
+			wantID:   zones[0].ID,
+		},
+		{
+			name:    "fails when no zone matches",
+			domain:  "concourse.example.org",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources := &iaasfakes.FakeAzureResourceClient{}
+			resources.ListDNSZonesReturns(zones, nil)
+			a := iaas.NewAzureWithClients("westeurope", nil, resources, nil)
+
+			gotName, gotID, err := a.FindLongestMatchingHostedZone(tt.domain)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AzureProvider.FindLongestMatchingHostedZone() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotName != tt.wantName || gotID != tt.wantID {
+				t.Errorf("AzureProvider.FindLongestMatchingHostedZone() = %v, %v, want %v, %v", gotName, gotID, tt.wantName, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestAzureProvider_CheckForWhitelistedIP(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []iaas.AzureSecurityRule
+		want  bool
+	}{
+		{
+			name: "all director ports are open to the IP",
+			rules: []iaas.AzureSecurityRule{
+				{Access: "Allow", Direction: "Inbound", SourceAddressPrefixes: []string{"1.2.3.4/32"}, DestinationPortRanges: []string{"22", "6868", "25555"}},
+			},
+			want: true,
+		},
+		{
+			name: "ports are open via a range",
+			rules: []iaas.AzureSecurityRule{
+				{Access: "Allow", Direction: "Inbound", SourceAddressPrefixes: []string{"1.2.3.0/24"}, DestinationPortRanges: []string{"1-30000"}},
+			},
+			want: true,
+		},
+		{
+			name: "a port is missing",
+			rules: []iaas.AzureSecurityRule{
+				{Access: "Allow", Direction: "Inbound", SourceAddressPrefixes: []string{"1.2.3.4"}, DestinationPortRanges: []string{"22", "6868"}},
+			},
+			want: false,
+		},
+		{
+			name: "the IP is not in the source range",
+			rules: []iaas.AzureSecurityRule{
+				{Access: "Allow", Direction: "Inbound", SourceAddressPrefixes: []string{"5.6.7.8/32"}, DestinationPortRanges: []string{"*"}},
+			},
+			want: false,
+		},
+		{
+			name: "deny rules are ignored",
+			rules: []iaas.AzureSecurityRule{
+				{Access: "Deny", Direction: "Inbound", SourceAddressPrefixes: []string{"*"}, DestinationPortRanges: []string{"*"}},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources := &iaasfakes.FakeAzureResourceClient{}
+			resources.SecurityRulesReturns(tt.rules, nil)
+			a := iaas.NewAzureWithClients("westeurope", nil, resources, nil)
+
+			got, err := a.CheckForWhitelistedIP("1.2.3.4", "nsg-id")
+			if err != nil {
+				t.Errorf("AzureProvider.CheckForWhitelistedIP() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("AzureProvider.CheckForWhitelistedIP() = %v, want %v", got, tt.want)
+			}
+			if resources.SecurityRulesArgsForCall(0) != "nsg-id" {
+				t.Errorf("AzureProvider.CheckForWhitelistedIP() looked up %v, want nsg-id", resources.SecurityRulesArgsForCall(0))
+			}
+		})
+	}
+}
+
+func TestAzureProvider_DeleteVMsInDeployment(t *testing.T) {
+	resources := &iaasfakes.FakeAzureResourceClient{}
+	resources.ListVMsReturnsOnCall(0, []string{"vm-1", "vm-2"}, nil)
+	resources.ListVMsReturnsOnCall(1, []string{}, nil)
+	a := iaas.NewAzureWithClients("westeurope", nil, resources, nil)
+
+	if err := a.DeleteVMsInDeployment("1", "", "control-tower-ci"); err != nil {
+		t.Fatalf("AzureProvider.DeleteVMsInDeployment() error = %v", err)
+	}
+	if resources.DeleteVMCallCount() != 2 {
+		t.Fatalf("AzureProvider.DeleteVMsInDeployment() deleted %d VMs, want 2", resources.DeleteVMCallCount())
+	}
+	for i, want := range []string{"vm-1", "vm-2"} {
+		group, name := resources.DeleteVMArgsForCall(i)
+		if group != "control-tower-ci" || name != want {
+			t.Errorf("AzureProvider.DeleteVMsInDeployment() deleted %s/%s, want control-tower-ci/%s", group, name, want)
+		}
+	}
+}
+
+func TestAzureProvider_EnsureFileExists(t *testing.T) {
+	tests := []struct {
+		name        string
+		getErr      error
+		wantCreated bool
+		wantErr     bool
+	}{
+		{
+			name:        "returns existing contents",
+			wantCreated: false,
+		},
+		{
+			name:        "writes default contents when the blob is missing",
+			getErr:      iaas.ErrAzureBlobNotFound,
+			wantCreated: true,
+		},
+		{
+			name:    "returns other errors",
+			getErr:  errors.New("boom"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &iaasfakes.FakeAzureStorageClient{}
+			storage.GetBlobReturns([]byte("existing"), tt.getErr)
+			a := iaas.NewAzureWithClients("westeurope", storage, nil, nil)
+
+			_, created, err := a.EnsureFileExists("bucket", "file", []byte("default"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AzureProvider.EnsureFileExists() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if created != tt.wantCreated {
+				t.Errorf("AzureProvider.EnsureFileExists() created = %v, want %v", created, tt.wantCreated)
+			}
+			if tt.wantCreated && storage.PutBlobCallCount() != 1 {
+				t.Errorf("AzureProvider.EnsureFileExists() did not write the default contents")
+			}
+		})
+	}
+}
+
+func TestAzureResourceGroup(t *testing.T) {
+	got := iaas.AzureResourceGroup("/subscriptions/sub/resourceGroups/dns-rg/providers/Microsoft.Network/dnszones/example.com")
+	if got != "dns-rg" {
+		t.Errorf("AzureResourceGroup() = %v, want dns-rg", got)
+	}
+}
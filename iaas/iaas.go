@@ -10,8 +10,9 @@ import (
 // Choice is an interface which can help on the abstraction of provider data
 // by defining any kind of data mapped against the available providers
 type Choice struct {
-	AWS interface{}
-	GCP interface{}
+	AWS   interface{}
+	GCP   interface{}
+	Azure interface{}
 }
 
 type Name int
@@ -20,12 +21,14 @@ const (
 	Unknown = iota
 	AWS
 	GCP
+	Azure
 )
 
 var names = []string{
 	"Unknown",
 	"AWS",
 	"GCP",
+	"AZURE",
 }
 
 func (n Name) String() string {
@@ -80,6 +83,11 @@ func New(iaasName Name, region string) (Provider, error) {
 			region = "europe-west1"
 		}
 		return newGCP(region, GCPStorage())
+	case Azure:
+		if region == "" {
+			region = "westeurope"
+		}
+		return newAzure(region, AzureClients())
 	}
 
 	return nil, fmt.Errorf("IAAS not supported: [%s]", iaasName)
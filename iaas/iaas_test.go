@@ -55,6 +55,20 @@ func TestNew(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "return azure provider",
+			args: args{
+				iaas:   iaas.Azure,
+				region: "aRegion",
+			},
+			want:    iaas.Azure,
+			wantErr: false,
+			setup: func(t *testing.T) string {
+				testsupport.SetupFakeCredsForAzureProvider(t)
+				return ""
+			},
+			cleanup: func(t *testing.T, s string) {},
+		},
 		{
 			name: "does not care about case",
 			args: args{
@@ -109,6 +123,12 @@ func TestAssosiate(t *testing.T) {
 			want:    iaas.AWS,
 			wantErr: false,
 		},
+		{
+			name:    "get the Azure Name successfully case insensitive",
+			arg:     "azure",
+			want:    iaas.Azure,
+			wantErr: false,
+		},
 		{
 			name:    "fail on unknown iaas name",
 			arg:     "aProvider",
@@ -0,0 +1,341 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package iaasfakes
+
+import (
+	"sync"
+
+	"github.com/EngineerBetter/control-tower/iaas"
+)
+
+type FakeAzureResourceClient struct {
+	DeleteVMStub        func(string, string) error
+	deleteVMMutex       sync.RWMutex
+	deleteVMArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	deleteVMReturns struct {
+		result1 error
+	}
+	deleteVMReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ListDNSZonesStub        func() ([]iaas.AzureDNSZone, error)
+	listDNSZonesMutex       sync.RWMutex
+	listDNSZonesArgsForCall []struct {
+	}
+	listDNSZonesReturns struct {
+		result1 []iaas.AzureDNSZone
+		result2 error
+	}
+	listDNSZonesReturnsOnCall map[int]struct {
+		result1 []iaas.AzureDNSZone
+		result2 error
+	}
+	ListVMsStub        func(string) ([]string, error)
+	listVMsMutex       sync.RWMutex
+	listVMsArgsForCall []struct {
+		arg1 string
+	}
+	listVMsReturns struct {
+		result1 []string
+		result2 error
+	}
+	listVMsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	SecurityRulesStub        func(string) ([]iaas.AzureSecurityRule, error)
+	securityRulesMutex       sync.RWMutex
+	securityRulesArgsForCall []struct {
+		arg1 string
+	}
+	securityRulesReturns struct {
+		result1 []iaas.AzureSecurityRule
+		result2 error
+	}
+	securityRulesReturnsOnCall map[int]struct {
+		result1 []iaas.AzureSecurityRule
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeAzureResourceClient) DeleteVM(arg1 string, arg2 string) error {
+	fake.deleteVMMutex.Lock()
+	ret, specificReturn := fake.deleteVMReturnsOnCall[len(fake.deleteVMArgsForCall)]
+	fake.deleteVMArgsForCall = append(fake.deleteVMArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteVMStub
+	fakeReturns := fake.deleteVMReturns
+	fake.recordInvocation("DeleteVM", []interface{}{arg1, arg2})
+	fake.deleteVMMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAzureResourceClient) DeleteVMCallCount() int {
+	fake.deleteVMMutex.RLock()
+	defer fake.deleteVMMutex.RUnlock()
+	return len(fake.deleteVMArgsForCall)
+}
+
+func (fake *FakeAzureResourceClient) DeleteVMCalls(stub func(string, string) error) {
+	fake.deleteVMMutex.Lock()
+	defer fake.deleteVMMutex.Unlock()
+	fake.DeleteVMStub = stub
+}
+
+func (fake *FakeAzureResourceClient) DeleteVMArgsForCall(i int) (string, string) {
+	fake.deleteVMMutex.RLock()
+	defer fake.deleteVMMutex.RUnlock()
+	argsForCall := fake.deleteVMArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAzureResourceClient) DeleteVMReturns(result1 error) {
+	fake.deleteVMMutex.Lock()
+	defer fake.deleteVMMutex.Unlock()
+	fake.DeleteVMStub = nil
+	fake.deleteVMReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureResourceClient) DeleteVMReturnsOnCall(i int, result1 error) {
+	fake.deleteVMMutex.Lock()
+	defer fake.deleteVMMutex.Unlock()
+	fake.DeleteVMStub = nil
+	if fake.deleteVMReturnsOnCall == nil {
+		fake.deleteVMReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteVMReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureResourceClient) ListDNSZones() ([]iaas.AzureDNSZone, error) {
+	fake.listDNSZonesMutex.Lock()
+	ret, specificReturn := fake.listDNSZonesReturnsOnCall[len(fake.listDNSZonesArgsForCall)]
+	fake.listDNSZonesArgsForCall = append(fake.listDNSZonesArgsForCall, struct {
+	}{})
+	stub := fake.ListDNSZonesStub
+	fakeReturns := fake.listDNSZonesReturns
+	fake.recordInvocation("ListDNSZones", []interface{}{})
+	fake.listDNSZonesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureResourceClient) ListDNSZonesCallCount() int {
+	fake.listDNSZonesMutex.RLock()
+	defer fake.listDNSZonesMutex.RUnlock()
+	return len(fake.listDNSZonesArgsForCall)
+}
+
+func (fake *FakeAzureResourceClient) ListDNSZonesCalls(stub func() ([]iaas.AzureDNSZone, error)) {
+	fake.listDNSZonesMutex.Lock()
+	defer fake.listDNSZonesMutex.Unlock()
+	fake.ListDNSZonesStub = stub
+}
+
+func (fake *FakeAzureResourceClient) ListDNSZonesReturns(result1 []iaas.AzureDNSZone, result2 error) {
+	fake.listDNSZonesMutex.Lock()
+	defer fake.listDNSZonesMutex.Unlock()
+	fake.ListDNSZonesStub = nil
+	fake.listDNSZonesReturns = struct {
+		result1 []iaas.AzureDNSZone
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureResourceClient) ListDNSZonesReturnsOnCall(i int, result1 []iaas.AzureDNSZone, result2 error) {
+	fake.listDNSZonesMutex.Lock()
+	defer fake.listDNSZonesMutex.Unlock()
+	fake.ListDNSZonesStub = nil
+	if fake.listDNSZonesReturnsOnCall == nil {
+		fake.listDNSZonesReturnsOnCall = make(map[int]struct {
+			result1 []iaas.AzureDNSZone
+			result2 error
+		})
+	}
+	fake.listDNSZonesReturnsOnCall[i] = struct {
+		result1 []iaas.AzureDNSZone
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureResourceClient) ListVMs(arg1 string) ([]string, error) {
+	fake.listVMsMutex.Lock()
+	ret, specificReturn := fake.listVMsReturnsOnCall[len(fake.listVMsArgsForCall)]
+	fake.listVMsArgsForCall = append(fake.listVMsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ListVMsStub
+	fakeReturns := fake.listVMsReturns
+	fake.recordInvocation("ListVMs", []interface{}{arg1})
+	fake.listVMsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureResourceClient) ListVMsCallCount() int {
+	fake.listVMsMutex.RLock()
+	defer fake.listVMsMutex.RUnlock()
+	return len(fake.listVMsArgsForCall)
+}
+
+func (fake *FakeAzureResourceClient) ListVMsCalls(stub func(string) ([]string, error)) {
+	fake.listVMsMutex.Lock()
+	defer fake.listVMsMutex.Unlock()
+	fake.ListVMsStub = stub
+}
+
+func (fake *FakeAzureResourceClient) ListVMsArgsForCall(i int) string {
+	fake.listVMsMutex.RLock()
+	defer fake.listVMsMutex.RUnlock()
+	argsForCall := fake.listVMsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAzureResourceClient) ListVMsReturns(result1 []string, result2 error) {
+	fake.listVMsMutex.Lock()
+	defer fake.listVMsMutex.Unlock()
+	fake.ListVMsStub = nil
+	fake.listVMsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureResourceClient) ListVMsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.listVMsMutex.Lock()
+	defer fake.listVMsMutex.Unlock()
+	fake.ListVMsStub = nil
+	if fake.listVMsReturnsOnCall == nil {
+		fake.listVMsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.listVMsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureResourceClient) SecurityRules(arg1 string) ([]iaas.AzureSecurityRule, error) {
+	fake.securityRulesMutex.Lock()
+	ret, specificReturn := fake.securityRulesReturnsOnCall[len(fake.securityRulesArgsForCall)]
+	fake.securityRulesArgsForCall = append(fake.securityRulesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SecurityRulesStub
+	fakeReturns := fake.securityRulesReturns
+	fake.recordInvocation("SecurityRules", []interface{}{arg1})
+	fake.securityRulesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureResourceClient) SecurityRulesCallCount() int {
+	fake.securityRulesMutex.RLock()
+	defer fake.securityRulesMutex.RUnlock()
+	return len(fake.securityRulesArgsForCall)
+}
+
+func (fake *FakeAzureResourceClient) SecurityRulesCalls(stub func(string) ([]iaas.AzureSecurityRule, error)) {
+	fake.securityRulesMutex.Lock()
+	defer fake.securityRulesMutex.Unlock()
+	fake.SecurityRulesStub = stub
+}
+
+func (fake *FakeAzureResourceClient) SecurityRulesArgsForCall(i int) string {
+	fake.securityRulesMutex.RLock()
+	defer fake.securityRulesMutex.RUnlock()
+	argsForCall := fake.securityRulesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAzureResourceClient) SecurityRulesReturns(result1 []iaas.AzureSecurityRule, result2 error) {
+	fake.securityRulesMutex.Lock()
+	defer fake.securityRulesMutex.Unlock()
+	fake.SecurityRulesStub = nil
+	fake.securityRulesReturns = struct {
+		result1 []iaas.AzureSecurityRule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureResourceClient) SecurityRulesReturnsOnCall(i int, result1 []iaas.AzureSecurityRule, result2 error) {
+	fake.securityRulesMutex.Lock()
+	defer fake.securityRulesMutex.Unlock()
+	fake.SecurityRulesStub = nil
+	if fake.securityRulesReturnsOnCall == nil {
+		fake.securityRulesReturnsOnCall = make(map[int]struct {
+			result1 []iaas.AzureSecurityRule
+			result2 error
+		})
+	}
+	fake.securityRulesReturnsOnCall[i] = struct {
+		result1 []iaas.AzureSecurityRule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureResourceClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.deleteVMMutex.RLock()
+	defer fake.deleteVMMutex.RUnlock()
+	fake.listDNSZonesMutex.RLock()
+	defer fake.listDNSZonesMutex.RUnlock()
+	fake.listVMsMutex.RLock()
+	defer fake.listVMsMutex.RUnlock()
+	fake.securityRulesMutex.RLock()
+	defer fake.securityRulesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeAzureResourceClient) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ iaas.AzureResourceClient = new(FakeAzureResourceClient)
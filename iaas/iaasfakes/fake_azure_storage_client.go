@@ -0,0 +1,509 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package iaasfakes
+
+import (
+	"sync"
+
+	"github.com/EngineerBetter/control-tower/iaas"
+)
+
+type FakeAzureStorageClient struct {
+	BlobExistsStub        func(string, string) (bool, error)
+	blobExistsMutex       sync.RWMutex
+	blobExistsArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	blobExistsReturns struct {
+		result1 bool
+		result2 error
+	}
+	blobExistsReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	ContainerExistsStub        func(string) (bool, error)
+	containerExistsMutex       sync.RWMutex
+	containerExistsArgsForCall []struct {
+		arg1 string
+	}
+	containerExistsReturns struct {
+		result1 bool
+		result2 error
+	}
+	containerExistsReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	CreateContainerStub        func(string) error
+	createContainerMutex       sync.RWMutex
+	createContainerArgsForCall []struct {
+		arg1 string
+	}
+	createContainerReturns struct {
+		result1 error
+	}
+	createContainerReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteContainerStub        func(string) error
+	deleteContainerMutex       sync.RWMutex
+	deleteContainerArgsForCall []struct {
+		arg1 string
+	}
+	deleteContainerReturns struct {
+		result1 error
+	}
+	deleteContainerReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetBlobStub        func(string, string) ([]byte, error)
+	getBlobMutex       sync.RWMutex
+	getBlobArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getBlobReturns struct {
+		result1 []byte
+		result2 error
+	}
+	getBlobReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	PutBlobStub        func(string, string, []byte) error
+	putBlobMutex       sync.RWMutex
+	putBlobArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}
+	putBlobReturns struct {
+		result1 error
+	}
+	putBlobReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeAzureStorageClient) BlobExists(arg1 string, arg2 string) (bool, error) {
+	fake.blobExistsMutex.Lock()
+	ret, specificReturn := fake.blobExistsReturnsOnCall[len(fake.blobExistsArgsForCall)]
+	fake.blobExistsArgsForCall = append(fake.blobExistsArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.BlobExistsStub
+	fakeReturns := fake.blobExistsReturns
+	fake.recordInvocation("BlobExists", []interface{}{arg1, arg2})
+	fake.blobExistsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureStorageClient) BlobExistsCallCount() int {
+	fake.blobExistsMutex.RLock()
+	defer fake.blobExistsMutex.RUnlock()
+	return len(fake.blobExistsArgsForCall)
+}
+
+func (fake *FakeAzureStorageClient) BlobExistsCalls(stub func(string, string) (bool, error)) {
+	fake.blobExistsMutex.Lock()
+	defer fake.blobExistsMutex.Unlock()
+	fake.BlobExistsStub = stub
+}
+
+func (fake *FakeAzureStorageClient) BlobExistsArgsForCall(i int) (string, string) {
+	fake.blobExistsMutex.RLock()
+	defer fake.blobExistsMutex.RUnlock()
+	argsForCall := fake.blobExistsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAzureStorageClient) BlobExistsReturns(result1 bool, result2 error) {
+	fake.blobExistsMutex.Lock()
+	defer fake.blobExistsMutex.Unlock()
+	fake.BlobExistsStub = nil
+	fake.blobExistsReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureStorageClient) BlobExistsReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.blobExistsMutex.Lock()
+	defer fake.blobExistsMutex.Unlock()
+	fake.BlobExistsStub = nil
+	if fake.blobExistsReturnsOnCall == nil {
+		fake.blobExistsReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.blobExistsReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureStorageClient) ContainerExists(arg1 string) (bool, error) {
+	fake.containerExistsMutex.Lock()
+	ret, specificReturn := fake.containerExistsReturnsOnCall[len(fake.containerExistsArgsForCall)]
+	fake.containerExistsArgsForCall = append(fake.containerExistsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ContainerExistsStub
+	fakeReturns := fake.containerExistsReturns
+	fake.recordInvocation("ContainerExists", []interface{}{arg1})
+	fake.containerExistsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureStorageClient) ContainerExistsCallCount() int {
+	fake.containerExistsMutex.RLock()
+	defer fake.containerExistsMutex.RUnlock()
+	return len(fake.containerExistsArgsForCall)
+}
+
+func (fake *FakeAzureStorageClient) ContainerExistsCalls(stub func(string) (bool, error)) {
+	fake.containerExistsMutex.Lock()
+	defer fake.containerExistsMutex.Unlock()
+	fake.ContainerExistsStub = stub
+}
+
+func (fake *FakeAzureStorageClient) ContainerExistsArgsForCall(i int) string {
+	fake.containerExistsMutex.RLock()
+	defer fake.containerExistsMutex.RUnlock()
+	argsForCall := fake.containerExistsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAzureStorageClient) ContainerExistsReturns(result1 bool, result2 error) {
+	fake.containerExistsMutex.Lock()
+	defer fake.containerExistsMutex.Unlock()
+	fake.ContainerExistsStub = nil
+	fake.containerExistsReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureStorageClient) ContainerExistsReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.containerExistsMutex.Lock()
+	defer fake.containerExistsMutex.Unlock()
+	fake.ContainerExistsStub = nil
+	if fake.containerExistsReturnsOnCall == nil {
+		fake.containerExistsReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.containerExistsReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureStorageClient) CreateContainer(arg1 string) error {
+	fake.createContainerMutex.Lock()
+	ret, specificReturn := fake.createContainerReturnsOnCall[len(fake.createContainerArgsForCall)]
+	fake.createContainerArgsForCall = append(fake.createContainerArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CreateContainerStub
+	fakeReturns := fake.createContainerReturns
+	fake.recordInvocation("CreateContainer", []interface{}{arg1})
+	fake.createContainerMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAzureStorageClient) CreateContainerCallCount() int {
+	fake.createContainerMutex.RLock()
+	defer fake.createContainerMutex.RUnlock()
+	return len(fake.createContainerArgsForCall)
+}
+
+func (fake *FakeAzureStorageClient) CreateContainerCalls(stub func(string) error) {
+	fake.createContainerMutex.Lock()
+	defer fake.createContainerMutex.Unlock()
+	fake.CreateContainerStub = stub
+}
+
+func (fake *FakeAzureStorageClient) CreateContainerArgsForCall(i int) string {
+	fake.createContainerMutex.RLock()
+	defer fake.createContainerMutex.RUnlock()
+	argsForCall := fake.createContainerArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAzureStorageClient) CreateContainerReturns(result1 error) {
+	fake.createContainerMutex.Lock()
+	defer fake.createContainerMutex.Unlock()
+	fake.CreateContainerStub = nil
+	fake.createContainerReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureStorageClient) CreateContainerReturnsOnCall(i int, result1 error) {
+	fake.createContainerMutex.Lock()
+	defer fake.createContainerMutex.Unlock()
+	fake.CreateContainerStub = nil
+	if fake.createContainerReturnsOnCall == nil {
+		fake.createContainerReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createContainerReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureStorageClient) DeleteContainer(arg1 string) error {
+	fake.deleteContainerMutex.Lock()
+	ret, specificReturn := fake.deleteContainerReturnsOnCall[len(fake.deleteContainerArgsForCall)]
+	fake.deleteContainerArgsForCall = append(fake.deleteContainerArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteContainerStub
+	fakeReturns := fake.deleteContainerReturns
+	fake.recordInvocation("DeleteContainer", []interface{}{arg1})
+	fake.deleteContainerMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAzureStorageClient) DeleteContainerCallCount() int {
+	fake.deleteContainerMutex.RLock()
+	defer fake.deleteContainerMutex.RUnlock()
+	return len(fake.deleteContainerArgsForCall)
+}
+
+func (fake *FakeAzureStorageClient) DeleteContainerCalls(stub func(string) error) {
+	fake.deleteContainerMutex.Lock()
+	defer fake.deleteContainerMutex.Unlock()
+	fake.DeleteContainerStub = stub
+}
+
+func (fake *FakeAzureStorageClient) DeleteContainerArgsForCall(i int) string {
+	fake.deleteContainerMutex.RLock()
+	defer fake.deleteContainerMutex.RUnlock()
+	argsForCall := fake.deleteContainerArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAzureStorageClient) DeleteContainerReturns(result1 error) {
+	fake.deleteContainerMutex.Lock()
+	defer fake.deleteContainerMutex.Unlock()
+	fake.DeleteContainerStub = nil
+	fake.deleteContainerReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureStorageClient) DeleteContainerReturnsOnCall(i int, result1 error) {
+	fake.deleteContainerMutex.Lock()
+	defer fake.deleteContainerMutex.Unlock()
+	fake.DeleteContainerStub = nil
+	if fake.deleteContainerReturnsOnCall == nil {
+		fake.deleteContainerReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteContainerReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureStorageClient) GetBlob(arg1 string, arg2 string) ([]byte, error) {
+	fake.getBlobMutex.Lock()
+	ret, specificReturn := fake.getBlobReturnsOnCall[len(fake.getBlobArgsForCall)]
+	fake.getBlobArgsForCall = append(fake.getBlobArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetBlobStub
+	fakeReturns := fake.getBlobReturns
+	fake.recordInvocation("GetBlob", []interface{}{arg1, arg2})
+	fake.getBlobMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureStorageClient) GetBlobCallCount() int {
+	fake.getBlobMutex.RLock()
+	defer fake.getBlobMutex.RUnlock()
+	return len(fake.getBlobArgsForCall)
+}
+
+func (fake *FakeAzureStorageClient) GetBlobCalls(stub func(string, string) ([]byte, error)) {
+	fake.getBlobMutex.Lock()
+	defer fake.getBlobMutex.Unlock()
+	fake.GetBlobStub = stub
+}
+
+func (fake *FakeAzureStorageClient) GetBlobArgsForCall(i int) (string, string) {
+	fake.getBlobMutex.RLock()
+	defer fake.getBlobMutex.RUnlock()
+	argsForCall := fake.getBlobArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAzureStorageClient) GetBlobReturns(result1 []byte, result2 error) {
+	fake.getBlobMutex.Lock()
+	defer fake.getBlobMutex.Unlock()
+	fake.GetBlobStub = nil
+	fake.getBlobReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureStorageClient) GetBlobReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.getBlobMutex.Lock()
+	defer fake.getBlobMutex.Unlock()
+	fake.GetBlobStub = nil
+	if fake.getBlobReturnsOnCall == nil {
+		fake.getBlobReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.getBlobReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureStorageClient) PutBlob(arg1 string, arg2 string, arg3 []byte) error {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.putBlobMutex.Lock()
+	ret, specificReturn := fake.putBlobReturnsOnCall[len(fake.putBlobArgsForCall)]
+	fake.putBlobArgsForCall = append(fake.putBlobArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}{arg1, arg2, arg3Copy})
+	stub := fake.PutBlobStub
+	fakeReturns := fake.putBlobReturns
+	fake.recordInvocation("PutBlob", []interface{}{arg1, arg2, arg3Copy})
+	fake.putBlobMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAzureStorageClient) PutBlobCallCount() int {
+	fake.putBlobMutex.RLock()
+	defer fake.putBlobMutex.RUnlock()
+	return len(fake.putBlobArgsForCall)
+}
+
+func (fake *FakeAzureStorageClient) PutBlobCalls(stub func(string, string, []byte) error) {
+	fake.putBlobMutex.Lock()
+	defer fake.putBlobMutex.Unlock()
+	fake.PutBlobStub = stub
+}
+
+func (fake *FakeAzureStorageClient) PutBlobArgsForCall(i int) (string, string, []byte) {
+	fake.putBlobMutex.RLock()
+	defer fake.putBlobMutex.RUnlock()
+	argsForCall := fake.putBlobArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeAzureStorageClient) PutBlobReturns(result1 error) {
+	fake.putBlobMutex.Lock()
+	defer fake.putBlobMutex.Unlock()
+	fake.PutBlobStub = nil
+	fake.putBlobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureStorageClient) PutBlobReturnsOnCall(i int, result1 error) {
+	fake.putBlobMutex.Lock()
+	defer fake.putBlobMutex.Unlock()
+	fake.PutBlobStub = nil
+	if fake.putBlobReturnsOnCall == nil {
+		fake.putBlobReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.putBlobReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureStorageClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.blobExistsMutex.RLock()
+	defer fake.blobExistsMutex.RUnlock()
+	fake.containerExistsMutex.RLock()
+	defer fake.containerExistsMutex.RUnlock()
+	fake.createContainerMutex.RLock()
+	defer fake.createContainerMutex.RUnlock()
+	fake.deleteContainerMutex.RLock()
+	defer fake.deleteContainerMutex.RUnlock()
+	fake.getBlobMutex.RLock()
+	defer fake.getBlobMutex.RUnlock()
+	fake.putBlobMutex.RLock()
+	defer fake.putBlobMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeAzureStorageClient) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ iaas.AzureStorageClient = new(FakeAzureStorageClient)
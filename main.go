@@ -21,6 +21,7 @@ func main() {
 	app.Version = ControlTowerVersion
 	app.Commands = commands.Commands
 	app.Flags = commands.GlobalFlags
+	app.Before = commands.ResolveGlobalFlags
 	cli.AppHelpTemplate = fmt.Sprintf(`%s
 
 See 'control-tower help <command>' to read about a specific command.
@@ -18,9 +18,18 @@ var (
 	//go:embed assets/ops/shas-gcp.json
 	GcpConcourseSHAs []byte
 
+	//go:embed assets/ops/versions-azure.json
+	AzureConcourseVersions []byte
+
+	//go:embed assets/ops/shas-azure.json
+	AzureConcourseSHAs []byte
+
 	//go:embed assets/createenv-dependencies-and-cli-versions-aws.json
 	AWSVersionFile []byte
 
 	//go:embed assets/createenv-dependencies-and-cli-versions-gcp.json
 	GCPVersionFile []byte
+
+	//go:embed assets/createenv-dependencies-and-cli-versions-azure.json
+	AzureVersionFile []byte
 )
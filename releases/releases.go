@@ -0,0 +1,233 @@
+package releases
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/control-tower/config"
+)
+
+const (
+	// DefaultAPIURL is the GitHub API of the repository control-tower is released from
+	DefaultAPIURL = "https://api.github.com/repos/EngineerBetter/control-tower"
+	// DefaultOpsURL serves the files of the control-tower-ops repository at any of its versions
+	DefaultOpsURL = "https://raw.githubusercontent.com/EngineerBetter/control-tower-ops"
+)
+
+// Release is a GitHub release of control-tower
+type Release struct {
+	Tag        string `json:"tag_name"`
+	PreRelease bool   `json:"prerelease"`
+	Draft      bool   `json:"draft"`
+	Body       string `json:"body"`
+}
+
+var opsVersionInBody = regexp.MustCompile(`control-tower-ops/tree/([^)\s]+)`)
+
+// OpsVersion returns the version of control-tower-ops the release was built with, which its notes link to
+func (r Release) OpsVersion() (string, error) {
+	match := opsVersionInBody.FindStringSubmatch(r.Body)
+	if match == nil {
+		return "", fmt.Errorf("release %s does not name the control-tower-ops version it was built with", r.Tag)
+	}
+	return match[1], nil
+}
+
+// Client finds releases of control-tower and the component versions they deploy
+type Client struct {
+	APIURL string
+	OpsURL string
+	http   *http.Client
+}
+
+// New returns a Client for the public control-tower repositories
+func New() *Client {
+	return &Client{
+		APIURL: DefaultAPIURL,
+		OpsURL: DefaultOpsURL,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Latest returns the newest release that channel follows
+func (c *Client) Latest(channel string) (Release, error) {
+	var all []Release
+	if err := c.getJSON(c.APIURL+"/releases?per_page=100", &all); err != nil {
+		return Release{}, fmt.Errorf("failed to list control-tower releases: [%v]", err)
+	}
+	var matching []Release
+	for _, r := range all {
+		if !r.Draft && config.ReleaseChannelIncludes(channel, r.Tag, r.PreRelease) {
+			matching = append(matching, r)
+		}
+	}
+	if len(matching) == 0 {
+		return Release{}, fmt.Errorf("no release of control-tower is on the %s release channel", channel)
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return Newer(matching[i].Tag, matching[j].Tag)
+	})
+	return matching[0], nil
+}
+
+// Tagged returns the release of control-tower with tag
+func (c *Client) Tagged(tag string) (Release, error) {
+	var r Release
+	if err := c.getJSON(c.APIURL+"/releases/tags/"+tag, &r); err != nil {
+		return Release{}, fmt.Errorf("failed to find control-tower release %s: [%v]", tag, err)
+	}
+	return r, nil
+}
+
+// Versions returns the versions of the components that release deploys on iaas, read from the
+// control-tower-ops files it was built with
+func (c *Client) Versions(release Release, iaas string) (map[string]string, error) {
+	opsVersion, err := release.OpsVersion()
+	if err != nil {
+		return nil, err
+	}
+	iaas = strings.ToLower(iaas)
+	concourseVersions, err := c.get(fmt.Sprintf("%s/%s/ops/versions-%s.json", c.OpsURL, opsVersion, iaas))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Concourse versions of control-tower-ops %s: [%v]", opsVersion, err)
+	}
+	directorVersions, err := c.get(fmt.Sprintf("%s/%s/createenv-dependencies-and-cli-versions-%s.json", c.OpsURL, opsVersion, iaas))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read BOSH versions of control-tower-ops %s: [%v]", opsVersion, err)
+	}
+	return ParseVersions(concourseVersions, directorVersions)
+}
+
+func (c *Client) getJSON(url string, v interface{}) error {
+	body, err := c.get(url)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (c *Client) get(url string) ([]byte, error) {
+	resp, err := c.http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected response %s from %s", resp.Status, url)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// ParseVersions reads the versions of the Concourse releases and stemcell from an ops file such as
+// opsassets' versions-aws.json, and those of the director from a file such as
+// createenv-dependencies-and-cli-versions-aws.json
+func ParseVersions(concourseVersions, directorVersions []byte) (map[string]string, error) {
+	var ops []struct {
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal(concourseVersions, &ops); err != nil {
+		return nil, fmt.Errorf("failed to parse Concourse versions: [%v]", err)
+	}
+	versions := map[string]string{}
+	for _, op := range ops {
+		switch value := op.Value.(type) {
+		case map[string]interface{}:
+			name, _ := value["name"].(string)
+			version, _ := value["version"].(string)
+			if name != "" && version != "" {
+				versions[name] = version
+			}
+		case string:
+			if strings.HasPrefix(op.Path, "/stemcells/") && strings.HasSuffix(op.Path, "/version") {
+				versions["stemcell"] = value
+			}
+		}
+	}
+
+	var director map[string]struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(directorVersions, &director); err != nil {
+		return nil, fmt.Errorf("failed to parse BOSH versions: [%v]", err)
+	}
+	for name, dependency := range director {
+		if dependency.Version != "" {
+			versions["director "+name] = dependency.Version
+		}
+	}
+	return versions, nil
+}
+
+// Changes describes the components whose versions differ between from and to, sorted by name
+func Changes(from, to map[string]string) []string {
+	names := map[string]bool{}
+	for name := range from {
+		names[name] = true
+	}
+	for name := range to {
+		names[name] = true
+	}
+	var changes []string
+	for name := range names {
+		before, after := versionOrNone(from, name), versionOrNone(to, name)
+		if before != after {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, before, after))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+func versionOrNone(versions map[string]string, name string) string {
+	if version, ok := versions[name]; ok {
+		return version
+	}
+	return "none"
+}
+
+// Newer reports whether version a is newer than version b. A version that is not a dotted
+// list of numbers, such as that of a development build, is older than any other.
+func Newer(a, b string) bool {
+	partsA, okA := versionParts(a)
+	partsB, okB := versionParts(b)
+	if !okA || !okB {
+		return okA && !okB
+	}
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		var x, y int
+		if i < len(partsA) {
+			x = partsA[i]
+		}
+		if i < len(partsB) {
+			y = partsB[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+func versionParts(version string) ([]int, bool) {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
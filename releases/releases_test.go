@@ -0,0 +1,114 @@
+package releases
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const releasesJSON = `[
+  {"tag_name": "0.18.0", "prerelease": true, "body": "control-tower-ops/tree/1.3.0"},
+  {"tag_name": "0.17.10", "body": "[ops](https://github.com/EngineerBetter/control-tower-ops/tree/1.2.1)"},
+  {"tag_name": "0.19.0", "draft": true},
+  {"tag_name": "0.17.9", "body": "control-tower-ops/tree/1.2.0"},
+  {"tag_name": "0.16.4", "body": "control-tower-ops/tree/1.1.0"}
+]`
+
+func TestClient_Latest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/releases" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(releasesJSON))
+	}))
+	defer server.Close()
+	client := New()
+	client.APIURL = server.URL
+
+	tests := []struct {
+		channel string
+		want    string
+		wantErr bool
+	}{
+		{channel: "stable", want: "0.17.10"},
+		{channel: "pre-release", want: "0.18.0"},
+		{channel: "0.16.x", want: "0.16.4"},
+		{channel: "0.17.9", want: "0.17.9"},
+		{channel: "0.15.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			got, err := client.Latest(tt.channel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Client.Latest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Tag != tt.want {
+				t.Errorf("Client.Latest() = %s, want %s", got.Tag, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Versions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/1.2.1/ops/versions-aws.json":
+			fmt.Fprint(w, `[
+  {"type": "replace", "path": "/releases/name=concourse", "value": {"name": "concourse", "version": "7.5.0", "sha1": "a"}},
+  {"type": "replace", "path": "/stemcells/alias=bionic/version", "value": "1.36"}
+]`)
+		case "/1.2.1/createenv-dependencies-and-cli-versions-aws.json":
+			fmt.Fprint(w, `{"bosh": {"version": "270.1.0"}, "cpi": {"version": "88"}, "bosh-cli": {"linux": "https://example.com/bosh"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := New()
+	client.OpsURL = server.URL
+
+	got, err := client.Versions(Release{Tag: "0.17.10", Body: "control-tower-ops/tree/1.2.1"}, "AWS")
+	if err != nil {
+		t.Fatalf("Client.Versions() error = %v", err)
+	}
+	want := map[string]string{"concourse": "7.5.0", "stemcell": "1.36", "director bosh": "270.1.0", "director cpi": "88"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Client.Versions() = %v, want %v", got, want)
+	}
+
+	if _, err = client.Versions(Release{Tag: "0.1.0", Body: "Auto-generated release"}, "AWS"); err == nil {
+		t.Errorf("Client.Versions() succeeded for a release that does not name its control-tower-ops version")
+	}
+}
+
+func TestChanges(t *testing.T) {
+	from := map[string]string{"concourse": "7.4.0", "stemcell": "1.36", "grafana": "1.0"}
+	to := map[string]string{"concourse": "7.5.0", "stemcell": "1.36", "uaa": "74.0"}
+	want := []string{"concourse: 7.4.0 -> 7.5.0", "grafana: 1.0 -> none", "uaa: none -> 74.0"}
+	if got := Changes(from, to); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes() = %v, want %v", got, want)
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"0.17.10", "0.17.9", true},
+		{"0.17.9", "0.17.10", false},
+		{"v1.0.0", "0.17.10", true},
+		{"0.17.10", "0.17.10", false},
+		{"0.18.0-rc.1", "0.17.10", true},
+		{"0.17.10", "COMPILE_TIME_VARIABLE_main_ControlTowerVersion", true},
+		{"dev", "0.17.10", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
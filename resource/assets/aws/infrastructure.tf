@@ -1,9 +1,30 @@
 terraform {
+{{- if eq .StateBackend.Type "local" }}
+	backend "local" {
+		path = "{{ .StateBackend.Path }}"
+	}
+{{- else if eq .StateBackend.Type "s3" }}
+	backend "s3" {
+		bucket                      = "{{ .ConfigBucket }}"
+		key                         = "{{ .TFStatePath }}"
+		region                      = "{{ .StateBackend.Region }}"
+		endpoint                    = "{{ .StateBackend.Endpoint }}"
+{{- if .StateBackend.AccessKeyID }}
+		access_key                  = "{{ .StateBackend.AccessKeyID }}"
+		secret_key                  = "{{ .StateBackend.SecretAccessKey }}"
+{{- end }}
+		force_path_style            = true
+		skip_credentials_validation = true
+		skip_metadata_api_check     = true
+		skip_region_validation      = true
+	}
+{{- else }}
 	backend "s3" {
 		bucket = "{{ .ConfigBucket }}"
 		key    = "{{ .TFStatePath }}"
 		region = "{{ .Region }}"
 	}
+{{- end }}
 }
 
 data "aws_availability_zones" "available" {
@@ -83,7 +104,33 @@ variable "rds2_cidr" {
   type = "string"
   default = "{{ .RDS2CIDR }}"
 }
+{{if .Private }}
+variable "nat_cidr" {
+  type = "string"
+  default = "{{ .NATCIDR }}"
+}
+{{end}}
+{{if gt .WebCount 1 }}
+variable "web_a_cidr" {
+  type = "string"
+  default = "{{ index .WebCIDRs 0 }}"
+}
+
+variable "web_b_cidr" {
+  type = "string"
+  default = "{{ index .WebCIDRs 1 }}"
+}
+
+variable "web_lb_b_cidr" {
+  type = "string"
+  default = "{{ index .WebCIDRs 2 }}"
+}
 
+variable "web_ports" {
+  type = "list"
+  default = ["80", "443", "3000", "8443", "8844"]
+}
+{{end}}
 {{if .HostedZoneID }}
 variable "hosted_zone_id" {
   type = "string"
@@ -118,6 +165,7 @@ resource "aws_s3_bucket" "blobstore" {
   }
 }
 
+{{if or (not .InstanceIdentity) .KeepAccessKeys }}
 resource "aws_iam_user" "blobstore" {
   name = "${var.deployment}-{{ .Namespace }}-blobstore"
 }
@@ -179,6 +227,119 @@ resource "aws_iam_user_policy" "bosh" {
 }
 EOF
 }
+{{end}}
+{{if .InstanceIdentity }}
+# The director and the VMs it creates get their credentials from IAM instance profiles rather than
+# access keys. The director needs to pass the role of the VMs to them, and both reach the blobstore.
+resource "aws_iam_role" "bosh" {
+  name = "${var.deployment}-${var.region}-bosh"
+
+  assume_role_policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Action": "sts:AssumeRole",
+      "Effect": "Allow",
+      "Principal": {
+        "Service": "ec2.amazonaws.com"
+      }
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_role_policy" "bosh" {
+  name = "${var.deployment}-${var.region}-bosh"
+  role = "${aws_iam_role.bosh.id}"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Action": [
+        "ec2:*",
+        "elasticloadbalancing:*"
+      ],
+      "Effect": "Allow",
+      "Resource": "*"
+    },
+    {
+      "Action": [
+        "iam:PassRole"
+      ],
+      "Effect": "Allow",
+      "Resource": "${aws_iam_role.blobstore.arn}"
+    },
+    {
+      "Action": [
+        "s3:*"
+      ],
+      "Effect": "Allow",
+      "Resource": [
+        "arn:aws:s3:::${aws_s3_bucket.blobstore.id}",
+        "arn:aws:s3:::${aws_s3_bucket.blobstore.id}/*"
+      ]
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_instance_profile" "bosh" {
+  name = "${var.deployment}-${var.region}-bosh"
+  role = "${aws_iam_role.bosh.name}"
+}
+
+resource "aws_iam_role" "blobstore" {
+  name = "${var.deployment}-{{ .Namespace }}-blobstore"
+
+  assume_role_policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Action": "sts:AssumeRole",
+      "Effect": "Allow",
+      "Principal": {
+        "Service": "ec2.amazonaws.com"
+      }
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_role_policy" "blobstore" {
+  name = "${var.deployment}-{{ .Namespace }}-blobstore"
+  role = "${aws_iam_role.blobstore.id}"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Action": [
+        "s3:*"
+      ],
+      "Effect": "Allow",
+      "Resource": [
+        "arn:aws:s3:::${aws_s3_bucket.blobstore.id}",
+        "arn:aws:s3:::${aws_s3_bucket.blobstore.id}/*"
+      ]
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_instance_profile" "blobstore" {
+  name = "${var.deployment}-{{ .Namespace }}-blobstore"
+  role = "${aws_iam_role.blobstore.name}"
+}
+{{end}}
 
 resource "aws_vpc" "default" {
   cidr_block = "${var.network_cidr}"
@@ -208,7 +369,11 @@ resource "aws_route" "internet_access" {
 
  resource "aws_nat_gateway" "default" {
   allocation_id = "${aws_eip.nat.id}"
+{{- if .Private }}
+  subnet_id     = "${aws_subnet.nat.id}"
+{{- else }}
   subnet_id     = "${aws_subnet.public.id}"
+{{- end }}
 
   depends_on = ["aws_internet_gateway.default"]
 
@@ -237,7 +402,11 @@ resource "aws_subnet" "public" {
   vpc_id                  = "${aws_vpc.default.id}"
   availability_zone       = "${var.availability_zone}"
   cidr_block              = "${var.public_cidr}"
+{{- if .Private }}
+  map_public_ip_on_launch = false
+{{- else }}
   map_public_ip_on_launch = true
+{{- end }}
 
   tags {
     Name = "${var.deployment}-public"
@@ -263,17 +432,67 @@ resource "aws_route_table_association" "private" {
   subnet_id      = "${aws_subnet.private.id}"
   route_table_id = "${aws_route_table.private.id}"
 }
+{{if .Private }}
+# Without public IPs the director and web VMs reach the internet through the NAT gateway, which
+# needs a subnet of its own that routes to the internet gateway
+resource "aws_subnet" "nat" {
+  vpc_id                  = "${aws_vpc.default.id}"
+  availability_zone       = "${var.availability_zone}"
+  cidr_block              = "${var.nat_cidr}"
+  map_public_ip_on_launch = false
 
-{{if .HostedZoneID }}
+  tags {
+    Name = "${var.deployment}-nat"
+    control-tower-project = "${var.project}"
+    control-tower-component = "bosh"
+  }
+}
+
+resource "aws_route_table_association" "public" {
+  subnet_id      = "${aws_subnet.public.id}"
+  route_table_id = "${aws_route_table.private.id}"
+}
+{{end}}
+{{if and .HostedZoneID (not .Private) }}
 resource "aws_route53_record" "concourse" {
   zone_id = "${var.hosted_zone_id}"
   name    = "${var.hosted_zone_record_prefix}"
   ttl     = "60"
   type    = "A"
+{{- if gt .WebCount 1 }}
+  records = ["${aws_eip.web_lb.*.public_ip}"]
+{{- else }}
   records = ["${aws_eip.atc.public_ip}"]
+{{- end }}
 }
 {{end}}
+{{if and .Private .PrivateDomain }}
+# The private IP of a private deployment is kept out of public DNS, in a hosted zone for its domain
+# that only the VPC, and networks that forward queries to it, can resolve
+resource "aws_route53_zone" "private" {
+  name          = "{{ .PrivateDomain }}"
+  force_destroy = true
 
+  vpc {
+    vpc_id = "${aws_vpc.default.id}"
+  }
+
+  tags {
+    Name = "${var.deployment}"
+    control-tower-project = "${var.project}"
+  }
+}
+
+resource "aws_route53_record" "concourse" {
+  zone_id = "${aws_route53_zone.private.zone_id}"
+  name    = "{{ .PrivateDomain }}"
+  ttl     = "60"
+  type    = "A"
+  records = ["${cidrhost(var.public_cidr, 8)}"]
+}
+{{end}}
+
+{{if not .Private }}
 resource "aws_eip" "director" {
   vpc = true
   depends_on = ["aws_internet_gateway.default"]
@@ -283,7 +502,8 @@ resource "aws_eip" "director" {
     control-tower-project = "${var.project}"
   }
 }
-
+{{end}}
+{{if and (not .Private) (lt .WebCount 2) }}
 resource "aws_eip" "atc" {
   vpc = true
   depends_on = ["aws_internet_gateway.default"]
@@ -293,6 +513,7 @@ resource "aws_eip" "atc" {
     control-tower-project = "${var.project}"
   }
 }
+{{end}}
 
 resource "aws_eip" "nat" {
   vpc = true
@@ -319,21 +540,33 @@ resource "aws_security_group" "director" {
     from_port   = 6868
     to_port     = 6868
     protocol    = "tcp"
+{{- if .Private }}
+    cidr_blocks = ["${var.network_cidr}", {{ .AllowIPs }}]
+{{- else }}
     cidr_blocks = ["${var.source_access_ip}/32", "${aws_nat_gateway.default.public_ip}/32"]
+{{- end }}
   }
 
   ingress {
     from_port   = 25555
     to_port     = 25555
     protocol    = "tcp"
+{{- if .Private }}
+    cidr_blocks = ["${var.network_cidr}", {{ .AllowIPs }}]
+{{- else }}
     cidr_blocks = ["${var.source_access_ip}/32", "${aws_nat_gateway.default.public_ip}/32"]
+{{- end }}
   }
 
   ingress {
     from_port   = 22
     to_port     = 22
     protocol    = "tcp"
+{{- if .Private }}
+    cidr_blocks = ["${var.network_cidr}", {{ .AllowIPs }}]
+{{- else }}
     cidr_blocks = ["${var.source_access_ip}/32", "${aws_nat_gateway.default.public_ip}/32"]
+{{- end }}
   }
 
   egress {
@@ -470,13 +703,22 @@ resource "aws_security_group" "atc" {
   name        = "${var.deployment}-atc"
   description = "Control-Tower ATC security group"
   vpc_id      = "${aws_vpc.default.id}"
+{{- if or .Private (gt .WebCount 1) }}
+  depends_on = ["aws_eip.nat"]
+{{- else }}
   depends_on = ["aws_eip.nat", "aws_eip.atc"]
+{{- end }}
 
   tags {
     Name = "${var.deployment}-atc"
     control-tower-project = "${var.project}"
     control-tower-component = "concourse"
   }
+{{- if gt .WebCount 1 }}
+
+  # The load balancer keeps the addresses of clients, and health checks web VMs from within the VPC.
+  # Web VMs reach Concourse through the NAT gateway.
+{{- end }}
 
   egress {
     from_port   = 0
@@ -490,35 +732,65 @@ resource "aws_security_group" "atc" {
     to_port     = 80
     protocol    = "tcp"
     security_groups = ["${aws_security_group.vms.id}", "${aws_security_group.director.id}"]
+{{- if .Private }}
+    cidr_blocks = ["${var.network_cidr}", {{ .AllowIPs }}]
+{{- else if gt .WebCount 1 }}
+    cidr_blocks = ["${var.network_cidr}", "${aws_eip.nat.public_ip}/32", {{ .AllowIPs }}]
+{{- else }}
     cidr_blocks = ["${aws_eip.nat.public_ip}/32", "${aws_eip.atc.public_ip}/32", {{ .AllowIPs }}]
+{{- end }}
   }
 
   ingress {
     from_port   = 443
     to_port     = 443
     protocol    = "tcp"
+{{- if .Private }}
+    cidr_blocks = ["${var.network_cidr}", {{ .AllowIPs }}]
+{{- else if gt .WebCount 1 }}
+    cidr_blocks = ["${var.network_cidr}", "${aws_eip.nat.public_ip}/32", {{ .AllowIPs }}]
+{{- else }}
     cidr_blocks = ["${aws_eip.nat.public_ip}/32", "${aws_eip.atc.public_ip}/32", {{ .AllowIPs }}]
+{{- end }}
   }
 
   ingress {
     from_port   = 3000
     to_port     = 3000
     protocol    = "tcp"
+{{- if .Private }}
+    cidr_blocks = ["${var.network_cidr}", {{ .AllowIPs }}]
+{{- else if gt .WebCount 1 }}
+    cidr_blocks = ["${var.network_cidr}", "${aws_eip.nat.public_ip}/32", {{ .AllowIPs }}]
+{{- else }}
     cidr_blocks = ["${aws_eip.nat.public_ip}/32", {{ .AllowIPs }}]
+{{- end }}
   }
 
   ingress {
     from_port   = 8844
     to_port     = 8844
     protocol    = "tcp"
+{{- if .Private }}
+    cidr_blocks = ["${var.network_cidr}", {{ .AllowIPs }}]
+{{- else if gt .WebCount 1 }}
+    cidr_blocks = ["${var.network_cidr}", "${aws_eip.nat.public_ip}/32", {{ .AllowIPs }}]
+{{- else }}
     cidr_blocks = ["${aws_eip.nat.public_ip}/32", "${aws_eip.atc.public_ip}/32", {{ .AllowIPs }}]
+{{- end }}
   }
 
   ingress {
     from_port   = 8443
     to_port     = 8443
     protocol    = "tcp"
+{{- if .Private }}
+    cidr_blocks = ["${var.network_cidr}", {{ .AllowIPs }}]
+{{- else if gt .WebCount 1 }}
+    cidr_blocks = ["${var.network_cidr}", "${aws_eip.nat.public_ip}/32", {{ .AllowIPs }}]
+{{- else }}
     cidr_blocks = ["${aws_eip.nat.public_ip}/32", "${aws_eip.atc.public_ip}/32", {{ .AllowIPs }}]
+{{- end }}
   }
 
   ingress {
@@ -529,6 +801,133 @@ resource "aws_security_group" "atc" {
   }
 }
 
+{{if gt .WebCount 1 }}
+# Several web VMs are spread across two availability zones behind a network load balancer, which
+# needs a public subnet in each zone. The web VMs reach the internet through the NAT gateway, so
+# that they are allowed to reach Concourse through the load balancer.
+resource "aws_subnet" "web_a" {
+  vpc_id                  = "${aws_vpc.default.id}"
+  availability_zone       = "${var.availability_zone}"
+  cidr_block              = "${var.web_a_cidr}"
+  map_public_ip_on_launch = false
+
+  tags {
+    Name = "${var.deployment}-web-a"
+    control-tower-project = "${var.project}"
+    control-tower-component = "concourse"
+  }
+}
+
+resource "aws_subnet" "web_b" {
+  vpc_id                  = "${aws_vpc.default.id}"
+  availability_zone       = "${local.web_availability_zone}"
+  cidr_block              = "${var.web_b_cidr}"
+  map_public_ip_on_launch = false
+
+  tags {
+    Name = "${var.deployment}-web-b"
+    control-tower-project = "${var.project}"
+    control-tower-component = "concourse"
+  }
+}
+
+resource "aws_route_table_association" "web_a" {
+  subnet_id      = "${aws_subnet.web_a.id}"
+  route_table_id = "${aws_route_table.private.id}"
+}
+
+resource "aws_route_table_association" "web_b" {
+  subnet_id      = "${aws_subnet.web_b.id}"
+  route_table_id = "${aws_route_table.private.id}"
+}
+
+resource "aws_subnet" "web_lb_b" {
+  vpc_id                  = "${aws_vpc.default.id}"
+  availability_zone       = "${local.web_availability_zone}"
+  cidr_block              = "${var.web_lb_b_cidr}"
+  map_public_ip_on_launch = false
+
+  tags {
+    Name = "${var.deployment}-web-lb-b"
+    control-tower-project = "${var.project}"
+    control-tower-component = "concourse"
+  }
+}
+
+locals {
+  # The availability zone after the deployment's own
+  web_availability_zone = "${element(sort(data.aws_availability_zones.available.names), index(sort(data.aws_availability_zones.available.names), var.availability_zone) + 1)}"
+}
+
+resource "aws_eip" "web_lb" {
+  count = 2
+  vpc = true
+  depends_on = ["aws_internet_gateway.default"]
+
+    tags {
+    Name = "${var.deployment}-web-lb-${count.index}"
+    control-tower-project = "${var.project}"
+  }
+}
+
+resource "aws_lb" "web" {
+  name_prefix                      = "web-"
+  load_balancer_type               = "network"
+  enable_cross_zone_load_balancing = true
+
+  subnet_mapping {
+    subnet_id     = "${aws_subnet.public.id}"
+    allocation_id = "${aws_eip.web_lb.0.id}"
+  }
+
+  subnet_mapping {
+    subnet_id     = "${aws_subnet.web_lb_b.id}"
+    allocation_id = "${aws_eip.web_lb.1.id}"
+  }
+
+  tags {
+    Name = "${var.deployment}-web"
+    control-tower-project = "${var.project}"
+    control-tower-component = "concourse"
+  }
+}
+
+# Web VMs that stop listening are taken out of service within 20 seconds, so that BOSH can update
+# them one at a time without dropping new connections
+resource "aws_lb_target_group" "web" {
+  count                = "${length(var.web_ports)}"
+  name_prefix          = "web-"
+  port                 = "${element(var.web_ports, count.index)}"
+  protocol             = "TCP"
+  vpc_id               = "${aws_vpc.default.id}"
+  deregistration_delay = 30
+
+  health_check {
+    protocol            = "TCP"
+    interval            = 10
+    healthy_threshold   = 2
+    unhealthy_threshold = 2
+  }
+
+  tags {
+    Name = "${var.deployment}-web-${element(var.web_ports, count.index)}"
+    control-tower-project = "${var.project}"
+    control-tower-component = "concourse"
+  }
+}
+
+resource "aws_lb_listener" "web" {
+  count             = "${length(var.web_ports)}"
+  load_balancer_arn = "${aws_lb.web.arn}"
+  port              = "${element(var.web_ports, count.index)}"
+  protocol          = "TCP"
+
+  default_action {
+    type             = "forward"
+    target_group_arn = "${element(aws_lb_target_group.web.*.arn, count.index)}"
+  }
+}
+{{end}}
 resource "aws_route_table" "rds" {
   vpc_id = "${aws_vpc.default.id}"
 
@@ -624,12 +1023,23 @@ output "director_key_pair" {
   value = "${aws_key_pair.default.key_name}"
 }
 
+# A private deployment has no public IPs, so its outputs are the private IPs of the director and web VMs
 output "director_public_ip" {
+{{- if .Private }}
+  value = "${cidrhost(var.public_cidr, 6)}"
+{{- else }}
   value = "${aws_eip.director.public_ip}"
+{{- end }}
 }
 
 output "atc_public_ip" {
+{{- if .Private }}
+  value = "${cidrhost(var.public_cidr, 8)}"
+{{- else if gt .WebCount 1 }}
+  value = "${aws_eip.web_lb.0.public_ip}"
+{{- else }}
   value = "${aws_eip.atc.public_ip}"
+{{- end }}
 }
 
 output "director_security_group_id" {
@@ -664,6 +1074,7 @@ output "blobstore_bucket" {
   value = "${aws_s3_bucket.blobstore.id}"
 }
 
+{{if or (not .InstanceIdentity) .KeepAccessKeys }}
 output "blobstore_user_access_key_id" {
   value = "${aws_iam_access_key.blobstore.id}"
 }
@@ -681,6 +1092,16 @@ output "bosh_user_secret_access_key" {
   value     = "${aws_iam_access_key.bosh.secret}"
   sensitive = true
 }
+{{end}}
+{{if .InstanceIdentity }}
+output "director_instance_profile" {
+  value = "${aws_iam_instance_profile.bosh.name}"
+}
+
+output "vms_instance_profile" {
+  value = "${aws_iam_instance_profile.blobstore.name}"
+}
+{{end}}
 
 output "bosh_db_port" {
   value = "${aws_db_instance.default.port}"
@@ -689,3 +1110,20 @@ output "bosh_db_port" {
 output "bosh_db_address" {
   value = "${aws_db_instance.default.address}"
 }
+{{if gt .WebCount 1 }}
+output "web_availability_zone" {
+  value = "${local.web_availability_zone}"
+}
+
+output "web_subnet_a_id" {
+  value = "${aws_subnet.web_a.id}"
+}
+
+output "web_subnet_b_id" {
+  value = "${aws_subnet.web_b.id}"
+}
+
+output "web_target_groups" {
+  value = "${join(",", aws_lb_target_group.web.*.name)}"
+}
+{{end}}
@@ -0,0 +1,352 @@
+variable "region" {
+  type = "string"
+  default = "{{ .Region }}"
+}
+
+variable "deployment" {
+  type = "string"
+  default = "{{ .Deployment }}"
+}
+
+variable "namespace" {
+  type = "string"
+  default = "{{ .Namespace }}"
+}
+
+variable "source_access_ip" {
+  type = "string"
+  default = "{{ .ExternalIP }}"
+}
+
+variable "network_cidr" {
+  type = "string"
+  default = "{{ .NetworkCIDR }}"
+}
+
+variable "public_cidr" {
+  type = "string"
+  default = "{{ .PublicCIDR }}"
+}
+
+variable "private_cidr" {
+  type = "string"
+  default = "{{ .PrivateCIDR }}"
+}
+
+variable "db_tier" {
+  type = "string"
+  default = "{{ .DBTier }}"
+}
+
+variable "db_username" {
+  type = "string"
+  default = "{{ .DBUsername }}"
+}
+
+variable "db_password" {
+  type = "string"
+  default = "{{ .DBPassword }}"
+}
+
+variable "db_name" {
+  type = "string"
+  default = "{{ .DBName }}"
+}
+
+{{if .DNSZoneName }}
+variable "dns_zone_name" {
+  type = "string"
+  default = "{{ .DNSZoneName }}"
+}
+
+variable "dns_zone_resource_group" {
+  type = "string"
+  default = "{{ .DNSZoneResourceGroup }}"
+}
+
+variable "dns_record_set_prefix" {
+  type = "string"
+  default = "{{ .DNSRecordSetPrefix }}"
+}
+{{end}}
+
+provider "azurerm" {
+  subscription_id = "{{ .SubscriptionID }}"
+  tenant_id       = "{{ .TenantID }}"
+  client_id       = "{{ .ClientID }}"
+  client_secret   = "{{ .ClientSecret }}"
+  version         = "~> 2.60"
+  features {
+    resource_group {
+      prevent_deletion_if_contains_resources = false
+    }
+  }
+}
+
+terraform {
+{{- if eq .StateBackend.Type "local" }}
+  backend "local" {
+    path = "{{ .StateBackend.Path }}"
+  }
+{{- else if eq .StateBackend.Type "s3" }}
+  backend "s3" {
+    bucket                      = "{{ .ConfigBucket }}"
+    key                         = "{{ .TFStatePath }}"
+    region                      = "{{ .StateBackend.Region }}"
+    endpoint                    = "{{ .StateBackend.Endpoint }}"
+{{- if .StateBackend.AccessKeyID }}
+    access_key                  = "{{ .StateBackend.AccessKeyID }}"
+    secret_key                  = "{{ .StateBackend.SecretAccessKey }}"
+{{- end }}
+    force_path_style            = true
+    skip_credentials_validation = true
+    skip_metadata_api_check     = true
+    skip_region_validation      = true
+  }
+{{- else }}
+  backend "azurerm" {
+    subscription_id      = "{{ .SubscriptionID }}"
+    tenant_id            = "{{ .TenantID }}"
+    client_id            = "{{ .ClientID }}"
+    client_secret        = "{{ .ClientSecret }}"
+    resource_group_name  = "{{ .StorageResourceGroup }}"
+    storage_account_name = "{{ .StorageAccount }}"
+    container_name       = "{{ .ConfigBucket }}"
+    key                  = "{{ .TFStatePath }}"
+  }
+{{- end }}
+}
+
+resource "azurerm_resource_group" "default" {
+  name     = "${var.deployment}"
+  location = "${var.region}"
+}
+
+{{if .DNSZoneName }}
+resource "azurerm_dns_a_record" "dns" {
+  name                = "${var.dns_record_set_prefix == "" ? "@" : var.dns_record_set_prefix}"
+  zone_name           = "${var.dns_zone_name}"
+  resource_group_name = "${var.dns_zone_resource_group}"
+  ttl                 = 60
+  records             = ["${azurerm_public_ip.atc.ip_address}"]
+}
+{{end}}
+
+resource "azurerm_virtual_network" "default" {
+  name                = "${var.deployment}"
+  location            = "${azurerm_resource_group.default.location}"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+  address_space       = ["${var.network_cidr}"]
+}
+
+resource "azurerm_subnet" "public" {
+  name                 = "${var.deployment}-${var.namespace}-public"
+  resource_group_name  = "${azurerm_resource_group.default.name}"
+  virtual_network_name = "${azurerm_virtual_network.default.name}"
+  address_prefixes     = ["${var.public_cidr}"]
+  service_endpoints    = ["Microsoft.Sql"]
+}
+
+resource "azurerm_subnet" "private" {
+  name                 = "${var.deployment}-${var.namespace}-private"
+  resource_group_name  = "${azurerm_resource_group.default.name}"
+  virtual_network_name = "${azurerm_virtual_network.default.name}"
+  address_prefixes     = ["${var.private_cidr}"]
+  service_endpoints    = ["Microsoft.Sql"]
+}
+
+resource "azurerm_public_ip" "nat" {
+  name                = "${var.deployment}-nat-ip"
+  location            = "${azurerm_resource_group.default.location}"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+  allocation_method   = "Static"
+  sku                 = "Standard"
+}
+
+resource "azurerm_nat_gateway" "nat" {
+  name                = "${var.deployment}-nat"
+  location            = "${azurerm_resource_group.default.location}"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+  sku_name            = "Standard"
+}
+
+resource "azurerm_nat_gateway_public_ip_association" "nat" {
+  nat_gateway_id       = "${azurerm_nat_gateway.nat.id}"
+  public_ip_address_id = "${azurerm_public_ip.nat.id}"
+}
+
+resource "azurerm_subnet_nat_gateway_association" "private" {
+  subnet_id      = "${azurerm_subnet.private.id}"
+  nat_gateway_id = "${azurerm_nat_gateway.nat.id}"
+}
+
+resource "azurerm_public_ip" "director" {
+  name                = "${var.deployment}-director-ip"
+  location            = "${azurerm_resource_group.default.location}"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+  allocation_method   = "Static"
+  sku                 = "Standard"
+}
+
+resource "azurerm_public_ip" "atc" {
+  name                = "${var.deployment}-atc-ip"
+  location            = "${azurerm_resource_group.default.location}"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+  allocation_method   = "Static"
+  sku                 = "Standard"
+}
+
+resource "azurerm_network_security_group" "public" {
+  name                = "${var.deployment}-public"
+  location            = "${azurerm_resource_group.default.location}"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+
+  security_rule {
+    name                       = "director"
+    description                = "External access to BOSH director"
+    priority                   = 100
+    direction                  = "Inbound"
+    access                     = "Allow"
+    protocol                   = "Tcp"
+    source_port_range          = "*"
+    destination_port_ranges    = ["22", "6868", "25555"]
+    source_address_prefixes    = ["${var.source_access_ip}/32", "${azurerm_public_ip.nat.ip_address}/32"]
+    destination_address_prefix = "*"
+  }
+
+  security_rule {
+    name                       = "atc-http"
+    description                = "External access to concourse atc"
+    priority                   = 110
+    direction                  = "Inbound"
+    access                     = "Allow"
+    protocol                   = "Tcp"
+    source_port_range          = "*"
+    destination_port_range     = "80"
+    source_address_prefixes    = [{{ .AllowIPs }}]
+    destination_address_prefix = "*"
+  }
+
+  security_rule {
+    name                       = "atc-https"
+    description                = "External access to concourse atc"
+    priority                   = 120
+    direction                  = "Inbound"
+    access                     = "Allow"
+    protocol                   = "Tcp"
+    source_port_range          = "*"
+    destination_port_ranges    = ["443", "8443"]
+    source_address_prefixes    = ["${azurerm_public_ip.nat.ip_address}/32", "${azurerm_public_ip.atc.ip_address}/32", {{ .AllowIPs }}]
+    destination_address_prefix = "*"
+  }
+
+  security_rule {
+    name                       = "atc-services"
+    description                = "External access to concourse atc services"
+    priority                   = 130
+    direction                  = "Inbound"
+    access                     = "Allow"
+    protocol                   = "Tcp"
+    source_port_range          = "*"
+    destination_port_ranges    = ["3000", "8844"]
+    source_address_prefixes    = ["${azurerm_public_ip.nat.ip_address}/32", "${azurerm_public_ip.atc.ip_address}/32", {{ .AllowIPs }}]
+    destination_address_prefix = "*"
+  }
+}
+
+resource "azurerm_subnet_network_security_group_association" "public" {
+  subnet_id                 = "${azurerm_subnet.public.id}"
+  network_security_group_id = "${azurerm_network_security_group.public.id}"
+}
+
+resource "azurerm_postgresql_server" "director" {
+  name                = "${var.db_name}"
+  location            = "${azurerm_resource_group.default.location}"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+
+  sku_name   = "${var.db_tier}"
+  version    = "11"
+  storage_mb = 10240
+
+  administrator_login          = "${var.db_username}"
+  administrator_login_password = "${var.db_password}"
+
+  ssl_enforcement_enabled          = true
+  ssl_minimal_tls_version_enforced = "TLS1_2"
+
+  tags = {
+    deployment = "${var.deployment}"
+  }
+}
+
+resource "azurerm_postgresql_firewall_rule" "source" {
+  name                = "source"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+  server_name         = "${azurerm_postgresql_server.director.name}"
+  start_ip_address    = "${var.source_access_ip}"
+  end_ip_address      = "${var.source_access_ip}"
+}
+
+resource "azurerm_postgresql_firewall_rule" "atc" {
+  name                = "atc"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+  server_name         = "${azurerm_postgresql_server.director.name}"
+  start_ip_address    = "${azurerm_public_ip.atc.ip_address}"
+  end_ip_address      = "${azurerm_public_ip.atc.ip_address}"
+}
+
+resource "azurerm_postgresql_firewall_rule" "director" {
+  name                = "director"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+  server_name         = "${azurerm_postgresql_server.director.name}"
+  start_ip_address    = "${azurerm_public_ip.director.ip_address}"
+  end_ip_address      = "${azurerm_public_ip.director.ip_address}"
+}
+
+resource "azurerm_postgresql_firewall_rule" "nat" {
+  name                = "nat"
+  resource_group_name = "${azurerm_resource_group.default.name}"
+  server_name         = "${azurerm_postgresql_server.director.name}"
+  start_ip_address    = "${azurerm_public_ip.nat.ip_address}"
+  end_ip_address      = "${azurerm_public_ip.nat.ip_address}"
+}
+
+output "resource_group" {
+  value = "${azurerm_resource_group.default.name}"
+}
+
+output "network" {
+  value = "${azurerm_virtual_network.default.name}"
+}
+
+output "director_security_group_id" {
+  value = "${azurerm_network_security_group.public.id}"
+}
+
+output "private_subnetwork_name" {
+  value = "${azurerm_subnet.private.name}"
+}
+
+output "public_subnetwork_name" {
+  value = "${azurerm_subnet.public.name}"
+}
+
+output "atc_public_ip" {
+  value = "${azurerm_public_ip.atc.ip_address}"
+}
+
+output "director_public_ip" {
+  value = "${azurerm_public_ip.director.ip_address}"
+}
+
+output "nat_gateway_ip" {
+  value = "${azurerm_public_ip.nat.ip_address}"
+}
+
+output "bosh_db_address" {
+  value = "${azurerm_postgresql_server.director.fqdn}"
+}
+
+output "db_name" {
+  value = "${azurerm_postgresql_server.director.name}"
+}
@@ -88,10 +88,31 @@ provider "google" {
 
 
 terraform {
+{{- if eq .StateBackend.Type "local" }}
+	backend "local" {
+		path = "{{ .StateBackend.Path }}"
+	}
+{{- else if eq .StateBackend.Type "s3" }}
+	backend "s3" {
+		bucket                      = "{{ .ConfigBucket }}"
+		key                         = "{{ .TFStatePath }}"
+		region                      = "{{ .StateBackend.Region }}"
+		endpoint                    = "{{ .StateBackend.Endpoint }}"
+{{- if .StateBackend.AccessKeyID }}
+		access_key                  = "{{ .StateBackend.AccessKeyID }}"
+		secret_key                  = "{{ .StateBackend.SecretAccessKey }}"
+{{- end }}
+		force_path_style            = true
+		skip_credentials_validation = true
+		skip_metadata_api_check     = true
+		skip_region_validation      = true
+	}
+{{- else }}
 	backend "gcs" {
 		bucket = "{{ .ConfigBucket }}"
 		region = "{{ .Region }}"
 	}
+{{- end }}
 }
 
 {{if .DNSManagedZoneName }}
@@ -105,7 +126,11 @@ resource "google_dns_record_set" "dns" {
   type    = "A"
   ttl     = 60
 
+{{- if gt .WebCount 1 }}
+  rrdatas = ["${google_compute_address.web_lb.address}"]
+{{- else }}
   rrdatas = ["${google_compute_address.atc_ip.address}"]
+{{- end }}
 }
 {{end}}
 
@@ -130,6 +155,12 @@ resource "google_compute_router_nat" "worker-nat" {
     name                    = "${google_compute_subnetwork.private.self_link}"
     source_ip_ranges_to_nat = ["ALL_IP_RANGES"]
   }
+{{- if gt .WebCount 1 }}
+  subnetwork {
+    name                    = "${google_compute_subnetwork.public.self_link}"
+    source_ip_ranges_to_nat = ["ALL_IP_RANGES"]
+  }
+{{- end }}
   log_config {
     filter = "TRANSLATIONS_ONLY"
     enable = true
@@ -185,7 +216,11 @@ resource "google_compute_firewall" "atc-https" {
   description = "Firewall for external access to concourse atc"
   network     = "${google_compute_network.default.self_link}"
   target_tags = ["web"]
+{{- if gt .WebCount 1 }}
+  source_ranges = ["${google_compute_address.nat_ip.address}/32", {{ .AllowIPs }}]
+{{- else }}
   source_ranges = ["${google_compute_address.nat_ip.address}/32", "${google_compute_address.atc_ip.address}/32", {{ .AllowIPs }}]
+{{- end }}
   allow {
     protocol = "tcp"
     ports = ["443", "8443"]
@@ -244,7 +279,11 @@ resource "google_compute_firewall" "atc-services" {
   description = "Firewall for external access to concourse atc"
   network     = "${google_compute_network.default.self_link}"
   target_tags = ["web"]
+{{- if gt .WebCount 1 }}
+  source_ranges = ["${google_compute_address.nat_ip.address}/32", {{ .AllowIPs }}]
+{{- else }}
   source_ranges = ["${google_compute_address.nat_ip.address}/32", "${google_compute_address.atc_ip.address}/32", {{ .AllowIPs }}]
+{{- end }}
   allow {
     protocol = "tcp"
     ports = ["3000", "8844"]
@@ -287,19 +326,78 @@ resource "google_service_account" "bosh" {
   account_id   = "${var.deployment}-bosh"
   display_name = "bosh"
 }
+{{if or (not .InstanceIdentity) .KeepAccessKeys }}
 resource "google_service_account_key" "bosh" {
   service_account_id = "${google_service_account.bosh.name}"
   public_key_type = "TYPE_X509_PEM_FILE"
 }
+{{end}}
 
 resource "google_project_iam_member" "bosh" {
   project = "${var.project}"
   role    = "roles/owner"
   member  = "serviceAccount:${google_service_account.bosh.email}"
 }
+{{if lt .WebCount 2 }}
 resource "google_compute_address" "atc_ip" {
   name = "${var.deployment}-atc-ip"
 }
+{{end}}
+{{if gt .WebCount 1 }}
+# Several web VMs are spread across two zones behind a network load balancer. They have no public
+# IPs, and reach the internet and Concourse through the NAT gateway.
+data "google_compute_zones" "available" {
+  region = "${var.region}"
+}
+
+locals {
+  # The zone after the deployment's own
+  web_zone = "${element(sort(data.google_compute_zones.available.names), index(sort(data.google_compute_zones.available.names), var.zone) + 1)}"
+}
+
+resource "google_compute_address" "web_lb" {
+  name = "${var.deployment}-web-lb-ip"
+}
+
+# Target pools only support legacy HTTP health checks, and Grafana is the one plain HTTP service on
+# every web VM. It stops along with Concourse whenever BOSH updates the VM.
+resource "google_compute_http_health_check" "web" {
+  name                = "${var.deployment}-web"
+  port                = 3000
+  request_path        = "/api/health"
+  check_interval_sec  = 5
+  timeout_sec         = 5
+  healthy_threshold   = 2
+  unhealthy_threshold = 2
+}
+
+resource "google_compute_target_pool" "web" {
+  name          = "${var.deployment}-web"
+  region        = "${var.region}"
+  health_checks = ["${google_compute_http_health_check.web.name}"]
+}
+
+resource "google_compute_forwarding_rule" "web" {
+  name        = "${var.deployment}-web"
+  region      = "${var.region}"
+  target      = "${google_compute_target_pool.web.self_link}"
+  ip_address  = "${google_compute_address.web_lb.address}"
+  ip_protocol = "TCP"
+  port_range  = "80-8844"
+}
+
+resource "google_compute_firewall" "web-health-checks" {
+  name = "${var.deployment}-web-health-checks"
+  description = "Firewall for load balancer health checks of concourse web VMs"
+  network     = "${google_compute_network.default.self_link}"
+  target_tags = ["web"]
+  source_ranges = ["35.191.0.0/16", "209.85.152.0/22", "209.85.204.0/22"]
+  allow {
+    protocol = "tcp"
+    ports = ["3000"]
+  }
+}
+{{end}}
 
 resource "google_compute_address" "director" {
   name = "${var.deployment}-director-ip"
@@ -323,10 +421,12 @@ resource "google_sql_database_instance" "director" {
     ip_configuration {
       ipv4_enabled = "true"
       authorized_networks = [
+{{- if lt .WebCount 2 }}
         {
           name = "atc_conf"
           value = "${google_compute_address.atc_ip.address}/32"
         },
+{{- end }}
         {
           name = "bosh"
           value = "${google_compute_address.director.address}/32"
@@ -377,12 +477,23 @@ value = "${google_compute_subnetwork.public.gateway_address}"
 }
 
 output "atc_public_ip" {
+{{- if gt .WebCount 1 }}
+value = "${google_compute_address.web_lb.address}"
+{{- else }}
 value = "${google_compute_address.atc_ip.address}"
+{{- end }}
 }
 
+{{if or (not .InstanceIdentity) .KeepAccessKeys }}
 output "director_account_creds" {
   value = "${base64decode(google_service_account_key.bosh.private_key)}"
 }
+{{end}}
+{{if .InstanceIdentity }}
+output "director_service_account" {
+  value = "${google_service_account.bosh.email}"
+}
+{{end}}
 
 output "director_public_ip" {
   value = "${google_compute_address.director.address}"
@@ -403,3 +514,12 @@ output "nat_gateway_ip" {
 output "server_ca_cert" {
   value = "${google_sql_database_instance.director.server_ca_cert.0.cert}"
 }
+{{if gt .WebCount 1 }}
+output "web_zone" {
+  value = "${local.web_zone}"
+}
+
+output "web_target_pool" {
+  value = "${google_compute_target_pool.web.name}"
+}
+{{end}}
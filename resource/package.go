@@ -31,6 +31,14 @@ var (
 	//go:embed assets/aws/s3-blobstore-ops.yml
 	AWSBlobstoreOps string
 
+	// AWSInstanceProfileOps defines instance-profile.yml contents
+	//go:embed assets/aws/instance-profile.yml
+	AWSInstanceProfileOps string
+
+	// AWSSessionTokenOps defines session-token.yml contents
+	//go:embed assets/aws/session-token.yml
+	AWSSessionTokenOps string
+
 	// GCPDirectorCloudConfig statically defines gcp cloud-config.yml
 	//go:embed assets/gcp/cloud-config.yml
 	GCPDirectorCloudConfig string
@@ -51,6 +59,26 @@ var (
 	//go:embed assets/gcp/jumpbox-user.yml
 	GCPJumpboxUserOps string
 
+	// GCPServiceAccountOps defines service-account.yml contents
+	//go:embed assets/gcp/service-account.yml
+	GCPServiceAccountOps string
+
+	// AzureDirectorCloudConfig statically defines azure cloud-config.yml
+	//go:embed assets/azure/cloud-config.yml
+	AzureDirectorCloudConfig string
+
+	// AzureCPIOps statically defines azure cpi.yml contents
+	//go:embed assets/azure/cpi.yml
+	AzureCPIOps string
+
+	// AzureExternalIPOps statically defines azure external-ip.yml contents
+	//go:embed assets/azure/external-ip.yml
+	AzureExternalIPOps string
+
+	// AzureDirectorCustomOps statically defines azure custom-ops.yml contents
+	//go:embed assets/azure/custom-ops.yml
+	AzureDirectorCustomOps string
+
 	// AWSTerraformConfig holds the terraform conf for AWS
 	//go:embed assets/aws/infrastructure.tf
 	AWSTerraformConfig string
@@ -59,12 +87,19 @@ var (
 	//go:embed assets/gcp/infrastructure.tf
 	GCPTerraformConfig string
 
+	// AzureTerraformConfig holds the terraform conf for Azure
+	//go:embed assets/azure/infrastructure.tf
+	AzureTerraformConfig string
+
 	// AWSReleaseVersions carries all versions of releases
 	AWSReleaseVersions = string(opsassets.AwsConcourseVersions)
 
 	// GCPReleaseVersions carries all versions of releases
 	GCPReleaseVersions = string(opsassets.GcpConcourseVersions)
 
+	// AzureReleaseVersions carries all versions of releases
+	AzureReleaseVersions = string(opsassets.AzureConcourseVersions)
+
 	// AddNewCa carries the ops file that adds a new CA required for cert rotation
 	//go:embed assets/maintenance/add-new-ca.yml
 	AddNewCa string
@@ -77,8 +112,13 @@ var (
 	//go:embed assets/maintenance/cleanup-certs.yml
 	CleanupCerts string
 
-	AWSVersionFile = opsassets.AWSVersionFile
-	GCPVersionFile = opsassets.GCPVersionFile
+	// PriceCatalogue holds the prices used to estimate what a deployment costs to run
+	//go:embed assets/prices.yml
+	PriceCatalogue []byte
+
+	AWSVersionFile   = opsassets.AWSVersionFile
+	GCPVersionFile   = opsassets.GCPVersionFile
+	AzureVersionFile = opsassets.AzureVersionFile
 
 	//go:embed assets/gcp/uaa-cert.yml
 	UAACert []byte
@@ -18,8 +18,18 @@ type AWSInputVars struct {
 	Deployment             string
 	HostedZoneID           string
 	HostedZoneRecordPrefix string
-	Namespace              string
-	NetworkCIDR            string
+	// InstanceIdentity replaces the access keys of the director and blobstore with IAM instance profiles,
+	// and KeepAccessKeys keeps the keys too while a deployment moves to them
+	InstanceIdentity bool
+	KeepAccessKeys   bool
+	Namespace        string
+	// NATCIDR is the subnet of the NAT gateway of a private deployment
+	NATCIDR     string
+	NetworkCIDR string
+	// Private leaves out the public IPs of the director and web VMs
+	Private bool
+	// PrivateDomain is given a private hosted zone of its own in the VPC of a private deployment
+	PrivateDomain          string
 	PrivateCIDR            string
 	Project                string
 	PublicCIDR             string
@@ -32,7 +42,13 @@ type AWSInputVars struct {
 	RDS2CIDR               string
 	Region                 string
 	SourceAccessIP         string
+	StateBackend           StateBackend
 	TFStatePath            string
+	// WebCIDRs are the subnets of several web VMs in two availability zones, followed by the public
+	// subnet of their load balancer in the second zone
+	WebCIDRs []string
+	// WebCount above 1 puts the web VMs behind a network load balancer
+	WebCount int
 }
 
 // ConfigureTerraform interpolates terraform contents and returns terraform config
@@ -54,12 +70,13 @@ type AWSOutputs struct {
 	ATCPublicIP              MetadataStringValue `json:"atc_public_ip" valid:"required"`
 	ATCSecurityGroupID       MetadataStringValue `json:"atc_security_group_id" valid:"required"`
 	BlobstoreBucket          MetadataStringValue `json:"blobstore_bucket" valid:"required"`
-	BlobstoreSecretAccessKey MetadataStringValue `json:"blobstore_user_secret_access_key" valid:"required"`
-	BlobstoreUserAccessKeyID MetadataStringValue `json:"blobstore_user_access_key_id" valid:"required"`
+	BlobstoreSecretAccessKey MetadataStringValue `json:"blobstore_user_secret_access_key"`
+	BlobstoreUserAccessKeyID MetadataStringValue `json:"blobstore_user_access_key_id"`
 	BoshDBAddress            MetadataStringValue `json:"bosh_db_address" valid:"required"`
 	BoshDBPort               MetadataStringValue `json:"bosh_db_port" valid:"required"`
-	BoshSecretAccessKey      MetadataStringValue `json:"bosh_user_secret_access_key" valid:"required"`
-	BoshUserAccessKeyID      MetadataStringValue `json:"bosh_user_access_key_id" valid:"required"`
+	BoshSecretAccessKey      MetadataStringValue `json:"bosh_user_secret_access_key"`
+	BoshUserAccessKeyID      MetadataStringValue `json:"bosh_user_access_key_id"`
+	DirectorInstanceProfile  MetadataStringValue `json:"director_instance_profile"`
 	DirectorKeyPair          MetadataStringValue `json:"director_key_pair" valid:"required"`
 	DirectorPublicIP         MetadataStringValue `json:"director_public_ip" valid:"required"`
 	DirectorSecurityGroupID  MetadataStringValue `json:"director_security_group_id" valid:"required"`
@@ -67,8 +84,13 @@ type AWSOutputs struct {
 	PrivateSubnetID          MetadataStringValue `json:"private_subnet_id" valid:"required"`
 	PublicSubnetID           MetadataStringValue `json:"public_subnet_id" valid:"required"`
 	SourceAccessIP           MetadataStringValue `json:"source_access_ip"`
+	VMsInstanceProfile       MetadataStringValue `json:"vms_instance_profile"`
 	VMsSecurityGroupID       MetadataStringValue `json:"vms_security_group_id" valid:"required"`
 	VPCID                    MetadataStringValue `json:"vpc_id" valid:"required"`
+	WebAvailabilityZone      MetadataStringValue `json:"web_availability_zone"`
+	WebSubnetAID             MetadataStringValue `json:"web_subnet_a_id"`
+	WebSubnetBID             MetadataStringValue `json:"web_subnet_b_id"`
+	WebTargetGroups          MetadataStringValue `json:"web_target_groups"`
 }
 
 // AssertValid returns an error if the struct contains any missing fields
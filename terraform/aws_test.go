@@ -3,8 +3,10 @@ package terraform_test
 import (
 	"bytes"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/EngineerBetter/control-tower/resource"
 	. "github.com/EngineerBetter/control-tower/terraform"
 )
 
@@ -149,3 +151,235 @@ func TestAWSMetadata_Init(t *testing.T) {
 		})
 	}
 }
+
+func TestAWSInputVars_ConfigureTerraform_StateBackend(t *testing.T) {
+	tests := []struct {
+		name         string
+		stateBackend StateBackend
+		want         []string
+		wantNot      []string
+	}{
+		{name: "IAAS",
+			stateBackend: StateBackend{},
+			want:         []string{"backend \"s3\" {\n\t\tbucket = \"fakeBucket\"", "region = \"eu-west-1\""},
+			wantNot:      []string{"endpoint", "backend \"local\""},
+		},
+		{name: "Local",
+			stateBackend: StateBackend{Type: "local", Path: "/state/fakeBucket/terraform.tfstate"},
+			want:         []string{"backend \"local\" {\n\t\tpath = \"/state/fakeBucket/terraform.tfstate\""},
+			wantNot:      []string{"backend \"s3\""},
+		},
+		{name: "S3-compatible",
+			stateBackend: StateBackend{Type: "s3", Endpoint: "https://minio.example.com", Region: "us-east-1", AccessKeyID: "fakeKey", SecretAccessKey: "fakeSecret"},
+			want: []string{
+				"endpoint                    = \"https://minio.example.com\"",
+				"region                      = \"us-east-1\"",
+				"access_key                  = \"fakeKey\"",
+				"secret_key                  = \"fakeSecret\"",
+				"force_path_style            = true",
+			},
+			wantNot: []string{"backend \"local\""},
+		},
+		{name: "S3-compatible with ambient credentials",
+			stateBackend: StateBackend{Type: "s3", Endpoint: "https://minio.example.com", Region: "us-east-1"},
+			want:         []string{"endpoint                    = \"https://minio.example.com\""},
+			wantNot:      []string{"access_key                  =", "secret_key                  ="},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &AWSInputVars{
+				ConfigBucket: "fakeBucket",
+				Region:       "eu-west-1",
+				StateBackend: test.stateBackend,
+				TFStatePath:  "terraform.tfstate",
+			}
+			got, err := v.ConfigureTerraform(resource.AWSTerraformConfig)
+			if err != nil {
+				t.Fatalf("InputVars.ConfigureTerraform() test case \"%s\" returned error %v", test.name, err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output to contain \"%v\"", test.name, want)
+				}
+			}
+			for _, wantNot := range test.wantNot {
+				if strings.Contains(got, wantNot) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output not to contain \"%v\"", test.name, wantNot)
+				}
+			}
+		})
+	}
+}
+
+func TestAWSInputVars_ConfigureTerraform_Private(t *testing.T) {
+	tests := []struct {
+		name    string
+		private bool
+		want    []string
+		wantNot []string
+	}{
+		{name: "Public",
+			want:    []string{"resource \"aws_eip\" \"director\"", "value = \"${aws_eip.director.public_ip}\"", "records = [\"${aws_eip.atc.public_ip}\"]"},
+			wantNot: []string{"aws_subnet\" \"nat\"", "nat_cidr", "aws_route53_zone"},
+		},
+		{name: "Private",
+			private: true,
+			want: []string{
+				"default = \"10.0.255.240/28\"",
+				"subnet_id     = \"${aws_subnet.nat.id}\"",
+				"map_public_ip_on_launch = false\n\n  tags {\n    Name = \"${var.deployment}-public\"",
+				"value = \"${cidrhost(var.public_cidr, 6)}\"",
+				"resource \"aws_route53_zone\" \"private\" {\n  name          = \"ci.example.com\"",
+				"zone_id = \"${aws_route53_zone.private.zone_id}\"\n  name    = \"ci.example.com\"",
+				"records = [\"${cidrhost(var.public_cidr, 8)}\"]",
+				"cidr_blocks = [\"${var.network_cidr}\", \"10.100.0.0/16\"]",
+			},
+			wantNot: []string{"aws_eip.director", "aws_eip.atc", "var.source_access_ip}/32", "zone_id = \"${var.hosted_zone_id}\""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &AWSInputVars{
+				AllowIPs:     `"10.100.0.0/16"`,
+				ConfigBucket: "fakeBucket",
+				HostedZoneID: "fakeZone",
+				NATCIDR:      "10.0.255.240/28",
+				Private:      test.private,
+				Region:       "eu-west-1",
+			}
+			if test.private {
+				v.PrivateDomain = "ci.example.com"
+			}
+			got, err := v.ConfigureTerraform(resource.AWSTerraformConfig)
+			if err != nil {
+				t.Fatalf("InputVars.ConfigureTerraform() test case \"%s\" returned error %v", test.name, err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output to contain \"%v\"", test.name, want)
+				}
+			}
+			for _, wantNot := range test.wantNot {
+				if strings.Contains(got, wantNot) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output not to contain \"%v\"", test.name, wantNot)
+				}
+			}
+		})
+	}
+}
+
+func TestAWSInputVars_ConfigureTerraform_WebLoadBalancer(t *testing.T) {
+	tests := []struct {
+		name     string
+		webCount int
+		want     []string
+		wantNot  []string
+	}{
+		{name: "One web node",
+			webCount: 1,
+			want:     []string{"resource \"aws_eip\" \"atc\"", "value = \"${aws_eip.atc.public_ip}\""},
+			wantNot:  []string{"aws_lb", "web_a_cidr", "output \"web_target_groups\""},
+		},
+		{name: "Several web nodes",
+			webCount: 3,
+			want: []string{
+				"default = \"10.0.255.224/28\"",
+				"default = \"10.0.255.208/28\"",
+				"default = \"10.0.255.192/28\"",
+				"load_balancer_type               = \"network\"",
+				"records = [\"${aws_eip.web_lb.*.public_ip}\"]",
+				"value = \"${aws_eip.web_lb.0.public_ip}\"",
+				"cidr_blocks = [\"${var.network_cidr}\", \"${aws_eip.nat.public_ip}/32\", \"10.100.0.0/16\"]",
+				"output \"web_target_groups\"",
+			},
+			wantNot: []string{"aws_eip.atc", "resource \"aws_subnet\" \"nat\""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &AWSInputVars{
+				AllowIPs:     `"10.100.0.0/16"`,
+				ConfigBucket: "fakeBucket",
+				HostedZoneID: "fakeZone",
+				Region:       "eu-west-1",
+				WebCIDRs:     []string{"10.0.255.224/28", "10.0.255.208/28", "10.0.255.192/28"},
+				WebCount:     test.webCount,
+			}
+			got, err := v.ConfigureTerraform(resource.AWSTerraformConfig)
+			if err != nil {
+				t.Fatalf("InputVars.ConfigureTerraform() test case \"%s\" returned error %v", test.name, err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output to contain \"%v\"", test.name, want)
+				}
+			}
+			for _, wantNot := range test.wantNot {
+				if strings.Contains(got, wantNot) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output not to contain \"%v\"", test.name, wantNot)
+				}
+			}
+		})
+	}
+}
+
+func TestAWSInputVars_ConfigureTerraform_InstanceIdentity(t *testing.T) {
+	tests := []struct {
+		name             string
+		instanceIdentity bool
+		keepAccessKeys   bool
+		want             []string
+		wantNot          []string
+	}{
+		{name: "Access keys",
+			want:    []string{"resource \"aws_iam_access_key\" \"bosh\"", "output \"bosh_user_secret_access_key\""},
+			wantNot: []string{"aws_iam_instance_profile", "output \"director_instance_profile\""},
+		},
+		{name: "Instance identity",
+			instanceIdentity: true,
+			want: []string{
+				"resource \"aws_iam_instance_profile\" \"bosh\"",
+				"resource \"aws_iam_instance_profile\" \"blobstore\"",
+				"output \"director_instance_profile\"",
+				"output \"vms_instance_profile\"",
+			},
+			wantNot: []string{"aws_iam_access_key", "aws_iam_user\"", "output \"bosh_user_secret_access_key\""},
+		},
+		{name: "Instance identity while migrating from access keys",
+			instanceIdentity: true,
+			keepAccessKeys:   true,
+			want: []string{
+				"resource \"aws_iam_instance_profile\" \"bosh\"",
+				"resource \"aws_iam_access_key\" \"bosh\"",
+				"output \"bosh_user_secret_access_key\"",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &AWSInputVars{
+				AllowIPs:         `"10.100.0.0/16"`,
+				ConfigBucket:     "fakeBucket",
+				HostedZoneID:     "fakeZone",
+				InstanceIdentity: test.instanceIdentity,
+				KeepAccessKeys:   test.keepAccessKeys,
+				Region:           "eu-west-1",
+			}
+			got, err := v.ConfigureTerraform(resource.AWSTerraformConfig)
+			if err != nil {
+				t.Fatalf("InputVars.ConfigureTerraform() test case \"%s\" returned error %v", test.name, err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output to contain \"%v\"", test.name, want)
+				}
+			}
+			for _, wantNot := range test.wantNot {
+				if strings.Contains(got, wantNot) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output not to contain \"%v\"", test.name, wantNot)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	"github.com/EngineerBetter/control-tower/util"
+	"github.com/asaskevich/govalidator"
+)
+
+// AzureInputVars holds all the parameters Azure IAAS needs
+type AzureInputVars struct {
+	AllowIPs             string
+	ClientID             string
+	ClientSecret         string
+	ConfigBucket         string
+	DBName               string
+	DBPassword           string
+	DBTier               string
+	DBUsername           string
+	Deployment           string
+	DNSRecordSetPrefix   string
+	DNSZoneName          string
+	DNSZoneResourceGroup string
+	ExternalIP           string
+	Namespace            string
+	NetworkCIDR          string
+	PrivateCIDR          string
+	PublicCIDR           string
+	Region               string
+	StorageAccount       string
+	StorageResourceGroup string
+	StateBackend         StateBackend
+	SubscriptionID       string
+	TenantID             string
+	TFStatePath          string
+}
+
+// ConfigureTerraform interpolates terraform contents and returns terraform config
+func (v *AzureInputVars) ConfigureTerraform(terraformContents string) (string, error) {
+	terraformConfig, err := util.RenderTemplate("terraform", terraformContents, v)
+	if terraformConfig == nil {
+		return "", err
+	}
+	return string(terraformConfig), err
+}
+
+// AzureOutputs represents output from terraform on Azure
+type AzureOutputs struct {
+	ATCPublicIP             MetadataStringValue `json:"atc_public_ip" valid:"required"`
+	BoshDBAddress           MetadataStringValue `json:"bosh_db_address" valid:"required"`
+	DBName                  MetadataStringValue `json:"db_name" valid:"required"`
+	DirectorPublicIP        MetadataStringValue `json:"director_public_ip" valid:"required"`
+	DirectorSecurityGroupID MetadataStringValue `json:"director_security_group_id" valid:"required"`
+	NatGatewayIP            MetadataStringValue `json:"nat_gateway_ip" valid:"required"`
+	Network                 MetadataStringValue `json:"network" valid:"required"`
+	PrivateSubnetworkName   MetadataStringValue `json:"private_subnetwork_name" valid:"required"`
+	PublicSubnetworkName    MetadataStringValue `json:"public_subnetwork_name" valid:"required"`
+	ResourceGroup           MetadataStringValue `json:"resource_group" valid:"required"`
+}
+
+// AssertValid returns an error if the struct contains any missing fields
+func (outputs *AzureOutputs) AssertValid() error {
+	_, err := govalidator.ValidateStruct(outputs)
+	return err
+}
+
+// Init populates outputs struct with values from the buffer
+func (outputs *AzureOutputs) Init(buffer *bytes.Buffer) error {
+	if err := json.NewDecoder(buffer).Decode(&outputs); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get returns a the specified value from the outputs struct
+func (outputs *AzureOutputs) Get(key string) (string, error) {
+	reflectValue := reflect.ValueOf(outputs)
+	reflectStruct := reflectValue.Elem()
+	value := reflectStruct.FieldByName(key)
+	if !value.IsValid() {
+		return "", errors.New(key + " key not found")
+	}
+
+	return value.FieldByName("Value").String(), nil
+}
@@ -23,13 +23,21 @@ type GCPInputVars struct {
 	DNSRecordSetPrefix string
 	ExternalIP         string
 	GCPCredentialsJSON string
-	Namespace          string
-	PrivateCIDR        string
-	Project            string
-	PublicCIDR         string
-	Region             string
-	Tags               string
-	Zone               string
+	// InstanceIdentity attaches the director's service account to its VM rather than exporting a key for
+	// it, and KeepAccessKeys keeps the key too while a deployment moves to it
+	InstanceIdentity bool
+	KeepAccessKeys   bool
+	Namespace        string
+	PrivateCIDR      string
+	Project          string
+	PublicCIDR       string
+	Region           string
+	StateBackend     StateBackend
+	Tags             string
+	TFStatePath      string
+	// WebCount above 1 puts the web VMs behind a network load balancer
+	WebCount int
+	Zone     string
 }
 
 // ConfigureTerraform interpolates terraform contents and returns terraform config
@@ -46,8 +54,9 @@ type GCPOutputs struct {
 	ATCPublicIP                 MetadataStringValue `json:"atc_public_ip" valid:"required"`
 	BoshDBAddress               MetadataStringValue `json:"bosh_db_address" valid:"required"`
 	DBName                      MetadataStringValue `json:"db_name" valid:"required"`
-	DirectorAccountCreds        MetadataStringValue `json:"director_account_creds" valid:"required"`
+	DirectorAccountCreds        MetadataStringValue `json:"director_account_creds"`
 	DirectorPublicIP            MetadataStringValue `json:"director_public_ip" valid:"required"`
+	DirectorServiceAccount      MetadataStringValue `json:"director_service_account"`
 	DirectorSecurityGroupID     MetadataStringValue `json:"director_firewall_name" valid:"required"`
 	NatGatewayIP                MetadataStringValue `json:"nat_gateway_ip" valid:"required"`
 	Network                     MetadataStringValue `json:"network" valid:"required"`
@@ -56,6 +65,8 @@ type GCPOutputs struct {
 	PublicSubnetworkInternalGw  MetadataStringValue `json:"public_subnetwork_internal_gw" valid:"required"`
 	PublicSubnetworkName        MetadataStringValue `json:"public_subnetwork_name" valid:"required"`
 	SQLServerCert               MetadataStringValue `json:"server_ca_cert" valid:"required"`
+	WebTargetPool               MetadataStringValue `json:"web_target_pool"`
+	WebZone                     MetadataStringValue `json:"web_zone"`
 }
 
 // AssertValid returns an error if the struct contains any missing fields
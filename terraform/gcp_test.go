@@ -3,8 +3,10 @@ package terraform_test
 import (
 	"bytes"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/EngineerBetter/control-tower/resource"
 	. "github.com/EngineerBetter/control-tower/terraform"
 )
 
@@ -155,3 +157,101 @@ func TestGCPMetadata_Init(t *testing.T) {
 		})
 	}
 }
+
+func TestGCPInputVars_ConfigureTerraform_WebLoadBalancer(t *testing.T) {
+	tests := []struct {
+		name     string
+		webCount int
+		want     []string
+		wantNot  []string
+	}{
+		{name: "One web node",
+			webCount: 1,
+			want:     []string{"resource \"google_compute_address\" \"atc_ip\"", "value = \"${google_compute_address.atc_ip.address}/32\""},
+			wantNot:  []string{"google_compute_target_pool", "output \"web_zone\""},
+		},
+		{name: "Several web nodes",
+			webCount: 2,
+			want: []string{
+				"resource \"google_compute_target_pool\" \"web\"",
+				"ip_address  = \"${google_compute_address.web_lb.address}\"",
+				"rrdatas = [\"${google_compute_address.web_lb.address}\"]",
+				"name                    = \"${google_compute_subnetwork.public.self_link}\"",
+				"output \"web_target_pool\"",
+			},
+			wantNot: []string{"atc_ip"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &GCPInputVars{
+				AllowIPs:           `"10.100.0.0/16"`,
+				DNSManagedZoneName: "fakeZone",
+				WebCount:           test.webCount,
+			}
+			got, err := v.ConfigureTerraform(resource.GCPTerraformConfig)
+			if err != nil {
+				t.Fatalf("InputVars.ConfigureTerraform() test case \"%s\" returned error %v", test.name, err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output to contain \"%v\"", test.name, want)
+				}
+			}
+			for _, wantNot := range test.wantNot {
+				if strings.Contains(got, wantNot) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output not to contain \"%v\"", test.name, wantNot)
+				}
+			}
+		})
+	}
+}
+
+func TestGCPInputVars_ConfigureTerraform_InstanceIdentity(t *testing.T) {
+	tests := []struct {
+		name             string
+		instanceIdentity bool
+		keepAccessKeys   bool
+		want             []string
+		wantNot          []string
+	}{
+		{name: "Service account key",
+			want:    []string{"resource \"google_service_account_key\" \"bosh\"", "output \"director_account_creds\""},
+			wantNot: []string{"output \"director_service_account\""},
+		},
+		{name: "Instance identity",
+			instanceIdentity: true,
+			want:             []string{"resource \"google_service_account\" \"bosh\"", "output \"director_service_account\""},
+			wantNot:          []string{"google_service_account_key", "output \"director_account_creds\""},
+		},
+		{name: "Instance identity while migrating from a service account key",
+			instanceIdentity: true,
+			keepAccessKeys:   true,
+			want:             []string{"resource \"google_service_account_key\" \"bosh\"", "output \"director_service_account\""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &GCPInputVars{
+				AllowIPs:           `"10.100.0.0/16"`,
+				DNSManagedZoneName: "fakeZone",
+				InstanceIdentity:   test.instanceIdentity,
+				KeepAccessKeys:     test.keepAccessKeys,
+			}
+			got, err := v.ConfigureTerraform(resource.GCPTerraformConfig)
+			if err != nil {
+				t.Fatalf("InputVars.ConfigureTerraform() test case \"%s\" returned error %v", test.name, err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output to contain \"%v\"", test.name, want)
+				}
+			}
+			for _, wantNot := range test.wantNot {
+				if strings.Contains(got, wantNot) {
+					t.Errorf("InputVars.ConfigureTerraform() test case \"%s\" failed\nExpected output not to contain \"%v\"", test.name, wantNot)
+				}
+			}
+		})
+	}
+}
@@ -23,6 +23,39 @@ type InputVars interface {
 	ConfigureTerraform(string) (string, error)
 }
 
+// StateBackend overrides where terraform keeps its state.
+// An empty Type keeps state alongside the config in the IAAS' own storage.
+type StateBackend struct {
+	Type            string
+	Path            string
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// WithoutStoredState returns a copy of config whose terraform keeps its state in its own working
+// directory, for plans of a deployment whose bucket has not been created yet, and so cannot hold
+// state or be initialised as a backend
+func WithoutStoredState(config InputVars) InputVars {
+	local := StateBackend{Type: "local", Path: "terraform.tfstate"}
+	switch v := config.(type) {
+	case *AWSInputVars:
+		c := *v
+		c.StateBackend = local
+		return &c
+	case *GCPInputVars:
+		c := *v
+		c.StateBackend = local
+		return &c
+	case *AzureInputVars:
+		c := *v
+		c.StateBackend = local
+		return &c
+	}
+	return config
+}
+
 //counterfeiter:generate . Outputs
 // Outputs holds IAAS specific terraform outputs
 type Outputs interface {
@@ -35,6 +68,7 @@ type Outputs interface {
 //CLIInterface is the abstraction of execCmd
 type CLIInterface interface {
 	Apply(InputVars) error
+	Plan(InputVars) (string, error)
 	Destroy(InputVars) error
 	BuildOutput(InputVars) (Outputs, error)
 }
@@ -53,6 +87,8 @@ func outputsFor(name iaas.Name) (Outputs, error) {
 		return &AWSOutputs{}, nil
 	case iaas.GCP:
 		return &GCPOutputs{}, nil
+	case iaas.Azure:
+		return &AzureOutputs{}, nil
 	}
 	return &NullOutputs{}, errors.New("terraform: " + name.String() + " not a valid iaas provider")
 }
@@ -129,6 +165,11 @@ func (c *CLI) init(config InputVars) (string, error) {
 		if err != nil {
 			return "", err
 		}
+	case iaas.Azure:
+		tfConfig, err = config.ConfigureTerraform(resource.AzureTerraformConfig)
+		if err != nil {
+			return "", err
+		}
 	}
 
 	terraformConfigPath, err := writeTempFile([]byte(tfConfig))
@@ -164,6 +205,27 @@ func (c *CLI) Apply(config InputVars) error {
 	return cmd.Run()
 }
 
+// Plan runs terraform plan for a given config and returns the changes it would make
+func (c *CLI) Plan(config InputVars) (string, error) {
+	terraformConfigPath, err := c.init(config)
+	if err != nil {
+		return "", err
+	}
+
+	defer os.RemoveAll(terraformConfigPath)
+
+	stdoutBuffer := bytes.NewBuffer(nil)
+	cmd := c.execCmd(c.Path, "plan", "-input=false", "-no-color")
+	cmd.Dir = terraformConfigPath
+	cmd.Stderr = os.Stderr
+	cmd.Stdout = stdoutBuffer
+	if err = cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return stdoutBuffer.String(), nil
+}
+
 // Destroy destroys terraform resources specified in a config file
 func (c *CLI) Destroy(config InputVars) error {
 	terraformConfigPath, err := c.init(config)
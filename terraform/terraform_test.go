@@ -2,14 +2,28 @@ package terraform_test
 
 import (
 	"bytes"
-	"github.com/EngineerBetter/control-tower/iaas"
+	"fmt"
+	"os"
+	"strconv"
 	"testing"
 
+	"github.com/EngineerBetter/control-tower/iaas"
+
 	"github.com/EngineerBetter/control-tower/internal/fakeexec"
+	"github.com/EngineerBetter/control-tower/resource"
 	"github.com/EngineerBetter/control-tower/terraform"
 	"github.com/stretchr/testify/require"
 )
 
+func TestExecCommandHelper(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	fmt.Print(os.Getenv("STDOUT"))
+	i, _ := strconv.Atoi(os.Getenv("EXIT_STATUS"))
+	os.Exit(i)
+}
+
 type mockTerraformInputVars struct{}
 type mockOutputs struct{}
 
@@ -75,6 +89,31 @@ func TestCLI_ApplyPlan(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestCLI_Plan(t *testing.T) {
+	e := fakeexec.New(t)
+	defer e.Finish()
+	mockCLIent, err := terraform.New(iaas.AWS, terraform.FakeExec(e.Cmd()))
+	require.NoError(t, err)
+
+	config := &mockTerraformInputVars{}
+
+	e.ExpectFunc(func(t testing.TB, command string, args ...string) {
+		require.Equal(t, "terraform", command)
+		require.Equal(t, args[0], "init")
+
+	})
+	e.ExpectFunc(func(t testing.TB, command string, args ...string) {
+		require.Equal(t, "terraform", command)
+		require.Equal(t, args[0], "plan")
+		require.Equal(t, args[1], "-input=false")
+		require.Equal(t, args[2], "-no-color")
+
+	}).Outputs("Plan: 1 to add, 0 to change, 0 to destroy.")
+	plan, err := mockCLIent.Plan(config)
+	require.NoError(t, err)
+	require.Equal(t, "Plan: 1 to add, 0 to change, 0 to destroy.", plan)
+}
+
 func TestCLI_Destroy(t *testing.T) {
 	e := fakeexec.New(t)
 	defer e.Finish()
@@ -97,3 +136,29 @@ func TestCLI_Destroy(t *testing.T) {
 	err = mockCLIent.Destroy(config)
 	require.NoError(t, err)
 }
+
+func TestWithoutStoredState(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        terraform.InputVars
+		template      string
+		storedBackend string
+	}{
+		{name: "AWS", config: &terraform.AWSInputVars{ConfigBucket: "fakeBucket", Region: "eu-west-1"}, template: resource.AWSTerraformConfig, storedBackend: `backend "s3"`},
+		{name: "GCP", config: &terraform.GCPInputVars{ConfigBucket: "fakeBucket"}, template: resource.GCPTerraformConfig, storedBackend: `backend "gcs"`},
+		{name: "Azure", config: &terraform.AzureInputVars{ConfigBucket: "fakeBucket"}, template: resource.AzureTerraformConfig, storedBackend: `backend "azurerm"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := terraform.WithoutStoredState(tt.config).ConfigureTerraform(tt.template)
+			require.NoError(t, err)
+			require.Contains(t, got, "backend \"local\" {\n")
+			require.Contains(t, got, `path = "terraform.tfstate"`)
+			require.NotContains(t, got, tt.storedBackend)
+
+			stored, err := tt.config.ConfigureTerraform(tt.template)
+			require.NoError(t, err)
+			require.Contains(t, stored, tt.storedBackend)
+		})
+	}
+}
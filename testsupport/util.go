@@ -36,3 +36,18 @@ func SetupFakeCredsForGCPProvider(t *testing.T) string {
 	}
 	return filePath.Name()
 }
+
+func SetupFakeCredsForAzureProvider(t *testing.T) {
+	for _, envVar := range []string{
+		"AZURE_SUBSCRIPTION_ID",
+		"AZURE_TENANT_ID",
+		"AZURE_CLIENT_ID",
+		"AZURE_CLIENT_SECRET",
+		"AZURE_STORAGE_ACCOUNT",
+		"AZURE_STORAGE_RESOURCE_GROUP",
+	} {
+		if err := os.Setenv(envVar, "fake"); err != nil {
+			t.Errorf("cannot set %v", err)
+		}
+	}
+}
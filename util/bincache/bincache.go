@@ -2,84 +2,165 @@ package bincache
 
 import (
 	"archive/zip"
-	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
-// Download a file from url and check with a sha1
-func Download(url string) (string, error) {
-	path, err := os.UserCacheDir()
+// Mirror is a base URL or local directory to fetch binaries from instead of their public URLs.
+// Binaries are looked up in it by the file name at the end of their public URL.
+var Mirror string
+
+// Warnings is where downloads that cannot be verified are reported
+var Warnings io.Writer = os.Stderr
+
+// Download fetches the binary at url, or its copy in Mirror, checks it against sha256sum, and caches
+// it. Downloads are written to a temporary file and renamed into the cache, so that concurrent runs
+// never see a partial binary. A binary without a sha256sum is refused from Mirror, whose copies are
+// only trusted once verified, and downloaded from its public URL with a warning.
+func Download(url, sha256sum string) (string, error) {
+	if sha256sum == "" && Mirror != "" {
+		return "", fmt.Errorf("no SHA256 is known for %s, so its copy in mirror %s cannot be verified", url, Mirror)
+	}
+
+	dir, err := os.UserCacheDir()
 	if err != nil {
 		return "", err
 	}
-	path = filepath.Join(path, "control-tower", "bin")
-	err = os.MkdirAll(path, 0700)
+	dir = filepath.Join(dir, "control-tower", "bin")
+	err = os.MkdirAll(dir, 0700)
 	if err != nil {
 		return "", err
 	}
-	path = filepath.Join(path, hash(url))
-	if _, err = os.Stat(path); !os.IsNotExist(err) {
-		return path, nil
+	cachePath := filepath.Join(dir, hash(url+sha256sum))
+	if _, err = os.Stat(cachePath); err == nil {
+		return cachePath, nil
 	}
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0700)
+
+	source := mirrored(url)
+	if sha256sum == "" {
+		fmt.Fprintf(Warnings, "WARNING: no SHA256 is known for %s, so it will not be verified\n", source)
+	}
+	download, err := ioutil.TempFile(dir, ".download-")
 	if err != nil {
 		return "", err
 	}
-	defer f.Close()
-	resp, err := http.Get(url)
+	defer os.Remove(download.Name())
+	defer download.Close()
+
+	checksum := sha256.New()
+	contentType, err := fetch(source, io.MultiWriter(download, checksum))
 	if err != nil {
-		os.Remove(path)
+		return "", fmt.Errorf("failed to download %s: [%v]", source, err)
+	}
+	if got := hex.EncodeToString(checksum.Sum(nil)); sha256sum != "" && got != strings.ToLower(sha256sum) {
+		return "", fmt.Errorf("%s has SHA256 %s, expected %s", source, got, sha256sum)
+	}
+
+	binary := download
+	if isZip(source, contentType) {
+		if binary, err = extractFirstFile(download, dir); err != nil {
+			return "", fmt.Errorf("failed to extract %s: [%v]", source, err)
+		}
+		defer os.Remove(binary.Name())
+		defer binary.Close()
+	}
+
+	if err = binary.Chmod(0700); err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
+	if err = binary.Close(); err != nil {
+		return "", err
+	}
+	if err = os.Rename(binary.Name(), cachePath); err != nil {
+		return "", err
+	}
+	return cachePath, nil
+}
 
-	var closer io.ReadCloser
+// mirrored returns where to fetch url from, taking Mirror into account
+func mirrored(url string) string {
+	if Mirror == "" {
+		return url
+	}
+	name := path.Base(strings.SplitN(url, "?", 2)[0])
+	if isURL(Mirror) {
+		return strings.TrimSuffix(Mirror, "/") + "/" + name
+	}
+	return filepath.Join(Mirror, name)
+}
 
-	if isZip(url, resp) {
-		closer, err = handleZipFile(resp)
+// fetch writes the contents of a URL or local file to w, returning its content type if known
+func fetch(source string, w io.Writer) (string, error) {
+	if !isURL(source) {
+		f, err := os.Open(source)
 		if err != nil {
-			return "", nil
+			return "", err
 		}
-	} else {
-		closer = resp.Body
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return "", err
 	}
-	_, err = io.Copy(f, closer)
+
+	resp, err := http.Get(source)
 	if err != nil {
-		os.Remove(path)
 		return "", err
 	}
-	return path, nil
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return resp.Header.Get("Content-Type"), err
 }
 
-func handleZipFile(resp *http.Response) (io.ReadCloser, error) {
-	body, errz := ioutil.ReadAll(resp.Body)
-	if errz != nil {
-		return nil, errz
+// extractFirstFile writes the first file in the zip archive f to a temporary file in dir
+func extractFirstFile(f *os.File, dir string) (*os.File, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
 	}
-	r, errz := zip.NewReader(bytes.NewReader(body), resp.ContentLength)
-	if errz != nil {
-		return nil, errz
+	r, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	if len(r.File) == 0 {
+		return nil, fmt.Errorf("archive is empty")
+	}
+	firstFile, err := r.File[0].Open()
+	if err != nil {
+		return nil, err
 	}
-	firstFile, errz := r.File[0].Open()
-	if errz != nil {
-		return nil, errz
+	defer firstFile.Close()
+
+	extracted, err := ioutil.TempFile(dir, ".extract-")
+	if err != nil {
+		return nil, err
 	}
-	return firstFile, nil
+	if _, err = io.Copy(extracted, firstFile); err != nil {
+		extracted.Close()
+		os.Remove(extracted.Name())
+		return nil, err
+	}
+	return extracted, nil
+}
 
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
 }
 
-func isZip(url string, resp *http.Response) bool {
-	if strings.HasSuffix(url, ".zip") {
+func isZip(source, contentType string) bool {
+	if strings.HasSuffix(source, ".zip") {
 		return true
 	}
-	if resp.Header.Get("Content-Type") == "application/zip" {
+	if contentType == "application/zip" {
 		return true
 	}
 	return false
@@ -3,6 +3,8 @@ package bincache_test
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,6 +12,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/EngineerBetter/control-tower/util/bincache"
@@ -22,7 +27,7 @@ func TestDownload(t *testing.T) {
 	}))
 
 	defer s.Close()
-	path, err := bincache.Download(s.URL)
+	path, err := bincache.Download(s.URL, "")
 	require.NoError(t, err)
 	defer os.Remove(path)
 	out, err := exec.Command(path).Output()
@@ -31,7 +36,7 @@ func TestDownload(t *testing.T) {
 
 	// check download does not happen if file already exists
 	s.Close()
-	path1, err := bincache.Download(s.URL)
+	path1, err := bincache.Download(s.URL, "")
 	require.NoError(t, err)
 	require.Equal(t, path, path1)
 }
@@ -57,7 +62,7 @@ func TestDownloadZip(t *testing.T) {
 		w.Write(buf.Bytes())
 	}))
 
-	path, err := bincache.Download(s.URL)
+	path, err := bincache.Download(s.URL, "")
 	require.NoError(t, err)
 	defer os.Remove(path)
 	out, err := exec.Command(path).Output()
@@ -65,3 +70,131 @@ func TestDownloadZip(t *testing.T) {
 	require.Equal(t, "HELLO\n", string(out))
 	s.Close()
 }
+
+func TestDownloadVerifiesChecksum(t *testing.T) {
+	const script = "#!/bin/bash\necho verified"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, script)
+	}))
+	defer s.Close()
+
+	_, err := bincache.Download(s.URL+"/tampered", "0000000000000000000000000000000000000000000000000000000000000000")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "expected 0000")
+
+	// a binary that failed verification is not cached
+	_, err = bincache.Download(s.URL+"/tampered", "0000000000000000000000000000000000000000000000000000000000000000")
+	require.Error(t, err)
+
+	sum := sha256.Sum256([]byte(script))
+	path, err := bincache.Download(s.URL+"/verified", strings.ToUpper(hex.EncodeToString(sum[:])))
+	require.NoError(t, err)
+	defer os.Remove(path)
+	out, err := exec.Command(path).Output()
+	require.NoError(t, err)
+	require.Equal(t, "verified\n", string(out))
+}
+
+func TestDownloadFailsOnErrorResponse(t *testing.T) {
+	s := httptest.NewServer(http.NotFoundHandler())
+	defer s.Close()
+
+	_, err := bincache.Download(s.URL+"/missing", "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "404 Not Found")
+}
+
+func TestDownloadFromMirror(t *testing.T) {
+	defer func() { bincache.Mirror = "" }()
+
+	dir, err := ioutil.TempDir("", "mirror")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bosh-cli-linux-amd64"), []byte("#!/bin/bash\necho local"), 0600))
+
+	bincache.Mirror = dir
+	path, err := bincache.Download(fmt.Sprintf("https://unreachable.invalid/%d/bosh-cli-linux-amd64", os.Getpid()), sha256sum("#!/bin/bash\necho local"))
+	require.NoError(t, err)
+	defer os.Remove(path)
+	out, err := exec.Command(path).Output()
+	require.NoError(t, err)
+	require.Equal(t, "local\n", string(out))
+
+	var requested string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		io.WriteString(w, "#!/bin/bash\necho remote")
+	}))
+	defer s.Close()
+
+	bincache.Mirror = s.URL + "/artifacts/"
+	path, err = bincache.Download(fmt.Sprintf("https://unreachable.invalid/%d/terraform?arch=amd64", os.Getpid()), sha256sum("#!/bin/bash\necho remote"))
+	require.NoError(t, err)
+	defer os.Remove(path)
+	require.Equal(t, "/artifacts/terraform", requested)
+}
+
+func TestDownloadWithoutChecksum(t *testing.T) {
+	defer func() { bincache.Warnings = os.Stderr }()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "#!/bin/bash\necho unverified")
+	}))
+	defer s.Close()
+
+	warnings := new(bytes.Buffer)
+	bincache.Warnings = warnings
+	path, err := bincache.Download(s.URL+"/unverified", "")
+	require.NoError(t, err)
+	defer os.Remove(path)
+	require.Contains(t, warnings.String(), "WARNING: no SHA256 is known for "+s.URL+"/unverified, so it will not be verified")
+}
+
+func TestDownloadFromMirrorRequiresChecksum(t *testing.T) {
+	defer func() { bincache.Mirror = "" }()
+
+	requested := false
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		io.WriteString(w, "#!/bin/bash\necho unverified")
+	}))
+	defer s.Close()
+
+	bincache.Mirror = s.URL
+	_, err := bincache.Download(fmt.Sprintf("https://unreachable.invalid/%d/bosh-cli-linux-amd64", os.Getpid()), "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot be verified")
+	require.False(t, requested)
+}
+
+func TestDownloadConcurrently(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "#!/bin/bash\necho concurrent")
+	}))
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = bincache.Download(s.URL+"/concurrent", "")
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	path, err := bincache.Download(s.URL+"/concurrent", "")
+	require.NoError(t, err)
+	defer os.Remove(path)
+	out, err := exec.Command(path).Output()
+	require.NoError(t, err)
+	require.Equal(t, "concurrent\n", string(out))
+}
+
+func sha256sum(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
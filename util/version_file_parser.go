@@ -17,6 +17,10 @@ type Resource struct {
 type BinaryPaths struct {
 	Mac   string `json:"mac"`
 	Linux string `json:"linux"`
+	// MacSHA256 and LinuxSHA256 are the checksums of the files at Mac and Linux. When they are empty
+	// downloads are not verified, and are refused from a binary mirror
+	MacSHA256   string `json:"mac_sha256,omitempty"`
+	LinuxSHA256 string `json:"linux_sha256,omitempty"`
 }
 
 func ParseVersionResources(versionFile []byte) map[string]Resource {
@@ -49,14 +53,25 @@ func (p BinaryPaths) path() string {
 	}
 }
 
+func (p BinaryPaths) sha256() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return p.MacSHA256
+	case "linux":
+		return p.LinuxSHA256
+	default:
+		panic("OS not supported")
+	}
+}
+
 // DownloadBOSHCLI returns the path of the downloaded bosh-cli
 func DownloadBOSHCLI(binaries map[string]BinaryPaths) (string, error) {
-	p := binaries["bosh-cli"].path()
-	return bincache.Download(p)
+	b := binaries["bosh-cli"]
+	return bincache.Download(b.path(), b.sha256())
 }
 
 // DownloadTerraformCLI returns the path of the downloaded terraform-cli
 func DownloadTerraformCLI(binaries map[string]BinaryPaths) (string, error) {
-	p := binaries["terraform"].path()
-	return bincache.Download(p)
+	b := binaries["terraform"]
+	return bincache.Download(b.path(), b.sha256())
 }
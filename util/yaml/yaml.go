@@ -1,6 +1,10 @@
 package yaml
 
 import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/cloudfoundry/bosh-cli/director/template"
@@ -51,3 +55,126 @@ func Path(b []byte, path string) (string, error) {
 	})
 	return string(x), err
 }
+
+// RemoveCertificates deletes every certificate, along with its CA and private key, from a BOSH vars store
+// so that it is generated again on the next deploy. Other variables are kept.
+func RemoveCertificates(b []byte) ([]byte, error) {
+	var vars map[string]interface{}
+	if err := yamlenc.Unmarshal(b, &vars); err != nil {
+		return nil, err
+	}
+	for name, value := range vars {
+		if v, ok := value.(map[string]interface{}); ok {
+			if _, isCertificate := v["certificate"]; isCertificate {
+				delete(vars, name)
+			}
+		}
+	}
+	return yamlenc.Marshal(vars)
+}
+
+// SetVariables sets variables in a BOSH vars store, which may be empty, replacing any existing
+// values. Other variables are kept.
+func SetVariables(b []byte, values map[string]interface{}) ([]byte, error) {
+	var vars map[string]interface{}
+	if err := yamlenc.Unmarshal(b, &vars); err != nil {
+		return nil, err
+	}
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	for name, value := range values {
+		vars[name] = value
+	}
+	return yamlenc.Marshal(vars)
+}
+
+// Diff compares two YAML documents and returns one line per path that was
+// added (+), removed (-) or changed (~). Credentials are redacted.
+func Diff(before, after []byte) ([]string, error) {
+	var b, a interface{}
+	if err := yamlenc.Unmarshal(before, &b); err != nil {
+		return nil, err
+	}
+	if err := yamlenc.Unmarshal(after, &a); err != nil {
+		return nil, err
+	}
+
+	beforePaths := map[string]interface{}{}
+	flatten("", b, beforePaths)
+	afterPaths := map[string]interface{}{}
+	flatten("", a, afterPaths)
+
+	var changes []string
+	for path, oldValue := range beforePaths {
+		newValue, ok := afterPaths[path]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("- %s: %s", path, display(path, oldValue)))
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			if isSensitive(path, oldValue) || isSensitive(path, newValue) {
+				changes = append(changes, fmt.Sprintf("~ %s: <redacted>", path))
+				continue
+			}
+			changes = append(changes, fmt.Sprintf("~ %s: %v -> %v", path, oldValue, newValue))
+		}
+	}
+	for path, newValue := range afterPaths {
+		if _, ok := beforePaths[path]; !ok {
+			changes = append(changes, fmt.Sprintf("+ %s: %s", path, display(path, newValue)))
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i][2:] < changes[j][2:]
+	})
+	return changes, nil
+}
+
+// flatten records every leaf of v against its go-patch style path, naming
+// array elements by their `name` key where they have one
+func flatten(path string, v interface{}, paths map[string]interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 {
+			paths[path] = value
+		}
+		for k, child := range value {
+			flatten(path+"/"+k, child, paths)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			paths[path] = value
+		}
+		for i, child := range value {
+			segment := strconv.Itoa(i)
+			if m, ok := child.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok {
+					segment = "name=" + name
+				}
+			}
+			flatten(path+"/"+segment, child, paths)
+		}
+	default:
+		paths[path] = value
+	}
+}
+
+func display(path string, v interface{}) string {
+	if isSensitive(path, v) {
+		return "<redacted>"
+	}
+	return fmt.Sprint(v)
+}
+
+func isSensitive(path string, v interface{}) bool {
+	key := strings.ToLower(path[strings.LastIndex(path, "/")+1:])
+	for _, word := range []string{"password", "secret", "private_key", "certificate"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	s, ok := v.(string)
+	return ok && strings.Contains(s, "-----BEGIN")
+}
@@ -148,3 +148,136 @@ a:
 		})
 	}
 }
+
+func TestRemoveCertificates(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "certificates are removed",
+			b: `admin_password: secret
+jumpbox_ssh:
+  private_key: ssh-key
+  public_key: ssh-rsa
+nats_ca:
+  ca: ca-cert
+  certificate: ca-cert
+  private_key: ca-key
+nats_server_tls:
+  ca: ca-cert
+  certificate: server-cert
+  private_key: server-key
+`,
+			want: `admin_password: secret
+jumpbox_ssh:
+  private_key: ssh-key
+  public_key: ssh-rsa
+`,
+		},
+		{
+			name:    "invalid YAML",
+			b:       "[",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := yaml.RemoveCertificates([]byte(tt.b))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RemoveCertificates() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("RemoveCertificates() = '%s', want '%s'", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetVariables(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       string
+		values  map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "variables are added and replaced",
+			b:      "admin_password: secret\noidc_client_secret: old\n",
+			values: map[string]interface{}{"oidc_client_secret": "new", "ldap_bind_password": "bind"},
+			want:   "admin_password: secret\nldap_bind_password: bind\noidc_client_secret: new\n",
+		},
+		{
+			name:   "empty vars store",
+			b:      "",
+			values: map[string]interface{}{"gitlab_client_secret": "secret"},
+			want:   "gitlab_client_secret: secret\n",
+		},
+		{
+			name:    "invalid YAML",
+			b:       "[",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := yaml.SetVariables([]byte(tt.b), tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetVariables() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("SetVariables() = '%s', want '%s'", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []string
+	}{
+		{
+			name:   "identical documents",
+			before: "a: {b: 1}",
+			after:  "a: {b: 1}",
+			want:   nil,
+		},
+		{
+			name:   "changed, added and removed values",
+			before: "a: {b: 1, c: x}",
+			after:  "a: {b: 2, d: z}",
+			want:   []string{"~ /a/b: 1 -> 2", "- /a/c: x", "+ /a/d: z"},
+		},
+		{
+			name:   "array elements are identified by name",
+			before: "instance_groups: [{name: web, instances: 1}, {name: worker, instances: 1}]",
+			after:  "instance_groups: [{name: worker, instances: 2}]",
+			want:   []string{"- /instance_groups/name=web/instances: 1", "- /instance_groups/name=web/name: web", "~ /instance_groups/name=worker/instances: 1 -> 2"},
+		},
+		{
+			name:   "credentials are redacted",
+			before: "admin_password: foo\nca: \"-----BEGIN CERTIFICATE-----\"",
+			after:  "admin_password: bar\nca: \"-----BEGIN CERTIFICATE----- new\"",
+			want:   []string{"~ /admin_password: <redacted>", "~ /ca: <redacted>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := yaml.Diff([]byte(tt.before), []byte(tt.after))
+			if err != nil {
+				t.Fatalf("Diff() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}